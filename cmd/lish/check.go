@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Lingbou/Lish/internal/lint"
+	"github.com/Lingbou/Lish/internal/shell"
+	flag "github.com/spf13/pflag"
+)
+
+const checkUsage = `用法:
+  lish check [选项] <脚本>...
+
+只解析而不执行脚本，报告语法错误、未定义的变量、未加引号的展开、
+不可达代码、循环外的 break/continue、未知命令和覆盖已有命令的函数。
+
+选项:
+      --json              以 JSON 输出
+  -S, --severity <级别>   只报告不低于该级别的问题（error、warning，默认 warning）
+  -h, --help              显示此帮助信息
+
+退出码:
+  0  没有发现问题
+  1  发现问题
+  2  用法错误或无法读取文件
+`
+
+// runCheck 执行 lish check 子命令，返回进程退出码
+func runCheck(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, checkUsage) }
+
+	jsonOutput := flags.Bool("json", false, "以 JSON 输出")
+	severity := flags.StringP("severity", "S", "warning", "最低严重程度")
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	min, err := lint.ParseSeverity(*severity)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lish check: %v\n", err)
+		return 2
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		fmt.Fprint(os.Stderr, checkUsage)
+		return 2
+	}
+
+	// 不加载配置文件，只用于判断命令是否存在
+	sh, err := shell.NewShell(shell.Options{NoRC: true})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "初始化 Shell 失败: %v\n", err)
+		return 2
+	}
+	if err := sh.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "初始化 Shell 失败: %v\n", err)
+		return 2
+	}
+	env := sh.LintEnv()
+
+	var diags []lint.Diagnostic
+	status := 0
+	for _, file := range files {
+		result, err := lint.CheckFile(file, env)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "lish check: %v\n", err)
+			status = 2
+			continue
+		}
+		diags = append(diags, lint.Filter(result, min)...)
+	}
+
+	if *jsonOutput {
+		if err := lint.WriteJSON(os.Stdout, diags); err != nil {
+			fmt.Fprintf(os.Stderr, "lish check: %v\n", err)
+			return 2
+		}
+	} else {
+		lint.WriteText(os.Stdout, diags)
+	}
+
+	if status == 0 && len(diags) > 0 {
+		status = 1
+	}
+	return status
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/textdiff"
+	flag "github.com/spf13/pflag"
+)
+
+const fmtUsage = `用法:
+  lish fmt [选项] [脚本...]
+
+把 .lish 脚本改写为统一的格式：块内缩进四个空格，then/do 与 if/for/while
+写在同一行，函数写成 name() { ... }，保留注释和单个空行。
+不指定文件时从标准输入读取，格式化结果写到标准输出。
+
+选项:
+  -d, --diff    显示格式化前后的差异，不修改文件
+      --check   只检查格式，列出需要格式化的文件，不修改文件
+  -h, --help    显示此帮助信息
+
+退出码:
+  0  成功（--check 时表示格式都已正确）
+  1  --check 或 --diff 发现需要格式化的文件
+  2  用法错误、语法错误或无法读写文件
+`
+
+// fmtOptions lish fmt 的选项
+type fmtOptions struct {
+	diff  bool
+	check bool
+}
+
+// runFmt 执行 lish fmt 子命令，返回进程退出码
+func runFmt(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, fmtUsage) }
+
+	var opts fmtOptions
+	flags.BoolVarP(&opts.diff, "diff", "d", false, "显示差异")
+	flags.BoolVar(&opts.check, "check", false, "只检查格式")
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		return formatStdin(opts)
+	}
+
+	status := 0
+	for _, file := range files {
+		result, err := formatFile(file, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "lish fmt: %v\n", err)
+			status = 2
+			continue
+		}
+		if result > status {
+			status = result
+		}
+	}
+	return status
+}
+
+// formatStdin 格式化标准输入
+func formatStdin(opts fmtOptions) int {
+	content, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lish fmt: 读取输入失败: %v\n", err)
+		return 2
+	}
+
+	formatted, err := script.Format(string(content))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lish fmt: <标准输入>: %v\n", err)
+		return 2
+	}
+
+	if !opts.diff && !opts.check {
+		fmt.Print(formatted)
+		return 0
+	}
+	return report("<标准输入>", string(content), formatted, opts)
+}
+
+// formatFile 格式化一个文件，返回退出码
+func formatFile(path string, opts fmtOptions) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 2, err
+	}
+
+	formatted, err := script.Format(string(content))
+	if err != nil {
+		return 2, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if opts.diff || opts.check {
+		return report(path, string(content), formatted, opts), nil
+	}
+
+	if formatted == string(content) {
+		return 0, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 2, err
+	}
+	if err := os.WriteFile(path, []byte(formatted), info.Mode().Perm()); err != nil {
+		return 2, err
+	}
+	return 0, nil
+}
+
+// report 输出 --diff 和 --check 的结果，格式不正确时返回 1
+func report(name, original, formatted string, opts fmtOptions) int {
+	if original == formatted {
+		return 0
+	}
+
+	if opts.check {
+		fmt.Println(name)
+	}
+	if opts.diff {
+		textdiff.Unified(os.Stdout, name+".orig", name,
+			textdiff.SplitLines(original), textdiff.SplitLines(formatted), 3)
+	}
+	return 1
+}
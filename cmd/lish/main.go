@@ -1,29 +1,124 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/Lingbou/Lish/internal/shell"
+	"github.com/chzyer/readline"
+	flag "github.com/spf13/pflag"
 )
 
+const usage = `用法:
+  lish [选项]                       启动交互式 Shell
+  lish [选项] <脚本> [参数...]       执行脚本文件
+  lish [选项] -c <命令> [名称 [参数...]]
+  command | lish                    从标准输入读取命令
+  lish check [选项] <脚本>...        检查脚本而不执行（lish check --help 查看选项）
+  lish fmt [选项] [脚本...]          格式化脚本（lish fmt --help 查看选项）
+
+  check 和 fmt 总是作为子命令，执行同名的脚本文件要写成 lish ./check。
+
+选项:
+  -c, --command <命令>   执行命令字符串后退出
+  -i, --interactive      强制交互模式
+  -l, --login            作为登录 shell 启动（执行 ~/.lish/profile.lish）
+      --norc             不加载 ~/.lishrc 和 ~/.lish/init.lish
+      --noprofile        登录 shell 不执行 ~/.lish/profile.lish
+      --rcfile <文件>    使用指定的配置文件代替 ~/.lishrc
+  -h, --help             显示此帮助信息
+`
+
 func main() {
+	os.Exit(run())
+}
+
+// run 解析命令行参数并按对应模式运行，返回进程退出码
+func run() int {
+	flags := flag.NewFlagSet("lish", flag.ContinueOnError)
+	flags.SetInterspersed(false) // 脚本名之后的参数属于脚本
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+
+	command := flags.StringP("command", "c", "", "执行命令字符串后退出")
+	interactive := flags.BoolP("interactive", "i", false, "强制交互模式")
+	login := flags.BoolP("login", "l", false, "作为登录 shell 启动")
+	noRC := flags.Bool("norc", false, "不加载 ~/.lishrc 和 ~/.lish/init.lish")
+	noProfile := flags.Bool("noprofile", false, "登录 shell 不执行 ~/.lish/profile.lish")
+	rcFile := flags.String("rcfile", "", "使用指定的配置文件代替 ~/.lishrc")
+
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	hasCommand := flags.Changed("command")
+	args := flags.Args()
+
+	// lish check 和 lish fmt 只处理脚本文件，不启动 shell。子命令优先于同名的
+	// 脚本文件，这样 lish check 的含义不随当前目录变化，同名脚本用 ./check 执行
+	if !hasCommand && len(args) > 0 {
+		switch args[0] {
+		case "check":
+			return runCheck(args[1:])
+		case "fmt":
+			return runFmt(args[1:])
+		}
+	}
+
+	opts := shell.Options{
+		Interactive: *interactive || (!hasCommand && len(args) == 0 && readline.IsTerminal(int(os.Stdin.Fd()))),
+		Login:       *login,
+		NoRC:        *noRC,
+		NoProfile:   *noProfile,
+		RCFile:      *rcFile,
+	}
+
 	// Create Shell
-	sh, err := shell.NewShell()
+	sh, err := shell.NewShell(opts)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "初始化 Shell 失败: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
 
 	// Init Shell
 	if err := sh.Init(); err != nil {
 		fmt.Fprintf(os.Stderr, "初始化 Shell 失败: %v\n", err)
-		os.Exit(1)
+		return 1
+	}
+
+	ctx := context.Background()
+
+	// 执行 profile.lish / init.lish，其中的 exit 直接结束 lish
+	if !sh.SourceStartupScripts(ctx) {
+		return sh.ExitCode()
+	}
+
+	switch {
+	case hasCommand:
+		// lish -c 'command' [name [args...]]
+		name := "lish"
+		if len(args) > 0 {
+			name, args = args[0], args[1:]
+		}
+		return sh.RunString(ctx, *command, name, args)
+
+	case len(args) > 0:
+		// lish script.lish [args...]，也用于 #!/usr/bin/env lish
+		return sh.RunFile(ctx, args[0], args[1:])
+
+	case !opts.Interactive:
+		// 标准输入不是终端，按脚本读取
+		return sh.RunReader(ctx, os.Stdin)
 	}
 
 	// Run Shell
 	if err := sh.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "运行 Shell 失败: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
+
+	return sh.ExitCode()
 }
@@ -3,11 +3,9 @@ module github.com/Lingbou/Lish
 go 1.25.4
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/chzyer/readline v1.5.1
 	github.com/spf13/pflag v1.0.10
 )
 
-require (
-	github.com/BurntSushi/toml v1.5.0 // indirect
-	golang.org/x/sys v0.38.0 // indirect
-)
+require golang.org/x/sys v0.38.0 // indirect
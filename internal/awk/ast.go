@@ -0,0 +1,233 @@
+package awk
+
+// Program 解析后的 awk 程序，由 Parse 创建，可以多次运行
+type Program struct {
+	begin     [][]stmt
+	rules     []*rule
+	end       [][]stmt
+	functions []*funcDef
+	globals   []string // 全局变量名，下标即变量的位置
+}
+
+// rule 一条 pattern { action } 规则
+type rule struct {
+	pattern expr   // 为 nil 时匹配所有记录
+	endPat  expr   // 范围模式 pattern, endPat 的结束条件
+	action  []stmt // 为 nil 时输出 $0
+}
+
+// funcDef 用户定义的函数
+type funcDef struct {
+	name    string
+	params  []string
+	isArray []bool // 参数是否作为数组使用，数组按引用传递
+	body    []stmt
+}
+
+// ---- 表达式 ----
+
+type expr interface{}
+
+// numberExpr 数字常量
+type numberExpr struct {
+	value float64
+}
+
+// stringExpr 字符串常量
+type stringExpr struct {
+	value string
+}
+
+// regexExpr 正则表达式常量，单独使用时表示 $0 ~ /re/
+type regexExpr struct {
+	source string
+}
+
+// scope 变量的作用域
+type scope int
+
+const (
+	scopeGlobal  scope = iota
+	scopeLocal         // 函数参数
+	scopeSpecial       // NF、FS 等需要特殊处理的内置变量
+)
+
+// varExpr 变量引用
+type varExpr struct {
+	scope scope
+	index int
+	name  string
+}
+
+// indexExpr 数组元素 a[i] 或 a[i, j]
+type indexExpr struct {
+	array *varExpr
+	index []expr
+}
+
+// fieldExpr 字段 $n
+type fieldExpr struct {
+	index expr
+}
+
+// assignExpr 赋值，op 为 tokenAssign 时是普通赋值，否则是 += 等复合赋值的运算符
+type assignExpr struct {
+	target expr
+	op     tokenType
+	value  expr
+}
+
+// incrExpr ++ 和 --
+type incrExpr struct {
+	target expr
+	op     tokenType
+	prefix bool
+}
+
+// condExpr 条件表达式 a ? b : c
+type condExpr struct {
+	cond, yes, no expr
+}
+
+// binaryExpr 算术和比较运算
+type binaryExpr struct {
+	op          tokenType
+	left, right expr
+}
+
+// concatExpr 字符串连接
+type concatExpr struct {
+	left, right expr
+}
+
+// logicalExpr && 和 ||
+type logicalExpr struct {
+	op          tokenType
+	left, right expr
+}
+
+// matchExpr ~ 和 !~
+type matchExpr struct {
+	negate bool
+	left   expr
+	regex  expr
+}
+
+// unaryExpr 一元运算 - + !
+type unaryExpr struct {
+	op      tokenType
+	operand expr
+}
+
+// inExpr (i, j) in array
+type inExpr struct {
+	index []expr
+	array *varExpr
+}
+
+// groupExpr 括号中用逗号分隔的表达式列表，只出现在 print 的参数中
+type groupExpr struct {
+	exprs []expr
+}
+
+// builtinExpr 内置函数调用
+type builtinExpr struct {
+	name string
+	args []expr
+}
+
+// callExpr 用户函数调用
+type callExpr struct {
+	name string
+	fn   *funcDef // 解析结束后填入
+	args []expr
+	line int
+}
+
+// getlineKind getline 的输入来源
+type getlineKind int
+
+const (
+	getlineMain getlineKind = iota // getline [var]
+	getlineFile                    // getline [var] < file
+	getlineCmd                     // cmd | getline [var]
+)
+
+// getlineExpr getline 表达式
+type getlineExpr struct {
+	kind   getlineKind
+	target expr // 为 nil 时读入 $0
+	source expr // 文件名或命令
+}
+
+// ---- 语句 ----
+
+type stmt interface{}
+
+// printStmt print 和 printf，redirect 为 0 时输出到标准输出
+type printStmt struct {
+	printf   bool
+	args     []expr
+	redirect tokenType // tokenGt、tokenAppend 或 tokenPipe
+	dest     expr
+}
+
+// exprStmt 表达式语句
+type exprStmt struct {
+	expr expr
+}
+
+type ifStmt struct {
+	cond     expr
+	then     []stmt
+	elseBody []stmt
+}
+
+type whileStmt struct {
+	cond expr
+	body []stmt
+}
+
+type doStmt struct {
+	body []stmt
+	cond expr
+}
+
+type forStmt struct {
+	init stmt // 可以为 nil
+	cond expr // 可以为 nil
+	post stmt // 可以为 nil
+	body []stmt
+}
+
+type forInStmt struct {
+	variable expr
+	array    *varExpr
+	body     []stmt
+}
+
+type blockStmt struct {
+	body []stmt
+}
+
+type nextStmt struct{}
+
+type nextfileStmt struct{}
+
+type breakStmt struct{}
+
+type continueStmt struct{}
+
+type exitStmt struct {
+	status expr // 可以为 nil
+}
+
+type returnStmt struct {
+	value expr // 可以为 nil
+}
+
+// deleteStmt 删除数组元素，index 为 nil 时清空整个数组
+type deleteStmt struct {
+	array *varExpr
+	index []expr
+}
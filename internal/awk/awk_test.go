@@ -0,0 +1,121 @@
+package awk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// run 解析并运行 awk 程序，返回标准输出
+func run(t *testing.T, src, input string, vars ...string) (string, error) {
+	t.Helper()
+	prog, err := Parse(src)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", src, err)
+	}
+	var out, errOut bytes.Buffer
+	err = prog.Run(context.Background(), &Config{
+		Stdin:  strings.NewReader(input),
+		Stdout: &out,
+		Stderr: &errOut,
+		Vars:   vars,
+	})
+	return out.String(), err
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		input string
+		vars  []string
+		want  string
+	}{
+		{"print fields", `{ print $2, $1 }`, "a b\nc d\n", nil, "b a\nd c\n"},
+		{"NR and NF", `{ print NR ": " NF }`, "a b c\n\nx\n", nil, "1: 3\n2: 0\n3: 1\n"},
+		{"pattern", `/^b/`, "apple\nbanana\nblueberry\n", nil, "banana\nblueberry\n"},
+		{"range", `/start/,/end/`, "a\nstart\nb\nend\nc\n", nil, "start\nb\nend\n"},
+		{"sum in END", `{ s += $1 } END { print s }`, "1\n2\n3.5\n", nil, "6.5\n"},
+		{"field separator", `BEGIN { FS = ":" } { print $3 }`, "a:b:c\n", nil, "c\n"},
+		{"assign field rebuilds record", `BEGIN { OFS = "-" } { $2 = "X"; print }`, "a b c\n", nil, "a-X-c\n"},
+		{"NF grows record", `{ $5 = "e"; print; print NF }`, "a b\n", nil, "a b   e\n5\n"},
+		{"associative arrays", `{ n[$1]++ } END { print n["a"], n["b"], length(n) }`, "a\nb\na\n", nil, "2 1 2\n"},
+		{"in and delete", `BEGIN { a["x"]; delete a["x"]; print ("x" in a) }`, "", nil, "0\n"},
+		{"split", `BEGIN { n = split("a,b,c", p, ","); print n, p[1] p[3] }`, "", nil, "3 ac\n"},
+		{"sub and gsub", `{ gsub(/o/, "0"); sub(/l/, "L"); print }`, "hello world\n", nil, "heLl0 w0rld\n"},
+		{"gsub ampersand", `{ gsub(/[0-9]+/, "<&>"); print }`, "a1b22\n", nil, "a<1>b<22>\n"},
+		{"substr and index", `BEGIN { print substr("hello", 2, 3), index("hello", "ll") }`, "", nil, "ell 3\n"},
+		{"match", `BEGIN { print match("foobar", /ob+/), RSTART, RLENGTH }`, "", nil, "3 3 2\n"},
+		{"printf", `BEGIN { printf "%5.2f|%-4s|%03d|%x\n", 3.14159, "ab", 7, 255 }`, "", nil, " 3.14|ab  |007|ff\n"},
+		{"number output format", `BEGIN { print 0.1 + 0.2, 1e6, 2^31 }`, "", nil, "0.3 1000000 2147483648\n"},
+		{"string comparison", `BEGIN { print ("10" < "9"), (10 < 9) }`, "", nil, "1 0\n"},
+		{"numeric strings from input", `{ print ($1 < $2) }`, "10 9\n", nil, "0\n"},
+		{"user function and recursion", `function f(n) { return n <= 1 ? 1 : n * f(n - 1) } BEGIN { print f(10) }`, "", nil, "3628800\n"},
+		{"locals are arrays by reference", `function fill(a) { a[1] = "x" } BEGIN { fill(arr); print arr[1] }`, "", nil, "x\n"},
+		{"next", `NR == 2 { next } { print }`, "a\nb\nc\n", nil, "a\nc\n"},
+		{"while and break", `BEGIN { while (1) { if (++i > 3) break }; print i }`, "", nil, "4\n"},
+		{"for loop with in", `BEGIN { a[1]; a[2]; a[3]; for (k = 1; k <= 3; k++) if (k in a) s = s k; print s }`, "", nil, "123\n"},
+		{"getline var", `NR == 1 { getline line; print $0 "+" line }`, "a\nb\n", nil, "a+b\n"},
+		{"-v variable", `BEGIN { print x * 2 }`, "", []string{"x=21"}, "42\n"},
+		{"paragraph mode", `BEGIN { RS = "" } { print NR ": " $1 }`, "a b\nc\n\n\nd\n", nil, "1: a\n2: d\n"},
+		{"regex RS", `BEGIN { RS = "[,;]" } { print }`, "a,b;c", nil, "a\nb\nc\n"},
+		{"toupper and tolower", `BEGIN { print toupper("abc") tolower("DEF") }`, "", nil, "ABCdef\n"},
+		{"uninitialized", `BEGIN { print length(x), x + 0, "[" x "]" }`, "", nil, "0 0 []\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(t, tt.src, tt.input, tt.vars...)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExit(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+		code int
+	}{
+		{"exit skips remaining input", `{ print; exit }`, "a\n", 0},
+		{"exit runs END", `{ exit 3 } END { print "end" }`, "end\n", 3},
+		{"exit in END keeps code", `{ exit 4 } END { exit }`, "", 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := run(t, tt.src, "a\nb\n")
+			code := 0
+			var exitErr *ExitError
+			if errors.As(err, &exitErr) {
+				code = exitErr.Code
+			} else if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if got != tt.want || code != tt.code {
+				t.Errorf("got %q (exit %d), want %q (exit %d)", got, code, tt.want, tt.code)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for _, src := range []string{
+		`{ print `,
+		`BEGIN { x = }`,
+		`function f( { }`,
+		`{ getline < }`,
+	} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", src)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package awk
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// builtins 内置函数及其参数个数范围
+var builtins = map[string]struct{ min, max int }{
+	"length":  {0, 1},
+	"substr":  {2, 3},
+	"index":   {2, 2},
+	"split":   {2, 3},
+	"sub":     {2, 3},
+	"gsub":    {2, 3},
+	"match":   {2, 3},
+	"sprintf": {1, math.MaxInt},
+	"sin":     {1, 1},
+	"cos":     {1, 1},
+	"atan2":   {2, 2},
+	"exp":     {1, 1},
+	"log":     {1, 1},
+	"sqrt":    {1, 1},
+	"int":     {1, 1},
+	"rand":    {0, 0},
+	"srand":   {0, 1},
+	"tolower": {1, 1},
+	"toupper": {1, 1},
+	"system":  {1, 1},
+	"close":   {1, 1},
+	"fflush":  {0, 1},
+}
+
+// builtin 调用内置函数
+func (in *interp) builtin(e *builtinExpr) (value, error) {
+	switch e.name {
+	case "length":
+		if len(e.args) == 0 {
+			return num(float64(utf8.RuneCountInString(in.record))), nil
+		}
+		// length(arr) 返回数组元素个数
+		if v, ok := e.args[0].(*varExpr); ok && v.scope != scopeSpecial {
+			if c := in.cellOf(v); c.arr != nil {
+				return num(float64(len(c.arr))), nil
+			}
+		}
+		s, err := in.evalString(e.args[0])
+		return num(float64(utf8.RuneCountInString(s))), err
+	case "split":
+		return in.split(e)
+	case "sub", "gsub":
+		return in.substitute(e)
+	case "match":
+		return in.match(e)
+	case "close":
+		name, err := in.evalString(e.args[0])
+		if err != nil {
+			return value{}, err
+		}
+		status, err := in.closeStream(name)
+		return num(float64(status)), err
+	case "fflush":
+		if len(e.args) == 0 {
+			return num(0), in.flushAll()
+		}
+		name, err := in.evalString(e.args[0])
+		if err != nil {
+			return value{}, err
+		}
+		out, ok := in.outputs[name]
+		if !ok {
+			return num(-1), nil
+		}
+		if out.command {
+			return num(0), nil
+		}
+		return num(0), out.writer.Flush()
+	case "srand":
+		prev := in.seed
+		in.seed = float64(time.Now().Unix())
+		if len(e.args) == 1 {
+			v, err := in.eval(e.args[0])
+			if err != nil {
+				return value{}, err
+			}
+			in.seed = v.num()
+		}
+		in.random = rand.New(rand.NewSource(int64(in.seed)))
+		return num(prev), nil
+	case "rand":
+		return num(in.random.Float64()), nil
+	}
+
+	args, err := in.evalAll(e.args)
+	if err != nil {
+		return value{}, err
+	}
+	switch e.name {
+	case "substr":
+		return str(substr(args, in.convfmt)), nil
+	case "index":
+		s, t := args[0].str(in.convfmt), args[1].str(in.convfmt)
+		i := strings.Index(s, t)
+		if i < 0 {
+			return num(0), nil
+		}
+		return num(float64(utf8.RuneCountInString(s[:i]) + 1)), nil
+	case "sprintf":
+		return str(in.sprintf(args[0].str(in.convfmt), args[1:])), nil
+	case "sin":
+		return num(math.Sin(args[0].num())), nil
+	case "cos":
+		return num(math.Cos(args[0].num())), nil
+	case "atan2":
+		return num(math.Atan2(args[0].num(), args[1].num())), nil
+	case "exp":
+		return num(math.Exp(args[0].num())), nil
+	case "log":
+		return num(math.Log(args[0].num())), nil
+	case "sqrt":
+		return num(math.Sqrt(args[0].num())), nil
+	case "int":
+		return num(math.Trunc(args[0].num())), nil
+	case "tolower":
+		return str(strings.ToLower(args[0].str(in.convfmt))), nil
+	case "toupper":
+		return str(strings.ToUpper(args[0].str(in.convfmt))), nil
+	case "system":
+		if err := in.flushAll(); err != nil {
+			return value{}, err
+		}
+		status, err := in.runCommand(args[0].str(in.convfmt), in.cfg.Stdin, in.stdout)
+		if err != nil {
+			return value{}, err
+		}
+		return num(float64(status)), in.stdout.Flush()
+	}
+	return value{}, fmt.Errorf("未知的内置函数 %s", e.name)
+}
+
+// substr 返回从第 m 个字符开始的 n 个字符，下标从 1 开始并按 POSIX 规则取整
+func substr(args []value, convfmt string) string {
+	runes := []rune(args[0].str(convfmt))
+	start := math.RoundToEven(args[1].num())
+	end := math.Inf(1)
+	if len(args) == 3 {
+		n := args[2].num()
+		if math.IsNaN(n) {
+			return ""
+		}
+		end = start + math.RoundToEven(n)
+	}
+	if math.IsNaN(start) {
+		return ""
+	}
+	start = math.Max(start, 1)
+	end = math.Min(end, float64(len(runes)+1))
+	if end <= start {
+		return ""
+	}
+	return string(runes[int(start)-1 : int(end)-1])
+}
+
+// split 把字符串拆分到数组中，返回元素个数
+func (in *interp) split(e *builtinExpr) (value, error) {
+	s, err := in.evalString(e.args[0])
+	if err != nil {
+		return value{}, err
+	}
+	arr, err := in.array(e.args[1].(*varExpr))
+	if err != nil {
+		return value{}, err
+	}
+
+	var parts []string
+	if len(e.args) == 3 {
+		if re, ok := e.args[2].(*regexExpr); ok {
+			compiled, err := in.regex(re.source)
+			if err != nil {
+				return value{}, err
+			}
+			if s != "" {
+				parts = compiled.Split(s, -1)
+			}
+		} else {
+			fs, err := in.evalString(e.args[2])
+			if err != nil {
+				return value{}, err
+			}
+			parts = in.splitFields(s, fs, false)
+		}
+	} else {
+		parts = in.splitFields(s, in.fs, false)
+	}
+
+	for key := range arr {
+		delete(arr, key)
+	}
+	for i, part := range parts {
+		arr[strconv.Itoa(i+1)] = strnum(part)
+	}
+	return num(float64(len(parts))), nil
+}
+
+// substitute 实现 sub 和 gsub，返回替换的次数
+func (in *interp) substitute(e *builtinExpr) (value, error) {
+	re, err := in.regexOf(e.args[0])
+	if err != nil {
+		return value{}, err
+	}
+	repl, err := in.evalString(e.args[1])
+	if err != nil {
+		return value{}, err
+	}
+	ref, err := in.ref(e.args[2])
+	if err != nil {
+		return value{}, err
+	}
+	target, err := in.get(ref)
+	if err != nil {
+		return value{}, err
+	}
+	s := target.str(in.convfmt)
+
+	global := e.name == "gsub"
+	var sb strings.Builder
+	count, last := 0, 0
+	for _, loc := range re.FindAllStringIndex(s, -1) {
+		sb.WriteString(s[last:loc[0]])
+		expandReplacement(&sb, repl, s[loc[0]:loc[1]])
+		last = loc[1]
+		count++
+		if !global {
+			break
+		}
+	}
+	if count == 0 {
+		return num(0), nil
+	}
+	sb.WriteString(s[last:])
+	return num(float64(count)), in.set(ref, str(sb.String()))
+}
+
+// expandReplacement 写入替换文本，& 表示匹配的内容，\& 表示 & 本身
+func expandReplacement(sb *strings.Builder, repl, matched string) {
+	for i := 0; i < len(repl); i++ {
+		switch {
+		case repl[i] == '\\' && i+1 < len(repl) && (repl[i+1] == '&' || repl[i+1] == '\\'):
+			i++
+			sb.WriteByte(repl[i])
+		case repl[i] == '&':
+			sb.WriteString(matched)
+		default:
+			sb.WriteByte(repl[i])
+		}
+	}
+}
+
+// match 查找正则表达式，设置 RSTART 和 RLENGTH，第 3 个参数保存匹配和子匹配的内容
+func (in *interp) match(e *builtinExpr) (value, error) {
+	s, err := in.evalString(e.args[0])
+	if err != nil {
+		return value{}, err
+	}
+	re, err := in.regexOf(e.args[1])
+	if err != nil {
+		return value{}, err
+	}
+	loc := re.FindStringSubmatchIndex(s)
+
+	if len(e.args) == 3 {
+		arr, err := in.array(e.args[2].(*varExpr))
+		if err != nil {
+			return value{}, err
+		}
+		for key := range arr {
+			delete(arr, key)
+		}
+		for i := 0; loc != nil && i < len(loc)/2; i++ {
+			start, end := loc[2*i], loc[2*i+1]
+			if start < 0 {
+				continue
+			}
+			key := strconv.Itoa(i)
+			arr[key] = strnum(s[start:end])
+			arr[key+in.subsep+"start"] = num(float64(utf8.RuneCountInString(s[:start]) + 1))
+			arr[key+in.subsep+"length"] = num(float64(utf8.RuneCountInString(s[start:end])))
+		}
+	}
+
+	if loc == nil {
+		in.rstart, in.rlength = 0, -1
+		return num(0), nil
+	}
+	in.rstart = utf8.RuneCountInString(s[:loc[0]]) + 1
+	in.rlength = utf8.RuneCountInString(s[loc[0]:loc[1]])
+	return num(float64(in.rstart)), nil
+}
+
+// sprintf 按 C 风格的格式输出，参数不足时使用空值
+func (in *interp) sprintf(format string, args []value) string {
+	var sb strings.Builder
+	next := func() value {
+		if len(args) == 0 {
+			return value{}
+		}
+		v := args[0]
+		args = args[1:]
+		return v
+	}
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			sb.WriteByte(format[i])
+			continue
+		}
+		if i+1 < len(format) && format[i+1] == '%' {
+			sb.WriteByte('%')
+			i++
+			continue
+		}
+
+		// 解析 %[flags][width][.precision]verb
+		start := i
+		spec := []byte{'%'}
+		j := i + 1
+		for j < len(format) && strings.IndexByte("-+ #0", format[j]) >= 0 {
+			spec = append(spec, format[j])
+			j++
+		}
+		for j < len(format) && (isDigit(format[j]) || format[j] == '*') {
+			if format[j] == '*' {
+				spec = strconv.AppendInt(spec, int64(next().num()), 10)
+			} else {
+				spec = append(spec, format[j])
+			}
+			j++
+		}
+		if j < len(format) && format[j] == '.' {
+			spec = append(spec, '.')
+			j++
+			for j < len(format) && (isDigit(format[j]) || format[j] == '*') {
+				if format[j] == '*' {
+					spec = strconv.AppendInt(spec, int64(next().num()), 10)
+				} else {
+					spec = append(spec, format[j])
+				}
+				j++
+			}
+		}
+		// 忽略 C 的长度修饰符
+		for j < len(format) && strings.IndexByte("hlLqjzt", format[j]) >= 0 {
+			j++
+		}
+		if j >= len(format) {
+			sb.WriteString(format[i:])
+			break
+		}
+		i = j
+
+		verb := format[j]
+		switch verb {
+		case 'd', 'i':
+			spec = append(spec, 'd')
+			fmt.Fprintf(&sb, string(spec), toInt(next().num()))
+		case 'o', 'x', 'X':
+			spec = append(spec, verb)
+			fmt.Fprintf(&sb, string(spec), uint64(toInt(next().num())))
+		case 'u':
+			spec = append(spec, 'd')
+			fmt.Fprintf(&sb, string(spec), uint64(toInt(next().num())))
+		case 'e', 'E', 'f', 'F', 'g', 'G':
+			if verb == 'F' {
+				verb = 'f'
+			}
+			spec = append(spec, verb)
+			fmt.Fprintf(&sb, string(spec), next().num())
+		case 'c':
+			// 数字按字符编码输出，字符串取第一个字符
+			v := next()
+			var ch string
+			if v.kind == kindNum || v.kind == kindStrnum {
+				ch = string(rune(int(v.num())))
+			} else if s := v.str(in.convfmt); s != "" {
+				r, _ := utf8.DecodeRuneInString(s)
+				ch = string(r)
+			}
+			spec = append(spec, 's')
+			fmt.Fprintf(&sb, string(spec), ch)
+		case 's':
+			spec = append(spec, 's')
+			fmt.Fprintf(&sb, string(spec), next().str(in.convfmt))
+		default:
+			// 未知的格式原样输出
+			sb.WriteString(format[start : j+1])
+		}
+	}
+	return sb.String()
+}
+
+// toInt 把数字截断为整数，超出范围时取边界值
+func toInt(n float64) int64 {
+	switch {
+	case math.IsNaN(n):
+		return 0
+	case n >= math.MaxInt64:
+		return math.MaxInt64
+	case n <= math.MinInt64:
+		return math.MinInt64
+	}
+	return int64(n)
+}
@@ -0,0 +1,1063 @@
+package awk
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Config 运行 awk 程序的环境
+type Config struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Args 输入文件和 var=value 形式的赋值，即 ARGV[1] 之后的内容
+	Args []string
+	// Vars -v 指定的 var=value，在 BEGIN 之前赋值
+	Vars []string
+	// Environ ENVIRON 数组的内容，每项的格式为 KEY=value
+	Environ []string
+
+	// Exec 执行 shell 命令（system、print | cmd、cmd | getline），返回退出码
+	Exec func(command string, stdin io.Reader, stdout io.Writer) (int, error)
+}
+
+// ExitError exit 语句指定了非 0 的退出码
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("退出码 %d", e.Code)
+}
+
+// ExitCode 返回退出码
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// flowError 控制流，作为错误沿调用链返回
+type flowError int
+
+const (
+	flowNext flowError = iota
+	flowNextFile
+	flowBreak
+	flowContinue
+	flowReturn
+	flowExit
+)
+
+func (f flowError) Error() string {
+	return [...]string{"next", "nextfile", "break", "continue", "return", "exit"}[f]
+}
+
+// maxCallDepth 用户函数的最大递归深度
+const maxCallDepth = 10000
+
+// cell 变量的存储，作为数组使用时 arr 不为 nil
+type cell struct {
+	v   value
+	arr map[string]value
+}
+
+// interp awk 解释器的运行状态
+type interp struct {
+	prog *Program
+	ctx  context.Context
+	cfg  *Config
+
+	globals   []cell
+	globalIdx map[string]int
+	frame     []cell // 当前函数的参数
+	callDepth int
+	retval    value
+	inRules   bool   // 是否在处理输入记录（BEGIN 和 END 中不能用 next）
+	active    []bool // 范围模式是否处于范围内
+	steps     int
+
+	// 当前记录，字段在第一次使用时才拆分
+	record      string
+	fields      []value
+	fieldsValid bool
+	nf          int
+
+	nr, fnr         float64
+	fs, ofs, ors    string
+	rs, filename    string
+	subsep          string
+	convfmt, ofmt   string
+	rstart, rlength int
+
+	stdout   *bufio.Writer
+	outputs  map[string]*output
+	inputs   map[string]*input
+	main     *input // 当前的主输入
+	argIndex int    // 下一个要处理的 ARGV 下标
+	usedArg  bool   // 是否已经有输入文件
+
+	regexCache map[string]*regexp.Regexp
+	random     *rand.Rand
+	seed       float64
+	exitCode   int
+}
+
+// Run 运行程序：执行 BEGIN，逐条处理输入记录，最后执行 END
+func (p *Program) Run(ctx context.Context, cfg *Config) error {
+	in := &interp{
+		prog:       p,
+		ctx:        ctx,
+		cfg:        cfg,
+		globals:    make([]cell, len(p.globals)),
+		globalIdx:  make(map[string]int, len(p.globals)),
+		active:     make([]bool, len(p.rules)),
+		fs:         " ",
+		ofs:        " ",
+		ors:        "\n",
+		rs:         "\n",
+		subsep:     "\x1c",
+		convfmt:    "%.6g",
+		ofmt:       "%.6g",
+		rlength:    -1,
+		stdout:     bufio.NewWriterSize(cfg.Stdout, 64*1024),
+		outputs:    make(map[string]*output),
+		inputs:     make(map[string]*input),
+		argIndex:   1,
+		regexCache: make(map[string]*regexp.Regexp),
+		random:     rand.New(rand.NewSource(0)),
+	}
+	for i, name := range p.globals {
+		in.globalIdx[name] = i
+	}
+
+	err := in.run()
+	if closeErr := in.closeAll(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if in.exitCode != 0 {
+		return &ExitError{Code: in.exitCode}
+	}
+	return nil
+}
+
+// run 依次执行 BEGIN、主循环和 END，exit 会跳过剩余的输入但仍执行 END
+func (in *interp) run() error {
+	if err := in.setup(); err != nil {
+		return err
+	}
+
+	exited := false
+	for _, block := range in.prog.begin {
+		if err := in.execute(block); err != nil {
+			if err != flowExit {
+				return in.checkFlow(err)
+			}
+			exited = true
+			break
+		}
+	}
+
+	if !exited && (len(in.prog.rules) > 0 || len(in.prog.end) > 0) {
+		if err := in.mainLoop(); err != nil {
+			if err != flowExit {
+				return err
+			}
+		}
+	}
+
+	for _, block := range in.prog.end {
+		if err := in.execute(block); err != nil {
+			if err == flowExit {
+				return nil
+			}
+			return in.checkFlow(err)
+		}
+	}
+	return nil
+}
+
+// checkFlow 把 BEGIN 和 END 中不允许的控制流转为错误
+func (in *interp) checkFlow(err error) error {
+	if flow, ok := err.(flowError); ok && (flow == flowNext || flow == flowNextFile) {
+		return fmt.Errorf("BEGIN 和 END 中不能使用 %s", flow)
+	}
+	return err
+}
+
+// setup 设置 ARGV、ENVIRON 和 -v 指定的变量
+func (in *interp) setup() error {
+	argv := map[string]value{"0": str("awk")}
+	for i, arg := range in.cfg.Args {
+		argv[strconv.Itoa(i+1)] = strnum(arg)
+	}
+	in.globals[globalARGV].arr = argv
+	in.globals[globalARGC].v = num(float64(len(in.cfg.Args) + 1))
+
+	environ := make(map[string]value, len(in.cfg.Environ))
+	for _, kv := range in.cfg.Environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			environ[k] = strnum(v)
+		}
+	}
+	in.globals[globalENVIRON].arr = environ
+
+	for _, assign := range in.cfg.Vars {
+		if !isAssignment(assign) {
+			return fmt.Errorf("无效的变量赋值 '%s'，应为 var=value", assign)
+		}
+		if err := in.assignVar(assign); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isAssignment 判断命令行参数是否是 var=value 形式的赋值
+func isAssignment(arg string) bool {
+	name, _, ok := strings.Cut(arg, "=")
+	if !ok || name == "" || !isNameStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isNameChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// assignVar 执行 var=value 形式的赋值，值中的转义序列会被处理
+func (in *interp) assignVar(assign string) error {
+	name, raw, _ := strings.Cut(assign, "=")
+	v := strnum(Unescape(raw))
+	if i, ok := specialVars[name]; ok {
+		return in.setSpecial(i, v)
+	}
+	if i, ok := in.globalIdx[name]; ok {
+		if in.globals[i].arr != nil {
+			return fmt.Errorf("不能把数组 %s 当作标量赋值", name)
+		}
+		in.globals[i].v = v
+	}
+	return nil
+}
+
+// mainLoop 逐条读取输入记录并执行规则
+func (in *interp) mainLoop() error {
+	in.inRules = true
+	defer func() { in.inRules = false }()
+
+	for {
+		record, ok, err := in.nextMainRecord()
+		if err != nil || !ok {
+			return err
+		}
+		if err := in.ctx.Err(); err != nil {
+			return err
+		}
+		in.nr++
+		in.fnr++
+		in.setRecord(record)
+
+		switch err := in.runRules(); err {
+		case nil, flowNext:
+		case flowNextFile:
+			in.closeMain()
+		case flowExit:
+			return err
+		default:
+			if _, ok := err.(flowError); ok {
+				return err
+			}
+			name := in.filename
+			if name == "" {
+				name = "-"
+			}
+			return fmt.Errorf("%s:%d: %w", name, int(in.fnr), err)
+		}
+	}
+}
+
+// runRules 对当前记录执行所有规则
+func (in *interp) runRules() error {
+	for i, r := range in.prog.rules {
+		matched, err := in.matchRule(i, r)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+		if r.action == nil {
+			if _, err := in.stdout.WriteString(in.record + in.ors); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := in.execute(r.action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchRule 判断规则是否匹配当前记录，范围模式包括开始和结束的记录
+func (in *interp) matchRule(i int, r *rule) (bool, error) {
+	if r.pattern == nil {
+		return true, nil
+	}
+	if r.endPat == nil {
+		return in.evalBool(r.pattern)
+	}
+
+	if !in.active[i] {
+		start, err := in.evalBool(r.pattern)
+		if err != nil || !start {
+			return false, err
+		}
+		in.active[i] = true
+	}
+	end, err := in.evalBool(r.endPat)
+	if err != nil {
+		return false, err
+	}
+	if end {
+		in.active[i] = false
+	}
+	return true, nil
+}
+
+// tick 在循环中定期检查是否被取消（Ctrl+C）
+func (in *interp) tick() error {
+	in.steps++
+	if in.steps&1023 == 0 {
+		return in.ctx.Err()
+	}
+	return nil
+}
+
+// ---- 语句 ----
+
+func (in *interp) execute(body []stmt) error {
+	for _, s := range body {
+		if err := in.exec(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (in *interp) exec(s stmt) error {
+	switch s := s.(type) {
+	case *exprStmt:
+		_, err := in.eval(s.expr)
+		return err
+	case *printStmt:
+		return in.print(s)
+	case *ifStmt:
+		cond, err := in.evalBool(s.cond)
+		if err != nil {
+			return err
+		}
+		if cond {
+			return in.execute(s.then)
+		}
+		return in.execute(s.elseBody)
+	case *whileStmt:
+		for {
+			cond, err := in.evalBool(s.cond)
+			if err != nil || !cond {
+				return err
+			}
+			if done, err := in.loopBody(s.body); done || err != nil {
+				return err
+			}
+		}
+	case *doStmt:
+		for {
+			if done, err := in.loopBody(s.body); done || err != nil {
+				return err
+			}
+			cond, err := in.evalBool(s.cond)
+			if err != nil || !cond {
+				return err
+			}
+		}
+	case *forStmt:
+		if s.init != nil {
+			if err := in.exec(s.init); err != nil {
+				return err
+			}
+		}
+		for {
+			if s.cond != nil {
+				cond, err := in.evalBool(s.cond)
+				if err != nil || !cond {
+					return err
+				}
+			}
+			if done, err := in.loopBody(s.body); done || err != nil {
+				return err
+			}
+			if s.post != nil {
+				if err := in.exec(s.post); err != nil {
+					return err
+				}
+			}
+		}
+	case *forInStmt:
+		arr, err := in.array(s.array)
+		if err != nil {
+			return err
+		}
+		for _, key := range sortedKeys(arr) {
+			if _, ok := arr[key]; !ok {
+				continue // 循环中被删除
+			}
+			if err := in.assign(s.variable, strnum(key)); err != nil {
+				return err
+			}
+			if done, err := in.loopBody(s.body); done || err != nil {
+				return err
+			}
+		}
+		return nil
+	case *blockStmt:
+		return in.execute(s.body)
+	case *nextStmt:
+		return flowNext
+	case *nextfileStmt:
+		return flowNextFile
+	case *breakStmt:
+		return flowBreak
+	case *continueStmt:
+		return flowContinue
+	case *exitStmt:
+		if s.status != nil {
+			v, err := in.eval(s.status)
+			if err != nil {
+				return err
+			}
+			in.exitCode = int(v.num())
+		}
+		return flowExit
+	case *returnStmt:
+		in.retval = value{}
+		if s.value != nil {
+			v, err := in.eval(s.value)
+			if err != nil {
+				return err
+			}
+			in.retval = v
+		}
+		return flowReturn
+	case *deleteStmt:
+		arr, err := in.array(s.array)
+		if err != nil {
+			return err
+		}
+		if s.index == nil {
+			for key := range arr {
+				delete(arr, key)
+			}
+			return nil
+		}
+		key, err := in.key(s.index)
+		if err != nil {
+			return err
+		}
+		delete(arr, key)
+		return nil
+	}
+	return fmt.Errorf("未知的语句 %T", s)
+}
+
+// loopBody 执行一次循环体，返回循环是否因 break 结束
+func (in *interp) loopBody(body []stmt) (bool, error) {
+	if err := in.tick(); err != nil {
+		return true, err
+	}
+	switch err := in.execute(body); err {
+	case nil, flowContinue:
+		return false, nil
+	case flowBreak:
+		return true, nil
+	default:
+		return true, err
+	}
+}
+
+// sortedKeys 返回数组的下标：数字下标按数值排在前面，其余按字符串排序
+func sortedKeys(arr map[string]value) []string {
+	keys := make([]string, 0, len(arr))
+	for key := range arr {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, aNum := parseNumeric(keys[i])
+		b, bNum := parseNumeric(keys[j])
+		switch {
+		case aNum && bNum && a != b:
+			return a < b
+		case aNum != bNum:
+			return aNum
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// print 执行 print 和 printf
+func (in *interp) print(s *printStmt) error {
+	var text string
+	switch {
+	case s.printf:
+		values, err := in.evalAll(s.args)
+		if err != nil {
+			return err
+		}
+		text = in.sprintf(values[0].str(in.convfmt), values[1:])
+	case len(s.args) == 0:
+		text = in.record + in.ors
+	default:
+		parts := make([]string, len(s.args))
+		for i, arg := range s.args {
+			v, err := in.eval(arg)
+			if err != nil {
+				return err
+			}
+			parts[i] = in.outputString(v)
+		}
+		text = strings.Join(parts, in.ofs) + in.ors
+	}
+
+	w, err := in.writer(s.redirect, s.dest)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, text)
+	return err
+}
+
+// outputString print 输出值时的字符串形式，非整数的数字使用 OFMT
+func (in *interp) outputString(v value) string {
+	if v.kind == kindNum {
+		return formatNumber(v.n, in.ofmt)
+	}
+	return v.s
+}
+
+// ---- 表达式 ----
+
+func (in *interp) evalAll(exprs []expr) ([]value, error) {
+	values := make([]value, len(exprs))
+	for i, e := range exprs {
+		v, err := in.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+func (in *interp) evalBool(e expr) (bool, error) {
+	v, err := in.eval(e)
+	return v.bool(), err
+}
+
+func (in *interp) evalString(e expr) (string, error) {
+	v, err := in.eval(e)
+	return v.str(in.convfmt), err
+}
+
+func (in *interp) eval(e expr) (value, error) {
+	switch e := e.(type) {
+	case *numberExpr:
+		return num(e.value), nil
+	case *stringExpr:
+		return str(e.value), nil
+	case *regexExpr:
+		re, err := in.regex(e.source)
+		if err != nil {
+			return value{}, err
+		}
+		return boolean(re.MatchString(in.record)), nil
+	case *varExpr:
+		return in.variable(e)
+	case *indexExpr:
+		arr, err := in.array(e.array)
+		if err != nil {
+			return value{}, err
+		}
+		key, err := in.key(e.index)
+		if err != nil {
+			return value{}, err
+		}
+		v, ok := arr[key]
+		if !ok {
+			arr[key] = value{} // 引用不存在的元素会创建它
+		}
+		return v, nil
+	case *fieldExpr:
+		i, err := in.fieldIndex(e)
+		if err != nil {
+			return value{}, err
+		}
+		return in.field(i), nil
+	case *assignExpr:
+		return in.evalAssign(e)
+	case *incrExpr:
+		return in.evalIncr(e)
+	case *condExpr:
+		cond, err := in.evalBool(e.cond)
+		if err != nil {
+			return value{}, err
+		}
+		if cond {
+			return in.eval(e.yes)
+		}
+		return in.eval(e.no)
+	case *binaryExpr:
+		left, err := in.eval(e.left)
+		if err != nil {
+			return value{}, err
+		}
+		right, err := in.eval(e.right)
+		if err != nil {
+			return value{}, err
+		}
+		return in.binary(e.op, left, right)
+	case *concatExpr:
+		left, err := in.evalString(e.left)
+		if err != nil {
+			return value{}, err
+		}
+		right, err := in.evalString(e.right)
+		return str(left + right), err
+	case *logicalExpr:
+		left, err := in.evalBool(e.left)
+		if err != nil {
+			return value{}, err
+		}
+		if e.op == tokenAnd && !left || e.op == tokenOr && left {
+			return boolean(left), nil
+		}
+		right, err := in.evalBool(e.right)
+		return boolean(right), err
+	case *matchExpr:
+		s, err := in.evalString(e.left)
+		if err != nil {
+			return value{}, err
+		}
+		re, err := in.regexOf(e.regex)
+		if err != nil {
+			return value{}, err
+		}
+		return boolean(re.MatchString(s) != e.negate), nil
+	case *unaryExpr:
+		v, err := in.eval(e.operand)
+		if err != nil {
+			return value{}, err
+		}
+		switch e.op {
+		case tokenNot:
+			return boolean(!v.bool()), nil
+		case tokenSub:
+			return num(-v.num()), nil
+		}
+		return num(v.num()), nil
+	case *inExpr:
+		arr, err := in.array(e.array)
+		if err != nil {
+			return value{}, err
+		}
+		key, err := in.key(e.index)
+		if err != nil {
+			return value{}, err
+		}
+		_, ok := arr[key]
+		return boolean(ok), nil
+	case *builtinExpr:
+		return in.builtin(e)
+	case *callExpr:
+		return in.call(e)
+	case *getlineExpr:
+		return in.getline(e)
+	case *groupExpr:
+		return value{}, fmt.Errorf("括号中的列表只能用于 in 或 print")
+	}
+	return value{}, fmt.Errorf("未知的表达式 %T", e)
+}
+
+// binary 计算算术和比较运算
+func (in *interp) binary(op tokenType, left, right value) (value, error) {
+	switch op {
+	case tokenLt, tokenLe, tokenGt, tokenGe, tokenEq, tokenNe:
+		c := compareValues(left, right, in.convfmt)
+		switch op {
+		case tokenLt:
+			return boolean(c < 0), nil
+		case tokenLe:
+			return boolean(c <= 0), nil
+		case tokenGt:
+			return boolean(c > 0), nil
+		case tokenGe:
+			return boolean(c >= 0), nil
+		case tokenEq:
+			return boolean(c == 0), nil
+		}
+		return boolean(c != 0), nil
+	}
+	n, err := arith(op, left.num(), right.num())
+	return num(n), err
+}
+
+// arith 算术运算
+func arith(op tokenType, a, b float64) (float64, error) {
+	switch op {
+	case tokenAdd:
+		return a + b, nil
+	case tokenSub:
+		return a - b, nil
+	case tokenMul:
+		return a * b, nil
+	case tokenDiv:
+		if b == 0 {
+			return 0, fmt.Errorf("除数为 0")
+		}
+		return a / b, nil
+	case tokenMod:
+		if b == 0 {
+			return 0, fmt.Errorf("取余时除数为 0")
+		}
+		return math.Mod(a, b), nil
+	case tokenPow:
+		return math.Pow(a, b), nil
+	}
+	return 0, fmt.Errorf("未知的运算符")
+}
+
+func (in *interp) evalAssign(e *assignExpr) (value, error) {
+	ref, err := in.ref(e.target)
+	if err != nil {
+		return value{}, err
+	}
+	v, err := in.eval(e.value)
+	if err != nil {
+		return value{}, err
+	}
+	if e.op != tokenAssign {
+		cur, err := in.get(ref)
+		if err != nil {
+			return value{}, err
+		}
+		n, err := arith(e.op, cur.num(), v.num())
+		if err != nil {
+			return value{}, err
+		}
+		v = num(n)
+	} else if v.kind == kindNull {
+		v = str("") // 赋值后不再是未初始化的值
+	}
+	return v, in.set(ref, v)
+}
+
+func (in *interp) evalIncr(e *incrExpr) (value, error) {
+	ref, err := in.ref(e.target)
+	if err != nil {
+		return value{}, err
+	}
+	cur, err := in.get(ref)
+	if err != nil {
+		return value{}, err
+	}
+	old := cur.num()
+	n := old + 1
+	if e.op == tokenDecr {
+		n = old - 1
+	}
+	if err := in.set(ref, num(n)); err != nil {
+		return value{}, err
+	}
+	if e.prefix {
+		return num(n), nil
+	}
+	return num(old), nil
+}
+
+// ---- 变量和数组 ----
+
+func (in *interp) cellOf(v *varExpr) *cell {
+	if v.scope == scopeLocal {
+		return &in.frame[v.index]
+	}
+	return &in.globals[v.index]
+}
+
+// variable 读取标量变量
+func (in *interp) variable(v *varExpr) (value, error) {
+	if v.scope == scopeSpecial {
+		return in.getSpecial(v.index), nil
+	}
+	c := in.cellOf(v)
+	if c.arr != nil {
+		return value{}, fmt.Errorf("不能把数组 %s 当作标量使用", v.name)
+	}
+	return c.v, nil
+}
+
+// array 返回数组变量的内容，未初始化的变量变为空数组
+func (in *interp) array(v *varExpr) (map[string]value, error) {
+	c := in.cellOf(v)
+	if c.arr == nil {
+		if c.v.kind != kindNull {
+			return nil, fmt.Errorf("不能把标量 %s 当作数组使用", v.name)
+		}
+		c.arr = make(map[string]value)
+	}
+	return c.arr, nil
+}
+
+// key 计算数组下标，多个下标用 SUBSEP 连接
+func (in *interp) key(index []expr) (string, error) {
+	if len(index) == 1 {
+		return in.evalString(index[0])
+	}
+	parts := make([]string, len(index))
+	for i, e := range index {
+		s, err := in.evalString(e)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, in.subsep), nil
+}
+
+// lref 可以赋值的位置：变量、数组元素或字段
+type lref struct {
+	v     *varExpr
+	arr   map[string]value
+	key   string
+	field int // v 和 arr 都为 nil 时使用
+}
+
+// ref 计算赋值目标的位置，下标只计算一次
+func (in *interp) ref(e expr) (lref, error) {
+	switch e := e.(type) {
+	case *varExpr:
+		return lref{v: e}, nil
+	case *indexExpr:
+		arr, err := in.array(e.array)
+		if err != nil {
+			return lref{}, err
+		}
+		key, err := in.key(e.index)
+		return lref{arr: arr, key: key}, err
+	case *fieldExpr:
+		i, err := in.fieldIndex(e)
+		return lref{field: i}, err
+	}
+	return lref{}, fmt.Errorf("不能给表达式赋值")
+}
+
+func (in *interp) get(r lref) (value, error) {
+	switch {
+	case r.v != nil:
+		return in.variable(r.v)
+	case r.arr != nil:
+		return r.arr[r.key], nil
+	}
+	return in.field(r.field), nil
+}
+
+func (in *interp) set(r lref, v value) error {
+	switch {
+	case r.v != nil:
+		if r.v.scope == scopeSpecial {
+			return in.setSpecial(r.v.index, v)
+		}
+		c := in.cellOf(r.v)
+		if c.arr != nil {
+			return fmt.Errorf("不能把数组 %s 当作标量赋值", r.v.name)
+		}
+		c.v = v
+		return nil
+	case r.arr != nil:
+		r.arr[r.key] = v
+		return nil
+	}
+	return in.setField(r.field, v)
+}
+
+// assign 给变量、数组元素或字段赋值
+func (in *interp) assign(target expr, v value) error {
+	ref, err := in.ref(target)
+	if err != nil {
+		return err
+	}
+	return in.set(ref, v)
+}
+
+// getSpecial 读取内置变量
+func (in *interp) getSpecial(i int) value {
+	switch i {
+	case specialNF:
+		in.splitRecord()
+		return num(float64(in.nf))
+	case specialNR:
+		return num(in.nr)
+	case specialFNR:
+		return num(in.fnr)
+	case specialFS:
+		return str(in.fs)
+	case specialOFS:
+		return str(in.ofs)
+	case specialORS:
+		return str(in.ors)
+	case specialRS:
+		return str(in.rs)
+	case specialFILENAME:
+		return str(in.filename)
+	case specialSUBSEP:
+		return str(in.subsep)
+	case specialRSTART:
+		return num(float64(in.rstart))
+	case specialRLENGTH:
+		return num(float64(in.rlength))
+	case specialCONVFMT:
+		return str(in.convfmt)
+	}
+	return str(in.ofmt)
+}
+
+// setSpecial 给内置变量赋值
+func (in *interp) setSpecial(i int, v value) error {
+	s := v.str(in.convfmt)
+	switch i {
+	case specialNF:
+		return in.setNF(int(v.num()))
+	case specialNR:
+		in.nr = v.num()
+	case specialFNR:
+		in.fnr = v.num()
+	case specialFS:
+		// 新的 FS 从下一条记录开始生效
+		in.splitRecord()
+		in.fs = s
+	case specialOFS:
+		in.ofs = s
+	case specialORS:
+		in.ors = s
+	case specialRS:
+		in.rs = s
+	case specialFILENAME:
+		in.filename = s
+	case specialSUBSEP:
+		in.subsep = s
+	case specialRSTART:
+		in.rstart = int(v.num())
+	case specialRLENGTH:
+		in.rlength = int(v.num())
+	case specialCONVFMT:
+		in.convfmt = s
+	case specialOFMT:
+		in.ofmt = s
+	}
+	return nil
+}
+
+// call 调用用户函数，作为数组使用的参数按引用传递
+func (in *interp) call(e *callExpr) (value, error) {
+	fn := e.fn
+	if in.callDepth >= maxCallDepth {
+		return value{}, fmt.Errorf("函数 %s 递归太深", fn.name)
+	}
+
+	frame := make([]cell, len(fn.params))
+	for i, arg := range e.args {
+		if v, ok := arg.(*varExpr); ok && v.scope != scopeSpecial {
+			c := in.cellOf(v)
+			if c.arr != nil || fn.isArray[i] && c.v.kind == kindNull {
+				if c.arr == nil {
+					c.arr = make(map[string]value)
+				}
+				frame[i].arr = c.arr
+				continue
+			}
+		}
+		if fn.isArray[i] {
+			return value{}, fmt.Errorf("函数 %s 的参数 %s 必须是数组", fn.name, fn.params[i])
+		}
+		v, err := in.eval(arg)
+		if err != nil {
+			return value{}, err
+		}
+		frame[i].v = v
+	}
+
+	saved := in.frame
+	in.frame = frame
+	in.callDepth++
+	err := in.execute(fn.body)
+	in.frame = saved
+	in.callDepth--
+
+	switch err {
+	case nil:
+		return value{}, nil
+	case flowReturn:
+		v := in.retval
+		in.retval = value{}
+		return v, nil
+	}
+	return value{}, err
+}
+
+// regexOf 返回表达式表示的正则表达式：正则常量直接使用，其他值作为动态正则
+func (in *interp) regexOf(e expr) (*regexp.Regexp, error) {
+	if re, ok := e.(*regexExpr); ok {
+		return in.regex(re.source)
+	}
+	source, err := in.evalString(e)
+	if err != nil {
+		return nil, err
+	}
+	return in.regex(source)
+}
+
+// regexCacheSize 缓存的动态正则表达式的最大数量
+const regexCacheSize = 256
+
+// awkRegexEscapes gawk 的单词边界转义对应的 Go 写法
+var awkRegexEscapes = strings.NewReplacer(`\y`, `\b`, `\<`, `\b`, `\>`, `\b`, `\/`, `/`)
+
+// regex 编译正则表达式（POSIX 最左最长匹配），结果会被缓存
+func (in *interp) regex(source string) (*regexp.Regexp, error) {
+	if re, ok := in.regexCache[source]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(awkRegexEscapes.Replace(source))
+	if err != nil {
+		return nil, fmt.Errorf("无效的正则表达式 /%s/: %v", source, err)
+	}
+	re.Longest()
+
+	if len(in.regexCache) >= regexCacheSize {
+		in.regexCache = make(map[string]*regexp.Regexp)
+	}
+	in.regexCache[source] = re
+	return re, nil
+}
@@ -0,0 +1,552 @@
+package awk
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ---- 输入 ----
+
+// input 一个输入来源：主输入文件、getline < file 或 cmd | getline
+type input struct {
+	reader  *bufio.Reader
+	closer  io.Closer // 为 nil 时不需要关闭（标准输入、命令的输出）
+	pending []byte    // RS 为正则表达式时已读入但未使用的内容
+	eof     bool
+	status  int // 命令的退出码，close 时返回
+}
+
+func newInput(r io.Reader, closer io.Closer) *input {
+	return &input{reader: bufio.NewReaderSize(r, 64*1024), closer: closer}
+}
+
+func (r *input) close() error {
+	if r.closer != nil {
+		return r.closer.Close()
+	}
+	return nil
+}
+
+// readRecord 按 RS 读取一条记录
+//   - RS 为单个字符时以该字符分隔（默认是换行）
+//   - RS 为空时按段落读取，记录之间是一个或多个空行
+//   - 其他情况 RS 是正则表达式
+func (in *interp) readRecord(r *input) (string, bool, error) {
+	switch {
+	case in.rs == "":
+		return readParagraph(r)
+	case len(in.rs) == 1:
+		return readDelimited(r, in.rs[0])
+	}
+	re, err := in.regex(in.rs)
+	if err != nil {
+		return "", false, err
+	}
+
+	for {
+		if loc := re.FindIndex(r.pending); loc != nil && loc[1] > loc[0] && (loc[1] < len(r.pending) || r.eof) {
+			record := string(r.pending[:loc[0]])
+			r.pending = r.pending[loc[1]:]
+			return record, true, nil
+		}
+		if r.eof {
+			if len(r.pending) == 0 {
+				return "", false, nil
+			}
+			record := string(r.pending)
+			r.pending = nil
+			return record, true, nil
+		}
+
+		// 匹配可能延伸到还没读入的内容，继续读取
+		buf := make([]byte, 64*1024)
+		n, err := r.reader.Read(buf)
+		r.pending = append(r.pending, buf[:n]...)
+		if err == io.EOF {
+			r.eof = true
+		} else if err != nil {
+			return "", false, err
+		}
+	}
+}
+
+// readDelimited 读取以 delim 结尾的记录，最后一条记录可以没有结束符
+func readDelimited(r *input, delim byte) (string, bool, error) {
+	data, err := r.reader.ReadBytes(delim)
+	if err != nil && err != io.EOF {
+		return "", false, err
+	}
+	if len(data) == 0 && err == io.EOF {
+		return "", false, nil
+	}
+	data = bytes.TrimSuffix(data, []byte{delim})
+	if delim == '\n' {
+		data = bytes.TrimSuffix(data, []byte{'\r'})
+	}
+	return string(data), true, nil
+}
+
+// readParagraph 读取一个段落，忽略开头的空行
+func readParagraph(r *input) (string, bool, error) {
+	var lines []string
+	for {
+		line, ok, err := readDelimited(r, '\n')
+		if err != nil {
+			return "", false, err
+		}
+		if !ok {
+			break
+		}
+		if line == "" {
+			if len(lines) == 0 {
+				continue
+			}
+			break
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return "", false, nil
+	}
+	return strings.Join(lines, "\n"), true, nil
+}
+
+// nextMainRecord 从主输入读取下一条记录，当前文件结束时打开 ARGV 中的下一个文件
+func (in *interp) nextMainRecord() (string, bool, error) {
+	for {
+		if in.main == nil {
+			ok, err := in.openNextFile()
+			if err != nil || !ok {
+				return "", false, err
+			}
+		}
+
+		// 等待输入前先输出已有结果，使交互式使用和管道能及时看到输出
+		if in.main.reader.Buffered() == 0 && len(in.main.pending) == 0 {
+			if err := in.stdout.Flush(); err != nil {
+				return "", false, err
+			}
+		}
+		record, ok, err := in.readRecord(in.main)
+		if err != nil {
+			return "", false, err
+		}
+		if ok {
+			return record, true, nil
+		}
+		in.closeMain()
+	}
+}
+
+// openNextFile 打开 ARGV 中的下一个输入文件，var=value 形式的参数在此时赋值
+func (in *interp) openNextFile() (bool, error) {
+	argv := in.globals[globalARGV].arr
+	for {
+		argc := int(in.globals[globalARGC].v.num())
+		if in.argIndex >= argc {
+			break
+		}
+		arg := ""
+		if argv != nil {
+			arg = argv[strconv.Itoa(in.argIndex)].str(in.convfmt)
+		}
+		in.argIndex++
+
+		switch {
+		case arg == "":
+			continue
+		case isAssignment(arg):
+			if err := in.assignVar(arg); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		in.usedArg = true
+		in.fnr = 0
+		in.filename = arg
+		if arg == "-" || arg == "/dev/stdin" {
+			in.main = newInput(in.cfg.Stdin, nil)
+			return true, nil
+		}
+		file, err := os.Open(arg)
+		if err != nil {
+			return false, fmt.Errorf("无法打开文件 %s: %w", arg, unwrapPathError(err))
+		}
+		in.main = newInput(file, file)
+		return true, nil
+	}
+
+	if in.usedArg {
+		return false, nil
+	}
+	// 没有输入文件时读取标准输入
+	in.usedArg = true
+	in.fnr = 0
+	in.main = newInput(in.cfg.Stdin, nil)
+	return true, nil
+}
+
+// closeMain 关闭当前的主输入文件
+func (in *interp) closeMain() {
+	if in.main != nil {
+		in.main.close()
+		in.main = nil
+	}
+}
+
+// unwrapPathError 去掉 *os.PathError 中重复的文件名
+func unwrapPathError(err error) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
+
+// getline 执行三种形式的 getline，返回 1 表示成功，0 表示输入结束，-1 表示出错
+func (in *interp) getline(e *getlineExpr) (value, error) {
+	var (
+		record string
+		ok     bool
+		err    error
+	)
+	switch e.kind {
+	case getlineMain:
+		record, ok, err = in.nextMainRecord()
+		if err != nil {
+			return num(-1), nil
+		}
+		if ok {
+			in.nr++
+			in.fnr++
+		}
+	case getlineFile, getlineCmd:
+		name, err := in.evalString(e.source)
+		if err != nil {
+			return value{}, err
+		}
+		r, err := in.openInput(name, e.kind == getlineCmd)
+		if err != nil {
+			return num(-1), nil
+		}
+		record, ok, err = in.readRecord(r)
+		if err != nil {
+			return num(-1), nil
+		}
+		if ok && e.kind == getlineCmd {
+			in.nr++
+		}
+	}
+	if !ok {
+		return num(0), nil
+	}
+
+	if e.target == nil {
+		in.setRecord(record)
+		return num(1), nil
+	}
+	return num(1), in.assign(e.target, strnum(record))
+}
+
+// openInput 返回 getline 使用的输入，同一个文件或命令在 close 之前只打开一次
+func (in *interp) openInput(name string, command bool) (*input, error) {
+	if r, ok := in.inputs[name]; ok {
+		return r, nil
+	}
+
+	var r *input
+	switch {
+	case command:
+		// 先输出已有的内容，使命令的输出顺序正确
+		if err := in.flushAll(); err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		status, err := in.runCommand(name, nil, &buf)
+		if err != nil {
+			return nil, err
+		}
+		r = newInput(&buf, nil)
+		r.status = status
+	case name == "-" || name == "/dev/stdin":
+		r = newInput(in.cfg.Stdin, nil)
+	default:
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		r = newInput(file, file)
+	}
+	in.inputs[name] = r
+	return r, nil
+}
+
+// ---- 字段 ----
+
+// setRecord 设置 $0，字段在使用时再拆分
+func (in *interp) setRecord(record string) {
+	in.record = record
+	in.fieldsValid = false
+}
+
+// splitRecord 按 FS 拆分 $0
+func (in *interp) splitRecord() {
+	if in.fieldsValid {
+		return
+	}
+	parts := in.splitFields(in.record, in.fs, in.rs == "")
+	in.fields = in.fields[:0]
+	for _, part := range parts {
+		in.fields = append(in.fields, strnum(part))
+	}
+	in.nf = len(parts)
+	in.fieldsValid = true
+}
+
+// splitFields 按字段分隔符拆分字符串
+//   - " " 按空格、制表符和换行拆分，忽略开头和结尾的空白
+//   - 单个字符（空格除外）按字面拆分
+//   - "" 把每个字符作为一个字段
+//   - 其他情况是正则表达式
+//
+// paragraph 为 true 时（段落模式拆分记录）换行总是字段分隔符
+func (in *interp) splitFields(s, fs string, paragraph bool) []string {
+	if s == "" {
+		return nil
+	}
+	switch {
+	case fs == " ":
+		return strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == '\t' || r == '\n' })
+	case fs == "":
+		return strings.Split(s, "")
+	case len(fs) == 1 && fs != "\\":
+		parts := strings.Split(s, fs)
+		if paragraph && fs != "\n" {
+			var out []string
+			for _, part := range parts {
+				out = append(out, strings.Split(part, "\n")...)
+			}
+			return out
+		}
+		return parts
+	}
+
+	re, err := in.regex(fs)
+	if err != nil {
+		return []string{s}
+	}
+	return re.Split(s, -1)
+}
+
+// fieldIndex 计算字段下标
+func (in *interp) fieldIndex(e *fieldExpr) (int, error) {
+	v, err := in.eval(e.index)
+	if err != nil {
+		return 0, err
+	}
+	i := int(v.num())
+	if i < 0 {
+		return 0, fmt.Errorf("无效的字段下标 $%d", i)
+	}
+	return i, nil
+}
+
+// field 返回 $i
+func (in *interp) field(i int) value {
+	if i == 0 {
+		return strnum(in.record)
+	}
+	in.splitRecord()
+	if i > in.nf {
+		return value{}
+	}
+	return in.fields[i-1]
+}
+
+// setField 给 $i 赋值，赋值给字段时用 OFS 重建 $0
+func (in *interp) setField(i int, v value) error {
+	if i == 0 {
+		in.setRecord(v.str(in.convfmt))
+		return nil
+	}
+	in.splitRecord()
+	for in.nf < i {
+		in.fields = append(in.fields, value{})
+		in.nf++
+	}
+	in.fields[i-1] = str(v.str(in.convfmt))
+	if v.kind == kindStrnum {
+		in.fields[i-1] = v
+	}
+	in.rebuildRecord()
+	return nil
+}
+
+// setNF 修改 NF，截断或补齐字段后重建 $0
+func (in *interp) setNF(n int) error {
+	if n < 0 {
+		return fmt.Errorf("NF 不能为负数")
+	}
+	in.splitRecord()
+	for in.nf < n {
+		in.fields = append(in.fields, value{})
+		in.nf++
+	}
+	in.fields = in.fields[:n]
+	in.nf = n
+	in.rebuildRecord()
+	return nil
+}
+
+func (in *interp) rebuildRecord() {
+	parts := make([]string, in.nf)
+	for i, f := range in.fields[:in.nf] {
+		parts[i] = in.outputString(f)
+	}
+	in.record = strings.Join(parts, in.ofs)
+}
+
+// ---- 输出 ----
+
+// output 一个输出目标：文件或通过管道传给命令
+type output struct {
+	writer  *bufio.Writer
+	file    *os.File     // 文件输出
+	buf     bytes.Buffer // 命令输出，close 时作为命令的标准输入
+	command bool
+}
+
+// writer 返回 print 的输出目标
+func (in *interp) writer(redirect tokenType, dest expr) (io.Writer, error) {
+	if redirect == 0 {
+		return in.stdout, nil
+	}
+	name, err := in.evalString(dest)
+	if err != nil {
+		return nil, err
+	}
+	if redirect != tokenPipe {
+		switch name {
+		case "/dev/stdout", "-":
+			return in.stdout, nil
+		case "/dev/stderr":
+			// 标准错误不缓冲，先输出标准输出的内容以保持顺序
+			if err := in.stdout.Flush(); err != nil {
+				return nil, err
+			}
+			return in.cfg.Stderr, nil
+		}
+	}
+
+	if out, ok := in.outputs[name]; ok {
+		return out.writer, nil
+	}
+	out := &output{command: redirect == tokenPipe}
+	if out.command {
+		out.writer = bufio.NewWriter(&out.buf)
+	} else {
+		mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if redirect == tokenAppend {
+			mode = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		file, err := os.OpenFile(name, mode, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("无法打开文件 %s: %w", name, unwrapPathError(err))
+		}
+		out.file = file
+		out.writer = bufio.NewWriter(file)
+	}
+	in.outputs[name] = out
+	return out.writer, nil
+}
+
+// closeOutput 关闭输出，命令在此时执行，返回命令的退出码
+func (in *interp) closeOutput(out *output, name string) (int, error) {
+	if err := out.writer.Flush(); err != nil {
+		return -1, err
+	}
+	if !out.command {
+		return 0, out.file.Close()
+	}
+	if err := in.stdout.Flush(); err != nil {
+		return -1, err
+	}
+	return in.runCommand(name, &out.buf, in.stdout)
+}
+
+// closeStream 实现 close()：关闭名为 name 的输出或输入，没有打开时返回 -1
+func (in *interp) closeStream(name string) (int, error) {
+	status := -1
+	if out, ok := in.outputs[name]; ok {
+		delete(in.outputs, name)
+		s, err := in.closeOutput(out, name)
+		if err != nil {
+			return -1, err
+		}
+		status = s
+	}
+	if r, ok := in.inputs[name]; ok {
+		delete(in.inputs, name)
+		r.close()
+		status = r.status
+	}
+	return status, nil
+}
+
+// flushAll 输出所有缓冲的内容（不包括还没执行的命令）
+func (in *interp) flushAll() error {
+	for _, out := range in.outputs {
+		if !out.command {
+			if err := out.writer.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return in.stdout.Flush()
+}
+
+// closeAll 程序结束时关闭所有输入和输出，执行还没执行的输出命令
+func (in *interp) closeAll() error {
+	var firstErr error
+	if err := in.stdout.Flush(); err != nil {
+		firstErr = err
+	}
+	names := make([]string, 0, len(in.outputs))
+	for name := range in.outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := in.closeStream(name); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := in.stdout.Flush(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	for name, r := range in.inputs {
+		r.close()
+		delete(in.inputs, name)
+	}
+	in.closeMain()
+	return firstErr
+}
+
+// runCommand 通过 Config.Exec 执行命令
+func (in *interp) runCommand(command string, stdin io.Reader, stdout io.Writer) (int, error) {
+	if in.cfg.Exec == nil {
+		return -1, fmt.Errorf("不支持执行命令: %s", command)
+	}
+	if stdin == nil {
+		stdin = strings.NewReader("")
+	}
+	return in.cfg.Exec(command, stdin, stdout)
+}
@@ -0,0 +1,406 @@
+package awk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenType 词法单元类型
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenNewline
+	tokenLBrace
+	tokenRBrace
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenSemicolon
+	tokenComma
+
+	tokenNumber
+	tokenString
+	tokenRegex
+	tokenName
+	tokenFuncName // 紧跟 ( 的名字，即用户函数调用
+	tokenBuiltin  // 内置函数名
+
+	tokenAdd
+	tokenSub
+	tokenMul
+	tokenDiv
+	tokenMod
+	tokenPow
+	tokenAssign
+	tokenAddAssign
+	tokenSubAssign
+	tokenMulAssign
+	tokenDivAssign
+	tokenModAssign
+	tokenPowAssign
+	tokenEq
+	tokenNe
+	tokenLt
+	tokenLe
+	tokenGt
+	tokenGe
+	tokenMatch
+	tokenNoMatch
+	tokenNot
+	tokenAnd
+	tokenOr
+	tokenQuestion
+	tokenColon
+	tokenIncr
+	tokenDecr
+	tokenDollar
+	tokenPipe
+	tokenAppend
+
+	tokenBegin
+	tokenEnd
+	tokenFunction
+	tokenIf
+	tokenElse
+	tokenWhile
+	tokenFor
+	tokenDo
+	tokenBreak
+	tokenContinue
+	tokenNext
+	tokenNextfile
+	tokenExit
+	tokenReturn
+	tokenDelete
+	tokenIn
+	tokenGetline
+	tokenPrint
+	tokenPrintf
+)
+
+// keywords 关键字
+var keywords = map[string]tokenType{
+	"BEGIN":    tokenBegin,
+	"END":      tokenEnd,
+	"function": tokenFunction,
+	"func":     tokenFunction,
+	"if":       tokenIf,
+	"else":     tokenElse,
+	"while":    tokenWhile,
+	"for":      tokenFor,
+	"do":       tokenDo,
+	"break":    tokenBreak,
+	"continue": tokenContinue,
+	"next":     tokenNext,
+	"nextfile": tokenNextfile,
+	"exit":     tokenExit,
+	"return":   tokenReturn,
+	"delete":   tokenDelete,
+	"in":       tokenIn,
+	"getline":  tokenGetline,
+	"print":    tokenPrint,
+	"printf":   tokenPrintf,
+}
+
+// operators 运算符，较长的写在前面
+var operators = []struct {
+	text string
+	typ  tokenType
+}{
+	{"**=", tokenPowAssign},
+	{"&&", tokenAnd}, {"||", tokenOr}, {"==", tokenEq}, {"!=", tokenNe},
+	{"<=", tokenLe}, {">=", tokenGe}, {"!~", tokenNoMatch}, {"++", tokenIncr},
+	{"--", tokenDecr}, {"+=", tokenAddAssign}, {"-=", tokenSubAssign},
+	{"*=", tokenMulAssign}, {"/=", tokenDivAssign}, {"%=", tokenModAssign},
+	{"^=", tokenPowAssign}, {"**", tokenPow}, {">>", tokenAppend},
+	{"{", tokenLBrace}, {"}", tokenRBrace}, {"(", tokenLParen}, {")", tokenRParen},
+	{"[", tokenLBracket}, {"]", tokenRBracket}, {";", tokenSemicolon}, {",", tokenComma},
+	{"+", tokenAdd}, {"-", tokenSub}, {"*", tokenMul}, {"/", tokenDiv}, {"%", tokenMod},
+	{"^", tokenPow}, {"=", tokenAssign}, {"<", tokenLt}, {">", tokenGt}, {"~", tokenMatch},
+	{"!", tokenNot}, {"?", tokenQuestion}, {":", tokenColon}, {"$", tokenDollar}, {"|", tokenPipe},
+}
+
+// token 词法单元
+type token struct {
+	typ  tokenType
+	text string // 字符串和正则表达式是处理转义后的内容
+	num  float64
+	line int
+}
+
+// describe 用于错误信息的词法单元描述
+func (t token) describe() string {
+	switch t.typ {
+	case tokenEOF:
+		return "程序末尾"
+	case tokenNewline:
+		return "换行"
+	case tokenString:
+		return strconv.Quote(t.text)
+	case tokenRegex:
+		return "/" + t.text + "/"
+	}
+	return "'" + t.text + "'"
+}
+
+// lexer awk 词法分析器
+type lexer struct {
+	src  string
+	pos  int
+	line int
+	last tokenType // 上一个词法单元，用于区分除号和正则表达式
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, last: tokenNewline}
+}
+
+// regexAllowed 当前位置的 / 是否开始一个正则表达式（前面不是操作数）
+func (l *lexer) regexAllowed() bool {
+	switch l.last {
+	case tokenNumber, tokenString, tokenRegex, tokenName, tokenBuiltin,
+		tokenRParen, tokenRBracket, tokenDollar, tokenIncr, tokenDecr:
+		return false
+	}
+	return true
+}
+
+// next 返回下一个词法单元
+func (l *lexer) next() (token, error) {
+	tok, err := l.scan()
+	if err == nil {
+		l.last = tok.typ
+	}
+	return tok, err
+}
+
+func (l *lexer) scan() (token, error) {
+	// 跳过空白、注释和续行
+	for l.pos < len(l.src) {
+		ch := l.src[l.pos]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			l.pos++
+		case ch == '\\' && strings.HasPrefix(l.src[l.pos+1:], "\n"):
+			l.pos += 2
+			l.line++
+		case ch == '\\' && strings.HasPrefix(l.src[l.pos+1:], "\r\n"):
+			l.pos += 3
+			l.line++
+		case ch == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			goto scan
+		}
+	}
+scan:
+	line := l.line
+	if l.pos >= len(l.src) {
+		return token{typ: tokenEOF, line: line}, nil
+	}
+
+	ch := l.src[l.pos]
+	switch {
+	case ch == '\n':
+		l.pos++
+		l.line++
+		return token{typ: tokenNewline, text: "\n", line: line}, nil
+	case ch == '"':
+		return l.scanString()
+	case ch == '/' && l.regexAllowed():
+		return l.scanRegex()
+	case isDigit(ch) || ch == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1]):
+		return l.scanNumber()
+	case isNameStart(ch):
+		start := l.pos
+		for l.pos < len(l.src) && isNameChar(l.src[l.pos]) {
+			l.pos++
+		}
+		word := l.src[start:l.pos]
+		if typ, ok := keywords[word]; ok {
+			return token{typ: typ, text: word, line: line}, nil
+		}
+		if _, ok := builtins[word]; ok {
+			return token{typ: tokenBuiltin, text: word, line: line}, nil
+		}
+		if l.pos < len(l.src) && l.src[l.pos] == '(' {
+			return token{typ: tokenFuncName, text: word, line: line}, nil
+		}
+		return token{typ: tokenName, text: word, line: line}, nil
+	}
+
+	for _, op := range operators {
+		if strings.HasPrefix(l.src[l.pos:], op.text) {
+			l.pos += len(op.text)
+			return token{typ: op.typ, text: op.text, line: line}, nil
+		}
+	}
+	return token{}, &SyntaxError{Line: line, Message: fmt.Sprintf("无效的字符 '%c'", []rune(l.src[l.pos:])[0])}
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isNameStart(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch == '_'
+}
+
+func isNameChar(ch byte) bool {
+	return isNameStart(ch) || isDigit(ch)
+}
+
+// scanNumber 读取数字常量，支持 0x 开头的十六进制
+func (l *lexer) scanNumber() (token, error) {
+	start := l.pos
+	src := l.src[l.pos:]
+	if len(src) > 2 && src[0] == '0' && (src[1] == 'x' || src[1] == 'X') {
+		end := 2
+		for end < len(src) && strings.IndexByte("0123456789abcdefABCDEF", src[end]) >= 0 {
+			end++
+		}
+		if end > 2 {
+			n, _ := strconv.ParseUint(src[2:end], 16, 64)
+			l.pos += end
+			return token{typ: tokenNumber, text: src[:end], num: float64(n), line: l.line}, nil
+		}
+	}
+
+	l.pos += scanNumber(src)
+	text := l.src[start:l.pos]
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil && !isRangeError(err) {
+		return token{}, &SyntaxError{Line: l.line, Message: "无效的数字: " + text}
+	}
+	return token{typ: tokenNumber, text: text, num: n, line: l.line}, nil
+}
+
+// scanString 读取字符串常量并处理转义
+func (l *lexer) scanString() (token, error) {
+	line := l.line
+	l.pos++ // "
+	var sb strings.Builder
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+			return token{}, &SyntaxError{Line: line, Message: "字符串没有结束"}
+		}
+		ch := l.src[l.pos]
+		if ch == '"' {
+			l.pos++
+			return token{typ: tokenString, text: sb.String(), line: line}, nil
+		}
+		if ch == '\\' && l.pos+1 < len(l.src) {
+			if l.src[l.pos+1] == '\n' {
+				l.pos += 2
+				l.line++
+				continue
+			}
+			n := unescape(l.src[l.pos+1:], &sb)
+			l.pos += 1 + n
+			continue
+		}
+		sb.WriteByte(ch)
+		l.pos++
+	}
+}
+
+// unescape 处理 s 开头的转义序列（不含反斜杠），结果写入 sb，返回使用的字节数
+func unescape(s string, sb *strings.Builder) int {
+	switch s[0] {
+	case 'n':
+		sb.WriteByte('\n')
+	case 't':
+		sb.WriteByte('\t')
+	case 'r':
+		sb.WriteByte('\r')
+	case 'a':
+		sb.WriteByte('\a')
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'v':
+		sb.WriteByte('\v')
+	case '"', '\\', '/':
+		sb.WriteByte(s[0])
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		n, code := 0, 0
+		for n < 3 && n < len(s) && s[n] >= '0' && s[n] <= '7' {
+			code = code*8 + int(s[n]-'0')
+			n++
+		}
+		sb.WriteByte(byte(code))
+		return n
+	default:
+		// 未知的转义保留反斜杠，使 "\." 这样的动态正则表达式按字面意义使用
+		sb.WriteByte('\\')
+		sb.WriteByte(s[0])
+	}
+	return 1
+}
+
+// Unescape 处理字符串中的转义序列，用于 -v 和命令行赋值
+func Unescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i += unescape(s[i+1:], &sb)
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// scanRegex 读取正则表达式常量，\/ 表示 /
+func (l *lexer) scanRegex() (token, error) {
+	line := l.line
+	l.pos++ // /
+	var sb strings.Builder
+	inBracket := false
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' {
+			return token{}, &SyntaxError{Line: line, Message: "正则表达式没有结束"}
+		}
+		ch := l.src[l.pos]
+		switch {
+		case ch == '\\' && l.pos+1 < len(l.src):
+			if l.src[l.pos+1] == '/' {
+				sb.WriteByte('/')
+			} else {
+				sb.WriteString(l.src[l.pos : l.pos+2])
+			}
+			l.pos += 2
+			continue
+		case ch == '[' && !inBracket:
+			inBracket = true
+			// [] 和 [^] 开头的 ] 是普通字符
+			sb.WriteByte(ch)
+			l.pos++
+			if strings.HasPrefix(l.src[l.pos:], "^") {
+				sb.WriteByte('^')
+				l.pos++
+			}
+			if strings.HasPrefix(l.src[l.pos:], "]") {
+				sb.WriteByte(']')
+				l.pos++
+			}
+			continue
+		case ch == ']' && inBracket:
+			inBracket = false
+		case ch == '/' && !inBracket:
+			l.pos++
+			return token{typ: tokenRegex, text: sb.String(), line: line}, nil
+		}
+		sb.WriteByte(ch)
+		l.pos++
+	}
+}
@@ -0,0 +1,926 @@
+package awk
+
+import (
+	"fmt"
+)
+
+// SyntaxError awk 程序的语法错误
+type SyntaxError struct {
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("第 %d 行: %s", e.Line, e.Message)
+}
+
+// 需要特殊处理的内置变量
+const (
+	specialNF = iota
+	specialNR
+	specialFNR
+	specialFS
+	specialOFS
+	specialORS
+	specialRS
+	specialFILENAME
+	specialSUBSEP
+	specialRSTART
+	specialRLENGTH
+	specialCONVFMT
+	specialOFMT
+)
+
+var specialVars = map[string]int{
+	"NF":       specialNF,
+	"NR":       specialNR,
+	"FNR":      specialFNR,
+	"FS":       specialFS,
+	"OFS":      specialOFS,
+	"ORS":      specialORS,
+	"RS":       specialRS,
+	"FILENAME": specialFILENAME,
+	"SUBSEP":   specialSUBSEP,
+	"RSTART":   specialRSTART,
+	"RLENGTH":  specialRLENGTH,
+	"CONVFMT":  specialCONVFMT,
+	"OFMT":     specialOFMT,
+}
+
+// 预先定义的全局变量的位置
+const (
+	globalARGC = iota
+	globalARGV
+	globalENVIRON
+)
+
+// callSite 函数调用及其所在的函数，用于推断参数是否是数组
+type callSite struct {
+	call   *callExpr
+	caller *funcDef // 在规则中调用时为 nil
+}
+
+// parser 递归下降的语法分析器，语法错误通过 panic(*SyntaxError) 传递
+type parser struct {
+	lex    *lexer
+	tok    token
+	peeked []token
+	prog   *Program
+
+	globals map[string]int
+	locals  map[string]int // 当前函数的参数
+	funcs   map[string]*funcDef
+	curFunc *funcDef
+	calls   []callSite
+
+	loopDepth  int
+	noGreater  bool // print 的参数中 > 表示重定向
+	allowGroup bool // 括号中可以是逗号分隔的列表（print (a, b) > file）
+}
+
+// Parse 解析 awk 程序
+func Parse(src string) (prog *Program, err error) {
+	p := &parser{
+		lex:     newLexer(src),
+		prog:    &Program{},
+		globals: make(map[string]int),
+		funcs:   make(map[string]*funcDef),
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			syntaxErr, ok := r.(*SyntaxError)
+			if !ok {
+				panic(r)
+			}
+			prog, err = nil, syntaxErr
+		}
+	}()
+
+	// ARGC、ARGV 和 ENVIRON 总是占用前三个全局变量
+	p.variable("ARGC")
+	p.variable("ARGV")
+	p.variable("ENVIRON")
+
+	p.advance()
+	p.items()
+	p.resolve()
+	return p.prog, nil
+}
+
+// fail 报告当前位置的语法错误
+func (p *parser) fail(format string, args ...interface{}) {
+	panic(&SyntaxError{Line: p.tok.line, Message: fmt.Sprintf(format, args...)})
+}
+
+// unexpected 报告意外的词法单元
+func (p *parser) unexpected() {
+	p.fail("语法错误: 意外的 %s", p.tok.describe())
+}
+
+func (p *parser) lexNext() token {
+	tok, err := p.lex.next()
+	if err != nil {
+		panic(err)
+	}
+	return tok
+}
+
+func (p *parser) advance() {
+	if len(p.peeked) > 0 {
+		p.tok, p.peeked = p.peeked[0], p.peeked[1:]
+		return
+	}
+	p.tok = p.lexNext()
+}
+
+// peek 返回当前词法单元之后的一个
+func (p *parser) peek() token {
+	if len(p.peeked) == 0 {
+		p.peeked = append(p.peeked, p.lexNext())
+	}
+	return p.peeked[0]
+}
+
+func (p *parser) expect(typ tokenType, text string) {
+	if p.tok.typ != typ {
+		p.fail("语法错误: 需要 '%s'，遇到 %s", text, p.tok.describe())
+	}
+	p.advance()
+}
+
+// optNewlines 跳过换行（用于 {、&&、|| 、逗号等之后）
+func (p *parser) optNewlines() {
+	for p.tok.typ == tokenNewline {
+		p.advance()
+	}
+}
+
+// skipTerminators 跳过换行和分号
+func (p *parser) skipTerminators() {
+	for p.tok.typ == tokenNewline || p.tok.typ == tokenSemicolon {
+		p.advance()
+	}
+}
+
+// atTerminator 当前是否位于简单语句的末尾
+func (p *parser) atTerminator() bool {
+	switch p.tok.typ {
+	case tokenSemicolon, tokenNewline, tokenRBrace, tokenEOF:
+		return true
+	}
+	return false
+}
+
+// items 解析程序的各项：BEGIN、END、函数和规则
+func (p *parser) items() {
+	p.skipTerminators()
+	for p.tok.typ != tokenEOF {
+		switch p.tok.typ {
+		case tokenBegin:
+			p.advance()
+			p.prog.begin = append(p.prog.begin, p.block())
+		case tokenEnd:
+			p.advance()
+			p.prog.end = append(p.prog.end, p.block())
+		case tokenFunction:
+			p.function()
+		default:
+			p.rule()
+		}
+		p.skipTerminators()
+	}
+}
+
+// rule 解析 pattern { action }，模式和动作都可以省略其一
+func (p *parser) rule() {
+	r := &rule{}
+	if p.tok.typ != tokenLBrace {
+		r.pattern = p.expr()
+		if p.tok.typ == tokenComma {
+			p.advance()
+			p.optNewlines()
+			r.endPat = p.expr()
+		}
+	}
+
+	if p.tok.typ == tokenLBrace {
+		r.action = p.block()
+		if r.action == nil {
+			r.action = []stmt{}
+		}
+	} else if !p.atTerminator() {
+		p.unexpected()
+	}
+	p.prog.rules = append(p.prog.rules, r)
+}
+
+// function 解析函数定义，多余的参数用作局部变量
+func (p *parser) function() {
+	p.advance()
+	if p.tok.typ != tokenName && p.tok.typ != tokenFuncName {
+		p.fail("语法错误: 需要函数名，遇到 %s", p.tok.describe())
+	}
+	name := p.tok.text
+	if _, ok := p.funcs[name]; ok {
+		p.fail("函数 %s 重复定义", name)
+	}
+	if _, ok := specialVars[name]; ok {
+		p.fail("不能用内置变量名 %s 作为函数名", name)
+	}
+	p.advance()
+
+	fn := &funcDef{name: name}
+	p.funcs[name] = fn
+	p.locals = make(map[string]int)
+
+	p.expect(tokenLParen, "(")
+	for p.tok.typ != tokenRParen {
+		if p.tok.typ != tokenName {
+			p.fail("语法错误: 需要参数名，遇到 %s", p.tok.describe())
+		}
+		if _, ok := p.locals[p.tok.text]; ok {
+			p.fail("函数 %s 的参数 %s 重复", name, p.tok.text)
+		}
+		p.locals[p.tok.text] = len(fn.params)
+		fn.params = append(fn.params, p.tok.text)
+		p.advance()
+		if p.tok.typ == tokenComma {
+			p.advance()
+			p.optNewlines()
+		} else if p.tok.typ != tokenRParen {
+			p.unexpected()
+		}
+	}
+	p.advance()
+	p.optNewlines()
+
+	fn.isArray = make([]bool, len(fn.params))
+	p.curFunc = fn
+	fn.body = p.block()
+	p.curFunc, p.locals = nil, nil
+	p.prog.functions = append(p.prog.functions, fn)
+}
+
+// resolve 把函数调用关联到函数定义，并推断通过参数传递的数组
+func (p *parser) resolve() {
+	for _, site := range p.calls {
+		fn, ok := p.funcs[site.call.name]
+		if !ok {
+			panic(&SyntaxError{Line: site.call.line, Message: "未定义的函数 " + site.call.name})
+		}
+		if len(site.call.args) > len(fn.params) {
+			panic(&SyntaxError{Line: site.call.line, Message: fmt.Sprintf("函数 %s 最多接受 %d 个参数", fn.name, len(fn.params))})
+		}
+		site.call.fn = fn
+	}
+
+	// 参数传给把它当作数组的函数时，它本身也是数组
+	for changed := true; changed; {
+		changed = false
+		for _, site := range p.calls {
+			if site.caller == nil {
+				continue
+			}
+			for i, arg := range site.call.args {
+				v, ok := arg.(*varExpr)
+				if ok && v.scope == scopeLocal && site.call.fn.isArray[i] && !site.caller.isArray[v.index] {
+					site.caller.isArray[v.index] = true
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// block 解析 { 语句... }
+func (p *parser) block() []stmt {
+	p.expect(tokenLBrace, "{")
+	var body []stmt
+	for {
+		p.skipTerminators()
+		if p.tok.typ == tokenRBrace {
+			p.advance()
+			return body
+		}
+		if p.tok.typ == tokenEOF {
+			p.fail("语法错误: 缺少 '}'")
+		}
+		if s := p.statement(); s != nil {
+			body = append(body, s)
+		}
+	}
+}
+
+// asList 把语句转为语句列表，{} 块展开
+func asList(s stmt) []stmt {
+	switch s := s.(type) {
+	case nil:
+		return nil
+	case *blockStmt:
+		return s.body
+	}
+	return []stmt{s}
+}
+
+// statement 解析一条语句，空语句返回 nil
+func (p *parser) statement() stmt {
+	switch p.tok.typ {
+	case tokenLBrace:
+		return &blockStmt{body: p.block()}
+	case tokenSemicolon:
+		p.advance()
+		return nil
+	case tokenIf:
+		return p.ifStatement()
+	case tokenWhile:
+		p.advance()
+		p.expect(tokenLParen, "(")
+		cond := p.expr()
+		p.expect(tokenRParen, ")")
+		if p.tok.typ == tokenSemicolon {
+			p.advance()
+			return &whileStmt{cond: cond}
+		}
+		p.optNewlines()
+		return &whileStmt{cond: cond, body: p.loopBody()}
+	case tokenDo:
+		p.advance()
+		p.optNewlines()
+		body := p.loopBody()
+		p.skipTerminators()
+		p.expect(tokenWhile, "while")
+		p.expect(tokenLParen, "(")
+		cond := p.expr()
+		p.expect(tokenRParen, ")")
+		p.endSimple()
+		return &doStmt{body: body, cond: cond}
+	case tokenFor:
+		return p.forStatement()
+	}
+
+	s := p.simpleStatement()
+	p.endSimple()
+	return s
+}
+
+// endSimple 读取简单语句后的分号或换行
+func (p *parser) endSimple() {
+	switch p.tok.typ {
+	case tokenSemicolon, tokenNewline:
+		p.advance()
+	case tokenRBrace, tokenEOF:
+	default:
+		p.unexpected()
+	}
+}
+
+func (p *parser) loopBody() []stmt {
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+	return asList(p.statement())
+}
+
+func (p *parser) ifStatement() stmt {
+	p.advance()
+	p.expect(tokenLParen, "(")
+	s := &ifStmt{cond: p.expr()}
+	p.expect(tokenRParen, ")")
+	p.optNewlines()
+	s.then = asList(p.statement())
+
+	p.optNewlines()
+	if p.tok.typ == tokenSemicolon && p.peek().typ == tokenElse {
+		p.advance()
+	}
+	if p.tok.typ == tokenElse {
+		p.advance()
+		p.optNewlines()
+		s.elseBody = asList(p.statement())
+	}
+	return s
+}
+
+func (p *parser) forStatement() stmt {
+	p.advance()
+	p.expect(tokenLParen, "(")
+
+	if p.tok.typ == tokenName && p.peek().typ == tokenIn {
+		variable := p.variable(p.tok.text)
+		p.advance()
+		p.advance()
+		array := p.arrayName()
+		p.expect(tokenRParen, ")")
+		p.optNewlines()
+		return &forInStmt{variable: variable, array: array, body: p.loopBody()}
+	}
+
+	s := &forStmt{}
+	if p.tok.typ != tokenSemicolon {
+		s.init = p.simpleStatement()
+	}
+	p.expect(tokenSemicolon, ";")
+	p.optNewlines()
+	if p.tok.typ != tokenSemicolon {
+		s.cond = p.expr()
+	}
+	p.expect(tokenSemicolon, ";")
+	p.optNewlines()
+	if p.tok.typ != tokenRParen {
+		s.post = p.simpleStatement()
+	}
+	p.expect(tokenRParen, ")")
+	if p.tok.typ == tokenSemicolon {
+		p.advance()
+		return s
+	}
+	p.optNewlines()
+	s.body = p.loopBody()
+	return s
+}
+
+// simpleStatement 解析不含语句块的语句
+func (p *parser) simpleStatement() stmt {
+	switch p.tok.typ {
+	case tokenPrint, tokenPrintf:
+		return p.printStatement()
+	case tokenNext:
+		p.advance()
+		return &nextStmt{}
+	case tokenNextfile:
+		p.advance()
+		return &nextfileStmt{}
+	case tokenBreak, tokenContinue:
+		if p.loopDepth == 0 {
+			p.fail("%s 只能在循环中使用", p.tok.text)
+		}
+		typ := p.tok.typ
+		p.advance()
+		if typ == tokenBreak {
+			return &breakStmt{}
+		}
+		return &continueStmt{}
+	case tokenExit:
+		p.advance()
+		s := &exitStmt{}
+		if !p.atTerminator() {
+			s.status = p.expr()
+		}
+		return s
+	case tokenReturn:
+		if p.curFunc == nil {
+			p.fail("return 只能在函数中使用")
+		}
+		p.advance()
+		s := &returnStmt{}
+		if !p.atTerminator() {
+			s.value = p.expr()
+		}
+		return s
+	case tokenDelete:
+		p.advance()
+		s := &deleteStmt{array: p.arrayName()}
+		if p.tok.typ == tokenLBracket {
+			p.advance()
+			s.index = p.subscript()
+		}
+		return s
+	}
+	return &exprStmt{expr: p.expr()}
+}
+
+// printStatement 解析 print 和 printf 语句及其输出重定向
+func (p *parser) printStatement() stmt {
+	s := &printStmt{printf: p.tok.typ == tokenPrintf}
+	p.advance()
+
+	if !p.atTerminator() && !isRedirect(p.tok.typ) {
+		p.noGreater, p.allowGroup = true, p.tok.typ == tokenLParen
+		s.args = p.exprList()
+		p.noGreater, p.allowGroup = false, false
+		if len(s.args) == 1 {
+			if group, ok := s.args[0].(*groupExpr); ok {
+				s.args = group.exprs
+			}
+		}
+	}
+	if s.printf && len(s.args) == 0 {
+		p.fail("printf 需要格式字符串")
+	}
+
+	if isRedirect(p.tok.typ) {
+		s.redirect = p.tok.typ
+		p.advance()
+		s.dest = p.concat()
+	}
+	return s
+}
+
+func isRedirect(typ tokenType) bool {
+	return typ == tokenGt || typ == tokenAppend || typ == tokenPipe
+}
+
+// exprList 解析逗号分隔的表达式列表
+func (p *parser) exprList() []expr {
+	list := []expr{p.expr()}
+	for p.tok.typ == tokenComma {
+		p.advance()
+		p.optNewlines()
+		list = append(list, p.expr())
+	}
+	return list
+}
+
+// subscript 解析数组下标，[ 已读取
+func (p *parser) subscript() []expr {
+	saved := p.noGreater
+	p.noGreater = false
+	index := p.exprList()
+	p.noGreater = saved
+	p.expect(tokenRBracket, "]")
+	return index
+}
+
+// variable 返回变量引用：函数参数、特殊变量或全局变量
+func (p *parser) variable(name string) *varExpr {
+	if i, ok := p.locals[name]; ok {
+		return &varExpr{scope: scopeLocal, index: i, name: name}
+	}
+	if i, ok := specialVars[name]; ok {
+		return &varExpr{scope: scopeSpecial, index: i, name: name}
+	}
+	if _, ok := p.funcs[name]; ok {
+		p.fail("不能把函数 %s 当作变量使用", name)
+	}
+	i, ok := p.globals[name]
+	if !ok {
+		i = len(p.prog.globals)
+		p.globals[name] = i
+		p.prog.globals = append(p.prog.globals, name)
+	}
+	return &varExpr{scope: scopeGlobal, index: i, name: name}
+}
+
+// markArray 记录变量被当作数组使用
+func (p *parser) markArray(v *varExpr) {
+	switch v.scope {
+	case scopeSpecial:
+		p.fail("不能把 %s 当作数组使用", v.name)
+	case scopeLocal:
+		p.curFunc.isArray[v.index] = true
+	}
+}
+
+// arrayName 读取数组名
+func (p *parser) arrayName() *varExpr {
+	if p.tok.typ != tokenName {
+		p.fail("语法错误: 需要数组名，遇到 %s", p.tok.describe())
+	}
+	v := p.variable(p.tok.text)
+	p.markArray(v)
+	p.advance()
+	return v
+}
+
+func isLvalue(e expr) bool {
+	switch e.(type) {
+	case *varExpr, *indexExpr, *fieldExpr:
+		return true
+	}
+	return false
+}
+
+// compoundOps 复合赋值对应的运算符
+var compoundOps = map[tokenType]tokenType{
+	tokenAssign:    tokenAssign,
+	tokenAddAssign: tokenAdd,
+	tokenSubAssign: tokenSub,
+	tokenMulAssign: tokenMul,
+	tokenDivAssign: tokenDiv,
+	tokenModAssign: tokenMod,
+	tokenPowAssign: tokenPow,
+}
+
+// expr 解析表达式，赋值和条件表达式优先级最低且右结合
+func (p *parser) expr() expr {
+	left := p.or()
+
+	if op, ok := compoundOps[p.tok.typ]; ok && isLvalue(left) {
+		p.advance()
+		p.optNewlines()
+		return &assignExpr{target: left, op: op, value: p.expr()}
+	}
+	if p.tok.typ == tokenQuestion {
+		p.advance()
+		p.optNewlines()
+		yes := p.expr()
+		p.optNewlines()
+		p.expect(tokenColon, ":")
+		p.optNewlines()
+		return &condExpr{cond: left, yes: yes, no: p.expr()}
+	}
+	return left
+}
+
+func (p *parser) or() expr {
+	left := p.and()
+	for p.tok.typ == tokenOr {
+		p.advance()
+		p.optNewlines()
+		left = &logicalExpr{op: tokenOr, left: left, right: p.and()}
+	}
+	return left
+}
+
+func (p *parser) and() expr {
+	left := p.in()
+	for p.tok.typ == tokenAnd {
+		p.advance()
+		p.optNewlines()
+		left = &logicalExpr{op: tokenAnd, left: left, right: p.in()}
+	}
+	return left
+}
+
+func (p *parser) in() expr {
+	left := p.match()
+	for p.tok.typ == tokenIn {
+		p.advance()
+		left = &inExpr{index: []expr{left}, array: p.arrayName()}
+	}
+	return left
+}
+
+func (p *parser) match() expr {
+	left := p.compare()
+	for p.tok.typ == tokenMatch || p.tok.typ == tokenNoMatch {
+		negate := p.tok.typ == tokenNoMatch
+		p.advance()
+		left = &matchExpr{negate: negate, left: left, regex: p.compare()}
+	}
+	return left
+}
+
+// compare 解析比较运算（不结合），print 的参数中 > 是重定向
+func (p *parser) compare() expr {
+	left := p.pipeGetline()
+	switch p.tok.typ {
+	case tokenGt:
+		if p.noGreater {
+			return left
+		}
+	case tokenLt, tokenLe, tokenGe, tokenEq, tokenNe:
+	default:
+		return left
+	}
+	op := p.tok.typ
+	p.advance()
+	return &binaryExpr{op: op, left: left, right: p.pipeGetline()}
+}
+
+// pipeGetline 解析 cmd | getline [var]
+func (p *parser) pipeGetline() expr {
+	left := p.concat()
+	for p.tok.typ == tokenPipe && p.peek().typ == tokenGetline {
+		p.advance()
+		p.advance()
+		left = &getlineExpr{kind: getlineCmd, source: left, target: p.optionalLvalue()}
+	}
+	return left
+}
+
+// concat 解析字符串连接（相邻的表达式）
+func (p *parser) concat() expr {
+	left := p.additive()
+	for p.startsConcat() {
+		left = &concatExpr{left: left, right: p.additive()}
+	}
+	return left
+}
+
+// startsConcat 当前词法单元能否开始连接的下一个操作数（不能以 + - 开头）
+func (p *parser) startsConcat() bool {
+	switch p.tok.typ {
+	case tokenNumber, tokenString, tokenRegex, tokenName, tokenFuncName, tokenBuiltin,
+		tokenDollar, tokenLParen, tokenIncr, tokenDecr:
+		return true
+	}
+	return false
+}
+
+func (p *parser) additive() expr {
+	left := p.multiplicative()
+	for p.tok.typ == tokenAdd || p.tok.typ == tokenSub {
+		op := p.tok.typ
+		p.advance()
+		left = &binaryExpr{op: op, left: left, right: p.multiplicative()}
+	}
+	return left
+}
+
+func (p *parser) multiplicative() expr {
+	left := p.unary()
+	for p.tok.typ == tokenMul || p.tok.typ == tokenDiv || p.tok.typ == tokenMod {
+		op := p.tok.typ
+		p.advance()
+		left = &binaryExpr{op: op, left: left, right: p.unary()}
+	}
+	return left
+}
+
+func (p *parser) unary() expr {
+	switch p.tok.typ {
+	case tokenNot, tokenSub, tokenAdd:
+		op := p.tok.typ
+		p.advance()
+		return &unaryExpr{op: op, operand: p.unary()}
+	}
+	return p.power()
+}
+
+// power 解析右结合的乘方，指数可以带正负号（2^-1）
+func (p *parser) power() expr {
+	base := p.postfix()
+	if p.tok.typ != tokenPow {
+		return base
+	}
+	p.advance()
+	return &binaryExpr{op: tokenPow, left: base, right: p.exponent()}
+}
+
+func (p *parser) exponent() expr {
+	if p.tok.typ == tokenSub || p.tok.typ == tokenAdd {
+		op := p.tok.typ
+		p.advance()
+		return &unaryExpr{op: op, operand: p.exponent()}
+	}
+	return p.power()
+}
+
+func (p *parser) postfix() expr {
+	e := p.primary()
+	if (p.tok.typ == tokenIncr || p.tok.typ == tokenDecr) && isLvalue(e) {
+		op := p.tok.typ
+		p.advance()
+		return &incrExpr{target: e, op: op}
+	}
+	return e
+}
+
+// primary 解析常量、变量、字段、函数调用、括号和 getline
+func (p *parser) primary() expr {
+	tok := p.tok
+	switch tok.typ {
+	case tokenNumber:
+		p.advance()
+		return &numberExpr{value: tok.num}
+	case tokenString:
+		p.advance()
+		return &stringExpr{value: tok.text}
+	case tokenRegex:
+		p.advance()
+		return &regexExpr{source: tok.text}
+	case tokenDollar:
+		// $NF-1 是 ($NF)-1，$i++ 是 ($i)++
+		p.advance()
+		return &fieldExpr{index: p.primary()}
+	case tokenIncr, tokenDecr:
+		p.advance()
+		target := p.primary()
+		if !isLvalue(target) {
+			p.fail("%s 需要变量、数组元素或字段", tok.text)
+		}
+		return &incrExpr{target: target, op: tok.typ, prefix: true}
+	case tokenNot, tokenSub, tokenAdd:
+		p.advance()
+		return &unaryExpr{op: tok.typ, operand: p.primary()}
+	case tokenLParen:
+		return p.group()
+	case tokenGetline:
+		p.advance()
+		e := &getlineExpr{kind: getlineMain, target: p.optionalLvalue()}
+		if p.tok.typ == tokenLt {
+			p.advance()
+			e.kind, e.source = getlineFile, p.primary()
+		}
+		return e
+	case tokenBuiltin:
+		return p.builtinCall()
+	case tokenFuncName:
+		return p.userCall()
+	case tokenName:
+		p.advance()
+		if p.tok.typ == tokenLBracket {
+			v := p.variable(tok.text)
+			p.markArray(v)
+			p.advance()
+			return &indexExpr{array: v, index: p.subscript()}
+		}
+		return p.variable(tok.text)
+	}
+	p.unexpected()
+	return nil
+}
+
+// group 解析括号，(a, b) 只能用在 in 之前或作为 print 的参数
+func (p *parser) group() expr {
+	allowGroup, noGreater := p.allowGroup, p.noGreater
+	p.allowGroup, p.noGreater = false, false
+	p.advance()
+	list := p.exprList()
+	p.expect(tokenRParen, ")")
+	p.noGreater = noGreater
+
+	if len(list) == 1 {
+		return list[0]
+	}
+	if p.tok.typ == tokenIn {
+		p.advance()
+		return &inExpr{index: list, array: p.arrayName()}
+	}
+	if allowGroup && (p.atTerminator() || isRedirect(p.tok.typ)) {
+		return &groupExpr{exprs: list}
+	}
+	p.fail("语法错误: 括号中的列表只能用于 in 或 print")
+	return nil
+}
+
+// optionalLvalue 解析 getline 后可选的变量、数组元素或字段
+func (p *parser) optionalLvalue() expr {
+	switch p.tok.typ {
+	case tokenDollar:
+		p.advance()
+		return &fieldExpr{index: p.primary()}
+	case tokenName:
+		name := p.tok.text
+		p.advance()
+		if p.tok.typ == tokenLBracket {
+			v := p.variable(name)
+			p.markArray(v)
+			p.advance()
+			return &indexExpr{array: v, index: p.subscript()}
+		}
+		return p.variable(name)
+	}
+	return nil
+}
+
+// callArgs 解析函数调用的参数，( 已读取
+func (p *parser) callArgs() []expr {
+	noGreater := p.noGreater
+	p.noGreater = false
+	defer func() { p.noGreater = noGreater }()
+
+	p.optNewlines()
+	if p.tok.typ == tokenRParen {
+		p.advance()
+		return nil
+	}
+	args := p.exprList()
+	p.optNewlines()
+	p.expect(tokenRParen, ")")
+	return args
+}
+
+// builtinCall 解析内置函数调用，length 可以不带括号
+func (p *parser) builtinCall() expr {
+	name, line := p.tok.text, p.tok.line
+	p.advance()
+	if name == "length" && p.tok.typ != tokenLParen {
+		return &builtinExpr{name: name}
+	}
+	p.expect(tokenLParen, "(")
+	args := p.callArgs()
+
+	arity := builtins[name]
+	if len(args) < arity.min || len(args) > arity.max {
+		panic(&SyntaxError{Line: line, Message: fmt.Sprintf("%s: 参数个数错误", name)})
+	}
+
+	// 需要数组或可赋值参数的内置函数
+	switch {
+	case name == "split" || name == "match" && len(args) == 3:
+		i := map[string]int{"split": 1, "match": 2}[name]
+		v, ok := args[i].(*varExpr)
+		if !ok {
+			panic(&SyntaxError{Line: line, Message: fmt.Sprintf("%s: 第 %d 个参数必须是数组", name, i+1)})
+		}
+		p.markArray(v)
+	case name == "sub" || name == "gsub":
+		if len(args) == 2 {
+			args = append(args, &fieldExpr{index: &numberExpr{value: 0}})
+		} else if !isLvalue(args[2]) {
+			panic(&SyntaxError{Line: line, Message: name + ": 第 3 个参数必须是变量、数组元素或字段"})
+		}
+	}
+	return &builtinExpr{name: name, args: args}
+}
+
+// userCall 解析用户函数调用，函数可以在调用之后定义
+func (p *parser) userCall() expr {
+	call := &callExpr{name: p.tok.text, line: p.tok.line}
+	p.advance()
+	p.advance() // (
+	call.args = p.callArgs()
+	p.calls = append(p.calls, callSite{call: call, caller: p.curFunc})
+	return call
+}
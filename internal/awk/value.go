@@ -0,0 +1,188 @@
+package awk
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// valueKind 值的类型
+type valueKind uint8
+
+const (
+	kindNull   valueKind = iota // 未初始化，既可以当作 "" 也可以当作 0
+	kindNum                     // 数字
+	kindStr                     // 字符串
+	kindStrnum                  // 来自输入且看起来像数字的字符串，比较时按数字比较
+)
+
+// value awk 的值
+type value struct {
+	kind valueKind
+	s    string
+	n    float64
+}
+
+func num(n float64) value {
+	return value{kind: kindNum, n: n}
+}
+
+func str(s string) value {
+	return value{kind: kindStr, s: s}
+}
+
+func boolean(b bool) value {
+	if b {
+		return num(1)
+	}
+	return num(0)
+}
+
+// strnum 创建来自输入的值（字段、getline、split 的结果、命令行赋值等）
+func strnum(s string) value {
+	if n, ok := parseNumeric(s); ok {
+		return value{kind: kindStrnum, s: s, n: n}
+	}
+	return str(s)
+}
+
+// num 返回数值，字符串取最长的数字前缀
+func (v value) num() float64 {
+	switch v.kind {
+	case kindNum, kindStrnum:
+		return v.n
+	case kindStr:
+		return parseNumberPrefix(v.s)
+	}
+	return 0
+}
+
+// str 返回字符串值，非整数的数字按 convfmt 格式化
+func (v value) str(convfmt string) string {
+	if v.kind == kindNum {
+		return formatNumber(v.n, convfmt)
+	}
+	return v.s
+}
+
+// bool 返回布尔值：数字非 0 为真，字符串非空为真
+func (v value) bool() bool {
+	switch v.kind {
+	case kindNum, kindStrnum:
+		return v.n != 0
+	case kindStr:
+		return v.s != ""
+	}
+	return false
+}
+
+// isNumeric 比较时是否按数字处理
+func (v value) isNumeric() bool {
+	return v.kind != kindStr
+}
+
+// formatNumber 把数字转为字符串，整数不使用 format
+func formatNumber(n float64, format string) string {
+	switch {
+	case math.IsNaN(n):
+		return "nan"
+	case math.IsInf(n, 1):
+		return "inf"
+	case math.IsInf(n, -1):
+		return "-inf"
+	case n == math.Trunc(n) && math.Abs(n) < 1<<63:
+		return strconv.FormatInt(int64(n), 10)
+	case format == "%.6g":
+		return strconv.FormatFloat(n, 'g', 6, 64)
+	}
+	return fmt.Sprintf(format, n)
+}
+
+// isBlank 判断是否是数字前后允许的空白
+func isBlank(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+// scanNumber 返回 s 开头的十进制数字的长度，不是数字时返回 0
+func scanNumber(s string) int {
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+		digits++
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+			digits++
+		}
+	}
+	if digits == 0 {
+		return 0
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			i = j
+		}
+	}
+	return i
+}
+
+// parseNumeric 判断整个字符串（允许前后空白）是否是数字
+func parseNumeric(s string) (float64, bool) {
+	start, end := 0, len(s)
+	for start < end && isBlank(s[start]) {
+		start++
+	}
+	for end > start && isBlank(s[end-1]) {
+		end--
+	}
+	s = s[start:end]
+	if s == "" || scanNumber(s) != len(s) {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil && !isRangeError(err) {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseNumberPrefix 返回字符串开头的数字，没有数字时为 0
+func parseNumberPrefix(s string) float64 {
+	s = strings.TrimLeft(s, " \t\n\r")
+	n, _ := strconv.ParseFloat(s[:scanNumber(s)], 64)
+	return n
+}
+
+func isRangeError(err error) bool {
+	numErr, ok := err.(*strconv.NumError)
+	return ok && numErr.Err == strconv.ErrRange
+}
+
+// compareValues 比较两个值：都是数字时按数值比较，否则按字符串比较
+func compareValues(a, b value, convfmt string) int {
+	if a.isNumeric() && b.isNumeric() {
+		x, y := a.num(), b.num()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a.str(convfmt), b.str(convfmt))
+}
@@ -0,0 +1,409 @@
+// Package calc 实现 math 命令使用的算术表达式求值
+//
+// 整数运算使用 math/big 的大整数，结果不会溢出；出现小数或不能整除的
+// 除法后改用 256 位精度的浮点数。log、三角函数和小数指数的乘方使用
+// float64 计算。
+package calc
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// Eval 计算表达式的值
+//
+// 支持的语法:
+//
+//	数字      42  3.14  1e-3  1_000  0x1f  0b101  0o17
+//	单位      1.5GiB  10MB  4K（K/M/G/T/P 和 KiB 等是 1024 进制，KB 等是 1000 进制）
+//	运算符    + - * / // % ** ^ & | << >>，一元 + -
+//	函数      sqrt pow log log2 log10 exp abs min max round floor ceil trunc
+//	          sin cos tan atan
+//	常量      pi e
+func Eval(expr string) (*Number, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("表达式为空")
+	}
+
+	p := &parser{tokens: tokens}
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("意外的 '%s'", tok.text)
+	}
+	return value, nil
+}
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenName
+	tokenOperator
+)
+
+// token 词法单元
+type token struct {
+	kind  tokenKind
+	text  string
+	value *Number // 数字的值
+}
+
+// operators 运算符，较长的写在前面
+var operators = []string{"**", "//", "<<", ">>", "+", "-", "*", "/", "%", "^", "&", "|", "(", ")", ","}
+
+// tokenize 把表达式拆分为词法单元
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	s := expr
+
+	for {
+		s = strings.TrimLeftFunc(s, unicode.IsSpace)
+		if s == "" {
+			return tokens, nil
+		}
+
+		switch ch := rune(s[0]); {
+		case isDigit(ch) || ch == '.':
+			n := scanNumber(s)
+			value, err := parseNumber(s[:n])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: s[:n], value: value})
+			s = s[n:]
+		case isLetter(ch):
+			n := 1
+			for n < len(s) && (isLetter(rune(s[n])) || isDigit(rune(s[n]))) {
+				n++
+			}
+			tokens = append(tokens, token{kind: tokenName, text: s[:n]})
+			s = s[n:]
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(s, candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				r := []rune(s)[0]
+				return nil, fmt.Errorf("无效的字符 '%c'", r)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op})
+			s = s[len(op):]
+		}
+	}
+}
+
+func isDigit(ch rune) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isLetter(ch rune) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch == '_'
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || ch >= 'a' && ch <= 'f' || ch >= 'A' && ch <= 'F'
+}
+
+// scanNumber 返回数字（包括紧跟的单位）的长度
+func scanNumber(s string) int {
+	n := 0
+	if len(s) > 2 && s[0] == '0' && strings.ContainsRune("xXbBoO", rune(s[1])) {
+		n = 2
+		for n < len(s) && (isHexDigit(rune(s[n])) || s[n] == '_') {
+			n++
+		}
+		return n
+	}
+
+	for n < len(s) && (isDigit(rune(s[n])) || s[n] == '_' || s[n] == '.') {
+		n++
+	}
+	// 指数部分：e 后面必须是数字，否则是单位（如 1EB）
+	if n < len(s) && (s[n] == 'e' || s[n] == 'E') {
+		m := n + 1
+		if m < len(s) && (s[m] == '+' || s[m] == '-') {
+			m++
+		}
+		if m < len(s) && isDigit(rune(s[m])) {
+			n = m
+			for n < len(s) && isDigit(rune(s[n])) {
+				n++
+			}
+		}
+	}
+	for n < len(s) && isLetter(rune(s[n])) {
+		n++
+	}
+	return n
+}
+
+// parseNumber 解析数字字面量和单位
+func parseNumber(text string) (*Number, error) {
+	if len(text) > 2 && text[0] == '0' && strings.ContainsRune("xXbBoO", rune(text[1])) {
+		base := map[byte]int{'x': 16, 'b': 2, 'o': 8}[text[1]|0x20]
+		digits := strings.ReplaceAll(text[2:], "_", "")
+		i, ok := new(big.Int).SetString(digits, base)
+		if !ok || digits == "" {
+			return nil, fmt.Errorf("无效的数字: %s", text)
+		}
+		return &Number{i: i}, nil
+	}
+
+	end := len(text)
+	for end > 0 && isLetter(rune(text[end-1])) {
+		end--
+	}
+	digits, unit := strings.ReplaceAll(text[:end], "_", ""), text[end:]
+
+	var value *Number
+	if i, ok := new(big.Int).SetString(digits, 10); ok {
+		value = &Number{i: i}
+	} else if f, ok := newBigFloat().SetString(digits); ok && digits != "." {
+		value = newFloat(f)
+	} else {
+		return nil, fmt.Errorf("无效的数字: %s", text)
+	}
+
+	if unit == "" {
+		return value, nil
+	}
+	factor, ok := unitFactor(unit)
+	if !ok {
+		return nil, fmt.Errorf("未知单位: %s", unit)
+	}
+	value = mul(value, &Number{i: factor})
+	if i, ok := value.Integral(); ok {
+		value = &Number{i: i}
+	}
+	return value, nil
+}
+
+// unitFactor 返回单位表示的字节数，不区分大小写
+//
+// B 为 1；KB、MB、GB、TB、PB、EB 是 1000 进制；K、M、G、T、P、E 和
+// KiB、MiB 等是 1024 进制。
+func unitFactor(unit string) (*big.Int, bool) {
+	u := strings.ToLower(unit)
+	if u == "b" {
+		return big.NewInt(1), true
+	}
+
+	prefix, rest := u[:1], u[1:]
+	exp := strings.Index("kmgtpe", prefix) + 1
+	if exp == 0 {
+		return nil, false
+	}
+
+	base := int64(1024)
+	switch rest {
+	case "", "ib":
+	case "b":
+		base = 1000
+	default:
+		return nil, false
+	}
+	return new(big.Int).Exp(big.NewInt(base), big.NewInt(int64(exp)), nil), true
+}
+
+// parser 递归下降的表达式求值器，边解析边计算
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return token{kind: tokenEOF, text: "表达式末尾"}
+}
+
+func (p *parser) next() token {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// accept 当前是给定运算符之一时读取并返回它
+func (p *parser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// binaryLevels 二元运算符的优先级，从低到高
+var binaryLevels = [][]string{
+	{"|"},
+	{"&"},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "//", "%"},
+}
+
+func (p *parser) expression() (*Number, error) {
+	return p.binary(0)
+}
+
+// binary 解析第 level 级的左结合二元运算
+func (p *parser) binary(level int) (*Number, error) {
+	if level == len(binaryLevels) {
+		return p.unary()
+	}
+
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(binaryLevels[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		if left, err = apply(op, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// apply 计算二元运算
+func apply(op string, a, b *Number) (*Number, error) {
+	switch op {
+	case "+":
+		return add(a, b), nil
+	case "-":
+		return sub(a, b), nil
+	case "*":
+		return mul(a, b), nil
+	case "/":
+		return div(a, b)
+	case "//":
+		return floorDiv(a, b)
+	case "%":
+		return mod(a, b)
+	case "**", "^":
+		return pow(a, b)
+	}
+	return bitwise(op, a, b)
+}
+
+// unary 解析一元正负号，乘方优先于一元负号（-2**2 等于 -4）
+func (p *parser) unary() (*Number, error) {
+	if op, ok := p.accept("-", "+"); ok {
+		value, err := p.unary()
+		if err != nil || op == "+" {
+			return value, err
+		}
+		return neg(value), nil
+	}
+	return p.power()
+}
+
+// power 解析右结合的乘方，指数可以带符号（2^-1）
+func (p *parser) power() (*Number, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.accept("**", "^"); ok {
+		exp, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return apply(op, base, exp)
+	}
+	return base, nil
+}
+
+// primary 解析数字、常量、函数调用和括号
+func (p *parser) primary() (*Number, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		return tok.value, nil
+	case tokenName:
+		if _, ok := p.accept("("); ok {
+			return p.call(tok.text)
+		}
+		if value, ok := constants[strings.ToLower(tok.text)]; ok {
+			f, _ := newBigFloat().SetString(value)
+			return newFloat(f), nil
+		}
+		if _, ok := functions[tok.text]; ok {
+			return nil, fmt.Errorf("%s: 缺少 '('", tok.text)
+		}
+		return nil, fmt.Errorf("未知的名字: %s", tok.text)
+	case tokenOperator:
+		if tok.text == "(" {
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, fmt.Errorf("缺少 ')'")
+			}
+			return value, nil
+		}
+	}
+	if tok.kind == tokenEOF {
+		return nil, fmt.Errorf("表达式不完整")
+	}
+	return nil, fmt.Errorf("意外的 '%s'", tok.text)
+}
+
+// call 解析函数参数并调用函数，'(' 已读取
+func (p *parser) call(name string) (*Number, error) {
+	fn, ok := functions[name]
+	if !ok {
+		return nil, fmt.Errorf("未知函数: %s", name)
+	}
+
+	var args []*Number
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); ok {
+				continue
+			}
+			if _, ok := p.accept(")"); !ok {
+				return nil, fmt.Errorf("%s: 缺少 ')'", name)
+			}
+			break
+		}
+	}
+
+	if len(args) < fn.min || fn.max >= 0 && len(args) > fn.max {
+		return nil, fmt.Errorf("%s: %s", name, fn.arity())
+	}
+	return fn.call(args)
+}
@@ -0,0 +1,121 @@
+package calc
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// function 表达式中可以调用的函数
+type function struct {
+	min, max int // 参数个数范围，max 为 -1 表示不限
+	call     func(args []*Number) (*Number, error)
+}
+
+// arity 描述参数个数的错误信息
+func (f function) arity() string {
+	switch {
+	case f.min == f.max:
+		return fmt.Sprintf("需要 %d 个参数", f.min)
+	case f.max < 0:
+		return fmt.Sprintf("至少需要 %d 个参数", f.min)
+	}
+	return fmt.Sprintf("需要 %d 到 %d 个参数", f.min, f.max)
+}
+
+// constants 常量，保留 80 位有效数字
+var constants = map[string]string{
+	"pi": "3.1415926535897932384626433832795028841971693993751058209749445923078164062862",
+	"e":  "2.7182818284590452353602874713526624977572470936999595749669676277240766303535",
+}
+
+// functions 内置函数
+var functions = map[string]function{
+	"sqrt":  {1, 1, sqrt},
+	"pow":   {2, 2, func(args []*Number) (*Number, error) { return pow(args[0], args[1]) }},
+	"log":   {1, 2, logarithm},
+	"log2":  float64Func("log2", math.Log2),
+	"log10": float64Func("log10", math.Log10),
+	"exp":   float64Func("exp", math.Exp),
+	"abs":   {1, 1, absolute},
+	"min":   {1, -1, func(args []*Number) (*Number, error) { return extreme(args, -1), nil }},
+	"max":   {1, -1, func(args []*Number) (*Number, error) { return extreme(args, 1), nil }},
+	"round": {1, 2, rounding},
+	"floor": {1, 1, func(args []*Number) (*Number, error) { return floor(args[0]), nil }},
+	"ceil":  {1, 1, func(args []*Number) (*Number, error) { return ceil(args[0]), nil }},
+	"trunc": {1, 1, func(args []*Number) (*Number, error) { return trunc(args[0]), nil }},
+	"sin":   float64Func("sin", math.Sin),
+	"cos":   float64Func("cos", math.Cos),
+	"tan":   float64Func("tan", math.Tan),
+	"atan":  float64Func("atan", math.Atan),
+}
+
+// float64Func 用 float64 计算的单参数函数
+func float64Func(name string, fn func(float64) float64) function {
+	return function{1, 1, func(args []*Number) (*Number, error) {
+		return newFloat64(name, fn(args[0].Float64()))
+	}}
+}
+
+// sqrt 平方根，完全平方数的结果仍是整数
+func sqrt(args []*Number) (*Number, error) {
+	x := args[0]
+	if x.Sign() < 0 {
+		return nil, fmt.Errorf("sqrt: 参数不能是负数")
+	}
+	if i, ok := x.Integral(); ok && x.IsInt() {
+		root := new(big.Int).Sqrt(i)
+		if new(big.Int).Mul(root, root).Cmp(i) == 0 {
+			return &Number{i: root}, nil
+		}
+	}
+	return newFloat(newBigFloat().Sqrt(x.Float())), nil
+}
+
+// logarithm 自然对数，第二个参数指定底数
+func logarithm(args []*Number) (*Number, error) {
+	if args[0].Sign() <= 0 {
+		return nil, fmt.Errorf("log: 参数必须大于 0")
+	}
+	result := math.Log(args[0].Float64())
+	if len(args) == 2 {
+		base := args[1].Float64()
+		if base <= 0 || base == 1 {
+			return nil, fmt.Errorf("log: 无效的底数 %s", args[1])
+		}
+		result /= math.Log(base)
+	}
+	return newFloat64("log", result)
+}
+
+// absolute 绝对值
+func absolute(args []*Number) (*Number, error) {
+	if args[0].Sign() < 0 {
+		return neg(args[0]), nil
+	}
+	return args[0], nil
+}
+
+// extreme 返回最小值（sign 为 -1）或最大值（sign 为 1）
+func extreme(args []*Number, sign int) *Number {
+	best := args[0]
+	for _, arg := range args[1:] {
+		if arg.Float().Cmp(best.Float()) == sign {
+			best = arg
+		}
+	}
+	return best
+}
+
+// rounding 四舍五入，第二个参数是保留的小数位数
+func rounding(args []*Number) (*Number, error) {
+	digits := 0
+	if len(args) == 2 {
+		d, ok := args[1].Integral()
+		if !ok || !d.IsInt64() || d.Int64() > 1000 || d.Int64() < -1000 {
+			return nil, fmt.Errorf("round: 无效的小数位数 %s", args[1])
+		}
+		digits = int(d.Int64())
+	}
+	return round(args[0], digits), nil
+}
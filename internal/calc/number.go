@@ -0,0 +1,385 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// precision 浮点运算使用的二进制精度（约 77 位十进制有效数字）
+const precision = 256
+
+// maxExponent 整数乘方允许的最大指数，防止意外生成巨大的数
+const maxExponent = 1 << 20
+
+// Number 计算结果：整数运算保持精确的大整数，出现小数后使用高精度浮点数
+type Number struct {
+	i *big.Int   // 整数值，f 为 nil 时有效
+	f *big.Float // 浮点值
+}
+
+// NewInt 创建整数
+func NewInt(x int64) *Number {
+	return &Number{i: big.NewInt(x)}
+}
+
+// newFloat 创建浮点数，整数值的浮点数仍保持浮点类型
+func newFloat(f *big.Float) *Number {
+	return &Number{f: f}
+}
+
+// newFloat64 从 float64 创建浮点数，无穷大和 NaN 返回错误
+func newFloat64(name string, x float64) (*Number, error) {
+	if math.IsNaN(x) {
+		return nil, fmt.Errorf("%s: 参数超出定义域", name)
+	}
+	if math.IsInf(x, 0) {
+		return nil, fmt.Errorf("%s: 结果超出范围", name)
+	}
+	return newFloat(new(big.Float).SetPrec(precision).SetFloat64(x)), nil
+}
+
+// IsInt 判断是否是整数类型（而不是值恰好为整数的浮点数）
+func (n *Number) IsInt() bool {
+	return n.f == nil
+}
+
+// Integral 返回整数值，浮点数的值不是整数时返回 false
+func (n *Number) Integral() (*big.Int, bool) {
+	if n.f == nil {
+		return n.i, true
+	}
+	if !n.f.IsInt() {
+		return nil, false
+	}
+	i, _ := n.f.Int(nil)
+	return i, true
+}
+
+// Float 返回高精度浮点值
+func (n *Number) Float() *big.Float {
+	if n.f != nil {
+		return n.f
+	}
+	return new(big.Float).SetPrec(precision).SetInt(n.i)
+}
+
+// Float64 返回最接近的 float64
+func (n *Number) Float64() float64 {
+	x, _ := n.Float().Float64()
+	return x
+}
+
+// Sign 返回符号：-1、0 或 1
+func (n *Number) Sign() int {
+	if n.f != nil {
+		return n.f.Sign()
+	}
+	return n.i.Sign()
+}
+
+// String 按默认格式输出
+func (n *Number) String() string {
+	s, _ := n.Format(FormatOptions{Scale: -1, Base: 10})
+	return s
+}
+
+func newBigFloat() *big.Float {
+	return new(big.Float).SetPrec(precision)
+}
+
+// add 加法
+func add(a, b *Number) *Number {
+	if a.IsInt() && b.IsInt() {
+		return &Number{i: new(big.Int).Add(a.i, b.i)}
+	}
+	return newFloat(newBigFloat().Add(a.Float(), b.Float()))
+}
+
+// sub 减法
+func sub(a, b *Number) *Number {
+	if a.IsInt() && b.IsInt() {
+		return &Number{i: new(big.Int).Sub(a.i, b.i)}
+	}
+	return newFloat(newBigFloat().Sub(a.Float(), b.Float()))
+}
+
+// mul 乘法
+func mul(a, b *Number) *Number {
+	if a.IsInt() && b.IsInt() {
+		return &Number{i: new(big.Int).Mul(a.i, b.i)}
+	}
+	return newFloat(newBigFloat().Mul(a.Float(), b.Float()))
+}
+
+// errDivisionByZero 除数为 0
+var errDivisionByZero = errors.New("除数为 0")
+
+// div 除法，整数能整除时结果仍是整数
+func div(a, b *Number) (*Number, error) {
+	if b.Sign() == 0 {
+		return nil, errDivisionByZero
+	}
+	if a.IsInt() && b.IsInt() {
+		q, r := new(big.Int).QuoRem(a.i, b.i, new(big.Int))
+		if r.Sign() == 0 {
+			return &Number{i: q}, nil
+		}
+	}
+	return newFloat(newBigFloat().Quo(a.Float(), b.Float())), nil
+}
+
+// floorDiv 向下取整的除法
+func floorDiv(a, b *Number) (*Number, error) {
+	if b.Sign() == 0 {
+		return nil, errDivisionByZero
+	}
+	if a.IsInt() && b.IsInt() {
+		q, r := new(big.Int).QuoRem(a.i, b.i, new(big.Int))
+		if r.Sign() != 0 && r.Sign() != b.i.Sign() {
+			q.Sub(q, big.NewInt(1))
+		}
+		return &Number{i: q}, nil
+	}
+	return floor(newFloat(newBigFloat().Quo(a.Float(), b.Float()))), nil
+}
+
+// mod 取余，结果的符号与被除数相同
+func mod(a, b *Number) (*Number, error) {
+	if b.Sign() == 0 {
+		return nil, errDivisionByZero
+	}
+	if a.IsInt() && b.IsInt() {
+		return &Number{i: new(big.Int).Rem(a.i, b.i)}, nil
+	}
+	q := newBigFloat().Quo(a.Float(), b.Float())
+	t, _ := q.Int(nil)
+	prod := newBigFloat().Mul(newBigFloat().SetInt(t), b.Float())
+	return newFloat(newBigFloat().Sub(a.Float(), prod)), nil
+}
+
+// pow 乘方：整数指数精确计算，小数指数使用 float64
+func pow(a, b *Number) (*Number, error) {
+	exp, ok := b.Integral()
+	if !ok {
+		return newFloat64("pow", math.Pow(a.Float64(), b.Float64()))
+	}
+	if exp.CmpAbs(big.NewInt(maxExponent)) > 0 {
+		return nil, fmt.Errorf("pow: 指数太大")
+	}
+
+	e := exp.Int64()
+	if a.IsInt() && e >= 0 {
+		return &Number{i: new(big.Int).Exp(a.i, exp, nil)}, nil
+	}
+	if e < 0 && a.Sign() == 0 {
+		return nil, errDivisionByZero
+	}
+
+	// 快速幂
+	result := newBigFloat().SetInt64(1)
+	base := newBigFloat().Set(a.Float())
+	for k := abs64(e); k > 0; k >>= 1 {
+		if k&1 == 1 {
+			result.Mul(result, base)
+		}
+		base.Mul(base, base)
+	}
+	if e < 0 {
+		result.Quo(newBigFloat().SetInt64(1), result)
+	}
+	return newFloat(result), nil
+}
+
+func abs64(x int64) int64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// neg 取负
+func neg(a *Number) *Number {
+	if a.IsInt() {
+		return &Number{i: new(big.Int).Neg(a.i)}
+	}
+	return newFloat(newBigFloat().Neg(a.f))
+}
+
+// bitwise 整数位运算
+func bitwise(op string, a, b *Number) (*Number, error) {
+	x, okA := a.Integral()
+	y, okB := b.Integral()
+	if !okA || !okB {
+		return nil, fmt.Errorf("'%s' 需要整数", op)
+	}
+
+	switch op {
+	case "&":
+		return &Number{i: new(big.Int).And(x, y)}, nil
+	case "|":
+		return &Number{i: new(big.Int).Or(x, y)}, nil
+	case "<<", ">>":
+		if y.Sign() < 0 || y.Cmp(big.NewInt(maxExponent)) > 0 {
+			return nil, fmt.Errorf("'%s': 无效的移位位数 %s", op, y)
+		}
+		if op == "<<" {
+			return &Number{i: new(big.Int).Lsh(x, uint(y.Uint64()))}, nil
+		}
+		return &Number{i: new(big.Int).Rsh(x, uint(y.Uint64()))}, nil
+	}
+	return nil, fmt.Errorf("未知运算符: %s", op)
+}
+
+// floor 向下取整
+func floor(a *Number) *Number {
+	if a.IsInt() {
+		return a
+	}
+	t, acc := a.f.Int(nil)
+	if acc == big.Above { // 负数截断后变大
+		t.Sub(t, big.NewInt(1))
+	}
+	return &Number{i: t}
+}
+
+// ceil 向上取整
+func ceil(a *Number) *Number {
+	if a.IsInt() {
+		return a
+	}
+	t, acc := a.f.Int(nil)
+	if acc == big.Below { // 正数截断后变小
+		t.Add(t, big.NewInt(1))
+	}
+	return &Number{i: t}
+}
+
+// trunc 向零取整
+func trunc(a *Number) *Number {
+	if a.IsInt() {
+		return a
+	}
+	t, _ := a.f.Int(nil)
+	return &Number{i: t}
+}
+
+// round 四舍五入到 digits 位小数（远离零方向），digits 为 0 时结果是整数
+func round(a *Number, digits int) *Number {
+	if a.IsInt() && digits >= 0 {
+		return a
+	}
+
+	scale := newBigFloat().SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs64(int64(digits)))), nil))
+	x := newBigFloat().Set(a.Float())
+	if digits >= 0 {
+		x.Mul(x, scale)
+	} else {
+		x.Quo(x, scale)
+	}
+
+	half := newBigFloat().SetFloat64(0.5)
+	if x.Sign() < 0 {
+		x.Sub(x, half)
+	} else {
+		x.Add(x, half)
+	}
+	t, _ := x.Int(nil)
+
+	switch {
+	case digits == 0:
+		return &Number{i: t}
+	case digits < 0:
+		return &Number{i: t.Mul(t, mustInt(scale))}
+	}
+	return newFloat(newBigFloat().Quo(newBigFloat().SetInt(t), scale))
+}
+
+func mustInt(f *big.Float) *big.Int {
+	i, _ := f.Int(nil)
+	return i
+}
+
+// FormatOptions 输出格式
+type FormatOptions struct {
+	Scale int // 小数位数，负数表示最多 6 位并去掉末尾的 0
+	Base  int // 输出进制：2、8、10 或 16，非 10 进制只能输出整数
+	Human int // 以带单位的形式输出：1024 使用 KiB 等，1000 使用 kB 等，0 不使用单位
+}
+
+// defaultScale 未指定小数位数时最多输出的位数
+const defaultScale = 6
+
+// Format 按选项格式化数字
+func (n *Number) Format(opts FormatOptions) (string, error) {
+	if opts.Human != 0 {
+		return n.formatHuman(opts), nil
+	}
+
+	if opts.Base != 0 && opts.Base != 10 {
+		i, ok := n.Integral()
+		if !ok {
+			return "", fmt.Errorf("%s 不是整数，不能以 %d 进制输出", n.formatDecimal(-1), opts.Base)
+		}
+		prefix := map[int]string{2: "0b", 8: "0o", 16: "0x"}[opts.Base]
+		if i.Sign() < 0 {
+			return "-" + prefix + new(big.Int).Neg(i).Text(opts.Base), nil
+		}
+		return prefix + i.Text(opts.Base), nil
+	}
+
+	return n.formatDecimal(opts.Scale), nil
+}
+
+// formatDecimal 以十进制输出，scale 为负数时最多 6 位小数并去掉末尾的 0
+func (n *Number) formatDecimal(scale int) string {
+	if scale < 0 {
+		if n.IsInt() {
+			return n.i.String()
+		}
+		return trimZeros(n.f.Text('f', defaultScale))
+	}
+	s := n.Float().Text('f', scale)
+	if strings.Trim(s, "-0.") == "" {
+		s = strings.TrimPrefix(s, "-")
+	}
+	return s
+}
+
+// trimZeros 去掉小数末尾的 0 和多余的小数点，-0 输出为 0
+func trimZeros(s string) string {
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// formatHuman 以字节单位输出（如 1.5GiB），结果可以作为输入重新计算
+func (n *Number) formatHuman(opts FormatOptions) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	if opts.Human == 1000 {
+		units = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+	}
+
+	x := newBigFloat().Set(n.Float())
+	abs := newBigFloat().Abs(x)
+	base := newBigFloat().SetInt64(int64(opts.Human))
+	unit := 0
+	for unit < len(units)-1 && abs.Cmp(base) >= 0 {
+		abs.Quo(abs, base)
+		x.Quo(x, base)
+		unit++
+	}
+
+	scale := opts.Scale
+	if scale < 0 {
+		scale = 2
+		return trimZeros(x.Text('f', scale)) + units[unit]
+	}
+	return x.Text('f', scale) + units[unit]
+}
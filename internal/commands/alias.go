@@ -3,21 +3,20 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os"
+	"io"
 	"sort"
 	"strings"
 
 	"github.com/Lingbou/Lish/internal/config"
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
 type AliasCommand struct {
-	stdout *os.File
 	config *config.Config
 }
 
-func NewAliasCommand(stdout *os.File, cfg *config.Config) *AliasCommand {
+func NewAliasCommand(cfg *config.Config) *AliasCommand {
 	return &AliasCommand{
-		stdout: stdout,
 		config: cfg,
 	}
 }
@@ -27,9 +26,11 @@ func (c *AliasCommand) Name() string {
 }
 
 func (c *AliasCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	// 如果没有参数，显示所有别名
 	if len(args) == 0 {
-		return c.listAliases()
+		return c.listAliases(stdout)
 	}
 
 	// 设置别名
@@ -37,9 +38,9 @@ func (c *AliasCommand) Execute(ctx context.Context, args []string) error {
 		if !strings.Contains(arg, "=") {
 			// 显示特定别名
 			if cmd, exists := c.config.GetAlias(arg); exists {
-				fmt.Fprintf(c.stdout, "alias %s='%s'\n", arg, cmd)
+				fmt.Fprintf(stdout, "alias %s='%s'\n", arg, cmd)
 			} else {
-				fmt.Fprintf(c.stdout, "alias: %s: 未定义\n", arg)
+				fmt.Fprintf(stdout, "alias: %s: 未定义\n", arg)
 			}
 			continue
 		}
@@ -51,7 +52,7 @@ func (c *AliasCommand) Execute(ctx context.Context, args []string) error {
 
 		// 设置别名
 		c.config.SetAlias(name, command)
-		fmt.Fprintf(c.stdout, "设置别名: %s='%s'\n", name, command)
+		fmt.Fprintf(stdout, "设置别名: %s='%s'\n", name, command)
 	}
 
 	// 保存配置
@@ -62,9 +63,9 @@ func (c *AliasCommand) Execute(ctx context.Context, args []string) error {
 	return nil
 }
 
-func (c *AliasCommand) listAliases() error {
+func (c *AliasCommand) listAliases(stdout io.Writer) error {
 	if len(c.config.Aliases) == 0 {
-		fmt.Fprintln(c.stdout, "没有定义的别名")
+		fmt.Fprintln(stdout, "没有定义的别名")
 		return nil
 	}
 
@@ -77,7 +78,7 @@ func (c *AliasCommand) listAliases() error {
 
 	for _, name := range names {
 		cmd := c.config.Aliases[name]
-		fmt.Fprintf(c.stdout, "alias %s='%s'\n", name, cmd)
+		fmt.Fprintf(stdout, "alias %s='%s'\n", name, cmd)
 	}
 
 	return nil
@@ -2,376 +2,185 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"regexp"
-	"strconv"
 	"strings"
 
+	"github.com/Lingbou/Lish/internal/awk"
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
 	flag "github.com/spf13/pflag"
 )
 
-// AwkCommand awk 命令 - 文本处理（基础版）
-type AwkCommand struct{}
+// AwkCommand awk 命令 - 模式扫描和文本处理语言
+type AwkCommand struct {
+	executor *script.Executor
+}
+
+// NewAwkCommand 创建 awk 命令，executor 用于执行 system() 和管道中的命令
+func NewAwkCommand(executor *script.Executor) *AwkCommand {
+	return &AwkCommand{
+		executor: executor,
+	}
+}
 
 func (c *AwkCommand) Name() string {
 	return "awk"
 }
 
 func (c *AwkCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
 	flags := flag.NewFlagSet("awk", flag.ContinueOnError)
-	fieldSep := flags.StringP("field-separator", "F", " ", "字段分隔符")
+	flags.SetInterspersed(false)
+	fieldSep := flags.StringP("field-separator", "F", "", "字段分隔符")
+	assigns := flags.StringArrayP("assign", "v", nil, "在 BEGIN 之前给变量赋值")
+	progFiles := flags.StringArrayP("file", "f", nil, "从文件读取程序")
 
 	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
 		return err
 	}
+	operands := flags.Args()
 
-	remaining := flags.Args()
-
-	if len(remaining) == 0 {
-		return fmt.Errorf("用法: awk [-F sep] 'pattern { action }' [file...]")
-	}
-
-	// 解析awk脚本
-	script := remaining[0]
-	filenames := remaining[1:]
-
-	program, err := parseAwkProgram(script)
-	if err != nil {
-		return err
-	}
-
-	// 读取输入
-	var lines []string
-
-	if len(filenames) == 0 {
-		// 从标准输入读取
-		lines, err = readLines(os.Stdin)
-		if err != nil {
-			return fmt.Errorf("读取输入失败: %w", err)
-		}
-	} else {
-		// 从文件读取
-		for _, filename := range filenames {
-			fileLines, err := readLinesFromFile(filename)
+	var source string
+	if len(*progFiles) > 0 {
+		var parts []string
+		for _, name := range *progFiles {
+			data, err := os.ReadFile(name)
 			if err != nil {
-				return fmt.Errorf("读取文件 %s 失败: %w", filename, err)
+				return fmt.Errorf("awk: 无法读取程序文件 %s: %w", name, err)
 			}
-			lines = append(lines, fileLines...)
-		}
-	}
-
-	// 执行awk程序
-	return executeAwkProgram(program, lines, *fieldSep)
-}
-
-// awkProgram awk程序结构
-type awkProgram struct {
-	pattern string   // 匹配模式（可选）
-	actions []string // 操作列表
-}
-
-// parseAwkProgram 解析awk程序
-func parseAwkProgram(script string) (*awkProgram, error) {
-	prog := &awkProgram{}
-
-	script = strings.TrimSpace(script)
-
-	// 检查是否有模式
-	if strings.Contains(script, "{") {
-		// 有 pattern { action } 形式
-		parts := strings.SplitN(script, "{", 2)
-		prog.pattern = strings.TrimSpace(parts[0])
-
-		if len(parts) > 1 {
-			actionPart := strings.TrimSuffix(parts[1], "}")
-			prog.actions = parseActions(actionPart)
+			parts = append(parts, string(data))
 		}
+		source = strings.Join(parts, "\n")
 	} else {
-		// 只有 action，没有 pattern
-		prog.actions = parseActions(script)
-	}
-
-	return prog, nil
-}
-
-// parseActions 解析操作
-func parseActions(actionStr string) []string {
-	// 简单分割，按分号或换行分割
-	actions := []string{}
-	for _, action := range strings.Split(actionStr, ";") {
-		action = strings.TrimSpace(action)
-		if action != "" {
-			actions = append(actions, action)
+		if len(operands) == 0 {
+			return fmt.Errorf("用法: awk [-F sep] [-v var=value] 'program' [file...]")
 		}
+		source, operands = operands[0], operands[1:]
 	}
-	return actions
-}
-
-// awkContext awk执行上下文
-type awkContext struct {
-	fields []string          // 当前行的字段
-	NR     int               // 行号
-	NF     int               // 字段数
-	FS     string            // 字段分隔符
-	vars   map[string]string // 变量
-}
 
-// executeAwkProgram 执行awk程序
-func executeAwkProgram(prog *awkProgram, lines []string, fieldSep string) error {
-	ctx := &awkContext{
-		FS:   fieldSep,
-		vars: make(map[string]string),
+	program, err := awk.Parse(source)
+	if err != nil {
+		return fmt.Errorf("awk: %w", err)
 	}
 
-	for lineNum, line := range lines {
-		ctx.NR = lineNum + 1
-
-		// 分割字段
-		if fieldSep == " " {
-			ctx.fields = strings.Fields(line)
-		} else {
-			ctx.fields = strings.Split(line, fieldSep)
+	vars := *assigns
+	if flags.Changed("field-separator") {
+		// -F t 表示制表符
+		fs := *fieldSep
+		if fs == "t" {
+			fs = "\\t"
 		}
-		ctx.NF = len(ctx.fields)
-
-		// 检查模式匹配
-		if prog.pattern != "" {
-			matched, err := matchPattern(prog.pattern, line, ctx)
-			if err != nil {
-				return err
+		vars = append([]string{"FS=" + fs}, vars...)
+	}
+
+	executor := script.ExecutorFrom(ctx, c.executor)
+	config := &awk.Config{
+		Stdin:   std.Stdin,
+		Stdout:  std.Stdout,
+		Stderr:  std.Stderr,
+		Args:    operands,
+		Vars:    vars,
+		Environ: executor.Environ(),
+		Exec: func(command string, stdin io.Reader, stdout io.Writer) (int, error) {
+			cmdCtx := streams.With(ctx, &streams.Streams{Stdin: stdin, Stdout: stdout, Stderr: std.Stderr})
+			err := executor.ExecuteLine(cmdCtx, command)
+			if err != nil && !script.IsSilent(err) {
+				fmt.Fprintf(std.Stderr, "awk: %v\n", err)
 			}
-			if !matched {
-				continue
-			}
-		}
-
-		// 执行操作
-		for _, action := range prog.actions {
-			if err := executeAction(action, line, ctx); err != nil {
-				return err
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return -1, ctxErr
 			}
-		}
-	}
-
-	return nil
-}
-
-// matchPattern 匹配模式
-func matchPattern(pattern string, line string, ctx *awkContext) (bool, error) {
-	// 正则表达式模式
-	if strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
-		regexStr := strings.Trim(pattern, "/")
-		regex, err := regexp.Compile(regexStr)
-		if err != nil {
-			return false, err
-		}
-		return regex.MatchString(line), nil
+			return script.ExitCodeOf(err), nil
+		},
 	}
 
-	// 条件表达式（简单实现）
-	// 支持 $1 == "value" 这样的比较
-	if strings.Contains(pattern, "==") {
-		parts := strings.Split(pattern, "==")
-		if len(parts) == 2 {
-			left := evaluateExpression(strings.TrimSpace(parts[0]), ctx)
-			right := strings.Trim(strings.TrimSpace(parts[1]), "\"'")
-			return left == right, nil
-		}
-	}
-
-	return true, nil
-}
-
-// executeAction 执行操作
-func executeAction(action string, line string, ctx *awkContext) error {
-	action = strings.TrimSpace(action)
-
-	// print 语句
-	if strings.HasPrefix(action, "print") {
-		return executePrint(action, line, ctx)
+	err = program.Run(ctx, config)
+	var exitErr *awk.ExitError
+	if errors.As(err, &exitErr) {
+		return script.ExitStatus(exitErr.Code)
 	}
-
-	// printf 语句
-	if strings.HasPrefix(action, "printf") {
-		return executePrintf(action, ctx)
-	}
-
-	// 变量赋值
-	if strings.Contains(action, "=") && !strings.Contains(action, "==") {
-		return executeAssignment(action, ctx)
-	}
-
-	return fmt.Errorf("不支持的操作: %s", action)
-}
-
-// executePrint 执行print语句
-func executePrint(action string, line string, ctx *awkContext) error {
-	// 去掉 "print" 关键字
-	action = strings.TrimSpace(strings.TrimPrefix(action, "print"))
-
-	if action == "" {
-		// 打印整行
-		fmt.Println(line)
-		return nil
-	}
-
-	// 解析要打印的内容
-	parts := strings.Split(action, ",")
-	var output []string
-
-	for _, part := range parts {
-		part = strings.TrimSpace(part)
-		value := evaluateExpression(part, ctx)
-		output = append(output, value)
-	}
-
-	fmt.Println(strings.Join(output, " "))
-	return nil
-}
-
-// executePrintf 执行printf语句
-func executePrintf(action string, ctx *awkContext) error {
-	// 简单实现，暂不支持复杂格式化
-	action = strings.TrimSpace(strings.TrimPrefix(action, "printf"))
-	action = strings.Trim(action, "()")
-
-	// 分割格式字符串和参数
-	parts := strings.SplitN(action, ",", 2)
-	if len(parts) < 1 {
-		return fmt.Errorf("无效的printf语句")
-	}
-
-	format := strings.Trim(parts[0], "\"'")
-
-	if len(parts) > 1 {
-		args := strings.Split(parts[1], ",")
-		var values []interface{}
-		for _, arg := range args {
-			values = append(values, evaluateExpression(strings.TrimSpace(arg), ctx))
-		}
-		fmt.Printf(format, values...)
-	} else {
-		fmt.Print(format)
-	}
-
-	return nil
-}
-
-// executeAssignment 执行赋值语句
-func executeAssignment(action string, ctx *awkContext) error {
-	parts := strings.Split(action, "=")
-	if len(parts) != 2 {
-		return fmt.Errorf("无效的赋值语句: %s", action)
+	if err != nil {
+		return fmt.Errorf("awk: %w", err)
 	}
-
-	varName := strings.TrimSpace(parts[0])
-	value := evaluateExpression(strings.TrimSpace(parts[1]), ctx)
-	ctx.vars[varName] = value
-
 	return nil
 }
 
-// evaluateExpression 计算表达式
-func evaluateExpression(expr string, ctx *awkContext) string {
-	expr = strings.TrimSpace(expr)
-
-	// $0 - 整行
-	if expr == "$0" {
-		return strings.Join(ctx.fields, ctx.FS)
-	}
-
-	// $1, $2, ... - 字段
-	if strings.HasPrefix(expr, "$") {
-		fieldNumStr := strings.TrimPrefix(expr, "$")
-		fieldNum, err := strconv.Atoi(fieldNumStr)
-		if err == nil && fieldNum > 0 && fieldNum <= ctx.NF {
-			return ctx.fields[fieldNum-1]
-		}
-		return ""
-	}
-
-	// NR - 行号
-	if expr == "NR" {
-		return strconv.Itoa(ctx.NR)
-	}
-
-	// NF - 字段数
-	if expr == "NF" {
-		return strconv.Itoa(ctx.NF)
-	}
-
-	// FS - 字段分隔符
-	if expr == "FS" {
-		return ctx.FS
-	}
-
-	// 变量
-	if val, ok := ctx.vars[expr]; ok {
-		return val
-	}
-
-	// 字符串字面量
-	if strings.HasPrefix(expr, "\"") && strings.HasSuffix(expr, "\"") {
-		return strings.Trim(expr, "\"")
-	}
-	if strings.HasPrefix(expr, "'") && strings.HasSuffix(expr, "'") {
-		return strings.Trim(expr, "'")
-	}
-
-	// 简单算术表达式
-	if strings.Contains(expr, "+") {
-		parts := strings.Split(expr, "+")
-		if len(parts) == 2 {
-			a := evaluateExpression(parts[0], ctx)
-			b := evaluateExpression(parts[1], ctx)
-			na, _ := strconv.Atoi(a)
-			nb, _ := strconv.Atoi(b)
-			return strconv.Itoa(na + nb)
-		}
-	}
-
-	// 默认返回原始表达式
-	return expr
-}
-
 func (c *AwkCommand) Help() string {
-	return `awk - 文本处理工具（基础版）
+	return `awk - 模式扫描和文本处理语言
 
 用法:
-  awk [-F sep] 'pattern { action }' [file...]
-  command | awk 'pattern { action }'
+  awk [选项] 'program' [file...]
+  awk [选项] -f progfile [file...]
+  command | awk 'program'
 
 说明:
-  强大的文本处理工具，支持模式匹配和字段处理。
+  程序由 pattern { action } 规则组成，对每条输入记录依次检查所有规则，
+  pattern 为真时执行 action。省略 pattern 时对所有记录执行，省略 action
+  时输出当前记录。文件参数可以是 var=value 形式的赋值，在读到该参数时执行。
 
 选项:
-  -F, --field-separator   字段分隔符（默认为空格）
+  -F, --field-separator <fs>   字段分隔符，可以是正则表达式（-F t 表示制表符）
+  -v, --assign <var=value>     在 BEGIN 之前给变量赋值，可以多次使用
+  -f, --file <progfile>        从文件读取程序，可以多次使用
+
+模式:
+  BEGIN { ... }         读取输入之前执行
+  END { ... }           所有输入处理完之后执行
+  /regex/               记录匹配正则表达式
+  expression            表达式为真（非 0 或非空字符串）
+  pat1, pat2            从匹配 pat1 的记录到匹配 pat2 的记录（范围）
+
+语句:
+  print expr, ...               输出，用 OFS 分隔、以 ORS 结尾
+  printf fmt, expr, ...         格式化输出
+  print ... > file / >> file    输出到文件（覆盖 / 追加）
+  print ... | "command"         输出作为命令的输入
+  if/else  while  do/while  for (init; cond; post)  for (key in array)
+  break  continue  next  nextfile  exit [code]  delete arr[key]  delete arr
+  function name(params) { ... return expr }
+
+  getline / getline var          读取下一条输入记录
+  getline < file                 从文件读取一行
+  "command" | getline [var]      读取命令的输出
 
 内置变量:
-  $0          当前行的完整内容
-  $1, $2,...  第1个、第2个字段
-  NR          当前行号
-  NF          当前行的字段数
-  FS          字段分隔符
-
-支持的操作:
-  print           打印整行
-  print $1        打印第1个字段
-  print $1, $2    打印多个字段
-  printf "fmt"    格式化打印
+  $0, $1...  当前记录及其字段          NF        当前记录的字段数
+  NR         已读取的记录数              FNR       当前文件中的记录号
+  FS         字段分隔符（默认为空白）    OFS       输出字段分隔符（默认为空格）
+  RS         记录分隔符（默认为换行，为空时按段落读取，否则可以是正则表达式）
+  ORS        输出记录分隔符              FILENAME  当前输入文件名
+  SUBSEP     多维下标的分隔符            RSTART, RLENGTH  match() 的结果
+  CONVFMT    数字转字符串的格式          OFMT      print 输出数字的格式
+  ARGC, ARGV 命令行参数                  ENVIRON   环境变量
+
+内置函数:
+  length([s])  substr(s, m[, n])  index(s, t)  split(s, arr[, fs])
+  sub(re, repl[, target])  gsub(re, repl[, target])  match(s, re[, arr])
+  sprintf(fmt, ...)  tolower(s)  toupper(s)
+  sin  cos  atan2  exp  log  sqrt  int  rand  srand
+  system(cmd)  close(file_or_cmd)  fflush([file])
 
 示例:
-  awk '{ print $1 }' file.txt              # 打印第1列
-  awk '{ print $1, $3 }' file.txt          # 打印第1和第3列
-  awk '{ print NR, $0 }' file.txt          # 打印行号和内容
-  awk 'NR > 1 { print $1 }' file.txt       # 跳过第一行
-  awk -F: '{ print $1 }' /etc/passwd       # 使用:分隔
-  awk '/pattern/ { print $0 }' file.txt    # 只打印匹配行
-  ls -l | awk '{ print $9 }'               # 提取文件名
-  awk '{ sum += $1 } END { print sum }'    # 求和（未完全支持）`
+  awk '{ print $1 }' file.txt                       # 打印第1列
+  awk -F: '$3 >= 1000 { print $1 }' /etc/passwd    # 使用 : 分隔
+  awk 'NR > 1 { sum += $2 } END { print sum }'      # 跳过表头并求和
+  awk '{ count[$1]++ } END { for (k in count) print k, count[k] }'
+  awk '/start/,/end/' log.txt                       # 打印两个模式之间的行
+  awk -v OFS=, '{ $1 = $1; print }' data.txt        # 用逗号重新连接字段
+  awk 'function max(a, b) { return a > b ? a : b } { m = max(m, $1) } END { print m }'
+  ls -l | awk '{ printf "%-20s %8d\n", $9, $5 }'    # 格式化输出
+
+退出码:
+  0 成功，exit 语句指定的退出码，出错时为 1`
 }
 
 func (c *AwkCommand) ShortHelp() string {
-	return "文本处理和字段提取"
+	return "模式扫描和文本处理语言"
 }
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+)
+
+// BuiltinCommand builtin 命令 - 执行内置命令，忽略同名函数
+type BuiltinCommand struct {
+	registry *Registry
+}
+
+// NewBuiltinCommand 创建 builtin 命令
+func NewBuiltinCommand(registry *Registry) *BuiltinCommand {
+	return &BuiltinCommand{
+		registry: registry,
+	}
+}
+
+func (c *BuiltinCommand) Name() string {
+	return "builtin"
+}
+
+func (c *BuiltinCommand) Execute(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+
+	cmd, ok := c.registry.Get(args[0])
+	if !ok {
+		return fmt.Errorf("builtin: %s: 不是内置命令", args[0])
+	}
+	return cmd.Execute(ctx, args[1:])
+}
+
+func (c *BuiltinCommand) Help() string {
+	return `builtin - 执行内置命令
+
+用法:
+  builtin 命令名 [参数...]
+
+说明:
+  执行指定的内置命令，跳过同名的函数和外部命令。常用于在覆盖了
+  内置命令的函数中调用原来的命令。命令不是内置命令时报错。
+
+示例:
+  cd() {
+      builtin cd "$@" && echo "当前目录: $PWD"
+  }`
+}
+
+func (c *BuiltinCommand) ShortHelp() string {
+	return "执行内置命令"
+}
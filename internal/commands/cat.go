@@ -3,15 +3,15 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os"
+	"io"
+
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
-type CatCommand struct {
-	stdout *os.File
-}
+type CatCommand struct{}
 
-func NewCatCommand(stdout *os.File) *CatCommand {
-	return &CatCommand{stdout: stdout}
+func NewCatCommand() *CatCommand {
+	return &CatCommand{}
 }
 
 func (c *CatCommand) Name() string {
@@ -19,17 +19,24 @@ func (c *CatCommand) Name() string {
 }
 
 func (c *CatCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
+	// 没有指定文件时复制标准输入（用于管道）
 	if len(args) == 0 {
-		return fmt.Errorf("请指定至少一个文件")
+		_, err := io.Copy(stdout, streams.Stdin(ctx))
+		return err
 	}
 	
 	for _, filename := range args {
-		content, err := os.ReadFile(filename)
+		file, err := openInput(ctx, filename)
+		if err != nil {
+			return fmt.Errorf("读取文件 %s 失败: %w", filename, err)
+		}
+		_, err = io.Copy(stdout, file)
+		file.Close()
 		if err != nil {
 			return fmt.Errorf("读取文件 %s 失败: %w", filename, err)
 		}
-		
-		fmt.Fprint(c.stdout, string(content))
 	}
 	
 	return nil
@@ -42,11 +49,13 @@ func (c *CatCommand) Help() string {
   cat [文件...]
 
 描述:
-  连接文件并在标准输出上显示内容。
+  连接文件并在标准输出上显示内容。未指定文件或文件为 - 时读取标准输入。
+  文件内容边读边输出，不会整个读入内存。
 
 示例:
   cat file.txt           # 显示文件内容
-  cat file1.txt file2.txt # 显示多个文件内容`
+  cat file1.txt file2.txt # 显示多个文件内容
+  echo 标题 | cat - body.txt  # 在文件内容前加上标准输入`
 }
 
 func (c *CatCommand) ShortHelp() string {
@@ -3,10 +3,12 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"strconv"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	flag "github.com/spf13/pflag"
 )
 
@@ -18,6 +20,9 @@ func (c *ChmodCommand) Name() string {
 }
 
 func (c *ChmodCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+	stderr := streams.Stderr(ctx)
+
 	flags := flag.NewFlagSet("chmod", flag.ContinueOnError)
 	recursive := flags.BoolP("recursive", "R", false, "递归修改目录")
 	verbose := flags.BoolP("verbose", "v", false, "显示详细信息")
@@ -43,8 +48,8 @@ func (c *ChmodCommand) Execute(ctx context.Context, args []string) error {
 
 	// 修改文件权限
 	for _, file := range files {
-		if err := chmodFile(file, perm, isWindows, *recursive, *verbose); err != nil {
-			fmt.Fprintf(os.Stderr, "chmod: %s: %v\n", file, err)
+		if err := chmodFile(file, perm, isWindows, *recursive, *verbose, stdout, stderr); err != nil {
+			fmt.Fprintf(stderr, "chmod: %s: %v\n", file, err)
 		}
 	}
 
@@ -136,7 +141,7 @@ func parseSymbolicRemove(perm string) os.FileMode {
 }
 
 // chmodFile 修改文件权限
-func chmodFile(path string, perm os.FileMode, isWindowsMode bool, recursive bool, verbose bool) error {
+func chmodFile(path string, perm os.FileMode, isWindowsMode bool, recursive bool, verbose bool, stdout io.Writer, stderr io.Writer) error {
 	info, err := os.Stat(path)
 	if err != nil {
 		return err
@@ -144,7 +149,7 @@ func chmodFile(path string, perm os.FileMode, isWindowsMode bool, recursive bool
 
 	// Windows 特殊处理
 	if runtime.GOOS == "windows" && isWindowsMode {
-		return chmodWindows(path, perm, verbose)
+		return chmodWindows(path, perm, verbose, stdout)
 	}
 
 	// Unix 风格权限
@@ -153,7 +158,7 @@ func chmodFile(path string, perm os.FileMode, isWindowsMode bool, recursive bool
 	}
 
 	if verbose {
-		fmt.Printf("chmod: %s: 权限已修改为 %o\n", path, perm)
+		fmt.Fprintf(stdout, "chmod: %s: 权限已修改为 %o\n", path, perm)
 	}
 
 	// 递归处理目录
@@ -165,8 +170,8 @@ func chmodFile(path string, perm os.FileMode, isWindowsMode bool, recursive bool
 
 		for _, entry := range entries {
 			subPath := path + string(os.PathSeparator) + entry.Name()
-			if err := chmodFile(subPath, perm, isWindowsMode, recursive, verbose); err != nil {
-				fmt.Fprintf(os.Stderr, "chmod: %s: %v\n", subPath, err)
+			if err := chmodFile(subPath, perm, isWindowsMode, recursive, verbose, stdout, stderr); err != nil {
+				fmt.Fprintf(stderr, "chmod: %s: %v\n", subPath, err)
 			}
 		}
 	}
@@ -175,7 +180,7 @@ func chmodFile(path string, perm os.FileMode, isWindowsMode bool, recursive bool
 }
 
 // chmodWindows Windows 文件属性处理
-func chmodWindows(path string, perm os.FileMode, verbose bool) error {
+func chmodWindows(path string, perm os.FileMode, verbose bool, stdout io.Writer) error {
 	// Windows 上，我们使用 os.Chmod 来设置只读属性
 	// perm == 0444 表示只读，0666 表示可读可写
 
@@ -185,9 +190,9 @@ func chmodWindows(path string, perm os.FileMode, verbose bool) error {
 
 	if verbose {
 		if perm == 0444 {
-			fmt.Printf("chmod: %s: 已设置为只读\n", path)
+			fmt.Fprintf(stdout, "chmod: %s: 已设置为只读\n", path)
 		} else {
-			fmt.Printf("chmod: %s: 已设置为可读写\n", path)
+			fmt.Fprintf(stdout, "chmod: %s: 已设置为可读写\n", path)
 		}
 	}
 
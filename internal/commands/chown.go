@@ -3,11 +3,13 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"strconv"
 	"strings"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	flag "github.com/spf13/pflag"
 )
 
@@ -19,6 +21,9 @@ func (c *ChownCommand) Name() string {
 }
 
 func (c *ChownCommand) Execute(ctx context.Context, args []string) error {
+	stderr := streams.Stderr(ctx)
+	stdout := streams.Stdout(ctx)
+
 	flags := flag.NewFlagSet("chown", flag.ContinueOnError)
 	recursive := flags.BoolP("recursive", "R", false, "递归修改目录")
 	verbose := flags.BoolP("verbose", "v", false, "显示详细信息")
@@ -38,10 +43,10 @@ func (c *ChownCommand) Execute(ctx context.Context, args []string) error {
 
 	// Windows 平台提示
 	if runtime.GOOS == "windows" {
-		fmt.Println("⚠️  注意: Windows 平台的 chown 功能有限")
-		fmt.Println("   修改文件所有者需要管理员权限")
-		fmt.Println("   当前版本仅显示文件信息，不执行实际修改")
-		fmt.Println()
+		fmt.Fprintln(stdout, "⚠️  注意: Windows 平台的 chown 功能有限")
+		fmt.Fprintln(stdout, "   修改文件所有者需要管理员权限")
+		fmt.Fprintln(stdout, "   当前版本仅显示文件信息，不执行实际修改")
+		fmt.Fprintln(stdout)
 	}
 
 	// 解析所有者信息
@@ -49,8 +54,8 @@ func (c *ChownCommand) Execute(ctx context.Context, args []string) error {
 
 	// 修改文件所有者
 	for _, file := range files {
-		if err := chownFile(file, user, group, *recursive, *verbose); err != nil {
-			fmt.Fprintf(os.Stderr, "chown: %s: %v\n", file, err)
+		if err := chownFile(file, user, group, *recursive, *verbose, stdout, stderr); err != nil {
+			fmt.Fprintf(stderr, "chown: %s: %v\n", file, err)
 		}
 	}
 
@@ -69,7 +74,7 @@ func parseOwner(owner string) (string, string) {
 }
 
 // chownFile 修改文件所有者
-func chownFile(path string, user string, group string, recursive bool, verbose bool) error {
+func chownFile(path string, user string, group string, recursive bool, verbose bool, stdout io.Writer, stderr io.Writer) error {
 	info, err := os.Stat(path)
 	if err != nil {
 		return err
@@ -77,15 +82,15 @@ func chownFile(path string, user string, group string, recursive bool, verbose b
 
 	// Windows 平台处理
 	if runtime.GOOS == "windows" {
-		return chownWindows(path, user, group)
+		return chownWindows(path, user, group, stdout)
 	}
 
 	// Unix 平台处理
-	return chownUnix(path, user, group, info, recursive, verbose)
+	return chownUnix(path, user, group, info, recursive, verbose, stdout, stderr)
 }
 
 // chownWindows Windows 平台处理
-func chownWindows(path string, user string, group string) error {
+func chownWindows(path string, user string, group string, stdout io.Writer) error {
 	// Windows 上修改文件所有者需要使用 Windows API
 	// 这里只显示信息，不执行实际修改
 	info, err := os.Stat(path)
@@ -93,28 +98,28 @@ func chownWindows(path string, user string, group string) error {
 		return err
 	}
 
-	fmt.Printf("%s:\n", path)
-	fmt.Printf("  类型: ")
+	fmt.Fprintf(stdout, "%s:\n", path)
+	fmt.Fprintf(stdout, "  类型: ")
 	if info.IsDir() {
-		fmt.Println("目录")
+		fmt.Fprintln(stdout, "目录")
 	} else {
-		fmt.Println("文件")
+		fmt.Fprintln(stdout, "文件")
 	}
-	fmt.Printf("  大小: %d 字节\n", info.Size())
-	fmt.Printf("  修改时间: %s\n", info.ModTime().Format("2006-01-02 15:04:05"))
-	fmt.Printf("  请求更改所有者为: %s", user)
+	fmt.Fprintf(stdout, "  大小: %d 字节\n", info.Size())
+	fmt.Fprintf(stdout, "  修改时间: %s\n", info.ModTime().Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(stdout, "  请求更改所有者为: %s", user)
 	if group != "" {
-		fmt.Printf(":%s", group)
+		fmt.Fprintf(stdout, ":%s", group)
 	}
-	fmt.Println()
-	fmt.Println("  (Windows 平台需要管理员权限，当前未执行)")
-	fmt.Println()
+	fmt.Fprintln(stdout)
+	fmt.Fprintln(stdout, "  (Windows 平台需要管理员权限，当前未执行)")
+	fmt.Fprintln(stdout)
 
 	return nil
 }
 
 // chownUnix Unix 平台处理
-func chownUnix(path string, user string, group string, info os.FileInfo, recursive bool, verbose bool) error {
+func chownUnix(path string, user string, group string, info os.FileInfo, recursive bool, verbose bool, stdout io.Writer, stderr io.Writer) error {
 	// 解析 UID 和 GID
 	uid := -1
 	gid := -1
@@ -144,11 +149,11 @@ func chownUnix(path string, user string, group string, info os.FileInfo, recursi
 	}
 
 	if verbose {
-		fmt.Printf("chown: %s: 所有者已修改为 %s", path, user)
+		fmt.Fprintf(stdout, "chown: %s: 所有者已修改为 %s", path, user)
 		if group != "" {
-			fmt.Printf(":%s", group)
+			fmt.Fprintf(stdout, ":%s", group)
 		}
-		fmt.Println()
+		fmt.Fprintln(stdout)
 	}
 
 	// 递归处理目录
@@ -162,11 +167,11 @@ func chownUnix(path string, user string, group string, info os.FileInfo, recursi
 			subPath := path + string(os.PathSeparator) + entry.Name()
 			subInfo, err := entry.Info()
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "chown: %s: %v\n", subPath, err)
+				fmt.Fprintf(stderr, "chown: %s: %v\n", subPath, err)
 				continue
 			}
-			if err := chownUnix(subPath, user, group, subInfo, recursive, verbose); err != nil {
-				fmt.Fprintf(os.Stderr, "chown: %s: %v\n", subPath, err)
+			if err := chownUnix(subPath, user, group, subInfo, recursive, verbose, stdout, stderr); err != nil {
+				fmt.Fprintf(stderr, "chown: %s: %v\n", subPath, err)
 			}
 		}
 	}
@@ -3,15 +3,14 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os"
+
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
-type ClearCommand struct {
-	stdout *os.File
-}
+type ClearCommand struct{}
 
-func NewClearCommand(stdout *os.File) *ClearCommand {
-	return &ClearCommand{stdout: stdout}
+func NewClearCommand() *ClearCommand {
+	return &ClearCommand{}
 }
 
 func (c *ClearCommand) Name() string {
@@ -19,8 +18,10 @@ func (c *ClearCommand) Name() string {
 }
 
 func (c *ClearCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	// 使用 ANSI 转义码清屏
-	fmt.Fprint(c.stdout, "\033[2J\033[H")
+	fmt.Fprint(stdout, "\033[2J\033[H")
 	return nil
 }
 
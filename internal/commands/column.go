@@ -0,0 +1,234 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// ColumnCommand column 命令 - 把输入排成列或对齐为表格
+type ColumnCommand struct{}
+
+// NewColumnCommand 创建 column 命令
+func NewColumnCommand() *ColumnCommand {
+	return &ColumnCommand{}
+}
+
+func (c *ColumnCommand) Name() string {
+	return "column"
+}
+
+func (c *ColumnCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
+	flags := flag.NewFlagSet("column", flag.ContinueOnError)
+	table := flags.BoolP("table", "t", false, "对齐为表格")
+	separators := flags.StringP("separator", "s", "", "表格的字段分隔符（其中任意字符）")
+	outSep := flags.StringP("output-separator", "o", "  ", "表格列之间的分隔符")
+	right := flags.StringP("table-right", "R", "", "右对齐的列（逗号分隔的列号）")
+	width := flags.IntP("output-width", "c", 0, "输出宽度")
+	fillRows := flags.BoolP("fillrows", "x", false, "先填满行再换列")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	// 需要知道所有行的宽度，因此先读取全部输入
+	var lines []string
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	for _, name := range files {
+		file, err := openInput(ctx, name)
+		if err != nil {
+			return fmt.Errorf("column: %w", err)
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		for scanner.Scan() {
+			// 和 util-linux 一样忽略空行
+			if strings.TrimSpace(scanner.Text()) != "" {
+				lines = append(lines, scanner.Text())
+			}
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("column: %s: %w", inputName(name), err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	out := bufio.NewWriter(std.Stdout)
+	defer out.Flush()
+	if *table {
+		rightAligned := make(map[int]bool)
+		if *right != "" {
+			cols, err := parseFieldList(*right)
+			if err != nil {
+				return fmt.Errorf("column: %w", err)
+			}
+			for _, col := range cols {
+				rightAligned[col-1] = true
+			}
+		}
+		formatTable(out, lines, *separators, *outSep, rightAligned)
+	} else {
+		if *width <= 0 {
+			*width = outputWidth()
+		}
+		fillColumns(out, lines, *width, *fillRows)
+	}
+	return out.Flush()
+}
+
+// outputWidth 返回输出宽度：环境变量 COLUMNS，默认 80
+func outputWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
+
+// formatTable 把每行分成字段，按显示宽度对齐各列（中日韩字符宽度为 2）
+//
+// seps 为空时按连续的空白分隔；否则其中的任意字符都是分隔符，相邻的分隔符之间是空字段。
+func formatTable(w io.Writer, lines []string, seps, outSep string, right map[int]bool) {
+	rows := make([][]string, len(lines))
+	var widths []int
+	for i, line := range lines {
+		if seps == "" {
+			rows[i] = strings.Fields(line)
+		} else {
+			rows[i] = splitAny(line, seps)
+		}
+		for col, cell := range rows[i] {
+			if col == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[col] = max(widths[col], displayWidth(cell))
+		}
+	}
+
+	for _, row := range rows {
+		var sb strings.Builder
+		for col, cell := range row {
+			if col > 0 {
+				sb.WriteString(outSep)
+			}
+			pad := strings.Repeat(" ", widths[col]-displayWidth(cell))
+			switch {
+			case right[col]:
+				sb.WriteString(pad + cell)
+			case col == len(row)-1:
+				// 最后一列不补空格
+				sb.WriteString(cell)
+			default:
+				sb.WriteString(cell + pad)
+			}
+		}
+		sb.WriteByte('\n')
+		io.WriteString(w, sb.String())
+	}
+}
+
+// splitAny 用 seps 中的任意字符分割字符串，保留空字段
+func splitAny(s, seps string) []string {
+	var fields []string
+	start := 0
+	for i, r := range s {
+		if strings.ContainsRune(seps, r) {
+			fields = append(fields, s[start:i])
+			start = i + len(string(r))
+		}
+	}
+	return append(fields, s[start:])
+}
+
+// fillColumns 把各项排成多列，使总宽度不超过 width；默认先填满列（与 ls 相同）
+func fillColumns(w io.Writer, items []string, width int, fillRows bool) {
+	if len(items) == 0 {
+		return
+	}
+	// 每列宽度对齐到 8 的倍数（与 util-linux 一样按制表位对齐）
+	maxWidth := 0
+	for _, item := range items {
+		maxWidth = max(maxWidth, displayWidth(item))
+	}
+	colWidth := (maxWidth + 8) &^ 7
+	cols := max(width/colWidth, 1)
+	rows := (len(items) + cols - 1) / cols
+
+	for r := 0; r < rows; r++ {
+		var sb strings.Builder
+		for c := 0; c < cols; c++ {
+			i := c*rows + r
+			if fillRows {
+				i = r*cols + c
+			}
+			if i >= len(items) {
+				break
+			}
+			item := items[i]
+			sb.WriteString(item)
+			next := i + rows
+			if fillRows {
+				next = i + 1
+			}
+			if c < cols-1 && next < len(items) {
+				// 用制表符补齐到下一列
+				for pos := displayWidth(item); pos < colWidth; pos = (pos + 8) &^ 7 {
+					sb.WriteByte('\t')
+				}
+			}
+		}
+		sb.WriteByte('\n')
+		io.WriteString(w, sb.String())
+	}
+}
+
+func (c *ColumnCommand) Help() string {
+	return `column - 把输入排成列或对齐为表格
+
+用法:
+  column [选项] [文件...]
+
+选项:
+  -t, --table                  把每行分成字段，对齐为表格
+  -s, --separator=字符         表格的字段分隔符，其中任意字符都是分隔符
+                               （默认按连续的空白分隔）
+  -o, --output-separator=串    表格列之间的分隔符（默认两个空格）
+  -R, --table-right=列表       右对齐的列，如 2,3
+  -c, --output-width=N         不使用 -t 时的输出宽度（默认为环境变量
+                               COLUMNS 或 80）
+  -x, --fillrows               不使用 -t 时先填满行再换列
+
+描述:
+  不使用 -t 时把每一行作为一项，排成尽量多的列（类似 ls 的输出）。
+  使用 -t 时按显示宽度对齐各列，中文等宽字符占两列，因此包含中文的
+  表格也能对齐。空行被忽略。没有文件或文件为 - 时读取标准输入。
+
+示例:
+  mount | column -t                 # 对齐 mount 的输出
+  column -t -s, data.csv            # 把 CSV 显示为表格
+  column -t -s: -o ' | ' /etc/passwd
+  printf '名称 数量\n苹果 3\n香蕉 12\n' | column -t -R 2
+  seq 1 100 | column -c 60          # 排成多列`
+}
+
+func (c *ColumnCommand) ShortHelp() string {
+	return "排成列或对齐为表格"
+}
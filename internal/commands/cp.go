@@ -7,15 +7,14 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type CpCommand struct {
-	stdout *os.File
-}
+type CpCommand struct{}
 
-func NewCpCommand(stdout *os.File) *CpCommand {
-	return &CpCommand{stdout: stdout}
+func NewCpCommand() *CpCommand {
+	return &CpCommand{}
 }
 
 func (c *CpCommand) Name() string {
@@ -23,6 +22,8 @@ func (c *CpCommand) Name() string {
 }
 
 func (c *CpCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := pflag.NewFlagSet("cp", pflag.ContinueOnError)
 	recursive := flags.BoolP("recursive", "r", false, "递归复制目录")
 	verbose := flags.BoolP("verbose", "v", false, "显示详细信息")
@@ -52,13 +53,13 @@ func (c *CpCommand) Execute(ctx context.Context, args []string) error {
 
 	// 复制
 	if srcInfo.IsDir() {
-		return c.copyDir(src, dst, *verbose)
+		return c.copyDir(src, dst, *verbose, stdout)
 	}
 
-	return c.copyFile(src, dst, *verbose)
+	return c.copyFile(src, dst, *verbose, stdout)
 }
 
-func (c *CpCommand) copyFile(src, dst string, verbose bool) error {
+func (c *CpCommand) copyFile(src, dst string, verbose bool, stdout io.Writer) error {
 	// 打开源文件
 	srcFile, err := os.Open(src)
 	if err != nil {
@@ -91,13 +92,13 @@ func (c *CpCommand) copyFile(src, dst string, verbose bool) error {
 	os.Chmod(dst, srcInfo.Mode())
 
 	if verbose {
-		fmt.Fprintf(c.stdout, "'%s' -> '%s'\n", src, dst)
+		fmt.Fprintf(stdout, "'%s' -> '%s'\n", src, dst)
 	}
 
 	return nil
 }
 
-func (c *CpCommand) copyDir(src, dst string, verbose bool) error {
+func (c *CpCommand) copyDir(src, dst string, verbose bool, stdout io.Writer) error {
 	// 获取源目录信息
 	srcInfo, err := os.Stat(src)
 	if err != nil {
@@ -110,7 +111,7 @@ func (c *CpCommand) copyDir(src, dst string, verbose bool) error {
 	}
 
 	if verbose {
-		fmt.Fprintf(c.stdout, "'%s' -> '%s'\n", src, dst)
+		fmt.Fprintf(stdout, "'%s' -> '%s'\n", src, dst)
 	}
 
 	// 读取源目录内容
@@ -125,11 +126,11 @@ func (c *CpCommand) copyDir(src, dst string, verbose bool) error {
 		dstPath := filepath.Join(dst, entry.Name())
 
 		if entry.IsDir() {
-			if err := c.copyDir(srcPath, dstPath, verbose); err != nil {
+			if err := c.copyDir(srcPath, dstPath, verbose, stdout); err != nil {
 				return err
 			}
 		} else {
-			if err := c.copyFile(srcPath, dstPath, verbose); err != nil {
+			if err := c.copyFile(srcPath, dstPath, verbose, stdout); err != nil {
 				return err
 			}
 		}
@@ -8,15 +8,14 @@ import (
 	"os"
 	"time"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type CurlCommand struct {
-	stdout *os.File
-}
+type CurlCommand struct{}
 
-func NewCurlCommand(stdout *os.File) *CurlCommand {
-	return &CurlCommand{stdout: stdout}
+func NewCurlCommand() *CurlCommand {
+	return &CurlCommand{}
 }
 
 func (c *CurlCommand) Name() string {
@@ -24,6 +23,9 @@ func (c *CurlCommand) Name() string {
 }
 
 func (c *CurlCommand) Execute(ctx context.Context, args []string) error {
+	stderr := streams.Stderr(ctx)
+	stdout := streams.Stdout(ctx)
+
 	flags := pflag.NewFlagSet("curl", pflag.ContinueOnError)
 	method := flags.StringP("request", "X", "GET", "HTTP 方法")
 	output := flags.StringP("output", "o", "", "保存到文件")
@@ -75,7 +77,7 @@ func (c *CurlCommand) Execute(ctx context.Context, args []string) error {
 	defer resp.Body.Close()
 
 	// 读取响应
-	var writer io.Writer = c.stdout
+	var writer io.Writer = stdout
 
 	// 如果指定了输出文件
 	if *output != "" {
@@ -88,7 +90,7 @@ func (c *CurlCommand) Execute(ctx context.Context, args []string) error {
 	}
 
 	// 显示状态码
-	fmt.Fprintf(os.Stderr, "HTTP/%d.%d %s\n", resp.ProtoMajor, resp.ProtoMinor, resp.Status)
+	fmt.Fprintf(stderr, "HTTP/%d.%d %s\n", resp.ProtoMajor, resp.ProtoMinor, resp.Status)
 
 	// 复制响应体
 	_, err = io.Copy(writer, resp.Body)
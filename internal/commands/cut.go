@@ -0,0 +1,307 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// CutCommand cut 命令 - 从每行中选取字节、字符或字段
+type CutCommand struct{}
+
+// NewCutCommand 创建 cut 命令
+func NewCutCommand() *CutCommand {
+	return &CutCommand{}
+}
+
+func (c *CutCommand) Name() string {
+	return "cut"
+}
+
+// cutRange 选取的范围，从 1 开始，包含两端
+type cutRange struct {
+	lo, hi int
+}
+
+// cutter 保存一次 cut 调用的选项
+type cutter struct {
+	mode          byte // 'b'、'c' 或 'f'
+	ranges        []cutRange
+	complement    bool
+	delim         string
+	outDelim      string
+	outDelimSet   bool
+	onlyDelimited bool
+}
+
+func (c *CutCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
+	flags := flag.NewFlagSet("cut", flag.ContinueOnError)
+	byteList := flags.StringP("bytes", "b", "", "选取的字节")
+	charList := flags.StringP("characters", "c", "", "选取的字符")
+	fieldList := flags.StringP("fields", "f", "", "选取的字段")
+	delim := flags.StringP("delimiter", "d", "\t", "字段分隔符")
+	onlyDelimited := flags.BoolP("only-delimited", "s", false, "不输出不包含分隔符的行")
+	complement := flags.Bool("complement", false, "选取未列出的部分")
+	outDelim := flags.String("output-delimiter", "", "输出的分隔符")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	cu := &cutter{
+		complement:    *complement,
+		delim:         *delim,
+		outDelim:      *outDelim,
+		outDelimSet:   flags.Changed("output-delimiter"),
+		onlyDelimited: *onlyDelimited,
+	}
+	var spec string
+	for _, m := range []struct {
+		mode byte
+		name string
+		list string
+	}{{'b', "bytes", *byteList}, {'c', "characters", *charList}, {'f', "fields", *fieldList}} {
+		if !flags.Changed(m.name) {
+			continue
+		}
+		if cu.mode != 0 {
+			return fmt.Errorf("cut: 只能指定 -b、-c、-f 中的一个")
+		}
+		cu.mode, spec = m.mode, m.list
+	}
+	if cu.mode == 0 {
+		return fmt.Errorf("cut: 必须指定 -b、-c 或 -f")
+	}
+	if cu.mode != 'f' && (flags.Changed("delimiter") || cu.onlyDelimited) {
+		return fmt.Errorf("cut: -d 和 -s 只能与 -f 一起使用")
+	}
+	if utf8.RuneCountInString(cu.delim) != 1 {
+		return fmt.Errorf("cut: 分隔符必须是单个字符")
+	}
+	if !cu.outDelimSet && cu.mode == 'f' {
+		cu.outDelim = cu.delim
+	}
+	ranges, err := parseCutList(spec)
+	if err != nil {
+		return fmt.Errorf("cut: %w", err)
+	}
+	cu.ranges = ranges
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	out := bufio.NewWriter(std.Stdout)
+	defer out.Flush()
+
+	failed := false
+	for _, name := range files {
+		file, err := openInput(ctx, name)
+		if err != nil {
+			fmt.Fprintf(std.Stderr, "cut: %v\n", err)
+			failed = true
+			continue
+		}
+		err = cu.process(ctx, newLineStream(file, out))
+		file.Close()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(std.Stderr, "cut: %s: %v\n", inputName(name), err)
+			failed = true
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	if failed {
+		return script.ExitStatus(1)
+	}
+	return nil
+}
+
+// parseCutList 解析范围列表（如 1,3-5,7-、-2），返回排序并合并后的范围
+func parseCutList(spec string) ([]cutRange, error) {
+	var ranges []cutRange
+	for _, part := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ' ' }) {
+		r := cutRange{lo: 1, hi: math.MaxInt}
+		lo, hi, isRange := strings.Cut(part, "-")
+		var err error
+		if lo != "" {
+			if r.lo, err = strconv.Atoi(lo); err != nil || r.lo < 1 {
+				return nil, fmt.Errorf("无效的范围: %s", part)
+			}
+		}
+		switch {
+		case !isRange:
+			r.hi = r.lo
+		case hi != "":
+			if r.hi, err = strconv.Atoi(hi); err != nil || r.hi < 1 {
+				return nil, fmt.Errorf("无效的范围: %s", part)
+			}
+		case lo == "":
+			return nil, fmt.Errorf("无效的范围: %s", part)
+		}
+		if r.hi < r.lo {
+			return nil, fmt.Errorf("无效的递减范围: %s", part)
+		}
+		ranges = append(ranges, r)
+	}
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("缺少范围列表")
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].lo < ranges[j].lo })
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.lo <= last.hi || last.hi != math.MaxInt && r.lo == last.hi+1 {
+			last.hi = max(last.hi, r.hi)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged, nil
+}
+
+// selected 返回第 i 个位置是否被选取，以及它属于哪个范围（用于在范围之间插入输出分隔符）
+func (cu *cutter) selected(i int) (bool, int) {
+	for k, r := range cu.ranges {
+		if i < r.lo {
+			if cu.complement {
+				return true, -k - 1
+			}
+			return false, 0
+		}
+		if i <= r.hi {
+			return !cu.complement, k
+		}
+	}
+	return cu.complement, -len(cu.ranges) - 1
+}
+
+// process 逐行处理输入
+func (cu *cutter) process(ctx context.Context, s *lineStream) error {
+	for i := 0; ; i++ {
+		if i%1024 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line, err := s.readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch cu.mode {
+		case 'f':
+			if !strings.Contains(line, cu.delim) {
+				if cu.onlyDelimited {
+					continue
+				}
+				s.w.WriteString(line)
+			} else {
+				cu.fields(s.w, line)
+			}
+		case 'c':
+			cu.positions(s.w, line, true)
+		default:
+			cu.positions(s.w, line, false)
+		}
+		s.w.WriteByte('\n')
+	}
+}
+
+// fields 输出选取的字段
+func (cu *cutter) fields(w *bufio.Writer, line string) {
+	first := true
+	for i, field := range strings.Split(line, cu.delim) {
+		if ok, _ := cu.selected(i + 1); !ok {
+			continue
+		}
+		if !first {
+			w.WriteString(cu.outDelim)
+		}
+		w.WriteString(field)
+		first = false
+	}
+}
+
+// positions 输出选取的字节或字符；指定了 --output-delimiter 时在不相邻的范围之间插入分隔符
+func (cu *cutter) positions(w *bufio.Writer, line string, chars bool) {
+	prevRange, written := 0, false
+	pos := 0
+	for offset := 0; offset < len(line); {
+		size := 1
+		if chars {
+			_, size = utf8.DecodeRuneInString(line[offset:])
+		}
+		pos++
+		if ok, k := cu.selected(pos); ok {
+			if cu.outDelimSet && written && k != prevRange {
+				w.WriteString(cu.outDelim)
+			}
+			w.WriteString(line[offset : offset+size])
+			prevRange, written = k, true
+		}
+		offset += size
+	}
+}
+
+func (c *CutCommand) Help() string {
+	return `cut - 从每行中选取字节、字符或字段
+
+用法:
+  cut -b 列表 [选项] [文件...]
+  cut -c 列表 [选项] [文件...]
+  cut -f 列表 [选项] [文件...]
+
+选项:
+  -b, --bytes=列表             选取字节
+  -c, --characters=列表        选取字符（按 UTF-8 解码，一个汉字算一个字符）
+  -f, --fields=列表            选取字段；不包含分隔符的行原样输出
+  -d, --delimiter=字符         字段分隔符（默认制表符）
+  -s, --only-delimited         不输出不包含分隔符的行
+      --complement             选取列表以外的部分
+      --output-delimiter=字符串 输出的分隔符（-f 时默认与 -d 相同）
+
+列表:
+  由逗号分隔的范围，位置从 1 开始：
+    N      第 N 个
+    N-M    第 N 到第 M 个
+    N-     第 N 个到行尾
+    -M     行首到第 M 个
+  选取的部分总是按它们在行中的顺序输出。
+
+描述:
+  逐行处理文件，没有文件或文件为 - 时读取标准输入。
+
+示例:
+  cut -d: -f1,7 /etc/passwd        # 用户名和登录 Shell
+  cut -d, -f2- data.csv            # 去掉第一列
+  cut -c1-10 file.txt              # 每行前 10 个字符
+  cut -d: -f1 --complement a.txt   # 除第一个字段外的所有字段
+  echo a:b:c | cut -d: -f1,3 --output-delimiter=' '`
+}
+
+func (c *CutCommand) ShortHelp() string {
+	return "选取每行的字段或字符"
+}
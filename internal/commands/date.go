@@ -3,18 +3,16 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os"
 	"time"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type DateCommand struct {
-	stdout *os.File
-}
+type DateCommand struct{}
 
-func NewDateCommand(stdout *os.File) *DateCommand {
-	return &DateCommand{stdout: stdout}
+func NewDateCommand() *DateCommand {
+	return &DateCommand{}
 }
 
 func (c *DateCommand) Name() string {
@@ -22,6 +20,8 @@ func (c *DateCommand) Name() string {
 }
 
 func (c *DateCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := pflag.NewFlagSet("date", pflag.ContinueOnError)
 	format := flags.StringP("format", "f", "", "自定义格式")
 	iso := flags.Bool("iso", false, "ISO 8601 格式")
@@ -47,7 +47,7 @@ func (c *DateCommand) Execute(ctx context.Context, args []string) error {
 		output = now.Format("2006-01-02 15:04:05 Monday")
 	}
 	
-	fmt.Fprintln(c.stdout, output)
+	fmt.Fprintln(stdout, output)
 	return nil
 }
 
@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// DeclareCommand declare 命令 - 声明变量并设置属性
+type DeclareCommand struct {
+	executor *script.Executor
+}
+
+// NewDeclareCommand 创建 declare 命令
+func NewDeclareCommand(executor *script.Executor) *DeclareCommand {
+	return &DeclareCommand{
+		executor: executor,
+	}
+}
+
+func (c *DeclareCommand) Name() string {
+	return "declare"
+}
+
+func (c *DeclareCommand) Execute(ctx context.Context, args []string) error {
+	executor := script.ExecutorFrom(ctx, c.executor)
+	stdout := streams.Stdout(ctx)
+
+	flags := flag.NewFlagSet("declare", flag.ContinueOnError)
+	print := flags.BoolP("print", "p", false, "显示变量的属性和值")
+	export := flags.BoolP("export", "x", false, "导出变量")
+	readonly := flags.BoolP("readonly", "r", false, "将变量设为只读")
+	global := flags.BoolP("global", "g", false, "在函数中声明全局变量")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	vars := executor.Variables()
+	names := flags.Args()
+
+	// 显示变量声明
+	if len(names) == 0 {
+		for _, name := range vars.Names() {
+			if script.IsValidName(name) {
+				c.printDeclaration(vars, name, stdout)
+			}
+		}
+		return nil
+	}
+	if *print {
+		for _, name := range names {
+			if !c.printDeclaration(vars, name, stdout) {
+				return fmt.Errorf("declare: %s: 未找到", name)
+			}
+		}
+		return nil
+	}
+
+	for _, arg := range names {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !script.IsValidName(name) {
+			return fmt.Errorf("declare: '%s': 不是有效的标识符", arg)
+		}
+
+		// 函数中的 declare 与 local 相同，除非指定 -g
+		if vars.InFunction() && !*global {
+			if _, exists := vars.CurrentScope().Local()[name]; hasValue || !exists {
+				if err := vars.SetLocal(name, value); err != nil {
+					return fmt.Errorf("declare: %w", err)
+				}
+			}
+		} else if hasValue {
+			if err := vars.Set(name, value); err != nil {
+				return fmt.Errorf("declare: %w", err)
+			}
+		} else if _, exists := vars.Lookup(name); !exists {
+			vars.Set(name, "")
+		}
+
+		if *export {
+			vars.Export(name)
+		}
+		if *readonly {
+			vars.MarkReadOnly(name)
+		}
+	}
+
+	return nil
+}
+
+// printDeclaration 以可重新执行的形式输出变量声明
+func (c *DeclareCommand) printDeclaration(vars *script.VariableManager, name string, stdout io.Writer) bool {
+	v, ok := vars.Lookup(name)
+	if !ok {
+		return false
+	}
+
+	attrs := ""
+	if v.ReadOnly {
+		attrs += "r"
+	}
+	if v.Exported {
+		attrs += "x"
+	}
+	if attrs == "" {
+		attrs = "-"
+	}
+
+	fmt.Fprintf(stdout, "declare -%s %s=%s\n", attrs, name, quoteVariableValue(v.Value))
+	return true
+}
+
+func (c *DeclareCommand) Help() string {
+	return `declare - 声明变量并设置属性
+
+用法:
+  declare [-x] [-r] [-g] name[=value] ...
+  declare -p [name ...]
+
+说明:
+  声明变量并设置属性。在函数中使用时，变量默认是局部变量
+  （与 local 相同），使用 -g 声明全局变量。
+  不带参数时显示所有变量。
+
+选项:
+  -p, --print      显示变量的属性和值
+  -x, --export     导出变量到子进程环境
+  -r, --readonly   将变量设为只读
+  -g, --global     在函数中声明全局变量
+
+示例:
+  declare name=value       # 声明变量
+  declare -x PATH          # 导出变量
+  declare -r MAX=10        # 只读变量
+  declare -p name          # 显示: declare -- name="value"`
+}
+
+func (c *DeclareCommand) ShortHelp() string {
+	return "声明变量和属性"
+}
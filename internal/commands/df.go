@@ -3,9 +3,11 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	flag "github.com/spf13/pflag"
 )
 
@@ -17,6 +19,8 @@ func (c *DfCommand) Name() string {
 }
 
 func (c *DfCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := flag.NewFlagSet("df", flag.ContinueOnError)
 	human := flags.BoolP("human-readable", "h", false, "以人类可读的格式显示")
 	showAll := flags.BoolP("all", "a", false, "显示所有文件系统")
@@ -33,11 +37,11 @@ func (c *DfCommand) Execute(ctx context.Context, args []string) error {
 	}
 
 	// 打印表头
-	printHeader(*showType)
+	printHeader(*showType, stdout)
 
 	// 打印磁盘信息
 	for _, disk := range disks {
-		printDiskInfo(disk, *human, *showType)
+		printDiskInfo(disk, *human, *showType, stdout)
 	}
 
 	return nil
@@ -163,18 +167,18 @@ func getDiskInfoUnix(showAll bool) ([]DiskInfo, error) {
 }
 
 // printHeader 打印表头
-func printHeader(showType bool) {
+func printHeader(showType bool, stdout io.Writer) {
 	if showType {
-		fmt.Printf("%-15s %-8s %10s %10s %10s %5s  %s\n",
+		fmt.Fprintf(stdout, "%-15s %-8s %10s %10s %10s %5s  %s\n",
 			"Filesystem", "Type", "Size", "Used", "Avail", "Use%", "Mounted on")
 	} else {
-		fmt.Printf("%-15s %10s %10s %10s %5s  %s\n",
+		fmt.Fprintf(stdout, "%-15s %10s %10s %10s %5s  %s\n",
 			"Filesystem", "Size", "Used", "Avail", "Use%", "Mounted on")
 	}
 }
 
 // printDiskInfo 打印磁盘信息
-func printDiskInfo(disk DiskInfo, human bool, showType bool) {
+func printDiskInfo(disk DiskInfo, human bool, showType bool, stdout io.Writer) {
 	var total, used, avail string
 
 	if human {
@@ -189,11 +193,11 @@ func printDiskInfo(disk DiskInfo, human bool, showType bool) {
 	}
 
 	if showType {
-		fmt.Printf("%-15s %-8s %10s %10s %10s %4d%%  %s\n",
+		fmt.Fprintf(stdout, "%-15s %-8s %10s %10s %10s %4d%%  %s\n",
 			disk.Filesystem, disk.FSType, total, used, avail,
 			disk.UsePercent, disk.MountPoint)
 	} else {
-		fmt.Printf("%-15s %10s %10s %10s %4d%%  %s\n",
+		fmt.Fprintf(stdout, "%-15s %10s %10s %10s %4d%%  %s\n",
 			disk.Filesystem, total, used, avail,
 			disk.UsePercent, disk.MountPoint)
 	}
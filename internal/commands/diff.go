@@ -2,119 +2,692 @@ package commands
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	"github.com/Lingbou/Lish/internal/textdiff"
+	"github.com/Lingbou/Lish/internal/theme"
 	"github.com/spf13/pflag"
 )
 
-type DiffCommand struct {
-	stdout *os.File
-}
+type DiffCommand struct{}
 
-func NewDiffCommand(stdout *os.File) *DiffCommand {
-	return &DiffCommand{stdout: stdout}
+func NewDiffCommand() *DiffCommand {
+	return &DiffCommand{}
 }
 
 func (c *DiffCommand) Name() string {
 	return "diff"
 }
 
+// diffFormat 输出格式
+type diffFormat int
+
+const (
+	diffNormal     diffFormat = iota // 默认格式，如 2c2
+	diffUnified                      // 统一格式（-u）
+	diffSideBySide                   // 并排格式（-y）
+)
+
+// 与 GNU diff --color 相同的默认颜色
+var (
+	diffHeaderColor = theme.NewColor("", "bold")
+	diffHunkColor   = theme.NewColor("36")
+	diffDeleteColor = theme.NewColor("31")
+	diffInsertColor = theme.NewColor("32")
+)
+
+// differ 保存一次 diff 调用的选项和结果
+type differ struct {
+	format         diffFormat
+	context        int
+	width          int
+	suppressCommon bool
+	ignoreCase     bool
+	ignoreAllSpace bool
+	ignoreSpace    bool
+	ignoreBlank    bool
+	brief          bool
+	reportSame     bool
+	recursive      bool
+	newFile        bool
+	colored        bool
+	cmdline        string // 比较目录时每对文件前输出的命令行
+	out            *bufio.Writer
+	stderr         io.Writer
+	status         int // 0 相同，1 不同，2 出错
+}
+
+// diffFile 一个被比较的文件
+type diffFile struct {
+	name   string
+	lines  []string
+	noEOL  bool // 最后一行没有换行符
+	binary bool
+	data   []byte
+	mtime  time.Time
+}
+
 func (c *DiffCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
 	flags := pflag.NewFlagSet("diff", pflag.ContinueOnError)
 	brief := flags.BoolP("brief", "q", false, "只显示文件是否不同")
+	reportSame := flags.BoolP("report-identical-files", "s", false, "文件相同时也报告")
+	unifiedDefault := flags.BoolP("u", "u", false, "统一格式，3 行上下文")
+	unified := flags.IntP("unified", "U", 3, "统一格式，N 行上下文")
+	sideBySide := flags.BoolP("side-by-side", "y", false, "并排显示")
+	width := flags.IntP("width", "W", 130, "并排显示的总宽度")
+	suppressCommon := flags.Bool("suppress-common-lines", false, "并排显示时不显示相同的行")
+	color := flags.String("color", "never", "高亮显示: always、never 或 auto")
+	flags.Lookup("color").NoOptDefVal = "auto"
+	recursive := flags.BoolP("recursive", "r", false, "递归比较子目录")
+	newFile := flags.BoolP("new-file", "N", false, "把不存在的文件视为空文件")
+	ignoreCase := flags.BoolP("ignore-case", "i", false, "忽略大小写")
+	ignoreAllSpace := flags.BoolP("ignore-all-space", "w", false, "忽略所有空白")
+	ignoreSpace := flags.BoolP("ignore-space-change", "b", false, "忽略空白数量的变化")
+	ignoreBlank := flags.BoolP("ignore-blank-lines", "B", false, "忽略只涉及空行的变化")
 
 	if err := flags.Parse(args); err != nil {
-		return err
+		if errors.Is(err, pflag.ErrHelp) {
+			return nil
+		}
+		fmt.Fprintf(std.Stderr, "diff: %v\n", err)
+		return script.ExitStatus(2)
 	}
 
 	files := flags.Args()
-	if len(files) < 2 {
-		return fmt.Errorf("diff: 需要两个文件")
+	if len(files) != 2 {
+		fmt.Fprintln(std.Stderr, "diff: 需要两个文件")
+		return script.ExitStatus(2)
+	}
+	colored, err := colorEnabled(*color, std.Stdout)
+	if err != nil {
+		fmt.Fprintf(std.Stderr, "diff: %v\n", err)
+		return script.ExitStatus(2)
+	}
+
+	d := &differ{
+		context:        *unified,
+		width:          *width,
+		suppressCommon: *suppressCommon,
+		ignoreCase:     *ignoreCase,
+		ignoreAllSpace: *ignoreAllSpace,
+		ignoreSpace:    *ignoreSpace,
+		ignoreBlank:    *ignoreBlank,
+		brief:          *brief,
+		reportSame:     *reportSame,
+		recursive:      *recursive,
+		newFile:        *newFile,
+		colored:        colored,
+		cmdline:        diffCommandLine(args, files),
+		out:            bufio.NewWriter(std.Stdout),
+		stderr:         std.Stderr,
+	}
+	switch {
+	case *sideBySide:
+		d.format = diffSideBySide
+	case *unifiedDefault || flags.Changed("unified"):
+		d.format = diffUnified
+	}
+	if d.context < 0 || d.width < 1 {
+		fmt.Fprintln(std.Stderr, "diff: 无效的数值参数")
+		return script.ExitStatus(2)
 	}
 
-	file1, file2 := files[0], files[1]
+	d.compare(ctx, files[0], files[1])
+	if err := d.out.Flush(); err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if d.status != 0 {
+		return script.ExitStatus(d.status)
+	}
+	return nil
+}
 
-	// 读取两个文件
-	lines1, err := c.readLines(file1)
-	if err != nil {
-		return fmt.Errorf("读取 %s 失败: %w", file1, err)
+// diffCommandLine 返回去掉文件参数后的命令行，比较目录时输出在每对文件前
+func diffCommandLine(args, files []string) string {
+	parts := []string{"diff"}
+	j := 0
+	for _, arg := range args {
+		if j < len(files) && arg == files[j] {
+			j++
+			continue
+		}
+		if arg != "--" {
+			parts = append(parts, arg)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// fail 报告错误，退出码为 2
+func (d *differ) fail(err error) {
+	d.out.Flush()
+	fmt.Fprintf(d.stderr, "diff: %v\n", err)
+	d.status = 2
+}
+
+// differs 记录文件不同，退出码至少为 1
+func (d *differ) differs() {
+	if d.status == 0 {
+		d.status = 1
+	}
+}
+
+// compare 比较两个路径：两个都是目录时比较目录，一个是目录时比较目录中的同名文件
+func (d *differ) compare(ctx context.Context, a, b string) {
+	infoA, errA := diffStat(a)
+	infoB, errB := diffStat(b)
+	switch {
+	case errA != nil && !(d.newFile && errors.Is(errA, fs.ErrNotExist) && errB == nil):
+		d.fail(errA)
+		return
+	case errB != nil && !(d.newFile && errors.Is(errB, fs.ErrNotExist) && errA == nil):
+		d.fail(errB)
+		return
 	}
 
-	lines2, err := c.readLines(file2)
+	dirA := infoA != nil && infoA.IsDir()
+	dirB := infoB != nil && infoB.IsDir()
+	switch {
+	case dirA && dirB:
+		d.compareDirs(ctx, a, b)
+	case dirA:
+		d.compareFiles(ctx, filepath.Join(a, filepath.Base(b)), b, false)
+	case dirB:
+		d.compareFiles(ctx, a, filepath.Join(b, filepath.Base(a)), false)
+	default:
+		d.compareFiles(ctx, a, b, false)
+	}
+}
+
+// diffStat 返回文件信息，标准输入（-）返回 nil
+func diffStat(name string) (fs.FileInfo, error) {
+	if name == "-" {
+		return nil, nil
+	}
+	return os.Stat(name)
+}
+
+// compareDirs 按文件名顺序比较两个目录中的文件
+func (d *differ) compareDirs(ctx context.Context, a, b string) {
+	entriesA, err := os.ReadDir(a)
+	if err != nil {
+		d.fail(err)
+		return
+	}
+	entriesB, err := os.ReadDir(b)
 	if err != nil {
-		return fmt.Errorf("读取 %s 失败: %w", file2, err)
+		d.fail(err)
+		return
 	}
 
-	// 比较文件
-	hasDiff := c.compareFiles(lines1, lines2, file1, file2, *brief)
+	i, j := 0, 0
+	for i < len(entriesA) || j < len(entriesB) {
+		if ctx.Err() != nil {
+			return
+		}
+		var ea, eb fs.DirEntry
+		switch {
+		case j == len(entriesB) || i < len(entriesA) && entriesA[i].Name() < entriesB[j].Name():
+			ea = entriesA[i]
+			i++
+		case i == len(entriesA) || entriesB[j].Name() < entriesA[i].Name():
+			eb = entriesB[j]
+			j++
+		default:
+			ea, eb = entriesA[i], entriesB[j]
+			i++
+			j++
+		}
 
-	if !hasDiff {
-		if !*brief {
-			fmt.Fprintln(c.stdout, "文件相同")
+		switch {
+		case eb == nil:
+			d.onlyIn(ctx, a, b, ea, true)
+		case ea == nil:
+			d.onlyIn(ctx, b, a, eb, false)
+		default:
+			pathA, pathB := filepath.Join(a, ea.Name()), filepath.Join(b, eb.Name())
+			dirA, dirB := isDirEntry(pathA), isDirEntry(pathB)
+			switch {
+			case dirA && dirB && d.recursive:
+				d.compareDirs(ctx, pathA, pathB)
+			case dirA && dirB:
+				fmt.Fprintf(d.out, "公共子目录: %s 和 %s\n", pathA, pathB)
+			case dirA || dirB:
+				kind := func(dir bool) string {
+					if dir {
+						return "目录"
+					}
+					return "文件"
+				}
+				fmt.Fprintf(d.out, "%s 是%s，而 %s 是%s\n", pathA, kind(dirA), pathB, kind(dirB))
+				d.differs()
+			default:
+				d.compareFiles(ctx, pathA, pathB, true)
+			}
 		}
 	}
+}
 
-	return nil
+// isDirEntry 判断路径是否是目录（跟随符号链接）
+func isDirEntry(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
 }
 
-func (c *DiffCommand) readLines(filename string) ([]string, error) {
-	file, err := os.Open(filename)
+// onlyIn 处理只在一个目录中存在的文件；使用 -N 时与空文件比较
+func (d *differ) onlyIn(ctx context.Context, dir, other string, entry fs.DirEntry, first bool) {
+	path := filepath.Join(dir, entry.Name())
+	if !d.newFile {
+		fmt.Fprintf(d.out, "只在 %s 中存在: %s\n", dir, entry.Name())
+		d.differs()
+		return
+	}
+	missing := filepath.Join(other, entry.Name())
+	if isDirEntry(path) {
+		if d.recursive {
+			d.onlyInDir(ctx, path, missing, first)
+		}
+		return
+	}
+	if first {
+		d.compareFiles(ctx, path, missing, true)
+	} else {
+		d.compareFiles(ctx, missing, path, true)
+	}
+}
+
+// onlyInDir 使用 -N -r 时，把只在一边存在的目录中的每个文件与空文件比较
+func (d *differ) onlyInDir(ctx context.Context, dir, missing string, first bool) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return nil, err
+		d.fail(err)
+		return
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		d.onlyIn(ctx, dir, missing, entry, first)
 	}
-	defer file.Close()
+}
 
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// readDiffFile 读取文件；文件不存在且使用 -N 时视为空文件
+func (d *differ) readDiffFile(ctx context.Context, name string) (*diffFile, error) {
+	f := &diffFile{name: name}
+	if name == "-" {
+		data, err := io.ReadAll(streams.From(ctx).Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("标准输入: %w", err)
+		}
+		f.data, f.mtime = data, time.Now()
+	} else {
+		info, err := os.Stat(name)
+		switch {
+		case err != nil && d.newFile && errors.Is(err, fs.ErrNotExist):
+			f.mtime = time.Unix(0, 0).UTC()
+			return f, nil
+		case err != nil:
+			return nil, err
+		}
+		if f.data, err = os.ReadFile(name); err != nil {
+			return nil, err
+		}
+		f.mtime = info.ModTime()
 	}
 
-	return lines, scanner.Err()
+	// 和 GNU diff 一样，开头包含 NUL 字节的文件视为二进制文件
+	f.binary = bytes.IndexByte(f.data[:min(len(f.data), 8192)], 0) >= 0
+	f.noEOL = len(f.data) > 0 && f.data[len(f.data)-1] != '\n'
+	f.lines = textdiff.SplitLines(string(f.data))
+	return f, nil
 }
 
-func (c *DiffCommand) compareFiles(lines1, lines2 []string, file1, file2 string, brief bool) bool {
-	maxLen := len(lines1)
-	if len(lines2) > maxLen {
-		maxLen = len(lines2)
+// keys 返回用于比较的行：按 -i、-w、-b 规范化，没有换行符的最后一行与有换行符的行不同
+func (d *differ) keys(f *diffFile) []string {
+	keys := make([]string, len(f.lines))
+	for i, line := range f.lines {
+		switch {
+		case d.ignoreAllSpace:
+			line = strings.Join(strings.Fields(line), "")
+		case d.ignoreSpace:
+			line = collapseSpace(line)
+		}
+		if d.ignoreCase {
+			line = strings.ToLower(line)
+		}
+		keys[i] = line
 	}
+	if f.noEOL && len(keys) > 0 {
+		keys[len(keys)-1] += "\n"
+	}
+	return keys
+}
 
-	hasDiff := false
+// collapseSpace 把连续的空白变为一个空格并去掉行尾的空白（-b）
+func collapseSpace(line string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range strings.TrimRight(line, " \t\r\f\v") {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\f' || r == '\v' {
+			space = true
+			continue
+		}
+		if space {
+			sb.WriteByte(' ')
+			space = false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
 
-	for i := 0; i < maxLen; i++ {
-		line1 := ""
-		if i < len(lines1) {
-			line1 = lines1[i]
+// compareFiles 比较两个文件并按选定的格式输出差异，inDir 表示这是目录比较中的一对文件
+func (d *differ) compareFiles(ctx context.Context, nameA, nameB string, inDir bool) {
+	a, err := d.readDiffFile(ctx, nameA)
+	if err != nil {
+		d.fail(err)
+		return
+	}
+	b, err := d.readDiffFile(ctx, nameB)
+	if err != nil {
+		d.fail(err)
+		return
+	}
+
+	if a.binary || b.binary {
+		switch {
+		case !bytes.Equal(a.data, b.data) && d.brief:
+			fmt.Fprintf(d.out, "文件 %s 和 %s 不同\n", nameA, nameB)
+			d.differs()
+		case !bytes.Equal(a.data, b.data):
+			fmt.Fprintf(d.out, "二进制文件 %s 和 %s 不同\n", nameA, nameB)
+			d.differs()
+		case d.reportSame:
+			fmt.Fprintf(d.out, "文件 %s 和 %s 相同\n", nameA, nameB)
+		}
+		return
+	}
+
+	keysA, keysB := d.keys(a), d.keys(b)
+	edits := textdiff.Lines(keysA, keysB)
+	blank := func(e textdiff.Edit) bool {
+		if e.Kind == textdiff.Delete {
+			return keysA[e.A] == ""
+		}
+		return keysB[e.B] == ""
+	}
+	groups := changeGroups(edits)
+	if d.ignoreBlank {
+		// 忽略只增删空行的变化
+		kept := groups[:0]
+		for _, g := range groups {
+			if !allEdits(g, blank) {
+				kept = append(kept, g)
+			}
 		}
+		groups = kept
+	}
 
-		line2 := ""
-		if i < len(lines2) {
-			line2 = lines2[i]
+	if len(groups) == 0 {
+		if d.reportSame {
+			fmt.Fprintf(d.out, "文件 %s 和 %s 相同\n", nameA, nameB)
 		}
+		return
+	}
+	d.differs()
+	if d.brief {
+		fmt.Fprintf(d.out, "文件 %s 和 %s 不同\n", nameA, nameB)
+		return
+	}
+	if inDir {
+		fmt.Fprintf(d.out, "%s %s %s\n", d.cmdline, nameA, nameB)
+	}
 
-		if line1 != line2 {
-			hasDiff = true
+	switch d.format {
+	case diffUnified:
+		d.writeUnified(a, b, edits, blank)
+	case diffSideBySide:
+		d.writeSideBySide(a, b, edits)
+	default:
+		d.writeNormal(a, b, groups)
+	}
+}
+
+// changeGroups 返回编辑序列中每段连续的改动
+func changeGroups(edits []textdiff.Edit) [][]textdiff.Edit {
+	var groups [][]textdiff.Edit
+	start := -1
+	for i, e := range edits {
+		if e.Kind != textdiff.Equal {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			groups = append(groups, edits[start:i])
+			start = -1
+		}
+	}
+	if start >= 0 {
+		groups = append(groups, edits[start:])
+	}
+	return groups
+}
+
+// allEdits 判断所有改动是否都满足 fn（相同的行不检查）
+func allEdits(edits []textdiff.Edit, fn func(textdiff.Edit) bool) bool {
+	for _, e := range edits {
+		if e.Kind != textdiff.Equal && !fn(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *differ) paint(color theme.Color, text string) string {
+	if !d.colored {
+		return text
+	}
+	return color.Apply(text)
+}
+
+// writeLine 输出一行差异，文件最后一行没有换行符时加上说明
+func (d *differ) writeLine(color theme.Color, prefix string, f *diffFile, i int) {
+	fmt.Fprintln(d.out, d.paint(color, prefix+f.lines[i]))
+	if f.noEOL && i == len(f.lines)-1 {
+		fmt.Fprintln(d.out, `\ No newline at end of file`)
+	}
+}
+
+// writeNormal 以默认格式输出，如 "2,3c2" 之后是 "< 旧行"、"---"、"> 新行"
+func (d *differ) writeNormal(a, b *diffFile, groups [][]textdiff.Edit) {
+	lineRange := func(from, n int) string {
+		if n == 1 {
+			return fmt.Sprintf("%d", from+1)
+		}
+		return fmt.Sprintf("%d,%d", from+1, from+n)
+	}
+	for _, g := range groups {
+		var deleted, inserted []int
+		for _, e := range g {
+			if e.Kind == textdiff.Delete {
+				deleted = append(deleted, e.A)
+			} else {
+				inserted = append(inserted, e.B)
+			}
+		}
+		var header string
+		switch {
+		case len(deleted) > 0 && len(inserted) > 0:
+			header = lineRange(deleted[0], len(deleted)) + "c" + lineRange(inserted[0], len(inserted))
+		case len(deleted) > 0:
+			header = fmt.Sprintf("%sd%d", lineRange(deleted[0], len(deleted)), g[0].B)
+		default:
+			header = fmt.Sprintf("%da%s", g[0].A, lineRange(inserted[0], len(inserted)))
+		}
+		fmt.Fprintln(d.out, d.paint(diffHunkColor, header))
+		for _, i := range deleted {
+			d.writeLine(diffDeleteColor, "< ", a, i)
+		}
+		if len(deleted) > 0 && len(inserted) > 0 {
+			fmt.Fprintln(d.out, "---")
+		}
+		for _, i := range inserted {
+			d.writeLine(diffInsertColor, "> ", b, i)
+		}
+	}
+}
 
-			if brief {
-				fmt.Fprintf(c.stdout, "文件 %s 和 %s 不同\n", file1, file2)
-				return true
+// writeUnified 以统一格式输出，-B 时省略只增删空行的差异块
+func (d *differ) writeUnified(a, b *diffFile, edits []textdiff.Edit, blank func(textdiff.Edit) bool) {
+	const stamp = "2006-01-02 15:04:05.000000000 -0700"
+	fmt.Fprintln(d.out, d.paint(diffHeaderColor, fmt.Sprintf("--- %s\t%s", a.name, a.mtime.Format(stamp))))
+	fmt.Fprintln(d.out, d.paint(diffHeaderColor, fmt.Sprintf("+++ %s\t%s", b.name, b.mtime.Format(stamp))))
+	for _, h := range textdiff.Hunks(edits, d.context) {
+		if d.ignoreBlank && allEdits(h.Edits, blank) {
+			continue
+		}
+		fmt.Fprintln(d.out, d.paint(diffHunkColor, h.Header()))
+		for _, e := range h.Edits {
+			switch e.Kind {
+			case textdiff.Equal:
+				// 和 GNU diff 一样，使用 -i、-w 时上下文行取自第一个文件
+				d.writeLine(theme.Color{}, " ", a, e.A)
+			case textdiff.Delete:
+				d.writeLine(diffDeleteColor, "-", a, e.A)
+			case textdiff.Insert:
+				d.writeLine(diffInsertColor, "+", b, e.B)
 			}
+		}
+	}
+}
 
-			// 显示差异
-			lineNum := i + 1
-			if i < len(lines1) {
-				fmt.Fprintf(c.stdout, "< %d: %s\n", lineNum, line1)
+// writeSideBySide 以并排格式输出：相同的行没有标记，修改的行为 |，
+// 只在左边的行为 <，只在右边的行为 >；制表符展开为空格
+func (d *differ) writeSideBySide(a, b *diffFile, edits []textdiff.Edit) {
+	// 列宽的计算与 GNU diff -t 相同
+	offset := (d.width + 1 + 3) / 2
+	half := max(0, min(offset-3, d.width-offset))
+
+	row := func(left string, marker byte, right string, color theme.Color) {
+		var sb strings.Builder
+		left = fitWidth(expandTabs(left), half)
+		sb.WriteString(left)
+		sb.WriteString(strings.Repeat(" ", half-displayWidth(left)+1))
+		sb.WriteByte(marker)
+		if right != "" {
+			sb.WriteString(strings.Repeat(" ", max(offset-half-2, 1)))
+			sb.WriteString(fitWidth(expandTabs(right), d.width-offset))
+		}
+		line := sb.String()
+		if marker == ' ' {
+			line = strings.TrimRight(line, " ")
+		}
+		fmt.Fprintln(d.out, d.paint(color, line))
+	}
+
+	for _, g := range sideBySideGroups(edits) {
+		if g[0].Kind == textdiff.Equal {
+			if !d.suppressCommon {
+				for _, e := range g {
+					row(a.lines[e.A], ' ', b.lines[e.B], theme.Color{})
+				}
 			}
-			if i < len(lines2) {
-				fmt.Fprintf(c.stdout, "> %d: %s\n", lineNum, line2)
+			continue
+		}
+		var deleted, inserted []int
+		for _, e := range g {
+			if e.Kind == textdiff.Delete {
+				deleted = append(deleted, e.A)
+			} else {
+				inserted = append(inserted, e.B)
+			}
+		}
+		for i := 0; i < max(len(deleted), len(inserted)); i++ {
+			switch {
+			case i < len(deleted) && i < len(inserted):
+				left, right := a.lines[deleted[i]], b.lines[inserted[i]]
+				marker := byte('|')
+				if left == right {
+					// 两行只有末尾的换行符不同：\ 表示左边没有换行符，/ 表示右边没有
+					marker = '/'
+					if a.noEOL && deleted[i] == len(a.lines)-1 {
+						marker = '\\'
+					}
+				}
+				row(left, marker, right, diffHunkColor)
+			case i < len(deleted):
+				row(a.lines[deleted[i]], '<', "", diffDeleteColor)
+			default:
+				row("", '>', b.lines[inserted[i]], diffInsertColor)
 			}
-			fmt.Fprintln(c.stdout, "---")
 		}
 	}
+}
 
-	return hasDiff
+// sideBySideGroups 把编辑序列分成相同的行和改动交替的段
+func sideBySideGroups(edits []textdiff.Edit) [][]textdiff.Edit {
+	var groups [][]textdiff.Edit
+	start := 0
+	for i := 1; i <= len(edits); i++ {
+		if i == len(edits) || (edits[i].Kind == textdiff.Equal) != (edits[start].Kind == textdiff.Equal) {
+			groups = append(groups, edits[start:i])
+			start = i
+		}
+	}
+	return groups
+}
+
+// expandTabs 把制表符展开为空格（制表位间隔 8）
+func expandTabs(s string) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	var sb strings.Builder
+	col := 0
+	for _, r := range s {
+		if r == '\t' {
+			n := 8 - col%8
+			sb.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		sb.WriteRune(r)
+		col += runeWidth(r)
+	}
+	return sb.String()
+}
+
+// fitWidth 截断字符串，使显示宽度不超过 width
+func fitWidth(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	col := 0
+	for i, r := range s {
+		if col+runeWidth(r) > width {
+			return s[:i]
+		}
+		col += runeWidth(r)
+	}
+	return s
 }
 
 func (c *DiffCommand) Help() string {
@@ -122,16 +695,46 @@ func (c *DiffCommand) Help() string {
 
 用法:
   diff [选项] 文件1 文件2
+  diff [选项] 目录1 目录2
 
 选项:
-  -q, --brief  只显示文件是否不同
+  -q, --brief                  只显示文件是否不同
+  -s, --report-identical-files 文件相同时也报告
+  -u                           统一格式，显示 3 行上下文
+  -U, --unified=N              统一格式，显示 N 行上下文
+  -y, --side-by-side           并排显示两个文件
+  -W, --width=N                并排显示的总宽度（默认 130）
+      --suppress-common-lines  并排显示时不显示相同的行
+      --color[=WHEN]           高亮显示: always、never（默认）或 auto
+  -r, --recursive              比较目录时递归比较子目录
+  -N, --new-file               把不存在的文件视为空文件
+  -i, --ignore-case            忽略大小写
+  -w, --ignore-all-space       忽略所有空白
+  -b, --ignore-space-change    忽略空白数量的变化
+  -B, --ignore-blank-lines     忽略只增删空行的变化
 
 描述:
-  逐行比较两个文件的差异。
+  使用 Myers 算法计算两个文件之间最少的增删，插入一行不会使之后的
+  所有行都显示为不同。默认格式与 GNU diff 相同，如 "2c2" 之后是
+  "< 旧行"、"---"、"> 新行"。统一格式（-u）可以用 patch 应用。
+
+  比较两个目录时按文件名比较其中的文件，只在一边存在的文件会被报告
+  （使用 -N 时与空文件比较）。一个参数是目录时比较目录中的同名文件。
+  文件可以是 -（标准输入）。包含 NUL 字节的文件视为二进制文件，只报告
+  是否不同。
+
+退出码:
+  0  没有差异
+  1  有差异
+  2  出错（如文件不存在）
 
 示例:
-  diff file1.txt file2.txt     # 显示详细差异
-  diff -q file1.txt file2.txt  # 只显示是否不同`
+  diff old.txt new.txt              # 默认格式
+  diff -u old.txt new.txt > a.patch # 生成补丁
+  diff -y -W 80 a.txt b.txt         # 并排比较
+  diff -ruN old/ new/               # 递归比较目录
+  diff -q -r src/ backup/           # 只列出不同的文件
+  diff -iw a.txt b.txt              # 忽略大小写和空白`
 }
 
 func (c *DiffCommand) ShortHelp() string {
@@ -6,15 +6,14 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type DuCommand struct {
-	stdout *os.File
-}
+type DuCommand struct{}
 
-func NewDuCommand(stdout *os.File) *DuCommand {
-	return &DuCommand{stdout: stdout}
+func NewDuCommand() *DuCommand {
+	return &DuCommand{}
 }
 
 func (c *DuCommand) Name() string {
@@ -22,6 +21,9 @@ func (c *DuCommand) Name() string {
 }
 
 func (c *DuCommand) Execute(ctx context.Context, args []string) error {
+	stderr := streams.Stderr(ctx)
+	stdout := streams.Stdout(ctx)
+
 	flags := pflag.NewFlagSet("du", pflag.ContinueOnError)
 	humanReadable := flags.BoolP("human-readable", "h", false, "人类可读格式")
 	summarize := flags.BoolP("summarize", "s", false, "只显示总计")
@@ -38,12 +40,12 @@ func (c *DuCommand) Execute(ctx context.Context, args []string) error {
 	for _, path := range paths {
 		size, err := c.calculateSize(path, !*summarize)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "du: %v\n", err)
+			fmt.Fprintf(stderr, "du: %v\n", err)
 			continue
 		}
 
 		sizeStr := c.formatSize(size, *humanReadable)
-		fmt.Fprintf(c.stdout, "%s\t%s\n", sizeStr, path)
+		fmt.Fprintf(stdout, "%s\t%s\n", sizeStr, path)
 	}
 
 	return nil
@@ -2,17 +2,17 @@ package commands
 
 import (
 	"context"
-	"fmt"
-	"os"
+	"encoding/json"
+	"io"
 	"strings"
+
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
-type EchoCommand struct {
-	stdout *os.File
-}
+type EchoCommand struct{}
 
-func NewEchoCommand(stdout *os.File) *EchoCommand {
-	return &EchoCommand{stdout: stdout}
+func NewEchoCommand() *EchoCommand {
+	return &EchoCommand{}
 }
 
 func (c *EchoCommand) Name() string {
@@ -20,37 +20,121 @@ func (c *EchoCommand) Name() string {
 }
 
 func (c *EchoCommand) Execute(ctx context.Context, args []string) error {
-	// 展开环境变量
-	var expanded []string
-	for _, arg := range args {
-		expanded = append(expanded, os.ExpandEnv(arg))
+	stdout := streams.Stdout(ctx)
+
+	// 与 coreutils 一致：只有开头由 n、e、E 组成的参数才是选项，
+	// 其他以 - 开头的参数（包括 --）原样输出
+	newline, escapes, jsonMode := true, false, false
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--json" {
+			jsonMode = true
+			args = args[1:]
+			continue
+		}
+		if len(arg) < 2 || arg[0] != '-' || strings.Trim(arg[1:], "neE") != "" {
+			break
+		}
+		for _, ch := range arg[1:] {
+			switch ch {
+			case 'n':
+				newline = false
+			case 'e':
+				escapes = true
+			case 'E':
+				escapes = false
+			}
+		}
+		args = args[1:]
+	}
+
+	// 参数已由 shell 展开，这里只用一个空格连接
+	output := strings.Join(args, " ")
+	if escapes {
+		var stop bool
+		output, stop = expandEchoEscapes(output)
+		if stop {
+			// \c 之后的内容和末尾的换行都不输出
+			newline = false
+		}
+	}
+
+	if jsonMode {
+		output = jsonString(output)
+	}
+
+	if newline {
+		output += "\n"
 	}
-	
-	output := strings.Join(expanded, " ")
-	fmt.Fprintln(c.stdout, output)
-	
-	return nil
+	_, err := io.WriteString(stdout, output)
+	return err
+}
+
+// jsonString 把文本编码为 JSON 字符串（不转义 HTML 字符）
+func jsonString(s string) string {
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// expandEchoEscapes 处理 echo -e 的转义序列，返回结果和是否遇到 \c
+//
+// 与 coreutils 一致，八进制写作 \0NNN，\" 和 \' 不是转义序列。
+func expandEchoEscapes(s string) (string, bool) {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) || s[i+1] == '"' || s[i+1] == '\'' {
+			buf.WriteByte(s[i])
+			continue
+		}
+
+		text, n, stop := decodeEscape(s[i+1:], true)
+		if stop {
+			return buf.String(), true
+		}
+		buf.WriteString(text)
+		i += n
+	}
+	return buf.String(), false
 }
 
 func (c *EchoCommand) Help() string {
 	return `echo - 输出文本
 
 用法:
-  echo [文本...]
+  echo [-neE] [--json] [文本...]
 
 描述:
-  在标准输出上显示一行文本。支持环境变量展开。
+  用一个空格连接参数并输出，末尾加换行。变量在 shell 展开参数时
+  已经替换，echo 不再做第二次展开。
+
+选项:
+  -n       不输出末尾的换行
+  -e       解释反斜杠转义序列
+  -E       不解释转义序列（默认）
+  --json   把结果输出为 JSON 字符串（加双引号并转义），可安全写入配置文件
 
-环境变量:
-  使用 $VAR 或 ${VAR} 格式引用环境变量。
+  与 coreutils 相同，只有开头只含 n、e、E 的参数被当作选项，
+  其他以 - 开头的参数（如 --、-x）原样输出。
+
+转义序列（-e）:
+  \\  反斜杠      \a  响铃        \b  退格        \c  停止输出（包括换行）
+  \e  ESC         \f  换页        \n  换行        \r  回车
+  \t  制表符      \v  垂直制表符
+  \0NNN  八进制字节（最多 3 位）   \xHH  十六进制字节（最多 2 位）
+  \uHHHH、\UHHHHHHHH  Unicode 字符
 
 示例:
-  echo Hello World        # 输出: Hello World
-  echo $HOME              # 输出用户主目录路径
-  echo "Current: $PWD"    # 输出当前目录`
+  echo Hello World           # 输出: Hello World
+  echo $HOME                 # 输出用户主目录路径
+  echo -n "no newline"       # 不换行
+  echo -e "a\tb\u4e2d"       # 制表符和“中”
+  echo -e "\e[31m红色\e[0m"  # 彩色输出
+  echo --json "a \"b\""      # 输出: "a \"b\""`
 }
 
 func (c *EchoCommand) ShortHelp() string {
 	return "输出文本"
 }
-
@@ -3,17 +3,21 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os"
-	"sort"
+	"io"
 	"strings"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
 type EnvCommand struct {
-	stdout *os.File
+	executor *script.Executor
 }
 
-func NewEnvCommand(stdout *os.File) *EnvCommand {
-	return &EnvCommand{stdout: stdout}
+func NewEnvCommand(executor *script.Executor) *EnvCommand {
+	return &EnvCommand{
+		executor: executor,
+	}
 }
 
 func (c *EnvCommand) Name() string {
@@ -21,27 +25,35 @@ func (c *EnvCommand) Name() string {
 }
 
 func (c *EnvCommand) Execute(ctx context.Context, args []string) error {
+	executor := script.ExecutorFrom(ctx, c.executor)
+	stdout := streams.Stdout(ctx)
+
 	// 如果没有参数，显示所有环境变量
 	if len(args) == 0 {
-		return c.listEnv()
+		return c.listEnv(executor, stdout)
 	}
 
+	vars := executor.Variables()
+
 	// 设置或显示特定环境变量
 	for _, arg := range args {
 		if strings.Contains(arg, "=") {
-			// 设置环境变量 KEY=VALUE
+			// 设置环境变量 KEY=VALUE（等价于 export KEY=VALUE）
 			parts := strings.SplitN(arg, "=", 2)
 			if len(parts) == 2 {
-				os.Setenv(parts[0], parts[1])
-				fmt.Fprintf(c.stdout, "设置环境变量: %s=%s\n", parts[0], parts[1])
+				if err := vars.Set(parts[0], parts[1]); err != nil {
+					return fmt.Errorf("env: %w", err)
+				}
+				vars.Export(parts[0])
+				fmt.Fprintf(stdout, "设置环境变量: %s=%s\n", parts[0], parts[1])
 			}
 		} else {
 			// 显示特定环境变量
-			value := os.Getenv(arg)
-			if value != "" {
-				fmt.Fprintf(c.stdout, "%s=%s\n", arg, value)
+			v, ok := vars.Lookup(arg)
+			if ok && v.Exported {
+				fmt.Fprintf(stdout, "%s=%s\n", arg, v.Value)
 			} else {
-				fmt.Fprintf(c.stdout, "%s: 未设置\n", arg)
+				fmt.Fprintf(stdout, "%s: 未设置\n", arg)
 			}
 		}
 	}
@@ -49,12 +61,9 @@ func (c *EnvCommand) Execute(ctx context.Context, args []string) error {
 	return nil
 }
 
-func (c *EnvCommand) listEnv() error {
-	env := os.Environ()
-	sort.Strings(env)
-
-	for _, e := range env {
-		fmt.Fprintln(c.stdout, e)
+func (c *EnvCommand) listEnv(executor *script.Executor, stdout io.Writer) error {
+	for _, e := range executor.Environ() {
+		fmt.Fprintln(stdout, e)
 	}
 
 	return nil
@@ -72,7 +81,8 @@ func (c *EnvCommand) Help() string {
   查看和设置环境变量。
 
 注意:
-  使用 env 设置的环境变量只在当前 Shell 会话中有效。
+  使用 env 设置的环境变量只在当前 Shell 会话中有效，
+  等价于 export VAR=VALUE。
 
 示例:
   env                    # 显示所有环境变量
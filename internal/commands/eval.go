@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/script"
+)
+
+// EvalCommand eval 命令 - 把参数作为脚本代码执行
+type EvalCommand struct {
+	executor *script.Executor
+}
+
+// NewEvalCommand 创建 eval 命令
+func NewEvalCommand(executor *script.Executor) *EvalCommand {
+	return &EvalCommand{
+		executor: executor,
+	}
+}
+
+func (c *EvalCommand) Name() string {
+	return "eval"
+}
+
+func (c *EvalCommand) Execute(ctx context.Context, args []string) error {
+	executor := script.ExecutorFrom(ctx, c.executor)
+
+	// 参数已经展开过一次，用空格连接后再解析和展开一次
+	source := strings.Join(args, " ")
+	if strings.TrimSpace(source) == "" {
+		executor.Variables().SetStatus(0)
+		return nil
+	}
+
+	return executor.ExecuteLine(ctx, source)
+}
+
+func (c *EvalCommand) Help() string {
+	return `eval - 把参数作为脚本代码执行
+
+用法:
+  eval [参数...]
+
+说明:
+  用空格连接所有参数，作为脚本代码在当前环境中解析并执行。
+  代码中的变量和命令替换会再展开一次；定义的变量和函数在
+  eval 结束后仍然有效。退出码是最后一条命令的退出码。
+
+示例:
+  cmd="ls -l"; eval $cmd           # 执行 ls -l
+  name=x; eval "$name=42"          # 给 x 赋值
+  eval "f() { echo generated; }"   # 动态定义函数
+  var=HOME; eval echo \$$var       # 间接引用变量`
+}
+
+func (c *EvalCommand) ShortHelp() string {
+	return "把参数作为脚本代码执行"
+}
@@ -2,18 +2,15 @@ package commands
 
 import (
 	"context"
-	"os"
 	"strconv"
+
+	"github.com/Lingbou/Lish/internal/script"
 )
 
-type ExitCommand struct {
-	exitFunc func(int)
-}
+type ExitCommand struct{}
 
 func NewExitCommand() *ExitCommand {
-	return &ExitCommand{
-		exitFunc: os.Exit,
-	}
+	return &ExitCommand{}
 }
 
 func (c *ExitCommand) Name() string {
@@ -30,8 +27,8 @@ func (c *ExitCommand) Execute(ctx context.Context, args []string) error {
 		}
 	}
 	
-	c.exitFunc(exitCode)
-	return nil
+	// 由执行器结束当前 shell：子 shell 中只结束子 shell，顶层才退出 lish
+	return script.ExitRequest(exitCode & 0xff)
 }
 
 func (c *ExitCommand) Help() string {
@@ -42,6 +39,7 @@ func (c *ExitCommand) Help() string {
 
 描述:
   退出 Lish Shell。可以指定退出码（默认为 0）。
+  在管道中只结束管道，在命令替换 $(...) 或 xargs、parallel 执行的命令中只结束该命令。
 
 参数:
   退出码  可选，指定退出状态码（默认 0）
@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// ExportCommand export 命令 - 将变量导出到子进程环境
+type ExportCommand struct {
+	executor *script.Executor
+}
+
+// NewExportCommand 创建 export 命令
+func NewExportCommand(executor *script.Executor) *ExportCommand {
+	return &ExportCommand{
+		executor: executor,
+	}
+}
+
+func (c *ExportCommand) Name() string {
+	return "export"
+}
+
+func (c *ExportCommand) Execute(ctx context.Context, args []string) error {
+	executor := script.ExecutorFrom(ctx, c.executor)
+	stdout := streams.Stdout(ctx)
+
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	unexport := flags.BoolP("unexport", "n", false, "取消导出")
+	print := flags.BoolP("print", "p", false, "列出所有导出变量")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	vars := executor.Variables()
+	names := flags.Args()
+
+	if *print || len(names) == 0 {
+		for _, name := range vars.Names() {
+			if v, ok := vars.Lookup(name); ok && v.Exported && script.IsValidName(name) {
+				fmt.Fprintf(stdout, "export %s=%s\n", name, quoteVariableValue(v.Value))
+			}
+		}
+		return nil
+	}
+
+	for _, arg := range names {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !script.IsValidName(name) {
+			return fmt.Errorf("export: '%s': 不是有效的标识符", arg)
+		}
+
+		if *unexport {
+			vars.Unexport(name)
+			continue
+		}
+
+		if hasValue {
+			if err := vars.Set(name, value); err != nil {
+				return fmt.Errorf("export: %w", err)
+			}
+		}
+		vars.Export(name)
+	}
+
+	return nil
+}
+
+func (c *ExportCommand) Help() string {
+	return `export - 将变量导出到子进程环境
+
+用法:
+  export [-n] [name[=value] ...]
+  export -p
+
+说明:
+  导出的变量会传递给外部命令。不带参数时列出所有导出变量，
+  输出格式可以直接作为命令再次执行。
+
+选项:
+  -n, --unexport   取消变量的导出属性（变量本身保留）
+  -p, --print      列出所有导出变量
+
+示例:
+  export EDITOR=vim            # 设置并导出变量
+  name=value; export name      # 导出已有变量
+  export -n EDITOR             # 取消导出
+  export -p                    # 列出导出变量`
+}
+
+func (c *ExportCommand) ShortHelp() string {
+	return "导出环境变量"
+}
+
+// quoteVariableValue 用双引号包围变量值，转义 shell 中的特殊字符
+func quoteVariableValue(value string) string {
+	var buf strings.Builder
+	buf.WriteByte('"')
+	for _, ch := range value {
+		if strings.ContainsRune("\"\\$`", ch) {
+			buf.WriteByte('\\')
+		}
+		buf.WriteRune(ch)
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
@@ -3,18 +3,18 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type FindCommand struct {
-	stdout *os.File
-}
+type FindCommand struct{}
 
-func NewFindCommand(stdout *os.File) *FindCommand {
-	return &FindCommand{stdout: stdout}
+func NewFindCommand() *FindCommand {
+	return &FindCommand{}
 }
 
 func (c *FindCommand) Name() string {
@@ -22,6 +22,9 @@ func (c *FindCommand) Name() string {
 }
 
 func (c *FindCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+	stderr := streams.Stderr(ctx)
+
 	flags := pflag.NewFlagSet("find", pflag.ContinueOnError)
 	namePattern := flags.StringP("name", "n", "", "按文件名查找（支持通配符）")
 	fileType := flags.StringP("type", "t", "", "按类型查找（f=文件, d=目录）")
@@ -36,15 +39,15 @@ func (c *FindCommand) Execute(ctx context.Context, args []string) error {
 	}
 
 	for _, path := range paths {
-		if err := c.findInPath(path, *namePattern, *fileType); err != nil {
-			fmt.Fprintf(os.Stderr, "find: %v\n", err)
+		if err := c.findInPath(path, *namePattern, *fileType, stdout); err != nil {
+			fmt.Fprintf(stderr, "find: %v\n", err)
 		}
 	}
 
 	return nil
 }
 
-func (c *FindCommand) findInPath(root, namePattern, fileType string) error {
+func (c *FindCommand) findInPath(root, namePattern, fileType string, stdout io.Writer) error {
 	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // 忽略无法访问的文件
@@ -69,7 +72,7 @@ func (c *FindCommand) findInPath(root, namePattern, fileType string) error {
 		}
 
 		// 打印匹配的路径
-		fmt.Fprintln(c.stdout, path)
+		fmt.Fprintln(stdout, path)
 
 		return nil
 	})
@@ -0,0 +1,310 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+)
+
+// GetoptsCommand getopts 命令 - 逐个解析位置参数中的选项
+type GetoptsCommand struct {
+	executor *script.Executor
+
+	// 组合短选项（如 -abc）中下一个字符的位置，只在 OPTIND 没被脚本修改时有效
+	optind int
+	pos    int
+}
+
+// NewGetoptsCommand 创建 getopts 命令
+func NewGetoptsCommand(executor *script.Executor) *GetoptsCommand {
+	return &GetoptsCommand{executor: executor}
+}
+
+func (c *GetoptsCommand) Name() string {
+	return "getopts"
+}
+
+// longOption 长选项的定义
+type longOption struct {
+	name   string
+	hasArg bool
+}
+
+// getoptsResult 一次 getopts 调用的结果
+type getoptsResult struct {
+	name   string // 写入变量的值：选项名、? 或 :
+	optarg string
+	hasArg bool   // 是否设置 OPTARG
+	errMsg string // 非静默模式下输出的错误信息
+}
+
+func (c *GetoptsCommand) Execute(ctx context.Context, args []string) error {
+	executor := script.ExecutorFrom(ctx, c.executor)
+	vars := executor.Variables()
+
+	var longSpec string
+	var hasLong bool
+	if len(args) > 0 {
+		switch {
+		case args[0] == "-l" || args[0] == "--long":
+			if len(args) < 2 {
+				return fmt.Errorf("getopts: %s: 需要长选项列表", args[0])
+			}
+			longSpec, hasLong, args = args[1], true, args[2:]
+		case strings.HasPrefix(args[0], "--long="):
+			longSpec, hasLong, args = strings.TrimPrefix(args[0], "--long="), true, args[1:]
+		}
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("用法: getopts [-l 长选项] 选项字符串 变量名 [参数...]")
+	}
+
+	optstring, name := args[0], args[1]
+	if !script.IsValidName(name) {
+		return fmt.Errorf("getopts: '%s': 无效的变量名", name)
+	}
+
+	silent := strings.HasPrefix(optstring, ":")
+	optstring = strings.TrimPrefix(optstring, ":")
+	if value, ok := vars.Get("OPTERR"); ok && value == "0" {
+		silent = true
+	}
+
+	var longOpts []longOption
+	if hasLong {
+		longOpts = parseLongOptions(longSpec)
+	}
+
+	// 没有额外参数时解析位置参数
+	params := args[2:]
+	if len(args) == 2 {
+		params = vars.Positional()
+	}
+
+	optind := 1
+	if value, ok := vars.Get("OPTIND"); ok {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			optind = n
+		}
+	}
+	pos := 0
+	if optind == c.optind {
+		pos = c.pos
+	}
+
+	result, done := c.next(params, &optind, &pos, optstring, longOpts, hasLong)
+	c.optind, c.pos = optind, pos
+
+	if err := executor.SetVariable("OPTIND", strconv.Itoa(optind)); err != nil {
+		return err
+	}
+	if done {
+		if err := executor.SetVariable(name, "?"); err != nil {
+			return err
+		}
+		// 选项处理完不是错误，只以退出码 1 结束 while getopts 循环
+		return script.ExitStatus(1)
+	}
+
+	// 静默模式下错误通过变量报告：未知选项为 ?，缺少参数为 :，OPTARG 是选项名
+	if result.errMsg != "" {
+		if silent {
+			result.hasArg = true
+		} else {
+			fmt.Fprintf(streams.Stderr(ctx), "getopts: %s\n", result.errMsg)
+			result.name = "?"
+			result.optarg = ""
+			result.hasArg = false
+		}
+	}
+
+	if err := executor.SetVariable(name, result.name); err != nil {
+		return err
+	}
+	if result.hasArg {
+		return executor.SetVariable("OPTARG", result.optarg)
+	}
+	return vars.Unset("OPTARG")
+}
+
+// next 解析下一个选项，optind 和 pos 是当前位置，解析后更新；没有更多选项时返回 true
+func (c *GetoptsCommand) next(params []string, optind, pos *int, optstring string, longOpts []longOption, hasLong bool) (getoptsResult, bool) {
+	if *optind > len(params) {
+		*pos = 0
+		return getoptsResult{}, true
+	}
+	arg := []rune(params[*optind-1])
+
+	if *pos == 0 {
+		switch {
+		case string(arg) == "--":
+			*optind++
+			return getoptsResult{}, true
+		case len(arg) < 2 || arg[0] != '-':
+			return getoptsResult{}, true
+		case hasLong && arg[1] == '-':
+			*optind++
+			return nextLong(params, optind, string(arg[2:]), longOpts), false
+		}
+		*pos = 1
+	}
+
+	ch := arg[*pos]
+	*pos++
+	finishArg := func() {
+		if *pos >= len(arg) {
+			*optind++
+			*pos = 0
+		}
+	}
+
+	idx := strings.IndexRune(optstring, ch)
+	if ch == ':' || idx < 0 {
+		finishArg()
+		return getoptsResult{
+			name:   "?",
+			optarg: string(ch),
+			errMsg: fmt.Sprintf("非法选项 -- %c", ch),
+		}, false
+	}
+
+	if !strings.HasPrefix(optstring[idx+len(string(ch)):], ":") {
+		finishArg()
+		return getoptsResult{name: string(ch)}, false
+	}
+
+	// 参数可以紧跟在选项后面（-ofile），也可以是下一个参数（-o file）
+	if *pos < len(arg) {
+		value := string(arg[*pos:])
+		*optind++
+		*pos = 0
+		return getoptsResult{name: string(ch), optarg: value, hasArg: true}, false
+	}
+	*optind++
+	*pos = 0
+	if *optind > len(params) {
+		return getoptsResult{
+			name:   ":",
+			optarg: string(ch),
+			errMsg: fmt.Sprintf("选项需要参数 -- %c", ch),
+		}, false
+	}
+	value := params[*optind-1]
+	*optind++
+	return getoptsResult{name: string(ch), optarg: value, hasArg: true}, false
+}
+
+// nextLong 解析长选项，optind 已指向选项之后的参数
+func nextLong(params []string, optind *int, arg string, longOpts []longOption) getoptsResult {
+	name, value, hasValue := strings.Cut(arg, "=")
+
+	var opt *longOption
+	for i := range longOpts {
+		if longOpts[i].name == name {
+			opt = &longOpts[i]
+			break
+		}
+	}
+	if opt == nil {
+		return getoptsResult{
+			name:   "?",
+			optarg: name,
+			errMsg: fmt.Sprintf("非法选项 -- %s", name),
+		}
+	}
+
+	if !opt.hasArg {
+		if hasValue {
+			return getoptsResult{
+				name:   "?",
+				optarg: name,
+				errMsg: fmt.Sprintf("选项不接受参数 -- %s", name),
+			}
+		}
+		return getoptsResult{name: name}
+	}
+
+	// 参数可以写作 --name=value 或 --name value
+	if hasValue {
+		return getoptsResult{name: name, optarg: value, hasArg: true}
+	}
+	if *optind > len(params) {
+		return getoptsResult{
+			name:   ":",
+			optarg: name,
+			errMsg: fmt.Sprintf("选项需要参数 -- %s", name),
+		}
+	}
+	value = params[*optind-1]
+	*optind++
+	return getoptsResult{name: name, optarg: value, hasArg: true}
+}
+
+// parseLongOptions 解析逗号分隔的长选项列表，名字后加 : 表示需要参数
+func parseLongOptions(spec string) []longOption {
+	var opts []longOption
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		opts = append(opts, longOption{
+			name:   strings.TrimSuffix(field, ":"),
+			hasArg: strings.HasSuffix(field, ":"),
+		})
+	}
+	return opts
+}
+
+func (c *GetoptsCommand) Help() string {
+	return `getopts - 逐个解析位置参数中的选项
+
+用法:
+  getopts [-l 长选项] 选项字符串 变量名 [参数...]
+
+说明:
+  每次调用解析一个选项，把选项字母写入变量，选项的参数写入 OPTARG，
+  下一个要处理的参数的序号写入 OPTIND。遇到第一个非选项参数、-- 或
+  参数用完时，变量设为 ?，退出码为 1，因此通常写在 while 条件中。
+  没有给出参数时解析位置参数 $1 $2 ...。
+
+  选项字符串中字母后加 : 表示该选项需要参数（-o file 或 -ofile），
+  多个不带参数的选项可以组合（-abc）。
+
+  错误处理:
+    默认模式   未知选项或缺少参数时输出错误信息，变量设为 ?
+    静默模式   选项字符串以 : 开头时不输出错误信息，未知选项时变量为 ?，
+               缺少参数时变量为 :，OPTARG 为出错的选项
+  OPTERR=0 同样关闭错误信息。
+
+  重新解析（例如在另一个函数中）之前把 OPTIND 设为 1，函数中可以用
+  local OPTIND 避免影响调用者。
+
+长选项（Lish 扩展）:
+  -l, --long 列表   逗号分隔的长选项名，名字后加 : 表示需要参数
+                    （--name value 或 --name=value）。解析到长选项时
+                    变量设为选项名（不含 --）
+
+示例:
+  while getopts "vo:" opt; do
+      if [ "$opt" = v ]; then
+          verbose=1
+      elif [ "$opt" = o ]; then
+          output=$OPTARG
+      fi
+  done
+
+  while getopts -l "help,output:" "ho:" opt; do
+      if [ "$opt" = h ] || [ "$opt" = help ]; then
+          usage
+      fi
+  done`
+}
+
+func (c *GetoptsCommand) ShortHelp() string {
+	return "逐个解析位置参数中的选项"
+}
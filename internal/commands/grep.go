@@ -2,210 +2,665 @@ package commands
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	"github.com/Lingbou/Lish/internal/theme"
 	"github.com/spf13/pflag"
 )
 
+// 二进制文件的处理方式（--binary-files）
+const (
+	binaryReport = "binary"        // 只报告是否匹配
+	binaryText   = "text"          // 当作文本处理
+	binarySkip   = "without-match" // 跳过
+)
+
 type GrepCommand struct {
-	stdout *os.File
-	stdin  *os.File
+	themeManager *theme.Manager
 }
 
-func NewGrepCommand(stdout, stdin *os.File) *GrepCommand {
-	return &GrepCommand{
-		stdout: stdout,
-		stdin:  stdin,
-	}
+func NewGrepCommand(themeManager *theme.Manager) *GrepCommand {
+	return &GrepCommand{themeManager: themeManager}
 }
 
 func (c *GrepCommand) Name() string {
 	return "grep"
 }
 
+// grepOptions 一次 grep 调用的选项
+type grepOptions struct {
+	invert       bool
+	lineNumber   bool
+	count        bool
+	listMatching bool // -l
+	listMissing  bool // -L
+	onlyMatching bool
+	wordRegexp   bool
+	quiet        bool
+	noMessages   bool
+	withFilename bool
+	recursive    bool
+	before       int
+	after        int
+	maxCount     int // 小于 0 表示不限制
+	binaryFiles  string
+	include      []string
+	exclude      []string
+	excludeDir   []string
+}
+
+// grepper 保存一次 grep 调用的状态，可以依次搜索多个文件
+type grepper struct {
+	opts   grepOptions
+	re     *regexp.Regexp // 为 nil 时没有任何模式（-f 空文件），不匹配任何行
+	stdout io.Writer
+	stderr io.Writer
+
+	// 彩色输出时使用的主题颜色
+	colored        bool
+	matchColor     theme.Color
+	fileColor      theme.Color
+	lineColor      theme.Color
+	separatorColor theme.Color
+
+	matched bool // 有行被选中（-L 模式下为有文件被列出）
+	failed  bool // 出现过错误
+	done    bool // -q 已找到匹配，不再搜索
+	printed bool // 已经输出过行，之后不连续的行之间需要 -- 分隔
+}
+
 func (c *GrepCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+	stderr := streams.Stderr(ctx)
+	stdin := streams.Stdin(ctx)
+
 	flags := pflag.NewFlagSet("grep", pflag.ContinueOnError)
 	ignoreCase := flags.BoolP("ignore-case", "i", false, "忽略大小写")
 	lineNumber := flags.BoolP("line-number", "n", false, "显示行号")
 	invert := flags.BoolP("invert-match", "v", false, "反向匹配")
 	recursive := flags.BoolP("recursive", "r", false, "递归搜索目录")
-
-	if err := flags.Parse(args); err != nil {
+	afterContext := flags.IntP("after-context", "A", 0, "显示匹配行之后的 N 行")
+	beforeContext := flags.IntP("before-context", "B", 0, "显示匹配行之前的 N 行")
+	contextLines := flags.IntP("context", "C", 0, "显示匹配行前后各 N 行")
+	count := flags.BoolP("count", "c", false, "只显示匹配的行数")
+	listMatching := flags.BoolP("files-with-matches", "l", false, "只显示有匹配的文件名")
+	listMissing := flags.BoolP("files-without-match", "L", false, "只显示没有匹配的文件名")
+	onlyMatching := flags.BoolP("only-matching", "o", false, "只显示匹配的部分")
+	wordRegexp := flags.BoolP("word-regexp", "w", false, "只匹配整个单词")
+	lineRegexp := flags.BoolP("line-regexp", "x", false, "只匹配整行")
+	fixed := flags.BoolP("fixed-strings", "F", false, "模式是普通字符串")
+	flags.BoolP("extended-regexp", "E", false, "使用扩展正则表达式（默认）")
+	patterns := flags.StringArrayP("regexp", "e", nil, "搜索模式，可以多次使用")
+	patternFiles := flags.StringArrayP("file", "f", nil, "从文件读取模式，每行一个")
+	maxCount := flags.IntP("max-count", "m", -1, "每个文件最多选中 N 行")
+	withFilename := flags.BoolP("with-filename", "H", false, "显示文件名")
+	noFilename := flags.BoolP("no-filename", "h", false, "不显示文件名")
+	quiet := flags.BoolP("quiet", "q", false, "不输出，只返回退出码")
+	flags.BoolVar(quiet, "silent", false, "同 --quiet")
+	noMessages := flags.BoolP("no-messages", "s", false, "不显示文件错误信息")
+	text := flags.BoolP("text", "a", false, "把二进制文件当作文本")
+	skipBinary := flags.BoolP("I", "I", false, "跳过二进制文件")
+	binaryFiles := flags.String("binary-files", binaryReport, "二进制文件的处理方式")
+	include := flags.StringArray("include", nil, "只搜索文件名匹配的文件")
+	exclude := flags.StringArray("exclude", nil, "跳过文件名匹配的文件")
+	excludeDir := flags.StringArray("exclude-dir", nil, "递归时跳过名称匹配的目录")
+	color := flags.String("color", "auto", "高亮显示: always、never 或 auto")
+	flags.Lookup("color").NoOptDefVal = "always"
+	flags.StringVar(color, "colour", "auto", "同 --color")
+	flags.Lookup("colour").NoOptDefVal = "always"
+	flags.MarkHidden("colour")
+
+	if err := flags.Parse(expandNumericOption(flags, args, "-C")); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return nil
+		}
 		return err
 	}
+	operands := flags.Args()
 
-	grepArgs := flags.Args()
-	if len(grepArgs) == 0 {
-		return fmt.Errorf("grep: 需要指定搜索模式")
+	// 模式来自所有 -e 和 -f，都没有时使用第一个参数
+	var list []string
+	for _, p := range *patterns {
+		list = append(list, strings.Split(p, "\n")...)
 	}
-
-	pattern := grepArgs[0]
-	files := grepArgs[1:]
-
-	// 编译正则表达式
-	regexFlags := ""
-	if *ignoreCase {
-		regexFlags = "(?i)"
+	for _, name := range *patternFiles {
+		var data []byte
+		var err error
+		if name == "-" {
+			data, err = io.ReadAll(stdin)
+		} else {
+			data, err = os.ReadFile(name)
+		}
+		if err != nil {
+			return fmt.Errorf("grep: 无法读取模式文件: %w", err)
+		}
+		if len(data) > 0 {
+			list = append(list, strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")...)
+		}
 	}
-	re, err := regexp.Compile(regexFlags + pattern)
+	if len(*patterns) == 0 && len(*patternFiles) == 0 {
+		if len(operands) == 0 {
+			return fmt.Errorf("grep: 需要指定搜索模式")
+		}
+		list = append(list, strings.Split(operands[0], "\n")...)
+		operands = operands[1:]
+	}
+
+	re, err := compileGrepPattern(list, *fixed, *ignoreCase, *lineRegexp)
 	if err != nil {
-		return fmt.Errorf("grep: 无效的正则表达式: %w", err)
+		return fmt.Errorf("grep: %w", err)
 	}
 
-	// 如果没有指定文件，从标准输入读取
-	if len(files) == 0 {
-		return c.grepReader(c.stdin, "-", re, *lineNumber, *invert)
+	opts := grepOptions{
+		invert:       *invert,
+		lineNumber:   *lineNumber,
+		count:        *count,
+		listMatching: *listMatching,
+		listMissing:  *listMissing,
+		onlyMatching: *onlyMatching,
+		wordRegexp:   *wordRegexp && !*lineRegexp,
+		quiet:        *quiet,
+		noMessages:   *noMessages,
+		recursive:    *recursive,
+		before:       *contextLines,
+		after:        *contextLines,
+		maxCount:     *maxCount,
+		binaryFiles:  *binaryFiles,
+		include:      *include,
+		exclude:      *exclude,
+		excludeDir:   *excludeDir,
+	}
+	if flags.Changed("before-context") {
+		opts.before = *beforeContext
+	}
+	if flags.Changed("after-context") {
+		opts.after = *afterContext
+	}
+	if opts.before < 0 || opts.after < 0 {
+		return fmt.Errorf("grep: 上下文行数不能为负数")
+	}
+	switch {
+	case *text:
+		opts.binaryFiles = binaryText
+	case *skipBinary:
+		opts.binaryFiles = binarySkip
+	}
+	switch opts.binaryFiles {
+	case binaryReport, binaryText, binarySkip:
+	default:
+		return fmt.Errorf("grep: 无效的 --binary-files 参数: %s", opts.binaryFiles)
+	}
+	for _, glob := range append(append(append([]string{}, opts.include...), opts.exclude...), opts.excludeDir...) {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return fmt.Errorf("grep: 无效的通配符 '%s'", glob)
+		}
 	}
 
-	// 搜索指定文件
-	for _, file := range files {
+	// 没有文件时读取标准输入，-r 时搜索当前目录
+	if len(operands) == 0 {
 		if *recursive {
-			if err := c.grepRecursive(file, re, *lineNumber, *invert); err != nil {
-				fmt.Fprintf(os.Stderr, "grep: %v\n", err)
-			}
+			operands = []string{"."}
 		} else {
-			if err := c.grepFile(file, re, *lineNumber, *invert, len(files) > 1); err != nil {
-				fmt.Fprintf(os.Stderr, "grep: %v\n", err)
-			}
+			operands = []string{"-"}
 		}
 	}
+	opts.withFilename = len(operands) > 1 || (*recursive && operands[0] != "-")
+	if *withFilename {
+		opts.withFilename = true
+	}
+	if *noFilename {
+		opts.withFilename = false
+	}
 
-	return nil
-}
-
-func (c *GrepCommand) grepFile(filename string, re *regexp.Regexp, showLine, invert, showFilename bool) error {
-	file, err := os.Open(filename)
+	g := &grepper{opts: opts, re: re, stdout: stdout, stderr: stderr}
+	colored, err := colorEnabled(*color, stdout)
 	if err != nil {
-		return err
+		return fmt.Errorf("grep: %w", err)
+	}
+	if colored && c.themeManager != nil {
+		g.useScheme(c.themeManager.CurrentScheme())
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
-		matched := re.MatchString(line)
 
-		// 反向匹配
-		if invert {
-			matched = !matched
+	for _, name := range operands {
+		if g.done {
+			break
 		}
-
-		if matched {
-			c.printMatch(filename, lineNum, line, showLine, showFilename, re)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if name == "-" {
+			g.search(ctx, stdin, "(标准输入)")
+			continue
 		}
+		g.searchPath(ctx, name)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	return scanner.Err()
+	switch {
+	case g.failed && !(g.opts.quiet && g.matched):
+		return script.ExitStatus(2)
+	case !g.matched:
+		return script.ExitStatus(1)
+	}
+	return nil
 }
 
-func (c *GrepCommand) grepReader(reader io.Reader, name string, re *regexp.Regexp, showLine, invert bool) error {
-	scanner := bufio.NewScanner(reader)
-	lineNum := 0
+// colorEnabled 解析 --color 参数，auto 时只在输出到终端时高亮
+func colorEnabled(when string, stdout io.Writer) (bool, error) {
+	switch when {
+	case "always", "yes", "force":
+		return true, nil
+	case "never", "no", "none":
+		return false, nil
+	case "auto", "tty", "if-tty":
+		return streams.IsTerminal(stdout), nil
+	}
+	return false, fmt.Errorf("无效的 --color 参数: %s", when)
+}
 
-	for scanner.Scan() {
-		lineNum++
-		line := scanner.Text()
-		matched := re.MatchString(line)
+// useScheme 启用彩色输出并使用主题的颜色
+func (g *grepper) useScheme(scheme theme.ColorScheme) {
+	g.colored = true
+	g.matchColor = scheme.Match()
+	g.fileColor = scheme.PromptPath()
+	g.lineColor = scheme.Success()
+	g.separatorColor = scheme.Secondary()
+}
 
-		if invert {
-			matched = !matched
+// compileGrepPattern 把多个模式合并为一个正则表达式，任意一个匹配即可
+//
+// 使用最左最长匹配，使 -o 和高亮显示的范围与 GNU grep 一致。
+func compileGrepPattern(patterns []string, fixed, ignoreCase, lineRegexp bool) (*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	parts := make([]string, len(patterns))
+	for i, p := range patterns {
+		if fixed {
+			p = regexp.QuoteMeta(p)
+		} else if _, err := regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("无效的正则表达式 '%s': %v", p, err)
 		}
-
-		if matched {
-			c.printMatch(name, lineNum, line, showLine, false, re)
+		if lineRegexp {
+			p = "^(?:" + p + ")$"
 		}
+		parts[i] = "(?:" + p + ")"
+	}
+	expr := strings.Join(parts, "|")
+	if ignoreCase {
+		expr = "(?i)" + expr
 	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("无效的正则表达式: %v", err)
+	}
+	re.Longest()
+	return re, nil
+}
 
-	return scanner.Err()
+// searchPath 搜索命令行给出的文件或目录
+func (g *grepper) searchPath(ctx context.Context, name string) {
+	info, err := os.Stat(name)
+	if err != nil {
+		g.report(err)
+		return
+	}
+	if info.IsDir() {
+		if !g.opts.recursive {
+			g.report(fmt.Errorf("%s: 是一个目录", name))
+			return
+		}
+		g.walk(ctx, name)
+		return
+	}
+	if !g.included(filepath.Base(name)) {
+		return
+	}
+	g.searchFile(ctx, name)
 }
 
-func (c *GrepCommand) grepRecursive(path string, re *regexp.Regexp, showLine, invert bool) error {
-	return filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+// walk 递归搜索目录，跳过符号链接和特殊文件
+func (g *grepper) walk(ctx context.Context, root string) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return nil // 忽略无法访问的文件
+			g.report(err)
+			return nil
 		}
-
-		// 跳过目录
-		if info.IsDir() {
+		if g.done {
+			return filepath.SkipAll
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && matchAnyGlob(g.opts.excludeDir, d.Name()) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
-
-		// 跳过二进制文件（简单检测）
-		if !c.isTextFile(path) {
+		if !d.Type().IsRegular() || !g.included(d.Name()) {
 			return nil
 		}
-
-		return c.grepFile(path, re, showLine, invert, true)
+		g.searchFile(ctx, path)
+		return nil
 	})
 }
 
-func (c *GrepCommand) isTextFile(path string) bool {
-	// 简单的文本文件检测
-	ext := strings.ToLower(filepath.Ext(path))
-	textExts := []string{".txt", ".md", ".go", ".js", ".py", ".java", ".c", ".cpp", ".h", ".html", ".css", ".json", ".xml", ".yaml", ".yml"}
+// included 判断文件名是否满足 --include 和 --exclude
+func (g *grepper) included(base string) bool {
+	if len(g.opts.include) > 0 && !matchAnyGlob(g.opts.include, base) {
+		return false
+	}
+	return !matchAnyGlob(g.opts.exclude, base)
+}
 
-	for _, te := range textExts {
-		if ext == te {
+func matchAnyGlob(globs []string, name string) bool {
+	for _, glob := range globs {
+		if ok, _ := filepath.Match(glob, name); ok {
 			return true
 		}
 	}
+	return false
+}
+
+func (g *grepper) searchFile(ctx context.Context, name string) {
+	file, err := os.Open(name)
+	if err != nil {
+		g.report(err)
+		return
+	}
+	defer file.Close()
+	g.search(ctx, file, name)
+}
 
-	// 如果没有扩展名，尝试读取前几个字节判断
-	if ext == "" {
-		file, err := os.Open(path)
+// report 输出文件错误（-s 时不输出），并记录退出码为 2
+func (g *grepper) report(err error) {
+	g.failed = true
+	if !g.opts.noMessages {
+		fmt.Fprintf(g.stderr, "grep: %v\n", err)
+	}
+}
+
+// contextLine 等待作为前置上下文输出的行
+type contextLine struct {
+	num  int
+	text string
+}
+
+// search 搜索一个输入，按选项输出匹配的行、计数或文件名
+func (g *grepper) search(ctx context.Context, r io.Reader, name string) {
+	opts := &g.opts
+	reader := bufio.NewReaderSize(r, 64*1024)
+
+	// 根据第一次读取的内容检测二进制文件，不等待更多输入以免阻塞管道
+	binary := false
+	if opts.binaryFiles != binaryText {
+		reader.Peek(1)
+		head, _ := reader.Peek(reader.Buffered())
+		binary = bytes.IndexByte(head, 0) >= 0
+	}
+	if binary && opts.binaryFiles == binarySkip {
+		g.finish(name, 0)
+		return
+	}
+
+	// 只需要计数或文件名时不输出行
+	summary := opts.count || opts.listMatching || opts.listMissing || opts.quiet
+	before, after := opts.before, opts.after
+	if opts.onlyMatching {
+		before, after = 0, 0
+	}
+
+	var pending []contextLine // 前置上下文
+	afterLeft := 0            // 还需要输出的后置上下文行数
+	lastPrinted := 0          // 最后输出的行号
+	selected := 0
+	lineNum := 0
+
+	for opts.maxCount != 0 {
+		line, err := reader.ReadString('\n')
+		if line == "" && err != nil {
+			if err != io.EOF {
+				g.report(fmt.Errorf("%s: %v", name, err))
+			}
+			break
+		}
+		lineNum++
+		if lineNum%1024 == 0 && ctx.Err() != nil {
+			return
+		}
+		text := strings.TrimSuffix(line, "\n")
+		limited := opts.maxCount > 0 && selected >= opts.maxCount
+
+		if !limited && g.matchLine(text) != opts.invert {
+			selected++
+			reached := opts.maxCount > 0 && selected >= opts.maxCount
+			if summary {
+				if !opts.count || opts.quiet || reached {
+					break
+				}
+				continue
+			}
+			if binary {
+				fmt.Fprintf(g.stdout, "匹配到二进制文件 %s\n", name)
+				break
+			}
+			first := lineNum
+			if len(pending) > 0 {
+				first = pending[0].num
+			}
+			g.separate(first, lastPrinted, before+after > 0)
+			for _, p := range pending {
+				g.printLine(name, p.num, p.text, '-')
+			}
+			pending = pending[:0]
+			if opts.onlyMatching {
+				g.printMatches(name, lineNum, text)
+			} else {
+				g.printLine(name, lineNum, text, ':')
+			}
+			lastPrinted = lineNum
+			afterLeft = after
+			if reached && afterLeft == 0 {
+				break
+			}
+		} else if !summary && !binary {
+			switch {
+			case afterLeft > 0:
+				g.printLine(name, lineNum, text, '-')
+				lastPrinted = lineNum
+				afterLeft--
+			case limited:
+				// 达到 -m 的限制并输出完后置上下文
+				err = io.EOF
+			case before > 0:
+				if len(pending) == before {
+					pending = append(pending[:0], pending[1:]...)
+				}
+				pending = append(pending, contextLine{num: lineNum, text: text})
+			}
+		} else if limited {
+			break
+		}
 		if err != nil {
-			return false
+			break
 		}
-		defer file.Close()
+	}
+	g.finish(name, selected)
+}
 
-		buf := make([]byte, 512)
-		n, _ := file.Read(buf)
-		return c.isPrintable(buf[:n])
+// finish 处理一个输入的计数和文件名输出
+func (g *grepper) finish(name string, selected int) {
+	opts := &g.opts
+	if opts.listMissing {
+		if selected == 0 {
+			g.matched = true
+			if !opts.quiet {
+				fmt.Fprintln(g.stdout, g.paint(g.fileColor, name))
+			}
+		}
+		return
+	}
+	if selected == 0 {
+		if opts.count && !opts.quiet && !opts.listMatching {
+			g.printCount(name, 0)
+		}
+		return
 	}
+	g.matched = true
+	switch {
+	case opts.quiet:
+		g.done = true
+	case opts.listMatching:
+		fmt.Fprintln(g.stdout, g.paint(g.fileColor, name))
+	case opts.count:
+		g.printCount(name, selected)
+	}
+}
 
-	return false
+func (g *grepper) printCount(name string, n int) {
+	if g.opts.withFilename {
+		fmt.Fprint(g.stdout, g.paint(g.fileColor, name)+g.paint(g.separatorColor, ":"))
+	}
+	fmt.Fprintln(g.stdout, n)
+}
+
+// separate 在不连续的输出之间输出 -- 分隔行（只在有上下文时）
+func (g *grepper) separate(first, lastPrinted int, withContext bool) {
+	if withContext && g.printed && (lastPrinted == 0 || first > lastPrinted+1) {
+		fmt.Fprintln(g.stdout, g.paint(g.separatorColor, "--"))
+	}
+	g.printed = true
+}
+
+// matchLine 判断一行是否匹配
+func (g *grepper) matchLine(text string) bool {
+	if g.re == nil {
+		return false
+	}
+	if !g.opts.wordRegexp {
+		return g.re.MatchString(text)
+	}
+	return len(g.matches(text)) > 0
 }
 
-func (c *GrepCommand) isPrintable(data []byte) bool {
-	for _, b := range data {
-		if b == 0 {
+// matches 返回一行中所有匹配的位置，-w 时只保留前后都不是单词字符的匹配
+func (g *grepper) matches(text string) [][]int {
+	if g.re == nil {
+		return nil
+	}
+	locs := g.re.FindAllStringIndex(text, -1)
+	if !g.opts.wordRegexp {
+		return locs
+	}
+	result := locs[:0]
+	for _, loc := range locs {
+		if isWholeWord(text, loc[0], loc[1]) {
+			result = append(result, loc)
+		}
+	}
+	return result
+}
+
+func isWholeWord(text string, start, end int) bool {
+	if start == end {
+		return false
+	}
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if isWordRune(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordRune(r) {
 			return false
 		}
 	}
 	return true
 }
 
-func (c *GrepCommand) printMatch(filename string, lineNum int, line string, showLine, showFilename bool, re *regexp.Regexp) {
-	var prefix string
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
 
-	if showFilename {
-		prefix = fmt.Sprintf("%s:", filename)
+// prefix 生成文件名和行号前缀，sep 为 ':'（匹配行）或 '-'（上下文行）
+func (g *grepper) prefix(name string, lineNum int, sep byte) string {
+	var sb strings.Builder
+	if g.opts.withFilename {
+		sb.WriteString(g.paint(g.fileColor, name))
+		sb.WriteString(g.paint(g.separatorColor, string(sep)))
+	}
+	if g.opts.lineNumber {
+		sb.WriteString(g.paint(g.lineColor, strconv.Itoa(lineNum)))
+		sb.WriteString(g.paint(g.separatorColor, string(sep)))
 	}
+	return sb.String()
+}
 
-	if showLine {
-		prefix += fmt.Sprintf("%d:", lineNum)
+func (g *grepper) printLine(name string, lineNum int, text string, sep byte) {
+	if g.colored {
+		text = g.highlight(text)
 	}
+	fmt.Fprintln(g.stdout, g.prefix(name, lineNum, sep)+text)
+}
 
-	// 彩色高亮匹配部分
-	const (
-		colorRed   = "\033[31m"
-		colorReset = "\033[0m"
-	)
+// printMatches 实现 -o：每个非空匹配单独输出一行
+func (g *grepper) printMatches(name string, lineNum int, text string) {
+	if g.opts.invert {
+		return
+	}
+	for _, loc := range g.matches(text) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		fmt.Fprintln(g.stdout, g.prefix(name, lineNum, ':')+g.paint(g.matchColor, text[loc[0]:loc[1]]))
+	}
+}
 
-	highlighted := re.ReplaceAllStringFunc(line, func(match string) string {
-		return colorRed + match + colorReset
-	})
+// highlight 用主题的匹配颜色标出一行中所有匹配的部分
+func (g *grepper) highlight(text string) string {
+	locs := g.matches(text)
+	if len(locs) == 0 {
+		return text
+	}
+	var sb strings.Builder
+	last := 0
+	for _, loc := range locs {
+		if loc[0] == loc[1] {
+			continue
+		}
+		sb.WriteString(text[last:loc[0]])
+		sb.WriteString(g.matchColor.Apply(text[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
 
-	fmt.Fprintf(c.stdout, "%s%s\n", prefix, highlighted)
+// paint 在彩色输出时用 color 显示 text
+func (g *grepper) paint(color theme.Color, text string) string {
+	if !g.colored {
+		return text
+	}
+	return color.Apply(text)
 }
 
 func (c *GrepCommand) Help() string {
@@ -213,24 +668,67 @@ func (c *GrepCommand) Help() string {
 
 用法:
   grep [选项] 模式 [文件...]
-
-选项:
-  -i, --ignore-case   忽略大小写
-  -n, --line-number   显示行号
-  -v, --invert-match  反向匹配（显示不匹配的行）
-  -r, --recursive     递归搜索目录
+  grep [选项] -e 模式 [-e 模式...] [文件...]
+  grep [选项] -f 模式文件 [文件...]
+
+匹配选项:
+  -e, --regexp <模式>       搜索模式，可以多次使用（匹配任意一个即可）
+  -f, --file <文件>         从文件读取模式，每行一个（- 表示标准输入）
+  -E, --extended-regexp     使用扩展正则表达式（默认）
+  -F, --fixed-strings       模式是普通字符串而不是正则表达式
+  -i, --ignore-case         忽略大小写
+  -w, --word-regexp         只匹配整个单词
+  -x, --line-regexp         只匹配整行
+  -v, --invert-match        反向匹配（选中不匹配的行）
+
+输出选项:
+  -n, --line-number         显示行号
+  -H, --with-filename       显示文件名（搜索多个文件时默认显示）
+  -h, --no-filename         不显示文件名
+  -o, --only-matching       只显示匹配的部分，每个一行
+  -c, --count               只显示每个文件选中的行数
+  -l, --files-with-matches  只显示有匹配的文件名
+  -L, --files-without-match 只显示没有匹配的文件名
+  -m, --max-count <N>       每个文件选中 N 行后停止
+  -q, --quiet, --silent     不输出任何内容，只返回退出码
+  -s, --no-messages         不显示文件不存在或无法读取的错误
+  --color[=WHEN]            高亮显示匹配（使用当前主题的匹配颜色），
+                            WHEN 为 always、never 或 auto（默认，输出到终端时高亮）
+
+上下文:
+  -A, --after-context <N>   显示匹配行之后的 N 行
+  -B, --before-context <N>  显示匹配行之前的 N 行
+  -C, --context <N>, -N     显示匹配行前后各 N 行
+                            上下文行用 - 代替 : 分隔，不连续的部分之间输出 --
+
+文件选项:
+  -r, --recursive           递归搜索目录（不跟随符号链接），没有文件时搜索当前目录
+  --include <GLOB>          只搜索文件名匹配通配符的文件，可以多次使用
+  --exclude <GLOB>          跳过文件名匹配通配符的文件，可以多次使用
+  --exclude-dir <GLOB>      递归时跳过名称匹配通配符的目录，可以多次使用
+  -a, --text                把二进制文件当作文本处理
+  -I                        跳过二进制文件
+  --binary-files=<TYPE>     二进制文件的处理方式: binary（默认，只报告是否匹配）、
+                            text 或 without-match
 
 描述:
-  在文件中搜索匹配模式的行。支持正则表达式。
-  如果不指定文件，从标准输入读取。
+  在文件中搜索匹配模式的行。模式使用 Go 正则表达式语法（与 POSIX 扩展正则表达式相近）。
+  如果不指定文件，从标准输入读取。包含 NUL 字节的文件被视为二进制文件。
 
 示例:
-  grep "error" log.txt            # 搜索 "error"
-  grep -i "ERROR" log.txt         # 忽略大小写搜索
-  grep -n "error" log.txt         # 显示行号
-  grep -r "TODO" .                # 递归搜索当前目录
-  ls | grep ".txt"                # 从管道输入搜索
-  grep -v "^#" file.txt           # 显示非注释行`
+  grep "error" log.txt                    # 搜索 "error"
+  grep -i "ERROR" log.txt                 # 忽略大小写搜索
+  grep -n -C 2 "panic" log.txt            # 显示行号和前后两行
+  grep -r --include='*.go' "TODO" .       # 递归搜索所有 Go 文件
+  grep -rl --exclude-dir=.git "foo" .     # 列出包含 foo 的文件
+  grep -c "^$" file.txt                   # 统计空行数
+  grep -o -E "[0-9]+" file.txt            # 提取所有数字
+  grep -F -e "a.b" -e "c*d" file.txt      # 按普通字符串搜索多个模式
+  grep -v "^#" file.txt                   # 显示非注释行
+  ls | grep ".txt"                        # 从管道输入搜索
+
+退出码:
+  0 有选中的行，1 没有选中的行，2 出现错误（-q 时找到匹配仍返回 0）`
 }
 
 func (c *GrepCommand) ShortHelp() string {
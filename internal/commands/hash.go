@@ -0,0 +1,272 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// HashEntry 命令路径缓存中的一项
+type HashEntry struct {
+	Name string
+	Path string
+	Hits int // 通过缓存执行的次数
+}
+
+// PathCache 外部命令的 PATH 查找缓存
+//
+// 执行外部命令时先查缓存，缓存的文件不存在时重新查找；PATH 改变后
+// 整个缓存失效。包含 / 的命令名不查找 PATH，也不缓存。
+type PathCache struct {
+	mu      sync.Mutex
+	entries map[string]*HashEntry
+	path    string // 建立缓存时的 PATH
+}
+
+// NewPathCache 创建命令路径缓存
+func NewPathCache() *PathCache {
+	return &PathCache{
+		entries: make(map[string]*HashEntry),
+		path:    os.Getenv("PATH"),
+	}
+}
+
+// Lookup 查找命令的路径并记录一次命中，用于执行外部命令
+func (h *PathCache) Lookup(name string) (string, error) {
+	if strings.ContainsRune(name, '/') {
+		return exec.LookPath(name)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkPath()
+
+	if entry, ok := h.entries[name]; ok {
+		if info, err := os.Stat(entry.Path); err == nil && !info.IsDir() {
+			entry.Hits++
+			return entry.Path, nil
+		}
+		delete(h.entries, name)
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", err
+	}
+	h.entries[name] = &HashEntry{Name: name, Path: path, Hits: 1}
+	return path, nil
+}
+
+// Peek 返回已缓存的路径，不查找 PATH
+func (h *PathCache) Peek(name string) (string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkPath()
+
+	if entry, ok := h.entries[name]; ok {
+		return entry.Path, true
+	}
+	return "", false
+}
+
+// Add 把命令加入缓存，path 为空时在 PATH 中查找
+func (h *PathCache) Add(name, path string) error {
+	if path == "" {
+		var err error
+		if path, err = exec.LookPath(name); err != nil {
+			return err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkPath()
+	h.entries[name] = &HashEntry{Name: name, Path: path}
+	return nil
+}
+
+// Remove 从缓存中删除命令，命令不在缓存中时返回 false
+func (h *PathCache) Remove(name string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	_, ok := h.entries[name]
+	delete(h.entries, name)
+	return ok
+}
+
+// Clear 清空缓存
+func (h *PathCache) Clear() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = make(map[string]*HashEntry)
+}
+
+// Entries 返回按命令名排序的缓存内容
+func (h *PathCache) Entries() []HashEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checkPath()
+
+	result := make([]HashEntry, 0, len(h.entries))
+	for _, entry := range h.entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// checkPath PATH 改变时清空缓存，调用者需要持有锁
+func (h *PathCache) checkPath() {
+	if path := os.Getenv("PATH"); path != h.path {
+		h.entries = make(map[string]*HashEntry)
+		h.path = path
+	}
+}
+
+// HashCommand hash 命令 - 管理命令路径缓存
+type HashCommand struct {
+	cache *PathCache
+}
+
+// NewHashCommand 创建 hash 命令
+func NewHashCommand(cache *PathCache) *HashCommand {
+	return &HashCommand{
+		cache: cache,
+	}
+}
+
+func (c *HashCommand) Name() string {
+	return "hash"
+}
+
+func (c *HashCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
+	flags := flag.NewFlagSet("hash", flag.ContinueOnError)
+	reset := flags.BoolP("reset", "r", false, "清空缓存")
+	remove := flags.BoolP("delete", "d", false, "从缓存中删除命令")
+	path := flags.StringP("path", "p", "", "把指定路径作为命令的位置")
+	show := flags.BoolP("show", "t", false, "显示命令缓存的路径")
+	list := flags.BoolP("list", "l", false, "以可重新输入的形式列出缓存")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	names := flags.Args()
+
+	if *reset {
+		c.cache.Clear()
+	}
+
+	switch {
+	case *remove:
+		var err error
+		for _, name := range names {
+			if !c.cache.Remove(name) {
+				err = fmt.Errorf("hash: %s: 不在缓存中", name)
+			}
+		}
+		return err
+
+	case *path != "":
+		if len(names) == 0 {
+			return fmt.Errorf("用法: hash -p 路径 命令名...")
+		}
+		for _, name := range names {
+			c.cache.Add(name, *path)
+		}
+		return nil
+
+	case *show:
+		var err error
+		for _, name := range names {
+			p, ok := c.cache.Peek(name)
+			if !ok {
+				err = fmt.Errorf("hash: %s: 不在缓存中", name)
+				continue
+			}
+			if len(names) > 1 {
+				fmt.Fprintf(stdout, "%s\t%s\n", name, p)
+			} else {
+				fmt.Fprintln(stdout, p)
+			}
+		}
+		return err
+
+	case len(names) > 0:
+		var err error
+		for _, name := range names {
+			if addErr := c.cache.Add(name, ""); addErr != nil {
+				err = fmt.Errorf("hash: %s: 未找到", name)
+			}
+		}
+		return err
+	}
+
+	if *reset {
+		return nil
+	}
+
+	entries := c.cache.Entries()
+	if len(entries) == 0 {
+		if !*list {
+			fmt.Fprintln(stdout, "hash: 缓存为空")
+		}
+		return nil
+	}
+	if *list {
+		for _, entry := range entries {
+			fmt.Fprintf(stdout, "hash -p %s %s\n", entry.Path, entry.Name)
+		}
+		return nil
+	}
+	fmt.Fprintln(stdout, "命中\t命令")
+	for _, entry := range entries {
+		fmt.Fprintf(stdout, "%4d\t%s\n", entry.Hits, entry.Path)
+	}
+	return nil
+}
+
+func (c *HashCommand) Help() string {
+	return `hash - 管理外部命令的路径缓存
+
+用法:
+  hash [-lr] [-p 路径] [-dt] [命令名...]
+
+说明:
+  执行外部命令时，Lish 会缓存在 PATH 中找到的路径，之后直接使用缓存。
+  缓存的文件被删除时自动重新查找；修改 PATH 会清空缓存。
+  不带参数时列出缓存的命令及其命中次数。
+
+选项:
+  -r, --reset       清空缓存
+  -d, --delete      从缓存中删除指定的命令
+  -p, --path 路径   把指定路径作为命令的位置，不查找 PATH
+  -t, --show        显示命令缓存的路径
+  -l, --list        以 hash -p 的形式列出缓存，可以重新输入
+
+示例:
+  hash                  # 列出缓存
+  hash git go           # 查找并缓存 git 和 go
+  hash -t git           # 显示缓存的 git 路径
+  hash -p /opt/go/bin/go go
+  hash -r               # 清空缓存`
+}
+
+func (c *HashCommand) ShortHelp() string {
+	return "管理外部命令的路径缓存"
+}
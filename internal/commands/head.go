@@ -3,18 +3,19 @@ package commands
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"io"
 
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type HeadCommand struct {
-	stdout *os.File
-}
+type HeadCommand struct{}
 
-func NewHeadCommand(stdout *os.File) *HeadCommand {
-	return &HeadCommand{stdout: stdout}
+func NewHeadCommand() *HeadCommand {
+	return &HeadCommand{}
 }
 
 func (c *HeadCommand) Name() string {
@@ -22,69 +23,183 @@ func (c *HeadCommand) Name() string {
 }
 
 func (c *HeadCommand) Execute(ctx context.Context, args []string) error {
-	flags := pflag.NewFlagSet("head", pflag.ContinueOnError)
-	lines := flags.IntP("lines", "n", 10, "显示的行数")
+	std := streams.From(ctx)
 
-	if err := flags.Parse(args); err != nil {
+	flags := pflag.NewFlagSet("head", pflag.ContinueOnError)
+	lines := flags.StringP("lines", "n", "10", "显示的行数")
+	byteCount := flags.StringP("bytes", "c", "", "显示的字节数")
+	quiet := flags.BoolP("quiet", "q", false, "不显示文件名标题")
+	flags.BoolVar(quiet, "silent", false, "同 --quiet")
+	verbose := flags.BoolP("verbose", "v", false, "总是显示文件名标题")
+
+	if err := flags.Parse(expandNumericOption(flags, args, "-n")); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return nil
+		}
 		return err
 	}
 
+	useBytes := flags.Changed("bytes")
+	spec := *lines
+	if useBytes {
+		spec = *byteCount
+	}
+	n, sign, err := parseCount(spec)
+	if err != nil {
+		return fmt.Errorf("head: %w", err)
+	}
+	// -n -N 表示除最后 N 行以外的所有行
+	allBut := sign == '-'
+
 	files := flags.Args()
 	if len(files) == 0 {
-		return fmt.Errorf("head: 需要指定文件")
+		files = []string{"-"}
 	}
+	headers := (len(files) > 1 || *verbose) && !*quiet
 
-	for i, filename := range files {
-		if i > 0 {
-			fmt.Fprintln(c.stdout)
-		}
+	out := bufio.NewWriter(std.Stdout)
+	defer out.Flush()
 
-		if len(files) > 1 {
-			fmt.Fprintf(c.stdout, "==> %s <==\n", filename)
+	failed, printed := false, false
+	for _, name := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		file, err := openInput(ctx, name)
+		if err != nil {
+			fmt.Fprintf(std.Stderr, "head: %v\n", err)
+			failed = true
+			continue
+		}
+		if headers {
+			if printed {
+				out.WriteString("\n")
+			}
+			fmt.Fprintf(out, "==> %s <==\n", inputName(name))
+			printed = true
 		}
 
-		if err := c.printHead(filename, *lines); err != nil {
-			return err
+		switch {
+		case useBytes && allBut:
+			err = headAllButBytes(file, out, n)
+		case useBytes:
+			_, err = io.CopyN(out, file, n)
+			if err == io.EOF {
+				err = nil
+			}
+		case allBut:
+			err = headAllButLines(ctx, file, out, n)
+		default:
+			err = headLines(ctx, file, out, n)
+		}
+		file.Close()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(std.Stderr, "head: %s: %v\n", inputName(name), err)
+			failed = true
 		}
 	}
 
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	if failed {
+		return script.ExitStatus(1)
+	}
 	return nil
 }
 
-func (c *HeadCommand) printHead(filename string, n int) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %w", err)
+// headLines 输出前 n 行，之后不再读取输入（因此可以用于无限的输入）
+func headLines(ctx context.Context, r io.Reader, out *bufio.Writer, n int64) error {
+	s := newLineStream(r, out)
+	for i := int64(0); i < n; i++ {
+		if i%1024 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line, err := s.readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		out.WriteString(line)
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	count := 0
+	return nil
+}
 
-	for scanner.Scan() && count < n {
-		fmt.Fprintln(c.stdout, scanner.Text())
-		count++
+// headAllButLines 输出除最后 n 行以外的行，只保留 n 行在内存中
+func headAllButLines(ctx context.Context, r io.Reader, out *bufio.Writer, n int64) error {
+	s := newLineStream(r, out)
+	ring := newLineRing(int(n))
+	for i := 0; ; i++ {
+		if i%1024 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line, err := s.readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if evicted, ok := ring.push(line); ok {
+			out.WriteString(evicted)
+		}
 	}
+}
 
-	return scanner.Err()
+// headAllButBytes 输出除最后 n 个字节以外的内容
+func headAllButBytes(r io.Reader, out *bufio.Writer, n int64) error {
+	var pending []byte
+	buf := make([]byte, 32*1024)
+	for {
+		size, err := r.Read(buf)
+		pending = append(pending, buf[:size]...)
+		if extra := int64(len(pending)) - n; extra > 0 {
+			out.Write(pending[:extra])
+			pending = append(pending[:0], pending[extra:]...)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
 }
 
 func (c *HeadCommand) Help() string {
 	return `head - 显示文件头部内容
 
 用法:
-  head [选项] 文件...
+  head [选项] [文件...]
 
 选项:
-  -n, --lines  显示的行数（默认 10 行）
+  -n, --lines=[-]N   显示前 N 行（默认 10 行）；N 前有 - 时显示除最后 N 行外的所有行
+  -c, --bytes=[-]N   显示前 N 个字节；N 前有 - 时显示除最后 N 个字节外的所有内容
+  -q, --quiet        不显示文件名标题
+  -v, --verbose      总是显示文件名标题
+  -NUM               同 -n NUM
+
+  N 可以带单位：b (512)、K/KiB (1024)、M/MiB、G/GiB、kB (1000)、MB、GB。
 
 描述:
-  显示文件的前 N 行内容。
+  显示文件的前 N 行内容。没有文件或文件为 - 时读取标准输入。
+  多个文件时在每个文件前显示 "==> 文件名 <==" 标题。
+
+  输入是逐行处理的，读到需要的行数后立即停止，因此可以用于
+  无限的输入（如 yes | head）；-n -N 只在内存中保留 N 行。
 
 示例:
   head file.txt           # 显示前 10 行
   head -n 20 file.txt     # 显示前 20 行
-  head -n 5 *.txt         # 显示多个文件的前 5 行`
+  head -5 *.txt           # 显示多个文件的前 5 行
+  head -n -1 file.txt     # 去掉最后一行
+  head -c 1K data.bin     # 显示前 1024 个字节
+  ls | head -n 3          # 只显示前 3 项`
 }
 
 func (c *HeadCommand) ShortHelp() string {
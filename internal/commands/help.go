@@ -3,19 +3,18 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os"
 	"sort"
+
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
 type HelpCommand struct {
 	registry *Registry
-	stdout   *os.File
 }
 
-func NewHelpCommand(registry *Registry, stdout *os.File) *HelpCommand {
+func NewHelpCommand(registry *Registry) *HelpCommand {
 	return &HelpCommand{
 		registry: registry,
-		stdout:   stdout,
 	}
 }
 
@@ -24,6 +23,8 @@ func (c *HelpCommand) Name() string {
 }
 
 func (c *HelpCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	if len(args) > 0 {
 		// 显示特定命令的帮助
 		cmdName := args[0]
@@ -31,13 +32,13 @@ func (c *HelpCommand) Execute(ctx context.Context, args []string) error {
 		if !exists {
 			return fmt.Errorf("未知命令: %s", cmdName)
 		}
-		fmt.Fprintln(c.stdout, cmd.Help())
+		fmt.Fprintln(stdout, cmd.Help())
 		return nil
 	}
 	
 	// 显示所有命令列表
-	fmt.Fprintln(c.stdout, "Lish - Linux 风格的轻量级 Shell")
-	fmt.Fprintln(c.stdout, "\n可用命令:")
+	fmt.Fprintln(stdout, "Lish - Linux 风格的轻量级 Shell")
+	fmt.Fprintln(stdout, "\n可用命令:")
 	
 	commands := c.registry.GetAll()
 	names := make([]string, 0, len(commands))
@@ -48,10 +49,10 @@ func (c *HelpCommand) Execute(ctx context.Context, args []string) error {
 	
 	for _, name := range names {
 		cmd := commands[name]
-		fmt.Fprintf(c.stdout, "  %-12s %s\n", name, cmd.ShortHelp())
+		fmt.Fprintf(stdout, "  %-12s %s\n", name, cmd.ShortHelp())
 	}
 	
-	fmt.Fprintln(c.stdout, "\n输入 'help <命令>' 查看详细帮助信息")
+	fmt.Fprintln(stdout, "\n输入 'help <命令>' 查看详细帮助信息")
 	
 	return nil
 }
@@ -3,19 +3,19 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type HistoryCommand struct {
-	stdout *os.File
-}
+type HistoryCommand struct{}
 
-func NewHistoryCommand(stdout *os.File) *HistoryCommand {
-	return &HistoryCommand{stdout: stdout}
+func NewHistoryCommand() *HistoryCommand {
+	return &HistoryCommand{}
 }
 
 func (c *HistoryCommand) Name() string {
@@ -23,6 +23,8 @@ func (c *HistoryCommand) Name() string {
 }
 
 func (c *HistoryCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := pflag.NewFlagSet("history", pflag.ContinueOnError)
 	clear := flags.BoolP("clear", "c", false, "清空历史记录")
 	count := flags.IntP("count", "n", 0, "显示最近 N 条记录")
@@ -34,10 +36,10 @@ func (c *HistoryCommand) Execute(ctx context.Context, args []string) error {
 	historyFile := c.getHistoryFile()
 
 	if *clear {
-		return c.clearHistory(historyFile)
+		return c.clearHistory(historyFile, stdout)
 	}
 
-	return c.showHistory(historyFile, *count)
+	return c.showHistory(historyFile, *count, stdout)
 }
 
 func (c *HistoryCommand) getHistoryFile() string {
@@ -48,11 +50,11 @@ func (c *HistoryCommand) getHistoryFile() string {
 	return filepath.Join(homeDir, ".lish_history")
 }
 
-func (c *HistoryCommand) showHistory(historyFile string, count int) error {
+func (c *HistoryCommand) showHistory(historyFile string, count int, stdout io.Writer) error {
 	content, err := os.ReadFile(historyFile)
 	if err != nil {
 		if os.IsNotExist(err) {
-			fmt.Fprintln(c.stdout, "历史记录为空")
+			fmt.Fprintln(stdout, "历史记录为空")
 			return nil
 		}
 		return fmt.Errorf("读取历史记录失败: %w", err)
@@ -77,18 +79,18 @@ func (c *HistoryCommand) showHistory(historyFile string, count int) error {
 
 	// 显示历史记录
 	for i := start; i < len(history); i++ {
-		fmt.Fprintf(c.stdout, "%5d  %s\n", i+1, history[i])
+		fmt.Fprintf(stdout, "%5d  %s\n", i+1, history[i])
 	}
 
 	return nil
 }
 
-func (c *HistoryCommand) clearHistory(historyFile string) error {
+func (c *HistoryCommand) clearHistory(historyFile string, stdout io.Writer) error {
 	if err := os.Remove(historyFile); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("清空历史记录失败: %w", err)
 	}
 
-	fmt.Fprintln(c.stdout, "历史记录已清空")
+	fmt.Fprintln(stdout, "历史记录已清空")
 	return nil
 }
 
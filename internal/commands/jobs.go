@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+)
+
+// 由输入拼成命令并执行的内置命令（xargs、parallel）共用的函数
+
+// openNull 打开 /dev/null 作为被执行命令的标准输入，使命令不会读走
+// xargs、parallel 自己的输入
+func openNull() io.ReadCloser {
+	f, err := os.Open(os.DevNull)
+	if err != nil {
+		return io.NopCloser(strings.NewReader(""))
+	}
+	return f
+}
+
+// syncWriter 串行化并发执行的命令对同一个输出的写入
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// runJob 用 std 作为标准流执行一个命令，返回退出码
+//
+// 命令自己没有输出的错误（如命令不存在）以 name 为前缀写入 std.Stderr。
+func runJob(ctx context.Context, name string, std *streams.Streams, run func(ctx context.Context) error) int {
+	err := run(streams.With(ctx, std))
+	if err != nil && ctx.Err() == nil && !script.IsSilent(err) && !errors.Is(err, io.ErrClosedPipe) {
+		fmt.Fprintf(std.Stderr, "%s: %v\n", name, err)
+	}
+	return script.ExitCodeOf(err)
+}
+
+// parseDelimiter 解析 -d 指定的分隔符，支持 \n、\t、\0、\\ 和 \xHH
+func parseDelimiter(s string) (byte, error) {
+	if len(s) == 1 {
+		return s[0], nil
+	}
+	switch s {
+	case `\n`:
+		return '\n', nil
+	case `\t`:
+		return '\t', nil
+	case `\0`:
+		return 0, nil
+	case `\\`:
+		return '\\', nil
+	}
+	var b byte
+	if _, err := fmt.Sscanf(s, `\x%02x`, &b); err == nil && len(s) == 4 {
+		return b, nil
+	}
+	return 0, fmt.Errorf("分隔符必须是单个字节: %s", s)
+}
@@ -0,0 +1,378 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// JoinCommand join 命令 - 按公共字段连接两个已排序的文件
+type JoinCommand struct{}
+
+// NewJoinCommand 创建 join 命令
+func NewJoinCommand() *JoinCommand {
+	return &JoinCommand{}
+}
+
+func (c *JoinCommand) Name() string {
+	return "join"
+}
+
+// joinField -o 格式中的一项，file 为 0 表示连接字段
+type joinField struct {
+	file, field int
+}
+
+// joiner 保存一次 join 调用的选项
+type joiner struct {
+	sep        string // 字段分隔符，为空时按空白分隔
+	ignoreCase bool
+	unpaired   [3]bool // 输出文件 1、2 中没有匹配的行
+	paired     bool    // 输出匹配的行（-v 时为 false）
+	empty      string
+	format     []joinField
+	checkOrder bool
+	out        *bufio.Writer
+	stderr     io.Writer
+	unsorted   bool
+}
+
+// joinInput 一个输入文件，按键分组读取
+type joinInput struct {
+	index   int
+	name    string
+	field   int // 连接字段，从 0 开始
+	s       *lineStream
+	next    []string // 下一条记录，nil 表示已经读完
+	prevKey string
+	started bool
+	warned  bool
+}
+
+func (c *JoinCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
+	flags := flag.NewFlagSet("join", flag.ContinueOnError)
+	field1 := flags.IntP("field1", "1", 1, "文件 1 的连接字段")
+	field2 := flags.IntP("field2", "2", 1, "文件 2 的连接字段")
+	both := flags.IntP("j", "j", 0, "两个文件的连接字段")
+	sep := flags.StringP("separator", "t", "", "字段分隔符")
+	unpaired := flags.StringArrayP("unpaired", "a", nil, "同时输出文件 N 中没有匹配的行")
+	only := flags.StringArrayP("only-unpaired", "v", nil, "只输出文件 N 中没有匹配的行")
+	empty := flags.StringP("empty", "e", "", "替换缺少的字段")
+	format := flags.StringP("format", "o", "", "输出格式")
+	ignoreCase := flags.BoolP("ignore-case", "i", false, "比较时忽略大小写")
+	flags.Bool("check-order", true, "检查输入是否已排序")
+	noCheck := flags.Bool("nocheck-order", false, "不检查输入是否已排序")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	files := flags.Args()
+	if len(files) != 2 {
+		return fmt.Errorf("join: 需要两个文件")
+	}
+	if files[0] == "-" && files[1] == "-" {
+		return fmt.Errorf("join: 两个文件不能都是标准输入")
+	}
+	if flags.Changed("j") {
+		*field1, *field2 = *both, *both
+	}
+	if *field1 < 1 || *field2 < 1 {
+		return fmt.Errorf("join: 字段从 1 开始")
+	}
+	if utf8.RuneCountInString(*sep) > 1 {
+		return fmt.Errorf("join: 分隔符必须是单个字符")
+	}
+
+	j := &joiner{
+		sep:        *sep,
+		ignoreCase: *ignoreCase,
+		paired:     len(*only) == 0,
+		empty:      *empty,
+		checkOrder: !*noCheck,
+		stderr:     std.Stderr,
+	}
+	for _, list := range [][]string{*unpaired, *only} {
+		for _, n := range list {
+			switch n {
+			case "1":
+				j.unpaired[1] = true
+			case "2":
+				j.unpaired[2] = true
+			default:
+				return fmt.Errorf("join: 无效的文件编号: %s", n)
+			}
+		}
+	}
+	if *format != "" {
+		parsed, err := parseJoinFormat(*format)
+		if err != nil {
+			return fmt.Errorf("join: %w", err)
+		}
+		j.format = parsed
+	}
+
+	j.out = bufio.NewWriter(std.Stdout)
+	defer j.out.Flush()
+
+	var inputs [2]*joinInput
+	for i, name := range files {
+		file, err := openInput(ctx, name)
+		if err != nil {
+			return fmt.Errorf("join: %w", err)
+		}
+		defer file.Close()
+		inputs[i] = &joinInput{index: i + 1, name: inputName(name), field: []int{*field1, *field2}[i] - 1,
+			s: newLineStream(file, j.out)}
+	}
+
+	if err := j.run(ctx, inputs[0], inputs[1]); err != nil {
+		return fmt.Errorf("join: %w", err)
+	}
+	if err := j.out.Flush(); err != nil {
+		return err
+	}
+	if j.unsorted {
+		return script.ExitStatus(1)
+	}
+	return nil
+}
+
+// parseJoinFormat 解析 -o 格式，如 "0,1.2,2.3" 或 "1.1 2.2"
+func parseJoinFormat(spec string) ([]joinField, error) {
+	var fields []joinField
+	for _, item := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if item == "0" {
+			fields = append(fields, joinField{})
+			continue
+		}
+		file, field, ok := strings.Cut(item, ".")
+		n, err := strconv.Atoi(field)
+		if !ok || file != "1" && file != "2" || err != nil || n < 1 {
+			return nil, fmt.Errorf("无效的字段说明: %s", item)
+		}
+		fields = append(fields, joinField{file: int(file[0] - '0'), field: n})
+	}
+	return fields, nil
+}
+
+// split 把一行分成字段：指定 -t 时按分隔符分割，否则忽略开头的空白，按连续的空白分割
+func (j *joiner) split(line string) []string {
+	if j.sep != "" {
+		return strings.Split(line, j.sep)
+	}
+	return strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == '\t' })
+}
+
+// key 返回记录的连接字段，不存在时为空
+func (in *joinInput) key(record []string) string {
+	if in.field < len(record) {
+		return record[in.field]
+	}
+	return ""
+}
+
+func (j *joiner) compare(a, b string) int {
+	if j.ignoreCase {
+		a, b = strings.ToLower(a), strings.ToLower(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// advance 读取下一条记录，并检查输入是否已排序
+func (j *joiner) advance(in *joinInput) error {
+	line, err := in.s.readLine()
+	if err == io.EOF {
+		in.next = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	in.next = j.split(strings.TrimSuffix(line, "\n"))
+	key := in.key(in.next)
+	if in.started && j.checkOrder && !in.warned && j.compare(in.prevKey, key) > 0 {
+		fmt.Fprintf(j.stderr, "join: 文件 %d（%s）没有排序: %s\n", in.index, in.name, strings.TrimSuffix(line, "\n"))
+		in.warned = true
+		j.unsorted = true
+	}
+	in.prevKey, in.started = key, true
+	return nil
+}
+
+// group 读取键相同的一组记录
+func (j *joiner) group(in *joinInput) ([][]string, error) {
+	key := in.key(in.next)
+	var records [][]string
+	for in.next != nil && j.compare(in.key(in.next), key) == 0 {
+		records = append(records, in.next)
+		if err := j.advance(in); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// run 同时遍历两个已排序的文件，内存中只保存当前键相同的一组记录
+func (j *joiner) run(ctx context.Context, in1, in2 *joinInput) error {
+	if err := j.advance(in1); err != nil {
+		return err
+	}
+	if err := j.advance(in2); err != nil {
+		return err
+	}
+	for n := 0; in1.next != nil && in2.next != nil; n++ {
+		if n%1024 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		switch c := j.compare(in1.key(in1.next), in2.key(in2.next)); {
+		case c < 0:
+			j.emitUnpaired(in1, in1.next)
+			if err := j.advance(in1); err != nil {
+				return err
+			}
+		case c > 0:
+			j.emitUnpaired(in2, in2.next)
+			if err := j.advance(in2); err != nil {
+				return err
+			}
+		default:
+			g1, err := j.group(in1)
+			if err != nil {
+				return err
+			}
+			g2, err := j.group(in2)
+			if err != nil {
+				return err
+			}
+			if !j.paired {
+				continue
+			}
+			for _, r1 := range g1 {
+				for _, r2 := range g2 {
+					j.emit(in1, in2, r1, r2)
+				}
+			}
+		}
+	}
+	for _, in := range []*joinInput{in1, in2} {
+		for in.next != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			j.emitUnpaired(in, in.next)
+			if err := j.advance(in); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// emitUnpaired 输出没有匹配的记录（如果用 -a 或 -v 要求输出）
+func (j *joiner) emitUnpaired(in *joinInput, record []string) {
+	if !j.unpaired[in.index] {
+		return
+	}
+	if in.index == 1 {
+		j.emit(in, nil, record, nil)
+	} else {
+		j.emit(nil, in, nil, record)
+	}
+}
+
+// emit 输出一行；默认格式为连接字段、文件 1 的其余字段、文件 2 的其余字段
+func (j *joiner) emit(in1, in2 *joinInput, r1, r2 []string) {
+	sep := j.sep
+	if sep == "" {
+		sep = " "
+	}
+	var parts []string
+	if j.format != nil {
+		for _, f := range j.format {
+			switch {
+			case f.file == 0 && r1 != nil:
+				parts = append(parts, in1.key(r1))
+			case f.file == 0:
+				parts = append(parts, in2.key(r2))
+			case f.file == 1 && f.field <= len(r1):
+				parts = append(parts, r1[f.field-1])
+			case f.file == 2 && f.field <= len(r2):
+				parts = append(parts, r2[f.field-1])
+			default:
+				parts = append(parts, j.empty)
+			}
+		}
+	} else {
+		if r1 != nil {
+			parts = append(parts, in1.key(r1))
+		} else {
+			parts = append(parts, in2.key(r2))
+		}
+		for _, side := range []struct {
+			in     *joinInput
+			record []string
+		}{{in1, r1}, {in2, r2}} {
+			for i, field := range side.record {
+				if i != side.in.field {
+					parts = append(parts, field)
+				}
+			}
+		}
+	}
+	j.out.WriteString(strings.Join(parts, sep))
+	j.out.WriteByte('\n')
+}
+
+func (c *JoinCommand) Help() string {
+	return `join - 按公共字段连接两个已排序的文件
+
+用法:
+  join [选项] 文件1 文件2
+
+选项:
+  -1 N                 文件 1 的连接字段（默认第 1 个）
+  -2 N                 文件 2 的连接字段（默认第 1 个）
+  -j N                 两个文件都使用第 N 个字段连接
+  -t 字符              字段分隔符（默认按空白分隔，输出用空格分隔）
+  -a 1|2               同时输出该文件中没有匹配的行（可以重复）
+  -v 1|2               只输出该文件中没有匹配的行
+  -e 字符串            用字符串替换缺少的字段（与 -o 一起使用）
+  -o 格式              输出格式：逗号或空格分隔的 N.M（文件 N 的第 M 个字段）
+                       或 0（连接字段）
+  -i, --ignore-case    比较时忽略大小写
+      --nocheck-order  不检查输入是否已排序
+
+描述:
+  对于两个文件中连接字段相同的每一对行，输出一行：连接字段、文件 1 的
+  其余字段、文件 2 的其余字段。两个文件都必须按连接字段排序（如
+  sort -k 1,1）；发现没有排序的行时输出警告并以状态 1 退出。
+
+  两个文件同时逐行读取，内存中只保存键相同的一组行。
+  其中一个文件可以是 -（标准输入）。
+
+示例:
+  join users.txt orders.txt               # 按第一列连接
+  join -t, -1 2 -2 1 a.csv b.csv          # 按 a 的第 2 列和 b 的第 1 列连接
+  join -a 1 -e NULL -o 0,1.2,2.2 a b      # 左连接，缺少的字段填 NULL
+  join -v 1 all.txt done.txt              # 只在 all.txt 中的行
+  sort a.txt | join - b.txt`
+}
+
+func (c *JoinCommand) ShortHelp() string {
+	return "按公共字段连接两个文件"
+}
@@ -7,14 +7,14 @@ import (
 	"os/exec"
 	"runtime"
 	"strconv"
+
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
-type KillCommand struct {
-	stdout *os.File
-}
+type KillCommand struct{}
 
-func NewKillCommand(stdout *os.File) *KillCommand {
-	return &KillCommand{stdout: stdout}
+func NewKillCommand() *KillCommand {
+	return &KillCommand{}
 }
 
 func (c *KillCommand) Name() string {
@@ -22,6 +22,9 @@ func (c *KillCommand) Name() string {
 }
 
 func (c *KillCommand) Execute(ctx context.Context, args []string) error {
+	stderr := streams.Stderr(ctx)
+	stdout := streams.Stdout(ctx)
+
 	if len(args) == 0 {
 		return fmt.Errorf("kill: 需要指定进程 ID")
 	}
@@ -29,14 +32,14 @@ func (c *KillCommand) Execute(ctx context.Context, args []string) error {
 	for _, arg := range args {
 		pid, err := strconv.Atoi(arg)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "kill: 无效的进程 ID: %s\n", arg)
+			fmt.Fprintf(stderr, "kill: 无效的进程 ID: %s\n", arg)
 			continue
 		}
 		
 		if err := c.killProcess(pid); err != nil {
-			fmt.Fprintf(os.Stderr, "kill: %v\n", err)
+			fmt.Fprintf(stderr, "kill: %v\n", err)
 		} else {
-			fmt.Fprintf(c.stdout, "已终止进程 %d\n", pid)
+			fmt.Fprintf(stdout, "已终止进程 %d\n", pid)
 		}
 	}
 	
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/streams"
+	"github.com/spf13/pflag"
+)
+
+// 文本命令（head、tail、uniq、wc、cat）共用的流式输入输出
+
+// openInput 打开输入文件，- 表示标准输入（关闭时不会关闭标准输入）
+func openInput(ctx context.Context, name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(streams.Stdin(ctx)), nil
+	}
+	return os.Open(name)
+}
+
+// inputName 返回在标题和错误信息中显示的文件名
+func inputName(name string) string {
+	if name == "-" {
+		return "标准输入"
+	}
+	return name
+}
+
+// lineStream 逐行读取输入，输出写入共享的缓冲区
+//
+// 读取可能阻塞（缓冲区中没有完整的行）之前先刷新输出，因此读文件时批量写出，
+// 而管道中的每一行都会立即出现在下游。
+type lineStream struct {
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func newLineStream(r io.Reader, w *bufio.Writer) *lineStream {
+	return &lineStream{r: bufio.NewReaderSize(r, 64*1024), w: w}
+}
+
+// readLine 读取下一行，保留结尾的换行符（最后一行可能没有）；没有更多的行时返回 io.EOF
+func (s *lineStream) readLine() (string, error) {
+	if !s.lineBuffered() {
+		if err := s.w.Flush(); err != nil {
+			return "", err
+		}
+	}
+	line, err := s.r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		return line, nil
+	}
+	return line, err
+}
+
+// lineBuffered 判断缓冲区中是否已有完整的一行
+func (s *lineStream) lineBuffered() bool {
+	buf, _ := s.r.Peek(s.r.Buffered())
+	return bytes.IndexByte(buf, '\n') >= 0
+}
+
+// lineRing 保存最近的 n 行，内存占用与 n 成正比而与输入大小无关
+type lineRing struct {
+	lines []string
+	limit int
+	next  int
+}
+
+func newLineRing(n int) *lineRing {
+	return &lineRing{limit: n}
+}
+
+// push 加入一行，缓冲区已满时返回被挤出的最早一行
+func (r *lineRing) push(line string) (evicted string, ok bool) {
+	if r.limit == 0 {
+		return line, true
+	}
+	if len(r.lines) < r.limit {
+		r.lines = append(r.lines, line)
+		return "", false
+	}
+	evicted = r.lines[r.next]
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % r.limit
+	return evicted, true
+}
+
+// items 按加入的顺序返回保存的行
+func (r *lineRing) items() []string {
+	return append(append([]string{}, r.lines[r.next:]...), r.lines[:r.next]...)
+}
+
+// countUnits head 和 tail 的数量单位
+var countUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+	{"kB", 1000}, {"KB", 1000}, {"MB", 1000 * 1000}, {"GB", 1000 * 1000 * 1000},
+	{"b", 512}, {"K", 1 << 10}, {"k", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+}
+
+// parseCount 解析 head、tail 的 -n、-c 参数，返回数值和 + 或 - 前缀（没有前缀时为 0）
+func parseCount(s string) (int64, byte, error) {
+	var sign byte
+	text := s
+	if text != "" && (text[0] == '+' || text[0] == '-') {
+		sign = text[0]
+		text = text[1:]
+	}
+	multiplier := int64(1)
+	for _, unit := range countUnits {
+		if rest, ok := strings.CutSuffix(text, unit.suffix); ok {
+			text = rest
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || n < 0 {
+		return 0, 0, fmt.Errorf("无效的数量: %s", s)
+	}
+	return n * multiplier, sign, nil
+}
+
+// expandNumericOption 把 -NUM 简写转为 option NUM（如 grep -2 即 -C 2，head -5 即 -n 5）
+//
+// 作为前一个选项的参数时（如 head -n -5）不转换。
+func expandNumericOption(flags *pflag.FlagSet, args []string, option string) []string {
+	result := make([]string, 0, len(args))
+	for i, arg := range args {
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		if len(arg) > 1 && arg[0] == '-' && (i == 0 || !takesValue(flags, args[i-1])) {
+			if _, err := strconv.Atoi(arg[1:]); err == nil {
+				result = append(result, option, arg[1:])
+				continue
+			}
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// takesValue 判断 arg 是否是需要下一个参数作为值的选项
+func takesValue(flags *pflag.FlagSet, arg string) bool {
+	var f *pflag.Flag
+	switch {
+	case strings.HasPrefix(arg, "--") && !strings.Contains(arg, "="):
+		f = flags.Lookup(arg[2:])
+	case len(arg) == 2 && arg[0] == '-':
+		f = flags.ShorthandLookup(arg[1:])
+	}
+	return f != nil && f.NoOptDefVal == ""
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"runtime"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	flag "github.com/spf13/pflag"
 )
 
@@ -17,6 +18,8 @@ func (c *LnCommand) Name() string {
 }
 
 func (c *LnCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := flag.NewFlagSet("ln", flag.ContinueOnError)
 	symbolic := flags.BoolP("symbolic", "s", false, "创建符号链接")
 	force := flags.BoolP("force", "f", false, "强制覆盖已存在的链接")
@@ -37,18 +40,18 @@ func (c *LnCommand) Execute(ctx context.Context, args []string) error {
 
 	// Windows 平台提示
 	if runtime.GOOS == "windows" {
-		fmt.Println("⚠️  注意: Windows 平台创建符号链接的要求")
-		fmt.Println("   • 需要管理员权限，或")
-		fmt.Println("   • 启用开发者模式")
-		fmt.Println("   • 硬链接只支持文件，不支持目录")
-		fmt.Println()
+		fmt.Fprintln(stdout, "⚠️  注意: Windows 平台创建符号链接的要求")
+		fmt.Fprintln(stdout, "   • 需要管理员权限，或")
+		fmt.Fprintln(stdout, "   • 启用开发者模式")
+		fmt.Fprintln(stdout, "   • 硬链接只支持文件，不支持目录")
+		fmt.Fprintln(stdout)
 	}
 
 	// 检查目标是否存在
 	if _, err := os.Stat(target); os.IsNotExist(err) {
-		fmt.Printf("⚠️  警告: 目标 '%s' 不存在\n", target)
+		fmt.Fprintf(stdout, "⚠️  警告: 目标 '%s' 不存在\n", target)
 		if *symbolic {
-			fmt.Println("   符号链接允许指向不存在的目标")
+			fmt.Fprintln(stdout, "   符号链接允许指向不存在的目标")
 		} else {
 			return fmt.Errorf("硬链接的目标必须存在")
 		}
@@ -61,7 +64,7 @@ func (c *LnCommand) Execute(ctx context.Context, args []string) error {
 				return fmt.Errorf("无法删除已存在的链接: %w", err)
 			}
 			if *verbose {
-				fmt.Printf("已删除已存在的链接: %s\n", linkName)
+				fmt.Fprintf(stdout, "已删除已存在的链接: %s\n", linkName)
 			}
 		} else {
 			return fmt.Errorf("链接已存在: %s (使用 -f 强制覆盖)", linkName)
@@ -80,7 +83,7 @@ func (c *LnCommand) Execute(ctx context.Context, args []string) error {
 			return fmt.Errorf("创建符号链接失败: %w", err)
 		}
 		if *verbose {
-			fmt.Printf("✓ 已创建符号链接: %s -> %s\n", linkName, target)
+			fmt.Fprintf(stdout, "✓ 已创建符号链接: %s -> %s\n", linkName, target)
 		}
 	} else {
 		// 创建硬链接
@@ -89,7 +92,7 @@ func (c *LnCommand) Execute(ctx context.Context, args []string) error {
 			return fmt.Errorf("创建硬链接失败: %w", err)
 		}
 		if *verbose {
-			fmt.Printf("✓ 已创建硬链接: %s -> %s\n", linkName, target)
+			fmt.Fprintf(stdout, "✓ 已创建硬链接: %s -> %s\n", linkName, target)
 		}
 	}
 
@@ -3,11 +3,13 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"sort"
 	"strings"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
@@ -19,12 +21,10 @@ const (
 	colorYellow = "\033[33m" // 特殊文件
 )
 
-type LsCommand struct {
-	stdout *os.File
-}
+type LsCommand struct{}
 
-func NewLsCommand(stdout *os.File) *LsCommand {
-	return &LsCommand{stdout: stdout}
+func NewLsCommand() *LsCommand {
+	return &LsCommand{}
 }
 
 func (c *LsCommand) Name() string {
@@ -32,6 +32,8 @@ func (c *LsCommand) Name() string {
 }
 
 func (c *LsCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := pflag.NewFlagSet("ls", pflag.ContinueOnError)
 	longFormat := flags.BoolP("long", "l", false, "使用长格式")
 	all := flags.BoolP("all", "a", false, "显示隐藏文件")
@@ -49,14 +51,14 @@ func (c *LsCommand) Execute(ctx context.Context, args []string) error {
 
 	for i, dir := range dirs {
 		if i > 0 {
-			fmt.Fprintln(c.stdout)
+			fmt.Fprintln(stdout)
 		}
 
 		if len(dirs) > 1 {
-			fmt.Fprintf(c.stdout, "%s:\n", dir)
+			fmt.Fprintf(stdout, "%s:\n", dir)
 		}
 
-		if err := c.listDir(dir, *longFormat, *all, *humanReadable); err != nil {
+		if err := c.listDir(dir, *longFormat, *all, *humanReadable, stdout); err != nil {
 			return err
 		}
 	}
@@ -64,7 +66,7 @@ func (c *LsCommand) Execute(ctx context.Context, args []string) error {
 	return nil
 }
 
-func (c *LsCommand) listDir(dir string, longFormat, all, humanReadable bool) error {
+func (c *LsCommand) listDir(dir string, longFormat, all, humanReadable bool, stdout io.Writer) error {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return fmt.Errorf("读取目录失败: %w", err)
@@ -85,28 +87,28 @@ func (c *LsCommand) listDir(dir string, longFormat, all, humanReadable bool) err
 	})
 
 	if longFormat {
-		return c.printLongFormat(dir, filtered, humanReadable)
+		return c.printLongFormat(dir, filtered, humanReadable, stdout)
 	}
 
-	return c.printSimpleFormat(filtered)
+	return c.printSimpleFormat(filtered, stdout)
 }
 
-func (c *LsCommand) printSimpleFormat(entries []fs.DirEntry) error {
+func (c *LsCommand) printSimpleFormat(entries []fs.DirEntry, stdout io.Writer) error {
 	for _, entry := range entries {
 		name := entry.Name()
 		if entry.IsDir() {
-			fmt.Fprintf(c.stdout, "%s%s%s  ", colorBlue, name, colorReset)
+			fmt.Fprintf(stdout, "%s%s%s  ", colorBlue, name, colorReset)
 		} else if isExecutable(entry) {
-			fmt.Fprintf(c.stdout, "%s%s%s  ", colorGreen, name, colorReset)
+			fmt.Fprintf(stdout, "%s%s%s  ", colorGreen, name, colorReset)
 		} else {
-			fmt.Fprintf(c.stdout, "%s  ", name)
+			fmt.Fprintf(stdout, "%s  ", name)
 		}
 	}
-	fmt.Fprintln(c.stdout)
+	fmt.Fprintln(stdout)
 	return nil
 }
 
-func (c *LsCommand) printLongFormat(dir string, entries []fs.DirEntry, humanReadable bool) error {
+func (c *LsCommand) printLongFormat(dir string, entries []fs.DirEntry, humanReadable bool, stdout io.Writer) error {
 	for _, entry := range entries {
 		info, err := entry.Info()
 		if err != nil {
@@ -133,7 +135,7 @@ func (c *LsCommand) printLongFormat(dir string, entries []fs.DirEntry, humanRead
 			coloredName = colorGreen + name + colorReset
 		}
 
-		fmt.Fprintf(c.stdout, "%s %10s %s %s\n", modeStr, sizeStr, modTime, coloredName)
+		fmt.Fprintf(stdout, "%s %10s %s %s\n", modeStr, sizeStr, modTime, coloredName)
 	}
 	return nil
 }
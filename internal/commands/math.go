@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/calc"
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// MathCommand math 命令 - 计算算术表达式，同一个命令也以 calc 注册
+type MathCommand struct {
+	name string
+}
+
+// NewMathCommand 创建 math 命令，name 是注册的命令名（math 或 calc）
+func NewMathCommand(name string) *MathCommand {
+	return &MathCommand{name: name}
+}
+
+func (c *MathCommand) Name() string {
+	return c.name
+}
+
+func (c *MathCommand) Execute(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	flags.SetInterspersed(false)
+	scale := flags.IntP("scale", "s", -1, "保留的小数位数")
+	base := flags.StringP("base", "b", "10", "输出的进制")
+	human := flags.BoolP("human", "H", false, "以 KiB、MiB 等单位输出")
+	si := flags.Bool("si", false, "以 kB、MB 等单位输出")
+
+	// 负数开头的表达式（如 -3 + 4）不是选项
+	options, expr := args, []string(nil)
+	for i, arg := range args {
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' || !strings.ContainsAny(arg[1:2], "0123456789.(") {
+			continue
+		}
+		options, expr = args[:i], args[i:]
+		break
+	}
+	if err := flags.Parse(options); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	expr = append(flags.Args(), expr...)
+
+	opts := calc.FormatOptions{Scale: *scale}
+	if *scale < -1 {
+		return fmt.Errorf("%s: 无效的小数位数 %d", c.name, *scale)
+	}
+	switch strings.ToLower(*base) {
+	case "10", "dec":
+		opts.Base = 10
+	case "16", "hex":
+		opts.Base = 16
+	case "2", "bin":
+		opts.Base = 2
+	case "8", "oct":
+		opts.Base = 8
+	default:
+		return fmt.Errorf("%s: 不支持的进制 '%s'（可用 2、8、10、16）", c.name, *base)
+	}
+	switch {
+	case *human && *si:
+		return fmt.Errorf("%s: -H 和 --si 不能同时使用", c.name)
+	case *human:
+		opts.Human = 1024
+	case *si:
+		opts.Human = 1000
+	}
+	if opts.Human != 0 && opts.Base != 10 {
+		return fmt.Errorf("%s: 带单位输出时只能使用十进制", c.name)
+	}
+
+	if len(expr) > 0 {
+		return c.evaluate(ctx, strings.Join(expr, " "), opts)
+	}
+
+	// 没有表达式时逐行计算标准输入
+	scanner := bufio.NewScanner(streams.Stdin(ctx))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := c.evaluate(ctx, line, opts); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// evaluate 计算一个表达式并输出结果
+func (c *MathCommand) evaluate(ctx context.Context, expr string, opts calc.FormatOptions) error {
+	value, err := calc.Eval(expr)
+	if err != nil {
+		return fmt.Errorf("%s: %s: %w", c.name, expr, err)
+	}
+	text, err := value.Format(opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.name, err)
+	}
+	fmt.Fprintln(streams.Stdout(ctx), text)
+	return nil
+}
+
+func (c *MathCommand) Help() string {
+	return `math, calc - 计算算术表达式
+
+用法:
+  math [选项] 表达式...
+  calc [选项] 表达式...
+
+说明:
+  多个参数用空格连接成一个表达式。没有给出表达式时逐行计算标准输入，
+  空行和 # 开头的行被忽略。
+
+  整数运算的结果是精确的大整数，不会溢出；出现小数或不能整除的除法后
+  使用高精度浮点数。默认最多输出 6 位小数并去掉末尾的 0。
+  注意 * ( ) < > & | 在 shell 中有特殊含义，表达式通常需要加引号。
+
+选项:
+  -s, --scale N     固定输出 N 位小数（四舍五入）
+  -b, --base 进制   以 2、8、10 或 16 进制输出整数（也可写 bin、oct、dec、hex），
+                    结果带 0b、0o、0x 前缀
+  -H, --human       以 B、KiB、MiB、GiB 等 1024 进制单位输出
+      --si          以 B、kB、MB、GB 等 1000 进制单位输出
+
+数字:
+  42  3.14  .5  1e-3  1_000_000
+  0x1f  0b1010  0o17             十六进制、二进制、八进制
+  1.5GiB  512MiB  4K             1024 进制单位（K M G T P E，可加 iB）
+  10MB  2GB                      1000 进制单位（KB MB GB TB PB EB）
+  单位不区分大小写，B 表示 1。
+
+运算符（优先级从高到低）:
+  ( )                括号
+  ** ^               乘方（右结合，-2**2 等于 -4）
+  + -                正负号
+  * / // %           乘、除、向下取整的除法、取余
+  + -                加、减
+  << >>              移位（整数）
+  &                  按位与（整数）
+  |                  按位或（整数）
+
+函数:
+  sqrt(x)  pow(x, y)  exp(x)  abs(x)
+  log(x)   自然对数，log(x, b) 以 b 为底；log2(x)  log10(x)
+  min(x, ...)  max(x, ...)
+  round(x)  round(x, n) 保留 n 位小数  floor(x)  ceil(x)  trunc(x)
+  sin(x)  cos(x)  tan(x)  atan(x)
+  常量 pi、e
+
+示例:
+  math "3.5 * 1.2"                 # 4.2
+  math -s 1 "17 / 40 * 100"        # 42.5
+  math "2 ** 100"                  # 1267650600228229401496703205376
+  math --base hex "0xff + 1"       # 0x100
+  math -H "1.5GiB * 3"             # 4.5GiB
+  math "round(10 / 3, 2)"          # 3.33
+  used=$(math -s 0 "$size / 1MiB")`
+}
+
+func (c *MathCommand) ShortHelp() string {
+	return "计算算术表达式"
+}
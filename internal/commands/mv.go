@@ -7,15 +7,14 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type MvCommand struct {
-	stdout *os.File
-}
+type MvCommand struct{}
 
-func NewMvCommand(stdout *os.File) *MvCommand {
-	return &MvCommand{stdout: stdout}
+func NewMvCommand() *MvCommand {
+	return &MvCommand{}
 }
 
 func (c *MvCommand) Name() string {
@@ -23,6 +22,8 @@ func (c *MvCommand) Name() string {
 }
 
 func (c *MvCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := pflag.NewFlagSet("mv", pflag.ContinueOnError)
 	verbose := flags.BoolP("verbose", "v", false, "显示详细信息")
 
@@ -55,20 +56,20 @@ func (c *MvCommand) Execute(ctx context.Context, args []string) error {
 	err = os.Rename(src, dst)
 	if err == nil {
 		if *verbose {
-			fmt.Fprintf(c.stdout, "'%s' -> '%s'\n", src, dst)
+			fmt.Fprintf(stdout, "'%s' -> '%s'\n", src, dst)
 		}
 		return nil
 	}
 
 	// 重命名失败，可能是跨盘，使用复制+删除
-	if err := c.copyAndRemove(src, dst, *verbose); err != nil {
+	if err := c.copyAndRemove(src, dst, *verbose, stdout); err != nil {
 		return fmt.Errorf("mv: %w", err)
 	}
 
 	return nil
 }
 
-func (c *MvCommand) copyAndRemove(src, dst string, verbose bool) error {
+func (c *MvCommand) copyAndRemove(src, dst string, verbose bool, stdout io.Writer) error {
 	// 获取源信息
 	srcInfo, err := os.Stat(src)
 	if err != nil {
@@ -76,13 +77,13 @@ func (c *MvCommand) copyAndRemove(src, dst string, verbose bool) error {
 	}
 
 	if srcInfo.IsDir() {
-		return c.copyDirAndRemove(src, dst, verbose)
+		return c.copyDirAndRemove(src, dst, verbose, stdout)
 	}
 
-	return c.copyFileAndRemove(src, dst, verbose)
+	return c.copyFileAndRemove(src, dst, verbose, stdout)
 }
 
-func (c *MvCommand) copyFileAndRemove(src, dst string, verbose bool) error {
+func (c *MvCommand) copyFileAndRemove(src, dst string, verbose bool, stdout io.Writer) error {
 	// 打开源文件
 	srcFile, err := os.Open(src)
 	if err != nil {
@@ -117,13 +118,13 @@ func (c *MvCommand) copyFileAndRemove(src, dst string, verbose bool) error {
 	}
 
 	if verbose {
-		fmt.Fprintf(c.stdout, "'%s' -> '%s'\n", src, dst)
+		fmt.Fprintf(stdout, "'%s' -> '%s'\n", src, dst)
 	}
 
 	return nil
 }
 
-func (c *MvCommand) copyDirAndRemove(src, dst string, verbose bool) error {
+func (c *MvCommand) copyDirAndRemove(src, dst string, verbose bool, stdout io.Writer) error {
 	// 获取源目录信息
 	srcInfo, err := os.Stat(src)
 	if err != nil {
@@ -147,11 +148,11 @@ func (c *MvCommand) copyDirAndRemove(src, dst string, verbose bool) error {
 		dstPath := filepath.Join(dst, entry.Name())
 
 		if entry.IsDir() {
-			if err := c.copyDirAndRemove(srcPath, dstPath, verbose); err != nil {
+			if err := c.copyDirAndRemove(srcPath, dstPath, verbose, stdout); err != nil {
 				return err
 			}
 		} else {
-			if err := c.copyFileAndRemove(srcPath, dstPath, verbose); err != nil {
+			if err := c.copyFileAndRemove(srcPath, dstPath, verbose, stdout); err != nil {
 				return err
 			}
 		}
@@ -163,7 +164,7 @@ func (c *MvCommand) copyDirAndRemove(src, dst string, verbose bool) error {
 	}
 
 	if verbose {
-		fmt.Fprintf(c.stdout, "'%s' -> '%s'\n", src, dst)
+		fmt.Fprintf(stdout, "'%s' -> '%s'\n", src, dst)
 	}
 
 	return nil
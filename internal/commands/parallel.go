@@ -0,0 +1,622 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// ParallelCommand parallel 命令 - 用工作池并行执行命令模板
+type ParallelCommand struct {
+	executor *script.Executor
+}
+
+// NewParallelCommand 创建 parallel 命令，executor 用于执行任务
+func NewParallelCommand(executor *script.Executor) *ParallelCommand {
+	return &ParallelCommand{executor: executor}
+}
+
+func (c *ParallelCommand) Name() string {
+	return "parallel"
+}
+
+// parallelJob 一个任务及其缓存的输出
+type parallelJob struct {
+	seq     int
+	slot    int
+	args    []string
+	command string
+	start   time.Time
+	elapsed time.Duration
+	code    int
+	killed  bool // 因 --halt now 被终止
+	stdout  bytes.Buffer
+	stderr  bytes.Buffer
+}
+
+// haltPolicy --halt 指定的停止条件
+type haltPolicy struct {
+	now     bool // 立即终止正在运行的任务，否则等待它们结束
+	success bool // 按成功的任务计数，否则按失败的任务计数
+	count   int
+	percent float64 // 大于 0 时按已完成任务的百分比计算
+}
+
+// parallel 保存一次 parallel 调用的选项和运行状态
+type parallel struct {
+	executor  *script.Executor
+	template  string
+	keepOrder bool
+	tag       bool
+	ungroup   bool
+	dryRun    bool
+	halt      *haltPolicy
+	std       *streams.Streams
+	null      io.Reader
+	joblog    io.Writer
+
+	slots  chan int // 空闲的槽位号（{%}），同时限制并发数
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	pending map[int]*parallelJob // -k 时等待输出的任务
+	nextOut int
+	running int
+	done    int
+	failed  int
+	success int
+	halted  bool
+	status  int
+}
+
+// parallelPlaceholder 匹配命令模板中的替换字符串
+var parallelPlaceholder = regexp.MustCompile(`\{(\d*)(\.|/\.|//|/)?\}|\{#\}|\{%\}`)
+
+func (c *ParallelCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
+	flags := flag.NewFlagSet("parallel", flag.ContinueOnError)
+	flags.SetInterspersed(false)
+	jobs := flags.StringP("jobs", "j", "", "同时运行的任务数")
+	keepOrder := flags.BoolP("keep-order", "k", false, "按输入顺序输出")
+	joblog := flags.String("joblog", "", "把每个任务的执行情况写入文件")
+	halt := flags.String("halt", "never", "满足条件时停止")
+	dryRun := flags.Bool("dry-run", false, "只输出要执行的命令")
+	tag := flags.Bool("tag", false, "在每行输出前加上参数")
+	ungroup := flags.BoolP("ungroup", "u", false, "不缓存任务的输出")
+	null := flags.BoolP("null", "0", false, "输入项以 NUL 字符分隔")
+	argFile := flags.StringP("arg-file", "a", "", "从文件读取参数")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	template, lists := splitParallelArgs(flags.Args())
+	if lists != nil && *argFile != "" {
+		return fmt.Errorf("parallel: 不能同时使用 ::: 和 -a")
+	}
+	n, err := parseJobs(*jobs)
+	if err != nil {
+		return fmt.Errorf("parallel: %w", err)
+	}
+	policy, err := parseHalt(*halt)
+	if err != nil {
+		return fmt.Errorf("parallel: %w", err)
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	devNull := openNull()
+	defer devNull.Close()
+	p := &parallel{
+		executor:  script.ExecutorFrom(ctx, c.executor),
+		template:  strings.Join(template, " "),
+		keepOrder: *keepOrder,
+		tag:       *tag,
+		ungroup:   *ungroup,
+		dryRun:    *dryRun,
+		halt:      policy,
+		std:       std,
+		null:      devNull,
+		slots:     make(chan int, n),
+		cancel:    cancel,
+		pending:   make(map[int]*parallelJob),
+		nextOut:   1,
+	}
+	for slot := 1; slot <= n; slot++ {
+		p.slots <- slot
+	}
+	if p.ungroup {
+		p.std = &streams.Streams{Stdin: std.Stdin, Stdout: &syncWriter{w: std.Stdout}, Stderr: &syncWriter{w: std.Stderr}}
+	}
+
+	if *joblog != "" {
+		// 文件名以 + 开头时追加到已有的日志
+		mode := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		name := *joblog
+		if rest, ok := strings.CutPrefix(name, "+"); ok {
+			mode, name = os.O_CREATE|os.O_WRONLY|os.O_APPEND, rest
+		}
+		file, err := os.OpenFile(name, mode, 0644)
+		if err != nil {
+			return fmt.Errorf("parallel: %w", err)
+		}
+		defer file.Close()
+		if info, err := file.Stat(); err == nil && info.Size() == 0 {
+			fmt.Fprintln(file, "Seq\tHost\tStarttime\tJobRuntime\tSend\tReceive\tExitval\tSignal\tCommand")
+		}
+		p.joblog = file
+	}
+
+	var next func() ([]string, bool, error)
+	if lists != nil {
+		next = productOf(lists)
+	} else {
+		in := std.Stdin
+		if *argFile != "" {
+			file, err := openInput(ctx, *argFile)
+			if err != nil {
+				return fmt.Errorf("parallel: %w", err)
+			}
+			defer file.Close()
+			in = file
+		}
+		delim := byte('\n')
+		if *null {
+			delim = 0
+		}
+		next = readItems(bufio.NewReader(in), delim)
+	}
+
+	var readErr error
+	for seq := 1; ctx.Err() == nil && !p.isHalted(); seq++ {
+		items, ok, err := next()
+		if err != nil {
+			readErr = err
+			break
+		}
+		if !ok {
+			break
+		}
+		p.start(jobCtx, seq, items)
+	}
+	p.wg.Wait()
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if readErr != nil {
+		return fmt.Errorf("parallel: %w", readErr)
+	}
+	status := min(p.failed, 101)
+	if p.halted {
+		status = p.status
+	}
+	if status != 0 {
+		return script.ExitStatus(status)
+	}
+	return nil
+}
+
+// splitParallelArgs 把参数分成命令模板和 ::: 之后的各组参数
+func splitParallelArgs(args []string) ([]string, [][]string) {
+	var lists [][]string
+	for i, arg := range args {
+		if arg != ":::" {
+			continue
+		}
+		template := args[:i]
+		for _, arg := range args[i:] {
+			if arg == ":::" {
+				lists = append(lists, nil)
+			} else {
+				lists[len(lists)-1] = append(lists[len(lists)-1], arg)
+			}
+		}
+		return template, lists
+	}
+	return args, nil
+}
+
+// productOf 依次返回各组参数的所有组合，第一组变化最慢
+func productOf(lists [][]string) func() ([]string, bool, error) {
+	total := 1
+	for _, list := range lists {
+		total *= len(list)
+	}
+	i := 0
+	return func() ([]string, bool, error) {
+		if i >= total {
+			return nil, false, nil
+		}
+		items := make([]string, len(lists))
+		rest := i
+		for k := len(lists) - 1; k >= 0; k-- {
+			items[k] = lists[k][rest%len(lists[k])]
+			rest /= len(lists[k])
+		}
+		i++
+		return items, true, nil
+	}
+}
+
+// readItems 依次返回以 delim 分隔的输入项，每项作为一个任务的参数
+func readItems(r *bufio.Reader, delim byte) func() ([]string, bool, error) {
+	return func() ([]string, bool, error) {
+		item, err := r.ReadString(delim)
+		if err == io.EOF {
+			if item == "" {
+				return nil, false, nil
+			}
+			return []string{item}, true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return []string{item[:len(item)-1]}, true, nil
+	}
+}
+
+// parseJobs 解析 -j：N、N%（CPU 核数的百分比）、+N 或 -N（在 CPU 核数上增减），
+// 0 表示尽量多
+func parseJobs(spec string) (int, error) {
+	cpus := runtime.NumCPU()
+	if spec == "" {
+		return cpus, nil
+	}
+	var n int
+	var err error
+	switch {
+	case strings.HasSuffix(spec, "%"):
+		var pct float64
+		pct, err = strconv.ParseFloat(strings.TrimSuffix(spec, "%"), 64)
+		n = int(float64(cpus) * pct / 100)
+	case spec[0] == '+' || spec[0] == '-':
+		n, err = strconv.Atoi(spec)
+		n += cpus
+	default:
+		n, err = strconv.Atoi(spec)
+		if n == 0 {
+			n = cpus * 4
+		}
+	}
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的任务数: %s", spec)
+	}
+	return max(n, 1), nil
+}
+
+// parseHalt 解析 --halt：never、1（soon,fail=1）、2（now,fail=1）或
+// [soon|now,]fail|success=N[%]
+func parseHalt(spec string) (*haltPolicy, error) {
+	switch spec {
+	case "never", "0", "":
+		return nil, nil
+	case "1":
+		return &haltPolicy{count: 1}, nil
+	case "2":
+		return &haltPolicy{now: true, count: 1}, nil
+	}
+	h := &haltPolicy{}
+	when, cond, ok := strings.Cut(spec, ",")
+	if !ok {
+		when, cond = "soon", spec
+	}
+	switch when {
+	case "soon":
+	case "now":
+		h.now = true
+	default:
+		return nil, fmt.Errorf("无效的停止条件: %s", spec)
+	}
+	kind, value, _ := strings.Cut(cond, "=")
+	switch kind {
+	case "fail":
+	case "success":
+		h.success = true
+	default:
+		return nil, fmt.Errorf("无效的停止条件: %s", spec)
+	}
+	if pct, ok := strings.CutSuffix(value, "%"); ok {
+		f, err := strconv.ParseFloat(pct, 64)
+		if err != nil || f <= 0 || f > 100 {
+			return nil, fmt.Errorf("无效的停止条件: %s", spec)
+		}
+		h.percent = f
+	} else {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("无效的停止条件: %s", spec)
+		}
+		h.count = n
+	}
+	return h, nil
+}
+
+// modifyArg 按替换字符串的修饰符处理参数
+func modifyArg(s, mod string) string {
+	switch mod {
+	case ".":
+		return strings.TrimSuffix(s, filepath.Ext(s))
+	case "/":
+		return filepath.Base(s)
+	case "//":
+		return filepath.Dir(s)
+	case "/.":
+		base := filepath.Base(s)
+		return strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return s
+}
+
+// expand 把任务的参数代入命令模板；模板中没有替换字符串时把参数追加到末尾，
+// 没有模板时每个输入本身就是命令
+func (p *parallel) expand(job *parallelJob) string {
+	quoteAll := func(mod string) string {
+		quoted := make([]string, len(job.args))
+		for i, arg := range job.args {
+			quoted[i] = shellQuote(modifyArg(arg, mod))
+		}
+		return strings.Join(quoted, " ")
+	}
+	if p.template == "" {
+		return strings.Join(job.args, " ")
+	}
+
+	found := false
+	command := parallelPlaceholder.ReplaceAllStringFunc(p.template, func(m string) string {
+		found = true
+		switch m {
+		case "{#}":
+			return strconv.Itoa(job.seq)
+		case "{%}":
+			return strconv.Itoa(job.slot)
+		}
+		sub := parallelPlaceholder.FindStringSubmatch(m)
+		if sub[1] == "" {
+			return quoteAll(sub[2])
+		}
+		n, _ := strconv.Atoi(sub[1])
+		if n < 1 || n > len(job.args) {
+			return ""
+		}
+		return shellQuote(modifyArg(job.args[n-1], sub[2]))
+	})
+	if !found {
+		command += " " + quoteAll("")
+	}
+	return command
+}
+
+// start 等待空闲的槽位，然后在后台执行一个任务
+func (p *parallel) start(ctx context.Context, seq int, args []string) {
+	var slot int
+	select {
+	case slot = <-p.slots:
+	case <-ctx.Done():
+		return
+	}
+	if p.isHalted() {
+		p.slots <- slot
+		return
+	}
+
+	job := &parallelJob{seq: seq, slot: slot, args: args}
+	job.command = p.expand(job)
+	if p.dryRun {
+		p.slots <- slot
+		fmt.Fprintln(p.std.Stdout, job.command)
+		return
+	}
+
+	// 子 shell 在启动 goroutine 之前创建，避免并发读取当前 shell 的变量
+	sub := p.executor.Subshell()
+	p.mu.Lock()
+	p.running++
+	p.mu.Unlock()
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() { p.slots <- slot }()
+		std := &streams.Streams{Stdin: p.null, Stdout: &job.stdout, Stderr: &job.stderr}
+		if p.ungroup {
+			std.Stdout, std.Stderr = p.std.Stdout, p.std.Stderr
+		}
+		job.start = time.Now()
+		job.code = runJob(ctx, "parallel", std, func(ctx context.Context) error {
+			return sub.ExecuteLine(ctx, job.command)
+		})
+		job.elapsed = time.Since(job.start)
+		job.killed = ctx.Err() != nil
+		p.finish(job)
+	}()
+}
+
+// finish 记录任务的结果，输出任务的输出，并检查是否满足停止条件
+func (p *parallel) finish(job *parallelJob) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running--
+
+	if p.joblog != nil {
+		code, signal := job.code, 0
+		if job.killed {
+			code, signal = -1, 15
+		}
+		fmt.Fprintf(p.joblog, "%d\t:\t%.3f\t%10.3f\t0\t%d\t%d\t%d\t%s\n", job.seq,
+			float64(job.start.UnixMilli())/1000, job.elapsed.Seconds(), job.stdout.Len(), code, signal, job.command)
+	}
+
+	if !p.ungroup {
+		if p.keepOrder {
+			p.pending[job.seq] = job
+			for {
+				next, ok := p.pending[p.nextOut]
+				if !ok {
+					break
+				}
+				delete(p.pending, p.nextOut)
+				p.output(next)
+				p.nextOut++
+			}
+		} else {
+			p.output(job)
+		}
+	}
+
+	if job.killed {
+		return
+	}
+	p.done++
+	if job.code == 0 {
+		p.success++
+	} else {
+		p.failed++
+	}
+	p.checkHalt(job)
+}
+
+// output 输出任务缓存的标准输出和标准错误，--tag 时在每行前加上参数
+func (p *parallel) output(job *parallelJob) {
+	for _, o := range []struct {
+		w    io.Writer
+		data []byte
+	}{{p.std.Stdout, job.stdout.Bytes()}, {p.std.Stderr, job.stderr.Bytes()}} {
+		if !p.tag || len(o.data) == 0 {
+			o.w.Write(o.data)
+			continue
+		}
+		prefix := strings.Join(job.args, " ") + "\t"
+		var buf bytes.Buffer
+		for data := o.data; len(data) > 0; {
+			line := data
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				line = data[:i+1]
+			}
+			buf.WriteString(prefix)
+			buf.Write(line)
+			data = data[len(line):]
+		}
+		o.w.Write(buf.Bytes())
+	}
+}
+
+// checkHalt 检查刚结束的任务是否使 --halt 的条件成立
+func (p *parallel) checkHalt(job *parallelJob) {
+	h := p.halt
+	if h == nil || p.halted || h.success != (job.code == 0) {
+		return
+	}
+	n := p.failed
+	if h.success {
+		n = p.success
+	}
+	if h.percent > 0 {
+		// 至少完成 3 个任务后才按百分比判断
+		if p.done < 3 || float64(n)*100 < h.percent*float64(p.done) {
+			return
+		}
+	} else if n < h.count {
+		return
+	}
+
+	p.halted = true
+	if !h.success {
+		p.status = job.code
+		fmt.Fprintf(p.std.Stderr, "parallel: 任务失败:\n%s\n", job.command)
+	}
+	if p.running > 0 {
+		if h.now {
+			fmt.Fprintf(p.std.Stderr, "parallel: 终止正在运行的 %d 个任务\n", p.running)
+			p.cancel()
+		} else {
+			fmt.Fprintf(p.std.Stderr, "parallel: 不再启动新的任务，等待 %d 个任务结束\n", p.running)
+		}
+	}
+}
+
+func (p *parallel) isHalted() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.halted
+}
+
+func (c *ParallelCommand) Help() string {
+	return `parallel - 用工作池并行执行命令模板
+
+用法:
+  parallel [选项] 命令模板 ::: 参数... [::: 参数...]
+  parallel [选项] 命令模板 < 输入
+
+选项:
+  -j, --jobs=N            同时运行的任务数（默认为 CPU 核数）；也可以是
+                          N%（CPU 核数的百分比）、+N、-N，0 表示尽量多
+  -k, --keep-order        按输入顺序输出，而不是按完成顺序
+      --joblog=文件       把每个任务的序号、开始时间、耗时、退出码和命令
+                          写入文件（文件名以 + 开头时追加）
+      --halt=条件         满足条件时停止，见下文
+      --dry-run           只输出要执行的命令，不执行
+      --tag               在每行输出前加上任务的参数和制表符
+  -u, --ungroup           不缓存输出，任务的输出可能互相交错
+  -0, --null              输入项以 NUL 字符分隔
+  -a, --arg-file=文件     从文件读取参数
+
+描述:
+  对每个输入执行一次命令模板。参数来自 ::: 之后的列表（有多个列表时
+  执行所有组合），或者标准输入（每行一个）。模板在子 shell 中执行，
+  可以使用管道、函数、内置命令和外部命令；模板需要包含 | 等符号时
+  用引号括起来。被执行的命令从 /dev/null 读取标准输入。
+
+  默认每个任务的输出先缓存，任务结束后一起输出，不会互相交错。
+
+替换字符串:
+  {}     参数（已加引号）       {.}    去掉扩展名
+  {/}    文件名部分             {//}   目录部分
+  {/.}   文件名部分去掉扩展名   {#}    任务序号
+  {%}    槽位号（1 到 -j）      {N}    第 N 组参数，可以加修饰符，如 {2/.}
+  模板中没有替换字符串时把参数追加到末尾；没有模板时每个输入就是命令。
+
+停止条件:
+  never                   不停止（默认）
+  1 或 soon,fail=1        有任务失败后不再启动新的任务
+  2 或 now,fail=1         有任务失败后立即终止正在运行的任务
+  soon|now,fail=N         N 个任务失败后停止
+  soon|now,fail=P%        P% 的任务失败后停止（至少完成 3 个任务）
+  soon|now,success=N      N 个任务成功后停止（也可以是 P%）
+
+退出码:
+  0        所有任务都成功
+  1-100    失败的任务数
+  101      超过 100 个任务失败
+  按 --halt 的失败条件停止时，为导致停止的任务的退出码
+
+示例:
+  parallel gzip ::: *.log                     # 并行压缩
+  ls *.jpg | parallel -j 4 convert {} {.}.png
+  parallel echo {1}-{2} ::: a b ::: 1 2       # a-1 a-2 b-1 b-2
+  parallel -k --tag 'wc -l < {}' ::: *.txt
+  cat hosts | parallel --joblog jobs.log --halt now,fail=1 ping -c 1 {}`
+}
+
+func (c *ParallelCommand) ShortHelp() string {
+	return "并行执行命令模板"
+}
@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// PasteCommand paste 命令 - 按列合并文件的行
+type PasteCommand struct{}
+
+// NewPasteCommand 创建 paste 命令
+func NewPasteCommand() *PasteCommand {
+	return &PasteCommand{}
+}
+
+func (c *PasteCommand) Name() string {
+	return "paste"
+}
+
+func (c *PasteCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
+	flags := flag.NewFlagSet("paste", flag.ContinueOnError)
+	delimList := flags.StringP("delimiters", "d", "\t", "循环使用的分隔符")
+	serial := flags.BoolP("serial", "s", false, "每个文件的所有行合并为一行")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	delims := parsePasteDelims(*delimList)
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	out := bufio.NewWriter(std.Stdout)
+	defer out.Flush()
+
+	// 多次出现的 - 共用标准输入，依次读取其中的行
+	var stdin *lineStream
+	inputs := make([]*lineStream, len(files))
+	for i, name := range files {
+		if name == "-" {
+			if stdin == nil {
+				stdin = newLineStream(streams.Stdin(ctx), out)
+			}
+			inputs[i] = stdin
+			continue
+		}
+		file, err := openInput(ctx, name)
+		if err != nil {
+			return fmt.Errorf("paste: %w", err)
+		}
+		defer file.Close()
+		inputs[i] = newLineStream(file, out)
+	}
+
+	var err error
+	if *serial {
+		err = pasteSerial(ctx, inputs, delims, out)
+	} else {
+		err = pasteParallel(ctx, inputs, delims, out)
+	}
+	if err != nil {
+		return fmt.Errorf("paste: %w", err)
+	}
+	return out.Flush()
+}
+
+// parsePasteDelims 解析分隔符列表，\0 表示没有分隔符
+func parsePasteDelims(list string) []string {
+	var delims []string
+	for i := 0; i < len(list); i++ {
+		if list[i] != '\\' || i+1 == len(list) {
+			delims = append(delims, list[i:i+1])
+			continue
+		}
+		i++
+		switch list[i] {
+		case 'n':
+			delims = append(delims, "\n")
+		case 't':
+			delims = append(delims, "\t")
+		case '0':
+			delims = append(delims, "")
+		default:
+			delims = append(delims, list[i:i+1])
+		}
+	}
+	if len(delims) == 0 {
+		return []string{""}
+	}
+	return delims
+}
+
+// readPasteLine 读取一行并去掉换行符，ok 为 false 表示已经读完
+func readPasteLine(s *lineStream) (string, bool, error) {
+	line, err := s.readLine()
+	if err == io.EOF {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSuffix(line, "\n"), true, nil
+}
+
+// pasteParallel 每次从每个文件各读一行合并输出，所有文件都读完时结束
+func pasteParallel(ctx context.Context, inputs []*lineStream, delims []string, out *bufio.Writer) error {
+	done := make([]bool, len(inputs))
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var sb strings.Builder
+		active := false
+		for i, s := range inputs {
+			if i > 0 {
+				sb.WriteString(delims[(i-1)%len(delims)])
+			}
+			if done[i] {
+				continue
+			}
+			line, ok, err := readPasteLine(s)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				done[i] = true
+				continue
+			}
+			sb.WriteString(line)
+			active = true
+		}
+		if !active {
+			return nil
+		}
+		sb.WriteByte('\n')
+		out.WriteString(sb.String())
+	}
+}
+
+// pasteSerial 把每个文件的所有行合并为一行
+func pasteSerial(ctx context.Context, inputs []*lineStream, delims []string, out *bufio.Writer) error {
+	for _, s := range inputs {
+		for n := 0; ; n++ {
+			if n%1024 == 0 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			line, ok, err := readPasteLine(s)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				break
+			}
+			if n > 0 {
+				out.WriteString(delims[(n-1)%len(delims)])
+			}
+			out.WriteString(line)
+		}
+		out.WriteByte('\n')
+	}
+	return nil
+}
+
+func (c *PasteCommand) Help() string {
+	return `paste - 按列合并文件的行
+
+用法:
+  paste [选项] [文件...]
+
+选项:
+  -d, --delimiters=列表   循环使用列表中的字符作为分隔符（默认制表符）；
+                          支持 \t、\n、\\ 和 \0（没有分隔符）
+  -s, --serial            每个文件的所有行合并为一行，而不是按列合并
+
+描述:
+  依次从每个文件读取一行，用分隔符连接后输出。较短的文件读完后
+  对应的列为空。没有文件或文件为 - 时读取标准输入；- 出现多次时
+  依次读取标准输入中的行。
+
+示例:
+  paste names.txt ages.txt        # 两个文件按列合并
+  paste -d, a.txt b.txt c.txt     # 用逗号分隔
+  paste -s -d+ nums.txt | calc    # 所有数字连成一个表达式
+  ls | paste - - -                # 每行显示三个文件名
+  paste -s -d'\t\n' pairs.txt     # 每两行合并为一行`
+}
+
+func (c *PasteCommand) ShortHelp() string {
+	return "按列合并文件的行"
+}
@@ -0,0 +1,641 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// PatchCommand patch 命令 - 把统一格式的差异应用到文件
+type PatchCommand struct{}
+
+// NewPatchCommand 创建 patch 命令
+func NewPatchCommand() *PatchCommand {
+	return &PatchCommand{}
+}
+
+func (c *PatchCommand) Name() string {
+	return "patch"
+}
+
+// filePatch 补丁中对一个文件的修改
+type filePatch struct {
+	oldName, newName   string
+	oldStamp, newStamp string
+	header             []string // --- 和 +++ 两行，写入 .rej 文件
+	hunks              []*patchHunk
+}
+
+// patchHunk 一个差异块；old 和 new 中的每行都包含换行符（文件末尾没有换行符的行除外）
+type patchHunk struct {
+	oldStart, oldLines int
+	newStart, newLines int
+	old, new           []string
+	leading, trailing  int      // 开头和末尾的上下文行数，模糊匹配时可以忽略
+	raw                []string // 原始文本，写入 .rej 文件
+}
+
+// patcher 保存一次 patch 调用的选项和结果
+type patcher struct {
+	strip   int // -p，小于 0 时只使用文件名部分
+	reverse bool
+	dryRun  bool
+	fuzz    int
+	dir     string
+	file    string // 命令行指定的要修改的文件
+	silent  bool
+	out     io.Writer
+	stderr  io.Writer
+	status  int // 0 成功，1 有差异块失败或找不到文件，2 出错
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+func (c *PatchCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
+	flags := flag.NewFlagSet("patch", flag.ContinueOnError)
+	strip := flags.IntP("strip", "p", -1, "去掉文件名开头的 N 层目录")
+	reverse := flags.BoolP("reverse", "R", false, "反向应用补丁")
+	dryRun := flags.Bool("dry-run", false, "只检查能否应用，不修改文件")
+	input := flags.StringP("input", "i", "", "从文件读取补丁")
+	fuzz := flags.IntP("fuzz", "F", 2, "最多忽略的上下文行数")
+	dir := flags.StringP("directory", "d", "", "在该目录中应用补丁")
+	silent := flags.BoolP("silent", "s", false, "只输出错误")
+	flags.BoolVar(silent, "quiet", false, "同 --silent")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		fmt.Fprintf(std.Stderr, "patch: %v\n", err)
+		return script.ExitStatus(2)
+	}
+
+	p := &patcher{
+		strip:   *strip,
+		reverse: *reverse,
+		dryRun:  *dryRun,
+		fuzz:    *fuzz,
+		dir:     *dir,
+		silent:  *silent,
+		out:     std.Stdout,
+		stderr:  std.Stderr,
+	}
+	operands := flags.Args()
+	if len(operands) > 2 {
+		fmt.Fprintln(std.Stderr, "patch: 参数过多")
+		return script.ExitStatus(2)
+	}
+	if len(operands) > 0 {
+		p.file = operands[0]
+	}
+	source := *input
+	if len(operands) == 2 {
+		source = operands[1]
+	}
+	if source == "" {
+		source = "-"
+	}
+
+	r, err := openInput(ctx, source)
+	if err != nil {
+		fmt.Fprintf(std.Stderr, "patch: %v\n", err)
+		return script.ExitStatus(2)
+	}
+	patches, err := parsePatch(r)
+	r.Close()
+	if err != nil {
+		fmt.Fprintf(std.Stderr, "patch: %s: %v\n", inputName(source), err)
+		return script.ExitStatus(2)
+	}
+	if len(patches) == 0 {
+		fmt.Fprintln(std.Stderr, "patch: 输入中没有找到补丁")
+		return script.ExitStatus(2)
+	}
+
+	for _, fp := range patches {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if p.reverse {
+			fp.swap()
+		}
+		p.apply(fp)
+	}
+	if p.status != 0 {
+		return script.ExitStatus(p.status)
+	}
+	return nil
+}
+
+// parsePatch 读取统一格式的补丁，忽略文件头之外的其他行（如 diff、Index 和 git 的扩展头）
+func parsePatch(r io.Reader) ([]*filePatch, error) {
+	var lines []string
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var patches []*filePatch
+	var cur *filePatch
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			cur = &filePatch{header: []string{line, lines[i+1]}}
+			cur.oldName, cur.oldStamp = parsePatchName(line[4:])
+			cur.newName, cur.newStamp = parsePatchName(lines[i+1][4:])
+			patches = append(patches, cur)
+			i += 2
+		case cur != nil && hunkHeader.MatchString(line):
+			h, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			cur.hunks = append(cur.hunks, h)
+			i = next
+		default:
+			i++
+		}
+	}
+	return patches, nil
+}
+
+// parsePatchName 解析文件头中的文件名和时间戳（以制表符分隔），支持 git 的带引号的文件名
+func parsePatchName(s string) (name, stamp string) {
+	s = strings.TrimRight(s, "\r\n")
+	name, stamp, _ = strings.Cut(s, "\t")
+	name = strings.TrimRight(name, " ")
+	if strings.HasPrefix(name, `"`) {
+		if unquoted, err := strconv.Unquote(name); err == nil {
+			name = unquoted
+		}
+	}
+	return name, stamp
+}
+
+// parseHunk 解析从 lines[i] 开始的差异块，返回差异块和之后一行的下标
+func parseHunk(lines []string, i int) (*patchHunk, int, error) {
+	m := hunkHeader.FindStringSubmatch(lines[i])
+	count := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		n, _ := strconv.Atoi(s)
+		return n
+	}
+	h := &patchHunk{raw: []string{lines[i]}}
+	h.oldStart, _ = strconv.Atoi(m[1])
+	h.oldLines = count(m[2])
+	h.newStart, _ = strconv.Atoi(m[3])
+	h.newLines = count(m[4])
+
+	start := i + 1
+	oldLeft, newLeft := h.oldLines, h.newLines
+	var kinds []byte
+	for i++; oldLeft > 0 || newLeft > 0; i++ {
+		if i >= len(lines) {
+			return nil, 0, fmt.Errorf("第 %d 行: 补丁在差异块中意外结束", i)
+		}
+		line := lines[i]
+		kind := line[0]
+		text := ""
+		if len(line) > 1 {
+			text = line[1:]
+		}
+		if line == "\n" || line == "\r\n" {
+			// 有些编辑器会去掉空的上下文行行首的空格
+			kind, text = ' ', line
+		}
+		switch kind {
+		case ' ':
+			h.old = append(h.old, text)
+			h.new = append(h.new, text)
+			oldLeft--
+			newLeft--
+		case '-':
+			h.old = append(h.old, text)
+			oldLeft--
+		case '+':
+			h.new = append(h.new, text)
+			newLeft--
+		case '\\':
+			h.noNewline(kinds)
+			continue
+		default:
+			return nil, 0, fmt.Errorf("第 %d 行: 差异块格式错误", i+1)
+		}
+		if oldLeft < 0 || newLeft < 0 {
+			return nil, 0, fmt.Errorf("第 %d 行: 差异块的行数与头部不符", i+1)
+		}
+		kinds = append(kinds, kind)
+	}
+	if i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+		h.noNewline(kinds)
+		i++
+	}
+	h.raw = append(h.raw, lines[start:i]...)
+
+	for _, k := range kinds {
+		if k != ' ' {
+			break
+		}
+		h.leading++
+	}
+	for j := len(kinds) - 1; j >= 0 && kinds[j] == ' '; j-- {
+		h.trailing++
+	}
+	return h, i, nil
+}
+
+// noNewline 处理 "\ No newline at end of file"：去掉上一行的换行符
+func (h *patchHunk) noNewline(kinds []byte) {
+	if len(kinds) == 0 {
+		return
+	}
+	trim := func(lines []string) {
+		last := len(lines) - 1
+		lines[last] = strings.TrimSuffix(lines[last], "\n")
+	}
+	switch kinds[len(kinds)-1] {
+	case ' ':
+		trim(h.old)
+		trim(h.new)
+	case '-':
+		trim(h.old)
+	case '+':
+		trim(h.new)
+	}
+}
+
+// swap 交换新旧两边（-R）
+func (fp *filePatch) swap() {
+	fp.oldName, fp.newName = fp.newName, fp.oldName
+	fp.oldStamp, fp.newStamp = fp.newStamp, fp.oldStamp
+	for _, h := range fp.hunks {
+		h.oldStart, h.newStart = h.newStart, h.oldStart
+		h.oldLines, h.newLines = h.newLines, h.oldLines
+		h.old, h.new = h.new, h.old
+	}
+}
+
+// isEpoch 判断时间戳是否是 1970-01-01（diff -N 用它表示不存在的文件）
+func isEpoch(stamp string) bool {
+	return strings.HasPrefix(stamp, "1970-01-01 00:00:00") || strings.HasPrefix(stamp, "1969-12-31 ")
+}
+
+// creates 判断补丁是否创建新文件
+func (fp *filePatch) creates() bool {
+	if fp.oldName == os.DevNull {
+		return true
+	}
+	for _, h := range fp.hunks {
+		if h.oldLines != 0 {
+			return false
+		}
+	}
+	return isEpoch(fp.oldStamp)
+}
+
+// deletes 判断补丁是否删除文件
+func (fp *filePatch) deletes() bool {
+	if fp.newName == os.DevNull {
+		return true
+	}
+	for _, h := range fp.hunks {
+		if h.newLines != 0 {
+			return false
+		}
+	}
+	return isEpoch(fp.newStamp)
+}
+
+// stripPath 按 -p 去掉文件名开头的目录；没有指定 -p 时只保留文件名部分
+func (p *patcher) stripPath(name string) (string, bool) {
+	if p.strip < 0 {
+		return filepath.Base(name), true
+	}
+	rest := name
+	for n := 0; n < p.strip; n++ {
+		i := strings.Index(rest, "/")
+		if i < 0 {
+			return "", false
+		}
+		rest = strings.TrimLeft(rest[i+1:], "/")
+	}
+	return rest, rest != ""
+}
+
+// target 选择要修改的文件：创建文件时使用新文件名，否则使用存在的旧文件名或新文件名
+func (p *patcher) target(fp *filePatch) (string, error) {
+	if p.file != "" {
+		return p.file, nil
+	}
+	var candidates []string
+	for _, name := range []string{fp.oldName, fp.newName} {
+		if name == os.DevNull {
+			continue
+		}
+		stripped, ok := p.stripPath(name)
+		if !ok {
+			return "", fmt.Errorf("无法从 %s 去掉 %d 层目录", name, p.strip)
+		}
+		candidates = append(candidates, filepath.Join(p.dir, stripped))
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("补丁中没有文件名")
+	}
+	if fp.creates() {
+		return candidates[len(candidates)-1], nil
+	}
+	for _, name := range candidates {
+		if _, err := os.Stat(name); err == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("找不到要修改的文件: %s", candidates[0])
+}
+
+func (p *patcher) report(format string, args ...any) {
+	if !p.silent {
+		fmt.Fprintf(p.out, format+"\n", args...)
+	}
+}
+
+// fail 报告读写文件等严重错误，退出码为 2
+func (p *patcher) fail(err error) {
+	fmt.Fprintf(p.stderr, "patch: %v\n", err)
+	p.status = 2
+}
+
+// skip 报告无法应用的文件补丁（如找不到要修改的文件），退出码至少为 1
+func (p *patcher) skip(err error) {
+	fmt.Fprintf(p.stderr, "patch: %v，跳过这个补丁\n", err)
+	p.status = max(p.status, 1)
+}
+
+// apply 把一个文件的所有差异块应用到文件，失败的差异块写入 .rej 文件
+func (p *patcher) apply(fp *filePatch) {
+	name, err := p.target(fp)
+	if err != nil {
+		p.skip(err)
+		return
+	}
+
+	data, err := os.ReadFile(name)
+	switch {
+	case err != nil && errors.Is(err, fs.ErrNotExist) && fp.creates():
+	case err != nil && errors.Is(err, fs.ErrNotExist):
+		p.skip(fmt.Errorf("找不到要修改的文件: %s", name))
+		return
+	case err != nil:
+		p.fail(err)
+		return
+	case fp.creates() && len(data) > 0 && p.file == "":
+		fmt.Fprintf(p.stderr, "patch: 要创建的文件 %s 已经存在，跳过这个补丁\n", name)
+		p.status = max(p.status, 1)
+		return
+	}
+
+	action := "修改"
+	switch {
+	case p.dryRun:
+		action = "检查"
+	case fp.creates():
+		action = "创建"
+	case fp.deletes():
+		action = "删除"
+	}
+	p.report("%s文件 %s", action, name)
+
+	lines := splitKeepNewline(string(data))
+	var rejected []*patchHunk
+	var shifts []int
+	minPos, lastOffset, delta := 0, 0, 0
+	for n, h := range fp.hunks {
+		// 前面的差异块已经应用，新文件中的行号就是差异块应该出现的位置
+		expected := h.newStart - 1
+		if h.newLines == 0 {
+			expected = h.newStart
+		}
+		pos, prefix, suffix, fuzz, ok := findHunk(lines, h, minPos, expected+lastOffset, p.fuzz)
+		if !ok {
+			p.report("差异块 #%d 失败，位置 %d。", n+1, expected+1)
+			rejected = append(rejected, h)
+			shifts = append(shifts, delta)
+			continue
+		}
+
+		replacement := h.new[prefix : len(h.new)-suffix]
+		matched := len(h.old) - prefix - suffix
+		lines = append(lines[:pos], append(append([]string(nil), replacement...), lines[pos+matched:]...)...)
+		minPos = pos + len(replacement)
+		delta += len(h.new) - len(h.old)
+
+		start := pos - prefix
+		offset := start - expected
+		lastOffset = offset
+		switch {
+		case fuzz > 0 && offset != 0:
+			p.report("差异块 #%d 在第 %d 行应用成功（模糊 %d，偏移 %d 行）。", n+1, start+1, fuzz, offset)
+		case fuzz > 0:
+			p.report("差异块 #%d 在第 %d 行应用成功（模糊 %d）。", n+1, start+1, fuzz)
+		case offset != 0:
+			p.report("差异块 #%d 在第 %d 行应用成功（偏移 %d 行）。", n+1, start+1, offset)
+		}
+	}
+
+	if len(rejected) > 0 {
+		p.status = max(p.status, 1)
+		rej := name + ".rej"
+		if p.dryRun {
+			p.report("%d 个差异块中有 %d 个失败", len(fp.hunks), len(rejected))
+		} else {
+			p.report("%d 个差异块中有 %d 个失败 -- 已保存到 %s", len(fp.hunks), len(rejected), rej)
+			if err := writeRejects(rej, fp, rejected, shifts); err != nil {
+				p.fail(err)
+			}
+		}
+	}
+	if p.dryRun {
+		return
+	}
+
+	result := strings.Join(lines, "")
+	if fp.deletes() && len(rejected) == 0 {
+		if result == "" {
+			if err := os.Remove(name); err != nil {
+				p.fail(err)
+				return
+			}
+			// 和 GNU patch 一样删除变为空的上级目录
+			for dir := filepath.Dir(name); dir != "." && dir != filepath.Clean(p.dir) && dir != "/"; dir = filepath.Dir(dir) {
+				if os.Remove(dir) != nil {
+					break
+				}
+			}
+			return
+		}
+		fmt.Fprintf(p.stderr, "patch: %s 应用补丁后不为空，没有删除\n", name)
+	}
+
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(name); err == nil {
+		mode = info.Mode().Perm()
+	} else if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		p.fail(err)
+		return
+	}
+	if err := os.WriteFile(name, []byte(result), mode); err != nil {
+		p.fail(err)
+	}
+}
+
+// findHunk 在 lines 中查找差异块的旧内容：从 guess 开始向两边查找，
+// 找不到时逐步忽略开头和末尾的上下文行（最多 maxFuzz 行）
+func findHunk(lines []string, h *patchHunk, minPos, guess, maxFuzz int) (pos, prefix, suffix, fuzz int, ok bool) {
+	for fuzz = 0; fuzz <= maxFuzz; fuzz++ {
+		prefix, suffix = min(fuzz, h.leading), min(fuzz, h.trailing)
+		if fuzz > 0 && prefix == min(fuzz-1, h.leading) && suffix == min(fuzz-1, h.trailing) {
+			// 上下文行已经都忽略了，再增加模糊度也不会有不同的结果
+			break
+		}
+		pattern := h.old[prefix : len(h.old)-suffix]
+		last := len(lines) - len(pattern)
+		g := guess + prefix
+		for d := 0; g-d >= minPos || g+d <= last; d++ {
+			for _, p := range []int{g - d, g + d} {
+				if p >= minPos && p <= last && linesMatch(lines[p:], pattern) {
+					return p, prefix, suffix, fuzz, true
+				}
+				if d == 0 {
+					break
+				}
+			}
+		}
+	}
+	return 0, 0, 0, 0, false
+}
+
+// linesMatch 判断 lines 是否以 pattern 开头
+func linesMatch(lines, pattern []string) bool {
+	for i, line := range pattern {
+		if lines[i] != line {
+			return false
+		}
+	}
+	return true
+}
+
+// splitKeepNewline 把文本按行拆分，每行保留换行符
+func splitKeepNewline(text string) []string {
+	var lines []string
+	for text != "" {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			lines = append(lines, text)
+			break
+		}
+		lines = append(lines, text[:i+1])
+		text = text[i+1:]
+	}
+	return lines
+}
+
+// writeRejects 把失败的差异块写入 .rej 文件，差异块头部的行号加上之前已应用的差异块
+// 增加的行数 shifts，使其对应修改后的文件
+func writeRejects(name string, fp *filePatch, hunks []*patchHunk, shifts []int) error {
+	lineRange := func(start, lines string, shift int) string {
+		n, _ := strconv.Atoi(start)
+		if lines == "" {
+			return strconv.Itoa(n + shift)
+		}
+		return fmt.Sprintf("%d,%s", n+shift, lines)
+	}
+	var sb strings.Builder
+	for _, line := range fp.header {
+		sb.WriteString(line)
+	}
+	for i, h := range hunks {
+		m := hunkHeader.FindStringSubmatch(h.raw[0])
+		fmt.Fprintf(&sb, "@@ -%s +%s @@%s", lineRange(m[1], m[2], shifts[i]), lineRange(m[3], m[4], shifts[i]),
+			strings.TrimPrefix(h.raw[0], m[0]))
+		for _, line := range h.raw[1:] {
+			sb.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				sb.WriteByte('\n')
+			}
+		}
+	}
+	return os.WriteFile(name, []byte(sb.String()), 0644)
+}
+
+func (c *PatchCommand) Help() string {
+	return `patch - 把统一格式的差异应用到文件
+
+用法:
+  patch [选项] [原文件 [补丁文件]]
+  patch [选项] < 补丁文件
+
+选项:
+  -p, --strip=N        去掉补丁中文件名开头的 N 层目录（git 生成的补丁用 -p1）；
+                       不指定时只使用文件名部分
+  -R, --reverse        反向应用补丁（撤销补丁）
+      --dry-run        只检查补丁能否应用，不修改任何文件
+  -i, --input=文件     从文件读取补丁（默认读取标准输入）
+  -F, --fuzz=N         最多忽略开头和末尾 N 行上下文（默认 2）
+  -d, --directory=目录 在该目录中查找要修改的文件
+  -s, --silent         只输出错误
+
+描述:
+  读取 diff -u 或 git diff 生成的补丁，依次修改其中的每个文件。补丁
+  中文件头以外的行（如 "diff -ruN ..."、"Index:"）被忽略。
+
+  文件内容有变化时，差异块在原来的位置附近查找匹配的位置（偏移），
+  仍然找不到时忽略部分上下文行再查找（模糊匹配）。无法应用的差异块
+  写入 文件.rej，其余的差异块照常应用。
+
+  旧文件名为 /dev/null（或者 diff -N 生成的 1970-01-01 时间戳）时创建
+  文件，必要时创建目录；新文件名为 /dev/null 时删除文件。
+
+  指定了原文件时，补丁中的所有修改都应用到这个文件。
+
+退出码:
+  0  所有差异块都已应用
+  1  有差异块失败，或找不到要修改的文件
+  2  出错（如补丁格式错误、无法读取补丁文件）
+
+示例:
+  diff -u old.c new.c > fix.patch
+  patch old.c fix.patch              # 把 old.c 变为 new.c
+  patch -p1 < fix.patch              # 应用 git diff 生成的补丁
+  patch -p1 --dry-run -i fix.patch   # 检查能否应用
+  patch -R -p1 < fix.patch           # 撤销补丁
+  diff -ruN v1/ v2/ | patch -d v1 -p1`
+}
+
+func (c *PatchCommand) ShortHelp() string {
+	return "把差异应用到文件"
+}
@@ -3,20 +3,18 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os"
 	"os/exec"
 	"runtime"
 	"strconv"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type PingCommand struct {
-	stdout *os.File
-}
+type PingCommand struct{}
 
-func NewPingCommand(stdout *os.File) *PingCommand {
-	return &PingCommand{stdout: stdout}
+func NewPingCommand() *PingCommand {
+	return &PingCommand{}
 }
 
 func (c *PingCommand) Name() string {
@@ -24,6 +22,9 @@ func (c *PingCommand) Name() string {
 }
 
 func (c *PingCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+	stderr := streams.Stderr(ctx)
+
 	flags := pflag.NewFlagSet("ping", pflag.ContinueOnError)
 	count := flags.IntP("count", "c", 4, "发送的数据包数量")
 
@@ -47,8 +48,8 @@ func (c *PingCommand) Execute(ctx context.Context, args []string) error {
 	}
 
 	// 设置输出
-	cmd.Stdout = c.stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
 	// 执行命令
 	if err := cmd.Run(); err != nil {
@@ -0,0 +1,515 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+)
+
+// PrintfCommand printf 命令 - 按格式输出
+type PrintfCommand struct {
+	executor *script.Executor
+}
+
+// NewPrintfCommand 创建 printf 命令
+func NewPrintfCommand(executor *script.Executor) *PrintfCommand {
+	return &PrintfCommand{
+		executor: executor,
+	}
+}
+
+func (c *PrintfCommand) Name() string {
+	return "printf"
+}
+
+func (c *PrintfCommand) Execute(ctx context.Context, args []string) error {
+	executor := script.ExecutorFrom(ctx, c.executor)
+
+	// 不使用 pflag：格式和参数经常以 - 开头（如 printf "%d" -5）
+	varName := ""
+	if len(args) >= 2 && args[0] == "-v" {
+		varName = args[1]
+		if !script.IsValidName(varName) {
+			return fmt.Errorf("printf: '%s': 不是有效的标识符", varName)
+		}
+		args = args[2:]
+	}
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("用法: printf [-v 变量] 格式 [参数...]")
+	}
+
+	p := &printfState{args: args[1:]}
+	p.run(args[0])
+
+	if varName != "" {
+		if err := executor.Variables().Set(varName, p.out.String()); err != nil {
+			return fmt.Errorf("printf: %w", err)
+		}
+	} else {
+		io.WriteString(streams.Stdout(ctx), p.out.String())
+	}
+
+	return p.err
+}
+
+// printfState 一次 printf 的输出和参数位置
+type printfState struct {
+	out  strings.Builder
+	args []string
+	next int
+	stop bool  // 遇到 \c，停止所有输出
+	err  error // 第一个参数错误，输出继续进行
+}
+
+// run 按格式输出；参数没有用完时重复使用格式，格式中没有转换时只输出一次
+func (p *printfState) run(format string) {
+	for {
+		start := p.next
+		p.format(format)
+		if p.stop || p.next >= len(p.args) || p.next == start {
+			return
+		}
+	}
+}
+
+// format 处理一遍格式字符串
+func (p *printfState) format(format string) {
+	for i := 0; i < len(format) && !p.stop; i++ {
+		switch format[i] {
+		case '\\':
+			n := p.escape(format[i+1:], false)
+			i += n
+		case '%':
+			n := p.directive(format[i+1:])
+			i += n
+		default:
+			p.out.WriteByte(format[i])
+		}
+	}
+}
+
+// escape 处理反斜杠之后的转义序列，返回消耗的字节数
+//
+// inArg 为 true 时按 %b 的规则处理：八进制写作 \0NNN。
+func (p *printfState) escape(s string, inArg bool) int {
+	text, n, stop := decodeEscape(s, inArg)
+	p.out.WriteString(text)
+	if stop {
+		p.stop = true
+	}
+	return n
+}
+
+// directive 处理 % 之后的转换说明，返回消耗的字节数
+func (p *printfState) directive(s string) int {
+	if s == "" {
+		p.out.WriteByte('%')
+		return 0
+	}
+	if s[0] == '%' {
+		p.out.WriteByte('%')
+		return 1
+	}
+
+	// 标志、宽度、精度
+	i := 0
+	flags := ""
+	for i < len(s) && strings.IndexByte("-+ #0", s[i]) >= 0 {
+		flags += string(s[i])
+		i++
+	}
+
+	width, hasWidth := "", false
+	if i < len(s) && s[i] == '*' {
+		w := p.intArg()
+		if w < 0 {
+			flags += "-"
+			w = -w
+		}
+		width, hasWidth = strconv.FormatInt(w, 10), true
+		i++
+	} else {
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			width += string(s[i])
+			i++
+		}
+		hasWidth = width != ""
+	}
+
+	precision, hasPrecision := "", false
+	if i < len(s) && s[i] == '.' {
+		hasPrecision = true
+		i++
+		if i < len(s) && s[i] == '*' {
+			prec := p.intArg()
+			if prec < 0 {
+				hasPrecision = false
+			} else {
+				precision = strconv.FormatInt(prec, 10)
+			}
+			i++
+		} else {
+			for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+				precision += string(s[i])
+				i++
+			}
+			if precision == "" {
+				precision = "0"
+			}
+		}
+	}
+
+	// 忽略 C 的长度修饰符（如 %ld、%lld）
+	for i < len(s) && strings.IndexByte("hlLjzt", s[i]) >= 0 {
+		i++
+	}
+	if i >= len(s) {
+		p.setError(fmt.Errorf("printf: %%%s: 缺少格式字符", s))
+		p.stop = true
+		return i
+	}
+
+	spec := "%" + flags
+	if hasWidth {
+		spec += width
+	}
+	if hasPrecision {
+		spec += "." + precision
+	}
+
+	verb := s[i]
+	switch verb {
+	case 's':
+		fmt.Fprintf(&p.out, spec+"s", p.stringArg())
+	case 'b':
+		fmt.Fprintf(&p.out, spec+"s", p.escapedArg())
+	case 'q':
+		fmt.Fprintf(&p.out, spec+"s", shellQuote(p.stringArg()))
+	case 'c':
+		arg := p.stringArg()
+		if arg != "" {
+			r, _ := utf8.DecodeRuneInString(arg)
+			arg = string(r)
+		}
+		fmt.Fprintf(&p.out, strings.Split(spec, ".")[0]+"s", arg)
+	case 'd', 'i':
+		fmt.Fprintf(&p.out, spec+"d", p.intArg())
+	case 'u':
+		fmt.Fprintf(&p.out, spec+"d", uint64(p.intArg()))
+	case 'o', 'x', 'X':
+		fmt.Fprintf(&p.out, spec+string(verb), uint64(p.intArg()))
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		if !hasPrecision && (verb == 'g' || verb == 'G') {
+			spec += ".6" // 与 C 一致：%g 默认 6 位有效数字
+		}
+		p.out.WriteString(formatFloat(spec, verb, p.floatArg()))
+	default:
+		p.setError(fmt.Errorf("printf: %%%c: 无效的格式字符", verb))
+		p.stop = true
+	}
+
+	return i + 1
+}
+
+// stringArg 取下一个参数，参数用完时为空字符串
+func (p *printfState) stringArg() string {
+	if p.next >= len(p.args) {
+		return ""
+	}
+	arg := p.args[p.next]
+	p.next++
+	return arg
+}
+
+// escapedArg 取下一个参数并处理其中的转义（%b）
+func (p *printfState) escapedArg() string {
+	arg := p.stringArg()
+	var buf strings.Builder
+	for i := 0; i < len(arg); i++ {
+		if arg[i] != '\\' {
+			buf.WriteByte(arg[i])
+			continue
+		}
+		text, n, stop := decodeEscape(arg[i+1:], true)
+		buf.WriteString(text)
+		if stop {
+			p.stop = true
+			break
+		}
+		i += n
+	}
+	return buf.String()
+}
+
+// intArg 取下一个参数并转换为整数
+//
+// 支持 0x 十六进制、0 开头的八进制，以及 'c 表示字符 c 的编码。
+// 无法转换时记录错误并使用已转换的部分（与 bash 一致）。
+func (p *printfState) intArg() int64 {
+	arg := strings.TrimSpace(p.stringArg())
+	if arg == "" {
+		return 0
+	}
+	if r, ok := charConstant(arg); ok {
+		return int64(r)
+	}
+
+	n, err := strconv.ParseInt(arg, 0, 64)
+	if err == nil {
+		return n
+	}
+	if u, uerr := strconv.ParseUint(arg, 0, 64); uerr == nil {
+		return int64(u)
+	}
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		p.setError(fmt.Errorf("printf: %s: 结果超出范围", arg))
+		if strings.HasPrefix(arg, "-") {
+			return math.MinInt64
+		}
+		return math.MaxInt64
+	}
+
+	p.setError(fmt.Errorf("printf: %s: 无效的数字", arg))
+	end := 0
+	if end < len(arg) && (arg[end] == '-' || arg[end] == '+') {
+		end++
+	}
+	for end < len(arg) && arg[end] >= '0' && arg[end] <= '9' {
+		end++
+	}
+	n, _ = strconv.ParseInt(arg[:end], 10, 64)
+	return n
+}
+
+// floatArg 取下一个参数并转换为浮点数
+func (p *printfState) floatArg() float64 {
+	arg := strings.TrimSpace(p.stringArg())
+	if arg == "" {
+		return 0
+	}
+	if r, ok := charConstant(arg); ok {
+		return float64(r)
+	}
+
+	f, err := strconv.ParseFloat(arg, 64)
+	if err != nil {
+		if n, ierr := strconv.ParseInt(arg, 0, 64); ierr == nil {
+			return float64(n)
+		}
+		p.setError(fmt.Errorf("printf: %s: 无效的数字", arg))
+		return 0
+	}
+	return f
+}
+
+// setError 记录第一个错误
+func (p *printfState) setError(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// charConstant 解析 'c 或 "c 形式的字符常量
+func charConstant(arg string) (rune, bool) {
+	if len(arg) < 2 || (arg[0] != '\'' && arg[0] != '"') {
+		return 0, false
+	}
+	r, _ := utf8.DecodeRuneInString(arg[1:])
+	return r, true
+}
+
+// formatFloat 按 C 的规则格式化浮点数，inf 和 nan 使用 C 的写法
+func formatFloat(spec string, verb byte, f float64) string {
+	if !math.IsInf(f, 0) && !math.IsNaN(f) {
+		if verb == 'F' {
+			verb = 'f'
+		}
+		return fmt.Sprintf(spec+string(verb), f)
+	}
+
+	text := "inf"
+	switch {
+	case math.IsNaN(f):
+		text = "nan"
+	case f < 0:
+		text = "-inf"
+	case strings.Contains(spec, "+"):
+		text = "+inf"
+	}
+	if verb >= 'A' && verb <= 'Z' {
+		text = strings.ToUpper(text)
+	}
+
+	// 只保留左对齐标志和宽度
+	width := strings.TrimLeft(strings.Split(spec, ".")[0], "%-+ #0")
+	if strings.Contains(spec, "-") {
+		width = "-" + width
+	}
+	return fmt.Sprintf("%"+width+"s", text)
+}
+
+// decodeEscape 解码反斜杠之后的转义序列，返回文本、消耗的字节数和是否遇到 \c
+//
+// 支持 \\ \a \b \e \f \n \r \t \v \" \' \NNN \xHH \uHHHH \UHHHHHHHH 和 \c；
+// octalZero 为 true 时八进制写作 \0NNN（echo -e 和 printf %b 的规则）。
+// 无法识别的转义原样保留反斜杠。
+func decodeEscape(s string, octalZero bool) (string, int, bool) {
+	if s == "" {
+		return "\\", 0, false
+	}
+
+	switch s[0] {
+	case '\\':
+		return "\\", 1, false
+	case 'a':
+		return "\a", 1, false
+	case 'b':
+		return "\b", 1, false
+	case 'e', 'E':
+		return "\x1b", 1, false
+	case 'f':
+		return "\f", 1, false
+	case 'n':
+		return "\n", 1, false
+	case 'r':
+		return "\r", 1, false
+	case 't':
+		return "\t", 1, false
+	case 'v':
+		return "\v", 1, false
+	case '"':
+		return "\"", 1, false
+	case '\'':
+		return "'", 1, false
+	case 'c':
+		return "", 1, true
+	case 'x':
+		value, n := parseDigits(s[1:], 16, 2)
+		if n == 0 {
+			return "\\x", 1, false
+		}
+		return string([]byte{byte(value)}), 1 + n, false
+	case 'u', 'U':
+		max := 4
+		if s[0] == 'U' {
+			max = 8
+		}
+		value, n := parseDigits(s[1:], 16, max)
+		if n == 0 {
+			return "\\" + s[:1], 1, false
+		}
+		return string(rune(value)), 1 + n, false
+	}
+
+	if s[0] >= '0' && s[0] <= '7' {
+		if octalZero {
+			if s[0] != '0' {
+				return "\\" + s[:1], 1, false
+			}
+			value, n := parseDigits(s[1:], 8, 3)
+			return string([]byte{byte(value)}), 1 + n, false
+		}
+		value, n := parseDigits(s, 8, 3)
+		return string([]byte{byte(value)}), n, false
+	}
+
+	return "\\" + s[:1], 1, false
+}
+
+// parseDigits 解析最多 max 位指定进制的数字，返回值和位数
+func parseDigits(s string, base, max int) (int, int) {
+	value, n := 0, 0
+	for n < len(s) && n < max {
+		d := strings.IndexByte("0123456789abcdef", lower(s[n]))
+		if d < 0 || d >= base {
+			break
+		}
+		value = value*base + d
+		n++
+	}
+	return value, n
+}
+
+// lower 把 ASCII 字母转换为小写
+func lower(b byte) byte {
+	if b >= 'A' && b <= 'Z' {
+		return b + 'a' - 'A'
+	}
+	return b
+}
+
+// shellQuote 把字符串转换为 shell 可以安全读入的形式（%q）
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !(r == '_' || r == '-' || r == '.' || r == '/' || r == ',' || r == ':' || r == '=' || r == '+' || r == '@' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r >= utf8.RuneSelf) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (c *PrintfCommand) Help() string {
+	return `printf - 按格式输出
+
+用法:
+  printf [-v 变量] 格式 [参数...]
+
+说明:
+  按格式字符串输出参数。参数多于格式中的转换说明时重复使用格式，
+  直到参数用完；参数不足时字符串视为空，数字视为 0。printf 不会自动
+  添加换行。
+
+转换说明: %[标志][宽度][.精度]转换字符
+  %s  字符串                %b  字符串，处理其中的反斜杠转义
+  %d  十进制整数（同 %i）   %u  无符号十进制整数
+  %x  十六进制（%X 大写）   %o  八进制
+  %f  浮点数                %e  科学计数法（%E 大写）
+  %g  %f 或 %e 中较短的     %c  参数的第一个字符
+  %q  加引号，可作为 shell 输入
+  %%  百分号
+
+  标志: - 左对齐，+ 总是显示符号，空格 正数前加空格，0 用 0 填充，
+        # 备用格式（如 0x 前缀）
+  宽度和精度可以写成 *，从参数中读取。
+  整数参数可以写成 0x1F、017，或 'A 表示字符 A 的编码。
+
+转义序列:
+  \\  \a  \b  \e  \f  \n  \r  \t  \v  \"  \'
+  \NNN 八进制   \xHH 十六进制   \uHHHH \UHHHHHHHH Unicode 字符
+  \c  停止输出（%b 参数中的八进制写作 \0NNN）
+
+选项:
+  -v 变量   把结果赋值给变量而不是输出
+
+示例:
+  printf "%s\n" hello                  # hello
+  printf "%-10s|%5d\n" name 42         # 左对齐的列
+  printf "%05.1f\n" 3.14159            # 003.1
+  printf "%x %o %c\n" 255 8 A          # ff 10 A
+  printf "%*d\n" 6 42                  # 宽度来自参数
+  printf "%s=%s\n" a 1 b 2             # 重复使用格式，输出两行
+  printf -v line "%03d" 7              # line=007`
+}
+
+func (c *PrintfCommand) ShortHelp() string {
+	return "按格式输出"
+}
@@ -3,17 +3,17 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os"
+	"io"
 	"os/exec"
 	"runtime"
+
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
-type PsCommand struct {
-	stdout *os.File
-}
+type PsCommand struct{}
 
-func NewPsCommand(stdout *os.File) *PsCommand {
-	return &PsCommand{stdout: stdout}
+func NewPsCommand() *PsCommand {
+	return &PsCommand{}
 }
 
 func (c *PsCommand) Name() string {
@@ -21,32 +21,34 @@ func (c *PsCommand) Name() string {
 }
 
 func (c *PsCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	// Windows 使用 tasklist，Linux 使用 ps
 	if runtime.GOOS == "windows" {
-		return c.windowsPs()
+		return c.windowsPs(stdout)
 	}
-	return c.unixPs()
+	return c.unixPs(stdout)
 }
 
-func (c *PsCommand) windowsPs() error {
+func (c *PsCommand) windowsPs(stdout io.Writer) error {
 	cmd := exec.Command("tasklist")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("执行 tasklist 失败: %w", err)
 	}
 
-	fmt.Fprint(c.stdout, string(output))
+	fmt.Fprint(stdout, string(output))
 	return nil
 }
 
-func (c *PsCommand) unixPs() error {
+func (c *PsCommand) unixPs(stdout io.Writer) error {
 	cmd := exec.Command("ps", "aux")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("执行 ps 失败: %w", err)
 	}
 
-	fmt.Fprint(c.stdout, string(output))
+	fmt.Fprint(stdout, string(output))
 	return nil
 }
 
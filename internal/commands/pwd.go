@@ -4,14 +4,14 @@ import (
 	"context"
 	"fmt"
 	"os"
+
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
-type PwdCommand struct {
-	stdout *os.File
-}
+type PwdCommand struct{}
 
-func NewPwdCommand(stdout *os.File) *PwdCommand {
-	return &PwdCommand{stdout: stdout}
+func NewPwdCommand() *PwdCommand {
+	return &PwdCommand{}
 }
 
 func (c *PwdCommand) Name() string {
@@ -19,12 +19,14 @@ func (c *PwdCommand) Name() string {
 }
 
 func (c *PwdCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	dir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("获取当前目录失败: %w", err)
 	}
 
-	fmt.Fprintln(c.stdout, dir)
+	fmt.Fprintln(stdout, dir)
 	return nil
 }
 
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// ReadonlyCommand readonly 命令 - 将变量标记为只读
+type ReadonlyCommand struct {
+	executor *script.Executor
+}
+
+// NewReadonlyCommand 创建 readonly 命令
+func NewReadonlyCommand(executor *script.Executor) *ReadonlyCommand {
+	return &ReadonlyCommand{
+		executor: executor,
+	}
+}
+
+func (c *ReadonlyCommand) Name() string {
+	return "readonly"
+}
+
+func (c *ReadonlyCommand) Execute(ctx context.Context, args []string) error {
+	executor := script.ExecutorFrom(ctx, c.executor)
+	stdout := streams.Stdout(ctx)
+
+	flags := flag.NewFlagSet("readonly", flag.ContinueOnError)
+	print := flags.BoolP("print", "p", false, "列出所有只读变量")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	vars := executor.Variables()
+	names := flags.Args()
+
+	if *print || len(names) == 0 {
+		for _, name := range vars.Names() {
+			if v, ok := vars.Lookup(name); ok && v.ReadOnly {
+				fmt.Fprintf(stdout, "readonly %s=%s\n", name, quoteVariableValue(v.Value))
+			}
+		}
+		return nil
+	}
+
+	for _, arg := range names {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !script.IsValidName(name) {
+			return fmt.Errorf("readonly: '%s': 不是有效的标识符", arg)
+		}
+
+		if hasValue {
+			if err := vars.Set(name, value); err != nil {
+				return fmt.Errorf("readonly: %w", err)
+			}
+		}
+		vars.MarkReadOnly(name)
+	}
+
+	return nil
+}
+
+func (c *ReadonlyCommand) Help() string {
+	return `readonly - 将变量标记为只读
+
+用法:
+  readonly [name[=value] ...]
+  readonly -p
+
+说明:
+  只读变量不能再被赋值或 unset，在整个会话中保持不变。
+  不带参数时列出所有只读变量。
+
+选项:
+  -p, --print   列出所有只读变量
+
+示例:
+  readonly VERSION=1.0     # 定义只读变量
+  readonly PATH            # 将已有变量设为只读
+  readonly -p              # 列出只读变量`
+}
+
+func (c *ReadonlyCommand) ShortHelp() string {
+	return "定义只读变量"
+}
@@ -0,0 +1,364 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/search"
+	"github.com/Lingbou/Lish/internal/streams"
+	"github.com/Lingbou/Lish/internal/theme"
+	flag "github.com/spf13/pflag"
+)
+
+// searchBufferSize 每次读取文件的字节数，小于它的文件一次读完
+const searchBufferSize = 1 << 20
+
+// searchBuffers 复用读取缓冲区，避免每个文件都分配
+var searchBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, searchBufferSize)
+		return &buf
+	},
+}
+
+// SearchCommand search 命令 - 并发递归搜索，遵循 .gitignore
+type SearchCommand struct {
+	themeManager *theme.Manager
+}
+
+// NewSearchCommand 创建 search 命令
+func NewSearchCommand(themeManager *theme.Manager) *SearchCommand {
+	return &SearchCommand{themeManager: themeManager}
+}
+
+func (c *SearchCommand) Name() string {
+	return "search"
+}
+
+// searchResult 一个文件的搜索结果
+type searchResult struct {
+	output  []byte
+	matches int
+	err     error
+}
+
+// fileSearcher 在文件中查找匹配的行，可以被多个 goroutine 同时使用
+type fileSearcher struct {
+	g          *grepper       // 逐行匹配、-w 判断和高亮
+	prefilter  *regexp.Regexp // 多行模式的同一个表达式，用于在整块数据中快速定位候选行
+	lineNumber bool
+	list       bool // -l
+	count      bool // -c
+	quiet      bool
+	maxCount   int
+	text       bool // 搜索二进制文件
+}
+
+func (c *SearchCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	patterns := flags.StringArrayP("regexp", "e", nil, "搜索模式，可以多次使用")
+	ignoreCase := flags.BoolP("ignore-case", "i", false, "忽略大小写")
+	smartCase := flags.BoolP("smart-case", "S", false, "模式全为小写时忽略大小写")
+	fixed := flags.BoolP("fixed-strings", "F", false, "模式是普通字符串")
+	word := flags.BoolP("word-regexp", "w", false, "只匹配整个单词")
+	lineRegexp := flags.BoolP("line-regexp", "x", false, "只匹配整行")
+	noLineNumber := flags.BoolP("no-line-number", "N", false, "不显示行号")
+	list := flags.BoolP("files-with-matches", "l", false, "只显示有匹配的文件名")
+	count := flags.BoolP("count", "c", false, "只显示每个文件匹配的行数")
+	quiet := flags.BoolP("quiet", "q", false, "不输出，只返回退出码")
+	maxCount := flags.IntP("max-count", "m", 0, "每个文件最多匹配 N 行")
+	globs := flags.StringArrayP("glob", "g", nil, "只搜索匹配的文件，! 开头时排除")
+	hidden := flags.Bool("hidden", false, "搜索隐藏文件和目录")
+	noIgnore := flags.Bool("no-ignore", false, "不使用 .gitignore 等忽略文件")
+	unrestricted := flags.CountP("unrestricted", "u", "减少过滤: -u 不忽略，-uu 加隐藏文件，-uuu 加二进制文件")
+	text := flags.BoolP("text", "a", false, "搜索二进制文件")
+	threads := flags.IntP("threads", "j", 0, "并发数，默认为 CPU 核数")
+	maxDepth := flags.Int("max-depth", 0, "最大目录深度")
+	color := flags.String("color", "auto", "高亮显示: always、never 或 auto")
+	flags.Lookup("color").NoOptDefVal = "always"
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	operands := flags.Args()
+	exprs := append([]string(nil), *patterns...)
+	if len(exprs) == 0 {
+		if len(operands) == 0 {
+			return fmt.Errorf("search: 需要指定搜索模式")
+		}
+		exprs, operands = operands[:1], operands[1:]
+	}
+	if len(operands) == 0 {
+		operands = []string{"."}
+	}
+
+	if *smartCase && !*ignoreCase {
+		*ignoreCase = true
+		for _, p := range exprs {
+			if strings.ToLower(p) != p {
+				*ignoreCase = false
+				break
+			}
+		}
+	}
+	re, err := compileGrepPattern(exprs, *fixed, *ignoreCase, *lineRegexp)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	// 快速定位只需要匹配的位置，不需要最左最长
+	prefilter, err := regexp.Compile("(?m)" + re.String())
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+
+	colored, err := colorEnabled(*color, std.Stdout)
+	if err != nil {
+		return fmt.Errorf("search: %w", err)
+	}
+	g := &grepper{opts: grepOptions{wordRegexp: *word && !*lineRegexp}, re: re}
+	if colored && c.themeManager != nil {
+		g.useScheme(c.themeManager.CurrentScheme())
+	}
+
+	s := &fileSearcher{
+		g:          g,
+		prefilter:  prefilter,
+		lineNumber: !*noLineNumber,
+		list:       *list,
+		count:      *count,
+		quiet:      *quiet,
+		maxCount:   *maxCount,
+		text:       *text || *unrestricted >= 3,
+	}
+	opts := search.Options{
+		Hidden:   *hidden || *unrestricted >= 2,
+		NoIgnore: *noIgnore || *unrestricted >= 1,
+		Globs:    *globs,
+		MaxDepth: *maxDepth,
+	}
+
+	matched, failed := false, false
+	err = search.Run(ctx, operands, opts, *threads, s.searchFile,
+		func(path string, result searchResult, err error) bool {
+			if err == nil {
+				err = result.err
+			}
+			if err != nil {
+				failed = true
+				fmt.Fprintf(std.Stderr, "search: %v\n", err)
+				return true
+			}
+			if result.matches > 0 {
+				matched = true
+				if s.quiet {
+					return false
+				}
+			}
+			if _, err := std.Stdout.Write(result.output); err != nil {
+				failed = true
+				return false
+			}
+			return true
+		})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case failed && !(s.quiet && matched):
+		return script.ExitStatus(2)
+	case !matched:
+		return script.ExitStatus(1)
+	}
+	return nil
+}
+
+// searchFile 分块读取文件并搜索，每块在最后一个换行处截断，剩余部分留给下一块
+func (s *fileSearcher) searchFile(ctx context.Context, path string) searchResult {
+	file, err := os.Open(path)
+	if err != nil {
+		return searchResult{err: err}
+	}
+	defer file.Close()
+
+	bufp := searchBuffers.Get().(*[]byte)
+	defer searchBuffers.Put(bufp)
+	buf := *bufp
+
+	var result searchResult
+	var out bytes.Buffer
+	lineNum := 1 // data[0] 所在的行号
+	carry := 0   // 上一块留下的不完整行
+	first := true
+	for {
+		if ctx.Err() != nil {
+			return searchResult{}
+		}
+		n, err := io.ReadFull(file, buf[carry:])
+		eof := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !eof {
+			return searchResult{err: err}
+		}
+		data := buf[:carry+n]
+		if first && !s.text && bytes.IndexByte(data, 0) >= 0 {
+			// 二进制文件
+			return searchResult{}
+		}
+		first = false
+
+		end := len(data)
+		if !eof {
+			end = bytes.LastIndexByte(data, '\n') + 1
+			if end == 0 {
+				// 一行比缓冲区还长，扩大缓冲区（不放回池中）
+				bigger := make([]byte, len(buf)*2)
+				carry = copy(bigger, data)
+				buf = bigger
+				continue
+			}
+		}
+		if s.scan(path, data[:end], &lineNum, &result, &out) || eof {
+			break
+		}
+		carry = copy(buf, data[end:])
+	}
+
+	if s.count && !s.quiet && !s.list && result.matches > 0 {
+		fmt.Fprintf(&out, "%s%s%d\n", s.g.paint(s.g.fileColor, path), s.g.paint(s.g.separatorColor, ":"), result.matches)
+	}
+	result.output = out.Bytes()
+	return result
+}
+
+// scan 搜索一块以完整行结尾的数据，返回 true 表示这个文件不需要再搜索
+//
+// 先用多行模式的表达式在整块数据中查找，只有包含匹配的行才逐行确认，
+// 大多数不匹配的内容不需要按行拆分。
+func (s *fileSearcher) scan(path string, data []byte, lineNum *int, result *searchResult, out *bytes.Buffer) bool {
+	pos := 0
+	counted := 0 // lineNum 已经统计到的位置
+	for pos < len(data) {
+		loc := s.prefilter.FindIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		start := pos + loc[0]
+		lineStart := bytes.LastIndexByte(data[:start], '\n') + 1
+		if lineStart >= len(data) {
+			break
+		}
+		lineEnd := len(data)
+		if i := bytes.IndexByte(data[start:], '\n'); i >= 0 {
+			lineEnd = start + i
+		}
+		*lineNum += bytes.Count(data[counted:lineStart], []byte{'\n'})
+		counted = lineStart
+		pos = lineEnd + 1
+
+		line := string(data[lineStart:lineEnd])
+		if !s.g.matchLine(line) {
+			continue
+		}
+		result.matches++
+		switch {
+		case s.quiet:
+			return true
+		case s.list:
+			fmt.Fprintln(out, s.g.paint(s.g.fileColor, path))
+			return true
+		case !s.count:
+			s.printLine(out, path, *lineNum, line)
+		}
+		if s.maxCount > 0 && result.matches >= s.maxCount {
+			return true
+		}
+	}
+	*lineNum += bytes.Count(data[counted:], []byte{'\n'})
+	return false
+}
+
+func (s *fileSearcher) printLine(out *bytes.Buffer, path string, lineNum int, line string) {
+	g := s.g
+	out.WriteString(g.paint(g.fileColor, path))
+	out.WriteString(g.paint(g.separatorColor, ":"))
+	if s.lineNumber {
+		out.WriteString(g.paint(g.lineColor, strconv.Itoa(lineNum)))
+		out.WriteString(g.paint(g.separatorColor, ":"))
+	}
+	if g.colored {
+		line = g.highlight(line)
+	}
+	out.WriteString(line)
+	out.WriteByte('\n')
+}
+
+func (c *SearchCommand) Help() string {
+	return `search - 并发递归搜索文本（遵循 .gitignore）
+
+用法:
+  search [选项] 模式 [路径...]
+  search [选项] -e 模式 [-e 模式...] [路径...]
+
+说明:
+  递归搜索目录（默认为当前目录）中的文件，输出 文件:行号:内容。
+  多个文件由多个 goroutine 同时搜索，输出顺序与遍历顺序一致（按名称排序，深度优先），
+  不随并发数变化。
+
+  默认跳过:
+    - .gitignore、.ignore 和 .git/info/exclude 忽略的文件（包括 Git 仓库中上层目录的规则）
+    - 以 . 开头的隐藏文件和目录，以及 .git 目录
+    - 二进制文件（包含 NUL 字节）
+    - 符号链接和特殊文件
+  命令行直接给出的文件总是被搜索。
+
+选项:
+  -e, --regexp <模式>       搜索模式，可以多次使用（匹配任意一个即可）
+  -i, --ignore-case         忽略大小写
+  -S, --smart-case          模式全为小写时忽略大小写，否则区分大小写
+  -F, --fixed-strings       模式是普通字符串而不是正则表达式
+  -w, --word-regexp         只匹配整个单词
+  -x, --line-regexp         只匹配整行
+  -N, --no-line-number      不显示行号
+  -l, --files-with-matches  只显示有匹配的文件名
+  -c, --count               只显示每个文件匹配的行数
+  -m, --max-count <N>       每个文件最多匹配 N 行
+  -q, --quiet               不输出，找到匹配后立即停止，只返回退出码
+  -g, --glob <GLOB>         只搜索匹配的文件，以 ! 开头时排除匹配的文件或目录，
+                            语法同 .gitignore，可以多次使用
+  --hidden                  搜索隐藏文件和目录
+  --no-ignore               不使用忽略文件
+  -u, --unrestricted        -u 同 --no-ignore，-uu 再加 --hidden，-uuu 再加 --text
+  -a, --text                搜索二进制文件
+  -j, --threads <N>         并发数（默认为 CPU 核数）
+  --max-depth <N>           最多进入 N 层目录（0 表示不限制）
+  --color[=WHEN]            高亮显示匹配，WHEN 为 always、never 或 auto（默认）
+
+示例:
+  search TODO                             # 搜索当前目录
+  search -S "func main" cmd internal      # 在多个目录中搜索
+  search -g '*.go' -g '!*_test.go' err    # 只搜索 Go 文件，排除测试
+  search -l -F "config.yaml"              # 列出包含该字符串的文件
+  search -uu secret                       # 包括被忽略的文件和隐藏文件
+  if search -q TODO; then echo "有待办事项"; fi
+
+退出码:
+  0 找到匹配，1 没有匹配，2 出现错误`
+}
+
+func (c *SearchCommand) ShortHelp() string {
+	return "并发递归搜索（遵循 .gitignore）"
+}
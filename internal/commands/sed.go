@@ -1,17 +1,21 @@
 package commands
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"regexp"
+	"path/filepath"
 	"strings"
 
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/sed"
+	"github.com/Lingbou/Lish/internal/streams"
 	flag "github.com/spf13/pflag"
 )
 
-// SedCommand sed 命令 - 流编辑器（基础版）
+// SedCommand sed 命令 - 流编辑器
 type SedCommand struct{}
 
 func (c *SedCommand) Name() string {
@@ -19,259 +23,263 @@ func (c *SedCommand) Name() string {
 }
 
 func (c *SedCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
+	// GNU sed 的 -i 可以直接跟备份后缀（-i.bak），pflag 不支持短选项的可选值
+	args, suffix := splitInPlaceSuffix(args)
+
 	flags := flag.NewFlagSet("sed", flag.ContinueOnError)
 	inPlace := flags.BoolP("in-place", "i", false, "原地编辑文件")
-	quiet := flags.BoolP("quiet", "n", false, "静默模式（不自动打印）")
-	expression := flags.StringP("expression", "e", "", "指定sed表达式")
+	quiet := flags.BoolP("quiet", "n", false, "不自动输出模式空间")
+	flags.BoolVar(quiet, "silent", false, "同 --quiet")
+	expressions := flags.StringArrayP("expression", "e", nil, "要执行的脚本")
+	scriptFiles := flags.StringArrayP("file", "f", nil, "从文件读取脚本")
+	extended := flags.BoolP("regexp-extended", "E", false, "使用扩展正则表达式")
+	flags.BoolVarP(extended, "r", "r", false, "同 -E")
+	flags.MarkHidden("r")
+	separate := flags.BoolP("separate", "s", false, "把每个文件作为单独的输入")
+	nullData := flags.BoolP("null-data", "z", false, "行以 NUL 字符分隔")
 
 	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
 		return err
 	}
-
-	remaining := flags.Args()
-
-	// 确定表达式和文件
-	var script string
-	var filenames []string
-
-	if *expression != "" {
-		script = *expression
-		filenames = remaining
-	} else if len(remaining) > 0 {
-		script = remaining[0]
-		filenames = remaining[1:]
-	} else {
-		return fmt.Errorf("用法: sed [-i] [-n] [-e expression] 'script' [file...]")
+	if suffix != "" {
+		*inPlace = true
 	}
-
-	// 读取输入
-	var lines []string
-	var err error
-
-	if len(filenames) == 0 {
-		// 从标准输入读取
-		lines, err = readLines(os.Stdin)
+	operands := flags.Args()
+
+	// 脚本由所有 -e 和 -f 按顺序组成，都没有时使用第一个参数
+	var parts []string
+	parts = append(parts, *expressions...)
+	for _, name := range *scriptFiles {
+		var data []byte
+		var err error
+		if name == "-" {
+			data, err = io.ReadAll(std.Stdin)
+		} else {
+			data, err = os.ReadFile(name)
+		}
 		if err != nil {
-			return fmt.Errorf("读取输入失败: %w", err)
+			return fmt.Errorf("sed: 无法读取脚本文件 %s: %w", name, err)
 		}
-	} else {
-		// 从文件读取
-		for _, filename := range filenames {
-			fileLines, err := readLinesFromFile(filename)
-			if err != nil {
-				return fmt.Errorf("读取文件 %s 失败: %w", filename, err)
-			}
-			lines = append(lines, fileLines...)
+		parts = append(parts, strings.TrimSuffix(string(data), "\n"))
+	}
+	if len(parts) == 0 {
+		if len(operands) == 0 {
+			return fmt.Errorf("用法: sed [选项] 'script' [文件...]")
 		}
+		parts, operands = operands[:1], operands[1:]
 	}
 
-	// 执行sed脚本
-	result, err := executeSedScript(script, lines, *quiet)
+	program, err := sed.Parse(strings.Join(parts, "\n"), *extended)
 	if err != nil {
-		return err
+		return fmt.Errorf("sed: %w", err)
 	}
-
-	// 输出或写回文件
-	if *inPlace && len(filenames) > 0 {
-		// 原地编辑模式
-		return writeLinesToFile(filenames[0], result)
-	} else {
-		// 输出到标准输出
-		for _, line := range result {
-			fmt.Println(line)
-		}
+	if *inPlace && len(operands) == 0 {
+		return fmt.Errorf("sed: -i 需要指定文件")
 	}
 
-	return nil
-}
-
-// executeSedScript 执行sed脚本
-func executeSedScript(script string, lines []string, quiet bool) ([]string, error) {
-	var result []string
-
-	// 解析脚本命令
-	cmd, err := parseSedCommand(script)
+	engine, err := sed.NewEngine(program, sed.Options{
+		Quiet:    *quiet,
+		NullData: *nullData,
+		Stdin:    std.Stdin,
+		Stdout:   std.Stdout,
+		Stderr:   std.Stderr,
+	})
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("sed: %w", err)
 	}
 
-	for lineNum, line := range lines {
-		processedLine, shouldPrint, err := processSedCommand(cmd, line, lineNum)
-		if err != nil {
-			return nil, err
+	failed := false
+	switch {
+	case *inPlace:
+		for _, name := range operands {
+			if engine.Quit() {
+				break
+			}
+			if _, err := os.Stat(name); err != nil {
+				fmt.Fprintf(std.Stderr, "sed: 无法读取文件: %v\n", err)
+				failed = true
+				continue
+			}
+			if err := editInPlace(ctx, engine, name, suffix); err != nil {
+				engine.Close()
+				return fmt.Errorf("sed: %w", err)
+			}
 		}
-
-		// 根据命令决定是否输出
-		if shouldPrint || (!quiet && cmd.action != "d") {
-			result = append(result, processedLine)
+	case *separate:
+		for _, name := range operands {
+			if engine.Quit() {
+				break
+			}
+			if err := engine.Run(ctx, []string{name}, std.Stdout); err != nil {
+				engine.Close()
+				return fmt.Errorf("sed: %w", err)
+			}
+		}
+	default:
+		if err := engine.Run(ctx, operands, std.Stdout); err != nil {
+			engine.Close()
+			return fmt.Errorf("sed: %w", err)
 		}
 	}
+	if err := engine.Close(); err != nil {
+		return fmt.Errorf("sed: %w", err)
+	}
 
-	return result, nil
-}
-
-// sedCommand sed命令结构
-type sedCommand struct {
-	action      string      // s, d, p, a, i
-	pattern     string      // 匹配模式
-	replacement string      // 替换字符串
-	flags       string      // g, i等标志
-	regex       *regexp.Regexp
+	switch {
+	case engine.ExitCode() != 0:
+		return script.ExitStatus(engine.ExitCode())
+	case failed || engine.Failed():
+		return script.ExitStatus(2)
+	}
+	return nil
 }
 
-// parseSedCommand 解析sed命令
-func parseSedCommand(script string) (*sedCommand, error) {
-	cmd := &sedCommand{}
-
-	script = strings.TrimSpace(script)
-
-	// 替换命令: s/pattern/replacement/flags
-	if strings.HasPrefix(script, "s/") || strings.HasPrefix(script, "s|") {
-		delimiter := script[1]
-		parts := strings.Split(script[2:], string(delimiter))
-		if len(parts) < 2 {
-			return nil, fmt.Errorf("无效的替换命令: %s", script)
-		}
-
-		cmd.action = "s"
-		cmd.pattern = parts[0]
-		cmd.replacement = parts[1]
-		if len(parts) > 2 {
-			cmd.flags = parts[2]
-		}
-
-		// 编译正则表达式
-		regexFlags := ""
-		if strings.Contains(cmd.flags, "i") {
-			regexFlags = "(?i)"
-		}
-		regex, err := regexp.Compile(regexFlags + cmd.pattern)
-		if err != nil {
-			return nil, fmt.Errorf("无效的正则表达式: %w", err)
+// splitInPlaceSuffix 把 -iSUFFIX 和 --in-place=SUFFIX 转为 -i，返回备份后缀
+func splitInPlaceSuffix(args []string) ([]string, string) {
+	result := make([]string, len(args))
+	copy(result, args)
+	suffix := ""
+	for i, arg := range result {
+		switch {
+		case arg == "--":
+			return result, suffix
+		case strings.HasPrefix(arg, "--in-place="):
+			suffix = strings.TrimPrefix(arg, "--in-place=")
+			result[i] = "--in-place"
+		case strings.HasPrefix(arg, "-i") && len(arg) > 2:
+			suffix = arg[2:]
+			result[i] = "-i"
 		}
-		cmd.regex = regex
-
-		return cmd, nil
 	}
+	return result, suffix
+}
 
-	// 删除命令: d
-	if script == "d" {
-		cmd.action = "d"
-		return cmd, nil
+// editInPlace 处理一个文件并用结果替换它，suffix 不为空时保留备份
+//
+// 结果先写入同一目录下的临时文件再重命名，中途出错不会破坏原文件。
+// 备份后缀中的 * 会被替换为文件名，例如 -i 'bak/*' 把备份放在 bak 目录中。
+func editInPlace(ctx context.Context, engine *sed.Engine, name, suffix string) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return fmt.Errorf("无法读取文件: %w", err)
 	}
-
-	// 打印命令: p
-	if script == "p" {
-		cmd.action = "p"
-		return cmd, nil
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s 不是普通文件", name)
 	}
 
-	// 追加命令: a\text
-	if strings.HasPrefix(script, "a\\") {
-		cmd.action = "a"
-		cmd.replacement = strings.TrimPrefix(script, "a\\")
-		return cmd, nil
+	dir := filepath.Dir(name)
+	tmp, err := os.CreateTemp(dir, ".sed-*")
+	if err != nil {
+		return fmt.Errorf("无法创建临时文件: %w", err)
 	}
+	defer os.Remove(tmp.Name())
 
-	// 插入命令: i\text
-	if strings.HasPrefix(script, "i\\") {
-		cmd.action = "i"
-		cmd.replacement = strings.TrimPrefix(script, "i\\")
-		return cmd, nil
+	runErr := engine.Run(ctx, []string{name}, tmp)
+	closeErr := tmp.Close()
+	if runErr != nil {
+		return runErr
 	}
-
-	return nil, fmt.Errorf("不支持的sed命令: %s", script)
-}
-
-// processSedCommand 处理sed命令
-func processSedCommand(cmd *sedCommand, line string, lineNum int) (string, bool, error) {
-	switch cmd.action {
-	case "s":
-		// 替换
-		if strings.Contains(cmd.flags, "g") {
-			// 全局替换
-			return cmd.regex.ReplaceAllString(line, cmd.replacement), false, nil
-		}
-		// 只替换第一个
-		return cmd.regex.ReplaceAllStringFunc(line, func(match string) string {
-			return cmd.replacement
-		}), false, nil
-
-	case "d":
-		// 删除（不输出）
-		return "", false, nil
-
-	case "p":
-		// 打印（额外输出一次）
-		return line, true, nil
-
-	case "a":
-		// 追加（在行后添加）
-		return line + "\n" + cmd.replacement, false, nil
-
-	case "i":
-		// 插入（在行前添加）
-		return cmd.replacement + "\n" + line, false, nil
-
-	default:
-		return line, false, fmt.Errorf("未知命令: %s", cmd.action)
+	if closeErr != nil {
+		return closeErr
 	}
-}
-
-// writeLinesToFile 将行写入文件
-func writeLinesToFile(filename string, lines []string) error {
-	file, err := os.Create(filename)
-	if err != nil {
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
 		return err
 	}
-	defer file.Close()
 
-	writer := bufio.NewWriter(file)
-	for _, line := range lines {
-		_, err := writer.WriteString(line + "\n")
-		if err != nil {
-			return err
+	if suffix != "" {
+		backup := name + suffix
+		if strings.Contains(suffix, "*") {
+			backup = strings.ReplaceAll(suffix, "*", filepath.Base(name))
+			if !strings.Contains(backup, "/") {
+				backup = filepath.Join(dir, backup)
+			}
+		}
+		if err := os.Rename(name, backup); err != nil {
+			return fmt.Errorf("无法创建备份: %w", err)
 		}
 	}
-
-	return writer.Flush()
+	return os.Rename(tmp.Name(), name)
 }
 
 func (c *SedCommand) Help() string {
-	return `sed - 流编辑器（基础版）
+	return `sed - 流编辑器
 
 用法:
   sed [选项] 'script' [文件...]
+  sed [选项] -e 'script' [-e 'script'...] [文件...]
+  sed [选项] -f script.sed [文件...]
   command | sed 'script'
 
 说明:
-  对文本进行流式编辑处理。支持基本的sed命令。
+  逐行读取输入到模式空间，执行脚本中的命令后输出模式空间。
+  脚本中的多条命令用分号或换行分隔。
 
 选项:
-  -i, --in-place      原地编辑文件
-  -n, --quiet         静默模式（不自动打印）
-  -e, --expression    指定sed表达式
-
-支持的命令:
-  s/pattern/replacement/[flags]   替换
-    - 标志 g: 全局替换
-    - 标志 i: 忽略大小写
-  d                               删除行
-  p                               打印行
-  a\text                          在行后追加文本
-  i\text                          在行前插入文本
+  -n, --quiet, --silent       不自动输出模式空间（脚本以 #n 行开头时相同）
+  -e, --expression <script>   添加脚本，可以多次使用
+  -f, --file <file>           从文件读取脚本，可以多次使用
+  -E, -r, --regexp-extended   使用扩展正则表达式（默认为基本正则表达式）
+  -i[SUFFIX], --in-place[=SUFFIX]
+                              原地编辑每个文件，指定后缀时保留备份（* 表示文件名）
+  -s, --separate              把每个文件作为单独的输入（行号和 $ 按文件计算）
+  -z, --null-data             行以 NUL 字符分隔
+
+地址:
+  N               第 N 行               $              最后一行
+  /re/  \%re%     匹配正则表达式的行（后跟 I 忽略大小写，M 多行模式）
+  first~step      从 first 开始每 step 行
+  addr1,addr2     从 addr1 到 addr2 的范围
+  addr1,+N        addr1 及之后的 N 行
+  addr1,~N        addr1 到下一个 N 的倍数行
+  0,/re/          从第一行开始到匹配 re 的行（第一行也可以结束范围）
+  addr!           不匹配地址的行
+  addr { ... }    对匹配的行执行一组命令
+
+命令:
+  s/re/repl/flags 替换。repl 中 & 表示匹配的内容，\1-\9 表示分组，\n 表示换行，
+                  \U \L \u \l \E 转换大小写；flags: g 全部、N 第 N 个、p 输出、
+                  i 忽略大小写、m 多行模式、w file 写入文件
+  y/abc/xyz/      逐字符转换
+  p / P           输出模式空间 / 输出第一行
+  d / D           删除模式空间 / 删除第一行并重新开始周期
+  n / N           输出并读入下一行 / 追加下一行到模式空间
+  h / H           复制 / 追加模式空间到保持空间
+  g / G           复制 / 追加保持空间到模式空间
+  x               交换模式空间和保持空间
+  a text          在当前行之后追加文本（也可以写成 a\ 换行 text）
+  i text          在当前行之前插入文本
+  c text          用文本替换当前行（范围只在结束时输出一次）
+  =               输出行号
+  l [N]           以转义形式输出模式空间，N 为行宽
+  r file          在当前行之后输出文件内容
+  w file          把模式空间写入文件
+  q [code]        输出模式空间后退出      Q [code]    直接退出
+  :label  b [label]  t [label]  T [label]
+                  定义标签 / 跳转 / 有替换时跳转 / 没有替换时跳转
+  z               清空模式空间           F           输出当前文件名
+  # comment       注释
 
 示例:
-  sed 's/old/new/' file.txt           # 替换第一个old为new
-  sed 's/old/new/g' file.txt          # 替换所有old为new
-  sed 's/old/new/gi' file.txt         # 忽略大小写全局替换
-  sed '/pattern/d' file.txt           # 删除匹配的行
-  sed -n '/pattern/p' file.txt        # 只打印匹配的行
-  sed 's/foo/bar/g' -i file.txt       # 原地编辑
-  echo "hello" | sed 's/hello/hi/'    # 管道使用`
+  sed 's/old/new/g' file.txt              # 替换所有 old 为 new
+  sed -n '/error/p' log.txt               # 只输出匹配的行
+  sed '1d; $d' file.txt                   # 删除第一行和最后一行
+  sed -n '10,20p' file.txt                # 输出第 10 到 20 行
+  sed '/^#/,/^$/d' file.txt               # 删除注释块
+  sed -E 's/([a-z]+)=([0-9]+)/\2=\1/' f   # 交换两个分组
+  sed -i.bak 's/foo/bar/' *.txt           # 原地编辑所有文件并保留备份
+  sed '$!N; s/\n/ /' file.txt             # 合并每两行
+  sed -n '1!G; h; $p' file.txt            # 倒序输出（同 tac）
+  sed ':a; N; $!ba; s/\n/,/g' file.txt    # 把所有行用逗号连接
+
+退出码:
+  0 成功，1 脚本错误，2 有输入文件无法读取，q/Q 指定的退出码`
 }
 
 func (c *SedCommand) ShortHelp() string {
 	return "流编辑器（替换、删除等）"
 }
-
@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Lingbou/Lish/internal/script"
+)
+
+// ShiftCommand shift 命令 - 左移位置参数
+type ShiftCommand struct {
+	executor *script.Executor
+}
+
+// NewShiftCommand 创建 shift 命令
+func NewShiftCommand(executor *script.Executor) *ShiftCommand {
+	return &ShiftCommand{executor: executor}
+}
+
+func (c *ShiftCommand) Name() string {
+	return "shift"
+}
+
+func (c *ShiftCommand) Execute(ctx context.Context, args []string) error {
+	executor := script.ExecutorFrom(ctx, c.executor)
+
+	if len(args) > 1 {
+		return fmt.Errorf("shift: 参数太多")
+	}
+
+	n := 1
+	if len(args) == 1 {
+		value, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("shift: %s: 需要数字参数", args[0])
+		}
+		n = value
+	}
+
+	return executor.Variables().Shift(n)
+}
+
+func (c *ShiftCommand) Help() string {
+	return `shift - 左移位置参数
+
+用法:
+  shift [n]
+
+说明:
+  丢弃前 n 个位置参数（默认 1），$n+1 变为 $1，$# 相应减少。
+  在函数中只影响函数自己的参数。n 大于参数个数时报错且不做修改。
+
+示例:
+  shift         # 丢弃 $1
+  shift 2       # 丢弃 $1 和 $2`
+}
+
+func (c *ShiftCommand) ShortHelp() string {
+	return "左移位置参数"
+}
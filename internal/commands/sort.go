@@ -1,14 +1,16 @@
 package commands
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"sort"
-	"strconv"
-	"strings"
+	"unicode/utf8"
 
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/sorting"
+	"github.com/Lingbou/Lish/internal/streams"
 	flag "github.com/spf13/pflag"
 )
 
@@ -20,175 +22,223 @@ func (c *SortCommand) Name() string {
 }
 
 func (c *SortCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
 	flags := flag.NewFlagSet("sort", flag.ContinueOnError)
-	reverse := flags.BoolP("reverse", "r", false, "反向排序")
-	numeric := flags.BoolP("numeric-sort", "n", false, "数字排序")
+	var global sorting.Modifiers
+	flags.BoolVarP(&global.Reverse, "reverse", "r", false, "反向排序")
+	flags.BoolVarP(&global.Numeric, "numeric-sort", "n", false, "数字排序")
+	flags.BoolVarP(&global.General, "general-numeric-sort", "g", false, "浮点数排序")
+	flags.BoolVarP(&global.Human, "human-numeric-sort", "h", false, "带单位的数字排序")
+	flags.BoolVarP(&global.Version, "version-sort", "V", false, "版本号排序")
+	flags.BoolVarP(&global.Month, "month-sort", "M", false, "月份排序")
+	flags.BoolVarP(&global.Blanks, "ignore-leading-blanks", "b", false, "忽略开头的空白")
+	flags.BoolVarP(&global.Dictionary, "dictionary-order", "d", false, "只比较空白、字母和数字")
+	flags.BoolVarP(&global.Fold, "ignore-case", "f", false, "忽略大小写")
+	flags.BoolVarP(&global.Fold, "i", "i", false, "同 -f")
+	flags.MarkHidden("i")
 	unique := flags.BoolP("unique", "u", false, "去除重复行")
-	fieldNum := flags.IntP("key", "k", 0, "按指定字段排序（从1开始）")
+	stable := flags.BoolP("stable", "s", false, "稳定排序")
+	keySpecs := flags.StringArrayP("key", "k", nil, "排序键")
 	separator := flags.StringP("field-separator", "t", "", "字段分隔符")
-	ignoreCase := flags.BoolP("ignore-case", "i", false, "忽略大小写")
+	check := flags.BoolP("check", "c", false, "检查是否已排序")
+	checkQuiet := flags.BoolP("check-quiet", "C", false, "检查是否已排序，不输出")
+	merge := flags.BoolP("merge", "m", false, "合并已排序的文件")
+	output := flags.StringP("output", "o", "", "输出到文件")
+	bufferSize := flags.StringP("buffer-size", "S", "", "内存缓冲区大小")
+	tempDir := flags.StringP("temporary-directory", "T", "", "临时文件目录")
+	parallel := flags.Int("parallel", 0, "并发排序数")
 
 	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
 		return err
 	}
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
 
-	remaining := flags.Args()
+	opts := sorting.Options{
+		Global:   global,
+		Stable:   *stable,
+		Unique:   *unique,
+		Parallel: *parallel,
+		TempDir:  *tempDir,
+		Stdin:    std.Stdin,
+	}
+	for _, spec := range *keySpecs {
+		key, err := sorting.ParseKey(spec)
+		if err != nil {
+			return fmt.Errorf("sort: %w", err)
+		}
+		opts.Keys = append(opts.Keys, key)
+	}
+	if *separator != "" {
+		switch *separator {
+		case `\t`:
+			*separator = "\t"
+		case `\0`:
+			*separator = "\x00"
+		}
+		if utf8.RuneCountInString(*separator) != 1 {
+			return fmt.Errorf("sort: 分隔符必须是单个字符: '%s'", *separator)
+		}
+		opts.Separator = *separator
+	}
+	if *bufferSize != "" {
+		size, err := sorting.ParseSize(*bufferSize)
+		if err != nil {
+			return fmt.Errorf("sort: %w", err)
+		}
+		opts.BufferSize = size
+	}
+	if *parallel < 0 {
+		return fmt.Errorf("sort: 无效的 --parallel 参数: %d", *parallel)
+	}
 
-	var lines []string
-	var err error
+	sorter, err := sorting.New(opts)
+	if err != nil {
+		return fmt.Errorf("sort: %w", err)
+	}
 
-	// 读取输入
-	if len(remaining) == 0 {
-		// 从标准输入读取
-		lines, err = readLines(os.Stdin)
+	if *check || *checkQuiet {
+		if len(files) > 1 {
+			return fmt.Errorf("sort: -c 只能检查一个文件")
+		}
+		disorder, err := sorter.Check(ctx, files[0])
 		if err != nil {
-			return fmt.Errorf("读取输入失败: %w", err)
+			return fmt.Errorf("sort: %w", err)
 		}
-	} else {
-		// 从文件读取
-		for _, filename := range remaining {
-			fileLines, err := readLinesFromFile(filename)
-			if err != nil {
-				return fmt.Errorf("读取文件 %s 失败: %w", filename, err)
+		if disorder != nil {
+			if !*checkQuiet {
+				fmt.Fprintf(std.Stderr, "sort: %s:%d: 无序: %s\n", files[0], disorder.Line, disorder.Text)
 			}
-			lines = append(lines, fileLines...)
+			return script.ExitStatus(1)
 		}
+		return nil
 	}
 
-	// 排序
-	if *numeric {
-		// 数字排序
-		sort.Slice(lines, func(i, j int) bool {
-			ni := extractNumber(lines[i], *fieldNum, *separator)
-			nj := extractNumber(lines[j], *fieldNum, *separator)
-			if *reverse {
-				return ni > nj
-			}
-			return ni < nj
-		})
-	} else if *fieldNum > 0 {
-		// 按字段排序
-		sort.Slice(lines, func(i, j int) bool {
-			fi := extractField(lines[i], *fieldNum, *separator)
-			fj := extractField(lines[j], *fieldNum, *separator)
-			
-			if *ignoreCase {
-				fi = strings.ToLower(fi)
-				fj = strings.ToLower(fj)
-			}
-			
-			if *reverse {
-				return fi > fj
-			}
-			return fi < fj
-		})
-	} else {
-		// 普通字符串排序
-		sort.Slice(lines, func(i, j int) bool {
-			li := lines[i]
-			lj := lines[j]
-			
-			if *ignoreCase {
-				li = strings.ToLower(li)
-				lj = strings.ToLower(lj)
-			}
-			
-			if *reverse {
-				return li > lj
-			}
-			return li < lj
-		})
+	var w io.Writer = std.Stdout
+	var out *lazyFile
+	if *output != "" {
+		out = &lazyFile{name: *output}
+		w = out
 	}
 
-	// 去重（如果指定）
-	if *unique {
-		lines = removeDuplicates(lines)
+	if *merge {
+		// 合并时边读边写，输出文件也是输入时先复制一份
+		inputs, cleanup, err := separateOutput(files, *output, *tempDir)
+		defer cleanup()
+		if err != nil {
+			return fmt.Errorf("sort: %w", err)
+		}
+		err = sorter.Merge(ctx, inputs, w)
+		if out != nil {
+			if closeErr := out.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("sort: %w", err)
+		}
+		return nil
 	}
 
-	// 输出结果
-	for _, line := range lines {
-		fmt.Println(line)
+	err = sorter.Sort(ctx, files, w)
+	if out != nil {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("sort: %w", err)
 	}
-
 	return nil
 }
 
-// extractField 提取指定字段
-func extractField(line string, fieldNum int, separator string) string {
-	if fieldNum <= 0 {
-		return line
-	}
+// lazyFile 第一次写入（或关闭）时才创建文件，
+// 排序时所有输入读完才开始输出，因此 -o 可以是输入文件之一
+type lazyFile struct {
+	name string
+	file *os.File
+}
 
-	var fields []string
-	if separator == "" {
-		// 默认使用空白字符分隔
-		fields = strings.Fields(line)
-	} else {
-		fields = strings.Split(line, separator)
+func (f *lazyFile) open() error {
+	if f.file != nil {
+		return nil
 	}
-
-	if fieldNum > len(fields) {
-		return ""
+	file, err := os.Create(f.name)
+	if err != nil {
+		return err
 	}
-
-	return fields[fieldNum-1]
+	f.file = file
+	return nil
 }
 
-// extractNumber 提取数字
-func extractNumber(line string, fieldNum int, separator string) float64 {
-	field := extractField(line, fieldNum, separator)
-	if field == "" {
-		field = line
+func (f *lazyFile) Write(p []byte) (int, error) {
+	if err := f.open(); err != nil {
+		return 0, err
 	}
+	return f.file.Write(p)
+}
 
-	// 尝试解析数字
-	num, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
-	if err != nil {
-		return 0
+func (f *lazyFile) Close() error {
+	if err := f.open(); err != nil {
+		return err
 	}
-	return num
+	return f.file.Close()
 }
 
-// removeDuplicates 去除重复行
-func removeDuplicates(lines []string) []string {
-	seen := make(map[string]bool)
-	result := []string{}
-
-	for _, line := range lines {
-		if !seen[line] {
-			seen[line] = true
-			result = append(result, line)
+// separateOutput 把与输出相同的输入文件复制到临时文件，返回替换后的输入列表
+func separateOutput(files []string, output, tempDir string) ([]string, func(), error) {
+	var temps []string
+	cleanup := func() {
+		for _, name := range temps {
+			os.Remove(name)
 		}
 	}
-
-	return result
-}
-
-// readLines 从 Reader 读取所有行
-func readLines(file *os.File) ([]string, error) {
-	var lines []string
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	outInfo, err := os.Stat(output)
+	if output == "" || err != nil {
+		return files, cleanup, nil
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	result := make([]string, len(files))
+	for i, name := range files {
+		result[i] = name
+		info, err := os.Stat(name)
+		if name == "-" || err != nil || !os.SameFile(info, outInfo) {
+			continue
+		}
+		temp, err := copyToTemp(name, tempDir)
+		if err != nil {
+			return nil, cleanup, err
+		}
+		temps = append(temps, temp)
+		result[i] = temp
 	}
-
-	return lines, nil
+	return result, cleanup, nil
 }
 
-// readLinesFromFile 从文件读取所有行
-func readLinesFromFile(filename string) ([]string, error) {
-	file, err := os.Open(filename)
+func copyToTemp(name, dir string) (string, error) {
+	src, err := os.Open(name)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer file.Close()
-
-	return readLines(file)
+	defer src.Close()
+	dst, err := os.CreateTemp(dir, "lish-sort-*")
+	if err != nil {
+		return "", fmt.Errorf("无法创建临时文件: %w", err)
+	}
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return "", err
+	}
+	return dst.Name(), nil
 }
 
 func (c *SortCommand) Help() string {
@@ -199,28 +249,56 @@ func (c *SortCommand) Help() string {
   command | sort [选项]
 
 说明:
-  对文本行进行排序。如果未指定文件，从标准输入读取。
-
-选项:
-  -r, --reverse           反向排序
-  -n, --numeric-sort      按数字大小排序
-  -u, --unique            去除重复行
-  -k, --key <N>           按第 N 个字段排序（从1开始）
-  -t, --field-separator   指定字段分隔符
-  -i, --ignore-case       忽略大小写
+  对所有输入的行排序后输出。如果未指定文件或文件为 -，从标准输入读取。
+  数据超过内存缓冲区（-S）时，已排序的部分写入临时文件，最后归并，可以排序任意大小的文件。
+  默认按字节比较；所有排序键都相等时再比较整行（-s 或 -u 时不比较）。
+
+排序方式（全局选项，也可以作为 -k 的修饰符）:
+  -b, --ignore-leading-blanks  忽略开头的空白
+  -d, --dictionary-order       只比较空白、字母和数字
+  -f, -i, --ignore-case        忽略大小写
+  -n, --numeric-sort           按数字大小排序
+  -g, --general-numeric-sort   按浮点数排序（支持 1e3 等科学计数法）
+  -h, --human-numeric-sort     按带单位的数字排序（2K < 1M < 3G）
+  -M, --month-sort             按月份排序（JAN < FEB < ... < DEC）
+  -V, --version-sort           按版本号排序（1.2 < 1.10）
+  -r, --reverse                反向排序
+
+其他选项:
+  -k, --key <POS1[,POS2]>      排序键，可以多次使用，依次比较
+                               POS 为 F[.C][修饰符]，F 是字段，C 是字符位置（都从 1 开始）；
+                               没有 POS2 时到行尾，POS2 没有 .C 时到字段末尾；
+                               修饰符为 b d f n g h M V r，没有修饰符的键使用全局选项
+  -t, --field-separator <C>    字段分隔符（默认为空白，字段包含前面的空白）
+  -u, --unique                 键相等的行只输出第一行
+  -s, --stable                 稳定排序：键相等的行保持输入顺序
+  -c, --check                  检查输入是否已排序，报告第一处无序的行
+  -C, --check-quiet            同 -c，但不输出
+  -m, --merge                  合并已排序的文件（不重新排序）
+  -o, --output <file>          输出到文件，可以是输入文件之一
+  -S, --buffer-size <SIZE>     内存缓冲区大小，如 100M、1G、50%（默认 256M，没有单位时为 K）
+  -T, --temporary-directory <DIR>  临时文件目录
+  --parallel <N>               并发排序的数量（默认为 CPU 核数，最多 8）
 
 示例:
-  sort file.txt                    # 排序文件
-  sort -r file.txt                 # 反向排序
-  sort -n numbers.txt              # 数字排序
-  sort -u file.txt                 # 去重排序
-  sort -k 2 file.txt               # 按第2个字段排序
-  sort -t: -k 3 /etc/passwd        # 使用:分隔，按第3字段排序
-  ls | sort                        # 对ls输出排序
-  cat file1.txt file2.txt | sort   # 合并排序`
+  sort file.txt                        # 排序文件
+  sort -r file.txt                     # 反向排序
+  sort -n numbers.txt                  # 数字排序
+  sort -u file.txt                     # 去重排序
+  sort -k 2,2 file.txt                 # 按第 2 个字段排序
+  sort -t: -k3,3n /etc/passwd          # 使用 : 分隔，按第 3 字段的数值排序
+  sort -k1,1 -k2,2nr data.txt          # 先按第 1 字段，再按第 2 字段数值倒序
+  du -sh * | sort -h                   # 按文件大小排序
+  sort -V versions.txt                 # 按版本号排序
+  sort -S 1G -T /data/tmp big.log      # 排序大文件
+  sort -m a.sorted b.sorted            # 合并已排序的文件
+  sort -o file.txt file.txt            # 原地排序
+  sort -c file.txt                     # 检查是否已排序
+
+退出码:
+  0 成功，1 -c/-C 发现无序，其他错误时非零`
 }
 
 func (c *SortCommand) ShortHelp() string {
 	return "排序文本行"
 }
-
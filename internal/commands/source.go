@@ -2,10 +2,14 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
+	"github.com/Lingbou/Lish/internal/debugger"
+	"github.com/Lingbou/Lish/internal/lint"
 	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
 	flag "github.com/spf13/pflag"
 )
 
@@ -26,9 +30,13 @@ func (c *SourceCommand) Name() string {
 }
 
 func (c *SourceCommand) Execute(ctx context.Context, args []string) error {
+	executor := script.ExecutorFrom(ctx, c.executor)
+	stdout := streams.Stdout(ctx)
+
 	flags := flag.NewFlagSet("source", flag.ContinueOnError)
 	verbose := flags.BoolP("verbose", "v", false, "详细模式")
-	_ = flags.BoolP("debug", "x", false, "调试模式") // 保留用于未来实现
+	debug := flags.BoolP("debug", "x", false, "调试模式")
+	check := flags.BoolP("check", "n", false, "只检查脚本，不执行")
 
 	if err := flags.Parse(args); err != nil {
 		return err
@@ -36,36 +44,57 @@ func (c *SourceCommand) Execute(ctx context.Context, args []string) error {
 
 	remaining := flags.Args()
 	if len(remaining) < 1 {
-		return fmt.Errorf("用法: source [-v] [-x] <script>")
+		return fmt.Errorf("用法: source [-v] [-x] [-n] <script>")
 	}
 
-	scriptFile := remaining[0]
+	// 在当前目录和 LISH_PATH 中查找脚本
+	scriptFile, err := executor.FindScript(remaining[0])
+	if err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
 	scriptArgs := remaining[1:]
 
-	// 检查文件是否存在
-	if _, err := os.Stat(scriptFile); os.IsNotExist(err) {
-		return fmt.Errorf("脚本文件不存在: %s", scriptFile)
+	// 只做静态检查
+	if *check {
+		diags, err := lint.CheckFile(scriptFile, lint.EnvFor(executor))
+		if err != nil {
+			return fmt.Errorf("source: %w", err)
+		}
+		lint.WriteText(stdout, diags)
+		if len(diags) > 0 {
+			return fmt.Errorf("source: %s: 发现 %d 个问题", scriptFile, len(diags))
+		}
+		return nil
 	}
 
 	// 显示调试信息
 	if *verbose {
-		fmt.Printf("执行脚本: %s\n", scriptFile)
+		fmt.Fprintf(stdout, "执行脚本: %s\n", scriptFile)
 		if len(scriptArgs) > 0 {
-			fmt.Printf("参数: %v\n", scriptArgs)
+			fmt.Fprintf(stdout, "参数: %v\n", scriptArgs)
 		}
 	}
 
+	// 调试模式：在第一条语句暂停并进入调试器
+	if *debug {
+		fmt.Fprintf(stdout, "调试脚本: %s（输入 help 查看调试命令）\n", scriptFile)
+		saved := executor.Tracer()
+		executor.SetTracer(debugger.New(scriptFile, streams.Stdin(ctx), stdout))
+		defer executor.SetTracer(saved)
+	}
+
 	// 执行脚本
-	if err := c.executor.ExecuteFile(ctx, scriptFile, scriptArgs); err != nil {
+	if err := executor.ExecuteFile(ctx, scriptFile, scriptArgs); err != nil {
+		if errors.Is(err, debugger.ErrQuit) {
+			return debugger.ErrQuit
+		}
 		return fmt.Errorf("脚本执行失败: %w", err)
 	}
 
 	if *verbose {
-		fmt.Printf("✓ 脚本执行完成\n")
+		fmt.Fprintf(stdout, "✓ 脚本执行完成\n")
 	}
 
-	// debug 模式在执行器内部处理
-
 	return nil
 }
 
@@ -73,30 +102,41 @@ func (c *SourceCommand) Help() string {
 	return `source - 在当前环境执行脚本
 
 用法:
-  source [-v] [-x] <script> [args...]
+  source [-v] [-x] [-n] <script> [args...]
   . <script> [args...]
 
 说明:
   在当前 shell 环境中执行 .lish 脚本文件。
   脚本中定义的变量和函数会在当前环境中保留。
+  脚本名不含路径时，依次在当前目录和 LISH_PATH 中的目录查找，
+  没有扩展名时同时尝试 .lish 扩展名。LISH_PATH 默认为 ~/.lish/lib。
 
 选项:
   -v, --verbose    详细模式，显示执行过程
-  -x, --debug      调试模式，显示每条命令
+  -x, --debug      在调试器中执行：断点、单步、查看变量和调用栈
+  -n, --check      只检查脚本（语法、未定义变量等），不执行
 
 示例:
   source script.lish              # 执行脚本
   source script.lish arg1 arg2    # 带参数执行
   . ~/.lishrc.lish                # 使用别名执行
   source -v script.lish           # 详细模式
+  source --check script.lish      # 检查脚本而不执行
+  source --debug script.lish      # 在调试器中执行（输入 help 查看调试命令）
+  source utils                    # 在 LISH_PATH 中查找 utils.lish
 
 脚本语法支持:
   - 变量: name=value, $name
+  - 局部变量: local name=value（仅在函数中）
   - 条件: if [ condition ]; then ... fi
   - 循环: for item in list; do ... done
   - 循环: while [ condition ]; do ... done
-  - 函数: function name() { ... }
-  - 控制: break, continue, return`
+  - 函数: function name() { ... } 或 name() { ... }
+  - 参数: $1 ... $N, $#, "$@", "$*", shift [n]
+  - 控制: break, continue, return [n]
+  - 模块: import name [as ns]（每个会话只加载一次，函数可加 ns. 前缀）
+  - 命令替换: $(command)
+  - 管道和重定向: cmd1 | cmd2, > >> < 2> 2>> 2>&1`
 }
 
 func (c *SourceCommand) ShortHelp() string {
@@ -120,6 +160,8 @@ func (c *ExecCommand) Name() string {
 }
 
 func (c *ExecCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := flag.NewFlagSet("exec", flag.ContinueOnError)
 	verbose := flags.BoolP("verbose", "v", false, "详细模式")
 
@@ -142,9 +184,9 @@ func (c *ExecCommand) Execute(ctx context.Context, args []string) error {
 
 	// 显示调试信息
 	if *verbose {
-		fmt.Printf("在新环境中执行脚本: %s\n", scriptFile)
+		fmt.Fprintf(stdout, "在新环境中执行脚本: %s\n", scriptFile)
 		if len(scriptArgs) > 0 {
-			fmt.Printf("参数: %v\n", scriptArgs)
+			fmt.Fprintf(stdout, "参数: %v\n", scriptArgs)
 		}
 	}
 
@@ -157,7 +199,7 @@ func (c *ExecCommand) Execute(ctx context.Context, args []string) error {
 	}
 
 	if *verbose {
-		fmt.Printf("✓ 脚本执行完成（退出码: %d）\n", executor.LastExitCode())
+		fmt.Fprintf(stdout, "✓ 脚本执行完成（退出码: %d）\n", executor.LastExitCode())
 	}
 
 	return nil
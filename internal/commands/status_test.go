@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+)
+
+// registryExecutor 用注册表中的内置命令执行脚本中的命令
+type registryExecutor struct {
+	registry *Registry
+}
+
+func (x registryExecutor) ExecuteCommand(ctx context.Context, command string, args []string) error {
+	cmd, ok := x.registry.Get(command)
+	if !ok {
+		return fmt.Errorf("%w: %s", script.ErrUnknownCommand, command)
+	}
+	return cmd.Execute(ctx, args)
+}
+
+// runScript 用给定的内置命令执行脚本，返回标准输出和退出码
+func runScript(t *testing.T, source string, cmds ...Command) (string, int) {
+	t.Helper()
+	registry := NewRegistry()
+	for _, cmd := range append(cmds, NewEchoCommand()) {
+		if err := registry.Register(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	ctx := streams.With(context.Background(), &streams.Streams{
+		Stdin:  strings.NewReader(""),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	e := script.NewExecutor(registryExecutor{registry})
+	err := e.ExecuteSource(ctx, "test", source, nil)
+	if err != nil && !script.IsSilent(err) {
+		t.Fatalf("执行出错: %v", err)
+	}
+	if err != nil {
+		return stdout.String(), script.ExitCodeOf(err)
+	}
+	return stdout.String(), e.LastExitCode()
+}
+
+// statusTest 命令的退出码以及失败后脚本继续执行
+type statusTest struct {
+	name   string
+	source string
+	want   string
+	code   int
+}
+
+func runStatusTests(t *testing.T, tests []statusTest, cmds ...Command) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, code := runScript(t, tt.source, cmds...)
+			if got != tt.want || code != tt.code {
+				t.Errorf("got %q (exit %d), want %q (exit %d)", got, code, tt.want, tt.code)
+			}
+		})
+	}
+}
+
+func TestGrepStatus(t *testing.T) {
+	runStatusTests(t, []statusTest{
+		{"match", "echo abc | grep -q b\necho $?", "0\n", 0},
+		{"no match", "echo abc | grep -q x\necho $?", "1\n", 0},
+		{"no match in a loop", "for i in a b; do\necho $i | grep a\ndone\necho done", "a\ndone\n", 0},
+		{"no match as condition", "if echo abc | grep -q x; then echo yes; else echo no; fi", "no\n", 0},
+		{"missing file", "grep a /nonexistent/file\necho $?", "2\n", 0},
+	}, NewGrepCommand(nil))
+}
+
+func TestSortCheckStatus(t *testing.T) {
+	runStatusTests(t, []statusTest{
+		{"sorted", "echo -e 'a\\nb' | sort -c\necho $?", "0\n", 0},
+		{"unsorted", "echo -e 'b\\na' | sort -c\necho $?", "1\n", 0},
+		{"unsorted quiet in a loop", "for i in 1 2; do\necho -e 'b\\na' | sort -C\necho $i\ndone", "1\n2\n", 0},
+	}, &SortCommand{})
+}
+
+func TestDiffStatus(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"a": "1\n2\n", "b": "1\n3\n", "c": "1\n2\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runStatusTests(t, []statusTest{
+		{"same", "diff -q " + dir + "/a " + dir + "/c\necho $?", "0\n", 0},
+		{"different", "diff -q " + dir + "/a " + dir + "/b > /dev/null\necho $?", "1\n", 0},
+		{"different in a loop", "for f in b c; do\ndiff -q " + dir + "/a " + dir + "/$f > /dev/null\necho $f $?\ndone", "b 1\nc 0\n", 0},
+		{"missing file", "diff " + dir + "/a " + dir + "/none\necho $?", "2\n", 0},
+	}, NewDiffCommand())
+}
+
+func TestGetoptsEndOfOptions(t *testing.T) {
+	registry := NewRegistry()
+	for _, cmd := range []Command{NewGetoptsCommand(nil), NewEchoCommand()} {
+		if err := registry.Register(cmd); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	ctx := streams.With(context.Background(), &streams.Streams{Stdin: strings.NewReader(""), Stdout: &stdout, Stderr: &stderr})
+	source := "function f() {\nwhile getopts ab: opt; do echo $opt $OPTARG; done\necho end\n}\nf -a -b x y"
+	if err := script.NewExecutor(registryExecutor{registry}).ExecuteSource(ctx, "test", source, nil); err != nil {
+		t.Fatalf("执行出错: %v", err)
+	}
+	if want := "a\nb x\nend\n"; stdout.String() != want || stderr.Len() > 0 {
+		t.Errorf("got %q, stderr %q, want %q and no error output", stdout.String(), stderr.String(), want)
+	}
+}
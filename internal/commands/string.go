@@ -0,0 +1,772 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// StringCommand string 命令 - 不启动子进程的字符串处理
+//
+// 所有子命令都处理一组字符串：子命令自己的参数（分隔符、模式等）之后
+// 还有参数时处理这些参数，否则逐行读取标准输入。每个结果单独输出一行。
+type StringCommand struct{}
+
+// NewStringCommand 创建 string 命令
+func NewStringCommand() *StringCommand {
+	return &StringCommand{}
+}
+
+func (c *StringCommand) Name() string {
+	return "string"
+}
+
+func (c *StringCommand) Execute(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("用法: string 子命令 [选项] [字符串...]，输入 'help string' 查看子命令")
+	}
+
+	sub, args := args[0], args[1:]
+	switch sub {
+	case "length":
+		return c.length(ctx, args)
+	case "sub":
+		return c.sub(ctx, args)
+	case "split":
+		return c.split(ctx, args)
+	case "join":
+		return c.join(ctx, args)
+	case "trim":
+		return c.trim(ctx, args)
+	case "upper":
+		return c.mapItems(ctx, "upper", args, strings.ToUpper)
+	case "lower":
+		return c.mapItems(ctx, "lower", args, strings.ToLower)
+	case "replace":
+		return c.replace(ctx, args)
+	case "match":
+		return c.match(ctx, args)
+	case "repeat":
+		return c.repeat(ctx, args)
+	case "pad":
+		return c.pad(ctx, args)
+	case "escape":
+		return c.escape(ctx, args)
+	case "collect":
+		return c.collect(ctx, args)
+	default:
+		return fmt.Errorf("string: 未知子命令: %s", sub)
+	}
+}
+
+// newStringFlags 创建子命令的选项集，选项必须写在其他参数之前
+func newStringFlags(sub string) *flag.FlagSet {
+	flags := flag.NewFlagSet("string "+sub, flag.ContinueOnError)
+	flags.SetInterspersed(false)
+	return flags
+}
+
+// eachItem 依次处理参数，没有参数时逐行处理标准输入
+func eachItem(ctx context.Context, items []string, fn func(string) error) error {
+	if len(items) > 0 {
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(streams.Stdin(ctx))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if err := fn(scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// collectItems 返回所有要处理的字符串
+func collectItems(ctx context.Context, items []string) ([]string, error) {
+	var result []string
+	err := eachItem(ctx, items, func(item string) error {
+		result = append(result, item)
+		return nil
+	})
+	return result, err
+}
+
+// mapItems 对每个字符串做同样的转换
+func (c *StringCommand) mapItems(ctx context.Context, sub string, args []string, fn func(string) string) error {
+	flags := newStringFlags(sub)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	stdout := streams.Stdout(ctx)
+	return eachItem(ctx, flags.Args(), func(item string) error {
+		_, err := fmt.Fprintln(stdout, fn(item))
+		return err
+	})
+}
+
+// length 输出每个字符串的字符数
+func (c *StringCommand) length(ctx context.Context, args []string) error {
+	flags := newStringFlags("length")
+	quiet := flags.BoolP("quiet", "q", false, "不输出，只用退出码表示是否有非空字符串")
+	visible := flags.BoolP("visible", "V", false, "输出显示宽度（中文等宽字符计为 2）")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	stdout := streams.Stdout(ctx)
+	nonEmpty := false
+	err := eachItem(ctx, flags.Args(), func(item string) error {
+		if item != "" {
+			nonEmpty = true
+		}
+		if *quiet {
+			return nil
+		}
+		n := utf8.RuneCountInString(item)
+		if *visible {
+			n = displayWidth(item)
+		}
+		_, err := fmt.Fprintln(stdout, n)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if *quiet && !nonEmpty {
+		return fmt.Errorf("string length: 字符串为空")
+	}
+	return nil
+}
+
+// sub 截取子串，位置按字符计算，从 1 开始，负数从末尾倒数
+func (c *StringCommand) sub(ctx context.Context, args []string) error {
+	flags := newStringFlags("sub")
+	start := flags.IntP("start", "s", 1, "起始位置")
+	end := flags.IntP("end", "e", 0, "结束位置（包含）")
+	length := flags.IntP("length", "l", -1, "长度")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *start == 0 {
+		return fmt.Errorf("string sub: 起始位置不能为 0")
+	}
+	if *length < -1 {
+		return fmt.Errorf("string sub: 长度不能为负数")
+	}
+	if flags.Changed("end") && flags.Changed("length") {
+		return fmt.Errorf("string sub: --end 和 --length 不能同时使用")
+	}
+
+	stdout := streams.Stdout(ctx)
+	return eachItem(ctx, flags.Args(), func(item string) error {
+		runes := []rune(item)
+		n := len(runes)
+
+		from := *start - 1
+		if *start < 0 {
+			from = n + *start
+		}
+		from = clamp(from, 0, n)
+
+		to := n
+		switch {
+		case *length >= 0:
+			to = from + *length
+		case flags.Changed("end") && *end >= 0:
+			to = *end
+		case flags.Changed("end"):
+			to = n + *end + 1
+		}
+		to = clamp(to, from, n)
+
+		_, err := fmt.Fprintln(stdout, string(runes[from:to]))
+		return err
+	})
+}
+
+// clamp 把 n 限制在 [lo, hi] 范围内
+func clamp(n, lo, hi int) int {
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
+
+// split 按分隔符拆分字符串，每个字段一行
+func (c *StringCommand) split(ctx context.Context, args []string) error {
+	flags := newStringFlags("split")
+	maxSplits := flags.IntP("max", "m", -1, "最多拆分的次数")
+	right := flags.BoolP("right", "r", false, "配合 -m 从右边开始拆分")
+	noEmpty := flags.BoolP("no-empty", "n", false, "不输出空字段")
+	fieldList := flags.StringP("fields", "f", "", "只输出指定的字段（如 1,3 或 2-4）")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 {
+		return fmt.Errorf("用法: string split [-m 次数] [-r] [-n] [-f 字段] 分隔符 [字符串...]")
+	}
+
+	var fields []int
+	if *fieldList != "" {
+		var err error
+		if fields, err = parseFieldList(*fieldList); err != nil {
+			return fmt.Errorf("string split: %w", err)
+		}
+	}
+
+	sep := flags.Arg(0)
+	stdout := streams.Stdout(ctx)
+	return eachItem(ctx, flags.Args()[1:], func(item string) error {
+		parts := splitString(item, sep, *maxSplits, *right)
+		if fields != nil {
+			selected := make([]string, 0, len(fields))
+			for _, f := range fields {
+				if f <= len(parts) {
+					selected = append(selected, parts[f-1])
+				}
+			}
+			parts = selected
+		}
+		for _, part := range parts {
+			if *noEmpty && part == "" {
+				continue
+			}
+			if _, err := fmt.Fprintln(stdout, part); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// splitString 拆分字符串，sep 为空时拆成单个字符，max 为负数时不限制次数
+func splitString(s, sep string, max int, right bool) []string {
+	if sep == "" {
+		var parts []string
+		for _, r := range s {
+			parts = append(parts, string(r))
+		}
+		if max >= 0 && len(parts) > max+1 {
+			if right {
+				head := strings.Join(parts[:len(parts)-max], "")
+				return append([]string{head}, parts[len(parts)-max:]...)
+			}
+			return append(parts[:max], strings.Join(parts[max:], ""))
+		}
+		return parts
+	}
+
+	if max < 0 {
+		return strings.Split(s, sep)
+	}
+	if !right {
+		return strings.SplitN(s, sep, max+1)
+	}
+
+	var parts []string
+	for len(parts) < max {
+		idx := strings.LastIndex(s, sep)
+		if idx < 0 {
+			break
+		}
+		parts = append([]string{s[idx+len(sep):]}, parts...)
+		s = s[:idx]
+	}
+	return append([]string{s}, parts...)
+}
+
+// parseFieldList 解析字段列表（如 1,3,5-7），字段从 1 开始
+func parseFieldList(spec string) ([]int, error) {
+	var fields []int
+	for _, part := range strings.Split(spec, ",") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(lo)
+		if err != nil || from < 1 {
+			return nil, fmt.Errorf("无效的字段: %s", part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(hi); err != nil || to < from {
+				return nil, fmt.Errorf("无效的字段范围: %s", part)
+			}
+		}
+		for f := from; f <= to; f++ {
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+// join 用分隔符连接所有字符串
+func (c *StringCommand) join(ctx context.Context, args []string) error {
+	flags := newStringFlags("join")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 {
+		return fmt.Errorf("用法: string join 分隔符 [字符串...]")
+	}
+
+	items, err := collectItems(ctx, flags.Args()[1:])
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(streams.Stdout(ctx), strings.Join(items, flags.Arg(0)))
+	return err
+}
+
+// trim 去除首尾的空白或指定字符
+func (c *StringCommand) trim(ctx context.Context, args []string) error {
+	flags := newStringFlags("trim")
+	left := flags.BoolP("left", "l", false, "只去除开头")
+	right := flags.BoolP("right", "r", false, "只去除末尾")
+	chars := flags.StringP("chars", "c", " \t\n\r\v\f", "要去除的字符")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !*left && !*right {
+		*left, *right = true, true
+	}
+
+	stdout := streams.Stdout(ctx)
+	return eachItem(ctx, flags.Args(), func(item string) error {
+		if *left {
+			item = strings.TrimLeft(item, *chars)
+		}
+		if *right {
+			item = strings.TrimRight(item, *chars)
+		}
+		_, err := fmt.Fprintln(stdout, item)
+		return err
+	})
+}
+
+// replace 替换字符串中的内容
+func (c *StringCommand) replace(ctx context.Context, args []string) error {
+	flags := newStringFlags("replace")
+	useRegex := flags.BoolP("regex", "r", false, "模式是正则表达式，替换文本中可以用 $1、${name} 引用分组")
+	all := flags.BoolP("all", "a", false, "替换所有匹配，默认只替换第一个")
+	ignoreCase := flags.BoolP("ignore-case", "i", false, "忽略大小写")
+	filter := flags.BoolP("filter", "f", false, "只输出发生了替换的字符串")
+	quiet := flags.BoolP("quiet", "q", false, "不输出，只用退出码表示是否发生了替换")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		return fmt.Errorf("用法: string replace [-r] [-a] [-i] [-f] [-q] 模式 替换文本 [字符串...]")
+	}
+
+	re, err := compileStringPattern(flags.Arg(0), *useRegex, *ignoreCase)
+	if err != nil {
+		return fmt.Errorf("string replace: %w", err)
+	}
+	replacement := flags.Arg(1)
+	if !*useRegex {
+		// 字面替换时 $ 没有特殊含义
+		replacement = strings.ReplaceAll(replacement, "$", "$$")
+	}
+
+	stdout := streams.Stdout(ctx)
+	replaced := false
+	err = eachItem(ctx, flags.Args()[2:], func(item string) error {
+		result, changed := replaceString(re, item, replacement, *all)
+		if changed {
+			replaced = true
+		}
+		if *quiet || (*filter && !changed) {
+			return nil
+		}
+		_, err := fmt.Fprintln(stdout, result)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if *quiet && !replaced {
+		return fmt.Errorf("string replace: 没有发生替换")
+	}
+	return nil
+}
+
+// replaceString 替换第一个或所有匹配，返回结果和是否发生了替换
+func replaceString(re *regexp.Regexp, s, replacement string, all bool) (string, bool) {
+	if all {
+		if !re.MatchString(s) {
+			return s, false
+		}
+		return re.ReplaceAllString(s, replacement), true
+	}
+
+	loc := re.FindStringSubmatchIndex(s)
+	if loc == nil {
+		return s, false
+	}
+	var buf []byte
+	buf = append(buf, s[:loc[0]]...)
+	buf = re.ExpandString(buf, replacement, s, loc)
+	buf = append(buf, s[loc[1]:]...)
+	return string(buf), true
+}
+
+// compileStringPattern 编译字面文本或正则表达式
+func compileStringPattern(pattern string, useRegex, ignoreCase bool) (*regexp.Regexp, error) {
+	if !useRegex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("无效的正则表达式: %w", err)
+	}
+	return re, nil
+}
+
+// globToRegexp 把通配符模式（*、?、[...]）转换为匹配整个字符串的正则表达式
+func globToRegexp(glob string) string {
+	var buf strings.Builder
+	buf.WriteString("^")
+	runes := []rune(glob)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			buf.WriteString("(?s:.*)")
+		case '?':
+			buf.WriteString("(?s:.)")
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				buf.WriteString(regexp.QuoteMeta(string(runes[i])))
+			} else {
+				buf.WriteString(`\\`)
+			}
+		case '[':
+			end := i + 1
+			if end < len(runes) && (runes[end] == '!' || runes[end] == '^') {
+				end++
+			}
+			if end < len(runes) && runes[end] == ']' {
+				end++
+			}
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end >= len(runes) {
+				buf.WriteString(`\[`)
+				continue
+			}
+			class := string(runes[i+1 : end])
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			buf.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i = end
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	buf.WriteString("$")
+	return buf.String()
+}
+
+// match 输出匹配模式的字符串；正则模式下输出匹配的部分和各个捕获分组
+func (c *StringCommand) match(ctx context.Context, args []string) error {
+	flags := newStringFlags("match")
+	useRegex := flags.BoolP("regex", "r", false, "模式是正则表达式（默认是通配符，需要匹配整个字符串）")
+	all := flags.BoolP("all", "a", false, "输出每个字符串中的所有匹配")
+	ignoreCase := flags.BoolP("ignore-case", "i", false, "忽略大小写")
+	invert := flags.BoolP("invert", "v", false, "输出不匹配的字符串")
+	quiet := flags.BoolP("quiet", "q", false, "不输出，只用退出码表示是否匹配")
+	entire := flags.BoolP("entire", "e", false, "输出整个字符串而不是匹配的部分")
+	groupsOnly := flags.BoolP("groups-only", "g", false, "只输出捕获分组")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 {
+		return fmt.Errorf("用法: string match [-r] [-a] [-i] [-v] [-q] [-e] [-g] 模式 [字符串...]")
+	}
+
+	pattern := flags.Arg(0)
+	if !*useRegex {
+		pattern = globToRegexp(pattern)
+	}
+	re, err := compileStringPattern(pattern, true, *ignoreCase)
+	if err != nil {
+		return fmt.Errorf("string match: %w", err)
+	}
+
+	stdout := streams.Stdout(ctx)
+	matched := false
+	err = eachItem(ctx, flags.Args()[1:], func(item string) error {
+		n := 1
+		if *all {
+			n = -1
+		}
+		matches := re.FindAllStringSubmatch(item, n)
+		if (len(matches) > 0) == *invert {
+			return nil
+		}
+		matched = true
+		if *quiet {
+			return nil
+		}
+
+		// 通配符、-v 和 -e 输出整个字符串
+		if !*useRegex || *invert || *entire {
+			_, err := fmt.Fprintln(stdout, item)
+			return err
+		}
+		for _, m := range matches {
+			if *groupsOnly {
+				m = m[1:]
+			}
+			for _, s := range m {
+				if _, err := fmt.Fprintln(stdout, s); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return fmt.Errorf("string match: 没有匹配")
+	}
+	return nil
+}
+
+// repeat 重复字符串
+func (c *StringCommand) repeat(ctx context.Context, args []string) error {
+	flags := newStringFlags("repeat")
+	count := flags.IntP("count", "n", 1, "重复次数")
+	maxLen := flags.IntP("max", "m", 0, "结果最多包含的字符数，0 表示不限制")
+	noNewline := flags.BoolP("no-newline", "N", false, "不输出换行")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *count < 0 || *maxLen < 0 {
+		return fmt.Errorf("string repeat: 次数和长度不能为负数")
+	}
+
+	stdout := streams.Stdout(ctx)
+	return eachItem(ctx, flags.Args(), func(item string) error {
+		n := *count
+		if *maxLen > 0 && !flags.Changed("count") {
+			n = *maxLen // 只给出 -m 时重复到满足长度为止
+		}
+		result := strings.Repeat(item, n)
+		if *maxLen > 0 {
+			if runes := []rune(result); len(runes) > *maxLen {
+				result = string(runes[:*maxLen])
+			}
+		}
+		if !*noNewline {
+			result += "\n"
+		}
+		_, err := io.WriteString(stdout, result)
+		return err
+	})
+}
+
+// pad 用字符填充字符串到指定的显示宽度
+func (c *StringCommand) pad(ctx context.Context, args []string) error {
+	flags := newStringFlags("pad")
+	width := flags.IntP("width", "w", 0, "目标宽度，默认为最宽的字符串的宽度")
+	char := flags.StringP("char", "c", " ", "填充字符")
+	right := flags.BoolP("right", "r", false, "在右边填充（左对齐），默认在左边填充")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if utf8.RuneCountInString(*char) != 1 || displayWidth(*char) != 1 {
+		return fmt.Errorf("string pad: 填充字符必须是一个单宽度字符")
+	}
+
+	// 需要先知道最大宽度，因此读取全部输入
+	items, err := collectItems(ctx, flags.Args())
+	if err != nil {
+		return err
+	}
+	target := *width
+	for _, item := range items {
+		if w := displayWidth(item); w > target {
+			target = w
+		}
+	}
+
+	stdout := streams.Stdout(ctx)
+	for _, item := range items {
+		fill := strings.Repeat(*char, target-displayWidth(item))
+		if *right {
+			item += fill
+		} else {
+			item = fill + item
+		}
+		if _, err := fmt.Fprintln(stdout, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escape 按指定风格转义字符串
+func (c *StringCommand) escape(ctx context.Context, args []string) error {
+	flags := newStringFlags("escape")
+	style := flags.String("style", "script", "转义风格: script、url、regex 或 json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var fn func(string) string
+	switch *style {
+	case "script":
+		fn = shellQuote
+	case "url":
+		fn = url.PathEscape
+	case "regex":
+		fn = regexp.QuoteMeta
+	case "json":
+		fn = jsonString
+	default:
+		return fmt.Errorf("string escape: 未知的转义风格: %s", *style)
+	}
+
+	stdout := streams.Stdout(ctx)
+	return eachItem(ctx, flags.Args(), func(item string) error {
+		_, err := fmt.Fprintln(stdout, fn(item))
+		return err
+	})
+}
+
+// collect 把所有输入合并为一个字符串输出，默认去掉末尾的换行
+func (c *StringCommand) collect(ctx context.Context, args []string) error {
+	flags := newStringFlags("collect")
+	keep := flags.BoolP("no-trim-newlines", "N", false, "保留末尾的换行")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var text string
+	if flags.NArg() > 0 {
+		text = strings.Join(flags.Args(), "\n")
+	} else {
+		data, err := io.ReadAll(streams.Stdin(ctx))
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	}
+	if !*keep {
+		text = strings.TrimRight(text, "\n")
+	}
+
+	_, err := io.WriteString(streams.Stdout(ctx), text)
+	return err
+}
+
+// displayWidth 返回字符串在终端中的显示宽度：中日韩等宽字符计为 2，
+// 组合字符和格式控制字符计为 0
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth 返回单个字符的显示宽度
+func runeWidth(r rune) int {
+	switch {
+	case r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r):
+		return 0
+	case r >= 0x1100 && r <= 0x115F, // 谚文字母
+		r >= 0x2E80 && r <= 0xA4CF && r != 0x303F, // 中日韩部首、假名、汉字
+		r >= 0xAC00 && r <= 0xD7A3,                // 谚文音节
+		r >= 0xF900 && r <= 0xFAFF,                // 兼容汉字
+		r >= 0xFE30 && r <= 0xFE4F,                // 竖排标点
+		r >= 0xFF00 && r <= 0xFF60,                // 全角字符
+		r >= 0xFFE0 && r <= 0xFFE6,
+		r >= 0x1F300 && r <= 0x1F64F, // 表情符号
+		r >= 0x1F900 && r <= 0x1F9FF,
+		r >= 0x20000 && r <= 0x3FFFD:
+		return 2
+	}
+	return 1
+}
+
+func (c *StringCommand) Help() string {
+	return `string - 字符串处理
+
+用法:
+  string 子命令 [选项] [参数...] [字符串...]
+
+说明:
+  在 Shell 内部完成常见的字符串处理，不启动 sed/awk 子进程。
+  子命令自己的参数（分隔符、模式等）之后还有参数时处理这些参数，
+  否则逐行读取标准输入。每个结果单独输出一行。选项必须写在
+  其他参数之前，字符串以 - 开头时用 -- 分隔。
+
+子命令:
+  length [-q] [-V]                  输出字符数（-V 输出显示宽度）
+  sub [-s 起点] [-l 长度|-e 终点]   截取子串，位置从 1 开始，负数从末尾倒数
+  split [-m 次数] [-r] [-n] [-f 字段] 分隔符
+                                    拆分字符串，每个字段一行；分隔符为空时
+                                    拆成单个字符，-r 配合 -m 从右边拆分
+  join 分隔符                       用分隔符连接所有字符串
+  trim [-l] [-r] [-c 字符]          去除首尾空白或指定字符
+  upper, lower                      转换为大写或小写
+  replace [-r] [-a] [-i] [-f] [-q] 模式 替换文本
+                                    替换第一个（-a 所有）匹配；-r 使用正则，
+                                    替换文本中用 $1、${name} 引用分组；
+                                    -f 只输出发生了替换的字符串
+  match [-r] [-a] [-i] [-v] [-q] [-e] [-g] 模式
+                                    默认按通配符（* ? [...]）匹配整个字符串并
+                                    输出匹配的字符串；-r 按正则匹配，输出匹配
+                                    的部分和各个捕获分组（-g 只输出分组）。
+                                    没有任何匹配时返回 1
+  repeat [-n 次数] [-m 长度] [-N]   重复字符串
+  pad [-w 宽度] [-c 字符] [-r]      在左边（-r 右边）填充到相同的显示宽度
+  escape [--style 风格]             转义: script（默认，Shell 引号）、url、
+                                    regex 或 json
+  collect [-N]                      把所有输入合并为一个字符串，去掉末尾换行
+
+  length、replace 的 -q 不输出结果，只用退出码表示是否有非空字符串或
+  是否发生了替换，适合写在 if 条件中。
+
+示例:
+  string length "你好"                     # 2
+  string sub -s 2 -l 3 abcdef              # bcd
+  echo "a,b,c" | string split ,            # 输出 a b c 各一行
+  string join / usr local bin              # usr/local/bin
+  string replace -r '(\w+)@(\w+)' '$2 的 $1' user@host
+  string match -r 'v(\d+)\.(\d+)' v1.25    # v1.25 1 25 各一行
+  if string match -q '*.go' $file; then echo go; fi
+  ls | string pad -r -w 20
+  string escape --style url "a b&c"        # a%20b&c`
+}
+
+func (c *StringCommand) ShortHelp() string {
+	return "字符串处理"
+}
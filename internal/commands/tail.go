@@ -3,154 +3,438 @@ package commands
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type TailCommand struct {
-	stdout *os.File
-}
+type TailCommand struct{}
 
-func NewTailCommand(stdout *os.File) *TailCommand {
-	return &TailCommand{stdout: stdout}
+func NewTailCommand() *TailCommand {
+	return &TailCommand{}
 }
 
 func (c *TailCommand) Name() string {
 	return "tail"
 }
 
+// tailer 保存一次 tail 调用的选项和输出状态
+type tailer struct {
+	useBytes  bool  // 按字节而不是按行计数
+	count     int64 // 行数或字节数
+	fromStart bool  // +N：从第 N 行（字节）开始输出
+	headers   bool
+	out       *bufio.Writer
+	stderr    io.Writer
+	last      string // 最近一次输出标题的文件，跟随时据此决定是否输出新标题
+}
+
+// followed 一个被跟随的文件
+type followed struct {
+	name    string
+	file    *os.File // 文件不存在（-F 等待文件出现）时为 nil
+	missing bool     // 已经报告过文件不可访问
+}
+
 func (c *TailCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
 	flags := pflag.NewFlagSet("tail", pflag.ContinueOnError)
-	lines := flags.IntP("lines", "n", 10, "显示的行数")
+	lines := flags.StringP("lines", "n", "10", "显示的行数")
+	byteCount := flags.StringP("bytes", "c", "", "显示的字节数")
 	follow := flags.BoolP("follow", "f", false, "实时监控文件变化")
-	
-	if err := flags.Parse(args); err != nil {
+	followName := flags.BoolP("follow-name", "F", false, "按文件名跟随，文件被替换或截断后重新打开")
+	interval := flags.Float64P("sleep-interval", "s", 0.5, "跟随时检查文件的间隔（秒）")
+	quiet := flags.BoolP("quiet", "q", false, "不显示文件名标题")
+	flags.BoolVar(quiet, "silent", false, "同 --quiet")
+	verbose := flags.BoolP("verbose", "v", false, "总是显示文件名标题")
+
+	if err := flags.Parse(expandNumericOption(flags, args, "-n")); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return nil
+		}
 		return err
 	}
-	
+	if *interval <= 0 {
+		return fmt.Errorf("tail: 无效的间隔: %v", *interval)
+	}
+
+	t := &tailer{useBytes: flags.Changed("bytes"), stderr: std.Stderr}
+	spec := *lines
+	if t.useBytes {
+		spec = *byteCount
+	}
+	n, sign, err := parseCount(spec)
+	if err != nil {
+		return fmt.Errorf("tail: %w", err)
+	}
+	t.count, t.fromStart = n, sign == '+'
+
 	files := flags.Args()
 	if len(files) == 0 {
-		return fmt.Errorf("tail: 需要指定文件")
+		files = []string{"-"}
 	}
-	
-	if *follow {
-		// 实时监控模式（只支持单个文件）
-		if len(files) > 1 {
-			return fmt.Errorf("tail -f: 只能监控一个文件")
+	t.headers = (len(files) > 1 || *verbose) && !*quiet
+
+	t.out = bufio.NewWriter(std.Stdout)
+	defer t.out.Flush()
+
+	var follows []*followed
+	failed := false
+	for _, name := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
-		return c.followFile(ctx, files[0], *lines)
-	}
-	
-	// 普通模式
-	for i, filename := range files {
-		if i > 0 {
-			fmt.Fprintln(c.stdout)
+		file, err := openInput(ctx, name)
+		if err != nil {
+			fmt.Fprintf(std.Stderr, "tail: %v\n", err)
+			failed = true
+			if *followName {
+				follows = append(follows, &followed{name: name, missing: true})
+			}
+			continue
 		}
-		
-		if len(files) > 1 {
-			fmt.Fprintf(c.stdout, "==> %s <==\n", filename)
+		t.header(name)
+		err = t.print(ctx, file)
+		if err != nil {
+			if ctx.Err() != nil {
+				file.Close()
+				return ctx.Err()
+			}
+			fmt.Fprintf(std.Stderr, "tail: %s: %v\n", inputName(name), err)
+			failed = true
 		}
-		
-		if err := c.printTail(filename, *lines); err != nil {
-			return err
+		// 标准输入（管道）已经读到结尾，不再跟随
+		if f, ok := file.(*os.File); ok && (*follow || *followName) && name != "-" {
+			follows = append(follows, &followed{name: name, file: f})
+			continue
 		}
+		file.Close()
+	}
+	if err := t.out.Flush(); err != nil {
+		return err
+	}
+
+	if len(follows) > 0 {
+		return t.follow(ctx, follows, *followName, time.Duration(*interval*float64(time.Second)))
+	}
+	if failed {
+		return script.ExitStatus(1)
 	}
-	
 	return nil
 }
 
-func (c *TailCommand) printTail(filename string, n int) error {
-	file, err := os.Open(filename)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %w", err)
-	}
-	defer file.Close()
-	
-	// 读取所有行
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-	
-	if err := scanner.Err(); err != nil {
+// header 在输出文件内容前显示标题
+func (t *tailer) header(name string) {
+	if !t.headers {
+		return
+	}
+	if t.last != "" {
+		t.out.WriteString("\n")
+	}
+	fmt.Fprintf(t.out, "==> %s <==\n", inputName(name))
+	t.last = name
+}
+
+// print 输出一个文件的尾部
+//
+// 普通文件从结尾向前查找起始位置，只读取需要输出的部分；管道等不能定位的输入
+// 逐行读取，只在内存中保留最后 N 行（或 N 个字节）。
+func (t *tailer) print(ctx context.Context, r io.Reader) error {
+	if f, ok := r.(*os.File); ok && !t.fromStart {
+		if info, err := f.Stat(); err == nil && info.Mode().IsRegular() {
+			offset := info.Size() - t.count
+			if !t.useBytes {
+				var err error
+				if offset, err = lastLinesOffset(f, info.Size(), t.count); err != nil {
+					return err
+				}
+			}
+			if _, err := f.Seek(max(offset, 0), io.SeekStart); err != nil {
+				return err
+			}
+			_, err := io.Copy(t.out, f)
+			return err
+		}
+	}
+
+	switch {
+	case t.fromStart && t.useBytes:
+		// +N 表示从第 N 个字节开始，即跳过 N-1 个字节
+		if t.count > 1 {
+			if _, err := io.CopyN(io.Discard, r, t.count-1); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+		return t.copyLive(r)
+	case t.fromStart:
+		s := newLineStream(r, t.out)
+		for i := int64(1); i < t.count; i++ {
+			if _, err := s.readLine(); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+		}
+		return t.copyLive(s.r)
+	case t.useBytes:
+		return t.lastBytes(r)
+	default:
+		return t.lastLines(ctx, r)
+	}
+}
+
+// copyLive 把剩余的输入原样复制到输出，读到的内容立即写出
+func (t *tailer) copyLive(r io.Reader) error {
+	if err := t.out.Flush(); err != nil {
 		return err
 	}
-	
-	// 打印最后 N 行
-	start := 0
-	if len(lines) > n {
-		start = len(lines) - n
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			t.out.Write(buf[:n])
+			if flushErr := t.out.Flush(); flushErr != nil {
+				return flushErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 	}
-	
-	for i := start; i < len(lines); i++ {
-		fmt.Fprintln(c.stdout, lines[i])
+}
+
+// lastLines 逐行读取输入，用环形缓冲区保留最后 N 行
+func (t *tailer) lastLines(ctx context.Context, r io.Reader) error {
+	s := newLineStream(r, t.out)
+	ring := newLineRing(int(min(t.count, 1<<30)))
+	for i := 0; ; i++ {
+		if i%1024 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line, err := s.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		ring.push(line)
+	}
+	for _, line := range ring.items() {
+		t.out.WriteString(line)
 	}
-	
 	return nil
 }
 
-func (c *TailCommand) followFile(ctx context.Context, filename string, n int) error {
-	// 先打印最后 N 行
-	if err := c.printTail(filename, n); err != nil {
-		return err
+// lastBytes 读取整个输入，只保留最后 N 个字节
+func (t *tailer) lastBytes(r io.Reader) error {
+	var pending []byte
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		pending = append(pending, buf[:n]...)
+		if extra := int64(len(pending)) - t.count; extra > int64(len(buf)) {
+			pending = append(pending[:0], pending[extra:]...)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
 	}
-	
-	// 打开文件准备监控
-	file, err := os.Open(filename)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %w", err)
-	}
-	defer file.Close()
-	
-	// 移动到文件末尾
-	file.Seek(0, 2)
-	
-	// 监控文件变化
-	scanner := bufio.NewScanner(file)
-	ticker := time.NewTicker(500 * time.Millisecond)
+	if extra := int64(len(pending)) - t.count; extra > 0 {
+		pending = pending[extra:]
+	}
+	_, err := t.out.Write(pending)
+	return err
+}
+
+// lastLinesOffset 从文件结尾向前按块查找最后 n 行的起始位置
+//
+// 文件结尾的换行符不算作新的一行，没有以换行符结尾的最后一行也算一行。
+func lastLinesOffset(f *os.File, size, n int64) (int64, error) {
+	if n == 0 {
+		return size, nil
+	}
+	buf := make([]byte, 32*1024)
+	found := int64(0)
+	for end := size; end > 0; {
+		start := max(end-int64(len(buf)), 0)
+		chunk := buf[:end-start]
+		if _, err := f.ReadAt(chunk, start); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if chunk[i] != '\n' || start+int64(i) == size-1 {
+				continue
+			}
+			found++
+			if found == n {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+// follow 定期检查文件，输出新增的内容，直到命令被中断
+//
+// byName 为 true（-F）时按文件名跟随：文件被删除后等待它重新出现，
+// 被替换（如日志轮转）后打开新文件从头输出。文件变短时认为被截断，从头开始读取。
+func (t *tailer) follow(ctx context.Context, follows []*followed, byName bool, interval time.Duration) error {
+	defer func() {
+		for _, f := range follows {
+			if f.file != nil {
+				f.file.Close()
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
-	
+	buf := make([]byte, 32*1024)
 	for {
+		for _, f := range follows {
+			if err := t.poll(f, byName, buf); err != nil {
+				return err
+			}
+		}
+		if err := t.out.Flush(); err != nil {
+			return err
+		}
+
 		select {
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			// 尝试读取新内容
-			for scanner.Scan() {
-				fmt.Fprintln(c.stdout, scanner.Text())
-			}
-			
-			if err := scanner.Err(); err != nil {
+		}
+	}
+}
+
+// poll 检查一个文件，输出新增的内容
+func (t *tailer) poll(f *followed, byName bool, buf []byte) error {
+	if f.file == nil {
+		file, err := os.Open(f.name)
+		if err != nil {
+			return nil
+		}
+		f.file = file
+		if f.missing {
+			fmt.Fprintf(t.stderr, "tail: %s 已出现，开始跟随\n", f.name)
+			f.missing = false
+		}
+	}
+
+	if err := t.drain(f, buf); err != nil {
+		return err
+	}
+	if !byName {
+		return nil
+	}
+
+	current, err := f.file.Stat()
+	if err != nil {
+		return nil
+	}
+	info, err := os.Stat(f.name)
+	switch {
+	case err != nil:
+		fmt.Fprintf(t.stderr, "tail: %s 已不可访问\n", f.name)
+		f.missing = true
+	case !os.SameFile(info, current):
+		fmt.Fprintf(t.stderr, "tail: %s 已被替换，跟随新文件\n", f.name)
+	default:
+		return nil
+	}
+	f.file.Close()
+	f.file = nil
+	if f.missing {
+		return nil
+	}
+	return t.poll(f, byName, buf)
+}
+
+// drain 输出文件当前位置之后的所有内容
+func (t *tailer) drain(f *followed, buf []byte) error {
+	if info, err := f.file.Stat(); err == nil && info.Mode().IsRegular() {
+		pos, err := f.file.Seek(0, io.SeekCurrent)
+		if err == nil && info.Size() < pos {
+			fmt.Fprintf(t.stderr, "tail: %s: 文件被截断\n", f.name)
+			if _, err := f.file.Seek(0, io.SeekStart); err != nil {
 				return err
 			}
 		}
 	}
+	for {
+		n, err := f.file.Read(buf)
+		if n > 0 {
+			if t.headers && t.last != f.name {
+				t.header(f.name)
+			}
+			t.out.Write(buf[:n])
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(t.stderr, "tail: %s: %v\n", f.name, err)
+			return nil
+		}
+	}
 }
 
 func (c *TailCommand) Help() string {
 	return `tail - 显示文件尾部内容
 
 用法:
-  tail [选项] 文件...
+  tail [选项] [文件...]
 
 选项:
-  -n, --lines   显示的行数（默认 10 行）
-  -f, --follow  实时监控文件变化（类似 tail -f）
+  -n, --lines=[+]N         显示最后 N 行（默认 10 行）；N 前有 + 时从第 N 行开始显示
+  -c, --bytes=[+]N         显示最后 N 个字节；N 前有 + 时从第 N 个字节开始显示
+  -f, --follow             输出后继续监控文件，显示新增的内容（Ctrl+C 退出）
+  -F, --follow-name        按文件名监控：文件被轮转、删除后重新出现或被截断时
+                           重新打开并继续显示
+  -s, --sleep-interval=S   监控时检查文件的间隔秒数（默认 0.5）
+  -q, --quiet              不显示文件名标题
+  -v, --verbose            总是显示文件名标题
+  -NUM                     同 -n NUM
+
+  N 可以带单位：b (512)、K/KiB (1024)、M/MiB、G/GiB、kB (1000)、MB、GB。
 
 描述:
-  显示文件的后 N 行内容。使用 -f 可以实时监控文件更新。
+  显示文件的后 N 行内容。没有文件或文件为 - 时读取标准输入。
+
+  普通文件从结尾向前定位，不需要读取整个文件；管道只在内存中保留
+  最后 N 行。使用 +N 时边读边输出。
+  监控多个文件时，输出来自不同文件的内容前会显示 "==> 文件名 <==" 标题。
 
 示例:
   tail file.txt           # 显示最后 10 行
   tail -n 20 file.txt     # 显示最后 20 行
-  tail -f log.txt         # 实时监控日志文件（Ctrl+C 退出）`
+  tail -n +2 data.csv     # 去掉第一行（表头）
+  tail -c 100 file.txt    # 显示最后 100 个字节
+  tail -f log.txt         # 实时监控日志文件（Ctrl+C 退出）
+  tail -F app.log         # 监控日志，轮转后继续跟随新文件
+  tail -f a.log b.log     # 同时监控多个文件`
 }
 
 func (c *TailCommand) ShortHelp() string {
 	return "显示文件尾部"
 }
-
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// TeeCommand tee 命令 - 把标准输入同时复制到标准输出和文件
+type TeeCommand struct{}
+
+// NewTeeCommand 创建 tee 命令
+func NewTeeCommand() *TeeCommand {
+	return &TeeCommand{}
+}
+
+func (c *TeeCommand) Name() string {
+	return "tee"
+}
+
+// teeOutput 一个输出目标，写入失败后不再写入
+type teeOutput struct {
+	name string
+	w    io.Writer
+}
+
+func (c *TeeCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
+	flags := flag.NewFlagSet("tee", flag.ContinueOnError)
+	appendMode := flags.BoolP("append", "a", false, "追加到文件而不是覆盖")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	mode := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if *appendMode {
+		mode = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	failed := false
+	outputs := []*teeOutput{{name: "标准输出", w: std.Stdout}}
+	for _, name := range flags.Args() {
+		if name == "-" {
+			outputs = append(outputs, &teeOutput{name: "标准输出", w: std.Stdout})
+			continue
+		}
+		file, err := os.OpenFile(name, mode, 0644)
+		if err != nil {
+			fmt.Fprintf(std.Stderr, "tee: %v\n", err)
+			failed = true
+			continue
+		}
+		defer file.Close()
+		outputs = append(outputs, &teeOutput{name: name, w: file})
+	}
+
+	// 读到多少写多少，不等待整行，使交互式管道中的输出立即出现
+	buf := make([]byte, 32*1024)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		n, err := std.Stdin.Read(buf)
+		if n > 0 {
+			active := 0
+			for _, out := range outputs {
+				if out.w == nil {
+					continue
+				}
+				if _, werr := out.w.Write(buf[:n]); werr != nil {
+					// 下游管道关闭时不报告错误，但仍然继续写入文件
+					if !errors.Is(werr, io.ErrClosedPipe) {
+						fmt.Fprintf(std.Stderr, "tee: %s: %v\n", out.name, werr)
+						failed = true
+					}
+					out.w = nil
+					continue
+				}
+				active++
+			}
+			if active == 0 {
+				break
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("tee: 读取输入失败: %w", err)
+		}
+	}
+
+	if failed {
+		return script.ExitStatus(1)
+	}
+	return nil
+}
+
+func (c *TeeCommand) Help() string {
+	return `tee - 把标准输入同时复制到标准输出和文件
+
+用法:
+  tee [选项] [文件...]
+
+选项:
+  -a, --append   追加到文件末尾，而不是覆盖文件
+
+描述:
+  读取标准输入，写入标准输出和每个指定的文件。输入是边读边写的，
+  不会等到输入结束。某个文件无法打开或写入时报告错误并继续写入
+  其他文件，最后以状态 1 退出。
+
+示例:
+  make 2>&1 | tee build.log            # 同时显示并保存输出
+  echo 配置 | tee -a a.conf b.conf     # 追加到两个文件
+  seq 1 10 | tee all.txt | grep 5`
+}
+
+func (c *TeeCommand) ShortHelp() string {
+	return "复制输入到标准输出和文件"
+}
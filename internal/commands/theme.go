@@ -3,8 +3,10 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"sort"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/Lingbou/Lish/internal/theme"
 )
 
@@ -23,11 +25,13 @@ func (c *ThemeCommand) Name() string {
 }
 
 func (c *ThemeCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	if len(args) == 0 {
 		// 显示当前主题
-		fmt.Printf("当前主题: %s\n", c.manager.CurrentTheme())
-		fmt.Println("\n使用 'theme list' 查看所有可用主题")
-		fmt.Println("使用 'theme set <name>' 切换主题")
+		fmt.Fprintf(stdout, "当前主题: %s\n", c.manager.CurrentTheme())
+		fmt.Fprintln(stdout, "\n使用 'theme list' 查看所有可用主题")
+		fmt.Fprintln(stdout, "使用 'theme set <name>' 切换主题")
 		return nil
 	}
 
@@ -35,17 +39,17 @@ func (c *ThemeCommand) Execute(ctx context.Context, args []string) error {
 
 	switch subcommand {
 	case "list":
-		return c.listThemes()
+		return c.listThemes(stdout)
 	case "show":
 		if len(args) < 2 {
 			return fmt.Errorf("用法: theme show <name>")
 		}
-		return c.showTheme(args[1])
+		return c.showTheme(args[1], stdout)
 	case "set":
 		if len(args) < 2 {
 			return fmt.Errorf("用法: theme set <name>")
 		}
-		return c.setTheme(args[1])
+		return c.setTheme(args[1], stdout)
 	case "export":
 		if len(args) < 2 {
 			return fmt.Errorf("用法: theme export <name>")
@@ -61,7 +65,7 @@ func (c *ThemeCommand) Execute(ctx context.Context, args []string) error {
 	}
 }
 
-func (c *ThemeCommand) listThemes() error {
+func (c *ThemeCommand) listThemes(stdout io.Writer) error {
 	themes := c.manager.ListThemes()
 	current := c.manager.CurrentTheme()
 
@@ -80,29 +84,29 @@ func (c *ThemeCommand) listThemes() error {
 	sort.Strings(builtin)
 	sort.Strings(custom)
 
-	fmt.Println("\n内置主题:")
+	fmt.Fprintln(stdout, "\n内置主题:")
 	for _, name := range builtin {
 		if name == current {
-			fmt.Printf("  * %s (当前)\n", name)
+			fmt.Fprintf(stdout, "  * %s (当前)\n", name)
 		} else {
-			fmt.Printf("    %s\n", name)
+			fmt.Fprintf(stdout, "    %s\n", name)
 		}
 	}
 
 	if len(custom) > 0 {
-		fmt.Println("\n自定义主题:")
+		fmt.Fprintln(stdout, "\n自定义主题:")
 		for _, name := range custom {
-			fmt.Printf("    %s\n", name)
+			fmt.Fprintf(stdout, "    %s\n", name)
 		}
 	}
 
-	fmt.Println("\n提示: 使用 'theme show <name>' 预览主题")
-	fmt.Println("      使用 'theme set <name>' 切换主题")
+	fmt.Fprintln(stdout, "\n提示: 使用 'theme show <name>' 预览主题")
+	fmt.Fprintln(stdout, "      使用 'theme set <name>' 切换主题")
 
 	return nil
 }
 
-func (c *ThemeCommand) showTheme(name string) error {
+func (c *ThemeCommand) showTheme(name string, stdout io.Writer) error {
 	// 移除可能的 (custom) 后缀
 	name = removeCustomSuffix(name)
 
@@ -116,47 +120,50 @@ func (c *ThemeCommand) showTheme(name string) error {
 
 	scheme := c.manager.CurrentScheme()
 
-	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("主题预览: %s\n", name)
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
-
-	fmt.Println("基础颜色:")
-	fmt.Println("  " + scheme.Primary().Apply("■ Primary (主要)"))
-	fmt.Println("  " + scheme.Success().Apply("■ Success (成功)"))
-	fmt.Println("  " + scheme.Warning().Apply("■ Warning (警告)"))
-	fmt.Println("  " + scheme.Error().Apply("■ Error (错误)"))
-	fmt.Println("  " + scheme.Info().Apply("■ Info (信息)"))
-
-	fmt.Println("\n文件类型:")
-	fmt.Println("  " + scheme.Directory().Apply("■ Directory/"))
-	fmt.Println("  " + scheme.Executable().Apply("■ Executable*"))
-	fmt.Println("  " + scheme.Symlink().Apply("■ Symlink@"))
-	fmt.Println("  " + scheme.Archive().Apply("■ Archive.zip"))
-
-	fmt.Println("\n提示符颜色:")
-	fmt.Print("  ")
-	fmt.Print(scheme.PromptUser().Apply("user"))
-	fmt.Print("@")
-	fmt.Print(scheme.PromptHost().Apply("hostname"))
-	fmt.Print(" ")
-	fmt.Print(scheme.PromptPath().Apply("~/path"))
-	fmt.Print(scheme.PromptGit().Apply(" (main)"))
-	fmt.Print("$ \n")
-
-	fmt.Println("\n语法高亮:")
-	fmt.Print("  ")
-	fmt.Print(scheme.SyntaxCommand().Apply("command"))
-	fmt.Print(" ")
-	fmt.Print(scheme.SyntaxArgument().Apply("-flag"))
-	fmt.Print(" ")
-	fmt.Print(scheme.SyntaxString().Apply("\"string\""))
-	fmt.Print(" ")
-	fmt.Print(scheme.SyntaxVariable().Apply("$var"))
-	fmt.Print(" ")
-	fmt.Print(scheme.SyntaxOperator().Apply("|"))
-	fmt.Print("\n")
-
-	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Fprintf(stdout, "\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	fmt.Fprintf(stdout, "主题预览: %s\n", name)
+	fmt.Fprintf(stdout, "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n\n")
+
+	fmt.Fprintln(stdout, "基础颜色:")
+	fmt.Fprintln(stdout, "  " + scheme.Primary().Apply("■ Primary (主要)"))
+	fmt.Fprintln(stdout, "  " + scheme.Success().Apply("■ Success (成功)"))
+	fmt.Fprintln(stdout, "  " + scheme.Warning().Apply("■ Warning (警告)"))
+	fmt.Fprintln(stdout, "  " + scheme.Error().Apply("■ Error (错误)"))
+	fmt.Fprintln(stdout, "  " + scheme.Info().Apply("■ Info (信息)"))
+
+	fmt.Fprintln(stdout, "\n文件类型:")
+	fmt.Fprintln(stdout, "  " + scheme.Directory().Apply("■ Directory/"))
+	fmt.Fprintln(stdout, "  " + scheme.Executable().Apply("■ Executable*"))
+	fmt.Fprintln(stdout, "  " + scheme.Symlink().Apply("■ Symlink@"))
+	fmt.Fprintln(stdout, "  " + scheme.Archive().Apply("■ Archive.zip"))
+
+	fmt.Fprintln(stdout, "\n提示符颜色:")
+	fmt.Fprint(stdout, "  ")
+	fmt.Fprint(stdout, scheme.PromptUser().Apply("user"))
+	fmt.Fprint(stdout, "@")
+	fmt.Fprint(stdout, scheme.PromptHost().Apply("hostname"))
+	fmt.Fprint(stdout, " ")
+	fmt.Fprint(stdout, scheme.PromptPath().Apply("~/path"))
+	fmt.Fprint(stdout, scheme.PromptGit().Apply(" (main)"))
+	fmt.Fprint(stdout, "$ \n")
+
+	fmt.Fprintln(stdout, "\n语法高亮:")
+	fmt.Fprint(stdout, "  ")
+	fmt.Fprint(stdout, scheme.SyntaxCommand().Apply("command"))
+	fmt.Fprint(stdout, " ")
+	fmt.Fprint(stdout, scheme.SyntaxArgument().Apply("-flag"))
+	fmt.Fprint(stdout, " ")
+	fmt.Fprint(stdout, scheme.SyntaxString().Apply("\"string\""))
+	fmt.Fprint(stdout, " ")
+	fmt.Fprint(stdout, scheme.SyntaxVariable().Apply("$var"))
+	fmt.Fprint(stdout, " ")
+	fmt.Fprint(stdout, scheme.SyntaxOperator().Apply("|"))
+	fmt.Fprint(stdout, "\n")
+
+	fmt.Fprintln(stdout, "\n搜索匹配:")
+	fmt.Fprintln(stdout, "  main.go:12: // " + scheme.Match().Apply("TODO") + " 处理错误")
+
+	fmt.Fprintln(stdout, "\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
 	// 恢复当前主题
 	c.manager.LoadTheme(currentTheme)
@@ -164,7 +171,7 @@ func (c *ThemeCommand) showTheme(name string) error {
 	return nil
 }
 
-func (c *ThemeCommand) setTheme(name string) error {
+func (c *ThemeCommand) setTheme(name string, stdout io.Writer) error {
 	// 移除可能的 (custom) 后缀
 	name = removeCustomSuffix(name)
 
@@ -172,9 +179,9 @@ func (c *ThemeCommand) setTheme(name string) error {
 		return err
 	}
 
-	fmt.Printf("✓ 主题已切换为: %s\n", name)
-	fmt.Println("\n提示: 重启 shell 以应用新的提示符颜色")
-	fmt.Println("      或使用 'theme show %s' 查看效果", name)
+	fmt.Fprintf(stdout, "✓ 主题已切换为: %s\n", name)
+	fmt.Fprintln(stdout, "\n提示: 重启 shell 以应用新的提示符颜色")
+	fmt.Fprintf(stdout, "      或使用 'theme show %s' 查看效果\n", name)
 
 	return nil
 }
@@ -0,0 +1,390 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// TrCommand tr 命令 - 替换、删除或压缩字符
+type TrCommand struct{}
+
+// NewTrCommand 创建 tr 命令
+func NewTrCommand() *TrCommand {
+	return &TrCommand{}
+}
+
+func (c *TrCommand) Name() string {
+	return "tr"
+}
+
+// trClasses 字符类，按 POSIX 区域设置展开（大小写类按字母顺序，因此可以互相转换）
+var trClasses = map[string]func(r rune) bool{
+	"alnum":  func(r rune) bool { return r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' },
+	"alpha":  func(r rune) bool { return r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' },
+	"blank":  func(r rune) bool { return r == ' ' || r == '\t' },
+	"cntrl":  func(r rune) bool { return r < 32 || r == 127 },
+	"digit":  func(r rune) bool { return r >= '0' && r <= '9' },
+	"graph":  func(r rune) bool { return r > 32 && r < 127 },
+	"lower":  func(r rune) bool { return r >= 'a' && r <= 'z' },
+	"print":  func(r rune) bool { return r >= 32 && r < 127 },
+	"punct":  func(r rune) bool { return r > 32 && r < 127 && !unicode.IsLetter(r) && !unicode.IsDigit(r) },
+	"space":  func(r rune) bool { return r == ' ' || r >= '\t' && r <= '\r' },
+	"upper":  func(r rune) bool { return r >= 'A' && r <= 'Z' },
+	"xdigit": func(r rune) bool { return r >= '0' && r <= '9' || r >= 'A' && r <= 'F' || r >= 'a' && r <= 'f' },
+}
+
+// trSet 展开后的字符集
+type trSet struct {
+	chars  []rune
+	member map[rune]bool
+	fill   int // SET2 中 [c*] 的位置，-1 表示没有
+}
+
+func (s *trSet) contains(r rune) bool {
+	return s.member[r]
+}
+
+func (c *TrCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
+	flags := flag.NewFlagSet("tr", flag.ContinueOnError)
+	complement := flags.BoolP("complement", "c", false, "使用 SET1 的补集")
+	deleteChars := flags.BoolP("delete", "d", false, "删除 SET1 中的字符")
+	squeeze := flags.BoolP("squeeze-repeats", "s", false, "把连续重复的字符压缩为一个")
+	truncate := flags.BoolP("truncate-set1", "t", false, "把 SET1 截断为 SET2 的长度")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	sets := flags.Args()
+	switch {
+	case len(sets) == 0:
+		return fmt.Errorf("tr: 缺少字符集")
+	case len(sets) > 2:
+		return fmt.Errorf("tr: 多余的参数: %s", sets[2])
+	case *deleteChars && !*squeeze && len(sets) == 2:
+		return fmt.Errorf("tr: 只删除字符时只能指定一个字符集")
+	case *deleteChars && *squeeze && len(sets) == 1:
+		return fmt.Errorf("tr: 删除并压缩时需要两个字符集")
+	case !*deleteChars && !*squeeze && len(sets) == 1:
+		return fmt.Errorf("tr: 替换字符时需要两个字符集")
+	}
+
+	set1, err := parseTrSet(sets[0], false)
+	if err != nil {
+		return fmt.Errorf("tr: %w", err)
+	}
+	var set2 *trSet
+	if len(sets) == 2 {
+		if set2, err = parseTrSet(sets[1], true); err != nil {
+			return fmt.Errorf("tr: %w", err)
+		}
+	}
+
+	tr := &translator{complement: *complement, set1: set1}
+	switch {
+	case *deleteChars:
+		tr.deleteSet = set1
+		if *squeeze {
+			tr.squeezeSet = set2
+		}
+	case len(sets) == 2:
+		if err := tr.buildMap(set2, *truncate); err != nil {
+			return fmt.Errorf("tr: %w", err)
+		}
+		if *squeeze {
+			tr.squeezeSet = set2
+		}
+	default:
+		tr.squeezeSet = set1
+		tr.squeezeComplement = *complement
+	}
+
+	return tr.run(ctx, streams.Stdin(ctx), std.Stdout)
+}
+
+// parseTrSet 解析字符集：转义序列、a-z 范围、[:class:]、[=c=]，SET2 中还可以使用 [c*n] 和 [c*]
+func parseTrSet(spec string, second bool) (*trSet, error) {
+	set := &trSet{member: make(map[rune]bool), fill: -1}
+	src := []rune(spec)
+
+	// next 读取一个字符（处理转义），返回字符和消耗的长度
+	next := func(i int) (rune, int) {
+		if src[i] != '\\' || i+1 >= len(src) {
+			return src[i], 1
+		}
+		switch ch := src[i+1]; ch {
+		case 'n':
+			return '\n', 2
+		case 't':
+			return '\t', 2
+		case 'r':
+			return '\r', 2
+		case 'a':
+			return '\a', 2
+		case 'b':
+			return '\b', 2
+		case 'f':
+			return '\f', 2
+		case 'v':
+			return '\v', 2
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			j := i + 1
+			for j < len(src) && j < i+4 && src[j] >= '0' && src[j] <= '7' {
+				j++
+			}
+			n, _ := strconv.ParseUint(string(src[i+1:j]), 8, 32)
+			return rune(n), j - i
+		default:
+			return ch, 2
+		}
+	}
+	add := func(r rune) {
+		set.chars = append(set.chars, r)
+		set.member[r] = true
+	}
+
+	for i := 0; i < len(src); {
+		if src[i] == '[' && i+1 < len(src) {
+			rest := string(src[i+1:])
+			// [:class:]
+			if name, _, ok := strings.Cut(strings.TrimPrefix(rest, ":"), ":]"); strings.HasPrefix(rest, ":") && ok {
+				class, known := trClasses[name]
+				if !known {
+					return nil, fmt.Errorf("无效的字符类: %s", name)
+				}
+				for r := rune(0); r < 128; r++ {
+					if class(r) {
+						add(r)
+					}
+				}
+				i += utf8.RuneCountInString(name) + 4
+				continue
+			}
+			// [=c=]
+			if len(src) > i+4 && src[i+1] == '=' && src[i+3] == '=' && src[i+4] == ']' {
+				add(src[i+2])
+				i += 5
+				continue
+			}
+			// [c*n] 或 [c*]
+			if second && i+2 < len(src) {
+				r, size := next(i + 1)
+				j := i + 1 + size
+				if j < len(src) && src[j] == '*' {
+					end := j + 1
+					for end < len(src) && src[end] != ']' {
+						end++
+					}
+					if end < len(src) {
+						count := string(src[j+1 : end])
+						if count == "" {
+							if set.fill >= 0 {
+								return nil, fmt.Errorf("只能使用一个 [c*]")
+							}
+							set.fill = len(set.chars)
+							add(r)
+						} else {
+							base := 10
+							if strings.HasPrefix(count, "0") {
+								base = 8
+							}
+							n, err := strconv.ParseInt(count, base, 32)
+							if err != nil {
+								return nil, fmt.Errorf("无效的重复次数: %s", count)
+							}
+							for k := int64(0); k < n; k++ {
+								add(r)
+							}
+						}
+						i = end + 1
+						continue
+					}
+				}
+			}
+		}
+
+		r, size := next(i)
+		// a-z 范围
+		if i+size+1 < len(src) && src[i+size] == '-' {
+			hi, hiSize := next(i + size + 1)
+			if hi < r {
+				return nil, fmt.Errorf("无效的递减范围: %c-%c", r, hi)
+			}
+			for ch := r; ch <= hi; ch++ {
+				add(ch)
+			}
+			i += size + 1 + hiSize
+			continue
+		}
+		add(r)
+		i += size
+	}
+	return set, nil
+}
+
+// translator 保存替换表和需要删除、压缩的字符
+type translator struct {
+	complement        bool
+	set1              *trSet
+	mapping           map[rune]rune
+	complementMap     bool // -c 替换时，补集中的字符都替换为 complementTo
+	complementTo      rune // SET2 的最后一个字符
+	deleteSet         *trSet
+	squeezeSet        *trSet
+	squeezeComplement bool // 只压缩时使用 SET1 的补集
+}
+
+// buildMap 建立 SET1 到 SET2 的替换表，SET2 较短时用最后一个字符补齐
+func (t *translator) buildMap(set2 *trSet, truncate bool) error {
+	if len(set2.chars) == 0 {
+		return fmt.Errorf("SET2 不能为空")
+	}
+	to := set2.chars
+	if set2.fill >= 0 {
+		// [c*] 重复到与 SET1 等长
+		need := len(t.set1.chars) - (len(to) - 1)
+		expanded := append([]rune{}, to[:set2.fill]...)
+		for k := 0; k < need; k++ {
+			expanded = append(expanded, to[set2.fill])
+		}
+		to = append(expanded, to[set2.fill+1:]...)
+	}
+	if t.complement {
+		t.complementMap, t.complementTo = true, to[len(to)-1]
+		return nil
+	}
+	from := t.set1.chars
+	if truncate && len(from) > len(to) {
+		from = from[:len(to)]
+	}
+	t.mapping = make(map[rune]rune, len(from))
+	for i, r := range from {
+		if i < len(to) {
+			t.mapping[r] = to[i]
+		} else {
+			t.mapping[r] = to[len(to)-1]
+		}
+	}
+	return nil
+}
+
+// translate 返回替换后的字符，keep 为 false 表示删除
+func (t *translator) translate(r rune) (rune, bool) {
+	if t.deleteSet != nil {
+		return r, t.deleteSet.contains(r) == t.complement
+	}
+	if t.complementMap && !t.set1.contains(r) {
+		return t.complementTo, true
+	}
+	if to, ok := t.mapping[r]; ok {
+		return to, true
+	}
+	return r, true
+}
+
+// squeezable 判断字符的连续重复是否需要压缩
+func (t *translator) squeezable(r rune) bool {
+	if t.squeezeSet == nil {
+		return false
+	}
+	return t.squeezeSet.contains(r) != t.squeezeComplement
+}
+
+// run 逐个字符处理输入；输入暂时没有数据时先刷新输出，使管道中的结果立即出现
+func (t *translator) run(ctx context.Context, in io.Reader, stdout io.Writer) error {
+	r := bufio.NewReaderSize(in, 64*1024)
+	w := bufio.NewWriter(stdout)
+	defer w.Flush()
+
+	last, haveLast := rune(0), false
+	for i := 0; ; i++ {
+		if i%4096 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+		ch, size, err := r.ReadRune()
+		if err == io.EOF {
+			return w.Flush()
+		}
+		if err != nil {
+			return err
+		}
+		if ch == utf8.RuneError && size == 1 {
+			// 无效的 UTF-8 字节原样输出
+			r.UnreadRune()
+			b, _ := r.ReadByte()
+			w.WriteByte(b)
+			haveLast = false
+			continue
+		}
+		out, keep := t.translate(ch)
+		if !keep {
+			continue
+		}
+		if haveLast && out == last && t.squeezable(out) {
+			continue
+		}
+		w.WriteRune(out)
+		last, haveLast = out, true
+	}
+}
+
+func (c *TrCommand) Help() string {
+	return `tr - 替换、删除或压缩字符
+
+用法:
+  tr [选项] SET1 [SET2]
+
+选项:
+  -c, --complement          使用 SET1 的补集（不在 SET1 中的字符）
+  -d, --delete              删除 SET1 中的字符
+  -s, --squeeze-repeats     把连续重复的字符压缩为一个：替换或删除时使用 SET2，
+                            否则使用 SET1
+  -t, --truncate-set1       把 SET1 截断为 SET2 的长度
+
+字符集:
+  abc         列出的字符（支持中文等 UTF-8 字符）
+  a-z         范围
+  \n \t \\    转义字符，\NNN 为八进制字符
+  [:alpha:]   字符类：alnum alpha blank cntrl digit graph lower print
+              punct space upper xdigit
+  [=c=]       与 c 等价的字符
+  [c*n]       （仅 SET2）重复 n 次的 c
+  [c*]        （仅 SET2）重复 c 直到 SET2 与 SET1 等长
+
+描述:
+  从标准输入读取，替换 SET1 中的字符为 SET2 中对应位置的字符后输出。
+  SET2 比 SET1 短时用 SET2 的最后一个字符补齐。
+  使用 -c 替换时，不在 SET1 中的字符都替换为 SET2 的最后一个字符。
+
+示例:
+  echo hello | tr a-z A-Z               # 转为大写
+  echo hello | tr '[:lower:]' '[:upper:]'
+  tr -d '\r' < dos.txt                  # 删除回车符
+  echo 'a   b    c' | tr -s ' '         # 压缩连续的空格
+  tr -cs '[:alnum:]' '\n' < a.txt       # 每行一个单词
+  echo 你好世界 | tr 你 您`
+}
+
+func (c *TrCommand) ShortHelp() string {
+	return "替换或删除字符"
+}
@@ -3,19 +3,19 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type TreeCommand struct {
-	stdout *os.File
-}
+type TreeCommand struct{}
 
-func NewTreeCommand(stdout *os.File) *TreeCommand {
-	return &TreeCommand{stdout: stdout}
+func NewTreeCommand() *TreeCommand {
+	return &TreeCommand{}
 }
 
 func (c *TreeCommand) Name() string {
@@ -23,6 +23,8 @@ func (c *TreeCommand) Name() string {
 }
 
 func (c *TreeCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := pflag.NewFlagSet("tree", pflag.ContinueOnError)
 	level := flags.IntP("level", "L", 0, "显示的层级深度（0=无限制）")
 	dirOnly := flags.BoolP("directories", "d", false, "只显示目录")
@@ -37,14 +39,14 @@ func (c *TreeCommand) Execute(ctx context.Context, args []string) error {
 	}
 
 	for _, path := range paths {
-		fmt.Fprintln(c.stdout, path)
-		c.printTree(path, "", 0, *level, *dirOnly)
+		fmt.Fprintln(stdout, path)
+		c.printTree(path, "", 0, *level, *dirOnly, stdout)
 	}
 
 	return nil
 }
 
-func (c *TreeCommand) printTree(root, prefix string, depth, maxDepth int, dirOnly bool) {
+func (c *TreeCommand) printTree(root, prefix string, depth, maxDepth int, dirOnly bool, stdout io.Writer) {
 	// 检查深度限制
 	if maxDepth > 0 && depth >= maxDepth {
 		return
@@ -83,7 +85,7 @@ func (c *TreeCommand) printTree(root, prefix string, depth, maxDepth int, dirOnl
 		if entry.IsDir() {
 			name += "/"
 		}
-		fmt.Fprintf(c.stdout, "%s%s%s\n", prefix, branch, name)
+		fmt.Fprintf(stdout, "%s%s%s\n", prefix, branch, name)
 
 		// 递归打印子目录
 		if entry.IsDir() {
@@ -95,7 +97,7 @@ func (c *TreeCommand) printTree(root, prefix string, depth, maxDepth int, dirOnl
 			}
 
 			subPath := filepath.Join(root, entry.Name())
-			c.printTree(subPath, newPrefix, depth+1, maxDepth, dirOnly)
+			c.printTree(subPath, newPrefix, depth+1, maxDepth, dirOnly, stdout)
 		}
 	}
 }
@@ -0,0 +1,349 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/config"
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// 命令名的种类，也是 type -t 的输出
+const (
+	kindAlias    = "alias"
+	kindKeyword  = "keyword"
+	kindFunction = "function"
+	kindBuiltin  = "builtin"
+	kindFile     = "file"
+)
+
+// resolution 命令名的一种解释
+type resolution struct {
+	kind   string
+	value  string // 别名的内容或文件路径
+	def    *script.FunctionDef
+	hashed bool // 文件路径来自 hash 缓存
+}
+
+// resolver 按 shell 的查找顺序解释命令名：别名、关键字、函数、内置命令、PATH
+type resolver struct {
+	registry *Registry
+	config   *config.Config
+	hash     *PathCache
+}
+
+// resolveOptions 控制 resolve 的查找范围
+type resolveOptions struct {
+	all         bool // 返回所有解释，而不是第一个
+	noFunctions bool // 跳过函数
+	pathOnly    bool // 只在 PATH 中查找
+}
+
+// resolve 返回命令名的解释，按优先级排序
+func (r *resolver) resolve(executor *script.Executor, name string, opts resolveOptions) []resolution {
+	var result []resolution
+	done := func() bool {
+		return len(result) > 0 && !opts.all
+	}
+
+	if !opts.pathOnly {
+		if value, ok := r.config.GetAlias(name); ok {
+			result = append(result, resolution{kind: kindAlias, value: value})
+		}
+		if !done() && script.IsKeyword(name) {
+			result = append(result, resolution{kind: kindKeyword})
+		}
+		if !done() && !opts.noFunctions {
+			if def, ok := executor.Function(name); ok {
+				result = append(result, resolution{kind: kindFunction, def: def})
+			}
+		}
+		if !done() {
+			if _, ok := r.registry.Get(name); ok {
+				result = append(result, resolution{kind: kindBuiltin})
+			}
+		}
+		if done() {
+			return result
+		}
+	}
+
+	// 只有第一个匹配才可能来自缓存，-a 时再列出 PATH 中的其他文件
+	if path, ok := r.hash.Peek(name); ok {
+		result = append(result, resolution{kind: kindFile, value: path, hashed: true})
+		if !opts.all {
+			return result
+		}
+	}
+	for _, path := range pathMatches(name) {
+		if len(result) > 0 && result[len(result)-1].kind == kindFile && result[len(result)-1].value == path {
+			continue
+		}
+		result = append(result, resolution{kind: kindFile, value: path})
+		if !opts.all {
+			break
+		}
+	}
+	return result
+}
+
+// pathMatches 返回 PATH 中所有名为 name 的可执行文件
+func pathMatches(name string) []string {
+	if strings.ContainsRune(name, '/') {
+		if isExecutableFile(name) {
+			return []string{name}
+		}
+		return nil
+	}
+
+	var matches []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			dir = "."
+		}
+		path := filepath.Join(dir, name)
+		if isExecutableFile(path) {
+			matches = append(matches, path)
+		}
+	}
+	return matches
+}
+
+// isExecutableFile 判断路径是否是可执行的普通文件
+func isExecutableFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode().Perm()&0111 != 0
+}
+
+// describe 输出 type 风格的描述
+func describe(w io.Writer, name string, res resolution) {
+	switch res.kind {
+	case kindAlias:
+		fmt.Fprintf(w, "%s 是 '%s' 的别名\n", name, res.value)
+	case kindKeyword:
+		fmt.Fprintf(w, "%s 是 shell 关键字\n", name)
+	case kindFunction:
+		fmt.Fprintf(w, "%s 是函数\n", name)
+		fmt.Fprintln(w, script.FormatStatement(res.def))
+	case kindBuiltin:
+		fmt.Fprintf(w, "%s 是 shell 内置命令\n", name)
+	case kindFile:
+		if res.hashed {
+			fmt.Fprintf(w, "%s 已被哈希 (%s)\n", name, res.value)
+		} else {
+			fmt.Fprintf(w, "%s 是 %s\n", name, res.value)
+		}
+	}
+}
+
+// TypeCommand type 命令 - 显示命令名会被如何解释
+type TypeCommand struct {
+	resolver
+	executor *script.Executor
+}
+
+// NewTypeCommand 创建 type 命令
+func NewTypeCommand(executor *script.Executor, registry *Registry, cfg *config.Config, hash *PathCache) *TypeCommand {
+	return &TypeCommand{
+		resolver: resolver{registry: registry, config: cfg, hash: hash},
+		executor: executor,
+	}
+}
+
+func (c *TypeCommand) Name() string {
+	return "type"
+}
+
+func (c *TypeCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+	executor := script.ExecutorFrom(ctx, c.executor)
+
+	flags := flag.NewFlagSet("type", flag.ContinueOnError)
+	kindOnly := flags.BoolP("type", "t", false, "只输出种类")
+	all := flags.BoolP("all", "a", false, "列出所有解释")
+	pathOnly := flags.BoolP("path", "p", false, "只输出文件路径")
+	forcePath := flags.BoolP("force-path", "P", false, "总是在 PATH 中查找")
+	noFunctions := flags.BoolP("no-functions", "f", false, "不查找函数")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("用法: type [-afptP] 命令名...")
+	}
+
+	opts := resolveOptions{
+		all:         *all,
+		noFunctions: *noFunctions,
+		pathOnly:    *forcePath,
+	}
+
+	var missing []string
+	for _, name := range flags.Args() {
+		results := c.resolve(executor, name, opts)
+		if len(results) == 0 {
+			missing = append(missing, name)
+			continue
+		}
+
+		for _, res := range results {
+			switch {
+			case *kindOnly:
+				fmt.Fprintln(stdout, res.kind)
+			case *pathOnly || *forcePath:
+				if res.kind == kindFile {
+					fmt.Fprintln(stdout, res.value)
+				}
+			default:
+				describe(stdout, name, res)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("type: %s: 未找到", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (c *TypeCommand) Help() string {
+	return `type - 显示命令名会被如何解释
+
+用法:
+  type [-afptP] 命令名...
+
+说明:
+  按执行时的查找顺序显示命令名是别名、关键字、函数、内置命令
+  还是外部文件。函数会同时显示其定义。任何一个命令名都找不到时
+  返回非零退出码。
+
+选项:
+  -t, --type           只输出种类: alias、keyword、function、builtin 或 file
+  -a, --all            列出所有解释，包括 PATH 中的所有同名文件
+  -p, --path           只输出外部文件的路径，其他种类不输出
+  -P, --force-path     总是在 PATH 中查找，即使命令名是别名、函数或内置命令
+  -f, --no-functions   不查找函数
+
+示例:
+  type ls              # ls 是 shell 内置命令
+  type -a ls           # 同时列出 PATH 中的 ls
+  type -t if           # keyword
+  type -P git          # /usr/bin/git`
+}
+
+func (c *TypeCommand) ShortHelp() string {
+	return "显示命令名会被如何解释"
+}
+
+// CommandCommand command 命令 - 执行命令时跳过同名函数，或查询命令
+type CommandCommand struct {
+	resolver
+	executor *script.Executor
+}
+
+// NewCommandCommand 创建 command 命令
+func NewCommandCommand(executor *script.Executor, registry *Registry, cfg *config.Config, hash *PathCache) *CommandCommand {
+	return &CommandCommand{
+		resolver: resolver{registry: registry, config: cfg, hash: hash},
+		executor: executor,
+	}
+}
+
+func (c *CommandCommand) Name() string {
+	return "command"
+}
+
+func (c *CommandCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+	executor := script.ExecutorFrom(ctx, c.executor)
+
+	flags := flag.NewFlagSet("command", flag.ContinueOnError)
+	flags.SetInterspersed(false)
+	short := flags.BoolP("short", "v", false, "输出命令的简短描述")
+	verbose := flags.BoolP("verbose", "V", false, "输出命令的详细描述")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return nil
+	}
+
+	if !*short && !*verbose {
+		// 别名只在交互行的第一个单词上展开，到这里已经不会再展开
+		return executor.RunCommand(ctx, args[0], args[1:])
+	}
+
+	var missing []string
+	for _, name := range args {
+		results := c.resolve(executor, name, resolveOptions{})
+		if len(results) == 0 {
+			missing = append(missing, name)
+			continue
+		}
+
+		res := results[0]
+		if *verbose {
+			describe(stdout, name, res)
+			continue
+		}
+		switch res.kind {
+		case kindAlias:
+			fmt.Fprintf(stdout, "alias %s='%s'\n", name, res.value)
+		case kindFile:
+			fmt.Fprintln(stdout, res.value)
+		default:
+			fmt.Fprintln(stdout, name)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+	// 和 POSIX 一致，command -v 找不到命令时不输出信息，只返回非零退出码
+	if !*verbose {
+		return script.ExitStatus(1)
+	}
+	return fmt.Errorf("command: %s: 未找到", strings.Join(missing, ", "))
+}
+
+func (c *CommandCommand) Help() string {
+	return `command - 执行命令并跳过同名函数，或查询命令
+
+用法:
+  command 命令名 [参数...]
+  command -v|-V 命令名...
+
+说明:
+  不带选项时执行内置命令或外部命令，跳过同名的函数和别名。
+  常用于在覆盖了命令的函数中调用原来的命令。查询时任何一个
+  命令名找不到都返回非零退出码。
+
+选项:
+  -v, --short     输出命令的简短描述: 外部命令输出路径，别名输出
+                  alias 定义，其他输出命令名
+  -V, --verbose   以 type 的格式输出详细描述
+
+示例:
+  ls() { command ls -l "$@"; }      # 在函数中调用原来的 ls
+  if command -v git >/dev/null; then echo "已安装 git"; fi
+  command -V cd`
+}
+
+func (c *CommandCommand) ShortHelp() string {
+	return "执行命令并跳过同名函数，或查询命令"
+}
@@ -3,19 +3,17 @@ package commands
 import (
 	"context"
 	"fmt"
-	"os"
 
 	"github.com/Lingbou/Lish/internal/config"
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
 type UnaliasCommand struct {
-	stdout *os.File
 	config *config.Config
 }
 
-func NewUnaliasCommand(stdout *os.File, cfg *config.Config) *UnaliasCommand {
+func NewUnaliasCommand(cfg *config.Config) *UnaliasCommand {
 	return &UnaliasCommand{
-		stdout: stdout,
 		config: cfg,
 	}
 }
@@ -25,18 +23,20 @@ func (c *UnaliasCommand) Name() string {
 }
 
 func (c *UnaliasCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	if len(args) == 0 {
 		return fmt.Errorf("unalias: 需要指定别名名称")
 	}
 
 	for _, name := range args {
 		if _, exists := c.config.GetAlias(name); !exists {
-			fmt.Fprintf(c.stdout, "unalias: %s: 未定义\n", name)
+			fmt.Fprintf(stdout, "unalias: %s: 未定义\n", name)
 			continue
 		}
 
 		c.config.RemoveAlias(name)
-		fmt.Fprintf(c.stdout, "已删除别名: %s\n", name)
+		fmt.Fprintf(stdout, "已删除别名: %s\n", name)
 	}
 
 	// 保存配置
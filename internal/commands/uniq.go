@@ -1,11 +1,15 @@
 package commands
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	flag "github.com/spf13/pflag"
 )
 
@@ -17,6 +21,8 @@ func (c *UniqCommand) Name() string {
 }
 
 func (c *UniqCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := flag.NewFlagSet("uniq", flag.ContinueOnError)
 	count := flags.BoolP("count", "c", false, "在每行前显示重复次数")
 	repeated := flags.BoolP("repeated", "d", false, "只显示重复的行")
@@ -24,105 +30,109 @@ func (c *UniqCommand) Execute(ctx context.Context, args []string) error {
 	ignoreCase := flags.BoolP("ignore-case", "i", false, "忽略大小写")
 
 	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
 		return err
 	}
 
 	remaining := flags.Args()
+	if len(remaining) > 2 {
+		return fmt.Errorf("uniq: 多余的参数: %s", remaining[2])
+	}
 
-	var lines []string
-	var err error
+	input := "-"
+	if len(remaining) > 0 {
+		input = remaining[0]
+	}
+	in, err := openInput(ctx, input)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	defer in.Close()
 
-	// 读取输入
-	if len(remaining) == 0 {
-		// 从标准输入读取
-		lines, err = readLines(os.Stdin)
-		if err != nil {
-			return fmt.Errorf("读取输入失败: %w", err)
-		}
-	} else {
-		// 从文件读取
-		filename := remaining[0]
-		lines, err = readLinesFromFile(filename)
+	// 第二个参数是输出文件
+	if len(remaining) == 2 && remaining[1] != "-" {
+		file, err := os.Create(remaining[1])
 		if err != nil {
-			return fmt.Errorf("读取文件失败: %w", err)
+			return fmt.Errorf("无法创建输出文件: %w", err)
 		}
+		defer file.Close()
+		stdout = file
 	}
 
-	// 处理重复行
-	result := processLines(lines, *count, *repeated, *uniqueOnly, *ignoreCase)
-
-	// 输出结果
-	for _, line := range result {
-		fmt.Println(line)
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	if err := uniqLines(ctx, newLineStream(in, out), *count, *repeated, *uniqueOnly, *ignoreCase); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("读取输入失败: %w", err)
 	}
-
-	return nil
+	return out.Flush()
 }
 
-// processLines 处理行，检测重复
-func processLines(lines []string, showCount, showRepeated, showUnique, ignoreCase bool) []string {
-	if len(lines) == 0 {
-		return []string{}
-	}
-
-	type lineInfo struct {
-		original string
-		count    int
-	}
-
-	var result []string
-	var current *lineInfo
-	var processed []*lineInfo
+// uniqLines 逐行比较相邻的行，一组重复行结束时立即输出，内存中只保留当前行
+func uniqLines(ctx context.Context, s *lineStream, showCount, showRepeated, showUnique, ignoreCase bool) error {
+	var current string
+	count := 0
 
 	// 比较函数
-	compare := func(a, b string) bool {
+	equal := func(a, b string) bool {
 		if ignoreCase {
-			return strings.ToLower(a) == strings.ToLower(b)
+			return strings.EqualFold(a, b)
 		}
 		return a == b
 	}
 
-	// 统计相邻的重复行
-	for _, line := range lines {
-		if current == nil {
-			current = &lineInfo{original: line, count: 1}
-		} else if compare(current.original, line) {
-			current.count++
+	// 输出一组重复行
+	emit := func() {
+		if showRepeated && count == 1 {
+			return // 只显示重复的，跳过唯一的
+		}
+		if showUnique && count > 1 {
+			return // 只显示唯一的，跳过重复的
+		}
+		if showCount {
+			fmt.Fprintf(s.w, "%7d %s\n", count, current)
 		} else {
-			processed = append(processed, current)
-			current = &lineInfo{original: line, count: 1}
+			s.w.WriteString(current)
+			s.w.WriteByte('\n')
 		}
 	}
-	if current != nil {
-		processed = append(processed, current)
-	}
 
-	// 根据选项输出
-	for _, info := range processed {
-		// 过滤条件
-		if showRepeated && info.count == 1 {
-			continue // 只显示重复的，跳过唯一的
+	for i := 0; ; i++ {
+		if i%1024 == 0 && ctx.Err() != nil {
+			return ctx.Err()
 		}
-		if showUnique && info.count > 1 {
-			continue // 只显示唯一的，跳过重复的
+		line, err := s.readLine()
+		if err == io.EOF {
+			break
 		}
-
-		// 输出格式
-		if showCount {
-			result = append(result, fmt.Sprintf("%7d %s", info.count, info.original))
-		} else {
-			result = append(result, info.original)
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if count > 0 && equal(current, line) {
+			count++
+			continue
 		}
+		if count > 0 {
+			emit()
+		}
+		current, count = line, 1
 	}
-
-	return result
+	if count > 0 {
+		emit()
+	}
+	return nil
 }
 
 func (c *UniqCommand) Help() string {
 	return `uniq - 去除或报告重复的相邻行
 
 用法:
-  uniq [选项] [文件]
+  uniq [选项] [输入文件 [输出文件]]
   command | uniq [选项]
 
 说明:
@@ -130,6 +140,9 @@ func (c *UniqCommand) Help() string {
   注意：uniq 只检测相邻的重复行，要去除所有重复行，
   请先使用 sort。
 
+  没有输入文件或输入文件为 - 时读取标准输入。输入是逐行处理的，
+  每组重复行结束后立即输出，内存占用与输入大小无关。
+
 选项:
   -c, --count         在每行前显示重复次数
   -d, --repeated      只显示重复的行
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Lingbou/Lish/internal/script"
+	flag "github.com/spf13/pflag"
+)
+
+// UnsetCommand unset 命令 - 删除变量或函数
+type UnsetCommand struct {
+	executor *script.Executor
+}
+
+// NewUnsetCommand 创建 unset 命令
+func NewUnsetCommand(executor *script.Executor) *UnsetCommand {
+	return &UnsetCommand{executor: executor}
+}
+
+func (c *UnsetCommand) Name() string {
+	return "unset"
+}
+
+func (c *UnsetCommand) Execute(ctx context.Context, args []string) error {
+	executor := script.ExecutorFrom(ctx, c.executor)
+	flags := flag.NewFlagSet("unset", flag.ContinueOnError)
+	function := flags.BoolP("function", "f", false, "删除函数")
+	variable := flags.BoolP("variable", "v", false, "只删除变量")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	vars := executor.Variables()
+
+	for _, name := range flags.Args() {
+		if *function {
+			executor.UnsetFunction(name)
+			continue
+		}
+
+		// 默认删除变量，变量不存在时删除同名函数
+		if _, ok := vars.Lookup(name); !ok && !*variable {
+			executor.UnsetFunction(name)
+			continue
+		}
+
+		if err := vars.Unset(name); err != nil {
+			return fmt.Errorf("unset: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c *UnsetCommand) Help() string {
+	return `unset - 删除变量或函数
+
+用法:
+  unset [-f] [-v] name...
+
+说明:
+  删除指定的 shell 变量，已导出的变量同时从环境中移除。
+  只读变量不能删除。未指定选项时，如果变量不存在则删除同名函数。
+
+选项:
+  -f, --function   删除函数
+  -v, --variable   只删除变量
+
+示例:
+  unset name           # 删除变量
+  unset -f greet       # 删除函数`
+}
+
+func (c *UnsetCommand) ShortHelp() string {
+	return "删除变量或函数"
+}
@@ -8,15 +8,14 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type UnzipCommand struct {
-	stdout *os.File
-}
+type UnzipCommand struct{}
 
-func NewUnzipCommand(stdout *os.File) *UnzipCommand {
-	return &UnzipCommand{stdout: stdout}
+func NewUnzipCommand() *UnzipCommand {
+	return &UnzipCommand{}
 }
 
 func (c *UnzipCommand) Name() string {
@@ -24,6 +23,8 @@ func (c *UnzipCommand) Name() string {
 }
 
 func (c *UnzipCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := pflag.NewFlagSet("unzip", pflag.ContinueOnError)
 	outputDir := flags.StringP("dir", "d", ".", "解压到指定目录")
 	list := flags.BoolP("list", "l", false, "列出压缩包内容")
@@ -48,7 +49,7 @@ func (c *UnzipCommand) Execute(ctx context.Context, args []string) error {
 
 	// 如果只是列出内容
 	if *list {
-		return c.listZip(reader)
+		return c.listZip(reader, stdout)
 	}
 
 	// 解压文件
@@ -58,14 +59,14 @@ func (c *UnzipCommand) Execute(ctx context.Context, args []string) error {
 		}
 	}
 
-	fmt.Fprintf(c.stdout, "✓ 已解压到: %s\n", *outputDir)
+	fmt.Fprintf(stdout, "✓ 已解压到: %s\n", *outputDir)
 	return nil
 }
 
-func (c *UnzipCommand) listZip(reader *zip.ReadCloser) error {
-	fmt.Fprintln(c.stdout, "压缩包内容:")
+func (c *UnzipCommand) listZip(reader *zip.ReadCloser, stdout io.Writer) error {
+	fmt.Fprintln(stdout, "压缩包内容:")
 	for _, file := range reader.File {
-		fmt.Fprintf(c.stdout, "  %s (%d bytes)\n", file.Name, file.UncompressedSize64)
+		fmt.Fprintf(stdout, "  %s (%d bytes)\n", file.Name, file.UncompressedSize64)
 	}
 	return nil
 }
@@ -1,129 +1,207 @@
 package commands
 
 import (
-	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"io"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type WcCommand struct {
-	stdout *os.File
-}
+type WcCommand struct{}
 
-func NewWcCommand(stdout *os.File) *WcCommand {
-	return &WcCommand{stdout: stdout}
+func NewWcCommand() *WcCommand {
+	return &WcCommand{}
 }
 
 func (c *WcCommand) Name() string {
 	return "wc"
 }
 
+// wcCounts 一个输入的统计结果
+type wcCounts struct {
+	lines, words, chars, bytes int64
+}
+
 func (c *WcCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
 	flags := pflag.NewFlagSet("wc", pflag.ContinueOnError)
 	countLines := flags.BoolP("lines", "l", false, "只统计行数")
 	countWords := flags.BoolP("words", "w", false, "只统计单词数")
+	countChars := flags.BoolP("chars", "m", false, "只统计字符数")
 	countBytes := flags.BoolP("bytes", "c", false, "只统计字节数")
-	
+
 	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return nil
+		}
 		return err
 	}
-	
+
 	files := flags.Args()
 	if len(files) == 0 {
-		return fmt.Errorf("wc: 需要指定文件")
+		files = []string{"-"}
 	}
-	
-	// 如果没有指定选项，显示所有统计
-	showAll := !*countLines && !*countWords && !*countBytes
-	
-	var totalLines, totalWords, totalBytes int64
-	
-	for _, filename := range files {
-		lines, words, bytes, err := c.countFile(filename)
+
+	// 如果没有指定选项，显示行数、单词数和字节数
+	show := [4]bool{*countLines, *countWords, *countChars, *countBytes}
+	if show == [4]bool{} {
+		show = [4]bool{true, true, false, true}
+	}
+	// 只统计行数和字节数时不需要逐个解码字符
+	simple := !show[1] && !show[2]
+
+	var total wcCounts
+	failed := false
+	for _, name := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		counts, err := c.count(ctx, name, simple)
 		if err != nil {
-			return err
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(std.Stderr, "wc: %v\n", err)
+			failed = true
+			continue
 		}
-		
-		c.printStats(filename, lines, words, bytes, showAll, *countLines, *countWords, *countBytes)
-		
-		totalLines += lines
-		totalWords += words
-		totalBytes += bytes
+		display := name
+		if name == "-" && len(files) == 1 {
+			display = ""
+		}
+		c.printStats(std.Stdout, display, counts, show)
+
+		total.lines += counts.lines
+		total.words += counts.words
+		total.chars += counts.chars
+		total.bytes += counts.bytes
 	}
-	
+
 	// 如果有多个文件，显示总计
 	if len(files) > 1 {
-		c.printStats("total", totalLines, totalWords, totalBytes, showAll, *countLines, *countWords, *countBytes)
+		c.printStats(std.Stdout, "total", total, show)
+	}
+
+	if failed {
+		return script.ExitStatus(1)
 	}
-	
 	return nil
 }
 
-func (c *WcCommand) countFile(filename string) (lines, words, bytes int64, err error) {
-	file, err := os.Open(filename)
+// count 分块读取输入并统计，内存占用与输入大小无关
+//
+// 单词是由空白字符（包括全角空格等 Unicode 空白）分隔的非空字符序列；
+// 不完整的 UTF-8 序列每个字节算作一个字符。
+func (c *WcCommand) count(ctx context.Context, name string, simple bool) (wcCounts, error) {
+	var counts wcCounts
+	file, err := openInput(ctx, name)
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("打开文件失败: %w", err)
+		return counts, err
 	}
 	defer file.Close()
-	
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		lines++
-		bytes += int64(len(line)) + 1 // +1 for newline
-		
-		// 统计单词数
-		fields := strings.Fields(line)
-		words += int64(len(fields))
+
+	buf := make([]byte, 64*1024)
+	carry := 0 // 上一块结尾不完整的 UTF-8 字节数，已移到 buf 开头
+	inWord := false
+	for {
+		if ctx.Err() != nil {
+			return counts, ctx.Err()
+		}
+		n, err := file.Read(buf[carry:])
+		counts.bytes += int64(n)
+		chunk := buf[:carry+n]
+		eof := err == io.EOF
+		if err != nil && !eof {
+			return counts, fmt.Errorf("%s: %w", inputName(name), err)
+		}
+
+		if simple {
+			counts.lines += int64(bytes.Count(chunk, []byte{'\n'}))
+			if eof {
+				return counts, nil
+			}
+			continue
+		}
+
+		i := 0
+		for i < len(chunk) {
+			r, size := rune(chunk[i]), 1
+			if r >= utf8.RuneSelf {
+				// 字符跨越了块的边界，留到下一块处理
+				if !eof && !utf8.FullRune(chunk[i:]) {
+					break
+				}
+				r, size = utf8.DecodeRune(chunk[i:])
+			}
+			i += size
+			counts.chars++
+			if r == '\n' {
+				counts.lines++
+			}
+			space := unicode.IsSpace(r)
+			if !space && !inWord {
+				counts.words++
+			}
+			inWord = !space
+		}
+		if eof {
+			return counts, nil
+		}
+		carry = copy(buf, chunk[i:])
 	}
-	
-	return lines, words, bytes, scanner.Err()
 }
 
-func (c *WcCommand) printStats(name string, lines, words, bytes int64, showAll, showLines, showWords, showBytes bool) {
-	var output string
-	
-	if showAll || showLines {
-		output += fmt.Sprintf("%8d", lines)
-	}
-	if showAll || showWords {
-		output += fmt.Sprintf("%8d", words)
+func (c *WcCommand) printStats(stdout io.Writer, name string, counts wcCounts, show [4]bool) {
+	var sb strings.Builder
+	for i, value := range []int64{counts.lines, counts.words, counts.chars, counts.bytes} {
+		if show[i] {
+			fmt.Fprintf(&sb, "%8d", value)
+		}
 	}
-	if showAll || showBytes {
-		output += fmt.Sprintf("%8d", bytes)
+	if name != "" {
+		sb.WriteString(" " + name)
 	}
-	
-	output += fmt.Sprintf(" %s\n", name)
-	fmt.Fprint(c.stdout, output)
+	sb.WriteString("\n")
+	io.WriteString(stdout, sb.String())
 }
 
 func (c *WcCommand) Help() string {
 	return `wc - 统计文件的行数、单词数和字节数
 
 用法:
-  wc [选项] 文件...
+  wc [选项] [文件...]
 
 选项:
   -l, --lines  只显示行数
   -w, --words  只显示单词数
+  -m, --chars  只显示字符数（按 UTF-8 解码）
   -c, --bytes  只显示字节数
 
 描述:
-  统计文件的行数、单词数和字节数。
-  如果不指定选项，显示所有统计信息。
+  统计文件的行数（换行符个数）、单词数和字节数。
+  如果不指定选项，显示行数、单词数和字节数。
+  没有文件或文件为 - 时读取标准输入。
+
+  输入按块读取，可以统计任意大小的文件。
 
 示例:
   wc file.txt             # 显示所有统计
   wc -l file.txt          # 只显示行数
   wc -w file.txt          # 只显示单词数
-  wc *.txt                # 统计多个文件并显示总计`
+  wc -m 中文.txt          # 统计字符数
+  wc *.txt                # 统计多个文件并显示总计
+  ls | wc -l              # 统计文件个数`
 }
 
 func (c *WcCommand) ShortHelp() string {
 	return "统计文件行数/字数"
 }
-
@@ -6,16 +6,16 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
 type WhichCommand struct {
-	stdout   *os.File
 	registry *Registry
 }
 
-func NewWhichCommand(stdout *os.File, registry *Registry) *WhichCommand {
+func NewWhichCommand(registry *Registry) *WhichCommand {
 	return &WhichCommand{
-		stdout:   stdout,
 		registry: registry,
 	}
 }
@@ -25,6 +25,8 @@ func (c *WhichCommand) Name() string {
 }
 
 func (c *WhichCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	if len(args) == 0 {
 		return fmt.Errorf("which: 需要指定命令名")
 	}
@@ -32,16 +34,16 @@ func (c *WhichCommand) Execute(ctx context.Context, args []string) error {
 	for _, cmdName := range args {
 		// 先检查是否是内置命令
 		if _, exists := c.registry.Get(cmdName); exists {
-			fmt.Fprintf(c.stdout, "%s: Lish 内置命令\n", cmdName)
+			fmt.Fprintf(stdout, "%s: Lish 内置命令\n", cmdName)
 			continue
 		}
 		
 		// 在 PATH 中查找
 		path, err := c.findInPath(cmdName)
 		if err != nil {
-			fmt.Fprintf(c.stdout, "%s: 未找到\n", cmdName)
+			fmt.Fprintf(stdout, "%s: 未找到\n", cmdName)
 		} else {
-			fmt.Fprintln(c.stdout, path)
+			fmt.Fprintln(stdout, path)
 		}
 	}
 	
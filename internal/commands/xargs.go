@@ -0,0 +1,387 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+	flag "github.com/spf13/pflag"
+)
+
+// XargsCommand xargs 命令 - 用输入中的参数构造并执行命令
+type XargsCommand struct {
+	executor *script.Executor
+}
+
+// NewXargsCommand 创建 xargs 命令，executor 用于执行构造的命令
+func NewXargsCommand(executor *script.Executor) *XargsCommand {
+	return &XargsCommand{executor: executor}
+}
+
+func (c *XargsCommand) Name() string {
+	return "xargs"
+}
+
+// xargsMaxChars 默认每个命令行的最大长度
+const xargsMaxChars = 128 * 1024
+
+// xargs 保存一次 xargs 调用的选项和运行状态
+type xargs struct {
+	executor *script.Executor
+	template []string
+	replace  string // -I 的替换字符串
+	maxArgs  int
+	maxLines int
+	maxChars int
+	trace    bool
+	std      *streams.Streams
+	null     io.Reader
+
+	input    *bufio.Reader
+	delim    byte
+	useDelim bool
+
+	// 并发执行
+	slots   chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	status  int  // 最终退出码
+	stopped bool // 命令以 255 退出或无法执行，不再启动新的命令
+}
+
+func (c *XargsCommand) Execute(ctx context.Context, args []string) error {
+	std := streams.From(ctx)
+
+	flags := flag.NewFlagSet("xargs", flag.ContinueOnError)
+	flags.SetInterspersed(false)
+	maxArgs := flags.IntP("max-args", "n", 0, "每个命令最多使用的参数个数")
+	maxLines := flags.IntP("max-lines", "L", 0, "每个命令最多使用的输入行数")
+	replace := flags.StringP("replace", "I", "", "用每行输入替换命令中的字符串")
+	null := flags.BoolP("null", "0", false, "输入项以 NUL 字符分隔")
+	delim := flags.StringP("delimiter", "d", "", "输入项的分隔符")
+	procs := flags.IntP("max-procs", "P", 1, "同时运行的命令数")
+	trace := flags.BoolP("verbose", "t", false, "执行前把命令输出到标准错误")
+	noRunEmpty := flags.BoolP("no-run-if-empty", "r", false, "没有输入时不执行命令")
+	maxChars := flags.IntP("max-chars", "s", xargsMaxChars, "每个命令行的最大长度")
+	argFile := flags.StringP("arg-file", "a", "", "从文件读取参数")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *maxArgs < 0 || *maxLines < 0 || *procs < 0 || *maxChars < 1 {
+		return fmt.Errorf("xargs: 无效的数值参数")
+	}
+
+	x := &xargs{
+		executor: script.ExecutorFrom(ctx, c.executor),
+		template: flags.Args(),
+		replace:  *replace,
+		maxArgs:  *maxArgs,
+		maxLines: *maxLines,
+		maxChars: *maxChars,
+		trace:    *trace,
+		std:      std,
+	}
+	if len(x.template) == 0 {
+		x.template = []string{"echo"}
+	}
+	switch {
+	case *null:
+		x.delim, x.useDelim = 0, true
+	case flags.Changed("delimiter"):
+		b, err := parseDelimiter(*delim)
+		if err != nil {
+			return fmt.Errorf("xargs: %w", err)
+		}
+		x.delim, x.useDelim = b, true
+	}
+
+	in := std.Stdin
+	if *argFile != "" {
+		// 从文件读取参数时，命令可以使用 xargs 的标准输入
+		file, err := openInput(ctx, *argFile)
+		if err != nil {
+			return fmt.Errorf("xargs: %w", err)
+		}
+		defer file.Close()
+		in = file
+		x.null = std.Stdin
+	} else {
+		null := openNull()
+		defer null.Close()
+		x.null = null
+	}
+	x.input = bufio.NewReader(in)
+
+	if *procs == 0 {
+		*procs = runtime.NumCPU() * 4
+	}
+	if *procs > 1 {
+		// 并发执行的命令共用 xargs 的输出
+		x.std = &streams.Streams{Stdin: std.Stdin, Stdout: &syncWriter{w: std.Stdout}, Stderr: &syncWriter{w: std.Stderr}}
+	}
+	x.slots = make(chan struct{}, *procs)
+
+	ran, err := x.run(ctx)
+	if err == nil && !ran && !*noRunEmpty && x.replace == "" {
+		// 和 GNU xargs 一样，没有输入时也执行一次命令
+		x.start(ctx, x.template)
+	}
+	x.wg.Wait()
+	if err != nil {
+		return fmt.Errorf("xargs: %w", err)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if x.status != 0 {
+		return script.ExitStatus(x.status)
+	}
+	return nil
+}
+
+// run 读取输入，按 -n、-L、-s 的限制把参数分批，每批执行一次命令
+func (x *xargs) run(ctx context.Context) (ran bool, err error) {
+	var batch []string
+	size, lines := x.templateSize(), 0
+	flush := func() {
+		if len(batch) > 0 {
+			x.start(ctx, append(append([]string{}, x.template...), batch...))
+			ran = true
+		}
+		batch, size, lines = nil, x.templateSize(), 0
+	}
+
+	for {
+		if ctx.Err() != nil || x.isStopped() {
+			return ran, nil
+		}
+		items, ok, err := x.next()
+		if err != nil {
+			return ran, err
+		}
+		if !ok {
+			break
+		}
+		if x.replace != "" {
+			for _, item := range items {
+				x.start(ctx, x.substitute(item))
+				ran = true
+			}
+			continue
+		}
+		if len(items) == 0 {
+			continue
+		}
+		for _, item := range items {
+			if x.maxArgs > 0 && len(batch) == x.maxArgs || len(batch) > 0 && size+len(item)+1 > x.maxChars {
+				flush()
+			}
+			batch = append(batch, item)
+			size += len(item) + 1
+		}
+		if lines++; x.maxLines > 0 && lines == x.maxLines {
+			flush()
+		}
+	}
+	flush()
+	return ran, nil
+}
+
+// templateSize 返回命令模板本身的长度
+func (x *xargs) templateSize() int {
+	size := 0
+	for _, arg := range x.template {
+		size += len(arg) + 1
+	}
+	return size
+}
+
+// substitute 把模板中的替换字符串替换为输入项（-I）
+func (x *xargs) substitute(item string) []string {
+	argv := make([]string, len(x.template))
+	for i, arg := range x.template {
+		argv[i] = strings.ReplaceAll(arg, x.replace, item)
+	}
+	return argv
+}
+
+// next 读取下一组输入项：使用 -0 或 -d 时每次一项；否则每次一行，
+// 按空白拆分并处理引号和反斜杠（-I 时整行是一项，只去掉开头的空白）
+func (x *xargs) next() ([]string, bool, error) {
+	if x.useDelim {
+		item, err := x.input.ReadString(x.delim)
+		if err == io.EOF {
+			if item == "" {
+				return nil, false, nil
+			}
+			return []string{item}, true, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return []string{item[:len(item)-1]}, true, nil
+	}
+
+	line, err := x.input.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return nil, false, nil
+	}
+	if err != nil && err != io.EOF {
+		return nil, false, err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	if x.replace != "" {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return nil, true, nil
+		}
+		return []string{line}, true, nil
+	}
+	items, err := splitXargsLine(line)
+	return items, err == nil, err
+}
+
+// splitXargsLine 按空白拆分一行，支持单引号、双引号和反斜杠转义
+func splitXargsLine(line string) ([]string, error) {
+	var items []string
+	var sb strings.Builder
+	inItem := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '\\':
+			escaped, inItem = true, true
+		case r == '\'' || r == '"':
+			quote, inItem = r, true
+		case r == ' ' || r == '\t':
+			if inItem {
+				items = append(items, sb.String())
+				sb.Reset()
+				inItem = false
+			}
+		default:
+			sb.WriteRune(r)
+			inItem = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("引号不匹配: %s", line)
+	}
+	if inItem {
+		items = append(items, sb.String())
+	}
+	return items, nil
+}
+
+// start 执行一个命令；并发数为 1 时等待命令结束，否则在空闲的槽位中后台执行
+func (x *xargs) start(ctx context.Context, argv []string) {
+	if x.trace {
+		fmt.Fprintln(x.std.Stderr, strings.Join(argv, " "))
+	}
+	x.slots <- struct{}{}
+	// 子 shell 在启动 goroutine 之前创建，避免并发读取当前 shell 的变量
+	sub := x.executor.Subshell()
+	x.wg.Add(1)
+	run := func() {
+		defer x.wg.Done()
+		defer func() { <-x.slots }()
+		code := runJob(ctx, "xargs", &streams.Streams{Stdin: x.null, Stdout: x.std.Stdout, Stderr: x.std.Stderr},
+			func(ctx context.Context) error { return sub.Call(ctx, argv[0], argv[1:]) })
+		x.finish(argv[0], code)
+	}
+	if cap(x.slots) == 1 {
+		run()
+		return
+	}
+	go run()
+}
+
+// finish 根据命令的退出码更新 xargs 的退出码（与 GNU xargs 相同）：
+// 命令失败为 123，命令以 255 退出时停止并返回 124，命令无法执行时停止并返回 126 或 127
+func (x *xargs) finish(name string, code int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	switch {
+	case code == 0:
+	case code == 255:
+		fmt.Fprintf(x.std.Stderr, "xargs: %s: 以状态 255 退出，停止执行\n", name)
+		x.status, x.stopped = 124, true
+	case code == 126 || code == 127:
+		x.status, x.stopped = code, true
+	case x.status == 0:
+		x.status = 123
+	}
+}
+
+func (x *xargs) isStopped() bool {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.stopped
+}
+
+func (c *XargsCommand) Help() string {
+	return `xargs - 用输入中的参数构造并执行命令
+
+用法:
+  xargs [选项] [命令 [初始参数...]]
+
+选项:
+  -n, --max-args=N          每个命令最多使用 N 个参数
+  -L, --max-lines=N         每个命令最多使用 N 行输入
+  -I 字符串                 每行输入执行一次命令，把命令中的字符串替换为该行
+  -0, --null                输入项以 NUL 字符分隔（与 find -print0 配合）
+  -d, --delimiter=字符      输入项以该字符分隔（支持 \n、\t、\0、\xHH）
+  -P, --max-procs=N         最多同时运行 N 个命令（默认 1，0 表示尽量多）
+  -t, --verbose             执行前把命令输出到标准错误
+  -r, --no-run-if-empty     没有输入时不执行命令
+  -s, --max-chars=N         每个命令行最多 N 个字符（默认 131072）
+  -a, --arg-file=文件       从文件读取参数，命令可以使用标准输入
+
+描述:
+  从标准输入读取参数，追加到命令后执行，参数太多时分成多批执行。
+  默认按空白分隔参数，可以用单引号、双引号和反斜杠包含空白；
+  使用 -0 或 -d 时按分隔符分隔，不处理引号。没有指定命令时使用 echo。
+
+  命令可以是函数、内置命令或外部命令。读到足够的参数后立即执行，
+  不会等待输入结束。被执行的命令从 /dev/null 读取标准输入。
+
+退出码:
+  0     所有命令都成功
+  123   有命令以 1-125 的状态退出
+  124   有命令以 255 退出（之后不再执行新的命令）
+  126   命令无法执行
+  127   命令不存在
+
+示例:
+  find . -name '*.tmp' | xargs rm              # 删除找到的文件
+  find . -name '*.go' -print0 | xargs -0 wc -l # 文件名可以包含空格
+  echo a b c d | xargs -n 2 echo               # 每次两个参数
+  ls *.log | xargs -I {} cp {} backup/{}       # 逐个复制
+  cat urls.txt | xargs -P 4 -n 1 curl -O       # 同时下载 4 个
+  echo 1 2 3 | xargs -t -n 1 echo 数字`
+}
+
+func (c *XargsCommand) ShortHelp() string {
+	return "用输入构造并执行命令"
+}
@@ -8,15 +8,14 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/Lingbou/Lish/internal/streams"
 	"github.com/spf13/pflag"
 )
 
-type ZipCommand struct {
-	stdout *os.File
-}
+type ZipCommand struct{}
 
-func NewZipCommand(stdout *os.File) *ZipCommand {
-	return &ZipCommand{stdout: stdout}
+func NewZipCommand() *ZipCommand {
+	return &ZipCommand{}
 }
 
 func (c *ZipCommand) Name() string {
@@ -24,6 +23,8 @@ func (c *ZipCommand) Name() string {
 }
 
 func (c *ZipCommand) Execute(ctx context.Context, args []string) error {
+	stdout := streams.Stdout(ctx)
+
 	flags := pflag.NewFlagSet("zip", pflag.ContinueOnError)
 	recursive := flags.BoolP("recursive", "r", false, "递归压缩目录")
 
@@ -56,7 +57,7 @@ func (c *ZipCommand) Execute(ctx context.Context, args []string) error {
 		}
 	}
 
-	fmt.Fprintf(c.stdout, "✓ 已创建压缩包: %s\n", zipFile)
+	fmt.Fprintf(stdout, "✓ 已创建压缩包: %s\n", zipFile)
 	return nil
 }
 
@@ -15,6 +15,8 @@ type Config struct {
 	Aliases map[string]string `toml:"aliases"`
 	Colors  ColorsConfig      `toml:"colors"`
 	Theme   ThemeConfig       `toml:"theme"`
+
+	path string // 配置文件路径，为空时不写回磁盘
 }
 
 // PromptConfig 提示符配置
@@ -76,14 +78,20 @@ func DefaultConfig() *Config {
 
 // Load 加载配置文件
 func Load() (*Config, error) {
-	cfg := DefaultConfig()
-
 	// 查找配置文件
 	configPath, err := getConfigPath()
 	if err != nil {
-		return cfg, nil // 使用默认配置
+		return DefaultConfig(), nil // 使用默认配置
 	}
 
+	return LoadFile(configPath)
+}
+
+// LoadFile 从指定路径加载配置文件，文件不存在时返回默认配置
+func LoadFile(configPath string) (*Config, error) {
+	cfg := DefaultConfig()
+	cfg.path = configPath
+
 	// 如果配置文件不存在，返回默认配置
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		return cfg, nil
@@ -98,11 +106,13 @@ func Load() (*Config, error) {
 }
 
 // Save 保存配置到文件
+//
+// 未关联配置文件的配置（如使用 --norc 启动）只在当前会话中生效。
 func (c *Config) Save() error {
-	configPath, err := getConfigPath()
-	if err != nil {
-		return err
+	if c.path == "" {
+		return nil
 	}
+	configPath := c.path
 
 	// 创建配置目录
 	configDir := filepath.Dir(configPath)
@@ -136,6 +146,31 @@ func getConfigPath() (string, error) {
 	return filepath.Join(homeDir, ".lishrc"), nil
 }
 
+// InitScriptPath 返回交互式 shell 启动时执行的脚本路径（~/.lish/init.lish）
+func InitScriptPath() (string, error) {
+	return lishDirPath("init.lish")
+}
+
+// ProfileScriptPath 返回登录 shell 启动时执行的脚本路径（~/.lish/profile.lish）
+func ProfileScriptPath() (string, error) {
+	return lishDirPath("profile.lish")
+}
+
+// LibDir 返回默认的脚本模块目录（~/.lish/lib），LISH_PATH 未设置时使用
+func LibDir() (string, error) {
+	return lishDirPath("lib")
+}
+
+// lishDirPath 返回 ~/.lish 目录下的文件路径
+func lishDirPath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(homeDir, ".lish", name), nil
+}
+
 // GetAlias 获取别名
 func (c *Config) GetAlias(name string) (string, bool) {
 	cmd, exists := c.Aliases[name]
@@ -0,0 +1,461 @@
+// Package debugger 为 .lish 脚本提供交互式调试器
+//
+// 调试器作为 script.Tracer 挂接到执行器上，在每条语句执行之前检查
+// 断点和单步状态，需要暂停时读取并执行调试命令，直到用户继续执行。
+package debugger
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/script"
+)
+
+// ErrQuit 用户在调试器中退出时返回的错误
+var ErrQuit = errors.New("调试器: 已退出")
+
+// stepMode 单步执行的方式
+type stepMode int
+
+const (
+	modeContinue stepMode = iota // 运行到下一个断点
+	modeStep                     // 在下一条语句暂停（进入函数）
+	modeNext                     // 在当前函数的下一条语句暂停（不进入函数）
+	modeFinish                   // 运行到当前函数返回
+)
+
+// breakpoint 行断点或函数断点
+type breakpoint struct {
+	id       int
+	file     string // 行断点的文件，为空表示被调试的脚本
+	line     int
+	function string // 函数断点的函数名
+	hits     int
+}
+
+func (b *breakpoint) String() string {
+	if b.function != "" {
+		return fmt.Sprintf("#%d 函数 %s（命中 %d 次）", b.id, b.function, b.hits)
+	}
+	file := b.file
+	if file == "" {
+		file = "<脚本>"
+	}
+	return fmt.Sprintf("#%d %s:%d（命中 %d 次）", b.id, file, b.line, b.hits)
+}
+
+// Debugger 交互式脚本调试器
+type Debugger struct {
+	script string // 被调试的脚本
+	in     *bufio.Reader
+	out    io.Writer
+
+	breakpoints []*breakpoint
+	nextID      int
+
+	mode      stepMode
+	stepDepth int // next 和 finish 开始时的调用深度
+	lastDepth int // 上一条语句的调用深度，用于识别函数入口
+	lastCmd   string
+	detached  bool // 输入结束后不再暂停
+
+	sources map[string][]string // 已读取的源文件
+}
+
+// New 创建调试器，script 是被调试的脚本文件，调试命令从 in 读取
+//
+// 调试器创建后处于单步模式，在脚本的第一条语句暂停。
+func New(script string, in io.Reader, out io.Writer) *Debugger {
+	return &Debugger{
+		script:  script,
+		in:      bufio.NewReader(in),
+		out:     out,
+		nextID:  1,
+		mode:    modeStep,
+		sources: make(map[string][]string),
+	}
+}
+
+// BeforeStatement 实现 script.Tracer，决定是否在语句之前暂停
+func (d *Debugger) BeforeStatement(ctx context.Context, e *script.Executor, stmt script.Statement) error {
+	if d.detached {
+		return nil
+	}
+
+	depth := e.CallDepth()
+	frame := e.CallStack()[0]
+	entered := depth > d.lastDepth
+	d.lastDepth = depth
+
+	reason := ""
+	switch {
+	case d.mode == modeStep:
+		reason = "单步"
+	case d.mode == modeNext && depth <= d.stepDepth:
+		reason = "单步"
+	case d.mode == modeFinish && depth < d.stepDepth:
+		reason = "函数返回"
+	}
+
+	for _, b := range d.breakpoints {
+		if d.hit(b, frame, entered) {
+			b.hits++
+			reason = fmt.Sprintf("断点 #%d", b.id)
+			break
+		}
+	}
+
+	if reason == "" {
+		return nil
+	}
+	return d.pause(ctx, e, stmt, reason)
+}
+
+// hit 判断断点是否在当前位置命中
+func (d *Debugger) hit(b *breakpoint, frame script.Frame, entered bool) bool {
+	if b.function != "" {
+		return entered && frame.Function == b.function
+	}
+	return frame.Line == b.line && d.sameFile(b.file, frame.File)
+}
+
+// sameFile 判断断点的文件是否就是当前执行的文件
+func (d *Debugger) sameFile(bpFile, current string) bool {
+	if bpFile == "" {
+		bpFile = d.script
+	}
+	if bpFile == current {
+		return true
+	}
+	a, errA := filepath.Abs(bpFile)
+	b, errB := filepath.Abs(current)
+	if errA == nil && errB == nil && a == b {
+		return true
+	}
+	// 只写了文件名时按文件名匹配
+	return !strings.ContainsRune(bpFile, filepath.Separator) && filepath.Base(current) == bpFile
+}
+
+// pause 显示当前位置并读取调试命令，直到继续执行
+func (d *Debugger) pause(ctx context.Context, e *script.Executor, stmt script.Statement, reason string) error {
+	frame := e.CallStack()[0]
+	fmt.Fprintf(d.out, "[%s] %s\n", reason, d.location(frame))
+	d.showLine(frame.File, frame.Line)
+
+	for {
+		fmt.Fprint(d.out, "(ldb) ")
+		line, err := d.in.ReadString('\n')
+		if err != nil && line == "" {
+			// 输入结束，不再暂停，运行到脚本结束
+			fmt.Fprintln(d.out)
+			d.detached = true
+			return nil
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			line = d.lastCmd
+		}
+		if line == "" {
+			continue
+		}
+		d.lastCmd = line
+
+		resume, err := d.command(ctx, e, line)
+		if err != nil {
+			return err
+		}
+		if resume {
+			return nil
+		}
+	}
+}
+
+// command 执行一条调试命令，返回是否继续执行脚本
+func (d *Debugger) command(ctx context.Context, e *script.Executor, line string) (bool, error) {
+	name, arg := line, ""
+	if idx := strings.IndexAny(line, " \t"); idx >= 0 {
+		name, arg = line[:idx], strings.TrimSpace(line[idx+1:])
+	}
+	depth := e.CallDepth()
+
+	switch name {
+	case "s", "step":
+		d.mode = modeStep
+		return true, nil
+	case "n", "next":
+		d.mode, d.stepDepth = modeNext, depth
+		return true, nil
+	case "f", "finish":
+		if depth == 0 {
+			fmt.Fprintln(d.out, "finish: 不在函数中")
+			return false, nil
+		}
+		d.mode, d.stepDepth = modeFinish, depth
+		return true, nil
+	case "c", "continue":
+		d.mode = modeContinue
+		return true, nil
+	case "q", "quit":
+		return false, ErrQuit
+	case "b", "break":
+		d.addBreakpoint(arg)
+	case "d", "delete":
+		d.deleteBreakpoint(arg)
+	case "i", "info":
+		d.listBreakpoints()
+	case "p", "print":
+		d.print(ctx, e, arg)
+	case "v", "vars":
+		d.showScopes(e, arg == "-a")
+	case "bt", "where", "backtrace":
+		d.backtrace(e)
+	case "l", "list":
+		frame := e.CallStack()[0]
+		d.list(frame.File, frame.Line)
+	case "e", "eval":
+		d.eval(ctx, e, arg)
+	case "h", "help", "?":
+		fmt.Fprint(d.out, helpText)
+	default:
+		fmt.Fprintf(d.out, "未知的调试命令: %s（输入 help 查看帮助）\n", name)
+	}
+	return false, nil
+}
+
+// addBreakpoint 添加断点：行号、文件:行号或函数名
+func (d *Debugger) addBreakpoint(arg string) {
+	if arg == "" {
+		fmt.Fprintln(d.out, "用法: break <行号> | <文件>:<行号> | <函数名>")
+		return
+	}
+
+	b := &breakpoint{id: d.nextID}
+	file, lineText := "", arg
+	if idx := strings.LastIndex(arg, ":"); idx > 0 {
+		file, lineText = arg[:idx], arg[idx+1:]
+	}
+
+	if n, err := strconv.Atoi(lineText); err == nil && n > 0 {
+		b.file, b.line = file, n
+	} else if file == "" && script.IsValidName(strings.ReplaceAll(arg, ".", "_")) {
+		b.function = arg
+	} else {
+		fmt.Fprintf(d.out, "break: 无效的位置: %s\n", arg)
+		return
+	}
+
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, b)
+	fmt.Fprintf(d.out, "已设置断点 %s\n", b)
+}
+
+// deleteBreakpoint 删除指定编号的断点，不指定时删除全部
+func (d *Debugger) deleteBreakpoint(arg string) {
+	if arg == "" {
+		d.breakpoints = nil
+		fmt.Fprintln(d.out, "已删除所有断点")
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil {
+		fmt.Fprintf(d.out, "delete: 无效的断点编号: %s\n", arg)
+		return
+	}
+	for i, b := range d.breakpoints {
+		if b.id == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			fmt.Fprintf(d.out, "已删除断点 #%d\n", id)
+			return
+		}
+	}
+	fmt.Fprintf(d.out, "delete: 没有断点 #%d\n", id)
+}
+
+// listBreakpoints 列出所有断点
+func (d *Debugger) listBreakpoints() {
+	if len(d.breakpoints) == 0 {
+		fmt.Fprintln(d.out, "没有断点")
+		return
+	}
+	for _, b := range d.breakpoints {
+		fmt.Fprintln(d.out, b)
+	}
+}
+
+// print 在当前环境中展开表达式，参数是变量名时直接显示变量
+func (d *Debugger) print(ctx context.Context, e *script.Executor, arg string) {
+	if arg == "" {
+		fmt.Fprintln(d.out, "用法: print <变量名> | <单词>（如 print \"$a-$b\"）")
+		return
+	}
+	if script.IsValidName(arg) {
+		value, ok := e.GetVariable(arg)
+		if !ok {
+			fmt.Fprintf(d.out, "%s 未定义\n", arg)
+			return
+		}
+		fmt.Fprintf(d.out, "%s=%s\n", arg, strconv.Quote(value))
+		return
+	}
+	fmt.Fprintln(d.out, strconv.Quote(e.ExpandWord(ctx, arg)))
+}
+
+// eval 在当前环境中执行一行命令，执行期间不暂停
+func (d *Debugger) eval(ctx context.Context, e *script.Executor, source string) {
+	if source == "" {
+		fmt.Fprintln(d.out, "用法: eval <命令>")
+		return
+	}
+
+	tracer := e.Tracer()
+	e.SetTracer(nil)
+	defer e.SetTracer(tracer)
+
+	if err := e.ExecuteLine(ctx, source); err != nil {
+		fmt.Fprintf(d.out, "eval: %v\n", err)
+	}
+}
+
+// showScopes 沿作用域链显示变量，全局作用域默认不显示环境变量
+func (d *Debugger) showScopes(e *script.Executor, all bool) {
+	stack := e.CallStack()
+	level := 0
+	for scope := e.Variables().CurrentScope(); scope != nil; scope = scope.Parent() {
+		label := "全局作用域"
+		if scope.Parent() != nil {
+			name := ""
+			if level < len(stack) {
+				name = stack[level].Function
+			}
+			label = fmt.Sprintf("函数 %s 的作用域", name)
+		}
+		fmt.Fprintf(d.out, "%s:\n", label)
+		level++
+
+		if params := scope.Params(); params != nil {
+			fmt.Fprintf(d.out, "  $@ = %s\n", quoteList(params[1:]))
+		}
+
+		vars := scope.Variables()
+		names := make([]string, 0, len(vars))
+		for name, v := range vars {
+			if scope.Parent() == nil && v.Exported && !all {
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Fprintf(d.out, "  %s=%s\n", name, strconv.Quote(vars[name].Value))
+		}
+		if len(names) == 0 {
+			fmt.Fprintln(d.out, "  （无）")
+		}
+	}
+}
+
+// backtrace 显示调用栈
+func (d *Debugger) backtrace(e *script.Executor) {
+	for i, frame := range e.CallStack() {
+		fmt.Fprintf(d.out, "#%d %s\n", i, d.location(frame))
+	}
+}
+
+// location 描述调用栈中一帧的位置
+func (d *Debugger) location(frame script.Frame) string {
+	file := frame.File
+	if file == "" {
+		file = "<输入>"
+	}
+	if frame.Function == "" {
+		return fmt.Sprintf("%s:%d", file, frame.Line)
+	}
+	return fmt.Sprintf("%s() 于 %s:%d", frame.Function, file, frame.Line)
+}
+
+// showLine 显示源文件中的一行
+func (d *Debugger) showLine(file string, line int) {
+	lines := d.source(file)
+	if line >= 1 && line <= len(lines) {
+		fmt.Fprintf(d.out, "%5d\t%s\n", line, lines[line-1])
+	}
+}
+
+// list 显示当前行附近的源码
+func (d *Debugger) list(file string, line int) {
+	lines := d.source(file)
+	if len(lines) == 0 {
+		fmt.Fprintln(d.out, "list: 没有可显示的源码")
+		return
+	}
+
+	start, end := line-5, line+5
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	for i := start; i <= end; i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+		fmt.Fprintf(d.out, "%s%4d\t%s\n", marker, i, lines[i-1])
+	}
+}
+
+// source 读取并缓存源文件的内容
+func (d *Debugger) source(file string) []string {
+	if file == "" {
+		return nil
+	}
+	if lines, ok := d.sources[file]; ok {
+		return lines
+	}
+
+	var lines []string
+	if content, err := os.ReadFile(file); err == nil {
+		lines = strings.Split(string(content), "\n")
+	}
+	d.sources[file] = lines
+	return lines
+}
+
+// quoteList 以带引号的形式显示参数列表
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = strconv.Quote(item)
+	}
+	return "(" + strings.Join(quoted, " ") + ")"
+}
+
+const helpText = `调试命令:
+  s, step              执行下一条语句（进入函数）
+  n, next              执行下一条语句（不进入函数）
+  f, finish            运行到当前函数返回
+  c, continue          运行到下一个断点
+  b, break <位置>      设置断点：<行号>、<文件>:<行号> 或 <函数名>
+  d, delete [编号]     删除断点，不指定编号时删除全部
+  i, info              列出断点
+  p, print <表达式>    显示变量或展开单词（如 print "$a-$b"、print $(pwd)）
+  v, vars [-a]         沿作用域链显示变量（-a 同时显示环境变量）
+  bt, where            显示调用栈
+  l, list              显示当前行附近的源码
+  e, eval <命令>       在当前环境中执行命令（可以修改变量）
+  q, quit              中止脚本
+  h, help              显示此帮助
+  直接回车重复上一条命令。
+`
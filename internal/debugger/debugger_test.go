@@ -0,0 +1,150 @@
+package debugger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+)
+
+const testScript = `function greet() {
+	echo "hi $1"
+}
+x=1
+echo a
+greet bob
+echo b
+`
+
+// echoCommands 只支持 echo 的命令执行器
+type echoCommands struct{}
+
+func (echoCommands) ExecuteCommand(ctx context.Context, command string, args []string) error {
+	if command != "echo" {
+		return fmt.Errorf("未知命令: %s", command)
+	}
+	fmt.Fprintln(streams.Stdout(ctx), strings.Join(args, " "))
+	return nil
+}
+
+// debug 用给定的调试命令调试测试脚本，返回调试器输出、脚本输出和错误
+//
+// 调试器输出中的脚本路径替换为 t.lish。
+func debug(t *testing.T, commands string) (string, string, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "t.lish")
+	if err := os.WriteFile(path, []byte(testScript), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out, stdout bytes.Buffer
+	e := script.NewExecutor(echoCommands{})
+	e.SetTracer(New(path, strings.NewReader(commands), &out))
+	ctx := streams.With(context.Background(), &streams.Streams{Stdin: strings.NewReader(""), Stdout: &stdout, Stderr: &stdout})
+	err := e.ExecuteFile(ctx, path, nil)
+	return strings.ReplaceAll(out.String(), path, "t.lish"), stdout.String(), err
+}
+
+// pauses 返回调试器输出中暂停位置的行
+func pauses(out string) []string {
+	var result []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimPrefix(line, "(ldb) ")
+		if strings.HasPrefix(line, "[") {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+func TestStepping(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands string
+		want     []string
+	}{
+		{"pauses at the first statement", "c\n", []string{"[单步] t.lish:1"}},
+		{"end of input detaches", "", []string{"[单步] t.lish:1"}},
+		{"line breakpoint", "b 7\nc\nc\n", []string{"[单步] t.lish:1", "[断点 #1] t.lish:7"}},
+		{"file and line breakpoint", "b t.lish:5\nc\nc\n", []string{"[单步] t.lish:1", "[断点 #1] t.lish:5"}},
+		{"function breakpoint", "b greet\nc\nc\n", []string{"[单步] t.lish:1", "[断点 #1] greet() 于 t.lish:2"}},
+		{"step", "s\ns\nc\n", []string{"[单步] t.lish:1", "[单步] t.lish:4", "[单步] t.lish:5"}},
+		{"step enters function", "b 6\nc\ns\nc\n", []string{"[单步] t.lish:1", "[断点 #1] t.lish:6", "[单步] greet() 于 t.lish:2"}},
+		{"next skips function body", "b 6\nc\nn\nc\n", []string{"[单步] t.lish:1", "[断点 #1] t.lish:6", "[单步] t.lish:7"}},
+		{"finish", "b greet\nc\nf\nc\n", []string{"[单步] t.lish:1", "[断点 #1] greet() 于 t.lish:2", "[函数返回] t.lish:7"}},
+		{"enter repeats the last command", "s\n\nc\n", []string{"[单步] t.lish:1", "[单步] t.lish:4", "[单步] t.lish:5"}},
+		{"deleted breakpoint", "b 7\nd 1\nc\n", []string{"[单步] t.lish:1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, stdout, err := debug(t, tt.commands)
+			if err != nil {
+				t.Fatalf("执行出错: %v", err)
+			}
+			if got := pauses(out); !slices.Equal(got, tt.want) {
+				t.Errorf("pauses = %q, want %q", got, tt.want)
+			}
+			if want := "a\nhi bob\nb\n"; stdout != want {
+				t.Errorf("stdout = %q, want %q", stdout, want)
+			}
+		})
+	}
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands string
+		want     string
+	}{
+		{"print variable", "b 5\nc\np x\nc\n", `x="1"`},
+		{"print undefined", "p x\nc\n", "x 未定义"},
+		{"print word", "b 5\nc\np \"[$x]\"\nc\n", `"[1]"`},
+		{"backtrace", "b greet\nc\nbt\nc\n", "#0 greet() 于 t.lish:2\n#1 t.lish:6"},
+		{"function scope", "b greet\nc\nv\nc\n", "函数 greet 的作用域:\n  $@ = (\"bob\")"},
+		{"list breakpoints", "b 7\nb greet\ni\nc\nc\nc\n", "#1 <脚本>:7（命中 0 次）\n#2 函数 greet（命中 0 次）"},
+		{"invalid breakpoint", "b 1x:y\nc\n", "break: 无效的位置: 1x:y"},
+		{"unknown command", "zz\nc\n", "未知的调试命令: zz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, _, err := debug(t, tt.commands)
+			if err != nil {
+				t.Fatalf("执行出错: %v", err)
+			}
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output does not contain %q:\n%s", tt.want, out)
+			}
+		})
+	}
+}
+
+func TestEvalChangesVariables(t *testing.T) {
+	_, stdout, err := debug(t, "b 6\nc\ne x=2\ne echo $x\nc\n")
+	if err != nil {
+		t.Fatalf("执行出错: %v", err)
+	}
+	if want := "a\n2\nhi bob\nb\n"; stdout != want {
+		t.Errorf("stdout = %q, want %q", stdout, want)
+	}
+}
+
+func TestQuit(t *testing.T) {
+	_, stdout, err := debug(t, "b 6\nc\nq\n")
+	if !errors.Is(err, ErrQuit) {
+		t.Errorf("err = %v, want ErrQuit", err)
+	}
+	if stdout != "a\n" {
+		t.Errorf("stdout = %q, want %q", stdout, "a\n")
+	}
+}
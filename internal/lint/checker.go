@@ -0,0 +1,525 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Lingbou/Lish/internal/script"
+)
+
+// knownVariables 由 shell 设置或读取的变量，脚本中没有赋值也不报告
+var knownVariables = map[string]bool{
+	"IFS":       true,
+	"LISH_PATH": true,
+	"FUNCNEST":  true,
+	"HOME":      true,
+	"PATH":      true,
+	"PWD":       true,
+	"OLDPWD":    true,
+	"OPTARG":    true,
+	"OPTIND":    true,
+	"OPTERR":    true,
+}
+
+// declaringCommands 参数中可以定义变量的命令（如 export NAME=value）
+var declaringCommands = map[string]bool{
+	"export":   true,
+	"declare":  true,
+	"readonly": true,
+}
+
+// checker 保存一次检查的状态
+//
+// 检查分两遍进行：第一遍收集脚本（以及 source/import 的模块）中定义的
+// 函数和变量，与定义出现的位置无关；第二遍逐条检查语句。
+type checker struct {
+	file  string
+	env   Env
+	diags []Diagnostic
+
+	functions       map[string][]int // 脚本中定义的函数 -> 定义所在的行
+	moduleFunctions map[string]bool  // 模块中定义的函数（带命名空间前缀）
+	variables       map[string]bool  // 赋值过的变量
+	modules         map[string]bool  // 已收集过定义的模块
+	undefined       map[string]bool  // 已报告过的未定义变量
+
+	loopDepth  int
+	lineOffset int // 检查命令替换内部的语句时加到行号上
+}
+
+// newChecker 创建检查器
+func newChecker(file string, env Env) *checker {
+	return &checker{
+		file:            file,
+		env:             env,
+		functions:       make(map[string][]int),
+		moduleFunctions: make(map[string]bool),
+		variables:       make(map[string]bool),
+		modules:         make(map[string]bool),
+		undefined:       make(map[string]bool),
+	}
+}
+
+// report 记录一个问题
+func (c *checker) report(line int, severity Severity, rule, format string, args ...interface{}) {
+	c.diags = append(c.diags, Diagnostic{
+		File:     c.file,
+		Line:     line + c.lineOffset,
+		Severity: severity,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// ============================================================================
+// 收集定义
+// ============================================================================
+
+// collect 收集语句中定义的函数和变量，namespace 非空表示来自带命名空间的模块
+func (c *checker) collect(stmts []script.Statement, namespace string) {
+	walk(stmts, func(stmt script.Statement) {
+		switch s := stmt.(type) {
+		case *script.FunctionDef:
+			c.collectFunction(s, namespace)
+		case *script.AssignStatement:
+			c.variables[s.Name] = true
+		case *script.LocalStatement:
+			for _, v := range s.Vars {
+				c.variables[v.Name] = true
+			}
+		case *script.ForStatement:
+			c.variables[s.Variable] = true
+		case *script.TryStatement:
+			if s.CatchVar != "" {
+				for _, name := range script.CaughtVariables(s.CatchVar) {
+					c.variables[name] = true
+				}
+			}
+		case *script.ImportStatement:
+			if name, ok := literal(s.Module); ok {
+				c.collectModule(name, s.Namespace)
+			}
+		case *script.CommandStatement:
+			c.collectCommand(s)
+		}
+	})
+}
+
+// collectFunction 记录函数定义
+func (c *checker) collectFunction(fn *script.FunctionDef, namespace string) {
+	if namespace != "" {
+		c.moduleFunctions[namespace+"."+fn.Name] = true
+		return
+	}
+	c.functions[fn.Name] = append(c.functions[fn.Name], fn.StartLine())
+}
+
+// collectCommand 记录 export 等命令定义的变量和 source 加载的模块
+func (c *checker) collectCommand(cmd *script.CommandStatement) {
+	name, ok := literal(cmd.Command)
+	if !ok {
+		return
+	}
+
+	switch {
+	case declaringCommands[name]:
+		for _, arg := range cmd.Args {
+			text, _ := script.ScanWord(arg)
+			if strings.HasPrefix(text, "-") {
+				continue
+			}
+			if idx := strings.Index(text, "="); idx >= 0 {
+				text = text[:idx]
+			}
+			if script.IsValidName(text) {
+				c.variables[text] = true
+			}
+		}
+	case name == "getopts":
+		if v, ok := getoptsVariable(cmd); ok {
+			c.variables[v] = true
+		}
+	case name == "source" || name == ".":
+		if module, ok := sourceTarget(cmd); ok {
+			c.collectModule(module, "")
+		}
+	}
+}
+
+// collectModule 解析模块并收集其中的定义，同一模块只处理一次
+func (c *checker) collectModule(name, namespace string) {
+	if c.env.FindScript == nil {
+		return
+	}
+	path, err := c.env.FindScript(name)
+	if err != nil {
+		return
+	}
+
+	key := namespace + ":" + path
+	if c.modules[key] {
+		return
+	}
+	c.modules[key] = true
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	program, err := script.Parse(string(content))
+	if err != nil {
+		return
+	}
+
+	// 模块中不带命名空间的函数同样可以直接调用
+	if namespace == "" {
+		walk(program.Statements, func(stmt script.Statement) {
+			if fn, ok := stmt.(*script.FunctionDef); ok {
+				c.moduleFunctions[fn.Name] = true
+			}
+		})
+		namespace = "\x00" // 仅用于跳过 collectFunction 中的行号记录
+	}
+	c.collect(program.Statements, namespace)
+}
+
+// ============================================================================
+// 检查语句
+// ============================================================================
+
+// checkBlock 检查语句块，return、break、continue 之后的语句不可达
+func (c *checker) checkBlock(block []script.Statement) {
+	stopper := ""
+	reported := false
+
+	for _, stmt := range block {
+		if stopper != "" && !reported {
+			c.report(stmt.StartLine(), SeverityWarning, RuleUnreachableCode,
+				"'%s' 之后的代码不会执行", stopper)
+			reported = true
+		}
+
+		c.checkStatement(stmt)
+
+		if stopper == "" {
+			switch stmt.(type) {
+			case *script.ReturnStatement:
+				stopper = "return"
+			case *script.BreakStatement:
+				stopper = "break"
+			case *script.ContinueStatement:
+				stopper = "continue"
+			}
+		}
+	}
+}
+
+// checkStatement 根据语句类型检查
+func (c *checker) checkStatement(stmt script.Statement) {
+	line := stmt.StartLine()
+
+	switch s := stmt.(type) {
+	case *script.CommandStatement:
+		c.checkCommand(s)
+	case *script.PipelineStatement:
+		for _, cmd := range s.Commands {
+			c.checkStatement(cmd)
+		}
+	case *script.AssignStatement:
+		c.checkExpression(line, s.Value)
+	case *script.LocalStatement:
+		for _, v := range s.Vars {
+			if v.Value != nil {
+				c.checkExpression(line, v.Value)
+			}
+		}
+	case *script.IfStatement:
+		c.checkExpression(line, s.Condition)
+		c.checkBlock(s.ThenBlock)
+		for _, elif := range s.ElseIfList {
+			c.checkExpression(line, elif.Condition)
+			c.checkBlock(elif.Block)
+		}
+		c.checkBlock(s.ElseBlock)
+	case *script.ForStatement:
+		for _, word := range s.List {
+			c.checkWord(line, word, false)
+		}
+		c.checkLoop(s.Block)
+	case *script.WhileStatement:
+		c.checkExpression(line, s.Condition)
+		c.checkLoop(s.Block)
+	case *script.TryStatement:
+		c.checkBlock(s.Block)
+		c.checkBlock(s.CatchBlock)
+		c.checkBlock(s.FinallyBlock)
+	case *script.FunctionDef:
+		c.checkFunction(s)
+	case *script.ReturnStatement:
+		if s.Value != nil {
+			c.checkExpression(line, s.Value)
+		}
+	case *script.BreakStatement:
+		c.checkLoopControl(line, "break")
+	case *script.ContinueStatement:
+		c.checkLoopControl(line, "continue")
+	case *script.ImportStatement:
+		if name, ok := literal(s.Module); ok {
+			c.checkModule(line, name)
+		}
+	}
+}
+
+// checkCommand 检查命令名、参数和重定向
+func (c *checker) checkCommand(cmd *script.CommandStatement) {
+	line := cmd.StartLine()
+
+	c.checkWord(line, cmd.Command, false)
+	for _, arg := range cmd.Args {
+		c.checkWord(line, arg, true)
+	}
+	for _, r := range cmd.Redirects {
+		if r.Target != "" {
+			c.checkWord(line, r.Target, false)
+		}
+	}
+
+	name, ok := literal(cmd.Command)
+	if !ok || name == "" || strings.ContainsRune(name, '/') {
+		return
+	}
+
+	if (name == "source" || name == ".") && c.env.FindScript != nil {
+		if module, ok := sourceTarget(cmd); ok {
+			c.checkModule(line, module)
+		}
+	}
+
+	if !c.commandExists(name) {
+		c.report(line, SeverityWarning, RuleUnknownCommand,
+			"未知命令: %s（不是函数、内置命令，也不在 PATH 中）", name)
+	}
+}
+
+// commandExists 判断命令是否是已知的函数、内置命令或外部命令
+func (c *checker) commandExists(name string) bool {
+	if len(c.functions[name]) > 0 || c.moduleFunctions[name] {
+		return true
+	}
+	if c.env.IsFunction != nil && c.env.IsFunction(name) {
+		return true
+	}
+	return c.env.IsCommand == nil || c.env.IsCommand(name)
+}
+
+// checkModule 检查 source 或 import 的模块能否找到
+func (c *checker) checkModule(line int, name string) {
+	if c.env.FindScript == nil {
+		return
+	}
+	if _, err := c.env.FindScript(name); err != nil {
+		c.report(line, SeverityWarning, RuleMissingModule, "找不到模块: %v", err)
+	}
+}
+
+// checkFunction 检查函数定义：覆盖同名函数或命令，以及函数体
+func (c *checker) checkFunction(fn *script.FunctionDef) {
+	line := fn.StartLine()
+
+	if lines := c.functions[fn.Name]; len(lines) > 0 && lines[0] != line {
+		c.report(line, SeverityWarning, RuleShadowedFunction,
+			"函数 %s 覆盖了第 %d 行定义的同名函数", fn.Name, lines[0]+c.lineOffset)
+	} else if c.env.IsCommand != nil && c.env.IsCommand(fn.Name) {
+		c.report(line, SeverityWarning, RuleShadowedFunction,
+			"函数 %s 覆盖了同名命令", fn.Name)
+	}
+
+	// 函数体中的 break 不能跳出调用者的循环
+	saved := c.loopDepth
+	c.loopDepth = 0
+	c.checkBlock(fn.Block)
+	c.loopDepth = saved
+}
+
+// checkLoop 检查循环体
+func (c *checker) checkLoop(block []script.Statement) {
+	c.loopDepth++
+	c.checkBlock(block)
+	c.loopDepth--
+}
+
+// checkLoopControl 检查 break/continue 是否位于循环中
+func (c *checker) checkLoopControl(line int, keyword string) {
+	if c.loopDepth == 0 {
+		c.report(line, SeverityError, RuleBreakOutsideLoop, "'%s' 只能在循环中使用", keyword)
+	}
+}
+
+// checkExpression 检查条件或赋值表达式中的单词
+//
+// 赋值和 [ ] 中的参数不做字段分割，因此只检查未定义的变量。
+func (c *checker) checkExpression(line int, expr script.Expression) {
+	switch e := expr.(type) {
+	case *script.StringLiteral:
+		c.checkWord(line, e.Value, false)
+	case *script.TestExpr:
+		for _, arg := range e.Args {
+			c.checkWord(line, arg, false)
+		}
+	case *script.BinaryExpr:
+		c.checkExpression(line, e.Left)
+		c.checkExpression(line, e.Right)
+	case *script.UnaryExpr:
+		c.checkExpression(line, e.Operand)
+	case *script.CommandCondition:
+		c.checkStatement(e.Command)
+	}
+}
+
+// checkWord 检查单词中的变量引用和命令替换，splits 表示展开结果会按 IFS 分割
+func (c *checker) checkWord(line int, word string, splits bool) {
+	_, refs := script.ScanWord(word)
+	for _, ref := range refs {
+		if ref.Substitution {
+			c.checkSubstitution(line, ref.Command)
+			if splits && !ref.Quoted {
+				c.report(line, SeverityWarning, RuleUnquotedExpansion,
+					"未加引号的 $(...) 会按空白分割，建议写成 \"$(...)\"")
+			}
+			continue
+		}
+
+		c.checkVariable(line, ref.Name)
+		if splits && !ref.Quoted && !isNumericParam(ref.Name) {
+			c.report(line, SeverityWarning, RuleUnquotedExpansion,
+				"未加引号的 $%s 会按空白分割，建议写成 \"$%s\"", ref.Name, ref.Name)
+		}
+	}
+}
+
+// checkVariable 检查变量是否有定义，每个变量只报告一次
+func (c *checker) checkVariable(line int, name string) {
+	if isSpecialParam(name) || c.variables[name] || knownVariables[name] || c.undefined[name] {
+		return
+	}
+	if c.env.IsVariable != nil && c.env.IsVariable(name) {
+		return
+	}
+
+	c.undefined[name] = true
+	c.report(line, SeverityWarning, RuleUndefinedVariable, "变量 $%s 没有定义", name)
+}
+
+// checkSubstitution 检查命令替换中的命令，它们在子 shell 中执行
+func (c *checker) checkSubstitution(line int, source string) {
+	program, err := script.Parse(source)
+	if err != nil {
+		if parseErr, ok := err.(*script.ParseError); ok && len(parseErr.Details) > 0 {
+			c.report(line, SeverityError, RuleSyntax, "命令替换中: %s", parseErr.Details[0].Message)
+		}
+		return
+	}
+
+	savedOffset, savedDepth := c.lineOffset, c.loopDepth
+	c.lineOffset += line - 1
+	c.loopDepth = 0
+	c.checkBlock(program.Statements)
+	c.lineOffset, c.loopDepth = savedOffset, savedDepth
+}
+
+// ============================================================================
+// 辅助函数
+// ============================================================================
+
+// walk 依次访问语句及其中嵌套的所有语句
+func walk(stmts []script.Statement, visit func(script.Statement)) {
+	for _, stmt := range stmts {
+		visit(stmt)
+
+		switch s := stmt.(type) {
+		case *script.IfStatement:
+			walk(conditionCommands(s.Condition), visit)
+			walk(s.ThenBlock, visit)
+			for _, elif := range s.ElseIfList {
+				walk(conditionCommands(elif.Condition), visit)
+				walk(elif.Block, visit)
+			}
+			walk(s.ElseBlock, visit)
+		case *script.ForStatement:
+			walk(s.Block, visit)
+		case *script.WhileStatement:
+			walk(conditionCommands(s.Condition), visit)
+			walk(s.Block, visit)
+		case *script.TryStatement:
+			walk(s.Block, visit)
+			walk(s.CatchBlock, visit)
+			walk(s.FinallyBlock, visit)
+		case *script.FunctionDef:
+			walk(s.Block, visit)
+		case *script.PipelineStatement:
+			walk(s.Commands, visit)
+		}
+	}
+}
+
+// conditionCommands 返回条件中作为命令执行的语句
+func conditionCommands(expr script.Expression) []script.Statement {
+	switch e := expr.(type) {
+	case *script.CommandCondition:
+		return []script.Statement{e.Command}
+	case *script.BinaryExpr:
+		return append(conditionCommands(e.Left), conditionCommands(e.Right)...)
+	case *script.UnaryExpr:
+		return conditionCommands(e.Operand)
+	}
+	return nil
+}
+
+// getoptsVariable 返回 getopts 写入的变量名（跳过 -l 长选项列表和选项字符串）
+func getoptsVariable(cmd *script.CommandStatement) (string, bool) {
+	args := cmd.Args
+	if len(args) > 0 && (args[0] == "-l" || args[0] == "--long") {
+		args = args[min(2, len(args)):]
+	} else if len(args) > 0 && strings.HasPrefix(args[0], "--long=") {
+		args = args[1:]
+	}
+	if len(args) < 2 {
+		return "", false
+	}
+	return literal(args[1])
+}
+
+// literal 返回去除引号后的单词，单词包含展开时返回 false
+func literal(word string) (string, bool) {
+	text, refs := script.ScanWord(word)
+	return text, len(refs) == 0
+}
+
+// sourceTarget 返回 source 命令加载的脚本名（跳过选项）
+func sourceTarget(cmd *script.CommandStatement) (string, bool) {
+	for _, arg := range cmd.Args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		return literal(arg)
+	}
+	return "", false
+}
+
+// isSpecialParam 判断是否是特殊参数或位置参数
+func isSpecialParam(name string) bool {
+	if len(name) == 1 && strings.Contains("#?@*$!-", name) {
+		return true
+	}
+	return name != "" && strings.Trim(name, "0123456789") == ""
+}
+
+// isNumericParam 判断参数的值是否总是数字，不会被分割
+func isNumericParam(name string) bool {
+	switch name {
+	case "#", "?", "$", "!":
+		return true
+	}
+	return false
+}
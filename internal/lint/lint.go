@@ -0,0 +1,179 @@
+// Package lint 对 .lish 脚本进行静态检查
+//
+// 检查只解析脚本而不执行，报告语法错误以及运行时才会暴露的常见问题：
+// 未定义的变量、会被分割的未加引号展开、不可达代码、循环外的
+// break/continue、找不到的命令和覆盖已有命令的函数。
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/Lingbou/Lish/internal/script"
+)
+
+// Severity 问题的严重程度
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+// String 返回严重程度的名称（用于 JSON 和命令行选项）
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// label 返回严重程度的中文名称（用于文本输出）
+func (s Severity) label() string {
+	if s == SeverityError {
+		return "错误"
+	}
+	return "警告"
+}
+
+// MarshalJSON 以名称输出严重程度
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// ParseSeverity 解析严重程度名称（error 或 warning）
+func ParseSeverity(name string) (Severity, error) {
+	switch name {
+	case "error":
+		return SeverityError, nil
+	case "warning":
+		return SeverityWarning, nil
+	default:
+		return SeverityWarning, fmt.Errorf("无效的严重程度: %s（可选 error、warning）", name)
+	}
+}
+
+// 检查规则名称
+const (
+	RuleSyntax            = "syntax"
+	RuleUndefinedVariable = "undefined-variable"
+	RuleUnquotedExpansion = "unquoted-expansion"
+	RuleUnreachableCode   = "unreachable-code"
+	RuleBreakOutsideLoop  = "break-outside-loop"
+	RuleUnknownCommand    = "unknown-command"
+	RuleMissingModule     = "missing-module"
+	RuleShadowedFunction  = "shadowed-function"
+)
+
+// Diagnostic 检查发现的一个问题
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Message  string   `json:"message"`
+}
+
+// String 以 file:line: 级别: 信息 [规则] 的形式显示问题
+func (d Diagnostic) String() string {
+	pos := fmt.Sprintf("%s:%d", d.File, d.Line)
+	if d.Column > 0 {
+		pos += fmt.Sprintf(":%d", d.Column)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", pos, d.Severity.label(), d.Message, d.Rule)
+}
+
+// Env 检查时需要的外部信息
+type Env struct {
+	IsCommand  func(name string) bool            // 内置命令或 PATH 中的程序
+	IsFunction func(name string) bool            // 当前会话中已定义的函数
+	IsVariable func(name string) bool            // 环境或会话中已定义的变量
+	FindScript func(name string) (string, error) // 查找 source 和 import 的模块
+}
+
+// EnvFor 使用执行器中的命令、函数、变量和模块搜索路径
+func EnvFor(e *script.Executor) Env {
+	return Env{
+		IsCommand:  e.HasCommand,
+		IsFunction: e.HasFunction,
+		IsVariable: func(name string) bool {
+			_, ok := e.GetVariable(name)
+			return ok
+		},
+		FindScript: e.FindScript,
+	}
+}
+
+// CheckFile 读取并检查脚本文件
+func CheckFile(path string, env Env) ([]Diagnostic, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Check(path, string(content), env), nil
+}
+
+// Check 检查脚本源码，返回按行号排序的问题列表
+func Check(file, source string, env Env) []Diagnostic {
+	program, err := script.Parse(source)
+	if err != nil {
+		var diags []Diagnostic
+		if parseErr, ok := err.(*script.ParseError); ok {
+			for _, e := range parseErr.Details {
+				diags = append(diags, Diagnostic{
+					File:     file,
+					Line:     e.Line,
+					Column:   e.Column,
+					Severity: SeverityError,
+					Rule:     RuleSyntax,
+					Message:  e.Message,
+				})
+			}
+		}
+		return diags
+	}
+
+	c := newChecker(file, env)
+	c.collect(program.Statements, "")
+	c.checkBlock(program.Statements)
+
+	sort.SliceStable(c.diags, func(i, j int) bool {
+		return c.diags[i].Line < c.diags[j].Line
+	})
+	return c.diags
+}
+
+// Filter 返回严重程度不低于 min 的问题
+func Filter(diags []Diagnostic, min Severity) []Diagnostic {
+	var result []Diagnostic
+	for _, d := range diags {
+		if d.Severity >= min {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// WriteText 以每行一个问题的形式输出
+func WriteText(w io.Writer, diags []Diagnostic) {
+	for _, d := range diags {
+		fmt.Fprintln(w, d)
+	}
+}
+
+// WriteJSON 以 JSON 数组输出，没有问题时输出 []
+func WriteJSON(w io.Writer, diags []Diagnostic) error {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	data, err := json.MarshalIndent(diags, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
@@ -0,0 +1,61 @@
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// testEnv 只认识 echo、ls 两个命令和环境变量 HOME
+var testEnv = Env{
+	IsCommand:  func(name string) bool { return name == "echo" || name == "ls" },
+	IsFunction: func(string) bool { return false },
+	IsVariable: func(name string) bool { return name == "HOME" },
+	FindScript: func(name string) (string, error) { return "", fmt.Errorf("找不到模块: %s", name) },
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string // 规则:行号
+	}{
+		{"clean script", "name=world\necho \"hello $name\"\necho \"$HOME\"", nil},
+		{"syntax error", "if true; then\necho a\n", []string{"syntax:3"}},
+		{"undefined variable", "echo \"$missing\"", []string{"undefined-variable:1"}},
+		{"unquoted expansion", "x=a\nls $x", []string{"unquoted-expansion:2"}},
+		{"unreachable code", "function f() {\nreturn 0\necho no\n}", []string{"unreachable-code:3"}},
+		{"break outside loop", "break", []string{"break-outside-loop:1"}},
+		{"break inside loop", "for i in a b; do\nbreak\ndone", nil},
+		{"unknown command", "nosuch arg", []string{"unknown-command:1"}},
+		{"function is a command", "function greet() { echo hi; }\ngreet", nil},
+		{"function defined later", "greet\nfunction greet() { echo hi; }", nil},
+		{"missing module", "import nosuch", []string{"missing-module:1"}},
+		{"shadowed command", "function ls() { echo hi; }", []string{"shadowed-function:1"}},
+		{"local variable", "function f() {\nlocal v=1\necho \"$v\"\n}", nil},
+		{"positional parameters", "echo \"$1 $# $@\"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range Check("test.lish", tt.source, testEnv) {
+				got = append(got, fmt.Sprintf("%s:%d", d.Rule, d.Line))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	diags := Check("test.lish", "echo \"$missing\"\nbreak", testEnv)
+	if len(diags) != 2 {
+		t.Fatalf("got %d diagnostics, want 2: %v", len(diags), diags)
+	}
+	errs := Filter(diags, SeverityError)
+	if len(errs) != 1 || errs[0].Rule != RuleBreakOutsideLoop {
+		t.Errorf("Filter(error) = %v, want only %s", errs, RuleBreakOutsideLoop)
+	}
+}
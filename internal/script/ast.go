@@ -9,6 +9,10 @@ type Node interface {
 type Statement interface {
 	Node
 	statementNode()
+	StartLine() int
+	EndLine() int
+	setLine(line int)
+	setEnd(line int)
 }
 
 // Expression 表示一个表达式
@@ -17,6 +21,22 @@ type Expression interface {
 	expressionNode()
 }
 
+// Position 记录语句在源码中的位置，嵌入到各语句节点中
+type Position struct {
+	Line int
+	End  int
+}
+
+// StartLine 返回语句起始行号
+func (p *Position) StartLine() int { return p.Line }
+
+// EndLine 返回语句最后一个 token 所在的行号
+func (p *Position) EndLine() int { return p.End }
+
+func (p *Position) setLine(line int) { p.Line = line }
+
+func (p *Position) setEnd(line int) { p.End = line }
+
 // ============================================================================
 // 程序和语句
 // ============================================================================
@@ -28,17 +48,35 @@ type Program struct {
 
 func (p *Program) String() string { return "Program" }
 
-// CommandStatement 表示一个命令语句（如: ls -la）
+// CommandStatement 表示一个命令语句（如: ls -la > out.txt）
 type CommandStatement struct {
-	Command string
-	Args    []string
+	Position
+	Command   string
+	Args      []string
+	Redirects []*Redirect
 }
 
 func (cs *CommandStatement) statementNode() {}
 func (cs *CommandStatement) String() string { return "Command: " + cs.Command }
 
+// Redirect 表示一个重定向（如: > out.txt, 2>> err.log, 2>&1）
+type Redirect struct {
+	Op     string // >, >>, <, 2>, 2>>, 2>&1, >&2
+	Target string // 目标文件，2>&1 和 >&2 为空
+}
+
+// PipelineStatement 表示管道（如: ls | grep go）
+type PipelineStatement struct {
+	Position
+	Commands []Statement
+}
+
+func (ps *PipelineStatement) statementNode() {}
+func (ps *PipelineStatement) String() string { return "Pipeline" }
+
 // AssignStatement 表示变量赋值语句（如: name=value）
 type AssignStatement struct {
+	Position
 	Name  string
 	Value Expression
 }
@@ -46,15 +84,37 @@ type AssignStatement struct {
 func (as *AssignStatement) statementNode() {}
 func (as *AssignStatement) String() string { return "Assign: " + as.Name }
 
+// LocalStatement 表示局部变量声明（如: local name=value other）
+type LocalStatement struct {
+	Position
+	Vars []*AssignStatement // 未赋值的声明 Value 为 nil
+}
+
+func (ls *LocalStatement) statementNode() {}
+func (ls *LocalStatement) String() string { return "Local" }
+
+// ImportStatement 表示模块导入（如: import utils as u）
+type ImportStatement struct {
+	Position
+	Module    string // 模块名或路径
+	Namespace string // 函数名前缀，为空时不加前缀
+}
+
+func (is *ImportStatement) statementNode() {}
+func (is *ImportStatement) String() string { return "Import: " + is.Module }
+
 // IfStatement 表示 if 条件语句
 type IfStatement struct {
+	Position
 	Condition  Expression
 	ThenBlock  []Statement
 	ElseIfList []*ElseIfClause
 	ElseBlock  []Statement
+	ElseLine   int // else 所在的行，没有 else 时为 0
 }
 
 type ElseIfClause struct {
+	Line      int // elif 所在的行
 	Condition Expression
 	Block     []Statement
 }
@@ -64,6 +124,7 @@ func (is *IfStatement) String() string { return "If" }
 
 // ForStatement 表示 for 循环语句
 type ForStatement struct {
+	Position
 	Variable string      // 循环变量名
 	List     []string    // 要遍历的列表
 	Block    []Statement // 循环体
@@ -74,6 +135,7 @@ func (fs *ForStatement) String() string { return "For: " + fs.Variable }
 
 // WhileStatement 表示 while 循环语句
 type WhileStatement struct {
+	Position
 	Condition Expression
 	Block     []Statement
 }
@@ -81,8 +143,23 @@ type WhileStatement struct {
 func (ws *WhileStatement) statementNode() {}
 func (ws *WhileStatement) String() string { return "While" }
 
+// TryStatement 表示 try { ... } catch 变量 { ... } finally { ... } 语句（Lish 扩展）
+type TryStatement struct {
+	Position
+	Block        []Statement
+	CatchLine    int    // catch 所在的行，没有 catch 时为 0
+	CatchVar     string // 保存错误信息的变量名，可以省略
+	CatchBlock   []Statement
+	FinallyLine  int // finally 所在的行，没有 finally 时为 0
+	FinallyBlock []Statement
+}
+
+func (ts *TryStatement) statementNode() {}
+func (ts *TryStatement) String() string { return "Try" }
+
 // FunctionDef 表示函数定义
 type FunctionDef struct {
+	Position
 	Name  string
 	Block []Statement
 }
@@ -92,6 +169,7 @@ func (fd *FunctionDef) String() string { return "Function: " + fd.Name }
 
 // ReturnStatement 表示 return 语句
 type ReturnStatement struct {
+	Position
 	Value Expression
 }
 
@@ -99,13 +177,17 @@ func (rs *ReturnStatement) statementNode() {}
 func (rs *ReturnStatement) String() string { return "Return" }
 
 // BreakStatement 表示 break 语句
-type BreakStatement struct{}
+type BreakStatement struct {
+	Position
+}
 
 func (bs *BreakStatement) statementNode() {}
 func (bs *BreakStatement) String() string { return "Break" }
 
 // ContinueStatement 表示 continue 语句
-type ContinueStatement struct{}
+type ContinueStatement struct {
+	Position
+}
 
 func (cs *ContinueStatement) statementNode() {}
 func (cs *ContinueStatement) String() string { return "Continue" }
@@ -158,6 +240,14 @@ type TestExpr struct {
 func (te *TestExpr) expressionNode() {}
 func (te *TestExpr) String() string  { return "Test" }
 
+// CommandCondition 表示以命令作为条件（如: while getopts "ab:" opt），退出码为 0 时为真
+type CommandCondition struct {
+	Command Statement
+}
+
+func (cc *CommandCondition) expressionNode() {}
+func (cc *CommandCondition) String() string  { return "Command" }
+
 // CommandSubstitution 表示命令替换（如: $(command)）
 type CommandSubstitution struct {
 	Command string
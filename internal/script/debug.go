@@ -0,0 +1,55 @@
+package script
+
+import "context"
+
+// Tracer 在每条语句执行之前被调用，用于实现调试器
+//
+// 返回错误时中止执行，错误向上传递给脚本的调用者。管道和命令替换
+// 在子 shell 中执行，其中的语句不会被跟踪。
+type Tracer interface {
+	BeforeStatement(ctx context.Context, e *Executor, stmt Statement) error
+}
+
+// abortError 包装 Tracer 返回的错误，使其不会被条件命令当作失败吞掉
+type abortError struct {
+	err error
+}
+
+func (a *abortError) Error() string { return a.err.Error() }
+func (a *abortError) Unwrap() error { return a.err }
+
+// Frame 调用栈中的一帧
+type Frame struct {
+	Function string // 函数名，脚本顶层为空
+	File     string // 脚本文件，交互输入和 -c 为空
+	Line     int    // 正在执行（或调用下一帧）的行
+}
+
+// SetTracer 设置语句跟踪器，nil 表示关闭跟踪
+func (e *Executor) SetTracer(t Tracer) {
+	e.tracer = t
+}
+
+// Tracer 返回当前的语句跟踪器
+func (e *Executor) Tracer() Tracer {
+	return e.tracer
+}
+
+// CallStack 返回当前的调用栈，第一帧是正在执行的位置
+func (e *Executor) CallStack() []Frame {
+	stack := []Frame{{Function: e.funcName, File: e.file, Line: e.line}}
+	for i := len(e.frames) - 1; i >= 0; i-- {
+		stack = append(stack, e.frames[i])
+	}
+	return stack
+}
+
+// CallDepth 返回当前的函数调用深度，脚本顶层为 0
+func (e *Executor) CallDepth() int {
+	return e.callDepth
+}
+
+// ExpandWord 在当前环境中展开单词（变量、命令替换、引号），不做字段分割
+func (e *Executor) ExpandWord(ctx context.Context, word string) string {
+	return e.expandWord(ctx, word)
+}
@@ -1,24 +1,106 @@
 package script
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/Lingbou/Lish/internal/streams"
 )
 
-// ExecutionError 执行错误
+// ExecutionError 执行错误，记录出错的脚本文件和行号
 type ExecutionError struct {
 	Message string
+	File    string
 	Line    int
+	Err     error // 原始错误
 }
 
 func (e *ExecutionError) Error() string {
+	msg := e.Message
 	if e.Line > 0 {
-		return fmt.Sprintf("行 %d: %s", e.Line, e.Message)
+		msg = fmt.Sprintf("行 %d: %s", e.Line, msg)
+	}
+	if e.File != "" {
+		msg = e.File + ": " + msg
 	}
-	return e.Message
+	return msg
+}
+
+func (e *ExecutionError) Unwrap() error {
+	return e.Err
+}
+
+// CommandError 命令执行失败，记录展开后的命令名和参数；错误信息与原始错误相同
+type CommandError struct {
+	Command string
+	Args    []string
+	Err     error
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// ExitStatus 命令以非零退出码结束但没有需要报告的错误（如 grep 没有匹配、
+// awk 的 exit 语句），只设置退出码，不输出错误信息
+type ExitStatus int
+
+func (e ExitStatus) Error() string {
+	return fmt.Sprintf("退出码 %d", int(e))
+}
+
+// ExitCode 返回退出码
+func (e ExitStatus) ExitCode() int {
+	return int(e)
+}
+
+// ErrUnknownCommand 命令既不是函数、内置命令也不是外部程序，脚本在此中止
+var ErrUnknownCommand = errors.New("未知命令")
+
+// ExitRequest exit 命令请求结束当前 shell
+//
+// 子 shell（管道、命令替换、xargs 等执行的命令）把它转换为退出码，
+// 只有顶层才结束 lish 进程。
+type ExitRequest int
+
+func (e ExitRequest) Error() string {
+	return fmt.Sprintf("exit %d", int(e))
+}
+
+// ExitCode 返回退出码
+func (e ExitRequest) ExitCode() int {
+	return int(e)
+}
+
+// ExitCodeOf 返回错误对应的退出码
+//
+// 实现了 ExitCode() int 的错误（如 *exec.ExitError）使用其退出码，
+// 其他错误统一视为 1，nil 为 0。
+func ExitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exit ExitRequest
+	if errors.As(err, &exit) {
+		return int(exit)
+	}
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) && coder.ExitCode() > 0 {
+		return coder.ExitCode()
+	}
+	return 1
 }
 
 // ControlFlow 控制流类型
@@ -31,6 +113,9 @@ const (
 	FLOW_RETURN
 )
 
+// maxCallDepth 默认的函数最大递归深度，可通过 FUNCNEST 变量修改
+const maxCallDepth = 1000
+
 // CommandExecutor 命令执行器接口
 type CommandExecutor interface {
 	ExecuteCommand(ctx context.Context, command string, args []string) error
@@ -40,10 +125,55 @@ type CommandExecutor interface {
 type Executor struct {
 	cmdExecutor CommandExecutor
 	variables   *VariableManager
-	functions   map[string]*FunctionDef
-	lastExit    int
+	functions   map[string]*function
+	imported    map[string]bool // 已通过 import 加载的模块
+	namespace   string          // 正在加载的模块或正在执行的函数所属的命名空间
 	flowType    ControlFlow
-	returnVal   string
+	callDepth   int // 当前函数调用深度
+
+	// 调试信息：正在执行的文件、函数和行，以及调用者的位置
+	file     string
+	funcName string
+	line     int
+	frames   []Frame
+	tracer   Tracer
+	script   bool // 正在执行脚本而不是交互输入，命令的错误信息带行号
+
+	tryDepth int // 正在执行的 try 块层数，try 块中命令失败会中断执行
+}
+
+// function 已定义的函数及其所属的命名空间和定义所在的文件
+type function struct {
+	def       *FunctionDef
+	namespace string
+	file      string
+}
+
+// name 返回函数的完整名称（带命名空间前缀）
+func (fn *function) name() string {
+	if fn.namespace != "" {
+		return fn.namespace + "." + fn.def.Name
+	}
+	return fn.def.Name
+}
+
+// CommandLookup 可以判断命令是否存在的命令执行器
+type CommandLookup interface {
+	HasCommand(name string) bool
+}
+
+// executorKey 在 context 中保存正在执行命令的执行器
+type executorKey struct{}
+
+// ExecutorFrom 返回正在执行当前命令的执行器，不在脚本中执行时返回 fallback
+//
+// 管道和命令替换中的命令由子 shell 执行，操作变量的内置命令（export、
+// shift 等）需要通过它找到子 shell 的变量，而不是创建命令时传入的执行器。
+func ExecutorFrom(ctx context.Context, fallback *Executor) *Executor {
+	if e, ok := ctx.Value(executorKey{}).(*Executor); ok {
+		return e
+	}
+	return fallback
 }
 
 // NewExecutor 创建新的执行器
@@ -51,21 +181,50 @@ func NewExecutor(cmdExecutor CommandExecutor) *Executor {
 	return &Executor{
 		cmdExecutor: cmdExecutor,
 		variables:   NewVariableManager(),
-		functions:   make(map[string]*FunctionDef),
-		lastExit:    0,
+		functions:   make(map[string]*function),
+		imported:    make(map[string]bool),
 		flowType:    FLOW_NORMAL,
 	}
 }
 
+// subshell 创建子 shell 执行器，用于管道中最后一个命令之前的命令和命令替换
+//
+// 子 shell 复制变量和函数，其中的赋值、函数定义和 shift 不影响当前 shell。
+func (e *Executor) subshell() *Executor {
+	functions := make(map[string]*function, len(e.functions))
+	for name, fn := range e.functions {
+		functions[name] = fn
+	}
+	imported := make(map[string]bool, len(e.imported))
+	for key := range e.imported {
+		imported[key] = true
+	}
+
+	return &Executor{
+		cmdExecutor: e.cmdExecutor,
+		variables:   e.variables.Subshell(),
+		functions:   functions,
+		imported:    imported,
+		namespace:   e.namespace,
+		flowType:    FLOW_NORMAL,
+		callDepth:   e.callDepth,
+		file:        e.file,
+		funcName:    e.funcName,
+		line:        e.line,
+		script:      e.script,
+	}
+}
+
 // Execute 执行脚本
 func (e *Executor) Execute(ctx context.Context, program *Program) error {
 	for _, stmt := range program.Statements {
-		if err := e.executeStatement(ctx, stmt); err != nil {
+		if err := e.executeStatement(ctx, stmt); err != nil && !e.onlyStatus(ctx, err) {
 			return err
 		}
 
-		// 检查控制流
+		// 检查控制流：脚本顶层的 return 结束脚本
 		if e.flowType == FLOW_RETURN {
+			e.flowType = FLOW_NORMAL
 			break
 		}
 	}
@@ -81,35 +240,100 @@ func (e *Executor) ExecuteFile(ctx context.Context, filepath string, args []stri
 		return fmt.Errorf("无法读取脚本文件: %w", err)
 	}
 
-	// 设置特殊变量
-	scriptArgs := append([]string{filepath}, args...)
-	e.variables.SetSpecialVars(scriptArgs, e.lastExit)
+	savedFile := e.file
+	e.file = filepath
+	err = e.ExecuteSource(ctx, filepath, string(content), args)
+	e.file = savedFile
 
-	// 解析
-	parser := NewParser(string(content))
-	program := parser.Parse()
+	// 为错误补充文件名（嵌套 source 时保留最内层的文件名）
+	var execErr *ExecutionError
+	if errors.As(err, &execErr) && execErr.File == "" {
+		execErr.File = filepath
+	}
+	return err
+}
 
-	if len(parser.Errors()) > 0 {
-		return fmt.Errorf("解析错误: %s", strings.Join(parser.Errors(), "\n"))
+// ExecuteSource 执行脚本源码，name 作为 $0，args 作为位置参数
+//
+// 位置参数只在脚本执行期间有效，结束后恢复调用者的参数。
+func (e *Executor) ExecuteSource(ctx context.Context, name, source string, args []string) error {
+	// 解析
+	program, err := Parse(source)
+	if err != nil {
+		return &ExecutionError{Message: err.Error(), Err: err}
 	}
 
+	// 设置位置参数
+	scope := e.variables.CurrentScope()
+	saved := scope.params
+	e.variables.SetParams(append([]string{name}, args...))
+	defer func() { scope.params = saved }()
+
+	savedScript := e.script
+	e.script = true
+	defer func() { e.script = savedScript }()
+
 	// 执行
 	return e.Execute(ctx, program)
 }
 
-// executeStatement 执行语句
+// ExecuteLine 执行交互式输入的一行命令，不改变位置参数
+func (e *Executor) ExecuteLine(ctx context.Context, line string) error {
+	program, err := Parse(line)
+	if err != nil {
+		return err
+	}
+
+	return e.Execute(ctx, program)
+}
+
+// executeStatement 执行语句，出错时附加行号
 func (e *Executor) executeStatement(ctx context.Context, stmt Statement) error {
+	e.line = stmt.StartLine()
+	if e.tracer != nil {
+		if err := e.tracer.BeforeStatement(ctx, e, stmt); err != nil {
+			return &abortError{err: err}
+		}
+	}
+
+	err := e.dispatchStatement(ctx, stmt)
+	if err == nil {
+		return nil
+	}
+
+	// 只记录最内层语句的位置
+	var execErr *ExecutionError
+	if errors.As(err, &execErr) {
+		return err
+	}
+	return &ExecutionError{
+		Message: err.Error(),
+		Line:    stmt.StartLine(),
+		Err:     err,
+	}
+}
+
+// dispatchStatement 根据语句类型执行
+func (e *Executor) dispatchStatement(ctx context.Context, stmt Statement) error {
 	switch s := stmt.(type) {
 	case *CommandStatement:
 		return e.executeCommand(ctx, s)
+	case *PipelineStatement:
+		return e.executePipeline(ctx, s)
 	case *AssignStatement:
 		return e.executeAssign(ctx, s)
+	case *LocalStatement:
+		return e.executeLocal(ctx, s)
+	case *ImportStatement:
+		return e.executeImport(ctx, s)
 	case *IfStatement:
 		return e.executeIf(ctx, s)
 	case *ForStatement:
 		return e.executeFor(ctx, s)
 	case *WhileStatement:
 		return e.executeWhile(ctx, s)
+	case *TryStatement:
+		return e.executeTry(ctx, s)
 	case *FunctionDef:
 		return e.executeFunctionDef(s)
 	case *ReturnStatement:
@@ -127,47 +351,249 @@ func (e *Executor) executeStatement(ctx context.Context, stmt Statement) error {
 
 // executeCommand 执行命令
 func (e *Executor) executeCommand(ctx context.Context, stmt *CommandStatement) error {
-	// 展开变量
-	command := e.variables.Expand(stmt.Command)
-	args := make([]string, len(stmt.Args))
-	for i, arg := range stmt.Args {
-		args[i] = e.variables.Expand(arg)
+	// 展开命令名和参数，展开结果为空时什么也不执行
+	words := e.expandWords(ctx, append([]string{stmt.Command}, stmt.Args...))
+	if len(words) == 0 {
+		return nil
+	}
+	command, args := words[0], words[1:]
+
+	// 应用重定向
+	ctx, closeFiles, err := e.applyRedirects(ctx, stmt.Redirects)
+	if err != nil {
+		e.setStatus(1)
+		return err
 	}
+	defer closeFiles()
 
 	// 检查是否是函数调用
-	if fn, ok := e.functions[command]; ok {
+	if fn, ok := e.lookupFunction(command); ok {
 		return e.executeFunction(ctx, fn, args)
 	}
 
-	// 使用命令执行器执行命令
+	return e.RunCommand(ctx, command, args)
+}
+
+// RunCommand 用命令执行器执行内置命令或外部命令，不查找同名函数
+//
+// command 和 builtin 命令用它绕过覆盖了命令的函数。命令的错误信息写入
+// 命令自己的标准错误（重定向之后），返回的错误只表示退出码。
+func (e *Executor) RunCommand(ctx context.Context, command string, args []string) error {
+	ctx = context.WithValue(ctx, executorKey{}, e)
 	err := e.cmdExecutor.ExecuteCommand(ctx, command, args)
-	if err != nil {
-		e.lastExit = 1
+	e.setStatus(ExitCodeOf(err))
+	if err == nil {
+		return nil
+	}
+
+	if ctx.Err() == nil && !IsSilent(err) && !errors.Is(err, io.ErrClosedPipe) {
+		message := err.Error()
+		if e.script {
+			message = (&ExecutionError{Message: message, File: e.file, Line: e.line}).Error()
+		}
+		fmt.Fprintf(streams.Stderr(ctx), "lish: %s\n", message)
+		err = &reportedError{ExitStatus: ExitStatus(ExitCodeOf(err)), err: err}
+	}
+	return &CommandError{Command: command, Args: args, Err: err}
+}
+
+// reportedError 已经写入命令标准错误的错误
+//
+// 和 ExitStatus 一样不再重复报告，但保留原始错误，catch 仍能取得错误信息。
+type reportedError struct {
+	ExitStatus
+	err error
+}
+
+func (e *reportedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reportedError) Unwrap() []error {
+	return []error{e.ExitStatus, e.err}
+}
+
+// Call 执行函数、内置命令或外部命令，参数不再展开
+//
+// xargs、parallel 等内置命令用它执行由输入拼成的命令。
+//
+// 命令像在子 shell 中一样执行，其中的 exit 只结束这个命令。
+func (e *Executor) Call(ctx context.Context, command string, args []string) error {
+	var err error
+	if fn, ok := e.lookupFunction(command); ok {
+		err = e.executeFunction(ctx, fn, args)
+	} else {
+		err = e.RunCommand(ctx, command, args)
+	}
+	return e.endSubshell(err)
+}
+
+// endSubshell 把子 shell 中 exit 的请求转换为子 shell 的退出码
+func (e *Executor) endSubshell(err error) error {
+	var exit ExitRequest
+	if !errors.As(err, &exit) {
 		return err
 	}
+	e.setStatus(int(exit))
+	if exit == 0 {
+		return nil
+	}
+	return ExitStatus(exit)
+}
 
-	e.lastExit = 0
-	e.variables.Set("?", "0")
-	return nil
+// Subshell 创建子 shell 执行器
+//
+// 并发执行命令的内置命令（xargs -P、parallel）为每个命令创建一个，
+// 使各个命令设置的退出码和变量互不影响。
+func (e *Executor) Subshell() *Executor {
+	return e.subshell()
+}
+
+// applyRedirects 打开重定向的文件，返回使用新输入输出的 context 和关闭文件的函数
+//
+// 重定向按从左到右的顺序处理，因此 > out 2>&1 把两个输出都写入 out。
+func (e *Executor) applyRedirects(ctx context.Context, redirects []*Redirect) (context.Context, func(), error) {
+	if len(redirects) == 0 {
+		return ctx, func() {}, nil
+	}
+
+	std := *streams.From(ctx)
+	var files []*os.File
+	closeFiles := func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+
+	for _, r := range redirects {
+		switch r.Op {
+		case "2>&1":
+			std.Stderr = std.Stdout
+			continue
+		case ">&2":
+			std.Stdout = std.Stderr
+			continue
+		}
+
+		target := e.expandWord(ctx, r.Target)
+		var f *os.File
+		var err error
+		switch r.Op {
+		case "<":
+			f, err = os.Open(target)
+		case ">>", "2>>":
+			f, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		default:
+			f, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		}
+		if err != nil {
+			closeFiles()
+			return ctx, nil, fmt.Errorf("重定向失败: %w", err)
+		}
+		files = append(files, f)
+
+		switch r.Op {
+		case "<":
+			std.Stdin = f
+		case ">", ">>":
+			std.Stdout = f
+		default:
+			std.Stderr = f
+		}
+	}
+
+	return streams.With(ctx, &std), closeFiles, nil
+}
+
+// executePipeline 执行管道
+//
+// 除最后一个命令外，各命令在子 shell 中并发执行，通过 io.Pipe 连接；
+// 最后一个命令在当前 shell 中执行（类似 bash 的 lastpipe），其中的赋值、
+// export 和函数定义在管道结束后仍然有效，管道的退出码是它的退出码。
+// 某个命令结束时关闭它的输入端，使前面仍在写入的命令收到错误并退出。
+func (e *Executor) executePipeline(ctx context.Context, stmt *PipelineStatement) error {
+	std := streams.From(ctx)
+	last := len(stmt.Commands) - 1
+
+	var wg sync.WaitGroup
+	stdin := std.Stdin
+	var input *io.PipeReader
+
+	for _, cmd := range stmt.Commands[:last] {
+		pr, pw := io.Pipe()
+		stageCtx := streams.With(ctx, &streams.Streams{Stdin: stdin, Stdout: pw, Stderr: std.Stderr})
+
+		// 子 shell 在启动 goroutine 之前创建，避免与当前 shell 并发访问变量
+		sub := e.subshell()
+		wg.Add(1)
+		go func(cmd Statement, input *io.PipeReader) {
+			defer wg.Done()
+			err := sub.executeStatement(stageCtx, cmd)
+			pw.Close()
+			if input != nil {
+				input.Close()
+			}
+			if err != nil {
+				reportError(std.Stderr, err)
+			}
+		}(cmd, input)
+
+		stdin = pr
+		input = pr
+	}
+
+	lastCtx := streams.With(ctx, &streams.Streams{Stdin: stdin, Stdout: std.Stdout, Stderr: std.Stderr})
+	err := e.executeStatement(lastCtx, stmt.Commands[last])
+	input.Close()
+	wg.Wait()
+
+	// 最后一个命令中的 exit 和前面的命令一样只结束管道，不结束当前 shell
+	return e.endSubshell(err)
 }
 
 // executeAssign 执行赋值
 func (e *Executor) executeAssign(ctx context.Context, stmt *AssignStatement) error {
 	value := e.evaluateExpression(ctx, stmt.Value)
-	e.variables.Set(stmt.Name, value)
+	return e.variables.Set(stmt.Name, value)
+}
+
+// executeLocal 在当前函数作用域中声明局部变量
+func (e *Executor) executeLocal(ctx context.Context, stmt *LocalStatement) error {
+	if !e.variables.InFunction() {
+		return fmt.Errorf("local: 只能在函数中使用")
+	}
+
+	for _, decl := range stmt.Vars {
+		value := ""
+		if decl.Value != nil {
+			value = e.evaluateExpression(ctx, decl.Value)
+		}
+		if err := e.variables.SetLocal(decl.Name, value); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // executeIf 执行 if 语句
 func (e *Executor) executeIf(ctx context.Context, stmt *IfStatement) error {
 	// 评估条件
-	if e.evaluateCondition(ctx, stmt.Condition) {
+	ok, err := e.evaluateCondition(ctx, stmt.Condition)
+	if err != nil {
+		return err
+	}
+	if ok {
 		return e.executeBlock(ctx, stmt.ThenBlock)
 	}
 
 	// 检查 elif
 	for _, elseif := range stmt.ElseIfList {
-		if e.evaluateCondition(ctx, elseif.Condition) {
+		ok, err := e.evaluateCondition(ctx, elseif.Condition)
+		if err != nil {
+			return err
+		}
+		if ok {
 			return e.executeBlock(ctx, elseif.Block)
 		}
 	}
@@ -182,12 +608,12 @@ func (e *Executor) executeIf(ctx context.Context, stmt *IfStatement) error {
 
 // executeFor 执行 for 循环
 func (e *Executor) executeFor(ctx context.Context, stmt *ForStatement) error {
-	for _, item := range stmt.List {
-		// 展开变量
-		item = e.variables.Expand(item)
-
+	// 展开列表并按 IFS 分割
+	for _, item := range e.expandWords(ctx, stmt.List) {
 		// 设置循环变量
-		e.variables.Set(stmt.Variable, item)
+		if err := e.variables.Set(stmt.Variable, item); err != nil {
+			return err
+		}
 
 		// 执行循环体
 		if err := e.executeBlock(ctx, stmt.Block); err != nil {
@@ -213,7 +639,15 @@ func (e *Executor) executeFor(ctx context.Context, stmt *ForStatement) error {
 
 // executeWhile 执行 while 循环
 func (e *Executor) executeWhile(ctx context.Context, stmt *WhileStatement) error {
-	for e.evaluateCondition(ctx, stmt.Condition) {
+	for {
+		ok, err := e.evaluateCondition(ctx, stmt.Condition)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+
 		if err := e.executeBlock(ctx, stmt.Block); err != nil {
 			return err
 		}
@@ -237,37 +671,90 @@ func (e *Executor) executeWhile(ctx context.Context, stmt *WhileStatement) error
 
 // executeFunctionDef 注册函数定义
 func (e *Executor) executeFunctionDef(stmt *FunctionDef) error {
-	e.functions[stmt.Name] = stmt
+	name := stmt.Name
+	if e.namespace != "" {
+		name = e.namespace + "." + name
+	}
+	e.functions[name] = &function{def: stmt, namespace: e.namespace, file: e.file}
 	return nil
 }
 
+// lookupFunction 查找函数，命名空间内优先查找同一命名空间的函数
+func (e *Executor) lookupFunction(name string) (*function, bool) {
+	if e.namespace != "" {
+		if fn, ok := e.functions[e.namespace+"."+name]; ok {
+			return fn, true
+		}
+	}
+	fn, ok := e.functions[name]
+	return fn, ok
+}
+
 // executeFunction 执行函数调用
-func (e *Executor) executeFunction(ctx context.Context, fn *FunctionDef, args []string) error {
-	// 进入新作用域
+//
+// 函数在新作用域中执行，位置参数为调用参数（$0 保持不变）；函数的
+// 退出码是 return 指定的值，没有 return 时是最后一条命令的退出码。
+func (e *Executor) executeFunction(ctx context.Context, fn *function, args []string) error {
+	if limit := e.callDepthLimit(); e.callDepth >= limit {
+		return fmt.Errorf("%s: 超过最大递归深度 (%d)", fn.def.Name, limit)
+	}
+	e.callDepth++
+	defer func() { e.callDepth-- }()
+
+	// 记录调用位置，函数体中的行号属于定义函数的文件
+	e.frames = append(e.frames, Frame{Function: e.funcName, File: e.file, Line: e.line})
+	savedFile, savedLine := e.file, e.line
+	e.funcName, e.file = fn.name(), fn.file
+	defer func() {
+		top := e.frames[len(e.frames)-1]
+		e.frames = e.frames[:len(e.frames)-1]
+		e.funcName, e.file, e.line = top.Function, savedFile, savedLine
+	}()
+
+	// 函数体中按函数所属的命名空间查找其他函数
+	savedNamespace := e.namespace
+	e.namespace = fn.namespace
+	defer func() { e.namespace = savedNamespace }()
+
+	// 进入新作用域并设置参数
+	name := e.variables.Params()[0]
 	e.variables.PushScope()
 	defer e.variables.PopScope()
-
-	// 设置参数
-	fnArgs := append([]string{fn.Name}, args...)
-	e.variables.SetSpecialVars(fnArgs, e.lastExit)
+	e.variables.SetParams(append([]string{name}, args...))
 
 	// 执行函数体
-	if err := e.executeBlock(ctx, fn.Block); err != nil {
-		return err
-	}
+	err := e.executeBlock(ctx, fn.def.Block)
 
-	// 重置控制流
+	// 重置控制流；return 非零值时函数和失败的命令一样返回退出码
 	if e.flowType == FLOW_RETURN {
 		e.flowType = FLOW_NORMAL
+		if status := e.variables.Status(); err == nil && status != 0 {
+			return ExitStatus(status)
+		}
 	}
 
-	return nil
+	return err
+}
+
+// callDepthLimit 返回函数最大递归深度，FUNCNEST 为正整数时使用其值
+func (e *Executor) callDepthLimit() int {
+	if value, ok := e.variables.Get("FUNCNEST"); ok {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return maxCallDepth
 }
 
-// executeReturn 执行 return 语句
+// executeReturn 执行 return 语句，指定的值作为函数的退出码
 func (e *Executor) executeReturn(ctx context.Context, stmt *ReturnStatement) error {
 	if stmt.Value != nil {
-		e.returnVal = e.evaluateExpression(ctx, stmt.Value)
+		value := e.evaluateExpression(ctx, stmt.Value)
+		code, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("return: %s: 需要数字参数", value)
+		}
+		e.setStatus(code & 0xff)
 	}
 	e.flowType = FLOW_RETURN
 	return nil
@@ -276,7 +763,7 @@ func (e *Executor) executeReturn(ctx context.Context, stmt *ReturnStatement) err
 // executeBlock 执行语句块
 func (e *Executor) executeBlock(ctx context.Context, block []Statement) error {
 	for _, stmt := range block {
-		if err := e.executeStatement(ctx, stmt); err != nil {
+		if err := e.executeStatement(ctx, stmt); err != nil && !e.onlyStatus(ctx, err) {
 			return err
 		}
 
@@ -290,39 +777,59 @@ func (e *Executor) executeBlock(ctx context.Context, block []Statement) error {
 }
 
 // evaluateCondition 评估条件表达式
-func (e *Executor) evaluateCondition(ctx context.Context, expr Expression) bool {
+//
+// 只有中断执行的错误（Ctrl+C、调试器退出）才会返回，条件命令失败
+// 只表示条件为假。
+func (e *Executor) evaluateCondition(ctx context.Context, expr Expression) (bool, error) {
 	switch exp := expr.(type) {
 	case *StringLiteral:
 		// 非空字符串为真
-		val := e.variables.Expand(exp.Value)
-		return val != "" && val != "0" && val != "false"
+		val := e.expandWord(ctx, exp.Value)
+		return val != "" && val != "0" && val != "false", nil
 
 	case *TestExpr:
-		return e.evaluateTest(exp)
+		return e.evaluateTest(ctx, exp), nil
+
+	case *CommandCondition:
+		return e.evaluateCommand(ctx, exp)
 
 	case *BinaryExpr:
-		return e.evaluateBinaryExpr(exp)
+		return e.evaluateBinaryExpr(ctx, exp)
 
 	case *UnaryExpr:
-		return e.evaluateUnaryExpr(exp)
+		return e.evaluateUnaryExpr(ctx, exp)
 
 	default:
-		return false
+		return false, nil
 	}
 }
 
+// evaluateCommand 执行条件中的命令，退出码为 0 时为真
+func (e *Executor) evaluateCommand(ctx context.Context, expr *CommandCondition) (bool, error) {
+	err := e.executeStatement(ctx, expr.Command)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return false, ctxErr
+	}
+	// 命令的非零退出码只表示条件为假，其余错误继续向上传递
+	var exit ExitRequest
+	if err != nil && (!IsSilent(err) || errors.As(err, &exit)) {
+		return false, err
+	}
+	return e.variables.Status() == 0, nil
+}
+
 // evaluateTest 评估测试表达式
-func (e *Executor) evaluateTest(expr *TestExpr) bool {
+func (e *Executor) evaluateTest(ctx context.Context, expr *TestExpr) bool {
 	if len(expr.Args) == 0 {
 		return false
 	}
 
 	op := expr.Operator
-	args := expr.Args
 
-	// 展开变量
-	for i := range args {
-		args[i] = e.variables.Expand(args[i])
+	// 展开变量（不修改 AST，循环条件需要每次重新展开）
+	args := make([]string, len(expr.Args))
+	for i, arg := range expr.Args {
+		args[i] = e.expandWord(ctx, arg)
 	}
 
 	switch op {
@@ -365,14 +872,20 @@ func (e *Executor) evaluateTest(expr *TestExpr) bool {
 		}
 		return e.compareNumbers(args[0], op, args[1])
 
-	case "==", "!=":
+	case "=", "==", "!=", "<", ">":
 		if len(args) < 2 {
 			return false
 		}
-		if op == "==" {
+		switch op {
+		case "!=":
+			return args[0] != args[1]
+		case "<":
+			return args[0] < args[1]
+		case ">":
+			return args[0] > args[1]
+		default:
 			return args[0] == args[1]
 		}
-		return args[0] != args[1]
 
 	default:
 		// 默认：非空为真
@@ -408,41 +921,47 @@ func (e *Executor) compareNumbers(a, op, b string) bool {
 }
 
 // evaluateBinaryExpr 评估二元表达式
-func (e *Executor) evaluateBinaryExpr(expr *BinaryExpr) bool {
-	left := e.evaluateCondition(context.Background(), expr.Left)
+func (e *Executor) evaluateBinaryExpr(ctx context.Context, expr *BinaryExpr) (bool, error) {
+	left, err := e.evaluateCondition(ctx, expr.Left)
+	if err != nil {
+		return false, err
+	}
 
 	switch expr.Operator {
 	case "&&":
 		if !left {
-			return false
+			return false, nil
 		}
-		return e.evaluateCondition(context.Background(), expr.Right)
+		return e.evaluateCondition(ctx, expr.Right)
 	case "||":
 		if left {
-			return true
+			return true, nil
 		}
-		return e.evaluateCondition(context.Background(), expr.Right)
+		return e.evaluateCondition(ctx, expr.Right)
 	default:
-		return false
+		return false, nil
 	}
 }
 
 // evaluateUnaryExpr 评估一元表达式
-func (e *Executor) evaluateUnaryExpr(expr *UnaryExpr) bool {
-	result := e.evaluateCondition(context.Background(), expr.Operand)
+func (e *Executor) evaluateUnaryExpr(ctx context.Context, expr *UnaryExpr) (bool, error) {
+	result, err := e.evaluateCondition(ctx, expr.Operand)
+	if err != nil {
+		return false, err
+	}
 
 	if expr.Operator == "!" {
-		return !result
+		return !result, nil
 	}
 
-	return result
+	return result, nil
 }
 
 // evaluateExpression 评估表达式并返回字符串值
 func (e *Executor) evaluateExpression(ctx context.Context, expr Expression) string {
 	switch exp := expr.(type) {
 	case *StringLiteral:
-		return e.variables.Expand(exp.Value)
+		return e.expandWord(ctx, exp.Value)
 	case *Variable:
 		val, _ := e.variables.Get(exp.Name)
 		return val
@@ -451,17 +970,138 @@ func (e *Executor) evaluateExpression(ctx context.Context, expr Expression) stri
 	}
 }
 
+// expandWord 展开单词为一个字符串，用于赋值、条件测试等不分割字段的场合
+func (e *Executor) expandWord(ctx context.Context, word string) string {
+	return e.newExpander(ctx, false).expandString(word)
+}
+
+// expandWords 展开单词列表并按 IFS 分割字段，用于命令参数和 for 列表
+func (e *Executor) expandWords(ctx context.Context, words []string) []string {
+	x := e.newExpander(ctx, true)
+	var fields []string
+	for _, word := range words {
+		fields = append(fields, x.expand(word)...)
+	}
+	return fields
+}
+
+// newExpander 创建使用当前变量和命令替换的单词展开器
+func (e *Executor) newExpander(ctx context.Context, split bool) *expander {
+	substitute := func(command string) string {
+		return e.substitute(ctx, command)
+	}
+	return newExpander(e.variables, substitute, split)
+}
+
+// substitute 在子 shell 中执行命令替换 $(...)，返回标准输出并去除末尾换行
+func (e *Executor) substitute(ctx context.Context, source string) string {
+	var out bytes.Buffer
+	std := streams.From(ctx)
+	subCtx := streams.With(ctx, &streams.Streams{Stdin: std.Stdin, Stdout: &out, Stderr: std.Stderr})
+
+	sub := e.subshell()
+	err := sub.ExecuteLine(subCtx, source)
+	if err != nil {
+		reportError(std.Stderr, err)
+		e.setStatus(ExitCodeOf(err))
+	} else {
+		e.setStatus(sub.LastExitCode())
+	}
+
+	return strings.TrimRight(out.String(), "\n")
+}
+
+// reportError 报告不会中止执行的错误（管道中前面的命令、命令替换）
+//
+// 外部命令自己输出错误信息，写入已关闭管道的错误是正常的提前结束，都不再重复报告。
+func reportError(w io.Writer, err error) {
+	if IsSilent(err) || errors.Is(err, io.ErrClosedPipe) {
+		return
+	}
+	fmt.Fprintf(w, "lish: %v\n", err)
+}
+
+// onlyStatus 判断语句的错误是否只是命令的非零退出码
+//
+// 和没有 set -e 的 bash 一样，命令失败只设置 $?，脚本继续执行下一条语句；
+// try 块中的失败转到 catch。找不到命令、exit、Ctrl+C、递归过深等错误仍然中止执行。
+func (e *Executor) onlyStatus(ctx context.Context, err error) bool {
+	if e.tryDepth > 0 || ctx.Err() != nil || !IsSilent(err) {
+		return false
+	}
+	var exit ExitRequest
+	if errors.As(err, &exit) || errors.Is(err, ErrUnknownCommand) {
+		return false
+	}
+	e.setStatus(ExitCodeOf(err))
+	return true
+}
+
+// IsSilent 判断错误是否只表示退出码（外部命令的非零退出码、ExitStatus
+// 或 exit 请求），错误信息已由命令自己输出或不需要输出
+func IsSilent(err error) bool {
+	var exitErr *exec.ExitError
+	var status ExitStatus
+	var exit ExitRequest
+	return errors.As(err, &exitErr) || errors.As(err, &status) || errors.As(err, &exit)
+}
+
+// setStatus 设置上一个命令的退出码 $?
+func (e *Executor) setStatus(code int) {
+	e.variables.SetStatus(code)
+}
+
 // GetVariable 获取变量值
 func (e *Executor) GetVariable(name string) (string, bool) {
 	return e.variables.Get(name)
 }
 
 // SetVariable 设置变量值
-func (e *Executor) SetVariable(name, value string) {
-	e.variables.Set(name, value)
+func (e *Executor) SetVariable(name, value string) error {
+	return e.variables.Set(name, value)
+}
+
+// Variables 返回会话共享的变量存储
+func (e *Executor) Variables() *VariableManager {
+	return e.variables
+}
+
+// Environ 返回传递给子进程的环境变量
+func (e *Executor) Environ() []string {
+	return e.variables.Environ()
+}
+
+// HasCommand 判断内置命令或外部命令是否存在（不包括函数）
+//
+// 命令执行器没有实现 CommandLookup 时无法判断，视为存在。
+func (e *Executor) HasCommand(name string) bool {
+	if lookup, ok := e.cmdExecutor.(CommandLookup); ok {
+		return lookup.HasCommand(name)
+	}
+	return true
+}
+
+// HasFunction 判断是否定义了指定函数
+func (e *Executor) HasFunction(name string) bool {
+	_, ok := e.functions[name]
+	return ok
+}
+
+// Function 返回函数定义，命名空间内优先查找同一命名空间的函数
+func (e *Executor) Function(name string) (*FunctionDef, bool) {
+	fn, ok := e.lookupFunction(name)
+	if !ok {
+		return nil, false
+	}
+	return fn.def, true
+}
+
+// UnsetFunction 删除函数定义
+func (e *Executor) UnsetFunction(name string) {
+	delete(e.functions, name)
 }
 
 // LastExitCode 获取上一个命令的退出码
 func (e *Executor) LastExitCode() int {
-	return e.lastExit
+	return e.variables.Status()
 }
@@ -0,0 +1,266 @@
+package script
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Lingbou/Lish/internal/streams"
+)
+
+// testCommands 测试用的命令执行器，提供几个最简单的命令
+type testCommands struct{}
+
+func (testCommands) ExecuteCommand(ctx context.Context, command string, args []string) error {
+	std := streams.From(ctx)
+	switch command {
+	case "echo":
+		fmt.Fprintln(std.Stdout, strings.Join(args, " "))
+	case "cat":
+		_, err := io.Copy(std.Stdout, std.Stdin)
+		return err
+	case "true":
+	case "false":
+		return ExitStatus(1)
+	case "status":
+		code, _ := strconv.Atoi(args[0])
+		if code != 0 {
+			return ExitStatus(code)
+		}
+	case "fail":
+		return errors.New("fail: 出错")
+	case "exit":
+		code := 0
+		if len(args) > 0 {
+			code, _ = strconv.Atoi(args[0])
+		}
+		return ExitRequest(code)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownCommand, command)
+	}
+	return nil
+}
+
+// runScript 执行脚本，返回标准输出、标准错误和退出码
+func runScript(t *testing.T, source string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	ctx := streams.With(context.Background(), &streams.Streams{
+		Stdin:  strings.NewReader(""),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+
+	e := NewExecutor(testCommands{})
+	err := e.ExecuteSource(ctx, "test", source, nil)
+	code := e.LastExitCode()
+	if err != nil {
+		if !IsSilent(err) {
+			t.Fatalf("执行出错: %v", err)
+		}
+		code = ExitCodeOf(err)
+	}
+	return stdout.String(), stderr.String(), code
+}
+
+func TestConditions(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"true literal", `if true; then echo yes; else echo no; fi`, "yes\n"},
+		{"false literal", `if false; then echo yes; else echo no; fi`, "no\n"},
+		{"single-word command runs", `if echo ran; then echo yes; fi`, "ran\nyes\n"},
+		{"failing command", `if status 2; then echo yes; else echo no $?; fi`, "no 2\n"},
+		{"command with arguments", `if status 0; then echo yes; fi`, "yes\n"},
+		{"function condition", "function ok() { return 0; }\nif ok; then echo yes; fi", "yes\n"},
+		{"function returning non-zero", "function f() { return 1; }\nif f; then echo yes; else echo no; fi", "no\n"},
+		{"function ending in failed status", "function f() { status 3; }\nif f; then echo yes; else echo no $?; fi", "no 3\n"},
+		{"negation", `if ! false; then echo yes; fi`, "yes\n"},
+		{"and", `if true && status 1; then echo yes; else echo no; fi`, "no\n"},
+		{"or", `if status 1 || true; then echo yes; fi`, "yes\n"},
+		{"test brackets", "x=5\nif [ $x -gt 3 ]; then echo big; fi", "big\n"},
+		{"string test", "s=abc\nif [ $s = abc ]; then echo same; fi", "same\n"},
+		{"variable literal", "verbose=1\nif $verbose; then echo on; fi", "on\n"},
+		{"empty variable literal", "verbose=\nif $verbose; then echo on; else echo off; fi", "off\n"},
+		{"elif", `if false; then echo a; elif true; then echo b; else echo c; fi`, "b\n"},
+		{"while with command", "function more() { return $1; }\nn=0\nwhile more $n; do echo loop; n=1; done", "loop\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, _ := runScript(t, tt.source)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionReturn(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		code   int
+	}{
+		{"return zero continues", "function f() { return 0; }\nf\necho after", "after\n", 0},
+		{"return sets status", "function f() { return 3; }\nf\necho $?", "3\n", 0},
+		{"return status is the script status", "function f() { return 2; }\nf", "", 2},
+		{"return code is caught", "function f() { return 3; }\ntry { f; } catch { echo caught $?; }", "caught 3\n", 0},
+		{"return code wraps", "function f() { return $1; }\nf 300", "", 44},
+		{"return without value keeps status", "function f() { try { status 4; } catch { return; } }\nf", "", 4},
+		{"return stops the function", "function f() { echo in; return 0; echo no; }\nf", "in\n", 0},
+		{"status of last command", "function f() { echo in; }\nf\necho $?", "in\n0\n", 0},
+		{"top-level return ends the script", "echo a\nreturn 0\necho b", "a\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, code := runScript(t, tt.source)
+			if got != tt.want || code != tt.code {
+				t.Errorf("got %q (exit %d), want %q (exit %d)", got, code, tt.want, tt.code)
+			}
+		})
+	}
+}
+
+func TestExit(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		code   int
+	}{
+		{"top-level exit", "echo a\nexit 3\necho b", "a\n", 3},
+		{"exit without code", "exit\necho b", "", 0},
+		{"exit in function ends the script", "function f() { exit 5; }\nf\necho no", "", 5},
+		{"exit is not caught", "try { exit 6; } catch { echo caught; }\necho no", "", 6},
+		{"exit in condition", "if exit 7; then echo yes; fi\necho no", "", 7},
+		{"command substitution", "x=$(echo hi; exit 3)\necho $x $?\necho after", "hi 3\nafter\n", 0},
+		{"pipeline stage", "exit 2 | cat\necho after", "after\n", 0},
+		{"last pipeline stage", "echo hi | exit 0\necho after", "after\n", 0},
+		{"failing last pipeline stage", "echo hi | exit 4\necho still $?", "still 4\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, code := runScript(t, tt.source)
+			if got != tt.want || code != tt.code {
+				t.Errorf("got %q (exit %d), want %q (exit %d)", got, code, tt.want, tt.code)
+			}
+		})
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"data flows through stages", "echo hi | cat | cat", "hi\n"},
+		{"last stage runs in the current shell", "function g() { Z=1; }\necho y | g\necho [$Z]", "[1]\n"},
+		{"earlier stages run in a subshell", "function g() { Z=1; }\ng | cat\necho [$Z]", "[]\n"},
+		{"status of the last stage", "status 3 | true\necho $?\ntrue | status 4\necho $?", "0\n4\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, _ := runScript(t, tt.source)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommandFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		code   int
+	}{
+		{"failure sets status and continues", "status 3\necho $?", "3\n", 0},
+		{"last command sets script status", "echo a\nstatus 2", "a\n", 2},
+		{"failure in function body continues", "function f() { status 1; echo in; }\nf", "in\n", 0},
+		{"failure in loop continues", "for i in a b; do\nstatus 1\necho $i\ndone", "a\nb\n", 0},
+		{"failure in try stops the block", "try { status 1; echo no; } catch { echo caught $?; }", "caught 1\n", 0},
+		{"failure in function called from try", "function f() { status 5; echo no; }\ntry { f; } catch { echo caught $?; }", "caught 5\n", 0},
+		{"command error continues", "fail\necho $?", "1\n", 0},
+		{"unknown command aborts", "nosuch\necho no", "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, code := runScript(t, tt.source)
+			if got != tt.want || code != tt.code {
+				t.Errorf("got %q (exit %d), want %q (exit %d)", got, code, tt.want, tt.code)
+			}
+		})
+	}
+}
+
+func TestAbortingErrors(t *testing.T) {
+	for _, source := range []string{
+		"function f() { return x; }\nf\necho no",
+		"FUNCNEST=3\nfunction f() { f; }\nf\necho no",
+		"function f() { local x=1; }\nlocal y=1\necho no",
+	} {
+		var stdout bytes.Buffer
+		ctx := streams.With(context.Background(), &streams.Streams{Stdin: strings.NewReader(""), Stdout: &stdout, Stderr: io.Discard})
+		err := NewExecutor(testCommands{}).ExecuteSource(ctx, "test", source, nil)
+		if err == nil || IsSilent(err) || stdout.Len() > 0 {
+			t.Errorf("%q: err = %v, stdout = %q, want an aborting error", source, err, stdout.String())
+		}
+	}
+}
+
+func TestCallEndsSubshell(t *testing.T) {
+	e := NewExecutor(testCommands{})
+	ctx := streams.With(context.Background(), &streams.Streams{Stdin: strings.NewReader(""), Stdout: io.Discard, Stderr: io.Discard})
+
+	if err := e.Call(ctx, "exit", []string{"0"}); err != nil {
+		t.Errorf("Call(exit 0) = %v, want nil", err)
+	}
+	err := e.Call(ctx, "exit", []string{"3"})
+	var status ExitStatus
+	if !errors.As(err, &status) || status != 3 {
+		t.Errorf("Call(exit 3) = %v, want ExitStatus(3)", err)
+	}
+	if code := e.LastExitCode(); code != 3 {
+		t.Errorf("LastExitCode() = %d, want 3", code)
+	}
+}
+
+func TestCommandErrorOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		wantStdout string
+		wantStderr string
+		code       int
+	}{
+		{"error goes to stderr", "fail", "", "lish: 行 1: fail: 出错\n", 1},
+		{"error follows redirection", "fail 2>&1 | cat\necho after", "lish: 行 1: fail: 出错\nafter\n", "", 0},
+		{"catch keeps the message", "try { fail; } catch err { echo $err; }", "fail: 出错\n", "lish: 行 1: fail: 出错\n", 0},
+		{"exit status is silent", "status 3", "", "", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr, code := runScript(t, tt.source)
+			if stdout != tt.wantStdout || stderr != tt.wantStderr || code != tt.code {
+				t.Errorf("got %q, %q (exit %d), want %q, %q (exit %d)",
+					stdout, stderr, code, tt.wantStdout, tt.wantStderr, tt.code)
+			}
+		})
+	}
+}
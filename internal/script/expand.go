@@ -0,0 +1,290 @@
+package script
+
+import "strings"
+
+// defaultIFS 未设置 IFS 时使用的字段分隔符
+const defaultIFS = " \t\n"
+
+// expander 单词展开器
+//
+// 依次完成变量替换、命令替换、字段分割和引号去除。只有未加引号的
+// 展开结果才按 IFS 分割；"$@" 展开为每个位置参数各自一个字段，
+// "$*" 用 IFS 的第一个字符把所有参数连接成一个字段；"$@" 在没有
+// 位置参数时不产生字段，而 "" 产生一个空字段。
+type expander struct {
+	vars       *VariableManager
+	substitute func(command string) string // 执行命令替换，nil 时展开为空
+	split      bool                        // 是否进行字段分割
+	ifs        string
+
+	fields   []string
+	cur      strings.Builder
+	hasField bool // 当前字段已经存在（可能是空字符串）
+	sawAt    bool // 当前双引号段中展开过 $@
+}
+
+// newExpander 创建单词展开器
+func newExpander(vars *VariableManager, substitute func(string) string, split bool) *expander {
+	ifs, ok := vars.Get("IFS")
+	if !ok {
+		ifs = defaultIFS
+	}
+	return &expander{
+		vars:       vars,
+		substitute: substitute,
+		split:      split,
+		ifs:        ifs,
+	}
+}
+
+// expandString 展开单词为一个字符串
+func (x *expander) expandString(word string) string {
+	return strings.Join(x.expand(word), " ")
+}
+
+// expand 展开单词，返回分割后的字段
+func (x *expander) expand(word string) []string {
+	x.fields = nil
+	x.cur.Reset()
+	x.hasField = false
+
+	runes := []rune(word)
+	inDouble := false
+
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		switch {
+		case ch == '\'' && !inDouble:
+			end := indexRune(runes, i+1, '\'')
+			x.writeLiteral(string(runes[i+1 : end]))
+			i = end
+		case ch == '"':
+			// "" 产生空字段，"$@" 是否产生字段由参数个数决定
+			if inDouble && !x.sawAt {
+				x.hasField = true
+			}
+			inDouble = !inDouble
+			x.sawAt = false
+		case ch == '\\' && i+1 < len(runes):
+			next := runes[i+1]
+			if inDouble && !strings.ContainsRune("$`\"\\\n", next) {
+				x.writeLiteral(string(ch))
+				continue
+			}
+			if next != '\n' {
+				x.writeLiteral(string(next))
+			}
+			i++
+		case ch == '$':
+			n := x.expandDollar(runes[i+1:], inDouble)
+			if n == 0 {
+				x.writeLiteral(string(ch))
+				continue
+			}
+			i += n
+		default:
+			x.writeLiteral(string(ch))
+		}
+	}
+
+	x.endField()
+	return x.fields
+}
+
+// expandDollar 展开 $ 之后的引用，返回消耗的字符数（0 表示不是引用）
+func (x *expander) expandDollar(runes []rune, quoted bool) int {
+	ref, n := scanReference(runes)
+	if n == 0 {
+		return 0
+	}
+
+	if !ref.Substitution {
+		x.writeParam(ref.Name, quoted)
+		return n
+	}
+
+	output := ""
+	if x.substitute != nil {
+		output = x.substitute(ref.Command)
+	}
+	x.writeValue(output, quoted)
+	return n
+}
+
+// writeParam 写入变量或参数的值
+func (x *expander) writeParam(name string, quoted bool) {
+	if name != "@" && name != "*" {
+		x.writeValue(x.vars.lookup(name), quoted)
+		return
+	}
+
+	params := x.vars.Positional()
+	switch {
+	case !x.split:
+		sep := " "
+		if name == "*" && quoted {
+			sep = x.joiner()
+		}
+		x.writeLiteral(strings.Join(params, sep))
+	case quoted && name == "@":
+		x.sawAt = true
+		for i, param := range params {
+			if i > 0 {
+				x.endField()
+			}
+			x.writeLiteral(param)
+		}
+	case quoted:
+		x.writeLiteral(strings.Join(params, x.joiner()))
+	default:
+		for i, param := range params {
+			if i > 0 {
+				x.endField()
+			}
+			x.writeSplit(param)
+		}
+	}
+}
+
+// writeValue 写入展开结果，未加引号时按 IFS 分割
+func (x *expander) writeValue(value string, quoted bool) {
+	if quoted || !x.split {
+		x.writeLiteral(value)
+		return
+	}
+	x.writeSplit(value)
+}
+
+// writeLiteral 原样写入当前字段
+func (x *expander) writeLiteral(s string) {
+	x.cur.WriteString(s)
+	x.hasField = true
+}
+
+// writeSplit 按 IFS 分割写入，分隔符结束当前字段
+func (x *expander) writeSplit(s string) {
+	for _, r := range s {
+		if strings.ContainsRune(x.ifs, r) {
+			x.endField()
+			continue
+		}
+		x.cur.WriteRune(r)
+		x.hasField = true
+	}
+}
+
+// endField 结束当前字段
+func (x *expander) endField() {
+	if !x.hasField {
+		return
+	}
+	x.fields = append(x.fields, x.cur.String())
+	x.cur.Reset()
+	x.hasField = false
+}
+
+// joiner 返回 "$*" 使用的连接符（IFS 的第一个字符）
+func (x *expander) joiner() string {
+	for _, r := range x.ifs {
+		return string(r)
+	}
+	return ""
+}
+
+// matchParen 返回与 runes[0] 处的 ( 匹配的 ) 的位置，未找到时返回 -1
+func matchParen(runes []rune) int {
+	depth := 0
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '\'', '"':
+			i = indexRune(runes, i+1, runes[i])
+		case '\\':
+			i++
+		}
+	}
+	return -1
+}
+
+// Reference 单词中的一个变量引用或命令替换
+type Reference struct {
+	Name         string // 变量名或特殊参数（如 1、@、#）
+	Substitution bool   // 是否是命令替换 $(...)
+	Command      string // 命令替换括号内的源码
+	Quoted       bool   // 是否位于双引号内
+}
+
+// ScanWord 在不执行的情况下分析单词，返回去除引号和引用后的字面文本
+// 以及其中的变量引用和命令替换，供静态检查使用
+func ScanWord(word string) (text string, refs []Reference) {
+	var buf strings.Builder
+	runes := []rune(word)
+	inDouble := false
+
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		switch {
+		case ch == '\'' && !inDouble:
+			end := indexRune(runes, i+1, '\'')
+			buf.WriteString(string(runes[i+1 : end]))
+			i = end
+		case ch == '"':
+			inDouble = !inDouble
+		case ch == '\\' && i+1 < len(runes):
+			buf.WriteRune(runes[i+1])
+			i++
+		case ch == '$':
+			ref, n := scanReference(runes[i+1:])
+			if n == 0 {
+				buf.WriteRune(ch)
+				continue
+			}
+			ref.Quoted = inDouble
+			refs = append(refs, ref)
+			i += n
+		default:
+			buf.WriteRune(ch)
+		}
+	}
+
+	return buf.String(), refs
+}
+
+// scanReference 分析 $ 之后的引用，返回引用和消耗的字符数（0 表示不是引用）
+func scanReference(runes []rune) (Reference, int) {
+	if len(runes) == 0 {
+		return Reference{}, 0
+	}
+
+	switch ch := runes[0]; {
+	case ch == '(':
+		end := matchParen(runes)
+		if end < 0 {
+			return Reference{}, 0
+		}
+		return Reference{Substitution: true, Command: string(runes[1:end])}, end + 1
+	case ch == '{':
+		end := indexRune(runes, 1, '}')
+		if end >= len(runes) {
+			return Reference{}, 0
+		}
+		return Reference{Name: string(runes[1:end])}, end + 1
+	case strings.ContainsRune("#?@*$!-", ch) || isDigit(ch):
+		// 特殊变量和位置参数只取一个字符
+		return Reference{Name: string(ch)}, 1
+	case isLetter(ch) || ch == '_':
+		n := 1
+		for n < len(runes) && (isLetter(runes[n]) || isDigit(runes[n]) || runes[n] == '_') {
+			n++
+		}
+		return Reference{Name: string(runes[:n])}, n
+	default:
+		return Reference{}, 0
+	}
+}
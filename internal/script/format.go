@@ -0,0 +1,323 @@
+package script
+
+import (
+	"math"
+	"strings"
+)
+
+// formatIndent 格式化输出每层缩进使用的字符串
+const formatIndent = "    "
+
+// Format 把脚本源码格式化为统一的风格
+//
+// 每条语句占一行，then、do 与 if、for、while 写在同一行，函数统一写成
+// name() { ... }，块内缩进四个空格，连续的空行合并为一行。注释保留在
+// 原来的位置：独占一行的注释放在下一条语句之前，行尾注释跟在源码同一行
+// 最后一项内容之后。源码有语法错误时返回 *ParseError。
+func Format(source string) (string, error) {
+	program, err := Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	p := &printer{comments: scanComments(source), first: true}
+	p.statements(program.Statements)
+	p.flush(math.MaxInt)
+
+	if p.buf.Len() == 0 {
+		return "", nil
+	}
+	p.buf.WriteString("\n")
+	return p.buf.String(), nil
+}
+
+// FormatStatement 按 Format 的风格格式化一条语句（不含注释），用于显示函数定义等
+func FormatStatement(stmt Statement) string {
+	p := &printer{first: true}
+	p.statement(stmt)
+	return p.buf.String()
+}
+
+// comment 源码中的一条注释
+type comment struct {
+	line     int
+	text     string
+	trailing bool // 同一行前面还有其他内容
+}
+
+// scanComments 收集源码中的注释，语法分析时注释会被丢弃
+func scanComments(source string) []comment {
+	var comments []comment
+	lexer := NewLexer(source)
+	prev := TOKEN_NEWLINE
+
+	for tok := lexer.NextToken(); tok.Type != TOKEN_EOF; tok = lexer.NextToken() {
+		if tok.Type == TOKEN_COMMENT {
+			comments = append(comments, comment{
+				line:     tok.Line,
+				text:     strings.TrimRight(tok.Literal, " \t\r"),
+				trailing: prev != TOKEN_NEWLINE,
+			})
+		}
+		prev = tok.Type
+	}
+
+	return comments
+}
+
+// printer 把 AST 输出为格式化的源码
+type printer struct {
+	buf      strings.Builder
+	depth    int
+	comments []comment
+	next     int  // 下一条未输出的注释
+	last     int  // 最后输出的内容在源码中的行号
+	pending  int  // 当前输出行末尾的内容所在的源码行，该行的行尾注释还没有输出
+	first    bool // 当前块中还没有输出任何内容
+}
+
+// startLine 开始新的一行，源码中与上一项之间有空行时保留一个空行
+func (p *printer) startLine(line int) {
+	if p.buf.Len() > 0 {
+		p.buf.WriteString("\n")
+		if !p.first && line > p.last+1 {
+			p.buf.WriteString("\n")
+		}
+	}
+	p.buf.WriteString(strings.Repeat(formatIndent, p.depth))
+	p.first = false
+}
+
+// flush 输出 line 行之前的注释，每条独占一行
+func (p *printer) flush(line int) {
+	p.settle(line)
+	for p.next < len(p.comments) && p.comments[p.next].line < line {
+		c := p.comments[p.next]
+		p.startLine(c.line)
+		p.buf.WriteString(c.text)
+		p.last = c.line
+		p.next++
+	}
+}
+
+// trailing 记录当前行末尾的内容来自源码第 line 行。行尾注释要等到确定
+// 同一行源码后面没有其他内容时才输出，这样一行写完的 for、函数等块的
+// 注释跟在 done、} 之后，而不是跟在 do、{ 之后
+func (p *printer) trailing(line int) {
+	p.last = line
+	p.pending = line
+}
+
+// settle 下一项内容从源码第 line 行开始时，输出上一行末尾的行尾注释
+func (p *printer) settle(line int) {
+	if p.pending == 0 || line <= p.pending {
+		return
+	}
+	if p.next < len(p.comments) && p.comments[p.next].line <= p.pending {
+		p.buf.WriteString(" " + p.comments[p.next].text)
+		p.next++
+	}
+	p.pending = 0
+}
+
+// statements 逐行输出语句列表
+func (p *printer) statements(stmts []Statement) {
+	for _, stmt := range stmts {
+		p.flush(stmt.StartLine())
+		p.startLine(stmt.StartLine())
+		p.statement(stmt)
+	}
+}
+
+// block 输出缩进的语句块和结束关键字，end 是结束关键字所在的行
+func (p *printer) block(stmts []Statement, closer string, end int) {
+	p.depth++
+	p.first = true
+	p.statements(stmts)
+	p.flush(end)
+	p.depth--
+
+	if closer == "" {
+		return
+	}
+	p.header(closer, end, false)
+}
+
+// header 输出复合语句的关键字行（如 if ...; then、else、fi），first 表示
+// 位于语句的第一行，line 是关键字在源码中的行
+func (p *printer) header(text string, line int, first bool) {
+	if !first {
+		p.buf.WriteString("\n" + strings.Repeat(formatIndent, p.depth))
+	}
+	p.buf.WriteString(text)
+	p.first = false
+	p.trailing(line)
+}
+
+// statement 输出一条语句，多行语句的后续行按当前缩进对齐
+func (p *printer) statement(stmt Statement) {
+	switch s := stmt.(type) {
+	case *IfStatement:
+		// 每个分支的注释输出到下一个分支关键字之前
+		ends := []int{}
+		for _, elif := range s.ElseIfList {
+			ends = append(ends, elif.Line)
+		}
+		if s.ElseLine > 0 {
+			ends = append(ends, s.ElseLine)
+		}
+		ends = append(ends, s.EndLine())
+
+		p.header("if "+formatExpression(s.Condition)+"; then", s.StartLine(), true)
+		p.block(s.ThenBlock, "", ends[0])
+		for i, elif := range s.ElseIfList {
+			p.header("elif "+formatExpression(elif.Condition)+"; then", elif.Line, false)
+			p.block(elif.Block, "", ends[i+1])
+		}
+		if s.ElseLine > 0 {
+			p.header("else", s.ElseLine, false)
+			p.block(s.ElseBlock, "", s.EndLine())
+		}
+		p.header("fi", s.EndLine(), false)
+	case *ForStatement:
+		p.header("for "+s.Variable+" in "+strings.Join(s.List, " ")+"; do", s.StartLine(), true)
+		p.block(s.Block, "done", s.EndLine())
+	case *WhileStatement:
+		p.header("while "+formatExpression(s.Condition)+"; do", s.StartLine(), true)
+		p.block(s.Block, "done", s.EndLine())
+	case *TryStatement:
+		// 每个块的注释输出到下一个块的关键字之前
+		ends := []int{}
+		if s.CatchLine > 0 {
+			ends = append(ends, s.CatchLine)
+		}
+		if s.FinallyLine > 0 {
+			ends = append(ends, s.FinallyLine)
+		}
+		ends = append(ends, s.EndLine())
+
+		p.header("try {", s.StartLine(), true)
+		p.block(s.Block, "", ends[0])
+		if s.CatchLine > 0 {
+			header := "} catch {"
+			if s.CatchVar != "" {
+				header = "} catch " + s.CatchVar + " {"
+			}
+			p.header(header, s.CatchLine, false)
+			p.block(s.CatchBlock, "", ends[1])
+		}
+		if s.FinallyLine > 0 {
+			p.header("} finally {", s.FinallyLine, false)
+			p.block(s.FinallyBlock, "", s.EndLine())
+		}
+		p.header("}", s.EndLine(), false)
+	case *FunctionDef:
+		p.header(s.Name+"() {", s.StartLine(), true)
+		p.block(s.Block, "}", s.EndLine())
+	case *PipelineStatement:
+		for i, cmd := range s.Commands {
+			if i > 0 {
+				p.buf.WriteString(" | ")
+			}
+			p.inline(cmd)
+		}
+		p.trailing(s.EndLine())
+	default:
+		p.buf.WriteString(formatSimple(stmt))
+		p.trailing(stmt.EndLine())
+	}
+}
+
+// inline 输出管道中的一个命令，复合语句照常换行
+func (p *printer) inline(stmt Statement) {
+	if text := formatSimple(stmt); text != "" {
+		p.buf.WriteString(text)
+		return
+	}
+	p.statement(stmt)
+}
+
+// formatSimple 格式化简单语句，复合语句返回空字符串
+func formatSimple(stmt Statement) string {
+	switch s := stmt.(type) {
+	case *CommandStatement:
+		words := append([]string{s.Command}, s.Args...)
+		for _, r := range s.Redirects {
+			if r.Target == "" {
+				words = append(words, r.Op)
+			} else {
+				words = append(words, r.Op+" "+r.Target)
+			}
+		}
+		return strings.Join(words, " ")
+	case *AssignStatement:
+		return s.Name + "=" + formatValue(s.Value)
+	case *LocalStatement:
+		words := []string{"local"}
+		for _, v := range s.Vars {
+			if v.Value == nil {
+				words = append(words, v.Name)
+			} else {
+				words = append(words, v.Name+"="+formatValue(v.Value))
+			}
+		}
+		return strings.Join(words, " ")
+	case *ImportStatement:
+		if s.Namespace != "" {
+			return "import " + s.Module + " as " + s.Namespace
+		}
+		return "import " + s.Module
+	case *ReturnStatement:
+		if s.Value != nil {
+			return "return " + formatValue(s.Value)
+		}
+		return "return"
+	case *BreakStatement:
+		return "break"
+	case *ContinueStatement:
+		return "continue"
+	}
+	return ""
+}
+
+// formatExpression 格式化条件或赋值表达式
+func formatExpression(expr Expression) string {
+	switch e := expr.(type) {
+	case *StringLiteral:
+		if e.Value == "" {
+			return "[ ]" // 只有空的 [ ] 会解析为空字符串
+		}
+		return e.Value
+	case *Variable:
+		return "$" + e.Name
+	case *TestExpr:
+		if len(e.Args) == 2 && isBinaryTestOp(e.Operator) {
+			return "[ " + e.Args[0] + " " + e.Operator + " " + e.Args[1] + " ]"
+		}
+		return "[ " + strings.Join(append([]string{e.Operator}, e.Args...), " ") + " ]"
+	case *UnaryExpr:
+		return e.Operator + " " + formatExpression(e.Operand)
+	case *BinaryExpr:
+		return formatExpression(e.Left) + " " + e.Operator + " " + formatExpression(e.Right)
+	case *CommandCondition:
+		if pipe, ok := e.Command.(*PipelineStatement); ok {
+			parts := make([]string, len(pipe.Commands))
+			for i, cmd := range pipe.Commands {
+				parts[i] = formatSimple(cmd)
+			}
+			return strings.Join(parts, " | ")
+		}
+		return formatSimple(e.Command)
+	case *CommandSubstitution:
+		return "$(" + strings.Join(append([]string{e.Command}, e.Args...), " ") + ")"
+	}
+	return ""
+}
+
+// formatValue 格式化赋值和 return 的值
+func formatValue(expr Expression) string {
+	if s, ok := expr.(*StringLiteral); ok {
+		return s.Value
+	}
+	return formatExpression(expr)
+}
@@ -0,0 +1,46 @@
+package script
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"indent and blank lines", "if true; then\necho a\n\n\necho b\nfi", "if true; then\n    echo a\n\n    echo b\nfi\n"},
+		{"function keyword", "function f() { echo a; }", "f() {\n    echo a\n}\n"},
+		{"own-line comments", "# top\n\necho a\n# before b\necho b", "# top\n\necho a\n# before b\necho b\n"},
+		{"trailing comments", "echo a # one\nfor i in a; do # loop\necho $i # body\ndone # end", "echo a # one\nfor i in a; do # loop\n    echo $i # body\ndone # end\n"},
+		{"comment after one-line for", "for i in 1 2; do echo $i; done # c\necho x", "for i in 1 2; do\n    echo $i\ndone # c\necho x\n"},
+		{"comment after one-line function", "f() { echo a; } # c", "f() {\n    echo a\n} # c\n"},
+		{"comment after one-line if", "if true; then echo a; else echo b; fi # c", "if true; then\n    echo a\nelse\n    echo b\nfi # c\n"},
+		{"comment after one-line try", "try { echo a; } catch { echo b; } # c", "try {\n    echo a\n} catch {\n    echo b\n} # c\n"},
+		{"comment after several statements", "echo a; echo b # c", "echo a\necho b # c\n"},
+		{"comment in one-line block", "f() {\necho a; echo b # c\n}", "f() {\n    echo a\n    echo b # c\n}\n"},
+		{"line continuation", "echo a \\\n  b # c\necho next", "echo a b # c\necho next\n"},
+		{"multi-line string", "echo \"a\nb\" # c\necho next", "echo \"a\nb\" # c\necho next\n"},
+		{"multi-line assignment", "x=\"a\n\nb\"\necho $x", "x=\"a\n\nb\"\necho $x\n"},
+		{"pipeline", "echo a|cat", "echo a | cat\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.source)
+			if err != nil {
+				t.Fatalf("Format: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+			// 格式化的结果可以重新解析，再次格式化不变
+			again, err := Format(got)
+			if err != nil {
+				t.Fatalf("重新解析: %v", err)
+			}
+			if again != got {
+				t.Errorf("再次格式化得到 %q, want %q", again, got)
+			}
+		})
+	}
+}
@@ -1,10 +1,8 @@
 package script
 
 import (
-	"bufio"
 	"bytes"
-	"io"
-	"strings"
+	"fmt"
 	"unicode"
 )
 
@@ -19,7 +17,7 @@ const (
 
 	// 标识符和字面量
 	TOKEN_IDENT  // 标识符
-	TOKEN_STRING // 字符串
+	TOKEN_WORD   // 普通单词（保留引号原文）
 	TOKEN_NUMBER // 数字
 
 	// 关键字
@@ -38,20 +36,17 @@ const (
 	TOKEN_BREAK
 	TOKEN_CONTINUE
 	TOKEN_LOCAL
+	TOKEN_IMPORT
+	TOKEN_TRY
+	TOKEN_CATCH
+	TOKEN_FINALLY
 
 	// 操作符
-	TOKEN_ASSIGN    // =
-	TOKEN_EQ        // ==
-	TOKEN_NE        // !=
-	TOKEN_LT        // <
-	TOKEN_GT        // >
-	TOKEN_LE        // <=
-	TOKEN_GE        // >=
 	TOKEN_AND       // &&
 	TOKEN_OR        // ||
 	TOKEN_NOT       // !
 	TOKEN_PIPE      // |
-	TOKEN_REDIRECT  // >, >>, <
+	TOKEN_REDIRECT  // >, >>, <, 2>, 2>>, 2>&1, >&2
 	TOKEN_SEMICOLON // ;
 
 	// 分隔符
@@ -72,9 +67,64 @@ type Token struct {
 	Column  int
 }
 
+// tokenNames token 类型的可读名称，用于错误信息
+var tokenNames = map[TokenType]string{
+	TOKEN_EOF:       "文件结尾",
+	TOKEN_ILLEGAL:   "非法字符",
+	TOKEN_COMMENT:   "注释",
+	TOKEN_IDENT:     "标识符",
+	TOKEN_WORD:      "单词",
+	TOKEN_NUMBER:    "数字",
+	TOKEN_IF:        "'if'",
+	TOKEN_THEN:      "'then'",
+	TOKEN_ELIF:      "'elif'",
+	TOKEN_ELSE:      "'else'",
+	TOKEN_FI:        "'fi'",
+	TOKEN_FOR:       "'for'",
+	TOKEN_IN:        "'in'",
+	TOKEN_DO:        "'do'",
+	TOKEN_DONE:      "'done'",
+	TOKEN_WHILE:     "'while'",
+	TOKEN_FUNCTION:  "'function'",
+	TOKEN_RETURN:    "'return'",
+	TOKEN_BREAK:     "'break'",
+	TOKEN_CONTINUE:  "'continue'",
+	TOKEN_LOCAL:     "'local'",
+	TOKEN_IMPORT:    "'import'",
+	TOKEN_TRY:       "'try'",
+	TOKEN_CATCH:     "'catch'",
+	TOKEN_FINALLY:   "'finally'",
+	TOKEN_AND:       "'&&'",
+	TOKEN_OR:        "'||'",
+	TOKEN_NOT:       "'!'",
+	TOKEN_PIPE:      "'|'",
+	TOKEN_REDIRECT:  "重定向",
+	TOKEN_SEMICOLON: "';'",
+	TOKEN_LPAREN:    "'('",
+	TOKEN_RPAREN:    "')'",
+	TOKEN_LBRACE:    "'{'",
+	TOKEN_RBRACE:    "'}'",
+	TOKEN_LBRACKET:  "'['",
+	TOKEN_RBRACKET:  "']'",
+	TOKEN_NEWLINE:   "换行",
+}
+
+// String 返回 token 类型的可读名称
+func (t TokenType) String() string {
+	if name, ok := tokenNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("TokenType(%d)", int(t))
+}
+
 // Lexer 词法分析器
+//
+// 词法规则与 POSIX shell 相近：单词由非空白、非操作符字符组成，引号、
+// 反斜杠转义、$(...) 与 ${...} 都属于单词的一部分。单词的 Literal
+// 保留源码原文（包括引号），引号去除和变量展开在执行阶段完成。
 type Lexer struct {
-	reader *bufio.Reader
+	input  []rune
+	pos    int
 	line   int
 	column int
 	ch     rune
@@ -83,7 +133,7 @@ type Lexer struct {
 // NewLexer 创建新的词法分析器
 func NewLexer(input string) *Lexer {
 	l := &Lexer{
-		reader: bufio.NewReader(strings.NewReader(input)),
+		input:  []rune(input),
 		line:   1,
 		column: 0,
 	}
@@ -93,25 +143,22 @@ func NewLexer(input string) *Lexer {
 
 // readChar 读取下一个字符
 func (l *Lexer) readChar() {
-	ch, _, err := l.reader.ReadRune()
-	if err != nil {
-		if err == io.EOF {
-			l.ch = 0
-		}
+	if l.pos >= len(l.input) {
+		l.ch = 0
+		l.pos = len(l.input) + 1
 		return
 	}
-	l.ch = ch
+	l.ch = l.input[l.pos]
+	l.pos++
 	l.column++
 }
 
 // peekChar 查看下一个字符但不移动位置
 func (l *Lexer) peekChar() rune {
-	ch, _, err := l.reader.ReadRune()
-	if err != nil {
+	if l.pos >= len(l.input) {
 		return 0
 	}
-	l.reader.UnreadRune()
-	return ch
+	return l.input[l.pos]
 }
 
 // NextToken 获取下一个 token
@@ -136,55 +183,6 @@ func (l *Lexer) NextToken() Token {
 		l.line++
 		l.column = 0
 		l.readChar()
-	case '=':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok.Type = TOKEN_EQ
-			tok.Literal = "=="
-			l.readChar()
-		} else {
-			tok.Type = TOKEN_ASSIGN
-			tok.Literal = "="
-			l.readChar()
-		}
-	case '!':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok.Type = TOKEN_NE
-			tok.Literal = "!="
-			l.readChar()
-		} else {
-			tok.Type = TOKEN_NOT
-			tok.Literal = "!"
-			l.readChar()
-		}
-	case '<':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok.Type = TOKEN_LE
-			tok.Literal = "<="
-			l.readChar()
-		} else {
-			tok.Type = TOKEN_LT
-			tok.Literal = "<"
-			l.readChar()
-		}
-	case '>':
-		if l.peekChar() == '=' {
-			l.readChar()
-			tok.Type = TOKEN_GE
-			tok.Literal = ">="
-			l.readChar()
-		} else if l.peekChar() == '>' {
-			l.readChar()
-			tok.Type = TOKEN_REDIRECT
-			tok.Literal = ">>"
-			l.readChar()
-		} else {
-			tok.Type = TOKEN_GT
-			tok.Literal = ">"
-			l.readChar()
-		}
 	case '&':
 		if l.peekChar() == '&' {
 			l.readChar()
@@ -219,39 +217,34 @@ func (l *Lexer) NextToken() Token {
 		tok.Type = TOKEN_RPAREN
 		tok.Literal = ")"
 		l.readChar()
-	case '{':
-		tok.Type = TOKEN_LBRACE
-		tok.Literal = "{"
-		l.readChar()
-	case '}':
-		tok.Type = TOKEN_RBRACE
-		tok.Literal = "}"
-		l.readChar()
-	case '[':
-		tok.Type = TOKEN_LBRACKET
-		tok.Literal = "["
-		l.readChar()
-	case ']':
-		tok.Type = TOKEN_RBRACKET
-		tok.Literal = "]"
-		l.readChar()
-	case '"', '\'':
-		tok.Type = TOKEN_STRING
-		tok.Literal = l.readString(l.ch)
+	case '<', '>':
+		tok.Type = TOKEN_REDIRECT
+		tok.Literal = l.readRedirect("")
 	default:
-		if isLetter(l.ch) || l.ch == '_' || l.ch == '$' {
-			tok.Literal = l.readIdentifier()
-			tok.Type = lookupKeyword(tok.Literal)
+		// { 和 } 只有单独出现时才是操作符，{} 等仍然是普通单词
+		if l.ch == '{' && isWordEnd(l.peekChar()) {
+			tok.Type = TOKEN_LBRACE
+			tok.Literal = "{"
+			l.readChar()
 			return tok
-		} else if isDigit(l.ch) {
-			tok.Type = TOKEN_NUMBER
-			tok.Literal = l.readNumber()
+		}
+		if l.ch == '}' {
+			tok.Type = TOKEN_RBRACE
+			tok.Literal = "}"
+			l.readChar()
 			return tok
-		} else {
-			tok.Type = TOKEN_ILLEGAL
-			tok.Literal = string(l.ch)
+		}
+
+		// 2> 和 2>> 错误重定向
+		if l.ch == '2' && l.peekChar() == '>' {
 			l.readChar()
+			tok.Type = TOKEN_REDIRECT
+			tok.Literal = l.readRedirect("2")
+			return tok
 		}
+
+		tok.Literal = l.readWord()
+		tok.Type = classifyWord(tok.Literal)
 	}
 
 	return tok
@@ -259,7 +252,13 @@ func (l *Lexer) NextToken() Token {
 
 // skipWhitespace 跳过空白字符（不包括换行）
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' {
+	for l.ch == ' ' || l.ch == '\t' || l.ch == '\r' || (l.ch == '\\' && l.peekChar() == '\n') {
+		// 行尾的反斜杠表示续行
+		if l.ch == '\\' {
+			l.readChar()
+			l.line++
+			l.column = 0
+		}
 		l.readChar()
 	}
 }
@@ -274,60 +273,178 @@ func (l *Lexer) readComment() string {
 	return buf.String()
 }
 
-// readIdentifier 读取标识符
-func (l *Lexer) readIdentifier() string {
-	var buf bytes.Buffer
-	for isLetter(l.ch) || isDigit(l.ch) || l.ch == '_' || l.ch == '$' {
-		buf.WriteRune(l.ch)
+// readRedirect 读取重定向操作符（>、>>、<、2>&1、>&2）
+func (l *Lexer) readRedirect(prefix string) string {
+	op := prefix + string(l.ch)
+	if l.ch == '>' && l.peekChar() == '>' {
 		l.readChar()
+		op += ">"
 	}
-	return buf.String()
+	l.readChar()
+
+	// 文件描述符复制
+	if l.ch == '&' && ((op == "2>" && l.peekChar() == '1') || (op == ">" && l.peekChar() == '2')) {
+		l.readChar()
+		op += "&" + string(l.ch)
+		l.readChar()
+	}
+	return op
 }
 
-// readNumber 读取数字
-func (l *Lexer) readNumber() string {
+// readWord 读取一个单词，保留引号和转义的原文
+func (l *Lexer) readWord() string {
 	var buf bytes.Buffer
-	for isDigit(l.ch) {
-		buf.WriteRune(l.ch)
-		l.readChar()
+
+	for !isWordEnd(l.ch) {
+		switch l.ch {
+		case '\\':
+			buf.WriteRune(l.ch)
+			l.readChar()
+			if l.ch != 0 {
+				l.writeChar(&buf)
+			}
+		case '\'':
+			l.readQuoted(&buf, '\'')
+		case '"':
+			l.readQuoted(&buf, '"')
+		case '$':
+			buf.WriteRune(l.ch)
+			l.readChar()
+			switch l.ch {
+			case '(':
+				l.readBalanced(&buf, '(', ')')
+			case '{':
+				l.readBalanced(&buf, '{', '}')
+			}
+		default:
+			buf.WriteRune(l.ch)
+			l.readChar()
+		}
 	}
+
 	return buf.String()
 }
 
-// readString 读取字符串
-func (l *Lexer) readString(quote rune) string {
-	var buf bytes.Buffer
-	l.readChar() // 跳过开始引号
+// readQuoted 读取引号内的内容（包括两侧引号）
+func (l *Lexer) readQuoted(buf *bytes.Buffer, quote rune) {
+	buf.WriteRune(l.ch)
+	l.readChar()
 
 	for l.ch != quote && l.ch != 0 {
-		if l.ch == '\\' {
+		switch {
+		case quote == '"' && l.ch == '\\':
+			buf.WriteRune(l.ch)
 			l.readChar()
-			// 处理转义字符
-			switch l.ch {
-			case 'n':
-				buf.WriteRune('\n')
-			case 't':
-				buf.WriteRune('\t')
-			case 'r':
-				buf.WriteRune('\r')
-			case '\\':
-				buf.WriteRune('\\')
-			case quote:
-				buf.WriteRune(quote)
-			default:
-				buf.WriteRune(l.ch)
+			if l.ch != 0 {
+				l.writeChar(buf)
 			}
-		} else {
+		case quote == '"' && l.ch == '$' && l.peekChar() == '(':
 			buf.WriteRune(l.ch)
+			l.readChar()
+			l.readBalanced(buf, '(', ')')
+		default:
+			l.writeChar(buf)
 		}
-		l.readChar()
 	}
 
 	if l.ch == quote {
-		l.readChar() // 跳过结束引号
+		buf.WriteRune(l.ch)
+		l.readChar()
 	}
+}
 
-	return buf.String()
+// readBalanced 读取成对括号包围的内容（如 $(...)、${...}），支持嵌套
+func (l *Lexer) readBalanced(buf *bytes.Buffer, open, close rune) {
+	depth := 0
+	for l.ch != 0 {
+		switch l.ch {
+		case open:
+			depth++
+		case close:
+			depth--
+		case '\'', '"':
+			l.readQuoted(buf, l.ch)
+			continue
+		case '\\':
+			buf.WriteRune(l.ch)
+			l.readChar()
+			if l.ch == 0 {
+				return
+			}
+		}
+
+		l.writeChar(buf)
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// writeChar 写入当前字符并前进，同时维护行号
+func (l *Lexer) writeChar(buf *bytes.Buffer) {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	buf.WriteRune(l.ch)
+	l.readChar()
+}
+
+// isWordEnd 判断字符是否结束一个单词
+func isWordEnd(ch rune) bool {
+	switch ch {
+	case 0, ' ', '\t', '\r', '\n', ';', '|', '&', '(', ')', '<', '>':
+		return true
+	}
+	return false
+}
+
+// classifyWord 根据单词内容确定 token 类型
+func classifyWord(word string) TokenType {
+	switch word {
+	case "[":
+		return TOKEN_LBRACKET
+	case "]":
+		return TOKEN_RBRACKET
+	case "!":
+		return TOKEN_NOT
+	}
+
+	if isIdentifier(word) {
+		return lookupKeyword(word)
+	}
+
+	if isNumber(word) {
+		return TOKEN_NUMBER
+	}
+
+	return TOKEN_WORD
+}
+
+// isIdentifier 判断字符串是否是合法的标识符（变量名、函数名）
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, ch := range s {
+		if !(isLetter(ch) || ch == '_' || (i > 0 && isDigit(ch))) {
+			return false
+		}
+	}
+	return true
+}
+
+// isNumber 判断字符串是否全部由数字组成
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, ch := range s {
+		if !isDigit(ch) {
+			return false
+		}
+	}
+	return true
 }
 
 // isLetter 判断是否是字母
@@ -358,6 +475,10 @@ func lookupKeyword(ident string) TokenType {
 		"break":    TOKEN_BREAK,
 		"continue": TOKEN_CONTINUE,
 		"local":    TOKEN_LOCAL,
+		"import":   TOKEN_IMPORT,
+		"try":      TOKEN_TRY,
+		"catch":    TOKEN_CATCH,
+		"finally":  TOKEN_FINALLY,
 	}
 
 	if tok, ok := keywords[ident]; ok {
@@ -365,3 +486,8 @@ func lookupKeyword(ident string) TokenType {
 	}
 	return TOKEN_IDENT
 }
+
+// IsKeyword 判断单词是否是脚本关键字
+func IsKeyword(word string) bool {
+	return lookupKeyword(word) != TOKEN_IDENT
+}
@@ -0,0 +1,80 @@
+package script
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FindScript 按 source 和 import 的规则查找脚本文件
+//
+// 包含路径分隔符的名称直接按路径查找；否则先查找当前目录，再依次查找
+// LISH_PATH 中的目录（以 os.PathListSeparator 分隔）。名称没有扩展名时
+// 同时尝试添加 .lish 扩展名。
+func (e *Executor) FindScript(name string) (string, error) {
+	candidates := []string{name}
+	if filepath.Ext(name) == "" {
+		candidates = append(candidates, name+".lish")
+	}
+
+	dirs := []string{""}
+	if !strings.ContainsRune(name, '/') && !strings.ContainsRune(name, filepath.Separator) {
+		if value, ok := e.variables.Get("LISH_PATH"); ok {
+			dirs = append(dirs, filepath.SplitList(value)...)
+		}
+	}
+
+	for i, dir := range dirs {
+		if i > 0 && dir == "" {
+			continue
+		}
+		for _, candidate := range candidates {
+			path := filepath.Join(dir, candidate)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+	}
+
+	if len(dirs) > 1 {
+		return "", fmt.Errorf("%s: 在当前目录和 LISH_PATH 中都未找到", name)
+	}
+	return "", fmt.Errorf("%s: 文件不存在", name)
+}
+
+// executeImport 加载模块，同一模块在一个会话中只加载一次
+//
+// 模块在当前 shell 中执行；指定命名空间时，模块定义的函数以
+// "命名空间.函数名" 注册，模块内部的函数仍可以用短名称互相调用。
+func (e *Executor) executeImport(ctx context.Context, stmt *ImportStatement) error {
+	module := e.expandWord(ctx, stmt.Module)
+	path, err := e.FindScript(module)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	key := path
+	if abs, err := filepath.Abs(path); err == nil {
+		key = abs
+	}
+	key = stmt.Namespace + ":" + key
+	if e.imported[key] {
+		return nil
+	}
+
+	// 执行之前标记，循环导入时不会重复加载
+	e.imported[key] = true
+
+	savedNamespace := e.namespace
+	e.namespace = stmt.Namespace
+	defer func() { e.namespace = savedNamespace }()
+
+	if err := e.ExecuteFile(ctx, path, nil); err != nil {
+		// 加载失败的模块可以在修复后重新导入
+		delete(e.imported, key)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package script
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeModule 在 dir 中创建模块文件
+func writeModule(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "greet.lish", "echo loaded\nfunction hello() { echo \"hello $1\"; helper; }\nfunction helper() { echo helper; }")
+	writeModule(t, dir, "loop.lish", "import loop\necho loop")
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		code   int
+	}{
+		{"import from LISH_PATH", "import greet\nhello bob", "loaded\nhello bob\nhelper\n", 0},
+		{"module is loaded once", "import greet\nimport greet.lish\nhello x", "loaded\nhello x\nhelper\n", 0},
+		{"namespace", "import greet as g\ng.hello x", "loaded\nhello x\nhelper\n", 0},
+		{"namespace hides short names", "import greet as g\nhello x", "loaded\n", 1},
+		{"same module in two namespaces", "import greet as a\nimport greet as b\nb.hello y", "loaded\nloaded\nhello y\nhelper\n", 0},
+		{"circular import", "import loop", "loop\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, code := runScript(t, "LISH_PATH="+dir+"\n"+tt.source)
+			if got != tt.want || code != tt.code {
+				t.Errorf("got %q (exit %d), want %q (exit %d)", got, code, tt.want, tt.code)
+			}
+		})
+	}
+}
+
+func TestFindScript(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	writeModule(t, first, "a.lish", "")
+	writeModule(t, second, "a.lish", "")
+	writeModule(t, second, "b.lish", "")
+	writeModule(t, second, "c", "")
+
+	e := NewExecutor(testCommands{})
+	if err := e.variables.Set("LISH_PATH", first+string(os.PathListSeparator)+second); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"a", filepath.Join(first, "a.lish")},
+		{"b", filepath.Join(second, "b.lish")},
+		{"b.lish", filepath.Join(second, "b.lish")},
+		{"c", filepath.Join(second, "c")},
+		{filepath.Join(second, "a"), filepath.Join(second, "a.lish")},
+	}
+	for _, tt := range tests {
+		got, err := e.FindScript(tt.name)
+		if err != nil || got != tt.want {
+			t.Errorf("FindScript(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, err := e.FindScript("missing"); err == nil {
+		t.Error("FindScript(missing) succeeded, want error")
+	}
+}
@@ -5,19 +5,63 @@ import (
 	"strings"
 )
 
+// ParseError 脚本解析错误
+type ParseError struct {
+	Errors     []string
+	Details    []SyntaxError // 与 Errors 一一对应，带有位置信息
+	Incomplete bool          // 输入在语句结束前就结束了（如缺少 fi、done、}）
+}
+
+// SyntaxError 一条语法错误及其位置
+type SyntaxError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e SyntaxError) String() string {
+	return fmt.Sprintf("行 %d:%d - %s", e.Line, e.Column, e.Message)
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("解析错误: %s", strings.Join(e.Errors, "\n"))
+}
+
+// Parse 解析脚本源码，有语法错误时返回 *ParseError
+func Parse(source string) (*Program, error) {
+	parser := NewParser(source)
+	program := parser.Parse()
+	if len(parser.Errors()) > 0 {
+		return program, &ParseError{
+			Errors:     parser.Errors(),
+			Details:    parser.errors,
+			Incomplete: parser.incomplete,
+		}
+	}
+	return program, nil
+}
+
+// IsIncomplete 判断源码是否是未输入完的语句，交互模式据此继续读取下一行
+func IsIncomplete(source string) bool {
+	_, err := Parse(source)
+	parseErr, ok := err.(*ParseError)
+	return ok && parseErr.Incomplete
+}
+
 // Parser 语法分析器
 type Parser struct {
-	lexer     *Lexer
-	curToken  Token
-	peekToken Token
-	errors    []string
+	lexer      *Lexer
+	curToken   Token
+	peekToken  Token
+	errors     []SyntaxError
+	incomplete bool
 }
 
 // NewParser 创建新的语法分析器
 func NewParser(input string) *Parser {
 	p := &Parser{
 		lexer:  NewLexer(input),
-		errors: []string{},
+		errors: []SyntaxError{},
 	}
 
 	// 读取两个 token，初始化 curToken 和 peekToken
@@ -40,13 +84,23 @@ func (p *Parser) nextToken() {
 
 // Errors 返回解析错误
 func (p *Parser) Errors() []string {
-	return p.errors
+	messages := make([]string, len(p.errors))
+	for i, e := range p.errors {
+		messages[i] = e.String()
+	}
+	return messages
 }
 
 // addError 添加错误信息
 func (p *Parser) addError(msg string) {
-	p.errors = append(p.errors, fmt.Sprintf("行 %d:%d - %s",
-		p.curToken.Line, p.curToken.Column, msg))
+	if len(p.errors) == 0 {
+		p.incomplete = p.curToken.Type == TOKEN_EOF
+	}
+	p.errors = append(p.errors, SyntaxError{
+		Line:    p.curToken.Line,
+		Column:  p.curToken.Column,
+		Message: msg,
+	})
 }
 
 // expectToken 期望特定类型的 token
@@ -59,21 +113,31 @@ func (p *Parser) expectToken(t TokenType) bool {
 	return false
 }
 
+// skipSeparators 跳过换行符和分号
+func (p *Parser) skipSeparators() {
+	for p.curToken.Type == TOKEN_NEWLINE || p.curToken.Type == TOKEN_SEMICOLON {
+		p.nextToken()
+	}
+}
+
 // skipNewlines 跳过换行符
 func (p *Parser) skipNewlines() {
-	for p.curToken.Type == TOKEN_NEWLINE || p.peekToken.Type == TOKEN_NEWLINE {
+	for p.curToken.Type == TOKEN_NEWLINE {
 		p.nextToken()
 	}
 }
 
 // Parse 解析脚本并返回 AST
+//
+// 各 parseXxx 方法约定：开始时 curToken 位于语句的第一个 token，
+// 返回时 curToken 位于语句的最后一个 token。
 func (p *Parser) Parse() *Program {
 	program := &Program{
 		Statements: []Statement{},
 	}
 
 	for p.curToken.Type != TOKEN_EOF {
-		p.skipNewlines()
+		p.skipSeparators()
 
 		if p.curToken.Type == TOKEN_EOF {
 			break
@@ -90,8 +154,58 @@ func (p *Parser) Parse() *Program {
 	return program
 }
 
-// parseStatement 解析语句
+// parseStatement 解析语句并记录起始行号
 func (p *Parser) parseStatement() Statement {
+	line := p.curToken.Line
+	stmt := p.parseStatementBody()
+	if stmt == nil {
+		return nil
+	}
+	stmt.setLine(line)
+	stmt.setEnd(p.endLine())
+
+	if p.peekToken.Type == TOKEN_PIPE {
+		return p.parsePipeline(stmt, line)
+	}
+	return stmt
+}
+
+// endLine 返回当前 token 结束的行号（单词中可能包含换行）
+func (p *Parser) endLine() int {
+	return p.curToken.Line + strings.Count(p.curToken.Literal, "\n")
+}
+
+// parsePipeline 解析管道，first 是第一个 | 之前的命令
+func (p *Parser) parsePipeline(first Statement, line int) Statement {
+	pipeline := &PipelineStatement{Commands: []Statement{first}}
+	pipeline.setLine(line)
+
+	for p.peekToken.Type == TOKEN_PIPE {
+		p.nextToken() // 移动到 '|'
+		p.nextToken()
+		p.skipNewlines()
+
+		if p.curToken.Type == TOKEN_EOF {
+			p.addError("管道缺少命令")
+			return nil
+		}
+
+		cmdLine := p.curToken.Line
+		stmt := p.parseStatementBody()
+		if stmt == nil {
+			return nil
+		}
+		stmt.setLine(cmdLine)
+		stmt.setEnd(p.endLine())
+		pipeline.Commands = append(pipeline.Commands, stmt)
+	}
+
+	pipeline.setEnd(p.endLine())
+	return pipeline
+}
+
+// parseStatementBody 根据当前 token 分派到具体的语句解析
+func (p *Parser) parseStatementBody() Statement {
 	switch p.curToken.Type {
 	case TOKEN_IF:
 		return p.parseIfStatement()
@@ -99,20 +213,35 @@ func (p *Parser) parseStatement() Statement {
 		return p.parseForStatement()
 	case TOKEN_WHILE:
 		return p.parseWhileStatement()
+	case TOKEN_TRY:
+		return p.parseTryStatement()
 	case TOKEN_FUNCTION:
 		return p.parseFunctionDef()
 	case TOKEN_RETURN:
 		return p.parseReturnStatement()
+	case TOKEN_LOCAL:
+		return p.parseLocalStatement()
+	case TOKEN_IMPORT:
+		return p.parseImportStatement()
 	case TOKEN_BREAK:
 		return &BreakStatement{}
 	case TOKEN_CONTINUE:
 		return &ContinueStatement{}
+	case TOKEN_THEN, TOKEN_ELIF, TOKEN_ELSE, TOKEN_FI, TOKEN_DO, TOKEN_DONE,
+		TOKEN_CATCH, TOKEN_FINALLY, TOKEN_RBRACE, TOKEN_RPAREN, TOKEN_PIPE, TOKEN_AND, TOKEN_OR, TOKEN_ILLEGAL:
+		p.addError(fmt.Sprintf("意外的 '%s'", p.curToken.Literal))
+		return nil
 	case TOKEN_IDENT:
+		// name() { ... } 形式的函数定义
+		if p.peekToken.Type == TOKEN_LPAREN {
+			return p.parseFunctionBody(p.curToken.Literal)
+		}
+		return p.parseCommandStatement()
+	case TOKEN_WORD:
 		// 检查是否是赋值语句
-		if p.peekToken.Type == TOKEN_ASSIGN {
+		if _, _, ok := splitAssignment(p.curToken.Literal); ok {
 			return p.parseAssignStatement()
 		}
-		// 否则是命令语句
 		return p.parseCommandStatement()
 	default:
 		// 默认作为命令语句处理
@@ -132,24 +261,24 @@ func (p *Parser) parseIfStatement() Statement {
 	stmt.Condition = p.parseCondition()
 
 	// 期望 'then'
-	if !p.expectToken(TOKEN_THEN) {
+	if !p.expectKeyword(TOKEN_THEN) {
 		return nil
 	}
-	p.skipNewlines()
+	p.nextToken()
 
 	// 解析 then 块
 	stmt.ThenBlock = p.parseBlock(TOKEN_ELIF, TOKEN_ELSE, TOKEN_FI)
 
 	// 处理 elif
 	for p.curToken.Type == TOKEN_ELIF {
+		elseif := &ElseIfClause{Line: p.curToken.Line}
 		p.nextToken()
-		elseif := &ElseIfClause{}
 		elseif.Condition = p.parseCondition()
 
-		if !p.expectToken(TOKEN_THEN) {
+		if !p.expectKeyword(TOKEN_THEN) {
 			return nil
 		}
-		p.skipNewlines()
+		p.nextToken()
 
 		elseif.Block = p.parseBlock(TOKEN_ELIF, TOKEN_ELSE, TOKEN_FI)
 		stmt.ElseIfList = append(stmt.ElseIfList, elseif)
@@ -157,8 +286,8 @@ func (p *Parser) parseIfStatement() Statement {
 
 	// 处理 else
 	if p.curToken.Type == TOKEN_ELSE {
+		stmt.ElseLine = p.curToken.Line
 		p.nextToken()
-		p.skipNewlines()
 		stmt.ElseBlock = p.parseBlock(TOKEN_FI)
 	}
 
@@ -183,31 +312,23 @@ func (p *Parser) parseForStatement() Statement {
 	}
 	stmt.Variable = p.curToken.Literal
 
-	// 期望 'in'
-	if !p.expectToken(TOKEN_IN) {
-		return nil
-	}
-	p.nextToken()
-
-	// 读取列表
-	for p.curToken.Type != TOKEN_DO && p.curToken.Type != TOKEN_NEWLINE && p.curToken.Type != TOKEN_SEMICOLON {
-		stmt.List = append(stmt.List, p.curToken.Literal)
+	// 省略 in 时遍历位置参数
+	if p.peekToken.Type != TOKEN_IN {
+		stmt.List = []string{`"$@"`}
+	} else {
 		p.nextToken()
+		for !isListEnd(p.peekToken.Type) {
+			p.nextToken()
+			stmt.List = append(stmt.List, p.curToken.Literal)
+		}
 	}
 
-	p.skipNewlines()
-
 	// 期望 'do'
-	if p.curToken.Type == TOKEN_SEMICOLON {
-		p.nextToken()
-		p.skipNewlines()
-	}
-	if p.curToken.Type != TOKEN_DO {
+	if !p.expectKeyword(TOKEN_DO) {
 		p.addError("for 语句需要 'do'")
 		return nil
 	}
 	p.nextToken()
-	p.skipNewlines()
 
 	// 解析循环体
 	stmt.Block = p.parseBlock(TOKEN_DONE)
@@ -230,10 +351,10 @@ func (p *Parser) parseWhileStatement() Statement {
 	stmt.Condition = p.parseCondition()
 
 	// 期望 'do'
-	if !p.expectToken(TOKEN_DO) {
+	if !p.expectKeyword(TOKEN_DO) {
 		return nil
 	}
-	p.skipNewlines()
+	p.nextToken()
 
 	// 解析循环体
 	stmt.Block = p.parseBlock(TOKEN_DONE)
@@ -246,7 +367,86 @@ func (p *Parser) parseWhileStatement() Statement {
 	return stmt
 }
 
-// parseFunctionDef 解析函数定义
+// parseTryStatement 解析 try 语句，catch 和 finally 至少要有一个
+func (p *Parser) parseTryStatement() Statement {
+	stmt := &TryStatement{}
+
+	if !p.parseBraceBlock("try", &stmt.Block) {
+		return nil
+	}
+
+	// catch 和 finally 可以与 } 在同一行，也可以另起一行
+	if p.peekPastNewlines() == TOKEN_CATCH {
+		p.nextToken()
+		p.skipNewlines()
+		stmt.CatchLine = p.curToken.Line
+		if p.peekToken.Type == TOKEN_IDENT {
+			p.nextToken()
+			stmt.CatchVar = p.curToken.Literal
+		}
+		if !p.parseBraceBlock("catch", &stmt.CatchBlock) {
+			return nil
+		}
+	}
+
+	if p.peekPastNewlines() == TOKEN_FINALLY {
+		p.nextToken()
+		p.skipNewlines()
+		stmt.FinallyLine = p.curToken.Line
+		if !p.parseBraceBlock("finally", &stmt.FinallyBlock) {
+			return nil
+		}
+	}
+
+	if stmt.CatchLine == 0 && stmt.FinallyLine == 0 {
+		// 交互模式下 catch 可能还没有输入
+		if p.peekPastNewlines() == TOKEN_EOF {
+			for p.curToken.Type != TOKEN_EOF {
+				p.nextToken()
+			}
+		}
+		p.addError("try 语句需要 catch 或 finally")
+		return nil
+	}
+
+	return stmt
+}
+
+// parseBraceBlock 解析关键字之后的 { ... }，开始时 curToken 位于关键字（或 catch
+// 的变量名），返回时位于 }
+func (p *Parser) parseBraceBlock(keyword string, block *[]Statement) bool {
+	p.nextToken()
+	p.skipNewlines()
+	if p.curToken.Type != TOKEN_LBRACE {
+		p.addError(fmt.Sprintf("%s 之后需要 '{'", keyword))
+		return false
+	}
+	p.nextToken()
+
+	*block = p.parseBlock(TOKEN_RBRACE)
+	if p.curToken.Type != TOKEN_RBRACE {
+		p.addError(fmt.Sprintf("%s 块缺少 '}'", keyword))
+		return false
+	}
+	return true
+}
+
+// peekPastNewlines 返回 curToken 之后第一个不是换行的 token 类型，不移动位置
+func (p *Parser) peekPastNewlines() TokenType {
+	if p.peekToken.Type != TOKEN_NEWLINE {
+		return p.peekToken.Type
+	}
+
+	lexer := *p.lexer
+	for {
+		tok := lexer.NextToken()
+		if tok.Type != TOKEN_NEWLINE && tok.Type != TOKEN_COMMENT {
+			return tok.Type
+		}
+	}
+}
+
+// parseFunctionDef 解析 function 关键字开头的函数定义
 func (p *Parser) parseFunctionDef() Statement {
 	p.nextToken() // 跳过 'function'
 
@@ -255,20 +455,24 @@ func (p *Parser) parseFunctionDef() Statement {
 		return nil
 	}
 
+	return p.parseFunctionBody(p.curToken.Literal)
+}
+
+// parseFunctionBody 解析函数名之后的部分：可选的 () 和 { ... }
+func (p *Parser) parseFunctionBody(name string) Statement {
 	fn := &FunctionDef{
-		Name: p.curToken.Literal,
+		Name: name,
 	}
 
-	p.nextToken()
-
-	// 期望 '()'
-	if p.curToken.Type == TOKEN_LPAREN {
+	// 可选的 '()'
+	if p.peekToken.Type == TOKEN_LPAREN {
+		p.nextToken()
 		if !p.expectToken(TOKEN_RPAREN) {
 			return nil
 		}
-		p.nextToken()
 	}
 
+	p.nextToken()
 	p.skipNewlines()
 
 	// 期望 '{'
@@ -277,7 +481,6 @@ func (p *Parser) parseFunctionDef() Statement {
 		return nil
 	}
 	p.nextToken()
-	p.skipNewlines()
 
 	// 解析函数体
 	fn.Block = p.parseBlock(TOKEN_RBRACE)
@@ -294,99 +497,252 @@ func (p *Parser) parseFunctionDef() Statement {
 func (p *Parser) parseReturnStatement() Statement {
 	stmt := &ReturnStatement{}
 
-	p.nextToken() // 跳过 'return'
-
 	// 如果后面有值，解析它
-	if p.curToken.Type != TOKEN_NEWLINE && p.curToken.Type != TOKEN_SEMICOLON && p.curToken.Type != TOKEN_EOF {
+	if !isCommandEnd(p.peekToken.Type) {
+		p.nextToken()
 		stmt.Value = &StringLiteral{Value: p.curToken.Literal}
 	}
 
 	return stmt
 }
 
-// parseAssignStatement 解析赋值语句
-func (p *Parser) parseAssignStatement() Statement {
-	stmt := &AssignStatement{
-		Name: p.curToken.Literal,
+// parseLocalStatement 解析 local 声明（如: local a=1 b）
+func (p *Parser) parseLocalStatement() Statement {
+	stmt := &LocalStatement{}
+
+	for !isCommandEnd(p.peekToken.Type) {
+		p.nextToken()
+
+		if name, value, ok := splitAssignment(p.curToken.Literal); ok {
+			stmt.Vars = append(stmt.Vars, &AssignStatement{
+				Name:  name,
+				Value: &StringLiteral{Value: value},
+			})
+			continue
+		}
+
+		if !isIdentifier(p.curToken.Literal) {
+			p.addError(fmt.Sprintf("local: 无效的变量名 '%s'", p.curToken.Literal))
+			continue
+		}
+		stmt.Vars = append(stmt.Vars, &AssignStatement{Name: p.curToken.Literal})
 	}
 
-	p.nextToken() // 跳过变量名
-	p.nextToken() // 跳过 '='
+	return stmt
+}
 
-	// 解析值
-	if p.curToken.Type == TOKEN_STRING {
-		stmt.Value = &StringLiteral{Value: p.curToken.Literal}
-	} else {
-		stmt.Value = &StringLiteral{Value: p.curToken.Literal}
+// parseImportStatement 解析 import 语句（如: import utils、import lib/str.lish as str）
+func (p *Parser) parseImportStatement() Statement {
+	if isCommandEnd(p.peekToken.Type) {
+		p.addError("import 需要模块名")
+		return nil
+	}
+	p.nextToken()
+	stmt := &ImportStatement{Module: p.curToken.Literal}
+
+	if p.peekToken.Type == TOKEN_IDENT && p.peekToken.Literal == "as" {
+		p.nextToken()
+		if p.peekToken.Type != TOKEN_IDENT {
+			p.addError("import ... as 需要合法的命名空间名称")
+			return nil
+		}
+		p.nextToken()
+		stmt.Namespace = p.curToken.Literal
+	}
+
+	if !isCommandEnd(p.peekToken.Type) {
+		p.nextToken()
+		p.addError(fmt.Sprintf("import: 多余的参数 '%s'", p.curToken.Literal))
+		return nil
 	}
 
 	return stmt
 }
 
-// parseCommandStatement 解析命令语句
+// parseAssignStatement 解析赋值语句
+func (p *Parser) parseAssignStatement() Statement {
+	name, value, _ := splitAssignment(p.curToken.Literal)
+
+	return &AssignStatement{
+		Name:  name,
+		Value: &StringLiteral{Value: value},
+	}
+}
+
+// parseCommandStatement 解析命令语句（命令名、参数和重定向）
 func (p *Parser) parseCommandStatement() Statement {
 	stmt := &CommandStatement{
-		Command: p.curToken.Literal,
-		Args:    []string{},
+		Args: []string{},
 	}
 
-	p.nextToken()
+	// 重定向可以出现在命令名之前（如: > out.txt echo hi）
+	for p.curToken.Type == TOKEN_REDIRECT {
+		if !p.parseRedirect(stmt) {
+			return nil
+		}
+		if isCommandEnd(p.peekToken.Type) {
+			p.addError("重定向之后缺少命令")
+			return nil
+		}
+		p.nextToken()
+	}
+	stmt.Command = p.curToken.Literal
 
 	// 读取参数
-	for p.curToken.Type != TOKEN_NEWLINE &&
-		p.curToken.Type != TOKEN_SEMICOLON &&
-		p.curToken.Type != TOKEN_EOF &&
-		p.curToken.Type != TOKEN_PIPE &&
-		!isBlockEnd(p.curToken.Type) {
-		stmt.Args = append(stmt.Args, p.curToken.Literal)
+	for !isCommandEnd(p.peekToken.Type) {
 		p.nextToken()
+		if p.curToken.Type == TOKEN_REDIRECT {
+			if !p.parseRedirect(stmt) {
+				return nil
+			}
+			continue
+		}
+		stmt.Args = append(stmt.Args, p.curToken.Literal)
 	}
 
 	return stmt
 }
 
+// parseRedirect 解析当前的重定向操作符及其目标文件
+func (p *Parser) parseRedirect(stmt *CommandStatement) bool {
+	redirect := &Redirect{Op: p.curToken.Literal}
+	stmt.Redirects = append(stmt.Redirects, redirect)
+
+	// 2>&1 和 >&2 没有目标文件
+	if strings.Contains(redirect.Op, "&") {
+		return true
+	}
+
+	if isCommandEnd(p.peekToken.Type) || p.peekToken.Type == TOKEN_REDIRECT || p.peekToken.Type == TOKEN_ILLEGAL {
+		p.addError(fmt.Sprintf("重定向 '%s' 缺少目标文件", redirect.Op))
+		return false
+	}
+	p.nextToken()
+	redirect.Target = p.curToken.Literal
+	return true
+}
+
 // parseCondition 解析条件表达式
 func (p *Parser) parseCondition() Expression {
-	// 简单实现：支持 [ condition ] 形式
-	if p.curToken.Type == TOKEN_LBRACKET {
+	left := p.parseConditionPrimary()
+
+	// 处理 && 和 ||
+	for p.peekToken.Type == TOKEN_AND || p.peekToken.Type == TOKEN_OR {
+		p.nextToken()
+		op := p.curToken.Literal
 		p.nextToken()
+		left = &BinaryExpr{
+			Left:     left,
+			Operator: op,
+			Right:    p.parseConditionPrimary(),
+		}
+	}
+
+	return left
+}
 
+// parseConditionPrimary 解析单个条件（[ ... ]、! 条件、命令或字符串）
+func (p *Parser) parseConditionPrimary() Expression {
+	if p.curToken.Type == TOKEN_NOT {
+		p.nextToken()
+		return &UnaryExpr{
+			Operator: "!",
+			Operand:  p.parseConditionPrimary(),
+		}
+	}
+
+	// 支持 [ condition ] 形式
+	if p.curToken.Type == TOKEN_LBRACKET {
 		// 收集测试表达式的所有部分
 		args := []string{}
-		for p.curToken.Type != TOKEN_RBRACKET && p.curToken.Type != TOKEN_EOF {
-			args = append(args, p.curToken.Literal)
+		for p.peekToken.Type != TOKEN_RBRACKET && p.peekToken.Type != TOKEN_EOF {
 			p.nextToken()
+			args = append(args, p.curToken.Literal)
 		}
 
-		if p.curToken.Type != TOKEN_RBRACKET {
+		if !p.expectToken(TOKEN_RBRACKET) {
 			return &StringLiteral{Value: "false"}
 		}
 
-		// 解析测试表达式
-		if len(args) >= 2 {
-			return &TestExpr{
-				Operator: args[0],
-				Args:     args[1:],
-			}
-		}
+		return newTestExpr(args)
+	}
+
+	// true、false 和单独的变量展开按字符串判断（如 while true、if $verbose）
+	if isConditionEnd(p.peekToken.Type) && isLiteralCondition(p.curToken.Literal) {
+		return &StringLiteral{Value: p.curToken.Literal}
+	}
+
+	// 其余条件都是命令，按退出码判断真假
+	stmt := p.parseStatement()
+	if stmt == nil {
+		return &StringLiteral{Value: "false"}
+	}
+	return &CommandCondition{Command: stmt}
+}
+
+// isLiteralCondition 判断单个单词的条件是否按字符串判断
+func isLiteralCondition(word string) bool {
+	switch word {
+	case "true", "false":
+		return true
+	}
+	return strings.HasPrefix(word, "$") || strings.HasPrefix(word, `"$`)
+}
+
+// isConditionEnd 判断 token 是否结束单个条件
+func isConditionEnd(t TokenType) bool {
+	switch t {
+	case TOKEN_NEWLINE, TOKEN_SEMICOLON, TOKEN_EOF, TOKEN_AND, TOKEN_OR, TOKEN_THEN, TOKEN_DO:
+		return true
+	}
+	return false
+}
 
-		return &StringLiteral{Value: strings.Join(args, " ")}
+// newTestExpr 根据 [ ] 内的参数构造测试表达式
+func newTestExpr(args []string) Expression {
+	switch {
+	case len(args) == 0:
+		return &StringLiteral{Value: ""}
+	case len(args) == 1:
+		// [ str ] 等价于 [ -n str ]
+		return &TestExpr{Operator: "-n", Args: args}
+	case len(args) == 2 && args[0] == "!":
+		return &UnaryExpr{Operator: "!", Operand: newTestExpr(args[1:])}
+	case len(args) == 3 && isBinaryTestOp(args[1]):
+		return &TestExpr{Operator: args[1], Args: []string{args[0], args[2]}}
+	default:
+		return &TestExpr{Operator: args[0], Args: args[1:]}
 	}
+}
 
-	// 否则作为字符串条件
-	cond := p.curToken.Literal
+// isBinaryTestOp 判断是否是二元测试操作符
+func isBinaryTestOp(op string) bool {
+	switch op {
+	case "=", "==", "!=", "<", ">", "-eq", "-ne", "-lt", "-gt", "-le", "-ge":
+		return true
+	}
+	return false
+}
+
+// expectKeyword 期望下一个非分隔符 token 是指定关键字（如 then、do）
+func (p *Parser) expectKeyword(t TokenType) bool {
 	p.nextToken()
-	return &StringLiteral{Value: cond}
+	p.skipSeparators()
+	if p.curToken.Type == t {
+		return true
+	}
+	p.addError(fmt.Sprintf("期望 %v，得到 %v", t, p.curToken.Type))
+	return false
 }
 
-// parseBlock 解析语句块
+// parseBlock 解析语句块，返回时 curToken 位于结束 token
 func (p *Parser) parseBlock(endTokens ...TokenType) []Statement {
 	statements := []Statement{}
 
-	for !contains(endTokens, p.curToken.Type) && p.curToken.Type != TOKEN_EOF {
-		p.skipNewlines()
+	for {
+		p.skipSeparators()
 
-		if contains(endTokens, p.curToken.Type) {
+		if contains(endTokens, p.curToken.Type) || p.curToken.Type == TOKEN_EOF {
 			break
 		}
 
@@ -401,10 +757,28 @@ func (p *Parser) parseBlock(endTokens ...TokenType) []Statement {
 	return statements
 }
 
-// isBlockEnd 判断是否是块结束 token
-func isBlockEnd(t TokenType) bool {
-	return t == TOKEN_FI || t == TOKEN_DONE || t == TOKEN_RBRACE ||
-		t == TOKEN_ELIF || t == TOKEN_ELSE
+// splitAssignment 拆分 name=value 形式的赋值单词
+func splitAssignment(word string) (name, value string, ok bool) {
+	idx := strings.Index(word, "=")
+	if idx <= 0 || !isIdentifier(word[:idx]) {
+		return "", "", false
+	}
+	return word[:idx], word[idx+1:], true
+}
+
+// isCommandEnd 判断 token 是否结束一条简单命令
+func isCommandEnd(t TokenType) bool {
+	switch t {
+	case TOKEN_NEWLINE, TOKEN_SEMICOLON, TOKEN_EOF, TOKEN_PIPE,
+		TOKEN_AND, TOKEN_OR, TOKEN_RBRACE, TOKEN_LPAREN, TOKEN_RPAREN:
+		return true
+	}
+	return false
+}
+
+// isListEnd 判断 token 是否结束 for 语句的列表
+func isListEnd(t TokenType) bool {
+	return t == TOKEN_DO || t == TOKEN_NEWLINE || t == TOKEN_SEMICOLON || t == TOKEN_EOF
 }
 
 // contains 判断切片是否包含元素
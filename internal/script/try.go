@@ -0,0 +1,154 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Lingbou/Lish/internal/streams"
+)
+
+// stderrTailSize try 块中保留的错误输出字节数
+const stderrTailSize = 4096
+
+// stderrTailLines 错误信息中保留的错误输出行数
+const stderrTailLines = 10
+
+// executeTry 执行 try 语句
+//
+// try 块中（包括其中调用的函数里）的命令失败时转到 catch 块，错误信息写入
+// catch 的变量：
+//
+//	$err           错误信息
+//	${err.command} 失败的命令（已展开）
+//	${err.status}  退出码
+//	${err.stderr}  try 块错误输出的最后几行
+//	${err.line}    出错的行号
+//
+// 没有 catch 时错误在 finally 之后继续向上传递。finally 块总会执行，
+// 即使 try 或 catch 中执行了 return、break 或 continue；finally 本身
+// 出错时以它的错误为准。Ctrl+C、调试器退出和 exit 不会被 catch。
+func (e *Executor) executeTry(ctx context.Context, stmt *TryStatement) error {
+	std := streams.From(ctx)
+	tail := newTailWriter(std.Stderr, stderrTailSize)
+	tryCtx := streams.With(ctx, &streams.Streams{Stdin: std.Stdin, Stdout: std.Stdout, Stderr: tail})
+
+	e.tryDepth++
+	err := e.executeBlock(tryCtx, stmt.Block)
+	e.tryDepth--
+	if err != nil && stmt.CatchLine > 0 && e.catchable(ctx, err) {
+		code := ExitCodeOf(err)
+		err = e.setCaught(stmt.CatchVar, err, code, tail.String())
+		if err == nil {
+			e.setStatus(code)
+			err = e.executeBlock(ctx, stmt.CatchBlock)
+		}
+	}
+
+	if stmt.FinallyLine == 0 {
+		return err
+	}
+
+	// finally 不改变 try/catch 的控制流和退出码，除非它自己改变了控制流
+	flow, status := e.flowType, e.variables.Status()
+	e.flowType = FLOW_NORMAL
+	if finallyErr := e.executeBlock(ctx, stmt.FinallyBlock); finallyErr != nil {
+		return finallyErr
+	}
+	if e.flowType == FLOW_NORMAL {
+		e.flowType = flow
+		e.setStatus(status)
+	}
+	return err
+}
+
+// catchable 判断错误能否被 catch，中断执行的错误继续向上传递
+func (e *Executor) catchable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var abort *abortError
+	var exit ExitRequest
+	return !errors.As(err, &abort) && !errors.As(err, &exit)
+}
+
+// setCaught 把捕获的错误写入 catch 的变量，name 为空时不写入
+func (e *Executor) setCaught(name string, err error, code int, stderr string) error {
+	if name == "" {
+		return nil
+	}
+
+	message, line := err.Error(), 0
+	var execErr *ExecutionError
+	if errors.As(err, &execErr) {
+		message, line = execErr.Message, execErr.Line
+	}
+
+	// 嵌套的命令（如 source 执行的脚本）以最内层失败的命令为准
+	command := ""
+	for cmdErr := (*CommandError)(nil); errors.As(err, &cmdErr); err = cmdErr.Err {
+		command = strings.Join(append([]string{cmdErr.Command}, cmdErr.Args...), " ")
+	}
+
+	values := []string{message, command, strconv.Itoa(code), stderr, strconv.Itoa(line)}
+	for i, variable := range CaughtVariables(name) {
+		if err := e.variables.Set(variable, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CaughtVariables 返回 catch 写入的变量名：错误信息以及命令、退出码、
+// 错误输出和行号四个字段
+func CaughtVariables(name string) []string {
+	return []string{name, name + ".command", name + ".status", name + ".stderr", name + ".line"}
+}
+
+// tailWriter 把写入的内容转发给 w，同时保留最后 size 个字节
+type tailWriter struct {
+	mu        sync.Mutex
+	w         io.Writer
+	buf       []byte
+	size      int
+	truncated bool
+}
+
+// newTailWriter 创建 tailWriter
+func newTailWriter(w io.Writer, size int) *tailWriter {
+	return &tailWriter{w: w, size: size}
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.size; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+		t.truncated = true
+	}
+	t.mu.Unlock()
+
+	return t.w.Write(p)
+}
+
+// String 返回保留内容的最后几行，去掉被截断的第一行和末尾的换行
+func (t *tailWriter) String() string {
+	t.mu.Lock()
+	text := string(t.buf)
+	truncated := t.truncated
+	t.mu.Unlock()
+
+	if truncated {
+		if idx := strings.IndexByte(text, '\n'); idx >= 0 {
+			text = text[idx+1:]
+		}
+	}
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) > stderrTailLines {
+		lines = lines[len(lines)-stderrTailLines:]
+	}
+	return strings.Join(lines, "\n")
+}
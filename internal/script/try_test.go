@@ -0,0 +1,35 @@
+package script
+
+import "testing"
+
+func TestTry(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+		code   int
+	}{
+		{"no error skips catch", "try { echo a; } catch { echo caught; }", "a\n", 0},
+		{"error stops try block", "try { false; echo no; } catch { echo caught $?; }", "caught 1\n", 0},
+		{"caught fields", "try {\nstatus 3\n} catch err { echo \"${err.status} ${err.line} ${err.command}\"; }", "3 2 status 3\n", 0},
+		{"caught message", "try { fail; } catch err { echo \"$err\"; }", "fail: 出错\n", 0},
+		{"caught stderr", "try { fail; } catch err { echo \"${err.stderr}\"; }", "lish: 行 1: fail: 出错\n", 0},
+		{"status after catch", "try { false; } catch { echo c; }\necho $?", "c\n0\n", 0},
+		{"finally after success", "try { echo a; } finally { echo f; }", "a\nf\n", 0},
+		{"finally after catch", "try { false; } catch { echo c; } finally { echo f; }", "c\nf\n", 0},
+		{"uncaught error runs finally", "try { status 2; echo no; } finally { echo f; }\necho $?", "f\n2\n", 0},
+		{"failure in catch sets status", "try { false; } catch { status 4; }\necho $?", "4\n", 0},
+		{"return runs finally", "function f() { try { return 3; } finally { echo f; } }\ntry { f; } catch { echo caught $?; }", "f\ncaught 3\n", 0},
+		{"break runs finally", "for i in a b; do\ntry { break; } finally { echo f $i; }\ndone", "f a\n", 0},
+		{"nested try", "try { try { status 5; } catch { echo inner $?; status 6; } } catch { echo outer $?; }", "inner 5\nouter 6\n", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, code := runScript(t, tt.source)
+			if got != tt.want || code != tt.code {
+				t.Errorf("got %q (exit %d), want %q (exit %d)", got, code, tt.want, tt.code)
+			}
+		})
+	}
+}
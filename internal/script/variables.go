@@ -2,46 +2,113 @@ package script
 
 import (
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 )
 
+// VariableInfo 表示一个变量及其属性
+type VariableInfo struct {
+	Value    string
+	Exported bool // 导出到子进程环境
+	ReadOnly bool // 只读，不能修改或删除
+}
+
+// ReadOnlyError 修改只读变量时返回的错误
+type ReadOnlyError struct {
+	Name string
+}
+
+func (e *ReadOnlyError) Error() string {
+	return fmt.Sprintf("%s: 只读变量", e.Name)
+}
+
 // Scope 表示变量作用域
 type Scope struct {
-	vars   map[string]string
+	vars   map[string]*VariableInfo
+	params []string // 位置参数，params[0] 为 $0；nil 表示沿用外层作用域的参数
 	parent *Scope
 }
 
 // NewScope 创建新的作用域
 func NewScope(parent *Scope) *Scope {
 	return &Scope{
-		vars:   make(map[string]string),
+		vars:   make(map[string]*VariableInfo),
 		parent: parent,
 	}
 }
 
-// Set 设置变量
+// Set 设置变量（只修改当前作用域，保留已有属性）
 func (s *Scope) Set(name, value string) {
-	s.vars[name] = value
+	if v, ok := s.vars[name]; ok {
+		v.Value = value
+		return
+	}
+	s.vars[name] = &VariableInfo{Value: value}
 }
 
 // Get 获取变量值
 func (s *Scope) Get(name string) (string, bool) {
-	// 先在当前作用域查找
-	if val, ok := s.vars[name]; ok {
-		return val, true
-	}
-	// 如果没找到，在父作用域查找
-	if s.parent != nil {
-		return s.parent.Get(name)
+	if v, ok := s.lookup(name); ok {
+		return v.Value, true
 	}
 	return "", false
 }
 
+// lookup 沿作用域链查找变量
+func (s *Scope) lookup(name string) (*VariableInfo, bool) {
+	for scope := s; scope != nil; scope = scope.parent {
+		if v, ok := scope.vars[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// owner 返回定义了该变量的最近作用域
+func (s *Scope) owner(name string) *Scope {
+	for scope := s; scope != nil; scope = scope.parent {
+		if _, ok := scope.vars[name]; ok {
+			return scope
+		}
+	}
+	return nil
+}
+
 // Delete 删除变量
 func (s *Scope) Delete(name string) {
 	delete(s.vars, name)
 }
 
+// Parent 返回父作用域（全局作用域返回 nil）
+func (s *Scope) Parent() *Scope {
+	return s.parent
+}
+
+// Local 返回只属于当前作用域的变量（不包括父作用域）
+func (s *Scope) Local() map[string]string {
+	result := make(map[string]string, len(s.vars))
+	for k, v := range s.vars {
+		result[k] = v.Value
+	}
+	return result
+}
+
+// Variables 返回只属于当前作用域的变量及其属性
+func (s *Scope) Variables() map[string]VariableInfo {
+	result := make(map[string]VariableInfo, len(s.vars))
+	for k, v := range s.vars {
+		result[k] = *v
+	}
+	return result
+}
+
+// Params 返回当前作用域自己的位置参数（包括 $0），沿用外层参数时返回 nil
+func (s *Scope) Params() []string {
+	return s.params
+}
+
 // All 获取所有变量（包括父作用域）
 func (s *Scope) All() map[string]string {
 	result := make(map[string]string)
@@ -55,21 +122,79 @@ func (s *Scope) All() map[string]string {
 
 	// 然后覆盖当前作用域的变量
 	for k, v := range s.vars {
-		result[k] = v
+		result[k] = v.Value
 	}
 
 	return result
 }
 
+// IsValidName 判断字符串是否是合法的变量名或函数名
+func IsValidName(name string) bool {
+	return isIdentifier(name)
+}
+
 // VariableManager 管理变量和作用域
+//
+// 整个会话共用一个变量存储：全局作用域在创建时导入进程环境变量并标记为
+// 已导出，函数调用时压入新的作用域。赋值语句遵循动态作用域规则，修改
+// 最近定义该变量的作用域，未定义时写入全局作用域；local 声明的变量只
+// 存在于当前函数作用域。全局已导出变量的修改会同步到进程环境，使
+// PATH 查找等依赖 os.Getenv 的逻辑保持一致。
 type VariableManager struct {
 	currentScope *Scope
+	global       *Scope
+	status       int  // $? 上一个命令的退出码
+	subshell     bool // 子 shell 的副本不修改进程环境
 }
 
 // NewVariableManager 创建新的变量管理器
 func NewVariableManager() *VariableManager {
+	global := NewScope(nil)
+	for _, kv := range os.Environ() {
+		if idx := strings.Index(kv, "="); idx > 0 {
+			global.vars[kv[:idx]] = &VariableInfo{Value: kv[idx+1:], Exported: true}
+		}
+	}
+
+	global.params = []string{"lish"}
+
 	return &VariableManager{
-		currentScope: NewScope(nil),
+		currentScope: global,
+		global:       global,
+	}
+}
+
+// Subshell 复制当前的变量状态，用于管道和命令替换
+//
+// 副本中的修改不会影响原变量存储，也不会同步到进程环境。
+func (vm *VariableManager) Subshell() *VariableManager {
+	var copyScope func(s *Scope) *Scope
+	copyScope = func(s *Scope) *Scope {
+		if s == nil {
+			return nil
+		}
+		c := NewScope(copyScope(s.parent))
+		for name, v := range s.vars {
+			info := *v
+			c.vars[name] = &info
+		}
+		if s.params != nil {
+			c.params = append([]string(nil), s.params...)
+		}
+		return c
+	}
+
+	current := copyScope(vm.currentScope)
+	global := current
+	for global.parent != nil {
+		global = global.parent
+	}
+
+	return &VariableManager{
+		currentScope: current,
+		global:       global,
+		status:       vm.status,
+		subshell:     true,
 	}
 }
 
@@ -85,9 +210,38 @@ func (vm *VariableManager) PopScope() {
 	}
 }
 
-// Set 设置变量
-func (vm *VariableManager) Set(name, value string) {
-	vm.currentScope.Set(name, value)
+// CurrentScope 返回当前作用域
+func (vm *VariableManager) CurrentScope() *Scope {
+	return vm.currentScope
+}
+
+// InFunction 判断当前是否处于函数作用域中
+func (vm *VariableManager) InFunction() bool {
+	return vm.currentScope != vm.global
+}
+
+// Set 设置变量：修改最近定义它的作用域，未定义时写入全局作用域
+func (vm *VariableManager) Set(name, value string) error {
+	scope := vm.currentScope.owner(name)
+	if scope == nil {
+		scope = vm.global
+	}
+	return vm.setIn(scope, name, value)
+}
+
+// SetLocal 在当前作用域中设置变量（用于 local 和位置参数）
+func (vm *VariableManager) SetLocal(name, value string) error {
+	return vm.setIn(vm.currentScope, name, value)
+}
+
+// setIn 在指定作用域中设置变量
+func (vm *VariableManager) setIn(scope *Scope, name, value string) error {
+	if v, ok := scope.vars[name]; ok && v.ReadOnly {
+		return &ReadOnlyError{Name: name}
+	}
+	scope.Set(name, value)
+	vm.syncEnv(scope, name)
+	return nil
 }
 
 // Get 获取变量值
@@ -95,77 +249,204 @@ func (vm *VariableManager) Get(name string) (string, bool) {
 	return vm.currentScope.Get(name)
 }
 
-// Delete 删除变量
+// Lookup 获取变量及其属性
+func (vm *VariableManager) Lookup(name string) (VariableInfo, bool) {
+	if v, ok := vm.currentScope.lookup(name); ok {
+		return *v, true
+	}
+	return VariableInfo{}, false
+}
+
+// Export 将变量标记为导出，变量不存在时在全局作用域创建空值
+func (vm *VariableManager) Export(name string) {
+	scope := vm.currentScope.owner(name)
+	if scope == nil {
+		scope = vm.global
+		scope.vars[name] = &VariableInfo{}
+	}
+	scope.vars[name].Exported = true
+	vm.syncEnv(scope, name)
+}
+
+// Unexport 取消变量的导出属性
+func (vm *VariableManager) Unexport(name string) {
+	if scope := vm.currentScope.owner(name); scope != nil {
+		scope.vars[name].Exported = false
+		if scope == vm.global && !vm.subshell {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+// MarkReadOnly 将变量标记为只读，变量不存在时在全局作用域创建空值
+func (vm *VariableManager) MarkReadOnly(name string) {
+	scope := vm.currentScope.owner(name)
+	if scope == nil {
+		scope = vm.global
+		scope.vars[name] = &VariableInfo{}
+	}
+	scope.vars[name].ReadOnly = true
+}
+
+// Unset 删除最近作用域中的变量
+func (vm *VariableManager) Unset(name string) error {
+	scope := vm.currentScope.owner(name)
+	if scope == nil {
+		return nil
+	}
+	v := scope.vars[name]
+	if v.ReadOnly {
+		return &ReadOnlyError{Name: name}
+	}
+	scope.Delete(name)
+	if scope == vm.global && v.Exported && !vm.subshell {
+		os.Unsetenv(name)
+	}
+	return nil
+}
+
+// Delete 删除当前作用域中的变量
 func (vm *VariableManager) Delete(name string) {
 	vm.currentScope.Delete(name)
 }
 
+// Names 返回所有可见变量的名称（已排序）
+func (vm *VariableManager) Names() []string {
+	all := vm.currentScope.All()
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Environ 返回传递给子进程的环境变量（KEY=VALUE 形式，已排序）
+func (vm *VariableManager) Environ() []string {
+	var env []string
+	for _, name := range vm.Names() {
+		if v, ok := vm.currentScope.lookup(name); ok && v.Exported {
+			env = append(env, name+"="+v.Value)
+		}
+	}
+	return env
+}
+
+// syncEnv 将全局已导出变量同步到进程环境
+func (vm *VariableManager) syncEnv(scope *Scope, name string) {
+	if scope != vm.global || vm.subshell {
+		return
+	}
+	if v := scope.vars[name]; v.Exported {
+		os.Setenv(name, v.Value)
+	}
+}
+
 // All 获取所有变量
 func (vm *VariableManager) All() map[string]string {
 	return vm.currentScope.All()
 }
 
-// Expand 展开变量引用
-func (vm *VariableManager) Expand(str string) string {
-	result := str
+// Expand 展开单词：去除引号、处理转义并替换变量引用，不做字段分割
+//
+// 单引号内的内容原样保留；双引号内只展开变量和 \$ \" \\ 等转义；
+// 未定义的变量展开为空字符串。命令替换需要执行器，这里展开为空。
+func (vm *VariableManager) Expand(word string) string {
+	return newExpander(vm, nil, false).expandString(word)
+}
 
-	// 处理 ${var} 形式
-	for {
-		start := strings.Index(result, "${")
-		if start == -1 {
-			break
-		}
-		end := strings.Index(result[start:], "}")
-		if end == -1 {
-			break
-		}
-		end += start
+// lookup 查找变量值，未定义时返回空字符串
+func (vm *VariableManager) lookup(name string) string {
+	if value, ok := vm.special(name); ok {
+		return value
+	}
+	if value, ok := vm.Get(name); ok {
+		return value
+	}
+	return ""
+}
 
-		varName := result[start+2 : end]
-		value, _ := vm.Get(varName)
-		result = result[:start] + value + result[end+1:]
+// special 返回特殊参数和位置参数的值
+func (vm *VariableManager) special(name string) (string, bool) {
+	params := vm.Params()
+	switch name {
+	case "?":
+		return strconv.Itoa(vm.status), true
+	case "$":
+		return strconv.Itoa(os.Getpid()), true
+	case "#":
+		return strconv.Itoa(len(params) - 1), true
+	case "@", "*":
+		return strings.Join(params[1:], " "), true
 	}
 
-	// 处理 $var 形式
-	words := strings.Fields(result)
-	for i, word := range words {
-		if strings.HasPrefix(word, "$") && !strings.Contains(word, "{") {
-			varName := strings.TrimPrefix(word, "$")
-			if value, ok := vm.Get(varName); ok {
-				words[i] = value
-			}
+	if isNumber(name) {
+		n, err := strconv.Atoi(name)
+		if err != nil || n >= len(params) {
+			return "", true
 		}
+		return params[n], true
 	}
+	return "", false
+}
 
-	return strings.Join(words, " ")
+// indexRune 从 start 开始查找字符，未找到时返回切片长度
+func indexRune(runes []rune, start int, target rune) int {
+	for i := start; i < len(runes); i++ {
+		if runes[i] == target {
+			return i
+		}
+	}
+	return len(runes)
 }
 
-// SetSpecialVars 设置特殊变量
-func (vm *VariableManager) SetSpecialVars(args []string, exitCode int) {
-	// $0 - 脚本名称
-	if len(args) > 0 {
-		vm.Set("0", args[0])
+// SetParams 设置当前作用域的位置参数，args[0] 为 $0
+func (vm *VariableManager) SetParams(args []string) {
+	if len(args) == 0 {
+		args = []string{""}
 	}
+	vm.currentScope.params = append([]string(nil), args...)
+}
 
-	// $1, $2, ... - 位置参数
-	for i, arg := range args[1:] {
-		vm.Set(fmt.Sprintf("%d", i+1), arg)
+// Params 返回最近作用域的位置参数（包括 $0）
+func (vm *VariableManager) Params() []string {
+	if scope := vm.paramsOwner(); scope != nil {
+		return scope.params
 	}
+	return []string{""}
+}
 
-	// $# - 参数个数
-	if len(args) > 0 {
-		vm.Set("#", fmt.Sprintf("%d", len(args)-1))
-	} else {
-		vm.Set("#", "0")
+// Positional 返回位置参数 $1 ... $N
+func (vm *VariableManager) Positional() []string {
+	return vm.Params()[1:]
+}
+
+// Shift 将位置参数左移 n 位
+func (vm *VariableManager) Shift(n int) error {
+	scope := vm.paramsOwner()
+	if scope == nil || n < 0 || n > len(scope.params)-1 {
+		return fmt.Errorf("shift: 移位计数超出范围")
 	}
+	scope.params = append(scope.params[:1:1], scope.params[1+n:]...)
+	return nil
+}
 
-	// $@ - 所有参数
-	if len(args) > 1 {
-		vm.Set("@", strings.Join(args[1:], " "))
-	} else {
-		vm.Set("@", "")
+// paramsOwner 返回定义了位置参数的最近作用域
+func (vm *VariableManager) paramsOwner() *Scope {
+	for scope := vm.currentScope; scope != nil; scope = scope.parent {
+		if scope.params != nil {
+			return scope
+		}
 	}
+	return nil
+}
+
+// SetStatus 设置 $?
+func (vm *VariableManager) SetStatus(code int) {
+	vm.status = code
+}
 
-	// $? - 上一个命令的退出码
-	vm.Set("?", fmt.Sprintf("%d", exitCode))
+// Status 返回 $?
+func (vm *VariableManager) Status() int {
+	return vm.status
 }
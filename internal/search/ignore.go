@@ -0,0 +1,171 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// rule 一条忽略规则（.gitignore 的一行）
+type rule struct {
+	re      *regexp.Regexp
+	negate  bool // 以 ! 开头，重新包含之前排除的路径
+	dirOnly bool // 以 / 结尾，只匹配目录
+}
+
+// Rules 一组 .gitignore 格式的规则，后面的规则优先
+type Rules struct {
+	rules []rule
+}
+
+// ParseRules 解析 .gitignore 格式的文本，无效的行被忽略
+//
+// 支持 # 注释、! 取反、结尾 / 只匹配目录、包含 / 时相对于规则所在目录，
+// 以及 *、?、[...] 和 ** 通配符。
+func ParseRules(text string) *Rules {
+	r := &Rules{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		// 结尾的空格被忽略，除非用反斜杠转义
+		for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+			line = line[:len(line)-1]
+		}
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		var ru rule
+		if line[0] == '!' {
+			ru.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			ru.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+
+		expr := "^"
+		if !anchored {
+			expr += "(?:.*/)?"
+		}
+		re, err := regexp.Compile(expr + globToRegex(line) + "$")
+		if err != nil {
+			continue
+		}
+		ru.re = re
+		r.rules = append(r.rules, ru)
+	}
+	return r
+}
+
+// Len 返回规则数量
+func (r *Rules) Len() int {
+	return len(r.rules)
+}
+
+// Match 用最后一条匹配的规则判断路径（相对于规则所在目录，用 / 分隔），
+// 返回是否有规则匹配以及该规则是否是取反规则
+func (r *Rules) Match(path string, isDir bool) (matched, negated bool) {
+	for i := len(r.rules) - 1; i >= 0; i-- {
+		ru := r.rules[i]
+		if ru.dirOnly && !isDir {
+			continue
+		}
+		if ru.re.MatchString(path) {
+			return true, ru.negate
+		}
+	}
+	return false, false
+}
+
+// globToRegex 把 .gitignore 通配符转为正则表达式
+func globToRegex(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		ch := glob[i]
+		switch ch {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				// ** 只在整个路径段时有特殊含义
+				atStart := i == 0 || glob[i-1] == '/'
+				rest := glob[i+2:]
+				switch {
+				case atStart && strings.HasPrefix(rest, "/"):
+					sb.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				case atStart && rest == "":
+					sb.WriteString(".*")
+					i++
+					continue
+				}
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			// 开头的 ! 表示取反，紧随其后的 ] 是普通字符
+			j := i + 1
+			if j < len(glob) && glob[j] == '!' {
+				j++
+			}
+			if j < len(glob) && glob[j] == ']' {
+				j++
+			}
+			end := strings.IndexByte(glob[j:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : j+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteByte('[')
+			sb.WriteString(strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(class))
+			sb.WriteByte(']')
+			i = j + end
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return sb.String()
+}
+
+// ignoreFiles 每个目录中按优先级从低到高读取的忽略文件
+var ignoreFiles = []string{
+	filepath.Join(".git", "info", "exclude"),
+	".gitignore",
+	".ignore",
+}
+
+// loadIgnore 读取目录中的忽略文件，没有任何规则时返回 nil
+func loadIgnore(dir string) *Rules {
+	var sb strings.Builder
+	for _, name := range ignoreFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		sb.Write(data)
+		sb.WriteByte('\n')
+	}
+	if sb.Len() == 0 {
+		return nil
+	}
+	rules := ParseRules(sb.String())
+	if rules.Len() == 0 {
+		return nil
+	}
+	return rules
+}
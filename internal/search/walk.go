@@ -0,0 +1,256 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Options 遍历目录的选项
+type Options struct {
+	Hidden   bool     // 包含以 . 开头的文件和目录
+	NoIgnore bool     // 不读取 .gitignore、.ignore 和 .git/info/exclude
+	Globs    []string // 只搜索匹配的文件，以 ! 开头时排除匹配的文件和目录（相对于搜索目录）
+	MaxDepth int      // 最大深度，0 表示不限制
+}
+
+// scope 一个目录中的忽略规则，base 是该目录的绝对路径
+type scope struct {
+	base  string
+	rules *Rules
+}
+
+// walker 按确定的顺序（每个目录内按名称排序，深度优先）列出需要搜索的文件
+type walker struct {
+	ctx       context.Context
+	opts      Options
+	overrides *Rules
+	whitelist bool // 有不以 ! 开头的 glob，文件必须匹配其中一个
+	send      func(path string, err error) bool
+}
+
+func newWalker(ctx context.Context, opts Options, send func(path string, err error) bool) *walker {
+	w := &walker{ctx: ctx, opts: opts, send: send}
+	if len(opts.Globs) > 0 {
+		w.overrides = ParseRules(strings.Join(opts.Globs, "\n"))
+		for _, glob := range opts.Globs {
+			if !strings.HasPrefix(glob, "!") {
+				w.whitelist = true
+			}
+		}
+	}
+	return w
+}
+
+// walkRoot 遍历一个命令行参数；直接给出的文件总是被搜索
+func (w *walker) walkRoot(root string) bool {
+	info, err := os.Stat(root)
+	if err != nil {
+		return w.send(root, err)
+	}
+	if !info.IsDir() {
+		return w.send(root, nil)
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return w.send(root, err)
+	}
+	var scopes []scope
+	if !w.opts.NoIgnore {
+		scopes = parentScopes(abs)
+	}
+	return w.walkDir(abs, root, "", 1, scopes)
+}
+
+// parentScopes 读取搜索目录之上、直到 Git 仓库根目录的忽略规则
+//
+// 不在 Git 仓库中时不使用上层目录的规则。
+func parentScopes(abs string) []scope {
+	var dirs []string
+	for dir := abs; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil
+		}
+		dir = parent
+		dirs = append(dirs, dir)
+	}
+	var scopes []scope
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if rules := loadIgnore(dirs[i]); rules != nil {
+			scopes = append(scopes, scope{base: dirs[i], rules: rules})
+		}
+	}
+	return scopes
+}
+
+// walkDir 遍历目录，abs 是绝对路径，display 是输出时使用的路径，
+// rel 是相对于搜索目录的路径（用于 --glob）
+func (w *walker) walkDir(abs, display, rel string, depth int, scopes []scope) bool {
+	if w.ctx.Err() != nil {
+		return false
+	}
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return w.send(display, err)
+	}
+	if !w.opts.NoIgnore {
+		if rules := loadIgnore(abs); rules != nil {
+			scopes = append(scopes[:len(scopes):len(scopes)], scope{base: abs, rules: rules})
+		}
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		isDir := entry.IsDir()
+		if isDir && name == ".git" {
+			continue
+		}
+		if !w.opts.Hidden && strings.HasPrefix(name, ".") {
+			continue
+		}
+		// 不跟随符号链接，也跳过设备、管道等特殊文件
+		if !isDir && !entry.Type().IsRegular() {
+			continue
+		}
+
+		childAbs := filepath.Join(abs, name)
+		childRel := name
+		if rel != "" {
+			childRel = rel + "/" + name
+		}
+		if !w.opts.NoIgnore && ignored(scopes, childAbs, isDir) {
+			continue
+		}
+		if w.overrides != nil {
+			matched, negated := w.overrides.Match(childRel, isDir)
+			if matched && negated || !matched && w.whitelist && !isDir {
+				continue
+			}
+		}
+
+		childDisplay := filepath.Join(display, name)
+		if isDir {
+			if w.opts.MaxDepth > 0 && depth >= w.opts.MaxDepth {
+				continue
+			}
+			if !w.walkDir(childAbs, childDisplay, childRel, depth+1, scopes) {
+				return false
+			}
+			continue
+		}
+		if !w.send(childDisplay, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// ignored 从最深的目录开始查找匹配的规则，第一个匹配的规则决定路径是否被忽略
+func ignored(scopes []scope, abs string, isDir bool) bool {
+	for i := len(scopes) - 1; i >= 0; i-- {
+		s := scopes[i]
+		rel, ok := strings.CutPrefix(abs, s.base+string(filepath.Separator))
+		if !ok {
+			continue
+		}
+		if matched, negated := s.rules.Match(filepath.ToSlash(rel), isDir); matched {
+			return !negated
+		}
+	}
+	return false
+}
+
+// Run 遍历 roots 中的文件，用 workers 个 goroutine 并发调用 search，
+// 再按遍历的顺序把结果交给 emit，因此输出顺序与并发数无关
+//
+// 遍历中的错误也按顺序交给 emit（此时不调用 search）。emit 返回 false 时停止搜索。
+// workers 小于 1 时使用 CPU 核数。
+func Run[T any](ctx context.Context, roots []string, opts Options, workers int,
+	search func(ctx context.Context, path string) T,
+	emit func(path string, result T, err error) bool) error {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type job struct {
+		index int
+		path  string
+		err   error
+	}
+	type result struct {
+		job
+		value T
+	}
+	jobs := make(chan job, workers*4)
+	results := make(chan result, workers*4)
+
+	go func() {
+		defer close(jobs)
+		index := 0
+		w := newWalker(runCtx, opts, func(path string, err error) bool {
+			select {
+			case jobs <- job{index: index, path: path, err: err}:
+				index++
+				return true
+			case <-runCtx.Done():
+				return false
+			}
+		})
+		for _, root := range roots {
+			if !w.walkRoot(root) {
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				r := result{job: j}
+				if j.err == nil && runCtx.Err() == nil {
+					r.value = search(runCtx, j.path)
+				}
+				select {
+				case results <- r:
+				case <-runCtx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// 先完成的结果暂存起来，直到轮到它输出
+	pending := make(map[int]result)
+	next := 0
+	for r := range results {
+		pending[r.index] = r
+		for {
+			p, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if !emit(p.path, p.value, p.err) {
+				return nil
+			}
+		}
+	}
+	return ctx.Err()
+}
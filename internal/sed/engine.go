@@ -0,0 +1,796 @@
+package sed
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Options 执行选项
+type Options struct {
+	Quiet    bool // -n，不自动输出模式空间
+	NullData bool // -z，行以 NUL 字符分隔
+
+	Stdin  io.Reader
+	Stdout io.Writer // w /dev/stdout 的输出目标
+	Stderr io.Writer // 输入文件无法读取时的错误信息
+}
+
+// Engine 执行 sed 程序，保持跨文件的状态（保持空间、q 命令、w 文件）
+type Engine struct {
+	prog *Program
+	opts Options
+
+	hold      string
+	lastRegex *regexp.Regexp
+	quit      bool
+	exitCode  int
+	failed    bool // 有输入文件无法读取
+	wfiles    map[string]*output
+
+	// 当前输入流的状态
+	in       *lineReader
+	out      *output
+	lineNo   int
+	ranges   []rangeState
+	appended []appendItem
+	steps    int
+}
+
+// rangeState 范围地址的状态
+type rangeState struct {
+	active  bool
+	started bool // 0,/re/ 是否已经开始
+	end     int  // 以行号结束的范围的最后一行
+}
+
+// appendItem 周期结束时输出的内容：a 的文本或 r 的文件
+type appendItem struct {
+	text string
+	file string
+}
+
+// NewEngine 创建执行引擎，w 命令使用的文件在此时创建
+func NewEngine(prog *Program, opts Options) (*Engine, error) {
+	e := &Engine{
+		prog:   prog,
+		opts:   opts,
+		wfiles: make(map[string]*output),
+	}
+	if prog.quiet {
+		e.opts.Quiet = true
+	}
+	for _, name := range prog.wfiles {
+		if _, ok := e.wfiles[name]; ok {
+			continue
+		}
+		switch name {
+		case "/dev/stdout":
+			e.wfiles[name] = e.newOutput(opts.Stdout, nil)
+		case "/dev/stderr":
+			e.wfiles[name] = e.newOutput(opts.Stderr, nil)
+		default:
+			file, err := os.Create(name)
+			if err != nil {
+				e.Close()
+				return nil, fmt.Errorf("无法打开文件 %s: %w", name, unwrapPathError(err))
+			}
+			e.wfiles[name] = e.newOutput(file, file)
+		}
+	}
+	return e, nil
+}
+
+// Close 关闭 w 命令打开的文件
+func (e *Engine) Close() error {
+	var firstErr error
+	for _, out := range e.wfiles {
+		if err := out.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Quit 是否执行了 q 或 Q 命令，之后不应再处理其他输入
+func (e *Engine) Quit() bool {
+	return e.quit
+}
+
+// ExitCode q 或 Q 命令指定的退出码
+func (e *Engine) ExitCode() int {
+	return e.exitCode
+}
+
+// Failed 是否有输入文件无法读取（错误信息已输出到 Stderr）
+func (e *Engine) Failed() bool {
+	return e.failed
+}
+
+// Run 把 files（"-" 表示标准输入）作为一个连续的输入流处理，结果写入 w。
+// 行号和范围地址在每次调用时重新开始，$ 表示这次调用的最后一行。
+func (e *Engine) Run(ctx context.Context, files []string, w io.Writer) error {
+	e.out = e.newOutput(w, nil)
+	e.in = newLineReader(ctx, e, files)
+	e.lineNo = 0
+	e.ranges = make([]rangeState, len(e.prog.commands))
+	defer e.in.close()
+
+	err := e.run(ctx)
+	for _, out := range e.wfiles {
+		if flushErr := out.flush(); err == nil {
+			err = flushErr
+		}
+	}
+	if flushErr := e.out.flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+func (e *Engine) run(ctx context.Context) error {
+	for !e.quit {
+		line, ok, err := e.in.next()
+		if err != nil || !ok {
+			return err
+		}
+		e.lineNo++
+		ps := line
+
+		// D 命令在不读取新行的情况下重新开始周期
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			result, err := e.cycle(ps)
+			if err != nil {
+				return err
+			}
+			if err := e.endCycle(result); err != nil {
+				return err
+			}
+			if !result.restart {
+				break
+			}
+			ps = result.ps
+		}
+	}
+	return nil
+}
+
+// cycleResult 一个周期结束时的状态
+type cycleResult struct {
+	ps        string
+	autoprint bool
+	restart   bool // D：用剩余的模式空间重新开始周期
+}
+
+// endCycle 周期结束时自动输出模式空间并输出追加的内容
+func (e *Engine) endCycle(r cycleResult) error {
+	if r.autoprint && !e.opts.Quiet {
+		if err := e.out.writeLine(r.ps, e.in.missingNewline); err != nil {
+			return err
+		}
+	}
+	return e.flushAppended()
+}
+
+// flushAppended 输出 a 和 r 命令追加的内容
+func (e *Engine) flushAppended() error {
+	for _, item := range e.appended {
+		if item.file == "" {
+			if err := e.out.writeLine(item.text, false); err != nil {
+				return err
+			}
+			continue
+		}
+		// r 命令的文件不存在时忽略
+		data, err := os.ReadFile(item.file)
+		if err != nil {
+			continue
+		}
+		if err := e.out.write(string(data)); err != nil {
+			return err
+		}
+	}
+	e.appended = e.appended[:0]
+	return nil
+}
+
+// cycle 对模式空间执行所有命令
+func (e *Engine) cycle(ps string) (cycleResult, error) {
+	cmds := e.prog.commands
+	substituted := false
+
+	for pc := 0; pc < len(cmds); pc++ {
+		cmd := cmds[pc]
+		matched, err := e.matches(pc, cmd, ps)
+		if err != nil {
+			return cycleResult{}, err
+		}
+		if !matched {
+			if cmd.name == '{' {
+				pc = cmd.jump
+			}
+			continue
+		}
+
+		switch cmd.name {
+		case '{', '}', ':':
+		case '=':
+			if err := e.out.writeLine(strconv.Itoa(e.lineNo), false); err != nil {
+				return cycleResult{}, err
+			}
+		case 'a':
+			e.appended = append(e.appended, appendItem{text: cmd.text})
+		case 'r':
+			e.appended = append(e.appended, appendItem{file: cmd.text})
+		case 'i':
+			if err := e.out.writeLine(cmd.text, false); err != nil {
+				return cycleResult{}, err
+			}
+		case 'c':
+			// 范围只在最后一行输出文本
+			if cmd.addr2 == nil || cmd.negate || !e.ranges[pc].active {
+				if err := e.out.writeLine(cmd.text, false); err != nil {
+					return cycleResult{}, err
+				}
+			}
+			return cycleResult{}, nil
+		case 'd':
+			return cycleResult{}, nil
+		case 'D':
+			i := strings.IndexByte(ps, '\n')
+			if i < 0 {
+				return cycleResult{}, nil
+			}
+			return cycleResult{ps: ps[i+1:], restart: true}, nil
+		case 'g':
+			ps = e.hold
+		case 'G':
+			ps += "\n" + e.hold
+		case 'h':
+			e.hold = ps
+		case 'H':
+			e.hold += "\n" + ps
+		case 'x':
+			ps, e.hold = e.hold, ps
+		case 'z':
+			ps = ""
+		case 'l':
+			width := cmd.num
+			if width < 0 {
+				width = 70
+			}
+			if err := e.out.write(escapeLine(ps, width)); err != nil {
+				return cycleResult{}, err
+			}
+		case 'n':
+			if !e.in.hasNext() {
+				// 没有下一行时结束周期，不再执行后面的命令
+				return cycleResult{ps: ps, autoprint: true}, nil
+			}
+			if err := e.endCycle(cycleResult{ps: ps, autoprint: true}); err != nil {
+				return cycleResult{}, err
+			}
+			line, _, err := e.in.next()
+			if err != nil {
+				return cycleResult{}, err
+			}
+			e.lineNo++
+			ps = line
+		case 'N':
+			if !e.in.hasNext() {
+				return cycleResult{ps: ps, autoprint: true}, nil
+			}
+			if err := e.flushAppended(); err != nil {
+				return cycleResult{}, err
+			}
+			line, _, err := e.in.next()
+			if err != nil {
+				return cycleResult{}, err
+			}
+			e.lineNo++
+			ps += "\n" + line
+		case 'p':
+			if err := e.out.writeLine(ps, e.in.missingNewline); err != nil {
+				return cycleResult{}, err
+			}
+		case 'P':
+			first, _, _ := strings.Cut(ps, "\n")
+			if err := e.out.writeLine(first, false); err != nil {
+				return cycleResult{}, err
+			}
+		case 'q', 'Q':
+			e.quit = true
+			if cmd.num > 0 {
+				e.exitCode = cmd.num
+			}
+			return cycleResult{ps: ps, autoprint: cmd.name == 'q'}, nil
+		case 'w':
+			if err := e.writeFile(cmd.text, ps); err != nil {
+				return cycleResult{}, err
+			}
+		case 's':
+			replaced, result, err := e.substitute(cmd.subst, ps)
+			if err != nil {
+				return cycleResult{}, err
+			}
+			if !replaced {
+				break
+			}
+			substituted = true
+			ps = result
+			for i := 0; i < cmd.subst.print; i++ {
+				if err := e.out.writeLine(ps, e.in.missingNewline); err != nil {
+					return cycleResult{}, err
+				}
+			}
+			if cmd.subst.wfile != "" {
+				if err := e.writeFile(cmd.subst.wfile, ps); err != nil {
+					return cycleResult{}, err
+				}
+			}
+		case 'y':
+			ps = strings.Map(func(r rune) rune {
+				if to, ok := cmd.trans[r]; ok {
+					return to
+				}
+				return r
+			}, ps)
+		case 'b':
+			pc = cmd.jump
+		case 't', 'T':
+			if substituted == (cmd.name == 't') {
+				pc = cmd.jump
+			}
+			substituted = false
+		case 'F':
+			if err := e.out.writeLine(e.in.fileName(), false); err != nil {
+				return cycleResult{}, err
+			}
+		}
+
+		// 跳转回前面的标签可能形成死循环，定期检查是否被取消
+		if cmd.name == 'b' || cmd.name == 't' || cmd.name == 'T' {
+			if e.steps++; e.steps&1023 == 0 && e.in.ctx.Err() != nil {
+				return cycleResult{}, e.in.ctx.Err()
+			}
+		}
+	}
+	return cycleResult{ps: ps, autoprint: true}, nil
+}
+
+// writeFile 执行 w 命令，/dev/stdout 和 /dev/stderr 立即输出以保持与其他输出的顺序
+func (e *Engine) writeFile(name, ps string) error {
+	out := e.wfiles[name]
+	if out.closer != nil {
+		return out.writeLine(ps, false)
+	}
+	if err := e.out.flush(); err != nil {
+		return err
+	}
+	if err := out.writeLine(ps, false); err != nil {
+		return err
+	}
+	return out.flush()
+}
+
+// matches 判断命令的地址是否匹配当前行
+func (e *Engine) matches(pc int, cmd *command, ps string) (bool, error) {
+	if cmd.addr1 == nil {
+		return true, nil
+	}
+	if cmd.addr2 == nil {
+		matched, err := e.matchAddress(cmd.addr1, ps)
+		return matched != cmd.negate, err
+	}
+	matched, err := e.matchRange(&e.ranges[pc], cmd, ps)
+	return matched != cmd.negate, err
+}
+
+// matchRange 判断范围地址是否匹配，范围包括开始和结束的行
+func (e *Engine) matchRange(state *rangeState, cmd *command, ps string) (bool, error) {
+	addr1, addr2 := cmd.addr1, cmd.addr2
+
+	if !state.active {
+		if addr1.kind == addrLine && addr1.line == 0 && !state.started {
+			// 0,/re/：范围从第一行开始，第一行也可以结束范围
+			state.started = true
+			state.active = true
+		} else {
+			matched, err := e.matchAddress(addr1, ps)
+			if err != nil || !matched {
+				return false, err
+			}
+			state.active = true
+			switch addr2.kind {
+			case addrLine:
+				if addr2.line <= e.lineNo {
+					state.active = false
+				}
+				state.end = addr2.line
+			case addrPlus:
+				state.end = e.lineNo + addr2.line
+				state.active = addr2.line > 0
+			case addrMult:
+				if addr2.line <= 0 || e.lineNo%addr2.line == 0 {
+					state.active = false
+				} else {
+					state.end = (e.lineNo/addr2.line + 1) * addr2.line
+				}
+			case addrLast:
+				state.active = !e.in.isLast()
+			}
+			return true, nil
+		}
+	}
+
+	// 范围已经开始，判断当前行是否结束范围
+	switch addr2.kind {
+	case addrLine, addrPlus, addrMult:
+		if e.lineNo >= state.end {
+			state.active = false
+		}
+	default:
+		matched, err := e.matchAddress(addr2, ps)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			state.active = false
+		}
+	}
+	return true, nil
+}
+
+// matchAddress 判断单个地址是否匹配当前行
+func (e *Engine) matchAddress(addr *address, ps string) (bool, error) {
+	switch addr.kind {
+	case addrLine:
+		return e.lineNo == addr.line, nil
+	case addrLast:
+		return e.in.isLast(), nil
+	case addrStep:
+		if addr.step <= 0 {
+			return e.lineNo == addr.line, nil
+		}
+		return e.lineNo >= addr.line && (e.lineNo-addr.line)%addr.step == 0, nil
+	case addrRegex:
+		re, err := e.useRegex(addr.regex)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(ps), nil
+	}
+	return false, nil
+}
+
+// useRegex 返回要使用的正则表达式，nil 表示上一次使用的正则表达式
+func (e *Engine) useRegex(re *regexp.Regexp) (*regexp.Regexp, error) {
+	if re == nil {
+		if e.lastRegex == nil {
+			return nil, fmt.Errorf("没有上一个正则表达式")
+		}
+		return e.lastRegex, nil
+	}
+	e.lastRegex = re
+	return re, nil
+}
+
+// substitute 执行 s 命令，返回是否发生了替换
+func (e *Engine) substitute(s *substitution, ps string) (bool, string, error) {
+	re, err := e.useRegex(s.regex)
+	if err != nil {
+		return false, "", err
+	}
+
+	var sb strings.Builder
+	replaced := false
+	last, count := 0, 0
+	for _, loc := range re.FindAllStringSubmatchIndex(ps, -1) {
+		count++
+		if count < s.occurrence {
+			continue
+		}
+		sb.WriteString(ps[last:loc[0]])
+		expandReplacement(&sb, s.replacement, ps, loc)
+		last = loc[1]
+		replaced = true
+		if !s.global {
+			break
+		}
+	}
+	if !replaced {
+		return false, ps, nil
+	}
+	sb.WriteString(ps[last:])
+	return true, sb.String(), nil
+}
+
+// expandReplacement 生成一次匹配的替换文本
+func expandReplacement(sb *strings.Builder, parts []replacePart, src string, loc []int) {
+	mode, next := caseNone, caseNone
+	write := func(s string) {
+		if s == "" {
+			return
+		}
+		switch mode {
+		case caseUpper:
+			s = strings.ToUpper(s)
+		case caseLower:
+			s = strings.ToLower(s)
+		}
+		if next != caseNone {
+			r, size := utf8.DecodeRuneInString(s)
+			if next == caseUpperNext {
+				r = unicode.ToUpper(r)
+			} else {
+				r = unicode.ToLower(r)
+			}
+			s = string(r) + s[size:]
+			next = caseNone
+		}
+		sb.WriteString(s)
+	}
+
+	for _, part := range parts {
+		switch {
+		case part.op == caseUpper || part.op == caseLower:
+			mode = part.op
+		case part.op == caseEnd:
+			mode, next = caseNone, caseNone
+		case part.op != caseNone:
+			next = part.op
+		case part.group < 0:
+			write(part.literal)
+		case 2*part.group+1 < len(loc) && loc[2*part.group] >= 0:
+			write(src[loc[2*part.group]:loc[2*part.group+1]])
+		}
+	}
+}
+
+// escapeLine l 命令的输出：不可打印字符转义，长行以 \ 折行，行尾加 $
+func escapeLine(s string, width int) string {
+	var sb, line strings.Builder
+	lineLen := 0
+	add := func(piece string) {
+		if width > 1 && lineLen+len(piece) > width-1 {
+			sb.WriteString(line.String())
+			sb.WriteString("\\\n")
+			line.Reset()
+			lineLen = 0
+		}
+		line.WriteString(piece)
+		lineLen += utf8.RuneCountInString(piece)
+	}
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '\\':
+			add(`\\`)
+		case r == '\a':
+			add(`\a`)
+		case r == '\b':
+			add(`\b`)
+		case r == '\f':
+			add(`\f`)
+		case r == '\n':
+			add(`\n`)
+		case r == '\r':
+			add(`\r`)
+		case r == '\t':
+			add(`\t`)
+		case r == '\v':
+			add(`\v`)
+		case r == utf8.RuneError && size == 1 || !unicode.IsPrint(r):
+			for _, b := range []byte(s[i : i+size]) {
+				add(fmt.Sprintf("\\%03o", b))
+			}
+		default:
+			add(s[i : i+size])
+		}
+		i += size
+	}
+	sb.WriteString(line.String())
+	sb.WriteString("$\n")
+	return sb.String()
+}
+
+// ---- 输入 ----
+
+// lineReader 按顺序读取多个文件的行，并预读一行以判断最后一行（$）
+type lineReader struct {
+	engine *Engine
+	ctx    context.Context
+	files  []string
+	name   string // 当前文件名
+	reader *bufio.Reader
+	closer io.Closer
+
+	// 预读的下一行
+	peeked      bool
+	peekLine    string
+	peekOK      bool
+	peekMissing bool
+	peekName    string
+
+	missingNewline bool // 当前行末尾没有换行符
+	current        string
+}
+
+func newLineReader(ctx context.Context, e *Engine, files []string) *lineReader {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	return &lineReader{engine: e, ctx: ctx, files: files}
+}
+
+func (r *lineReader) close() {
+	if r.closer != nil {
+		r.closer.Close()
+		r.closer = nil
+	}
+}
+
+// fileName 当前行所在的文件名，标准输入为 -
+func (r *lineReader) fileName() string {
+	return r.current
+}
+
+// next 返回下一行
+func (r *lineReader) next() (string, bool, error) {
+	if !r.peeked {
+		if err := r.peek(); err != nil {
+			return "", false, err
+		}
+	}
+	r.peeked = false
+	r.missingNewline = r.peekMissing
+	r.current = r.peekName
+	return r.peekLine, r.peekOK, nil
+}
+
+// hasNext 是否还有下一行
+func (r *lineReader) hasNext() bool {
+	if !r.peeked {
+		if err := r.peek(); err != nil {
+			return false
+		}
+	}
+	return r.peekOK
+}
+
+// isLast 当前行是否是最后一行
+func (r *lineReader) isLast() bool {
+	return !r.hasNext()
+}
+
+// peek 预读下一行，当前文件结束时打开下一个文件
+func (r *lineReader) peek() error {
+	delim := byte('\n')
+	if r.engine.opts.NullData {
+		delim = 0
+	}
+	for {
+		if r.reader == nil {
+			if len(r.files) == 0 {
+				r.peeked, r.peekOK = true, false
+				return nil
+			}
+			r.openNext()
+			continue
+		}
+
+		// 等待输入前先输出已有结果
+		if r.reader.Buffered() == 0 {
+			if err := r.engine.out.flush(); err != nil {
+				return err
+			}
+		}
+		data, err := r.reader.ReadString(delim)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if data == "" && err == io.EOF {
+			r.close()
+			r.reader = nil
+			continue
+		}
+		r.peeked, r.peekOK = true, true
+		r.peekMissing = !strings.HasSuffix(data, string(delim))
+		r.peekLine = strings.TrimSuffix(data, string(delim))
+		r.peekName = r.name
+		return nil
+	}
+}
+
+// openNext 打开下一个输入文件，无法打开时输出错误信息并跳过
+func (r *lineReader) openNext() {
+	name := r.files[0]
+	r.files = r.files[1:]
+	r.name = name
+	if name == "-" {
+		r.reader = bufio.NewReaderSize(r.engine.opts.Stdin, 64*1024)
+		return
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		fmt.Fprintf(r.engine.opts.Stderr, "sed: 无法读取 %s: %v\n", name, unwrapPathError(err))
+		r.engine.failed = true
+		return
+	}
+	r.reader = bufio.NewReaderSize(file, 64*1024)
+	r.closer = file
+}
+
+// unwrapPathError 去掉 *os.PathError 中重复的文件名
+func unwrapPathError(err error) error {
+	if pathErr, ok := err.(*os.PathError); ok {
+		return pathErr.Err
+	}
+	return err
+}
+
+// ---- 输出 ----
+
+// output 带缓冲的输出，保留最后一行缺少的换行符
+type output struct {
+	writer  *bufio.Writer
+	closer  io.Closer
+	delim   byte // 行结束符，-z 时为 NUL
+	missing bool // 上一次输出的行没有结束符
+}
+
+func (e *Engine) newOutput(w io.Writer, closer io.Closer) *output {
+	out := &output{writer: bufio.NewWriter(w), closer: closer, delim: '\n'}
+	if e.opts.NullData {
+		out.delim = 0
+	}
+	return out
+}
+
+func (o *output) write(s string) error {
+	if o.missing {
+		o.missing = false
+		if err := o.writer.WriteByte(o.delim); err != nil {
+			return err
+		}
+	}
+	_, err := o.writer.WriteString(s)
+	return err
+}
+
+// writeLine 输出一行，noNewline 为 true 时不输出结束符（输入的最后一行没有结束符）
+func (o *output) writeLine(s string, noNewline bool) error {
+	if err := o.write(s); err != nil {
+		return err
+	}
+	if noNewline {
+		o.missing = true
+		return nil
+	}
+	return o.writer.WriteByte(o.delim)
+}
+
+func (o *output) flush() error {
+	return o.writer.Flush()
+}
+
+func (o *output) close() error {
+	err := o.flush()
+	if o.closer != nil {
+		if closeErr := o.closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,642 @@
+package sed
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError sed 脚本的语法错误，Pos 是出错的字符位置（从 1 开始）
+type SyntaxError struct {
+	Pos     int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("第 %d 个字符: %s", e.Pos, e.Message)
+}
+
+// Program 解析后的 sed 脚本
+type Program struct {
+	commands []*command
+	quiet    bool     // 脚本以 #n 开头，相当于 -n
+	wfiles   []string // w 命令和 s///w 使用的文件，开始执行前创建
+}
+
+// Quiet 脚本是否以 #n 行开头（等同于 -n 选项）
+func (p *Program) Quiet() bool {
+	return p.quiet
+}
+
+// addressKind 地址类型
+type addressKind int
+
+const (
+	addrLine  addressKind = iota // 行号
+	addrLast                     // $
+	addrRegex                    // /re/
+	addrStep                     // first~step
+	addrPlus                     // addr1,+N
+	addrMult                     // addr1,~N
+)
+
+// address 命令的地址
+type address struct {
+	kind  addressKind
+	line  int
+	step  int
+	regex *regexp.Regexp // 为 nil 时使用上一次的正则表达式
+}
+
+// command 一条 sed 命令
+type command struct {
+	addr1, addr2 *address
+	negate       bool
+	name         byte
+	pos          int
+
+	text  string // a、i、c 的文本，r、w 的文件名，b、t、T、: 的标签
+	jump  int    // { 对应的 } 的位置，b、t、T 跳转的位置
+	num   int    // q、Q 的退出码，l 的行宽
+	subst *substitution
+	trans map[rune]rune // y
+}
+
+// substitution s 命令
+type substitution struct {
+	regex       *regexp.Regexp // 为 nil 时使用上一次的正则表达式
+	replacement []replacePart
+	global      bool
+	occurrence  int // 替换第几个匹配，默认为 1
+	print       int // p 标志的个数
+	wfile       string
+}
+
+// caseOp 替换文本中的大小写转换（GNU 扩展）
+type caseOp int
+
+const (
+	caseNone      caseOp = iota
+	caseUpper            // \U
+	caseLower            // \L
+	caseEnd              // \E
+	caseUpperNext        // \u
+	caseLowerNext        // \l
+)
+
+// replacePart 替换文本的一部分：普通文本、分组引用或大小写转换
+type replacePart struct {
+	literal string
+	group   int // -1 表示普通文本，0 表示 &
+	op      caseOp
+}
+
+// parser sed 脚本解析器
+type parser struct {
+	src      string
+	pos      int
+	extended bool
+	prog     *Program
+	blocks   []int // 未结束的 { 的位置
+}
+
+// Parse 解析 sed 脚本，extended 为 true 时使用扩展正则表达式（-E）
+func Parse(script string, extended bool) (prog *Program, err error) {
+	p := &parser{src: script, extended: extended, prog: &Program{}}
+	if strings.HasPrefix(script, "#n\n") || script == "#n" {
+		p.prog.quiet = true
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			syntaxErr, ok := r.(*SyntaxError)
+			if !ok {
+				panic(r)
+			}
+			prog, err = nil, syntaxErr
+		}
+	}()
+
+	p.commands()
+	if len(p.blocks) > 0 {
+		p.failAt(p.prog.commands[p.blocks[len(p.blocks)-1]].pos, "{ 没有对应的 }")
+	}
+	p.resolveLabels()
+	return p.prog, nil
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	p.failAt(p.pos+1, format, args...)
+}
+
+func (p *parser) failAt(pos int, format string, args ...interface{}) {
+	panic(&SyntaxError{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// commands 解析所有命令
+func (p *parser) commands() {
+	for {
+		// 跳过命令之间的空白和分隔符
+		for !p.eof() && strings.IndexByte(" \t\n;", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		if p.eof() {
+			return
+		}
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+		p.command()
+	}
+}
+
+func (p *parser) skipLine() {
+	for !p.eof() && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+// command 解析一条命令：[addr1[,addr2]][!]cmd[args]
+func (p *parser) command() {
+	cmd := &command{pos: p.pos + 1}
+	cmd.addr1 = p.address(false)
+	if cmd.addr1 != nil {
+		p.skipSpaces()
+		if p.peek() == ',' {
+			p.pos++
+			p.skipSpaces()
+			cmd.addr2 = p.address(true)
+			if cmd.addr2 == nil {
+				p.fail("缺少第二个地址")
+			}
+		}
+	}
+	if cmd.addr1 != nil && cmd.addr1.kind == addrLine && cmd.addr1.line == 0 &&
+		(cmd.addr2 == nil || cmd.addr2.kind != addrRegex) {
+		p.fail("行号 0 只能用于 0,/re/")
+	}
+
+	p.skipSpaces()
+	for p.peek() == '!' {
+		cmd.negate = true
+		p.pos++
+		p.skipSpaces()
+	}
+	if p.eof() {
+		p.fail("缺少命令")
+	}
+
+	cmd.name = p.src[p.pos]
+	p.pos++
+	index := len(p.prog.commands)
+	p.prog.commands = append(p.prog.commands, cmd)
+
+	switch cmd.name {
+	case '{':
+		p.blocks = append(p.blocks, index)
+		return
+	case '}':
+		if cmd.addr1 != nil || cmd.negate {
+			p.failAt(cmd.pos, "} 不能有地址")
+		}
+		if len(p.blocks) == 0 {
+			p.failAt(cmd.pos, "多余的 }")
+		}
+		open := p.blocks[len(p.blocks)-1]
+		p.blocks = p.blocks[:len(p.blocks)-1]
+		p.prog.commands[open].jump = index
+	case ':':
+		if cmd.addr1 != nil {
+			p.failAt(cmd.pos, ": 不能有地址")
+		}
+		cmd.text = p.label()
+		if cmd.text == "" {
+			p.fail(": 需要标签名")
+		}
+	case 'b', 't', 'T':
+		cmd.text = p.label()
+	case 'a', 'i', 'c':
+		cmd.text = p.text()
+		return
+	case 'r', 'w':
+		cmd.text = p.fileName()
+		if cmd.name == 'w' {
+			p.prog.wfiles = append(p.prog.wfiles, cmd.text)
+		}
+		return
+	case 's':
+		cmd.subst = p.substitution()
+		if cmd.subst.wfile != "" {
+			return
+		}
+	case 'y':
+		cmd.trans = p.transliteration()
+	case 'q', 'Q', 'l':
+		p.skipSpaces()
+		cmd.num = -1
+		if start := p.pos; isDigit(p.peek()) {
+			for isDigit(p.peek()) {
+				p.pos++
+			}
+			cmd.num, _ = strconv.Atoi(p.src[start:p.pos])
+		}
+		if (cmd.name == 'q' || cmd.name == 'Q') && cmd.addr2 != nil {
+			p.failAt(cmd.pos, "%c 只能有一个地址", cmd.name)
+		}
+	case '=', 'd', 'D', 'g', 'G', 'h', 'H', 'n', 'N', 'p', 'P', 'x', 'z', 'F':
+	default:
+		p.failAt(cmd.pos, "未知的命令 '%c'", cmd.name)
+	}
+	p.endCommand()
+}
+
+// endCommand 命令之后只能是空白、分号、换行、} 或注释
+func (p *parser) endCommand() {
+	p.skipSpaces()
+	switch p.peek() {
+	case 0, ';', '\n', '}', '#':
+		return
+	}
+	p.fail("命令后有多余的字符 '%c'", p.peek())
+}
+
+// address 解析地址，second 为 true 时允许 +N 和 ~N
+func (p *parser) address(second bool) *address {
+	switch ch := p.peek(); {
+	case isDigit(ch):
+		n := p.number()
+		if p.peek() == '~' && !second {
+			p.pos++
+			return &address{kind: addrStep, line: n, step: p.number()}
+		}
+		return &address{kind: addrLine, line: n}
+	case ch == '$':
+		p.pos++
+		return &address{kind: addrLast}
+	case second && (ch == '+' || ch == '~'):
+		p.pos++
+		if !isDigit(p.peek()) {
+			p.fail("%c 后面需要数字", ch)
+		}
+		kind := addrPlus
+		if ch == '~' {
+			kind = addrMult
+		}
+		return &address{kind: kind, line: p.number()}
+	case ch == '/' || ch == '\\':
+		if ch == '\\' {
+			p.pos++
+			if p.eof() || p.peek() == '\n' || p.peek() == '\\' {
+				p.fail("无效的正则表达式分隔符")
+			}
+		}
+		delim := p.src[p.pos]
+		p.pos++
+		pattern := p.delimited(delim, true)
+
+		// 地址的 I 和 M 标志
+		ignoreCase, multiline := false, false
+		for {
+			switch p.peek() {
+			case 'I':
+				ignoreCase = true
+			case 'M':
+				multiline = true
+			default:
+				return &address{kind: addrRegex, regex: p.regex(pattern, ignoreCase, multiline)}
+			}
+			p.pos++
+		}
+	}
+	return nil
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func (p *parser) number() int {
+	start := p.pos
+	for isDigit(p.peek()) {
+		p.pos++
+	}
+	if start == p.pos {
+		p.fail("需要数字")
+	}
+	n, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		p.fail("数字太大: %s", p.src[start:p.pos])
+	}
+	return n
+}
+
+// regex 编译正则表达式，空表达式表示使用上一次的正则表达式
+func (p *parser) regex(pattern string, ignoreCase, multiline bool) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	re, err := compileRegex(pattern, p.extended, ignoreCase, multiline)
+	if err != nil {
+		p.fail("%v", err)
+	}
+	return re
+}
+
+// delimited 读取到 delim 为止的内容（不含 delim）
+//
+// 转义的分隔符表示分隔符本身；inRegex 为 true 时其他转义原样保留，
+// 由正则表达式转换处理，否则 \n 转为换行，其他转义也原样保留。
+func (p *parser) delimited(delim byte, inRegex bool) string {
+	var sb strings.Builder
+	inBracket := false
+	for {
+		if p.eof() {
+			p.fail("未结束的 '%c'", delim)
+		}
+		ch := p.src[p.pos]
+		switch {
+		case ch == '\\' && p.pos+1 < len(p.src):
+			next := p.src[p.pos+1]
+			p.pos += 2
+			switch {
+			case next == delim && delim != '\n':
+				sb.WriteString(escapedDelimiter(delim, inRegex))
+			case next == '\n':
+				// 转义的换行表示换行
+				sb.WriteByte('\n')
+			case next == 'n' && !inRegex:
+				sb.WriteByte('\n')
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(next)
+			}
+			continue
+		case ch == '\n' && !inRegex:
+			p.fail("未结束的 '%c'", delim)
+		case inRegex && ch == '[' && !inBracket:
+			// 方括号中的分隔符不结束正则表达式
+			inBracket = true
+			sb.WriteByte(ch)
+			p.pos++
+			if p.peek() == '^' {
+				sb.WriteByte('^')
+				p.pos++
+			}
+			if p.peek() == ']' {
+				sb.WriteByte(']')
+				p.pos++
+			}
+			continue
+		case inRegex && ch == ']' && inBracket:
+			inBracket = false
+		case ch == delim && !inBracket:
+			p.pos++
+			return sb.String()
+		}
+		sb.WriteByte(ch)
+		p.pos++
+	}
+}
+
+// escapedDelimiter 转义的分隔符在正则表达式中表示普通字符
+func escapedDelimiter(delim byte, inRegex bool) string {
+	switch {
+	case !inRegex:
+		// 替换文本中 \ 后面的字符本身就是普通字符
+		return "\\" + string(delim)
+	case delim == '^':
+		return `\^`
+	case isWordByte(delim) || delim >= utf8.RuneSelf:
+		return string(delim)
+	}
+	return "[" + string(delim) + "]"
+}
+
+// label 读取标签名，标签以分号或换行结束
+func (p *parser) label() string {
+	p.skipSpaces()
+	start := p.pos
+	for !p.eof() && p.src[p.pos] != ';' && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+	return strings.TrimRight(p.src[start:p.pos], " \t")
+}
+
+// fileName 读取 r、w 的文件名，文件名到行尾为止
+func (p *parser) fileName() string {
+	p.skipSpaces()
+	start := p.pos
+	p.skipLine()
+	name := p.src[start:p.pos]
+	if name == "" {
+		p.fail("缺少文件名")
+	}
+	return name
+}
+
+// text 读取 a、i、c 的文本
+//
+// 支持 POSIX 的 a\<换行>text 形式和 GNU 的单行 a text 形式，
+// 行尾的反斜杠表示文本在下一行继续，其他反斜杠去掉后保留后面的字符。
+func (p *parser) text() string {
+	p.skipSpaces()
+	if p.peek() == '\\' {
+		p.pos++
+		if p.peek() == '\n' {
+			p.pos++
+		}
+	}
+
+	var sb strings.Builder
+	for !p.eof() {
+		ch := p.src[p.pos]
+		if ch == '\n' {
+			p.pos++
+			break
+		}
+		if ch == '\\' && p.pos+1 < len(p.src) {
+			p.pos++
+			ch = p.src[p.pos]
+		}
+		sb.WriteByte(ch)
+		p.pos++
+	}
+	return sb.String()
+}
+
+// substitution 解析 s/regex/replacement/flags
+func (p *parser) substitution() *substitution {
+	if p.eof() || p.peek() == '\n' || p.peek() == '\\' {
+		p.fail("无效的分隔符")
+	}
+	delim := p.src[p.pos]
+	p.pos++
+	pattern := p.delimited(delim, true)
+	replacement := p.delimited(delim, false)
+
+	s := &substitution{replacement: parseReplacement(replacement), occurrence: 1}
+	ignoreCase, multiline, hasOccurrence := false, false, false
+	for !p.eof() {
+		switch ch := p.peek(); {
+		case ch == 'g':
+			s.global = true
+		case ch == 'p':
+			s.print++
+		case ch == 'i' || ch == 'I':
+			ignoreCase = true
+		case ch == 'm' || ch == 'M':
+			multiline = true
+		case isDigit(ch):
+			if hasOccurrence {
+				p.fail("s 命令有多个数字标志")
+			}
+			hasOccurrence = true
+			s.occurrence = p.number()
+			if s.occurrence == 0 {
+				p.fail("s 命令的数字标志不能为 0")
+			}
+			continue
+		case ch == 'w':
+			p.pos++
+			s.wfile = p.fileName()
+			p.prog.wfiles = append(p.prog.wfiles, s.wfile)
+			s.regex = p.regex(pattern, ignoreCase, multiline)
+			return s
+		default:
+			s.regex = p.regex(pattern, ignoreCase, multiline)
+			return s
+		}
+		p.pos++
+	}
+	s.regex = p.regex(pattern, ignoreCase, multiline)
+	return s
+}
+
+// parseReplacement 解析替换文本：& 表示整个匹配，\1 到 \9 表示分组，
+// \n 表示换行，\U \L \u \l \E 转换大小写
+func parseReplacement(s string) []replacePart {
+	var parts []replacePart
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			parts = append(parts, replacePart{literal: literal.String(), group: -1})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case ch == '&':
+			flush()
+			parts = append(parts, replacePart{group: 0})
+		case ch == '\\' && i+1 < len(s):
+			i++
+			next := s[i]
+			switch {
+			case next >= '0' && next <= '9':
+				flush()
+				parts = append(parts, replacePart{group: int(next - '0')})
+			case strings.IndexByte("ULulE", next) >= 0:
+				flush()
+				op := map[byte]caseOp{'U': caseUpper, 'L': caseLower, 'u': caseUpperNext, 'l': caseLowerNext, 'E': caseEnd}[next]
+				parts = append(parts, replacePart{group: -1, op: op})
+			case next == 'n':
+				literal.WriteByte('\n')
+			case next == 't':
+				literal.WriteByte('\t')
+			default:
+				literal.WriteByte(next)
+			}
+		default:
+			literal.WriteByte(ch)
+		}
+	}
+	flush()
+	return parts
+}
+
+// transliteration 解析 y/source/dest/
+func (p *parser) transliteration() map[rune]rune {
+	if p.eof() || p.peek() == '\n' || p.peek() == '\\' {
+		p.fail("无效的分隔符")
+	}
+	delim := p.src[p.pos]
+	p.pos++
+	source := []rune(unescapeY(p.delimited(delim, false), delim))
+	dest := []rune(unescapeY(p.delimited(delim, false), delim))
+	if len(source) != len(dest) {
+		p.fail("y 命令的两个字符串长度不同")
+	}
+	trans := make(map[rune]rune, len(source))
+	for i, r := range source {
+		trans[r] = dest[i]
+	}
+	return trans
+}
+
+// unescapeY 处理 y 命令字符串中剩余的转义：\\ 表示反斜杠，\delim 表示分隔符
+func unescapeY(s string, delim byte) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(s[i])
+			}
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// resolveLabels 计算 b、t、T 的跳转位置，没有标签时跳到脚本末尾
+func (p *parser) resolveLabels() {
+	labels := make(map[string]int)
+	for i, cmd := range p.prog.commands {
+		if cmd.name == ':' {
+			if _, ok := labels[cmd.text]; ok {
+				p.failAt(cmd.pos, "重复的标签 '%s'", cmd.text)
+			}
+			labels[cmd.text] = i
+		}
+	}
+	for _, cmd := range p.prog.commands {
+		if cmd.name != 'b' && cmd.name != 't' && cmd.name != 'T' {
+			continue
+		}
+		if cmd.text == "" {
+			cmd.jump = len(p.prog.commands)
+			continue
+		}
+		target, ok := labels[cmd.text]
+		if !ok {
+			p.failAt(cmd.pos, "找不到标签 '%s'", cmd.text)
+		}
+		cmd.jump = target
+	}
+}
@@ -0,0 +1,176 @@
+package sed
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// compileRegex 把 POSIX 基本（BRE）或扩展（ERE）正则表达式转为 Go 的语法并编译，
+// 使用最左最长匹配
+func compileRegex(pattern string, extended, ignoreCase, multiline bool) (*regexp.Regexp, error) {
+	translated, err := translateRegex(pattern, extended)
+	if err != nil {
+		return nil, err
+	}
+	prefix := ""
+	switch {
+	case ignoreCase && multiline:
+		prefix = "(?im)"
+	case ignoreCase:
+		prefix = "(?i)"
+	case multiline:
+		prefix = "(?m)"
+	}
+	re, err := regexp.Compile(prefix + translated)
+	if err != nil {
+		return nil, fmt.Errorf("无效的正则表达式 '%s': %v", pattern, err)
+	}
+	re.Longest()
+	return re, nil
+}
+
+// translateRegex 转换正则表达式语法
+//
+// BRE 中 \( \) \{ \} \+ \? \| 是特殊字符，不带反斜杠时是普通字符；
+// 出现在开头的 * 和不在开头的 ^、不在结尾的 $ 也是普通字符。
+// 两种语法都支持 \n、\t、\< \> 和 \` \'，不支持反向引用。
+func translateRegex(pattern string, extended bool) (string, error) {
+	var sb strings.Builder
+	// atStart 当前位置是否是表达式或分组的开头（BRE 的 * 和 ^ 在此处有不同含义）
+	atStart := true
+
+	for i := 0; i < len(pattern); i++ {
+		ch := pattern[i]
+		start := atStart
+		atStart = false
+
+		switch ch {
+		case '[':
+			n, err := translateBracket(pattern[i:], &sb)
+			if err != nil {
+				return "", err
+			}
+			i += n - 1
+			continue
+		case '\\':
+			if i+1 >= len(pattern) {
+				return "", fmt.Errorf("正则表达式 '%s' 以反斜杠结尾", pattern)
+			}
+			i++
+			next := pattern[i]
+			switch {
+			case !extended && strings.IndexByte("(){}+?|", next) >= 0:
+				sb.WriteByte(next)
+				atStart = next == '(' || next == '|'
+			case next >= '1' && next <= '9':
+				return "", fmt.Errorf("不支持反向引用 \\%c", next)
+			case next == 'n':
+				sb.WriteString(`\n`)
+			case next == 't':
+				sb.WriteString(`\t`)
+			case next == '<' || next == '>':
+				sb.WriteString(`\b`)
+			case next == '`':
+				sb.WriteString(`\A`)
+			case next == '\'':
+				sb.WriteString(`\z`)
+			case strings.IndexByte("wWsSbB", next) >= 0:
+				sb.WriteByte('\\')
+				sb.WriteByte(next)
+			case isWordByte(next):
+				// 其他字母的转义表示字母本身
+				sb.WriteByte(next)
+			default:
+				sb.WriteString(regexp.QuoteMeta(string(next)))
+			}
+			continue
+		}
+
+		if extended {
+			sb.WriteByte(ch)
+			atStart = ch == '(' || ch == '|'
+			continue
+		}
+		switch ch {
+		case '(', ')', '{', '}', '+', '?', '|':
+			sb.WriteByte('\\')
+			sb.WriteByte(ch)
+		case '*':
+			if start {
+				sb.WriteString(`\*`)
+			} else {
+				sb.WriteByte('*')
+			}
+		case '^':
+			if start {
+				sb.WriteByte('^')
+				atStart = true // ^* 中的 * 是普通字符
+			} else {
+				sb.WriteString(`\^`)
+			}
+		case '$':
+			rest := pattern[i+1:]
+			if rest == "" || strings.HasPrefix(rest, `\)`) || strings.HasPrefix(rest, `\|`) {
+				sb.WriteByte('$')
+			} else {
+				sb.WriteString(`\$`)
+			}
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String(), nil
+}
+
+// translateBracket 转换方括号表达式，返回使用的字节数
+//
+// POSIX 方括号中的反斜杠是普通字符（sed 另外支持 \n 和 \t），开头的 ] 也是普通字符。
+func translateBracket(s string, sb *strings.Builder) (int, error) {
+	i := 1
+	sb.WriteByte('[')
+	if i < len(s) && s[i] == '^' {
+		sb.WriteByte('^')
+		i++
+	}
+	if i < len(s) && s[i] == ']' {
+		sb.WriteString(`\]`)
+		i++
+	}
+	for i < len(s) {
+		ch := s[i]
+		switch {
+		case ch == ']':
+			sb.WriteByte(']')
+			return i + 1, nil
+		case ch == '[' && i+1 < len(s) && strings.IndexByte(":.=", s[i+1]) >= 0:
+			// [:alpha:] 等字符类
+			end := strings.Index(s[i+2:], string(s[i+1])+"]")
+			if end < 0 {
+				return 0, fmt.Errorf("方括号表达式没有结束")
+			}
+			sb.WriteString(s[i : i+2+end+2])
+			i += 2 + end + 2
+			continue
+		case ch == '\\' && i+1 < len(s) && s[i+1] == 'n':
+			sb.WriteString(`\n`)
+			i += 2
+			continue
+		case ch == '\\' && i+1 < len(s) && s[i+1] == 't':
+			sb.WriteString(`\t`)
+			i += 2
+			continue
+		case ch == '\\' || ch == '[':
+			sb.WriteByte('\\')
+			sb.WriteByte(ch)
+		default:
+			sb.WriteByte(ch)
+		}
+		i++
+	}
+	return 0, fmt.Errorf("方括号表达式没有结束")
+}
+
+func isWordByte(ch byte) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_'
+}
@@ -0,0 +1,123 @@
+package sed
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// run 解析并执行 sed 脚本，输入取自 input，返回输出和 q/Q 指定的退出码
+func run(t *testing.T, script, input string, extended, quiet bool) (string, int) {
+	t.Helper()
+	prog, err := Parse(script, extended)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", script, err)
+	}
+	var out, errOut bytes.Buffer
+	engine, err := NewEngine(prog, Options{
+		Quiet:  quiet,
+		Stdin:  strings.NewReader(input),
+		Stdout: &out,
+		Stderr: &errOut,
+	})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+	if err := engine.Run(context.Background(), []string{"-"}, &out); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if err := engine.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return out.String(), engine.ExitCode()
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		input    string
+		extended bool
+		quiet    bool
+		want     string
+	}{
+		{"substitute first", `s/a/X/`, "banana\n", false, false, "bXnana\n"},
+		{"substitute global", `s/a/X/g`, "banana\n", false, false, "bXnXnX\n"},
+		{"substitute nth", `s/a/X/2`, "banana\n", false, false, "banXna\n"},
+		{"substitute nth and later", `s/a/X/2g`, "banana\n", false, false, "banXnX\n"},
+		{"ampersand and escapes", `s/[0-9]\+/<&>/g`, "a1b22\n", false, false, "a<1>b<22>\n"},
+		{"back references", `s/\(a*\)b\(c*\)/\2b\1/`, "aabccc\n", false, false, "cccbaa\n"},
+		{"extended groups", `s/(ab)+/[&]/`, "xababy\n", true, false, "x[abab]y\n"},
+		{"case conversion", `s/\(.\)\(.*\)/\u\1\U\2/`, "hello\n", false, false, "HELLO\n"},
+		{"alternate delimiter", `s|/usr|/opt|`, "/usr/bin\n", false, false, "/opt/bin\n"},
+		{"print with -n", `/b/p`, "a\nb\nc\n", false, true, "b\n"},
+		{"substitute p flag", `s/b/B/p`, "a\nb\n", false, true, "B\n"},
+		{"delete", `2d`, "a\nb\nc\n", false, false, "a\nc\n"},
+		{"last line", `$d`, "a\nb\nc\n", false, false, "a\nb\n"},
+		{"line range", `2,3d`, "a\nb\nc\nd\n", false, false, "a\nd\n"},
+		{"regex range", `/start/,/end/d`, "a\nstart\nb\nend\nc\n", false, false, "a\nc\n"},
+		{"zero address range", `0,/a/d`, "a\nb\na\n", false, false, "b\na\n"},
+		{"step address", `1~2d`, "1\n2\n3\n4\n5\n", false, false, "2\n4\n"},
+		{"relative range", `/b/,+1d`, "a\nb\nc\nd\n", false, false, "a\nd\n"},
+		{"negation", `/a/!d`, "a\nb\nab\n", false, false, "a\nab\n"},
+		{"block", `/x/{s/x/y/;p}`, "x\nz\n", false, true, "y\n"},
+		{"append insert change", "2i\\\nbefore\n2a\\\nafter\n3c\\\nchanged", "a\nb\nc\n", false, false, "a\nbefore\nb\nafter\nchanged\n"},
+		{"one-line append", `1a hello`, "a\nb\n", false, false, "a\nhello\nb\n"},
+		{"line numbers", `=`, "a\nb\n", false, false, "1\na\n2\nb\n"},
+		{"transliterate", `y/abc/xyz/`, "aabbcc\n", false, false, "xxyyzz\n"},
+		{"hold space reverse", `1!G;h;$!d`, "a\nb\nc\n", false, false, "c\nb\na\n"},
+		{"join lines with N", `N;s/\n/,/`, "a\nb\nc\n", false, false, "a,b\nc\n"},
+		{"join all lines", `:a;N;$!ba;s/\n/+/g`, "1\n2\n3\n", false, false, "1+2+3\n"},
+		{"P and D", `$!N;P;D`, "a\nb\nc\n", false, false, "a\nb\nc\n"},
+		{"branch on substitution", `:a;s/^\(x*\)y/\1x/;ta`, "yyy\n", false, false, "xxx\n"},
+		{"missing final newline", `s/b/B/`, "a\nb", false, false, "a\nB"},
+		{"quit", `2q`, "a\nb\nc\n", false, false, "a\nb\n"},
+		{"quit without printing", `2Q`, "a\nb\nc\n", false, false, "a\n"},
+		{"empty regex reuses last", `/b/s//B/`, "abc\n", false, false, "aBc\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := run(t, tt.script, tt.input, tt.extended, tt.quiet)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuitExitCode(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+		code   int
+	}{
+		{`q5`, "a\n", 5},
+		{`2Q3`, "a\n", 3},
+		{`/x/q7`, "a\nb\n", 0},
+	}
+
+	for _, tt := range tests {
+		got, code := run(t, tt.script, "a\nb\n", false, false)
+		if got != tt.want || code != tt.code {
+			t.Errorf("%s: got %q (exit %d), want %q (exit %d)", tt.script, got, code, tt.want, tt.code)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for _, script := range []string{
+		`s/a/b`,
+		`s/a/b/x`,
+		`y/ab/x/`,
+		`{p`,
+		`p}`,
+		`b nowhere`,
+		`k`,
+	} {
+		if _, err := Parse(script, false); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", script)
+		}
+	}
+}
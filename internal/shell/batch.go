@@ -0,0 +1,93 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Lingbou/Lish/internal/config"
+	"github.com/Lingbou/Lish/internal/script"
+)
+
+// RunString 以非交互方式执行命令字符串（lish -c），返回退出码
+func (s *Shell) RunString(ctx context.Context, source, name string, args []string) int {
+	err := s.scriptExecutor.ExecuteSource(ctx, name, source, args)
+	return s.exitStatus(err)
+}
+
+// RunFile 执行脚本文件（lish script.lish args...），返回退出码
+func (s *Shell) RunFile(ctx context.Context, path string, args []string) int {
+	if _, err := os.Stat(path); err != nil {
+		fmt.Fprintf(s.stderr, "lish: %s: 无法打开脚本文件\n", path)
+		return 127
+	}
+
+	err := s.scriptExecutor.ExecuteFile(ctx, path, args)
+	return s.exitStatus(err)
+}
+
+// RunReader 从非终端输入（如管道）读取命令并执行，返回退出码
+func (s *Shell) RunReader(ctx context.Context, r io.Reader) int {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		fmt.Fprintf(s.stderr, "lish: 读取输入失败: %v\n", err)
+		return 1
+	}
+
+	return s.RunString(ctx, string(content), "lish", nil)
+}
+
+// exitStatus 报告执行错误并返回退出码（无错误时为最后一条命令的退出码）
+func (s *Shell) exitStatus(err error) int {
+	if err != nil {
+		// 非零退出码和 exit 请求不是 Shell 错误，错误信息由命令自己输出
+		if !script.IsSilent(err) {
+			fmt.Fprintf(s.stderr, "lish: %v\n", err)
+		}
+		return script.ExitCodeOf(err)
+	}
+	return s.scriptExecutor.LastExitCode()
+}
+
+// SourceStartupScripts 在第一个提示符之前执行启动脚本
+//
+// 登录 shell 先执行 ~/.lish/profile.lish，交互式 shell 再执行
+// ~/.lish/init.lish。脚本出错时报告文件和行号，但不会中止启动。
+// 脚本执行了 exit 时返回 false，退出码由 ExitCode 返回。
+func (s *Shell) SourceStartupScripts(ctx context.Context) bool {
+	if s.options.Login && !s.options.NoProfile {
+		if path, err := config.ProfileScriptPath(); err == nil {
+			if !s.sourceStartupScript(ctx, path) {
+				return false
+			}
+		}
+	}
+
+	if s.options.Interactive && !s.options.NoRC {
+		if path, err := config.InitScriptPath(); err == nil {
+			return s.sourceStartupScript(ctx, path)
+		}
+	}
+	return true
+}
+
+// sourceStartupScript 执行单个启动脚本，文件不存在时忽略；脚本执行了 exit 时返回 false
+func (s *Shell) sourceStartupScript(ctx context.Context, path string) bool {
+	if _, err := os.Stat(path); err != nil {
+		return true
+	}
+
+	err := s.scriptExecutor.ExecuteFile(ctx, path, nil)
+	var exit script.ExitRequest
+	if errors.As(err, &exit) {
+		s.exitCode = int(exit)
+		return false
+	}
+	// 命令自己输出过错误信息的失败不再重复报告
+	if err != nil && !script.IsSilent(err) {
+		fmt.Fprintf(s.stderr, "lish: %v\n", err)
+	}
+	return true
+}
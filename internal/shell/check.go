@@ -0,0 +1,8 @@
+package shell
+
+import "github.com/Lingbou/Lish/internal/lint"
+
+// LintEnv 返回静态检查使用的环境（已注册的命令、PATH、函数、变量和模块搜索路径）
+func (s *Shell) LintEnv() lint.Env {
+	return lint.EnvFor(s.scriptExecutor)
+}
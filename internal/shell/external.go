@@ -0,0 +1,59 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/Lingbou/Lish/internal/script"
+	"github.com/Lingbou/Lish/internal/streams"
+)
+
+// UnknownCommandError 命令既不是内置命令也不在 PATH 中
+type UnknownCommandError struct {
+	Name string
+}
+
+func (e *UnknownCommandError) Error() string {
+	return fmt.Sprintf("未知命令: %s", e.Name)
+}
+
+// Unwrap 使脚本执行器把未知命令当作中止脚本的错误
+func (e *UnknownCommandError) Unwrap() error {
+	return script.ErrUnknownCommand
+}
+
+// ExitCode 与 POSIX shell 一致，未找到命令的退出码为 127
+func (e *UnknownCommandError) ExitCode() int {
+	return 127
+}
+
+// runExternal 在 PATH 中查找并运行外部命令
+//
+// 子进程的环境变量由当前 shell（可能是管道或命令替换的子 shell）中已导出的
+// 变量构造，标准流取自 ctx，使外部命令可以参与管道和重定向。
+func (s *Shell) runExternal(ctx context.Context, command string, args []string) error {
+	path, err := s.hash.Lookup(command)
+	if err != nil {
+		return &UnknownCommandError{Name: command}
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Args[0] = command
+	cmd.Env = script.ExecutorFrom(ctx, s.scriptExecutor).Environ()
+	std := streams.From(ctx)
+	cmd.Stdin = std.Stdin
+	cmd.Stdout = std.Stdout
+	cmd.Stderr = std.Stderr
+
+	return cmd.Run()
+}
+
+// HasCommand 判断命令是内置命令或 PATH 中的可执行文件，实现 script.CommandLookup
+func (s *Shell) HasCommand(name string) bool {
+	if _, ok := s.registry.Get(name); ok {
+		return true
+	}
+	_, err := exec.LookPath(name)
+	return err == nil
+}
@@ -2,9 +2,11 @@ package shell
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"os/user"
 	"path/filepath"
 	"strings"
@@ -14,14 +16,23 @@ import (
 	"github.com/Lingbou/Lish/internal/completer"
 	"github.com/Lingbou/Lish/internal/config"
 	"github.com/Lingbou/Lish/internal/history"
-	"github.com/Lingbou/Lish/internal/parser"
 	"github.com/Lingbou/Lish/internal/script"
 	"github.com/Lingbou/Lish/internal/theme"
 	"github.com/chzyer/readline"
 )
 
+// Options Shell 启动选项
+type Options struct {
+	Interactive bool   // 交互模式（读取终端输入并显示提示符）
+	Login       bool   // 作为登录 shell 启动（执行 ~/.lish/profile.lish）
+	NoRC        bool   // 不加载 ~/.lishrc 和 ~/.lish/init.lish
+	NoProfile   bool   // 登录 shell 不执行 ~/.lish/profile.lish
+	RCFile      string // 使用指定的配置文件代替 ~/.lishrc
+}
+
 // Shell Lish Shell 结构
 type Shell struct {
+	options         Options
 	registry        *commands.Registry
 	history         *history.Manager
 	config          *config.Config
@@ -29,15 +40,17 @@ type Shell struct {
 	themeManager    *theme.Manager
 	promptFormatter *PromptFormatter
 	scriptExecutor  *script.Executor
+	hash            *commands.PathCache
 	rl              *readline.Instance
 	stdout          *os.File
 	stderr          *os.File
+	exitCode        int // exit 命令指定的退出码
 }
 
 // NewShell 创建新的 Shell 实例
-func NewShell() (*Shell, error) {
+func NewShell(opts Options) (*Shell, error) {
 	// 加载配置
-	cfg, err := config.Load()
+	cfg, err := loadConfig(opts)
 	if err != nil {
 		return nil, fmt.Errorf("加载配置失败: %w", err)
 	}
@@ -62,12 +75,14 @@ func NewShell() (*Shell, error) {
 	promptFormatter := NewPromptFormatter(cfg.Prompt.Format, themeManager.CurrentScheme())
 
 	shell := &Shell{
+		options:         opts,
 		registry:        registry,
 		history:         histMgr,
 		config:          cfg,
 		suggester:       NewSuggester(),
 		themeManager:    themeManager,
 		promptFormatter: promptFormatter,
+		hash:            commands.NewPathCache(),
 		stdout:          os.Stdout,
 		stderr:          os.Stderr,
 	}
@@ -75,16 +90,40 @@ func NewShell() (*Shell, error) {
 	// 创建脚本执行器（使用 shell 作为命令执行器）
 	shell.scriptExecutor = script.NewExecutor(shell)
 
+	// source 和 import 的模块搜索路径
+	if _, ok := shell.scriptExecutor.GetVariable("LISH_PATH"); !ok {
+		if dir, err := config.LibDir(); err == nil {
+			shell.scriptExecutor.SetVariable("LISH_PATH", dir)
+		}
+	}
+
 	return shell, nil
 }
 
-// Init 初始化 Shell（注册命令、设置 readline）
+// loadConfig 根据启动选项加载配置
+func loadConfig(opts Options) (*config.Config, error) {
+	switch {
+	case opts.NoRC:
+		return config.DefaultConfig(), nil
+	case opts.RCFile != "":
+		return config.LoadFile(opts.RCFile)
+	default:
+		return config.Load()
+	}
+}
+
+// Init 初始化 Shell（注册命令）
 func (s *Shell) Init() error {
 	// 注册所有命令
 	if err := s.registerCommands(); err != nil {
 		return fmt.Errorf("注册命令失败: %w", err)
 	}
 
+	return nil
+}
+
+// initReadline 设置交互模式使用的 readline
+func (s *Shell) initReadline() error {
 	// 创建补全器
 	cmdNames := s.registry.List()
 	comp := completer.NewCompleter(cmdNames)
@@ -116,49 +155,65 @@ func (s *Shell) Init() error {
 func (s *Shell) registerCommands() error {
 	cmds := []commands.Command{
 		// 文件浏览
-		commands.NewPwdCommand(s.stdout),
+		commands.NewPwdCommand(),
 		commands.NewCdCommand(),
-		commands.NewLsCommand(s.stdout),
-		commands.NewFindCommand(s.stdout),
-		commands.NewTreeCommand(s.stdout), // v0.3.0 新增
+		commands.NewLsCommand(),
+		commands.NewFindCommand(),
+		commands.NewTreeCommand(), // v0.3.0 新增
 
 		// 文件操作
-		commands.NewCatCommand(s.stdout),
+		commands.NewCatCommand(),
 		commands.NewMkdirCommand(),
 		commands.NewRmCommand(),
 		commands.NewTouchCommand(),
-		commands.NewCpCommand(s.stdout),
-		commands.NewMvCommand(s.stdout),
-		commands.NewDiffCommand(s.stdout), // v0.3.0 新增
+		commands.NewCpCommand(),
+		commands.NewMvCommand(),
+		commands.NewDiffCommand(), // v0.3.0 新增
+		commands.NewPatchCommand(),
 
 		// 文本处理
-		commands.NewGrepCommand(s.stdout, os.Stdin),
-		commands.NewHeadCommand(s.stdout),
-		commands.NewTailCommand(s.stdout),
-		commands.NewWcCommand(s.stdout),
+		commands.NewGrepCommand(s.themeManager),
+		commands.NewSearchCommand(s.themeManager),
+		commands.NewHeadCommand(),
+		commands.NewTailCommand(),
+		commands.NewWcCommand(),
+		commands.NewCutCommand(),
+		commands.NewTrCommand(),
+		commands.NewPasteCommand(),
+		commands.NewJoinCommand(),
+		commands.NewColumnCommand(),
+		commands.NewTeeCommand(),
+		commands.NewXargsCommand(s.scriptExecutor),
+		commands.NewParallelCommand(s.scriptExecutor),
+		commands.NewStringCommand(),
+		commands.NewMathCommand("math"),
+		commands.NewMathCommand("calc"),
 
 		// 系统命令
-		commands.NewEchoCommand(s.stdout),
-		commands.NewClearCommand(s.stdout),
-		commands.NewEnvCommand(s.stdout),
-		commands.NewWhichCommand(s.stdout, s.registry),
-		commands.NewHistoryCommand(s.stdout),
-		commands.NewPsCommand(s.stdout),   // v0.3.0 新增
-		commands.NewKillCommand(s.stdout), // v0.3.0 新增
-		commands.NewDuCommand(s.stdout),   // v0.3.0 新增
-		commands.NewDateCommand(s.stdout), // v0.3.0 新增
+		commands.NewEchoCommand(),
+		commands.NewPrintfCommand(s.scriptExecutor),
+		commands.NewClearCommand(),
+		commands.NewEnvCommand(s.scriptExecutor),
+		commands.NewWhichCommand(s.registry),
+		commands.NewTypeCommand(s.scriptExecutor, s.registry, s.config, s.hash),
+		commands.NewHashCommand(s.hash),
+		commands.NewHistoryCommand(),
+		commands.NewPsCommand(),   // v0.3.0 新增
+		commands.NewKillCommand(), // v0.3.0 新增
+		commands.NewDuCommand(),   // v0.3.0 新增
+		commands.NewDateCommand(), // v0.3.0 新增
 
 		// 配置和别名
-		commands.NewAliasCommand(s.stdout, s.config),
-		commands.NewUnaliasCommand(s.stdout, s.config),
+		commands.NewAliasCommand(s.config),
+		commands.NewUnaliasCommand(s.config),
 
 		// 网络命令
-		commands.NewCurlCommand(s.stdout), // v0.4.0 新增
-		commands.NewPingCommand(s.stdout), // v0.4.0 新增
+		commands.NewCurlCommand(), // v0.4.0 新增
+		commands.NewPingCommand(), // v0.4.0 新增
 
 		// 压缩命令
-		commands.NewZipCommand(s.stdout),   // v0.4.0 新增
-		commands.NewUnzipCommand(s.stdout), // v0.4.0 新增
+		commands.NewZipCommand(),   // v0.4.0 新增
+		commands.NewUnzipCommand(), // v0.4.0 新增
 
 		// 主题命令
 		commands.NewThemeCommand(s.themeManager), // v0.5.1 新增
@@ -166,12 +221,23 @@ func (s *Shell) registerCommands() error {
 		// 脚本命令
 		commands.NewSourceCommand(s.scriptExecutor), // v0.5.2 新增
 		commands.NewExecCommand(s),                  // v0.5.2 新增
+		commands.NewEvalCommand(s.scriptExecutor),
+		commands.NewCommandCommand(s.scriptExecutor, s.registry, s.config, s.hash),
+		commands.NewBuiltinCommand(s.registry),
+
+		// 变量命令
+		commands.NewExportCommand(s.scriptExecutor),
+		commands.NewUnsetCommand(s.scriptExecutor),
+		commands.NewReadonlyCommand(s.scriptExecutor),
+		commands.NewDeclareCommand(s.scriptExecutor),
+		commands.NewShiftCommand(s.scriptExecutor),
+		commands.NewGetoptsCommand(s.scriptExecutor),
 
 		// 高级文本命令
-		&commands.SortCommand{}, // v0.5.3 新增
-		&commands.UniqCommand{}, // v0.5.3 新增
-		&commands.SedCommand{},  // v0.5.3 新增
-		&commands.AwkCommand{},  // v0.5.3 新增
+		&commands.SortCommand{},                  // v0.5.3 新增
+		&commands.UniqCommand{},                  // v0.5.3 新增
+		&commands.SedCommand{},                   // v0.5.3 新增
+		commands.NewAwkCommand(s.scriptExecutor), // v0.5.3 新增
 
 		// 系统工具命令
 		&commands.ChmodCommand{}, // v0.5.4 新增
@@ -180,7 +246,7 @@ func (s *Shell) registerCommands() error {
 		&commands.DfCommand{},    // v0.5.4 新增
 
 		commands.NewExitCommand(),
-		commands.NewHelpCommand(s.registry, s.stdout),
+		commands.NewHelpCommand(s.registry),
 	}
 
 	for _, cmd := range cmds {
@@ -194,12 +260,18 @@ func (s *Shell) registerCommands() error {
 
 // Run 运行 Shell 主循环
 func (s *Shell) Run() error {
+	if err := s.initReadline(); err != nil {
+		return err
+	}
 	defer s.rl.Close()
 
 	// 显示欢迎信息
 	s.printWelcome()
 
-	ctx := context.Background()
+	// Ctrl+C 只中断当前命令，不退出 Shell
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
 
 	for {
 		// 更新提示符
@@ -219,42 +291,41 @@ func (s *Shell) Run() error {
 			return fmt.Errorf("读取输入失败: %w", err)
 		}
 
-		// 添加到历史（用于智能建议）
-		s.suggester.AddToHistory(line)
-
-		// 展开别名
-		line = s.expandAlias(line)
-
-		// 解析命令
-		parsed, err := parser.Parse(line)
-		if err != nil {
-			fmt.Fprintf(s.stderr, "❌ 解析错误: %v\n", err)
+		if strings.TrimSpace(line) == "" {
 			continue
 		}
 
-		if parsed == nil || parsed.Command == "" {
+		// 函数定义、if、for 等语句未输入完时继续读取
+		line, ok := s.readContinuation(line)
+		if !ok {
 			continue
 		}
 
+		// 添加到历史（用于智能建议）
+		s.suggester.AddToHistory(line)
+
+		// 展开别名
+		line = s.expandAlias(line)
+
 		// 记录开始时间
 		startTime := time.Now()
 
-		// 执行命令
-		execErr := s.executeCommand(ctx, parsed)
+		// 执行命令（变量、函数与脚本共享同一个执行器）
+		execErr := s.executeLine(line, interrupts)
 
 		// 计算执行时间
 		duration := time.Since(startTime)
 
+		// exit 命令结束主循环
+		var exit script.ExitRequest
+		if errors.As(execErr, &exit) {
+			s.exitCode = int(exit)
+			break
+		}
+
 		// 显示错误（带拼写建议）
 		if execErr != nil {
-			fmt.Fprintf(s.stderr, "❌ 错误: %v\n", execErr)
-
-			// 如果是未知命令，提供拼写建议
-			if strings.Contains(execErr.Error(), "未知命令") {
-				if suggestion := s.suggester.SpellCheck(parsed.Command, s.registry.List()); suggestion != "" {
-					fmt.Fprintf(s.stderr, "💡 你是否想输入: %s\n", suggestion)
-				}
-			}
+			s.reportError(execErr)
 		}
 
 		// 显示执行时间（如果超过 100ms）
@@ -266,21 +337,84 @@ func (s *Shell) Run() error {
 	return nil
 }
 
-// executeCommand 执行解析后的命令
-func (s *Shell) executeCommand(ctx context.Context, parsed *parser.ParsedCommand) error {
-	cmd, exists := s.registry.Get(parsed.Command)
-	if !exists {
-		return fmt.Errorf("未知命令: %s。输入 'help' 查看可用命令", parsed.Command)
+// ExitCode 返回 exit 命令指定的退出码，没有执行 exit 时为 0
+func (s *Shell) ExitCode() int {
+	return s.exitCode
+}
+
+// readContinuation 输入是未完成的语句时以 "> " 提示继续读取后续行
+//
+// 按 Ctrl+C 放弃整段输入并返回 false；遇到 EOF 时执行已读取的部分。
+func (s *Shell) readContinuation(line string) (string, bool) {
+	for script.IsIncomplete(line) {
+		s.rl.SetPrompt("> ")
+		more, err := s.rl.Readline()
+		if err == readline.ErrInterrupt {
+			return "", false
+		}
+		if err != nil {
+			break
+		}
+		line += "\n" + more
+	}
+	return line, true
+}
+
+// executeLine 执行一行输入，收到 Ctrl+C 时取消命令的 context
+func (s *Shell) executeLine(line string, interrupts <-chan os.Signal) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-done:
+		}
+	}()
+
+	return s.scriptExecutor.ExecuteLine(ctx, line)
+}
+
+// reportError 显示交互模式下的执行错误
+func (s *Shell) reportError(err error) {
+	var parseErr *script.ParseError
+	if errors.As(err, &parseErr) {
+		fmt.Fprintf(s.stderr, "❌ 解析错误: %s\n", strings.Join(parseErr.Errors, "\n"))
+		return
 	}
 
-	return cmd.Execute(ctx, parsed.Args)
+	// 如果是未知命令，提供拼写建议（错误信息已由执行器输出）
+	var unknownErr *UnknownCommandError
+	if errors.As(err, &unknownErr) {
+		if suggestion := s.suggester.SpellCheck(unknownErr.Name, s.registry.List()); suggestion != "" {
+			fmt.Fprintf(s.stderr, "💡 你是否想输入: %s\n", suggestion)
+		}
+		return
+	}
+
+	// 外部命令的非零退出码和 ExitStatus 不是 Shell 错误，错误信息由命令自己输出
+	if script.IsSilent(err) {
+		return
+	}
+
+	// 交互输入只有一行，不显示行号
+	var execErr *script.ExecutionError
+	if errors.As(err, &execErr) && execErr.Err != nil {
+		err = execErr.Err
+	}
+	fmt.Fprintf(s.stderr, "❌ 错误: %v\n", err)
 }
 
 // ExecuteCommand 实现 script.CommandExecutor 接口
+//
+// 优先执行内置命令，否则在 PATH 中查找外部命令。
 func (s *Shell) ExecuteCommand(ctx context.Context, command string, args []string) error {
 	cmd, exists := s.registry.Get(command)
 	if !exists {
-		return fmt.Errorf("未知命令: %s", command)
+		return s.runExternal(ctx, command, args)
 	}
 	return cmd.Execute(ctx, args)
 }
@@ -348,5 +482,5 @@ func (s *Shell) printWelcome() {
 欢迎使用 Lish！轻量级 Linux 风格终端。
 输入 'help' 查看可用命令，输入 'exit' 退出。
 `
-	fmt.Fprintln(s.stdout, banner)
+	fmt.Fprint(s.stdout, banner+"\n")
 }
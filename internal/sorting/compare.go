@@ -0,0 +1,616 @@
+package sorting
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Modifiers 比较方式，可以作用于整行（全局选项）或单个排序键
+type Modifiers struct {
+	Blanks     bool // b 忽略开头的空白
+	Dictionary bool // d 只比较空白、字母和数字
+	Fold       bool // f 忽略大小写
+	Numeric    bool // n 数字
+	General    bool // g 浮点数（支持科学计数法）
+	Human      bool // h 带单位的数字（2K、1G）
+	Month      bool // M 月份（JAN < FEB < ... < DEC）
+	Version    bool // V 版本号（1.2 < 1.10）
+	Reverse    bool // r 反向
+}
+
+// Key 排序键（-k POS1[,POS2]），字段和字符位置从 1 开始
+type Key struct {
+	StartField int
+	StartChar  int
+	EndField   int  // 0 表示到行尾
+	EndChar    int  // 0 表示到字段末尾
+	EndBlanks  bool // 结束位置的 b
+	Modifiers       // 开始位置的 b 和其他修饰符
+}
+
+// ParseKey 解析 -k 参数，格式为 F[.C][OPTS][,F[.C][OPTS]]
+func ParseKey(spec string) (Key, error) {
+	var k Key
+	start, end, hasEnd := strings.Cut(spec, ",")
+
+	field, char, mods, err := parsePosition(start)
+	if err != nil {
+		return k, fmt.Errorf("无效的排序键 '%s': %v", spec, err)
+	}
+	if char == 0 {
+		return k, fmt.Errorf("无效的排序键 '%s': 字符位置必须大于 0", spec)
+	}
+	k.StartField, k.StartChar = field, 1
+	if char > 0 {
+		k.StartChar = char
+	}
+	if err := applyModifiers(&k.Modifiers, mods, &k.Blanks); err != nil {
+		return k, fmt.Errorf("无效的排序键 '%s': %v", spec, err)
+	}
+
+	if hasEnd {
+		field, char, mods, err := parsePosition(end)
+		if err != nil {
+			return k, fmt.Errorf("无效的排序键 '%s': %v", spec, err)
+		}
+		k.EndField = field
+		if char > 0 {
+			k.EndChar = char
+		}
+		if err := applyModifiers(&k.Modifiers, mods, &k.EndBlanks); err != nil {
+			return k, fmt.Errorf("无效的排序键 '%s': %v", spec, err)
+		}
+	}
+	if err := k.Modifiers.check(); err != nil {
+		return k, err
+	}
+	return k, nil
+}
+
+// parsePosition 解析 F[.C][OPTS]，没有 .C 时 char 为 -1
+func parsePosition(s string) (field, char int, mods string, err error) {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return 0, 0, "", fmt.Errorf("缺少字段编号")
+	}
+	field, _ = strconv.Atoi(s[:i])
+	if field == 0 {
+		return 0, 0, "", fmt.Errorf("字段编号必须大于 0")
+	}
+	char = -1
+	if i < len(s) && s[i] == '.' {
+		i++
+		j := i
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == j {
+			return 0, 0, "", fmt.Errorf("缺少字符位置")
+		}
+		char, _ = strconv.Atoi(s[j:i])
+	}
+	return field, char, s[i:], nil
+}
+
+// applyModifiers 把修饰符字母应用到 m，b 设置到 blanks（开始和结束位置分别记录）
+func applyModifiers(m *Modifiers, letters string, blanks *bool) error {
+	for _, ch := range letters {
+		switch ch {
+		case 'b':
+			*blanks = true
+		case 'd':
+			m.Dictionary = true
+		case 'f', 'i':
+			m.Fold = true
+		case 'n':
+			m.Numeric = true
+		case 'g':
+			m.General = true
+		case 'h':
+			m.Human = true
+		case 'M':
+			m.Month = true
+		case 'V':
+			m.Version = true
+		case 'r':
+			m.Reverse = true
+		default:
+			return fmt.Errorf("未知的修饰符 '%c'", ch)
+		}
+	}
+	return nil
+}
+
+// check 检查互相冲突的比较方式
+func (m Modifiers) check() error {
+	var modes []string
+	for _, mode := range []struct {
+		on   bool
+		name string
+	}{
+		{m.Numeric, "n"}, {m.General, "g"}, {m.Human, "h"}, {m.Month, "M"}, {m.Version, "V"},
+	} {
+		if mode.on {
+			modes = append(modes, mode.name)
+		}
+	}
+	if len(modes) > 1 {
+		return fmt.Errorf("选项 -%s 不能同时使用", strings.Join(modes, ""))
+	}
+	if m.Dictionary && len(modes) > 0 {
+		return fmt.Errorf("选项 -d%s 不能同时使用", modes[0])
+	}
+	return nil
+}
+
+// record 一行及其排序键（键是行的子串，不复制数据）
+type record struct {
+	line string
+	keys []string
+}
+
+// comparator 按排序键比较两行
+type comparator struct {
+	keys       []Key  // 没有 -k 时为一个 StartField 为 0 的整行键
+	separator  string // 为空时字段以空白分隔（字段包含前面的空白）
+	plain      bool   // 没有任何选项，直接比较整行
+	lastResort bool   // 所有键都相等时再比较整行（-s 和 -u 时不比较）
+	reverse    bool   // 全局 -r，也作用于最后的整行比较
+}
+
+func newComparator(opts *Options) *comparator {
+	c := &comparator{
+		separator:  opts.Separator,
+		lastResort: !opts.Stable && !opts.Unique,
+		reverse:    opts.Global.Reverse,
+	}
+	global := opts.Global
+	global.Reverse = false
+	if len(opts.Keys) == 0 {
+		c.plain = global == Modifiers{}
+		c.keys = []Key{{Modifiers: opts.Global, EndBlanks: opts.Global.Blanks}}
+		return c
+	}
+	for _, k := range opts.Keys {
+		// 没有修饰符的键继承全局选项
+		if k.Modifiers == (Modifiers{}) && !k.EndBlanks {
+			k.Modifiers = opts.Global
+			k.EndBlanks = opts.Global.Blanks
+		}
+		c.keys = append(c.keys, k)
+	}
+	return c
+}
+
+func (c *comparator) newRecord(line string) record {
+	if c.plain {
+		return record{line: line}
+	}
+	keys := make([]string, len(c.keys))
+	for i := range c.keys {
+		keys[i] = c.extract(line, &c.keys[i])
+	}
+	return record{line: line, keys: keys}
+}
+
+func (c *comparator) compare(a, b *record) int {
+	if !c.plain {
+		for i := range c.keys {
+			k := &c.keys[i]
+			if r := compareKey(a.keys[i], b.keys[i], &k.Modifiers); r != 0 {
+				if k.Reverse {
+					return -r
+				}
+				return r
+			}
+		}
+		if !c.lastResort {
+			return 0
+		}
+	}
+	r := strings.Compare(a.line, b.line)
+	if c.reverse {
+		return -r
+	}
+	return r
+}
+
+// extract 取出行中的排序键
+func (c *comparator) extract(line string, k *Key) string {
+	if k.StartField == 0 {
+		if k.Blanks {
+			return line[skipBlanks(line, 0):]
+		}
+		return line
+	}
+
+	start := c.fieldStart(line, k.StartField)
+	if k.Blanks {
+		start = skipBlanks(line, start)
+	}
+	start = min(start+k.StartChar-1, len(line))
+
+	end := len(line)
+	if k.EndField > 0 {
+		fieldStart := c.fieldStart(line, k.EndField)
+		if k.EndChar == 0 {
+			end = c.fieldEnd(line, fieldStart)
+		} else {
+			if k.EndBlanks {
+				fieldStart = skipBlanks(line, fieldStart)
+			}
+			end = min(fieldStart+k.EndChar, len(line))
+		}
+	}
+	if end <= start {
+		return ""
+	}
+	return line[start:end]
+}
+
+// fieldStart 返回第 n 个字段的开始位置
+func (c *comparator) fieldStart(line string, n int) int {
+	i := 0
+	for f := 1; f < n && i < len(line); f++ {
+		if c.separator != "" {
+			j := strings.Index(line[i:], c.separator)
+			if j < 0 {
+				return len(line)
+			}
+			i += j + len(c.separator)
+			continue
+		}
+		i = skipBlanks(line, i)
+		for i < len(line) && !isBlank(line[i]) {
+			i++
+		}
+	}
+	return i
+}
+
+// fieldEnd 返回从 start 开始的字段的结束位置
+func (c *comparator) fieldEnd(line string, start int) int {
+	if c.separator != "" {
+		j := strings.Index(line[start:], c.separator)
+		if j < 0 {
+			return len(line)
+		}
+		return start + j
+	}
+	i := skipBlanks(line, start)
+	for i < len(line) && !isBlank(line[i]) {
+		i++
+	}
+	return i
+}
+
+func isBlank(ch byte) bool {
+	return ch == ' ' || ch == '\t'
+}
+
+func skipBlanks(s string, i int) int {
+	for i < len(s) && isBlank(s[i]) {
+		i++
+	}
+	return i
+}
+
+// compareKey 按修饰符比较两个键（不处理 Reverse）
+func compareKey(a, b string, m *Modifiers) int {
+	switch {
+	case m.Numeric:
+		return compareNumeric(a, b)
+	case m.General:
+		return compareGeneral(a, b)
+	case m.Human:
+		return compareHuman(a, b)
+	case m.Month:
+		return compareInt(monthOf(a), monthOf(b))
+	case m.Version:
+		return compareVersion(a, b)
+	case m.Fold || m.Dictionary:
+		return compareText(a, b, m.Fold, m.Dictionary)
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// number 解析出的十进制数，用字符串表示以支持任意长度
+type number struct {
+	negative bool
+	integer  string // 去掉开头的 0
+	fraction string // 去掉结尾的 0
+	end      int    // 数字之后的位置
+}
+
+// parseNumber 解析开头的空白之后的 -?[0-9]*(\.[0-9]*)?，不是数字时为 0
+func parseNumber(s string) number {
+	var n number
+	i := skipBlanks(s, 0)
+	if i < len(s) && s[i] == '-' {
+		n.negative = true
+		i++
+	}
+	start := i
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	n.integer = strings.TrimLeft(s[start:i], "0")
+	digits := i > start
+	if i < len(s) && s[i] == '.' {
+		j := i + 1
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		n.fraction = strings.TrimRight(s[i+1:j], "0")
+		digits = digits || j > i+1
+		i = j
+	}
+	if !digits {
+		return number{}
+	}
+	n.end = i
+	if n.integer == "" && n.fraction == "" {
+		n.negative = false
+	}
+	return n
+}
+
+func (n number) sign() int {
+	switch {
+	case n.integer == "" && n.fraction == "":
+		return 0
+	case n.negative:
+		return -1
+	}
+	return 1
+}
+
+// compareMagnitude 比较绝对值
+func compareMagnitude(a, b number) int {
+	if r := compareInt(len(a.integer), len(b.integer)); r != 0 {
+		return r
+	}
+	if r := strings.Compare(a.integer, b.integer); r != 0 {
+		return r
+	}
+	return strings.Compare(a.fraction, b.fraction)
+}
+
+func compareNumbers(a, b number) int {
+	if r := compareInt(a.sign(), b.sign()); r != 0 {
+		return r
+	}
+	r := compareMagnitude(a, b)
+	if a.negative {
+		return -r
+	}
+	return r
+}
+
+func compareNumeric(a, b string) int {
+	return compareNumbers(parseNumber(a), parseNumber(b))
+}
+
+// humanSuffixes 单位从小到大的顺序
+const humanSuffixes = "KMGTPEZYRQ"
+
+// compareHuman 先比较符号，再比较单位，最后比较数值（2K < 1M）
+func compareHuman(a, b string) int {
+	na, nb := parseNumber(a), parseNumber(b)
+	if r := compareInt(na.sign(), nb.sign()); r != 0 {
+		return r
+	}
+	r := compareInt(humanUnit(a, na), humanUnit(b, nb))
+	if r == 0 {
+		r = compareMagnitude(na, nb)
+	}
+	if na.negative {
+		return -r
+	}
+	return r
+}
+
+func humanUnit(s string, n number) int {
+	if n.end == 0 || n.end >= len(s) {
+		return 0
+	}
+	ch := s[n.end]
+	if ch == 'k' {
+		ch = 'K'
+	}
+	return strings.IndexByte(humanSuffixes, ch) + 1
+}
+
+// compareGeneral 按浮点数比较，不是数字的排在最前面
+func compareGeneral(a, b string) int {
+	fa, okA := parseFloatPrefix(a)
+	fb, okB := parseFloatPrefix(b)
+	switch {
+	case !okA || !okB:
+		return compareInt(boolInt(okA), boolInt(okB))
+	case fa < fb:
+		return -1
+	case fa > fb:
+		return 1
+	}
+	return 0
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// parseFloatPrefix 解析开头的浮点数（[+-]digits[.digits][e[+-]digits]）
+func parseFloatPrefix(s string) (float64, bool) {
+	s = s[skipBlanks(s, 0):]
+	i := 0
+	if i < len(s) && (s[i] == '+' || s[i] == '-') {
+		i++
+	}
+	digits := 0
+	for i < len(s) && (s[i] >= '0' && s[i] <= '9' || s[i] == '.') {
+		if s[i] != '.' {
+			digits++
+		}
+		i++
+	}
+	if digits == 0 {
+		return 0, false
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+				j++
+			}
+			i = j
+		}
+	}
+	f, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil && f == 0 {
+		return 0, false
+	}
+	return f, true
+}
+
+var months = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+// monthOf 返回开头的月份缩写对应的 1-12，不是月份时为 0
+func monthOf(s string) int {
+	s = s[skipBlanks(s, 0):]
+	if len(s) < 3 {
+		return 0
+	}
+	prefix := strings.ToUpper(s[:3])
+	for i, m := range months {
+		if prefix == m {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// compareVersion 按版本号比较：数字部分按数值比较，其他部分逐字符比较，
+// 字母排在其他字符之前，~ 排在所有字符（包括结尾）之前
+func compareVersion(a, b string) int {
+	if r := versionCompare(a, b); r != 0 {
+		return r
+	}
+	return strings.Compare(a, b)
+}
+
+func versionCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for i < len(a) && !isDigit(a[i]) || j < len(b) && !isDigit(b[j]) {
+			ca, cb := versionOrder(a, i), versionOrder(b, j)
+			if ca != cb {
+				return compareInt(ca, cb)
+			}
+			i++
+			j++
+		}
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		first := 0
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if first == 0 {
+				first = compareInt(int(a[i]), int(b[j]))
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if first != 0 {
+			return first
+		}
+	}
+	return 0
+}
+
+func versionOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	ch := s[i]
+	switch {
+	case isDigit(ch):
+		return 0
+	case ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z':
+		return int(ch)
+	case ch == '~':
+		return -1
+	}
+	return int(ch) + 256
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+// compareText 逐字节比较，fold 时忽略大小写，dictionary 时跳过空白、字母和数字以外的字符
+func compareText(a, b string, fold, dictionary bool) int {
+	i, j := 0, 0
+	for {
+		if dictionary {
+			for i < len(a) && !isDictionary(a[i]) {
+				i++
+			}
+			for j < len(b) && !isDictionary(b[j]) {
+				j++
+			}
+		}
+		if i >= len(a) || j >= len(b) {
+			return compareInt(len(a)-i, len(b)-j)
+		}
+		ca, cb := a[i], b[j]
+		if fold {
+			ca, cb = toUpper(ca), toUpper(cb)
+		}
+		if ca != cb {
+			return compareInt(int(ca), int(cb))
+		}
+		i++
+		j++
+	}
+}
+
+func isDictionary(ch byte) bool {
+	return isBlank(ch) || isDigit(ch) || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= 0x80
+}
+
+func toUpper(ch byte) byte {
+	if ch >= 'a' && ch <= 'z' {
+		return ch - 'a' + 'A'
+	}
+	return ch
+}
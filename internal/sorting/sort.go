@@ -0,0 +1,532 @@
+// Package sorting 实现 sort 命令的排序引擎：GNU 兼容的排序键、
+// 超过内存限制时使用临时文件的外部归并排序，以及已排序文件的合并和检查。
+package sorting
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultBufferSize 默认的内存缓冲区大小，超过时把已排序的部分写入临时文件
+	DefaultBufferSize = 256 << 20
+	// mergeBatch 一次最多合并的临时文件数
+	mergeBatch = 16
+	// recordOverhead 估算每行除内容以外占用的内存
+	recordOverhead = 48
+	// parallelThreshold 行数少于此值时不并发排序
+	parallelThreshold = 8192
+)
+
+// Options 排序选项
+type Options struct {
+	Keys       []Key
+	Global     Modifiers // 没有 -k 时作用于整行，也被没有修饰符的键继承
+	Separator  string    // 字段分隔符，为空时以空白分隔
+	Stable     bool      // 所有键相等时保持输入顺序
+	Unique     bool      // 键相等的行只输出第一行
+	BufferSize int64     // 内存中排序的最大字节数，0 表示 DefaultBufferSize
+	Parallel   int       // 同时排序的 goroutine 数，0 表示 CPU 核数（最多 8）
+	TempDir    string    // 临时文件目录，为空时使用系统临时目录
+	Stdin      io.Reader // 文件名为 - 时读取
+}
+
+// Sorter 按选项排序、合并或检查输入
+type Sorter struct {
+	opts Options
+	cmp  *comparator
+}
+
+// New 创建 Sorter
+func New(opts Options) (*Sorter, error) {
+	if err := opts.Global.check(); err != nil {
+		return nil, err
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = DefaultBufferSize
+	}
+	if opts.Parallel <= 0 {
+		opts.Parallel = min(runtime.NumCPU(), 8)
+	}
+	return &Sorter{opts: opts, cmp: newComparator(&opts)}, nil
+}
+
+// Sort 读取所有输入并把排序结果写入 w
+//
+// 读取的数据超过 BufferSize 时，把已读取的部分排序后写入临时文件，
+// 最后归并所有临时文件。所有输入读完后才开始写入 w，因此输出可以是输入文件之一。
+func (s *Sorter) Sort(ctx context.Context, names []string, w io.Writer) error {
+	var runs []string
+	defer func() {
+		for _, name := range runs {
+			os.Remove(name)
+		}
+	}()
+
+	var chunk []record
+	var size int64
+	err := s.eachLine(ctx, names, func(line string) error {
+		chunk = append(chunk, s.cmp.newRecord(line))
+		size += int64(len(line)) + recordOverhead
+		if size < s.opts.BufferSize {
+			return nil
+		}
+		name, err := s.writeRun(chunk)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, name)
+		clear(chunk)
+		chunk, size = chunk[:0], 0
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(runs) == 0 {
+		s.sortRecords(chunk)
+		out := s.newOutput(w)
+		for i := range chunk {
+			if err := out.write(&chunk[i]); err != nil {
+				return err
+			}
+		}
+		return out.flush()
+	}
+	if len(chunk) > 0 {
+		name, err := s.writeRun(chunk)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, name)
+	}
+	chunk = nil
+
+	// 临时文件太多时先分批合并，合并结果放在最前面以保持稳定性
+	for len(runs) > mergeBatch {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name, err := s.mergeToRun(ctx, runs[:mergeBatch])
+		if err != nil {
+			return err
+		}
+		for _, old := range runs[:mergeBatch] {
+			os.Remove(old)
+		}
+		runs = append([]string{name}, runs[mergeBatch:]...)
+	}
+	return s.Merge(ctx, runs, w)
+}
+
+// Merge 合并已经排好序的输入
+func (s *Sorter) Merge(ctx context.Context, names []string, w io.Writer) error {
+	var sources []*source
+	defer func() {
+		for _, src := range sources {
+			src.close()
+		}
+	}()
+	for _, name := range names {
+		src, err := s.open(name)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, src)
+	}
+
+	out := s.newOutput(w)
+	if err := s.merge(ctx, sources, out.write); err != nil {
+		return err
+	}
+	return out.flush()
+}
+
+// Disorder Check 发现的第一处无序
+type Disorder struct {
+	Line int
+	Text string
+}
+
+// Check 检查输入是否已经排好序，-u 时还要求没有相等的行，返回第一处无序的位置
+func (s *Sorter) Check(ctx context.Context, name string) (*Disorder, error) {
+	var prev record
+	lineNum := 0
+	var disorder *Disorder
+	err := s.eachLine(ctx, []string{name}, func(line string) error {
+		lineNum++
+		rec := s.cmp.newRecord(line)
+		if lineNum > 1 {
+			r := s.cmp.compare(&prev, &rec)
+			if r > 0 || r == 0 && s.opts.Unique {
+				disorder = &Disorder{Line: lineNum, Text: line}
+				return errStop
+			}
+		}
+		prev = rec
+		return nil
+	})
+	if err != nil && err != errStop {
+		return nil, err
+	}
+	return disorder, nil
+}
+
+// errStop 用于提前结束 eachLine
+var errStop = errors.New("stop")
+
+// eachLine 依次读取所有输入的每一行（不含换行符）
+func (s *Sorter) eachLine(ctx context.Context, names []string, fn func(line string) error) error {
+	for _, name := range names {
+		src, err := s.open(name)
+		if err != nil {
+			return err
+		}
+		n := 0
+		for src.ok {
+			if n++; n%4096 == 0 && ctx.Err() != nil {
+				src.close()
+				return ctx.Err()
+			}
+			if err := fn(src.rec.line); err != nil {
+				src.close()
+				return err
+			}
+			src.next(nil)
+		}
+		src.close()
+		if src.err != nil {
+			return src.err
+		}
+	}
+	return nil
+}
+
+// sortRecords 排序一块数据，数据较多时分成 Parallel 份并发排序再归并
+func (s *Sorter) sortRecords(recs []record) {
+	compare := func(a, b record) int { return s.cmp.compare(&a, &b) }
+	n := s.opts.Parallel
+	if n <= 1 || len(recs) < parallelThreshold {
+		slices.SortStableFunc(recs, compare)
+		return
+	}
+
+	parts := make([][]record, 0, n)
+	size := (len(recs) + n - 1) / n
+	for start := 0; start < len(recs); start += size {
+		parts = append(parts, recs[start:min(start+size, len(recs))])
+	}
+	var wg sync.WaitGroup
+	for _, part := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slices.SortStableFunc(part, compare)
+		}()
+	}
+	wg.Wait()
+
+	// 两两归并相邻的部分，相等时取左边的，保持稳定
+	buf := make([]record, len(recs))
+	src, dst := recs, buf
+	for len(parts) > 1 {
+		var merged [][]record
+		offset := 0
+		var wg sync.WaitGroup
+		for i := 0; i < len(parts); i += 2 {
+			if i+1 == len(parts) {
+				out := dst[offset : offset+len(parts[i])]
+				copy(out, parts[i])
+				merged = append(merged, out)
+				break
+			}
+			a, b := parts[i], parts[i+1]
+			out := dst[offset : offset+len(a)+len(b)]
+			offset += len(out)
+			merged = append(merged, out)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.mergeSlices(a, b, out)
+			}()
+		}
+		wg.Wait()
+		parts = merged
+		src, dst = dst, src
+	}
+	if &src[0] != &recs[0] {
+		copy(recs, src)
+	}
+}
+
+func (s *Sorter) mergeSlices(a, b, out []record) {
+	i, j, k := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		if s.cmp.compare(&b[j], &a[i]) < 0 {
+			out[k] = b[j]
+			j++
+		} else {
+			out[k] = a[i]
+			i++
+		}
+		k++
+	}
+	k += copy(out[k:], a[i:])
+	copy(out[k:], b[j:])
+}
+
+// writeRun 排序一块数据并写入临时文件
+func (s *Sorter) writeRun(chunk []record) (string, error) {
+	s.sortRecords(chunk)
+	file, err := os.CreateTemp(s.opts.TempDir, "lish-sort-*")
+	if err != nil {
+		return "", fmt.Errorf("无法创建临时文件: %w", err)
+	}
+	out := s.newOutput(file)
+	for i := range chunk {
+		if err := out.write(&chunk[i]); err != nil {
+			file.Close()
+			os.Remove(file.Name())
+			return "", err
+		}
+	}
+	if err := out.flush(); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// mergeToRun 合并若干临时文件到一个新的临时文件
+func (s *Sorter) mergeToRun(ctx context.Context, runs []string) (string, error) {
+	file, err := os.CreateTemp(s.opts.TempDir, "lish-sort-*")
+	if err != nil {
+		return "", fmt.Errorf("无法创建临时文件: %w", err)
+	}
+	err = s.Merge(ctx, runs, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// merge 用堆归并多个有序的输入，相等时取前面的输入
+func (s *Sorter) merge(ctx context.Context, sources []*source, emit func(*record) error) error {
+	h := &mergeHeap{cmp: s.cmp}
+	for i, src := range sources {
+		src.index = i
+		if src.ok {
+			h.items = append(h.items, src)
+		} else if src.err != nil {
+			return src.err
+		}
+	}
+	heap.Init(h)
+	n := 0
+	for h.Len() > 0 {
+		if n++; n%4096 == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		src := h.items[0]
+		if err := emit(&src.rec); err != nil {
+			return err
+		}
+		src.next(s.cmp)
+		if src.ok {
+			heap.Fix(h, 0)
+			continue
+		}
+		if src.err != nil {
+			return src.err
+		}
+		heap.Pop(h)
+	}
+	return nil
+}
+
+type mergeHeap struct {
+	items []*source
+	cmp   *comparator
+}
+
+func (h *mergeHeap) Len() int { return len(h.items) }
+
+func (h *mergeHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if r := h.cmp.compare(&a.rec, &b.rec); r != 0 {
+		return r < 0
+	}
+	return a.index < b.index
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeHeap) Push(x any) { h.items = append(h.items, x.(*source)) }
+
+func (h *mergeHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// source 逐行读取一个输入，rec 是当前行
+type source struct {
+	name   string
+	reader *bufio.Reader
+	closer io.Closer
+	rec    record
+	ok     bool
+	err    error
+	index  int
+}
+
+// open 打开输入并读取第一行，- 表示标准输入
+func (s *Sorter) open(name string) (*source, error) {
+	src := &source{name: name}
+	if name == "-" {
+		if s.opts.Stdin == nil {
+			return nil, fmt.Errorf("没有标准输入")
+		}
+		src.reader = bufio.NewReaderSize(s.opts.Stdin, 64*1024)
+	} else {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		src.reader = bufio.NewReaderSize(file, 64*1024)
+		src.closer = file
+	}
+	src.next(s.cmp)
+	return src, nil
+}
+
+// next 读取下一行，cmp 不为 nil 时同时取出排序键
+func (src *source) next(cmp *comparator) {
+	line, err := src.reader.ReadString('\n')
+	if line == "" && err != nil {
+		src.ok = false
+		if err != io.EOF {
+			src.err = fmt.Errorf("无法读取 %s: %w", src.name, err)
+		}
+		return
+	}
+	line = strings.TrimSuffix(line, "\n")
+	if cmp != nil {
+		src.rec = cmp.newRecord(line)
+	} else {
+		src.rec = record{line: line}
+	}
+	src.ok = true
+}
+
+func (src *source) close() {
+	if src.closer != nil {
+		src.closer.Close()
+		src.closer = nil
+	}
+}
+
+// output 写出排序结果，Unique 时跳过与上一行键相等的行
+type output struct {
+	w      *bufio.Writer
+	cmp    *comparator
+	unique bool
+	last   record
+	any    bool
+}
+
+func (s *Sorter) newOutput(w io.Writer) *output {
+	return &output{w: bufio.NewWriterSize(w, 64*1024), cmp: s.cmp, unique: s.opts.Unique}
+}
+
+func (o *output) write(rec *record) error {
+	if o.unique {
+		if o.any && o.cmp.compare(&o.last, rec) == 0 {
+			return nil
+		}
+		o.last, o.any = *rec, true
+	}
+	if _, err := o.w.WriteString(rec.line); err != nil {
+		return err
+	}
+	return o.w.WriteByte('\n')
+}
+
+func (o *output) flush() error {
+	return o.w.Flush()
+}
+
+// ParseSize 解析 -S 参数：数字加单位 b、K、M、G、T（没有单位时为 K），或物理内存的百分比
+func ParseSize(s string) (int64, error) {
+	if s == "" {
+		return 0, fmt.Errorf("无效的缓冲区大小 ''")
+	}
+	unit := int64(1024)
+	num := s
+	switch last := s[len(s)-1]; last {
+	case 'b':
+		unit, num = 1, s[:len(s)-1]
+	case 'k', 'K':
+		num = s[:len(s)-1]
+	case 'm', 'M':
+		unit, num = 1<<20, s[:len(s)-1]
+	case 'g', 'G':
+		unit, num = 1<<30, s[:len(s)-1]
+	case 't', 'T':
+		unit, num = 1<<40, s[:len(s)-1]
+	case '%':
+		total, err := physicalMemory()
+		if err != nil {
+			return 0, err
+		}
+		pct, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil || pct <= 0 || pct > 100 {
+			return 0, fmt.Errorf("无效的缓冲区大小 '%s'", s)
+		}
+		return int64(float64(total) * pct / 100), nil
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n <= 0 || n > (1<<62)/unit {
+		return 0, fmt.Errorf("无效的缓冲区大小 '%s'", s)
+	}
+	return n * unit, nil
+}
+
+// physicalMemory 从 /proc/meminfo 读取物理内存大小
+func physicalMemory() (int64, error) {
+	data, err := os.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, fmt.Errorf("无法获取物理内存大小")
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "MemTotal:"); ok {
+			fields := strings.Fields(rest)
+			if len(fields) > 0 {
+				if kb, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+					return kb * 1024, nil
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("无法获取物理内存大小")
+}
@@ -0,0 +1,115 @@
+package sorting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// sortLines 按选项排序输入，keys 是 -k 参数
+func sortLines(t *testing.T, opts Options, keys []string, input string) string {
+	t.Helper()
+	for _, spec := range keys {
+		key, err := ParseKey(spec)
+		if err != nil {
+			t.Fatalf("ParseKey(%q): %v", spec, err)
+		}
+		opts.Keys = append(opts.Keys, key)
+	}
+	opts.Stdin = strings.NewReader(input)
+	s, err := New(opts)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	var out bytes.Buffer
+	if err := s.Sort(context.Background(), []string{"-"}, &out); err != nil {
+		t.Fatalf("Sort: %v", err)
+	}
+	return out.String()
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  Options
+		keys  []string
+		input string
+		want  string
+	}{
+		{"bytes", Options{}, nil, "b\nB\na\n10\n9\n", "10\n9\nB\na\nb\n"},
+		{"missing final newline", Options{}, nil, "b\na", "a\nb\n"},
+		{"reverse", Options{Global: Modifiers{Reverse: true}}, nil, "a\nc\nb\n", "c\nb\na\n"},
+		{"numeric", Options{Global: Modifiers{Numeric: true}}, nil, "10\n9\n-1\n1.5\nx\n", "-1\nx\n1.5\n9\n10\n"},
+		{"general numeric", Options{Global: Modifiers{General: true}}, nil, "1e3\n2\n-inf\n0.5\n", "-inf\n0.5\n2\n1e3\n"},
+		{"human", Options{Global: Modifiers{Human: true}}, nil, "1G\n2K\n3M\n100\n", "100\n2K\n3M\n1G\n"},
+		{"month", Options{Global: Modifiers{Month: true}}, nil, "MAR\nJan\nfeb\n", "Jan\nfeb\nMAR\n"},
+		{"version", Options{Global: Modifiers{Version: true}}, nil, "v1.10\nv1.2\nv1.9\n", "v1.2\nv1.9\nv1.10\n"},
+		{"fold case", Options{Global: Modifiers{Fold: true}, Stable: true}, nil, "b\nA\na\nB\n", "A\na\nb\nB\n"},
+		{"unique", Options{Unique: true}, nil, "b\na\nb\na\n", "a\nb\n"},
+		{"key field", Options{}, []string{"2"}, "x b\ny a\n", "y a\nx b\n"},
+		{"numeric key with separator", Options{Separator: ":"}, []string{"3,3n"}, "a:x:10\nb:y:9\nc:z:100\n", "b:y:9\na:x:10\nc:z:100\n"},
+		{"reverse key then forward key", Options{}, []string{"1,1r", "2,2n"}, "a 2\nb 1\na 1\n", "b 1\na 1\na 2\n"},
+		{"character position", Options{}, []string{"1.3"}, "abz\nxya\n", "xya\nabz\n"},
+		{"leading blanks", Options{}, []string{"2b"}, "1   c\n2 b\n", "2 b\n1   c\n"},
+		{"last resort comparison", Options{}, []string{"1,1"}, "a z\na y\n", "a y\na z\n"},
+		{"stable keeps input order", Options{Stable: true}, []string{"1,1"}, "a z\na y\n", "a z\na y\n"},
+		{"unique by key", Options{Unique: true}, []string{"1,1"}, "a 2\na 1\nb 3\n", "a 2\nb 3\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sortLines(t, tt.opts, tt.keys, tt.input); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExternalMerge(t *testing.T) {
+	var input, want strings.Builder
+	for i := 999; i >= 0; i-- {
+		fmt.Fprintf(&input, "%d\n", i)
+	}
+	for i := range 1000 {
+		fmt.Fprintf(&want, "%d\n", i)
+	}
+
+	// 缓冲区只能放下几行，排序必须经过多轮临时文件的归并
+	opts := Options{
+		Global:     Modifiers{Numeric: true},
+		BufferSize: 256,
+		TempDir:    t.TempDir(),
+	}
+	if got := sortLines(t, opts, nil, input.String()); got != want.String() {
+		t.Errorf("外部归并的结果不正确")
+	}
+}
+
+func TestParseKeyError(t *testing.T) {
+	for _, spec := range []string{"", "0", "1.0", "a", "1,x", "1z"} {
+		if _, err := ParseKey(spec); err == nil {
+			t.Errorf("ParseKey(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"100", 100 << 10},
+		{"100b", 100},
+		{"10K", 10 << 10},
+		{"2M", 2 << 20},
+		{"1G", 1 << 30},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
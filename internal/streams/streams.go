@@ -0,0 +1,69 @@
+package streams
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// Streams 命令执行时使用的标准输入、输出和错误输出
+//
+// Shell 通过 context 把 Streams 传给命令，管道、重定向和命令替换
+// 只需要替换其中的 Reader/Writer，命令本身无需关心输出去向。
+type Streams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+type contextKey struct{}
+
+// Default 返回进程的标准输入输出
+func Default() *Streams {
+	return &Streams{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// With 返回携带指定输入输出的 context
+func With(ctx context.Context, s *Streams) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// From 返回 context 中的输入输出，未设置时返回进程的标准输入输出
+func From(ctx context.Context) *Streams {
+	if s, ok := ctx.Value(contextKey{}).(*Streams); ok && s != nil {
+		return s
+	}
+	return Default()
+}
+
+// Stdin 返回 context 中的标准输入
+func Stdin(ctx context.Context) io.Reader {
+	return From(ctx).Stdin
+}
+
+// Stdout 返回 context 中的标准输出
+func Stdout(ctx context.Context) io.Writer {
+	return From(ctx).Stdout
+}
+
+// Stderr 返回 context 中的标准错误输出
+func Stderr(ctx context.Context) io.Writer {
+	return From(ctx).Stderr
+}
+
+// IsTerminal 判断输出是否直接连接到终端（用于决定是否输出颜色等）
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
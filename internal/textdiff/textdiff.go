@@ -0,0 +1,228 @@
+// Package textdiff 计算文本行之间的差异并以统一格式（unified diff）输出
+package textdiff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Kind 编辑操作的类型
+type Kind int
+
+const (
+	Equal  Kind = iota // 两边相同的行
+	Delete             // 只在旧文本中的行
+	Insert             // 只在新文本中的行
+)
+
+// Edit 一个编辑操作，A 和 B 分别是该行在旧文本和新文本中的下标
+//
+// Delete 操作的 B 和 Insert 操作的 A 是该位置之前已处理的行数。
+type Edit struct {
+	Kind Kind
+	A, B int
+}
+
+// Lines 使用 Myers 算法计算把 a 变为 b 的最短编辑序列
+//
+// 每一步只保存本步可能到达的对角线，内存与编辑距离的平方成正比。
+func Lines(a, b []string) []Edit {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		done := false
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // 向下：插入
+			} else {
+				x = v[offset+k-1] + 1 // 向右：删除
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		// 第 d 步的快照保存对角线 -d-1 到 d+1
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		if done {
+			break
+		}
+	}
+
+	return backtrack(trace, n, m)
+}
+
+// backtrack 从终点沿 trace 回溯出编辑序列
+func backtrack(trace [][]int, x, y int) []Edit {
+	var edits []Edit
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		k := x - y
+		var prevK, prevX, prevY int
+		if d > 0 {
+			// 第 d-1 步快照中对角线 k 的下标为 k+d
+			v := trace[d-1]
+			if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+				prevK = k + 1
+			} else {
+				prevK = k - 1
+			}
+			prevX = v[prevK+d]
+			prevY = prevX - prevK
+		}
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, Edit{Kind: Equal, A: x, B: y})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, Edit{Kind: Insert, A: x, B: y})
+		} else {
+			x--
+			edits = append(edits, Edit{Kind: Delete, A: x, B: y})
+		}
+	}
+
+	// 回溯得到的是倒序
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// Hunk 统一格式中的一个差异块
+type Hunk struct {
+	AStart, ALines int // 旧文本中的起始行（从 0 开始）和行数
+	BStart, BLines int // 新文本中的起始行和行数
+	Edits          []Edit
+}
+
+// Hunks 把编辑序列按上下文行数 context 分组为差异块
+func Hunks(edits []Edit, context int) []Hunk {
+	var hunks []Hunk
+	var cur *Hunk
+	lastChange := -1
+
+	for i, e := range edits {
+		if e.Kind == Equal {
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		if cur != nil && start <= lastChange+context+1 {
+			// 与上一个差异块的上下文重叠，合并
+			cur.Edits = append(cur.Edits, edits[lastChange+1:i+1]...)
+		} else {
+			if cur != nil {
+				cur.Edits = append(cur.Edits, trailing(edits, lastChange, context)...)
+				hunks = append(hunks, *cur)
+			}
+			cur = &Hunk{Edits: append([]Edit(nil), edits[start:i+1]...)}
+		}
+		lastChange = i
+	}
+
+	if cur != nil {
+		cur.Edits = append(cur.Edits, trailing(edits, lastChange, context)...)
+		hunks = append(hunks, *cur)
+	}
+
+	for i := range hunks {
+		hunks[i].count()
+	}
+	return hunks
+}
+
+// trailing 返回最后一个改动之后的上下文行
+func trailing(edits []Edit, last, context int) []Edit {
+	end := last + 1 + context
+	if end > len(edits) {
+		end = len(edits)
+	}
+	return edits[last+1 : end]
+}
+
+// count 计算差异块在两边的起始行和行数
+func (h *Hunk) count() {
+	first := h.Edits[0]
+	h.AStart, h.BStart = first.A, first.B
+	for _, e := range h.Edits {
+		switch e.Kind {
+		case Equal:
+			h.ALines++
+			h.BLines++
+		case Delete:
+			h.ALines++
+		case Insert:
+			h.BLines++
+		}
+	}
+}
+
+// Unified 以统一格式输出 a 与 b 的差异，没有差异时不输出并返回 false
+func Unified(w io.Writer, nameA, nameB string, a, b []string, context int) bool {
+	hunks := Hunks(Lines(a, b), context)
+	if len(hunks) == 0 {
+		return false
+	}
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", nameA, nameB)
+	for _, h := range hunks {
+		fmt.Fprintln(w, h.Header())
+		for _, e := range h.Edits {
+			switch e.Kind {
+			case Equal:
+				fmt.Fprintf(w, " %s\n", a[e.A])
+			case Delete:
+				fmt.Fprintf(w, "-%s\n", a[e.A])
+			case Insert:
+				fmt.Fprintf(w, "+%s\n", b[e.B])
+			}
+		}
+	}
+	return true
+}
+
+// Header 返回差异块的头部，如 "@@ -1,3 +1,4 @@"
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", hunkRange(h.AStart, h.ALines), hunkRange(h.BStart, h.BLines))
+}
+
+// hunkRange 格式化差异块头部的行范围（行号从 1 开始，空范围指向前一行）
+func hunkRange(start, lines int) string {
+	if lines == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if lines == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, lines)
+}
+
+// SplitLines 把文本按行拆分，末尾的换行不产生空行
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
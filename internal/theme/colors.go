@@ -50,6 +50,9 @@ type ColorScheme interface {
 	SyntaxString() Color     // 字符串
 	SyntaxVariable() Color   // 变量
 	SyntaxOperator() Color   // 操作符 (|, >, <)
+
+	// 搜索结果
+	Match() Color            // 匹配的文本（grep 高亮）
 }
 
 // Theme 表示一个完整主题
@@ -37,6 +37,7 @@ func (t *DarkTheme) SyntaxArgument() Color { return NewColor("#AAAAAA") }
 func (t *DarkTheme) SyntaxString() Color   { return NewColor("#FFFF00") }
 func (t *DarkTheme) SyntaxVariable() Color { return NewColor("#00FFFF") }
 func (t *DarkTheme) SyntaxOperator() Color { return NewColor("#FF00FF") }
+func (t *DarkTheme) Match() Color          { return NewColor("#FF5555", "bold") }
 
 // =============================================================================
 // 2. Light Theme
@@ -63,6 +64,7 @@ func (t *LightTheme) SyntaxArgument() Color { return NewColor("#444444") }
 func (t *LightTheme) SyntaxString() Color   { return NewColor("#AF5F00") }
 func (t *LightTheme) SyntaxVariable() Color { return NewColor("#00AFAF") }
 func (t *LightTheme) SyntaxOperator() Color { return NewColor("#AF00AF") }
+func (t *LightTheme) Match() Color          { return NewColor("#D70000", "bold") }
 
 // =============================================================================
 // 3. Solarized Dark
@@ -89,6 +91,7 @@ func (t *SolarizedDarkTheme) SyntaxArgument() Color { return NewColor("#93a1a1")
 func (t *SolarizedDarkTheme) SyntaxString() Color   { return NewColor("#b58900") }
 func (t *SolarizedDarkTheme) SyntaxVariable() Color { return NewColor("#2aa198") }
 func (t *SolarizedDarkTheme) SyntaxOperator() Color { return NewColor("#d33682") }
+func (t *SolarizedDarkTheme) Match() Color          { return NewColor("#dc322f", "bold") }
 
 // =============================================================================
 // 4. Solarized Light
@@ -115,6 +118,7 @@ func (t *SolarizedLightTheme) SyntaxArgument() Color { return NewColor("#657b83"
 func (t *SolarizedLightTheme) SyntaxString() Color   { return NewColor("#b58900") }
 func (t *SolarizedLightTheme) SyntaxVariable() Color { return NewColor("#2aa198") }
 func (t *SolarizedLightTheme) SyntaxOperator() Color { return NewColor("#d33682") }
+func (t *SolarizedLightTheme) Match() Color          { return NewColor("#dc322f", "bold") }
 
 // =============================================================================
 // 5. Gruvbox Dark
@@ -141,6 +145,7 @@ func (t *GruvboxDarkTheme) SyntaxArgument() Color { return NewColor("#ebdbb2") }
 func (t *GruvboxDarkTheme) SyntaxString() Color   { return NewColor("#fabd2f") }
 func (t *GruvboxDarkTheme) SyntaxVariable() Color { return NewColor("#8ec07c") }
 func (t *GruvboxDarkTheme) SyntaxOperator() Color { return NewColor("#d3869b") }
+func (t *GruvboxDarkTheme) Match() Color          { return NewColor("#fb4934", "bold") }
 
 // =============================================================================
 // 6. Dracula
@@ -167,6 +172,7 @@ func (t *DraculaTheme) SyntaxArgument() Color { return NewColor("#f8f8f2") }
 func (t *DraculaTheme) SyntaxString() Color   { return NewColor("#f1fa8c") }
 func (t *DraculaTheme) SyntaxVariable() Color { return NewColor("#8be9fd") }
 func (t *DraculaTheme) SyntaxOperator() Color { return NewColor("#ff79c6") }
+func (t *DraculaTheme) Match() Color          { return NewColor("#ff5555", "bold") }
 
 // =============================================================================
 // 7. Nord
@@ -193,6 +199,7 @@ func (t *NordTheme) SyntaxArgument() Color { return NewColor("#d8dee9") }
 func (t *NordTheme) SyntaxString() Color   { return NewColor("#ebcb8b") }
 func (t *NordTheme) SyntaxVariable() Color { return NewColor("#88c0d0") }
 func (t *NordTheme) SyntaxOperator() Color { return NewColor("#b48ead") }
+func (t *NordTheme) Match() Color          { return NewColor("#bf616a", "bold") }
 
 // =============================================================================
 // 8. Monokai Pro
@@ -219,4 +226,5 @@ func (t *MonokaiProTheme) SyntaxArgument() Color { return NewColor("#fcfcfa") }
 func (t *MonokaiProTheme) SyntaxString() Color   { return NewColor("#ffd866") }
 func (t *MonokaiProTheme) SyntaxVariable() Color { return NewColor("#78dce8") }
 func (t *MonokaiProTheme) SyntaxOperator() Color { return NewColor("#ff6188") }
+func (t *MonokaiProTheme) Match() Color          { return NewColor("#ff6188", "bold") }
 